@@ -103,4 +103,27 @@
 //	    Signer:          signer,
 //	    DigestAlgorithm: httpsig.DigestSHA256,
 //	})
+//
+// # Webhooks
+//
+// WebhookSigner signs outgoing webhook deliveries with a fixed set of
+// covered components (@method, @target-uri, content-digest, and the created
+// parameter), an always-present Content-Digest header, and an optional
+// X-Signature-Key-Url header pointing at the JWKS that publishes the keyid.
+// Rotate swaps the signing key without rebuilding clients:
+//
+//	ws, err := httpsig.NewWebhookSigner(httpsig.WebhookSignerConfig{
+//	    Signer: signer,
+//	    KeyURL: "https://sender.example.com/.well-known/jwks.json",
+//	})
+//	client := &http.Client{Transport: ws.Transport(nil)}
+//
+// WebhookVerifier checks the same requirements on the receiving side and
+// rejects signatures whose created time is outside a tolerance window:
+//
+//	wv, err := httpsig.NewWebhookVerifier(httpsig.WebhookVerifierConfig{
+//	    Resolver:  resolver,
+//	    Tolerance: 2 * time.Minute,
+//	})
+//	err = wv.Verify(r)
 package httpsig
@@ -28,8 +28,9 @@ var (
 	// maximum allowed age.
 	ErrSignatureExpired = errors.New("httpsig: signature expired")
 
-	// ErrCreatedRequired is returned when MaxAge is set but the signature
-	// does not contain a created parameter.
+	// ErrCreatedRequired is returned when MaxAge is set, or by
+	// WebhookVerifier, and the signature does not contain a created
+	// parameter.
 	ErrCreatedRequired = errors.New("httpsig: created parameter required when MaxAge is set")
 
 	// ErrMissingComponent is returned when a required covered component
@@ -68,3 +69,11 @@ var (
 	// identifier is used.
 	ErrUnknownComponent = errors.New("httpsig: unknown component identifier")
 )
+
+// Webhook errors.
+var (
+	// ErrWebhookKeyURLMismatch is returned when WebhookVerifierConfig.KeyURL
+	// is set and the X-Signature-Key-Url header differs from it or is not
+	// covered by the signature.
+	ErrWebhookKeyURLMismatch = errors.New("httpsig: webhook key URL mismatch")
+)
@@ -10,6 +10,10 @@ import "net/http"
 type Transport struct {
 	base   http.RoundTripper
 	config SignConfig
+
+	// sign, when set, replaces SignRequest with config, as used by
+	// WebhookSigner.Transport.
+	sign func(*http.Request) error
 }
 
 // NewTransport creates a signing Transport that delegates to base after
@@ -56,7 +60,14 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		clone.Body = body
 	}
 
-	if err := SignRequest(clone, t.config); err != nil {
+	var err error
+	if t.sign != nil {
+		err = t.sign(clone)
+	} else {
+		err = SignRequest(clone, t.config)
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,264 @@
+package httpsig
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// WebhookKeyURLHeader is the header carrying the URL of the JWKS document
+// that publishes the keys used to sign webhook deliveries. Receivers look
+// up the signature's keyid in that document.
+const WebhookKeyURLHeader = "X-Signature-Key-Url"
+
+// webhookKeyURLComponent is the covered component identifier of
+// WebhookKeyURLHeader; header components are lower case (RFC 9421
+// Section 2.1).
+const webhookKeyURLComponent = "x-signature-key-url"
+
+// DefaultWebhookTolerance is the WebhookVerifierConfig.Tolerance used when
+// none is set.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// webhookComponents are the components every webhook signature covers. The
+// created parameter is always included by SignRequest.
+var webhookComponents = []string{ComponentMethod, ComponentTargetURI, "content-digest"}
+
+// WebhookSignerConfig configures a WebhookSigner.
+type WebhookSignerConfig struct {
+	// Signer produces signatures; its KeyID is sent as the keyid
+	// parameter. Required.
+	Signer Signer
+
+	// KeyURL is the URL of the JWKS document publishing the public
+	// keys, sent in the X-Signature-Key-Url header and covered by the
+	// signature. Optional.
+	KeyURL string
+
+	// Label identifies the signature in Signature/Signature-Input
+	// headers. Defaults to "sig1".
+	Label string
+
+	// DigestAlgorithm is used for the Content-Digest header. Defaults to
+	// DigestSHA256.
+	DigestAlgorithm DigestAlgorithm
+
+	// Tag is an optional application-specific tag for the signature,
+	// such as "webhook".
+	Tag string
+}
+
+// WebhookSigner signs outgoing webhook deliveries with a fixed set of
+// covered components: @method, @target-uri, and content-digest, plus the
+// created parameter and the keyid of the current signing key. Every
+// request gets a Content-Digest header, computed over an empty body when
+// there is none, so receivers can always require it.
+//
+// The signing key can be replaced with Rotate while deliveries are in
+// flight; each request is signed with the key current when it is signed.
+// Publish the new public key in the JWKS at KeyURL before rotating, and
+// keep the old one there until receivers have processed the deliveries
+// signed with it.
+//
+// A WebhookSigner is safe for concurrent use.
+type WebhookSigner struct {
+	signer atomic.Pointer[Signer]
+	keyURL string
+	label  string
+	digest DigestAlgorithm
+	tag    string
+}
+
+// NewWebhookSigner returns a WebhookSigner for cfg. It returns ErrNoSigner
+// when cfg.Signer is nil and an error wrapping ErrUnsupportedDigest for an
+// unknown DigestAlgorithm.
+func NewWebhookSigner(cfg WebhookSignerConfig) (*WebhookSigner, error) {
+	if cfg.Signer == nil {
+		return nil, ErrNoSigner
+	}
+
+	digest := cfg.DigestAlgorithm
+	if digest == "" {
+		digest = DigestSHA256
+	}
+	if _, err := computeDigest(nil, digest); err != nil {
+		return nil, err
+	}
+
+	s := &WebhookSigner{
+		keyURL: cfg.KeyURL,
+		label:  cfg.Label,
+		digest: digest,
+		tag:    cfg.Tag,
+	}
+	s.signer.Store(&cfg.Signer)
+
+	return s, nil
+}
+
+// Rotate replaces the signing key used for subsequent requests. It
+// returns ErrNoSigner when signer is nil.
+func (s *WebhookSigner) Rotate(signer Signer) error {
+	if signer == nil {
+		return ErrNoSigner
+	}
+
+	s.signer.Store(&signer)
+
+	return nil
+}
+
+// KeyID returns the key ID of the current signing key.
+func (s *WebhookSigner) KeyID() string {
+	return (*s.signer.Load()).KeyID()
+}
+
+// Sign sets the Content-Digest and, when configured, X-Signature-Key-Url
+// headers on r and signs it in-place.
+func (s *WebhookSigner) Sign(r *http.Request) error {
+	components := append([]string(nil), webhookComponents...)
+	if s.keyURL != "" {
+		r.Header.Set(WebhookKeyURLHeader, s.keyURL)
+		components = append(components, webhookKeyURLComponent)
+	}
+
+	return SignRequest(r, SignConfig{
+		Signer:            *s.signer.Load(),
+		Label:             s.label,
+		CoveredComponents: components,
+		Tag:               s.tag,
+		DigestAlgorithm:   s.digest,
+	})
+}
+
+// Transport returns a Transport that signs every request with s, for the
+// HTTP client delivering webhooks. base is handled as in NewTransport.
+//
+//	client := &http.Client{Transport: webhookSigner.Transport(nil)}
+func (s *WebhookSigner) Transport(base *http.Transport) *Transport {
+	t := NewTransport(base, SignConfig{})
+	t.sign = s.Sign
+
+	return t
+}
+
+// WebhookVerifierConfig configures a WebhookVerifier.
+type WebhookVerifierConfig struct {
+	// Resolver looks up a Verifier for the signature's keyid, typically
+	// from a cached copy of the sender's JWKS. Required.
+	Resolver KeyResolver
+
+	// Label identifies which signature to verify. When empty, the first
+	// signature found in the Signature-Input header is used.
+	Label string
+
+	// Tolerance is the maximum difference between the signature's created
+	// time and the current time, in either direction, to allow for clock
+	// skew and delivery delay. Defaults to DefaultWebhookTolerance.
+	Tolerance time.Duration
+
+	// KeyURL, when set, is the only accepted X-Signature-Key-Url value,
+	// and the header must be covered by the signature. Receivers should
+	// set it, or otherwise check the header against an allowlist, before
+	// fetching keys from it.
+	KeyURL string
+}
+
+// WebhookVerifier verifies webhook deliveries signed by a WebhookSigner.
+// It requires the @method, @target-uri, and content-digest components, a
+// Content-Digest header matching the body, and a created time within the
+// tolerance window.
+//
+// @target-uri is rebuilt from the request as received, so receivers behind
+// a TLS-terminating proxy should restore the original scheme and host
+// first, for example with muxhandlers.ProxyHeadersMiddleware.
+//
+// A WebhookVerifier is safe for concurrent use.
+type WebhookVerifier struct {
+	resolver  KeyResolver
+	label     string
+	tolerance time.Duration
+	keyURL    string
+}
+
+// NewWebhookVerifier returns a WebhookVerifier for cfg. It returns
+// ErrNoResolver when cfg.Resolver is nil.
+func NewWebhookVerifier(cfg WebhookVerifierConfig) (*WebhookVerifier, error) {
+	if cfg.Resolver == nil {
+		return nil, ErrNoResolver
+	}
+
+	tolerance := cfg.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+
+	return &WebhookVerifier{
+		resolver:  cfg.Resolver,
+		label:     cfg.Label,
+		tolerance: tolerance,
+		keyURL:    cfg.KeyURL,
+	}, nil
+}
+
+// Verify verifies the webhook signature on r. The body is restored after
+// the digest check so handlers can read it.
+//
+// It returns ErrDigestNotFound or ErrDigestMismatch for a missing or wrong
+// Content-Digest, ErrCreatedRequired when the signature has no created
+// parameter, ErrSignatureExpired when created is outside the tolerance
+// window, an error wrapping ErrWebhookKeyURLMismatch when KeyURL is set and
+// the header differs or is not covered, and the errors of VerifyRequest
+// otherwise.
+func (v *WebhookVerifier) Verify(r *http.Request) error {
+	if v.keyURL != "" && r.Header.Get(WebhookKeyURLHeader) != v.keyURL {
+		return fmt.Errorf("%w: %q", ErrWebhookKeyURLMismatch, r.Header.Get(WebhookKeyURLHeader))
+	}
+
+	if err := v.checkParams(r); err != nil {
+		return err
+	}
+
+	return VerifyRequest(r, VerifyConfig{
+		Resolver:           v.resolver,
+		Label:              v.label,
+		RequiredComponents: webhookComponents,
+		RequireDigest:      true,
+	})
+}
+
+// checkParams checks the parameters of the selected signature that
+// VerifyRequest cannot: a created time within the tolerance window in
+// either direction, and coverage of X-Signature-Key-Url when KeyURL is set.
+func (v *WebhookVerifier) checkParams(r *http.Request) error {
+	sigInput := r.Header.Get("Signature-Input")
+	if sigInput == "" {
+		return ErrSignatureNotFound
+	}
+
+	_, raw, err := findSignatureInput(sigInput, v.label)
+	if err != nil {
+		return err
+	}
+
+	params, err := parseSignatureParams(raw)
+	if err != nil {
+		return err
+	}
+
+	if params.created.IsZero() {
+		return ErrCreatedRequired
+	}
+
+	if time.Since(params.created).Abs() > v.tolerance {
+		return ErrSignatureExpired
+	}
+
+	if v.keyURL != "" && !slices.Contains(params.components, webhookKeyURLComponent) {
+		return fmt.Errorf("%w: %s not covered", ErrWebhookKeyURLMismatch, webhookKeyURLComponent)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package httpsig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSigner(t *testing.T) {
+	const keyURL = "https://sender.example.com/.well-known/jwks.json"
+
+	newKey := func(t *testing.T, keyID string) (Signer, Verifier) {
+		t.Helper()
+
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		signer, err := NewEd25519Signer(keyID, priv)
+		require.NoError(t, err)
+
+		verifier, err := NewEd25519Verifier(keyID, pub)
+		require.NoError(t, err)
+
+		return signer, verifier
+	}
+
+	oldSigner, oldVerifier := newKey(t, "webhook-2025")
+	nextSigner, nextVerifier := newKey(t, "webhook-2026")
+
+	keys := map[string]Verifier{
+		"webhook-2025": oldVerifier,
+		"webhook-2026": nextVerifier,
+	}
+	resolver := func(_ *http.Request, keyID string, _ Algorithm) (Verifier, error) {
+		if v, ok := keys[keyID]; ok {
+			return v, nil
+		}
+		return nil, ErrInvalidKey
+	}
+
+	newVerifier := func(t *testing.T, cfg WebhookVerifierConfig) *WebhookVerifier {
+		t.Helper()
+
+		if cfg.Resolver == nil {
+			cfg.Resolver = resolver
+		}
+		v, err := NewWebhookVerifier(cfg)
+		require.NoError(t, err)
+
+		return v
+	}
+
+	newRequest := func(body string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "https://receiver.example.com/hooks", strings.NewReader(body))
+	}
+
+	t.Run("signs fixed components", func(t *testing.T) {
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner, KeyURL: keyURL})
+		require.NoError(t, err)
+
+		req := newRequest(`{"event":"created"}`)
+		require.NoError(t, s.Sign(req))
+
+		assert.Equal(t, keyURL, req.Header.Get(WebhookKeyURLHeader))
+		assert.True(t, strings.HasPrefix(req.Header.Get("Content-Digest"), "sha-256=:"))
+
+		input := req.Header.Get("Signature-Input")
+		assert.Contains(t, input, `("@method" "@target-uri" "content-digest" "x-signature-key-url")`)
+		assert.Contains(t, input, "created=")
+		assert.Contains(t, input, `keyid="webhook-2025"`)
+
+		assert.NoError(t, newVerifier(t, WebhookVerifierConfig{KeyURL: keyURL}).Verify(req))
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"event":"created"}`, string(body))
+	})
+
+	t.Run("empty body gets digest", func(t *testing.T) {
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "https://receiver.example.com/hooks", nil)
+		require.NoError(t, s.Sign(req))
+
+		assert.NotEmpty(t, req.Header.Get("Content-Digest"))
+		assert.Empty(t, req.Header.Get(WebhookKeyURLHeader))
+		assert.NoError(t, newVerifier(t, WebhookVerifierConfig{}).Verify(req))
+	})
+
+	t.Run("transport delivers signed requests", func(t *testing.T) {
+		v := newVerifier(t, WebhookVerifierConfig{KeyURL: keyURL})
+
+		var received string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := v.Verify(r); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			received = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner, KeyURL: keyURL, Tag: "webhook"})
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: s.Transport(nil)}
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/hooks", strings.NewReader(`{"id":1}`))
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, `{"id":1}`, received)
+		assert.Empty(t, req.Header.Get("Signature"), "caller request not mutated")
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner})
+		require.NoError(t, err)
+		assert.Equal(t, "webhook-2025", s.KeyID())
+
+		before := newRequest("a")
+		require.NoError(t, s.Sign(before))
+
+		require.NoError(t, s.Rotate(nextSigner))
+		assert.Equal(t, "webhook-2026", s.KeyID())
+
+		after := newRequest("b")
+		require.NoError(t, s.Sign(after))
+		assert.Contains(t, after.Header.Get("Signature-Input"), `keyid="webhook-2026"`)
+
+		v := newVerifier(t, WebhookVerifierConfig{})
+		assert.NoError(t, v.Verify(before), "deliveries signed with the old key still verify")
+		assert.NoError(t, v.Verify(after))
+
+		assert.ErrorIs(t, s.Rotate(nil), ErrNoSigner)
+		assert.Equal(t, "webhook-2026", s.KeyID())
+	})
+
+	t.Run("missing digest rejected", func(t *testing.T) {
+		req := newRequest(`{"id":1}`)
+		require.NoError(t, SignRequest(req, SignConfig{
+			Signer:            oldSigner,
+			CoveredComponents: []string{ComponentMethod, ComponentTargetURI},
+		}))
+
+		assert.ErrorIs(t, newVerifier(t, WebhookVerifierConfig{}).Verify(req), ErrDigestNotFound)
+	})
+
+	t.Run("tampered body rejected", func(t *testing.T) {
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner})
+		require.NoError(t, err)
+
+		req := newRequest(`{"amount":1}`)
+		require.NoError(t, s.Sign(req))
+		req.Body = io.NopCloser(strings.NewReader(`{"amount":1000}`))
+
+		assert.ErrorIs(t, newVerifier(t, WebhookVerifierConfig{}).Verify(req), ErrDigestMismatch)
+	})
+
+	t.Run("undigested components rejected", func(t *testing.T) {
+		req := newRequest(`{"id":1}`)
+		require.NoError(t, SignRequest(req, SignConfig{
+			Signer:            oldSigner,
+			CoveredComponents: []string{ComponentMethod},
+			DigestAlgorithm:   DigestSHA256,
+		}))
+
+		assert.ErrorIs(t, newVerifier(t, WebhookVerifierConfig{}).Verify(req), ErrMissingComponent)
+	})
+
+	t.Run("tolerance window", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			created time.Duration
+			wantErr error
+		}{
+			{"within window", -30 * time.Second, nil},
+			{"too old", -2 * time.Minute, ErrSignatureExpired},
+			{"clock skew within window", 30 * time.Second, nil},
+			{"too far in future", 2 * time.Minute, ErrSignatureExpired},
+		}
+
+		v := newVerifier(t, WebhookVerifierConfig{Tolerance: time.Minute})
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := newRequest("{}")
+				require.NoError(t, SignRequest(req, SignConfig{
+					Signer:            oldSigner,
+					CoveredComponents: []string{ComponentMethod, ComponentTargetURI},
+					Created:           time.Now().Add(tt.created),
+					DigestAlgorithm:   DigestSHA256,
+				}))
+
+				err := v.Verify(req)
+				if tt.wantErr == nil {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("key URL mismatch", func(t *testing.T) {
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner, KeyURL: "https://evil.example.com/jwks.json"})
+		require.NoError(t, err)
+
+		req := newRequest("{}")
+		require.NoError(t, s.Sign(req))
+
+		assert.ErrorIs(t, newVerifier(t, WebhookVerifierConfig{KeyURL: keyURL}).Verify(req), ErrWebhookKeyURLMismatch)
+	})
+
+	t.Run("key URL not covered", func(t *testing.T) {
+		s, err := NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner})
+		require.NoError(t, err)
+
+		req := newRequest("{}")
+		require.NoError(t, s.Sign(req))
+		req.Header.Set(WebhookKeyURLHeader, keyURL)
+
+		assert.ErrorIs(t, newVerifier(t, WebhookVerifierConfig{KeyURL: keyURL}).Verify(req), ErrWebhookKeyURLMismatch)
+	})
+
+	t.Run("config errors", func(t *testing.T) {
+		_, err := NewWebhookSigner(WebhookSignerConfig{})
+		assert.ErrorIs(t, err, ErrNoSigner)
+
+		_, err = NewWebhookSigner(WebhookSignerConfig{Signer: oldSigner, DigestAlgorithm: "md5"})
+		assert.ErrorIs(t, err, ErrUnsupportedDigest)
+
+		_, err = NewWebhookVerifier(WebhookVerifierConfig{})
+		assert.ErrorIs(t, err, ErrNoResolver)
+	})
+}
@@ -0,0 +1,197 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+)
+
+// ErrBodyTooLarge is returned by BufferBody when the request body is larger
+// than it may buffer.
+var ErrBodyTooLarge = errors.New("mux: request body too large")
+
+// BufferBodyOption configures BufferBody.
+type BufferBodyOption func(*bufferBodyOptions)
+
+type bufferBodyOptions struct {
+	spill   bool
+	dir     string
+	maxSize int64
+}
+
+// SpillToFile makes BufferBody write a body larger than its memory limit to
+// a temporary file in dir (os.TempDir when empty) instead of failing with
+// ErrBodyTooLarge. maxSize caps the total body size; zero or negative
+// means no cap. The file is removed when the request context ends.
+func SpillToFile(dir string, maxSize int64) BufferBodyOption {
+	return func(o *bufferBodyOptions) {
+		o.spill = true
+		o.dir = dir
+		o.maxSize = maxSize
+	}
+}
+
+// allows reports whether a body of size bytes may be buffered.
+func (o *bufferBodyOptions) allows(size, limit int64) bool {
+	return size <= limit || (o.spill && (o.maxSize <= 0 || size <= o.maxSize))
+}
+
+// BufferBody reads the body of r so that it can be read more than once,
+// for middleware that inspects the body, such as a digest check, before
+// the handler reads it. Up to limit bytes are kept in memory; a larger
+// body fails with ErrBodyTooLarge unless SpillToFile is given.
+//
+// r.Body is replaced with a reader of the same bytes, and each call of the
+// returned reread function returns a fresh reader from the start. r.GetBody
+// is set as well, so an outgoing request can be retried or redirected.
+// r.ContentLength is not changed. When r has no body, reread returns
+// http.NoBody.
+//
+//	reread, err := mux.BufferBody(r, 1<<20)
+//	if err != nil {
+//	    http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+//	    return
+//	}
+//	digest := sha256.New()
+//	body := reread()
+//	_, _ = io.Copy(digest, body)
+//	_ = body.Close()
+//	next.ServeHTTP(w, r) // the handler still reads the whole body
+//
+// A body already buffered by an earlier BufferBody call is reused rather
+// than copied again, so stacked middleware share one buffer. On an error,
+// r.Body still yields the complete original body, so the caller may
+// reject the request or stream it on.
+//
+// A spilled body is backed by a temporary file that is removed when the
+// request context is done; server request contexts end when ServeHTTP
+// returns, and readers used after that fail. BufferBody, r.Body and the
+// readers it returns are not safe for concurrent use.
+func BufferBody(r *http.Request, limit int64, opts ...BufferBodyOption) (reread func() io.ReadCloser, err error) {
+	var o bufferBodyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	limit = max(limit, 0)
+
+	if b, ok := r.Body.(*replayBody); ok {
+		r.Body = b.src.open()
+		if !o.allows(b.src.size, limit) {
+			return nil, ErrBodyTooLarge
+		}
+		return b.src.open, nil
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return func() io.ReadCloser { return http.NoBody }, nil
+	}
+
+	orig := r.Body
+	readLimit := limit
+	if readLimit < math.MaxInt64 {
+		readLimit++
+	}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, orig, readLimit)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.Body = rejoinBody(bytes.NewReader(buf.Bytes()), orig)
+		return nil, err
+	}
+
+	if n <= limit {
+		_ = orig.Close()
+		return useBody(r, &bodySource{data: buf.Bytes(), size: n}), nil
+	}
+
+	if !o.spill || (o.maxSize > 0 && n > o.maxSize) {
+		r.Body = rejoinBody(bytes.NewReader(buf.Bytes()), orig)
+		return nil, ErrBodyTooLarge
+	}
+
+	return spillBody(r, orig, &buf, &o)
+}
+
+// spillBody writes the bytes read so far and the rest of orig to a
+// temporary file and makes it the body of r.
+func spillBody(r *http.Request, orig io.ReadCloser, buf *bytes.Buffer, o *bufferBodyOptions) (func() io.ReadCloser, error) {
+	f, err := os.CreateTemp(o.dir, "kasper-body-*")
+	if err != nil {
+		r.Body = rejoinBody(bytes.NewReader(buf.Bytes()), orig)
+		return nil, err
+	}
+	context.AfterFunc(r.Context(), func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	})
+
+	written, err := buf.WriteTo(f)
+	if err != nil {
+		r.Body = rejoinBody(io.MultiReader(io.NewSectionReader(f, 0, written), bytes.NewReader(buf.Bytes())), orig)
+		return nil, err
+	}
+
+	rest := int64(math.MaxInt64)
+	if o.maxSize > 0 {
+		rest = o.maxSize - written + 1
+	}
+	m, err := io.CopyN(f, orig, rest)
+	written += m
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.Body = rejoinBody(io.NewSectionReader(f, 0, written), orig)
+		return nil, err
+	}
+	if o.maxSize > 0 && written > o.maxSize {
+		r.Body = rejoinBody(io.NewSectionReader(f, 0, written), orig)
+		return nil, ErrBodyTooLarge
+	}
+
+	_ = orig.Close()
+	return useBody(r, &bodySource{file: f, size: written}), nil
+}
+
+// useBody makes src the body of r and returns its reread function.
+func useBody(r *http.Request, src *bodySource) func() io.ReadCloser {
+	r.Body = src.open()
+	r.GetBody = func() (io.ReadCloser, error) {
+		return src.open(), nil
+	}
+	return src.open
+}
+
+// rejoinBody returns a body yielding read, the bytes already consumed from
+// orig, followed by the rest of orig.
+func rejoinBody(read io.Reader, orig io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(read, orig), orig}
+}
+
+// bodySource holds a buffered request body, in memory or in a file.
+type bodySource struct {
+	data []byte
+	file *os.File
+	size int64
+}
+
+// open returns a new reader of the whole body.
+func (s *bodySource) open() io.ReadCloser {
+	if s.file != nil {
+		return &replayBody{Reader: io.NewSectionReader(s.file, 0, s.size), src: s}
+	}
+	return &replayBody{Reader: bytes.NewReader(s.data), src: s}
+}
+
+// replayBody is a reader of a buffered body. Closing it is a no-op, since
+// other readers may share the source.
+type replayBody struct {
+	io.Reader
+	src *bodySource
+}
+
+func (b *replayBody) Close() error { return nil }
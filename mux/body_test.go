@@ -0,0 +1,209 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errReader returns its data and then err.
+type errReader struct {
+	data string
+	err  error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	if e.data == "" {
+		return 0, e.err
+	}
+	n := copy(p, e.data)
+	e.data = e.data[n:]
+	return n, nil
+}
+
+func TestBufferBody(t *testing.T) {
+	readAll := func(t *testing.T, rc io.ReadCloser) string {
+		t.Helper()
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		return string(data)
+	}
+
+	t.Run("replays body from memory", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		reread, err := BufferBody(req, 16)
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello", readAll(t, reread()))
+		assert.Equal(t, "hello", readAll(t, reread()))
+		assert.Equal(t, "hello", readAll(t, req.Body))
+		assert.Equal(t, int64(5), req.ContentLength)
+
+		body, err := req.GetBody()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", readAll(t, body))
+	})
+
+	t.Run("body exactly at limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		reread, err := BufferBody(req, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", readAll(t, reread()))
+	})
+
+	t.Run("content length preserved when unknown", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("hello")))
+		req.ContentLength = -1
+		_, err := BufferBody(req, 16)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), req.ContentLength)
+	})
+
+	t.Run("no body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		reread, err := BufferBody(req, 16)
+		require.NoError(t, err)
+		assert.Equal(t, http.NoBody, reread())
+
+		req.Body = nil
+		reread, err = BufferBody(req, 16)
+		require.NoError(t, err)
+		assert.Equal(t, http.NoBody, reread())
+	})
+
+	t.Run("too large keeps original body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+		reread, err := BufferBody(req, 4)
+		assert.ErrorIs(t, err, ErrBodyTooLarge)
+		assert.Nil(t, reread)
+		assert.Equal(t, "hello world", readAll(t, req.Body))
+	})
+
+	t.Run("read error keeps consumed bytes", func(t *testing.T) {
+		readErr := errors.New("connection reset")
+		req := httptest.NewRequest(http.MethodPost, "/", &errReader{data: "partial", err: readErr})
+		_, err := BufferBody(req, 64)
+		assert.ErrorIs(t, err, readErr)
+
+		data, err := io.ReadAll(req.Body)
+		assert.ErrorIs(t, err, readErr)
+		assert.Equal(t, "partial", string(data))
+	})
+
+	t.Run("spills to file", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader("hello world"))
+
+		reread, err := BufferBody(req, 4, SpillToFile(dir, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", readAll(t, reread()))
+		assert.Equal(t, "hello world", readAll(t, reread()))
+		assert.Equal(t, "hello world", readAll(t, req.Body))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Len(t, files, 1)
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(files[0])
+			return os.IsNotExist(err)
+		}, time.Second, 5*time.Millisecond)
+
+		_, err = io.ReadAll(reread())
+		assert.Error(t, err)
+	})
+
+	t.Run("spill cap", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader("hello world"))
+
+		_, err := BufferBody(req, 4, SpillToFile(dir, 8))
+		assert.ErrorIs(t, err, ErrBodyTooLarge)
+		assert.Equal(t, "hello world", readAll(t, req.Body))
+
+		req = httptest.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader("hello world"))
+		reread, err := BufferBody(req, 4, SpillToFile(dir, 11))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", readAll(t, reread()))
+	})
+
+	t.Run("spill cap below memory limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+		_, err := BufferBody(req, 4, SpillToFile(t.TempDir(), 2))
+		assert.ErrorIs(t, err, ErrBodyTooLarge)
+		assert.Equal(t, "hello world", readAll(t, req.Body))
+	})
+
+	t.Run("reuses buffered body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		_, err := BufferBody(req, 16)
+		require.NoError(t, err)
+
+		_, _ = io.ReadAll(req.Body)
+		reread, err := BufferBody(req, 16)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", readAll(t, reread()))
+		assert.Equal(t, "hello", readAll(t, req.Body), "body reset to the start")
+
+		_, err = BufferBody(req, 2)
+		assert.ErrorIs(t, err, ErrBodyTooLarge)
+		assert.Equal(t, "hello", readAll(t, req.Body))
+	})
+
+	t.Run("middleware and handler read the same body", func(t *testing.T) {
+		r := NewRouter()
+		var seen string
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				reread, err := BufferBody(req, 1<<10)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+					return
+				}
+				body := reread()
+				data, _ := io.ReadAll(body)
+				_ = body.Close()
+				seen = string(data)
+				next.ServeHTTP(w, req)
+			})
+		})
+		r.HandleFunc("/echo", func(w http.ResponseWriter, req *http.Request) {
+			_, _ = io.Copy(w, req.Body)
+		}).Methods(http.MethodPost)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"a":1}`)))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"a":1}`, w.Body.String())
+		assert.Equal(t, `{"a":1}`, seen)
+	})
+}
+
+func BenchmarkBufferBody(b *testing.B) {
+	payload := strings.Repeat("x", 4<<10)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+		reread, err := BufferBody(req, 1<<20)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, reread())
+	}
+}
@@ -0,0 +1,197 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Conditional evaluates the request's preconditions (RFC 9110 Section 13)
+// against the current representation, identified by etag and lastModified,
+// and reports whether the response has been written and the caller must
+// not write a body.
+//
+// The etag must be a quoted entity tag such as `"v42"` or `W/"v42"`, or ""
+// when the resource has none; a zero lastModified means unknown. Both are
+// set as the ETag and Last-Modified response headers when present. A
+// resource with neither is treated as having no current representation,
+// so "If-Match: *" fails and "If-None-Match: *" passes, as when creating a
+// resource with PUT.
+//
+// Preconditions are evaluated in the order of RFC 9110 Section 13.2.2:
+//
+//  1. If-Match (strong comparison) or, when absent, If-Unmodified-Since:
+//     on failure a 412 Precondition Failed is written.
+//  2. If-None-Match (weak comparison) or, when absent and the method is
+//     GET or HEAD, If-Modified-Since: on failure a 304 Not Modified is
+//     written for GET and HEAD, and a 412 Precondition Failed otherwise.
+//
+// If-None-Match and If-Match accept lists of entity tags and "*". Dates are
+// compared with one-second resolution, and invalid dates are ignored.
+//
+//	func getReport(w http.ResponseWriter, r *http.Request) {
+//	    report := loadReport(mux.Vars(r)["id"])
+//	    if mux.Conditional(w, r, report.ETag, report.UpdatedAt) {
+//	        return
+//	    }
+//	    mux.ResponseJSON(w, http.StatusOK, report)
+//	}
+func Conditional(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	h := w.Header()
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !isZeroTime(lastModified) {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	exists := etag != "" || !isZeroTime(lastModified)
+
+	if match := r.Header.Get("If-Match"); match != "" {
+		if !etagListMatch(match, etag, exists, true) {
+			preconditionFailed(w)
+			return true
+		}
+	} else if !checkIfUnmodifiedSince(r, lastModified) {
+		preconditionFailed(w)
+		return true
+	}
+
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" {
+		if etagListMatch(noneMatch, etag, exists, false) {
+			if safe {
+				notModified(w)
+			} else {
+				preconditionFailed(w)
+			}
+			return true
+		}
+	} else if safe && !checkIfModifiedSince(r, lastModified) {
+		notModified(w)
+		return true
+	}
+
+	return false
+}
+
+// checkIfUnmodifiedSince reports whether the If-Unmodified-Since
+// precondition passes. It passes when the header is absent or invalid, or
+// when the modification time is unknown.
+func checkIfUnmodifiedSince(r *http.Request, lastModified time.Time) bool {
+	since, ok := parseConditionalTime(r.Header.Get("If-Unmodified-Since"))
+	if !ok || isZeroTime(lastModified) {
+		return true
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// checkIfModifiedSince reports whether the If-Modified-Since precondition
+// passes, that is, whether the representation changed after the given
+// date. It passes when the header is absent or invalid, or when the
+// modification time is unknown.
+func checkIfModifiedSince(r *http.Request, lastModified time.Time) bool {
+	since, ok := parseConditionalTime(r.Header.Get("If-Modified-Since"))
+	if !ok || isZeroTime(lastModified) {
+		return true
+	}
+	return lastModified.Truncate(time.Second).After(since)
+}
+
+// parseConditionalTime parses an HTTP-date header value.
+func parseConditionalTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// isZeroTime reports whether t is the zero time or the Unix epoch, both of
+// which mean an unknown modification time.
+func isZeroTime(t time.Time) bool {
+	return t.IsZero() || t.Equal(time.Unix(0, 0))
+}
+
+// etagListMatch reports whether an If-Match or If-None-Match header value
+// matches the current entity tag. "*" matches any current representation.
+// Strong comparison requires both tags to be strong (RFC 9110 Section
+// 8.8.3.2); weak comparison ignores the W/ prefix.
+func etagListMatch(header, etag string, exists, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return exists
+	}
+	if etag == "" {
+		return false
+	}
+	for s := header; ; {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return false
+		}
+		if s[0] == ',' {
+			s = s[1:]
+			continue
+		}
+		candidate, rest := scanETag(s)
+		if candidate == "" {
+			return false
+		}
+		if etagEqual(candidate, etag, strong) {
+			return true
+		}
+		s = rest
+	}
+}
+
+// etagEqual compares two entity tags with strong or weak comparison.
+func etagEqual(a, b string, strong bool) bool {
+	if strong {
+		return a == b && !strings.HasPrefix(a, "W/")
+	}
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// scanETag returns the entity tag at the start of s and the remainder, or
+// "" when s does not start with a valid entity tag (RFC 9110 Section 8.8.3).
+func scanETag(s string) (string, string) {
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+	if len(s) <= start || s[start] != '"' {
+		return "", ""
+	}
+	for i := start + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return s[:i+1], s[i+1:]
+		case c == 0x21 || c >= 0x23 && c != 0x7f:
+			// etagc: %x21 / %x23-7E / obs-text
+		default:
+			return "", ""
+		}
+	}
+	return "", ""
+}
+
+// notModified writes a 304 Not Modified response, removing the headers that
+// describe a body (RFC 9110 Section 15.4.5).
+func notModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	if h.Get("ETag") != "" {
+		h.Del("Last-Modified")
+	}
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// preconditionFailed writes a 412 Precondition Failed response.
+func preconditionFailed(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+}
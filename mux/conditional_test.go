@@ -0,0 +1,175 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditional(t *testing.T) {
+	modified := time.Date(2025, time.January, 10, 12, 30, 45, 500, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	at := modified.Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		header       map[string]string
+		etag         string
+		lastModified time.Time
+		want         int // 0 when the caller writes the response
+	}{
+		// If-None-Match examples from RFC 9110 Section 13.1.2.
+		{"none match single", http.MethodGet, map[string]string{"If-None-Match": `"xyzzy"`}, `"xyzzy"`, time.Time{}, http.StatusNotModified},
+		{"none match weak header", http.MethodGet, map[string]string{"If-None-Match": `W/"xyzzy"`}, `"xyzzy"`, time.Time{}, http.StatusNotModified},
+		{"none match weak current", http.MethodGet, map[string]string{"If-None-Match": `"xyzzy"`}, `W/"xyzzy"`, time.Time{}, http.StatusNotModified},
+		{"none match list", http.MethodGet, map[string]string{"If-None-Match": `"xyzzy", "r2d2xxxx", "c3piozzzz"`}, `"r2d2xxxx"`, time.Time{}, http.StatusNotModified},
+		{"none match weak list", http.MethodGet, map[string]string{"If-None-Match": `W/"xyzzy", W/"r2d2xxxx", W/"c3piozzzz"`}, `"c3piozzzz"`, time.Time{}, http.StatusNotModified},
+		{"none match list miss", http.MethodGet, map[string]string{"If-None-Match": `"xyzzy", "r2d2xxxx"`}, `"c3piozzzz"`, time.Time{}, 0},
+		{"none match comma in tag", http.MethodGet, map[string]string{"If-None-Match": `"a,b", "c"`}, `"a,b"`, time.Time{}, http.StatusNotModified},
+		{"none match star", http.MethodGet, map[string]string{"If-None-Match": `*`}, `"xyzzy"`, time.Time{}, http.StatusNotModified},
+		{"none match head", http.MethodHead, map[string]string{"If-None-Match": `"xyzzy"`}, `"xyzzy"`, time.Time{}, http.StatusNotModified},
+		{"none match unsafe", http.MethodPut, map[string]string{"If-None-Match": `"xyzzy"`}, `"xyzzy"`, time.Time{}, http.StatusPreconditionFailed},
+		{"none match star create", http.MethodPut, map[string]string{"If-None-Match": `*`}, "", time.Time{}, 0},
+		{"none match star exists", http.MethodPut, map[string]string{"If-None-Match": `*`}, "", modified, http.StatusPreconditionFailed},
+		{"none match malformed", http.MethodGet, map[string]string{"If-None-Match": `xyzzy`}, `"xyzzy"`, time.Time{}, 0},
+
+		// If-Match examples from RFC 9110 Section 13.1.1.
+		{"match single", http.MethodPut, map[string]string{"If-Match": `"xyzzy"`}, `"xyzzy"`, time.Time{}, 0},
+		{"match list", http.MethodPut, map[string]string{"If-Match": `"xyzzy", "r2d2xxxx", "c3piozzzz"`}, `"c3piozzzz"`, time.Time{}, 0},
+		{"match miss", http.MethodPut, map[string]string{"If-Match": `"xyzzy"`}, `"r2d2xxxx"`, time.Time{}, http.StatusPreconditionFailed},
+		{"match weak header", http.MethodPut, map[string]string{"If-Match": `W/"xyzzy"`}, `W/"xyzzy"`, time.Time{}, http.StatusPreconditionFailed},
+		{"match weak current", http.MethodPut, map[string]string{"If-Match": `"xyzzy"`}, `W/"xyzzy"`, time.Time{}, http.StatusPreconditionFailed},
+		{"match star", http.MethodDelete, map[string]string{"If-Match": `*`}, `"xyzzy"`, time.Time{}, 0},
+		{"match star missing", http.MethodDelete, map[string]string{"If-Match": `*`}, "", time.Time{}, http.StatusPreconditionFailed},
+		{"match without etag", http.MethodPut, map[string]string{"If-Match": `"xyzzy"`}, "", modified, http.StatusPreconditionFailed},
+		{"match on get", http.MethodGet, map[string]string{"If-Match": `"other"`}, `"xyzzy"`, time.Time{}, http.StatusPreconditionFailed},
+
+		// If-Modified-Since.
+		{"modified since before", http.MethodGet, map[string]string{"If-Modified-Since": before}, "", modified, 0},
+		{"modified since at", http.MethodGet, map[string]string{"If-Modified-Since": at}, "", modified, http.StatusNotModified},
+		{"modified since after", http.MethodGet, map[string]string{"If-Modified-Since": after}, "", modified, http.StatusNotModified},
+		{"modified since invalid", http.MethodGet, map[string]string{"If-Modified-Since": "yesterday"}, "", modified, 0},
+		{"modified since unknown", http.MethodGet, map[string]string{"If-Modified-Since": after}, `"xyzzy"`, time.Time{}, 0},
+		{"modified since unsafe ignored", http.MethodPost, map[string]string{"If-Modified-Since": after}, "", modified, 0},
+		{"none match takes precedence", http.MethodGet, map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": after}, `"xyzzy"`, modified, 0},
+
+		// If-Unmodified-Since.
+		{"unmodified since before", http.MethodPut, map[string]string{"If-Unmodified-Since": before}, "", modified, http.StatusPreconditionFailed},
+		{"unmodified since at", http.MethodPut, map[string]string{"If-Unmodified-Since": at}, "", modified, 0},
+		{"unmodified since invalid", http.MethodPut, map[string]string{"If-Unmodified-Since": "yesterday"}, "", modified, 0},
+		{"match takes precedence", http.MethodPut, map[string]string{"If-Match": `"xyzzy"`, "If-Unmodified-Since": before}, `"xyzzy"`, modified, 0},
+
+		// Combined and absent.
+		{"match then none match", http.MethodGet, map[string]string{"If-Match": `"xyzzy"`, "If-None-Match": `"xyzzy"`}, `"xyzzy"`, time.Time{}, http.StatusNotModified},
+		{"no preconditions", http.MethodGet, nil, `"xyzzy"`, modified, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			done := Conditional(rec, req, tt.etag, tt.lastModified)
+			assert.Equal(t, tt.want != 0, done)
+			if tt.want != 0 {
+				assert.Equal(t, tt.want, rec.Code)
+			} else {
+				assert.False(t, rec.Flushed)
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("response headers", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.False(t, Conditional(rec, req, `"v1"`, modified))
+		assert.Equal(t, `"v1"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "Fri, 10 Jan 2025 12:30:45 GMT", rec.Header().Get("Last-Modified"))
+
+		rec = httptest.NewRecorder()
+		assert.False(t, Conditional(rec, req, "", time.Unix(0, 0)))
+		assert.Empty(t, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Header().Get("Last-Modified"))
+	})
+
+	t.Run("not modified strips body headers", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", ContentTypeApplicationJSON)
+		rec.Header().Set("Content-Length", "10")
+		rec.Header().Set("Cache-Control", "max-age=60")
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"v1"`)
+
+		assert.True(t, Conditional(rec, req, `"v1"`, modified))
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Type"))
+		assert.Empty(t, rec.Header().Get("Content-Length"))
+		assert.Empty(t, rec.Header().Get("Last-Modified"))
+		assert.Equal(t, `"v1"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "max-age=60", rec.Header().Get("Cache-Control"))
+		assert.Empty(t, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", at)
+		assert.True(t, Conditional(rec, req, "", modified))
+		assert.Equal(t, "Fri, 10 Jan 2025 12:30:45 GMT", rec.Header().Get("Last-Modified"))
+	})
+
+	t.Run("routed head shares get handler", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+			if Conditional(w, r, `"r1"`, modified) {
+				return
+			}
+			ResponseJSON(w, http.StatusOK, map[string]string{"id": "r1"})
+		}).Methods(http.MethodGet)
+
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			req := httptest.NewRequest(method, "/report", nil)
+			req.Header.Set("If-None-Match", `W/"r1"`)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusNotModified, rec.Code, method)
+			assert.Empty(t, rec.Body.String(), method)
+		}
+	})
+}
+
+func TestETagListMatch(t *testing.T) {
+	// Comparison examples from RFC 9110 Section 8.8.3.2.
+	tests := []struct {
+		a, b   string
+		strong bool
+		weak   bool
+	}{
+		{`W/"1"`, `W/"1"`, false, true},
+		{`W/"1"`, `W/"2"`, false, false},
+		{`W/"1"`, `"1"`, false, true},
+		{`"1"`, `"1"`, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.strong, etagListMatch(tt.a, tt.b, true, true))
+			assert.Equal(t, tt.weak, etagListMatch(tt.a, tt.b, true, false))
+			assert.Equal(t, tt.strong, etagListMatch(tt.b, tt.a, true, true))
+			assert.Equal(t, tt.weak, etagListMatch(tt.b, tt.a, true, false))
+		})
+	}
+
+	t.Run("malformed entries", func(t *testing.T) {
+		assert.False(t, etagListMatch(`"unterminated`, `"unterminated"`, true, false))
+		assert.False(t, etagListMatch(`"a b"`, `"a b"`, true, false))
+		assert.True(t, etagListMatch(` , "a"`, `"a"`, true, false))
+		assert.False(t, etagListMatch(`W/`, `"a"`, true, false))
+	})
+}
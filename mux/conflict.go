@@ -0,0 +1,288 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Conflict reports a route that cannot be reached for some or all of its
+// methods because an earlier route matches the same requests first.
+type Conflict struct {
+	// Route is the later route, unreachable for Methods.
+	Route *Route
+
+	// ShadowedBy is the earlier route that matches those requests.
+	ShadowedBy *Route
+
+	// Methods lists the methods for which Route is unreachable, or is nil
+	// when Route is unreachable for every method.
+	Methods []string
+}
+
+func (c Conflict) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "mux: route %q", describeRoute(c.Route))
+	if c.Methods != nil {
+		fmt.Fprintf(&b, " is unreachable for %s", strings.Join(c.Methods, ", "))
+	} else {
+		b.WriteString(" is unreachable")
+	}
+	fmt.Fprintf(&b, ": shadowed by route %q", describeRoute(c.ShadowedBy))
+	return b.String()
+}
+
+// DetectConflicts reports the routes of r and its subrouters that an
+// earlier route makes unreachable, in matching order. A route shadows a
+// later one when everything it requires is also required by the later
+// route: the same host and path templates (variable names aside), or a
+// PathPrefix covering a static path; the same or fewer header, query, and
+// scheme matchers; and methods that include some of the later route's.
+//
+// The check is conservative. Routes with custom matchers never shadow
+// others, since their outcome depends on the request, and routes made
+// unreachable through equivalent but differently written templates are
+// not reported. Routes already unreachable for every method are not
+// reported as shadowing later ones. DetectConflicts is meant for tests
+// and startup checks:
+//
+//	for _, c := range r.DetectConflicts() {
+//		log.Println(c)
+//	}
+func (r *Router) DetectConflicts() []Conflict {
+	var routes []*conflictRoute
+	_ = r.Walk(func(route *Route, _ *Router, ancestors []*Route) error {
+		if route.buildOnly || route.err != nil {
+			return SkipRouter
+		}
+		if _, ok := route.handler.(*Router); ok {
+			return nil
+		}
+		routes = append(routes, newConflictRoute(route, ancestors))
+		return nil
+	})
+
+	var conflicts []Conflict
+	unreachable := make(map[*conflictRoute]bool)
+	for i, later := range routes {
+		for _, earlier := range routes[:i] {
+			if unreachable[earlier] || !earlier.covers(later) {
+				continue
+			}
+			methods, all := earlier.shadowedMethods(later)
+			if !all && len(methods) == 0 {
+				continue
+			}
+			conflicts = append(conflicts, Conflict{
+				Route:      later.route,
+				ShadowedBy: earlier.route,
+				Methods:    methods,
+			})
+			if all {
+				unreachable[later] = true
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// conflictRoute holds the matchers of a route combined with those of the
+// routes it is mounted under.
+type conflictRoute struct {
+	route         *Route
+	methods       methodMatcher // nil matches any method
+	schemes       []string      // nil matches any scheme
+	paths         []*routeRegexp
+	hosts         []*routeRegexp
+	queries       []*routeRegexp
+	headers       map[string]string
+	headerRegexps map[string]string
+	custom        bool
+}
+
+func newConflictRoute(route *Route, ancestors []*Route) *conflictRoute {
+	c := &conflictRoute{
+		route:         route,
+		headers:       make(map[string]string),
+		headerRegexps: make(map[string]string),
+	}
+	for _, rt := range append(slices.Clip(ancestors), route) {
+		if rt.regexp.path != nil {
+			c.paths = append(c.paths, rt.regexp.path)
+		}
+		if rt.regexp.host != nil {
+			c.hosts = append(c.hosts, rt.regexp.host)
+		}
+		c.queries = append(c.queries, rt.regexp.queries...)
+
+		for _, m := range rt.matchers {
+			switch mm := m.(type) {
+			case methodMatcher:
+				c.methods = intersectMethods(c.methods, mm)
+			case schemeMatcher:
+				c.schemes = intersectSchemes(c.schemes, mm)
+			case headerMatcher:
+				for name, value := range mm {
+					c.headers[name] = value
+				}
+			case headerRegexMatcher:
+				for name, re := range mm {
+					c.headerRegexps[name] = re.String()
+				}
+			case MethodsReporter:
+				c.methods = intersectMethods(c.methods, mm.Methods())
+				c.custom = true
+			default:
+				c.custom = true
+			}
+		}
+	}
+	return c
+}
+
+// covers reports whether every request matched by other, methods aside,
+// is also matched by c.
+func (c *conflictRoute) covers(other *conflictRoute) bool {
+	if c.custom {
+		return false
+	}
+	for _, p := range c.paths {
+		if !slices.ContainsFunc(other.paths, p.coversPath) {
+			return false
+		}
+	}
+	for _, h := range c.hosts {
+		if !slices.ContainsFunc(other.hosts, h.sameAs) {
+			return false
+		}
+	}
+	for _, q := range c.queries {
+		if !slices.ContainsFunc(other.queries, q.sameAs) {
+			return false
+		}
+	}
+	for name, value := range c.headers {
+		if v, ok := other.headers[name]; ok && (value == "" || v == value) {
+			continue
+		}
+		if _, ok := other.headerRegexps[name]; ok && value == "" {
+			continue
+		}
+		return false
+	}
+	for name, re := range c.headerRegexps {
+		if other.headerRegexps[name] != re {
+			return false
+		}
+	}
+	if c.schemes != nil {
+		if other.schemes == nil {
+			return false
+		}
+		for _, s := range other.schemes {
+			if !slices.Contains(c.schemes, s) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// shadowedMethods returns the methods of other that c matches first, and
+// whether c matches all of them.
+func (c *conflictRoute) shadowedMethods(other *conflictRoute) (methods []string, all bool) {
+	switch {
+	case c.methods == nil && other.methods == nil:
+		return nil, true
+	case c.methods == nil:
+		return slices.Clone([]string(other.methods)), true
+	case other.methods == nil:
+		return slices.Clone([]string(c.methods)), false
+	}
+
+	all = true
+	for _, m := range other.methods {
+		if c.methods.allows(m) {
+			methods = append(methods, m)
+		} else {
+			all = false
+		}
+	}
+	// A GET route also serves HEAD, which an earlier route declaring HEAD
+	// but not GET takes first.
+	if other.methods.allows(http.MethodHead) && !slices.Contains(other.methods, http.MethodHead) &&
+		c.methods.allows(http.MethodHead) && !c.methods.allows(http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+	return methods, all
+}
+
+// coversPath reports whether every request path matched by other is also
+// matched by r: the two compile to the same expression, or r is a prefix
+// template that matches other's static path.
+func (r *routeRegexp) coversPath(other *routeRegexp) bool {
+	if r.sameAs(other) {
+		return true
+	}
+	if !r.wildcard || len(other.varsN) > 0 || !r.regexp.MatchString(other.template) {
+		return false
+	}
+	// With strict slash the static path also matches without its
+	// trailing slash.
+	return !other.strictSlash || r.regexp.MatchString(strings.TrimRight(other.template, "/"))
+}
+
+// sameAs reports whether r and other compile to the same expression.
+func (r *routeRegexp) sameAs(other *routeRegexp) bool {
+	return r.regexp.String() == other.regexp.String()
+}
+
+func intersectMethods(current methodMatcher, methods []string) methodMatcher {
+	if current == nil {
+		return slices.Clone(methodMatcher(methods))
+	}
+	var out methodMatcher
+	for _, m := range methods {
+		if current.allows(m) {
+			out = append(out, m)
+		}
+	}
+	if out == nil {
+		out = methodMatcher{}
+	}
+	return out
+}
+
+func intersectSchemes(current, schemes []string) []string {
+	if current == nil {
+		return slices.Clone(schemes)
+	}
+	out := []string{}
+	for _, s := range schemes {
+		if slices.Contains(current, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// describeRoute returns the host and path templates of the route, with
+// its methods, for conflict messages.
+func describeRoute(route *Route) string {
+	var desc string
+	if host, err := route.GetHostTemplate(); err == nil {
+		desc = host
+	}
+	if path, err := route.GetPathTemplate(); err == nil {
+		desc += path
+	}
+	if desc == "" {
+		desc = "*"
+	}
+	if methods, err := route.GetMethods(); err == nil && len(methods) > 0 {
+		desc = strings.Join(methods, ",") + " " + desc
+	}
+	return desc
+}
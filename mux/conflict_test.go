@@ -0,0 +1,149 @@
+package mux
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectConflicts(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	t.Run("unreachable duplicate", func(t *testing.T) {
+		r := NewRouter()
+		first := r.HandleFunc("/users/{id}", noop).Methods(http.MethodGet)
+		second := r.HandleFunc("/users/{uid}", noop).Methods(http.MethodGet)
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 1)
+		assert.Same(t, second, conflicts[0].Route)
+		assert.Same(t, first, conflicts[0].ShadowedBy)
+		assert.Equal(t, []string{http.MethodGet}, conflicts[0].Methods)
+		assert.Equal(t, `mux: route "GET /users/{uid}" is unreachable for GET: shadowed by route "GET /users/{id}"`, conflicts[0].String())
+	})
+
+	t.Run("distinct methods on the same path", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users", noop).Methods(http.MethodGet)
+		r.HandleFunc("/users", noop).Methods(http.MethodPost)
+		r.HandleFunc("/users/{id}", noop).Methods(http.MethodPut, http.MethodPatch)
+		r.HandleFunc("/users/{id}", noop).Methods(http.MethodDelete)
+
+		assert.Empty(t, r.DetectConflicts())
+	})
+
+	t.Run("partial method overlap", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/items", noop).Methods(http.MethodGet, http.MethodPost)
+		later := r.HandleFunc("/items", noop).Methods(http.MethodPost, http.MethodPut)
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 1)
+		assert.Same(t, later, conflicts[0].Route)
+		assert.Equal(t, []string{http.MethodPost}, conflicts[0].Methods)
+	})
+
+	t.Run("any method", func(t *testing.T) {
+		r := NewRouter()
+		catchAll := r.HandleFunc("/health", noop)
+		r.HandleFunc("/health", noop).Methods(http.MethodGet)
+		r.HandleFunc("/health", noop)
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 2)
+		assert.Same(t, catchAll, conflicts[0].ShadowedBy)
+		assert.Equal(t, []string{http.MethodGet}, conflicts[0].Methods)
+		assert.Same(t, catchAll, conflicts[1].ShadowedBy)
+		assert.Nil(t, conflicts[1].Methods)
+		assert.Equal(t, `mux: route "/health" is unreachable: shadowed by route "/health"`, conflicts[1].String())
+	})
+
+	t.Run("narrower earlier route", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet).Headers("Accept", "text/html")
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet).Queries("format", "{format}")
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet).Schemes("https")
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet).Host("docs.example.com")
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet).MatcherFunc(func(*http.Request, *RouteMatch) bool { return true })
+		r.HandleFunc("/docs/{page:[a-z]+}", noop).Methods(http.MethodGet)
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet)
+		r.HandleFunc("/docs/{page}", noop).Methods(http.MethodGet)
+
+		assert.Empty(t, r.DetectConflicts())
+	})
+
+	t.Run("broader earlier route", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/docs", noop).Methods(http.MethodGet)
+		headers := r.HandleFunc("/docs", noop).Methods(http.MethodGet).Headers("Accept", "text/html")
+		queries := r.HandleFunc("/docs", noop).Methods(http.MethodGet).Queries("format", "{format}")
+		schemes := r.HandleFunc("/docs", noop).Methods(http.MethodGet).Schemes("https")
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 3)
+		assert.Same(t, headers, conflicts[0].Route)
+		assert.Same(t, queries, conflicts[1].Route)
+		assert.Same(t, schemes, conflicts[2].Route)
+	})
+
+	t.Run("path prefix", func(t *testing.T) {
+		r := NewRouter()
+		static := r.PathPrefix("/static/").HandlerFunc(noop)
+		asset := r.HandleFunc("/static/app.js", noop)
+		r.HandleFunc("/static/{file}", noop)
+		r.HandleFunc("/status", noop)
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 1)
+		assert.Same(t, asset, conflicts[0].Route)
+		assert.Same(t, static, conflicts[0].ShadowedBy)
+	})
+
+	t.Run("subrouters", func(t *testing.T) {
+		r := NewRouter()
+		api := r.PathPrefix("/api").Methods(http.MethodGet, http.MethodPost).Subrouter()
+		list := api.HandleFunc("/users", noop)
+		r.HandleFunc("/api/users", noop).Methods(http.MethodPost)
+		r.HandleFunc("/api/users", noop).Methods(http.MethodDelete)
+
+		admin := r.PathPrefix("/admin").Headers("X-Admin", "").Subrouter()
+		admin.HandleFunc("/jobs", noop)
+		r.HandleFunc("/admin/jobs", noop)
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 1)
+		assert.Same(t, list, conflicts[0].ShadowedBy)
+		assert.Equal(t, []string{http.MethodPost}, conflicts[0].Methods)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/files", noop).Methods(http.MethodHead)
+		later := r.HandleFunc("/files", noop).Methods(http.MethodGet)
+
+		conflicts := r.DetectConflicts()
+		require.Len(t, conflicts, 1)
+		assert.Same(t, later, conflicts[0].Route)
+		assert.Equal(t, []string{http.MethodHead}, conflicts[0].Methods)
+	})
+
+	t.Run("duplicates reported once", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/a", noop)
+		r.HandleFunc("/a", noop)
+		r.HandleFunc("/a", noop)
+
+		assert.Len(t, r.DetectConflicts(), 2)
+	})
+
+	t.Run("build-only and disabled routes", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/a", noop).BuildOnly()
+		r.HandleFunc("/a", noop).Enabled(false)
+		r.HandleFunc("/a", noop)
+
+		assert.Empty(t, r.DetectConflicts())
+	})
+}
@@ -0,0 +1,146 @@
+package mux
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrProtoMismatch is wrapped by a ConnMismatchError when the request
+// protocol is not one of those set with Route.Proto.
+var ErrProtoMismatch = errors.New("protocol is not accepted")
+
+// ErrClientCertMismatch is wrapped by a ConnMismatchError when the TLS
+// client certificate, or its absence, is rejected by Route.TLSClientCert
+// or Route.RequireClientCert.
+var ErrClientCertMismatch = errors.New("TLS client certificate is not accepted")
+
+// ConnMismatchError reports a request that matches a route except for a
+// requirement on its connection: the protocol set with Route.Proto or the
+// TLS client certificate checked by Route.TLSClientCert. Router.Match sets
+// it as RouteMatch.MatchErr in place of ErrNotFound. It wraps both
+// ErrNotFound and Err, so errors.Is tells which matcher failed.
+type ConnMismatchError struct {
+	// Route is the route the request nearly matched. A requirement set on
+	// a subrouter's route applies to the routes of the subrouter, and
+	// Route is the one of those the request matched.
+	Route *Route
+
+	// Err is ErrProtoMismatch or ErrClientCertMismatch.
+	Err error
+
+	// Protos lists the protocols the route accepts when Err is
+	// ErrProtoMismatch.
+	Protos []string
+}
+
+func (e *ConnMismatchError) Error() string {
+	tpl, _ := e.Route.GetPathTemplate()
+	return fmt.Sprintf("mux: %v for route %q", e.Err, tpl)
+}
+
+// Unwrap returns Err and ErrNotFound.
+func (e *ConnMismatchError) Unwrap() []error {
+	return []error{e.Err, ErrNotFound}
+}
+
+// ConnMismatchReply is a Router.ConnMismatchHandler that replies with 426
+// Upgrade Required (RFC 9110 Section 15.5.22), listing the accepted
+// protocols in the Upgrade header, when the protocol did not match, and
+// with 403 Forbidden (RFC 9110 Section 15.5.4) when the client
+// certificate did not.
+func ConnMismatchReply(w http.ResponseWriter, req *http.Request, err *ConnMismatchError) {
+	if errors.Is(err.Err, ErrProtoMismatch) {
+		w.Header().Set("Upgrade", strings.Join(err.Protos, ", "))
+		// RFC 9110 Section 7.8: a sender of Upgrade must also send the
+		// "Upgrade" connection option. HTTP/2 and later forbid Connection.
+		if req.ProtoMajor < 2 {
+			w.Header().Set("Connection", "Upgrade")
+		}
+		http.Error(w, http.StatusText(http.StatusUpgradeRequired), http.StatusUpgradeRequired)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
+
+// connMismatchResponderFor returns the ConnMismatchHandler that answers a
+// failed match with err: that of the router owning the nearly matched
+// route or of its nearest ancestor that sets one. It returns nil when err
+// is not a *ConnMismatchError or no router sets a handler.
+func connMismatchResponderFor(err error) func(http.ResponseWriter, *http.Request, *ConnMismatchError) {
+	mismatch, ok := err.(*ConnMismatchError)
+	if !ok {
+		return nil
+	}
+	for router := range ownerRouters(mismatch.Route) {
+		if router.ConnMismatchHandler != nil {
+			return router.ConnMismatchHandler
+		}
+	}
+	return nil
+}
+
+// recordConnMismatch stores in match the connection requirement of r that
+// rejected req, unless an earlier route already recorded one. The
+// requirement of a subrouter's route is recorded only when a route of the
+// subrouter matches the request.
+func (r *Route) recordConnMismatch(req *http.Request, match *RouteMatch, mismatch ConnMismatchError) {
+	if match.connMismatch != nil {
+		return
+	}
+	target := r
+	if router, ok := r.handler.(*Router); ok {
+		inner := &RouteMatch{}
+		switch {
+		case router.Match(req, inner) && !inner.fallback:
+			target = inner.Route
+		case inner.connMismatch != nil:
+			target = inner.connMismatch.Route
+		default:
+			return
+		}
+	}
+	mismatch.Route = target
+	match.connMismatch = &mismatch
+}
+
+// connMatcher is a built-in matcher on a requirement of the connection,
+// whose failure is reported as a ConnMismatchError.
+type connMatcher interface {
+	Matcher
+	mismatch() ConnMismatchError
+}
+
+// protoMatcher matches the request protocol version, such as "HTTP/2.0".
+type protoMatcher []string
+
+func (m protoMatcher) Match(r *http.Request, _ *RouteMatch) bool {
+	return slices.ContainsFunc(m, func(proto string) bool {
+		return strings.EqualFold(proto, r.Proto)
+	})
+}
+
+func (m protoMatcher) mismatch() ConnMismatchError {
+	return ConnMismatchError{Err: ErrProtoMismatch, Protos: slices.Clone(m)}
+}
+
+// tlsClientCertMatcher matches the TLS connection state of the request.
+// Requests without TLS never match.
+type tlsClientCertMatcher func(*tls.ConnectionState) bool
+
+func (m tlsClientCertMatcher) Match(r *http.Request, _ *RouteMatch) bool {
+	return r.TLS != nil && m(r.TLS)
+}
+
+func (m tlsClientCertMatcher) mismatch() ConnMismatchError {
+	return ConnMismatchError{Err: ErrClientCertMismatch}
+}
+
+// hasVerifiedClientCert reports whether the client presented a certificate
+// that the server verified against its client CAs.
+func hasVerifiedClientCert(state *tls.ConnectionState) bool {
+	return len(state.PeerCertificates) > 0 && len(state.VerifiedChains) > 0
+}
@@ -0,0 +1,197 @@
+package mux
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnMismatch(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}}
+	withTLS := func(req *http.Request, verified bool) *http.Request {
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return req
+	}
+	withProto := func(req *http.Request, proto string, major int) *http.Request {
+		req.Proto, req.ProtoMajor, req.ProtoMinor = proto, major, 0
+		return req
+	}
+
+	t.Run("proto", func(t *testing.T) {
+		r := NewRouter()
+		route := r.HandleFunc("/stream", noop).Proto("HTTP/2.0", "http/3.0")
+
+		var match RouteMatch
+		assert.True(t, r.Match(withProto(httptest.NewRequest(http.MethodGet, "/stream", nil), "HTTP/2.0", 2), &match))
+		match = RouteMatch{}
+		assert.True(t, r.Match(withProto(httptest.NewRequest(http.MethodGet, "/stream", nil), "HTTP/3.0", 3), &match))
+
+		match = RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/stream", nil), &match))
+		var mismatch *ConnMismatchError
+		require.ErrorAs(t, match.MatchErr, &mismatch)
+		assert.Same(t, route, mismatch.Route)
+		assert.Equal(t, []string{"HTTP/2.0", "http/3.0"}, mismatch.Protos)
+		assert.ErrorIs(t, match.MatchErr, ErrProtoMismatch)
+		assert.ErrorIs(t, match.MatchErr, ErrNotFound)
+		assert.NotErrorIs(t, match.MatchErr, ErrClientCertMismatch)
+		assert.Equal(t, `mux: protocol is not accepted for route "/stream"`, match.MatchErr.Error())
+	})
+
+	t.Run("require client cert", func(t *testing.T) {
+		r := NewRouter()
+		route := r.HandleFunc("/admin", noop).RequireClientCert()
+
+		var match RouteMatch
+		assert.True(t, r.Match(withTLS(httptest.NewRequest(http.MethodGet, "/admin", nil), true), &match))
+
+		noCert := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		noCert.TLS = &tls.ConnectionState{}
+
+		for name, req := range map[string]*http.Request{
+			"plain http":   httptest.NewRequest(http.MethodGet, "/admin", nil),
+			"no cert":      noCert,
+			"unverified":   withTLS(httptest.NewRequest(http.MethodGet, "/admin", nil), false),
+			"another path": withTLS(httptest.NewRequest(http.MethodGet, "/other", nil), false),
+		} {
+			match = RouteMatch{}
+			assert.False(t, r.Match(req, &match), name)
+			if name == "another path" {
+				assert.Equal(t, ErrNotFound, match.MatchErr, name)
+				continue
+			}
+			var mismatch *ConnMismatchError
+			require.ErrorAs(t, match.MatchErr, &mismatch, name)
+			assert.Same(t, route, mismatch.Route, name)
+			assert.ErrorIs(t, match.MatchErr, ErrClientCertMismatch, name)
+		}
+	})
+
+	t.Run("tls client cert matcher", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/ops", noop).TLSClientCert(func(state *tls.ConnectionState) bool {
+			return len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName == "ops"
+		})
+
+		var match RouteMatch
+		assert.True(t, r.Match(withTLS(httptest.NewRequest(http.MethodGet, "/ops", nil), false), &match))
+		match = RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/ops", nil), &match))
+		assert.ErrorIs(t, match.MatchErr, ErrClientCertMismatch)
+	})
+
+	t.Run("composes with other matchers", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/admin", noop).Methods(http.MethodPost).RequireClientCert()
+		public := r.HandleFunc("/admin", noop).Methods(http.MethodGet)
+
+		var match RouteMatch
+		assert.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/admin", nil), &match))
+		assert.Same(t, public, match.Route)
+
+		match = RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodPost, "/admin", nil), &match))
+		assert.ErrorIs(t, match.MatchErr, ErrClientCertMismatch)
+
+		match = RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodDelete, "/admin", nil), &match))
+		assert.Equal(t, ErrMethodMismatch, match.MatchErr)
+
+		match = RouteMatch{}
+		assert.True(t, r.Match(withTLS(httptest.NewRequest(http.MethodPost, "/admin", nil), true), &match))
+	})
+
+	t.Run("subrouter requirement", func(t *testing.T) {
+		r := NewRouter()
+		admin := r.PathPrefix("/admin").RequireClientCert().Subrouter()
+		jobs := admin.HandleFunc("/jobs", noop)
+
+		var match RouteMatch
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/admin/jobs", nil), &match))
+		var mismatch *ConnMismatchError
+		require.ErrorAs(t, match.MatchErr, &mismatch)
+		assert.Same(t, jobs, mismatch.Route)
+
+		match = RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/admin/unknown", nil), &match))
+		assert.Equal(t, ErrNotFound, match.MatchErr)
+	})
+
+	t.Run("route inside subrouter", func(t *testing.T) {
+		r := NewRouter()
+		api := r.PathPrefix("/api").Subrouter()
+		grpc := api.HandleFunc("/grpc", noop).Proto("HTTP/2.0")
+
+		var match RouteMatch
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodPost, "/api/grpc", nil), &match))
+		var mismatch *ConnMismatchError
+		require.ErrorAs(t, match.MatchErr, &mismatch)
+		assert.Same(t, grpc, mismatch.Route)
+	})
+
+	t.Run("default response is 404", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/admin", noop).RequireClientCert()
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("conn mismatch reply", func(t *testing.T) {
+		r := NewRouter()
+		r.ConnMismatchHandler = ConnMismatchReply
+		r.HandleFunc("/admin", noop).RequireClientCert()
+		r.HandleFunc("/stream", noop).Proto("HTTP/2.0")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		assert.Equal(t, http.StatusUpgradeRequired, w.Code)
+		assert.Equal(t, "HTTP/2.0", w.Header().Get("Upgrade"))
+		assert.Equal(t, "Upgrade", w.Header().Get("Connection"))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, withProto(httptest.NewRequest(http.MethodGet, "/stream", nil), "HTTP/3.0", 3))
+		assert.Equal(t, http.StatusUpgradeRequired, w.Code)
+		assert.Empty(t, w.Header().Get("Connection"))
+	})
+
+	t.Run("handler of the owning subrouter", func(t *testing.T) {
+		r := NewRouter()
+		admin := r.PathPrefix("/admin").Subrouter()
+		admin.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Admin", "1")
+				next.ServeHTTP(w, req)
+			})
+		})
+		jobs := admin.HandleFunc("/jobs", noop).RequireClientCert()
+
+		var seen *Route
+		admin.ConnMismatchHandler = func(w http.ResponseWriter, req *http.Request, err *ConnMismatchError) {
+			seen = CurrentRoute(req)
+			w.WriteHeader(http.StatusForbidden)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/jobs", nil))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Admin"))
+		assert.Same(t, jobs, seen)
+	})
+}
@@ -24,6 +24,7 @@ const (
 
 	// Multipart types.
 	ContentTypeMultipartFormData = "multipart/form-data"
+	ContentTypeMultipartMixed    = "multipart/mixed"
 
 	// Text types.
 	ContentTypeTextPlain       = "text/plain"
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 )
 
 // routeContextKey is an unexported type for the single context key.
@@ -26,6 +27,73 @@ type routerCtxKeyType struct{}
 // routerCtxKey is the context key used to store the current router.
 var routerCtxKey = routerCtxKeyType{}
 
+// ContextKey is a typed key for a request-scoped value, usually one set by
+// a middleware and read by handlers further down the chain. Each key
+// created by NewContextKey is distinct, even when two keys share a name,
+// so packages never collide the way string keys do, and From returns the
+// value with its static type:
+//
+//	var userKey = mux.NewContextKey[*User]("user")
+//
+//	// in middleware
+//	r = userKey.WithValue(r, user)
+//
+//	// in a handler
+//	user, ok := userKey.From(r)
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey returns a new key for values of type T. The name is used
+// only by String, for debugging.
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+// WithValue returns a shallow copy of r whose context carries v under k.
+func (k *ContextKey[T]) WithValue(r *http.Request, v T) *http.Request {
+	return r.WithContext(k.WithContext(r.Context(), v))
+}
+
+// WithContext returns a copy of ctx that carries v under k.
+func (k *ContextKey[T]) WithContext(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// From returns the value stored under k in the request context and
+// whether it was present.
+func (k *ContextKey[T]) From(r *http.Request) (T, bool) {
+	return k.FromContext(r.Context())
+}
+
+// FromContext returns the value stored under k in ctx and whether it was
+// present.
+func (k *ContextKey[T]) FromContext(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// String returns the key name, so the key prints usefully in context
+// dumps.
+func (k *ContextKey[T]) String() string {
+	return "mux context key " + k.name
+}
+
+// requestPathsCtxKeyType is an unexported type for the request paths
+// context key.
+type requestPathsCtxKeyType struct{}
+
+// requestPathsCtxKey is the context key used to store the paths of a
+// request that no route matched.
+var requestPathsCtxKey = requestPathsCtxKeyType{}
+
+// requestPaths holds the path a request was matched against and its
+// cleaned form.
+type requestPaths struct {
+	original string
+	cleaned  string
+}
+
 // routeContext holds the matched route and extracted variables.
 type routeContext struct {
 	route *Route
@@ -33,8 +101,16 @@ type routeContext struct {
 }
 
 // Vars returns the route variables for the current request, if any.
+//
+// Routes without variables do not allocate a map while matching; for them
+// Vars returns a new empty map, which is safe to read and write but is not
+// retained by the request. Vars returns nil for requests that were not
+// dispatched by a Router.
 func Vars(r *http.Request) map[string]string {
 	if rc, ok := r.Context().Value(ctxKey).(*routeContext); ok {
+		if rc.vars == nil {
+			return map[string]string{}
+		}
 		return rc.vars
 	}
 	return nil
@@ -50,10 +126,101 @@ func VarGet(r *http.Request, name string) (string, bool) {
 	return "", false
 }
 
+// VarValues returns every value of a route variable. For a variable
+// captured by a Queries template whose key is repeated in the query
+// string, such as ?tag=a&tag=b with Queries("tag", "{tags}"), it returns
+// the capture from each value that matches the template, in query order.
+// Values that do not match the template are skipped. For other variables
+// it returns a one-element slice with the value from Vars, and nil when
+// the variable does not exist.
+//
+// Vars and VarGet keep single-value semantics: they hold the capture from
+// the first matching value of a repeated key.
+func VarValues(r *http.Request, name string) []string {
+	rc, ok := r.Context().Value(ctxKey).(*routeContext)
+	if !ok {
+		return nil
+	}
+	if rc.route != nil {
+		if values, found := queryVarValues(r, rc.route, name); found {
+			return values
+		}
+	}
+	if val, exists := rc.vars[name]; exists {
+		return []string{val}
+	}
+	return nil
+}
+
+// queryVarValues collects the captures of the named variable from every
+// value of the query key whose template declares it, looking at route and
+// then at the routes its subrouters are mounted on. It reports false when
+// no query template declares the variable.
+func queryVarValues(req *http.Request, route *Route, name string) ([]string, bool) {
+	var query url.Values
+	var p parentRoute = route
+	for p != nil {
+		switch v := p.(type) {
+		case *Route:
+			for _, q := range v.regexp.queries {
+				idx := slices.Index(q.varsN, name)
+				if idx < 0 {
+					continue
+				}
+				if query == nil {
+					query = req.URL.Query()
+				}
+				var values []string
+				for _, val := range query[q.queryKey] {
+					m := q.regexp.FindStringSubmatchIndex(val)
+					if m == nil || m[(idx+1)*2] < 0 {
+						continue
+					}
+					values = append(values, val[m[(idx+1)*2]:m[(idx+1)*2+1]])
+				}
+				return values, true
+			}
+			p = v.parent
+		case *Router:
+			p = v.parent
+		default:
+			p = nil
+		}
+	}
+	return nil, false
+}
+
+// RequestPaths returns the path a request was matched against and its
+// cleaned form (dot segments and duplicate slashes removed, per RFC 3986
+// Section 5.2.4), so a NotFoundHandler or MethodNotAllowedHandler can log
+// what the client actually asked for. The original path is the
+// percent-encoded path when the router uses UseEncodedPath.
+//
+// The paths are recorded for requests that no route matched, including
+// those handled by a subrouter's NotFoundHandler. With path cleaning
+// enabled (the default), a path that needs cleaning is redirected before
+// matching, so the two differ only for routers with SkipClean. For other
+// requests both values are derived from r.URL.Path as it is now.
+//
+//	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+//	    original, cleaned := mux.RequestPaths(req)
+//	    log.Printf("404: %s (cleaned %s)", original, cleaned)
+//	    http.NotFound(w, req)
+//	})
+func RequestPaths(r *http.Request) (original, cleaned string) {
+	if p, ok := r.Context().Value(requestPathsCtxKey).(requestPaths); ok {
+		return p.original, p.cleaned
+	}
+	return r.URL.Path, cleanPath(r.URL.Path)
+}
+
 // CurrentRouter returns the innermost router that handled the current
 // request. For subrouters, this returns the subrouter, not the parent.
-// This only works when called inside the handler of the matched route
-// because the router is stored in the request context during ServeHTTP.
+// When no route matched, it returns the subrouter whose prefix matched
+// the request, if any. The router is stored in the request context right
+// after matching, before any router, subrouter, or route middleware runs,
+// so it is available to every middleware as well as to the handler and
+// the 404 and 405 handlers. It returns nil outside a Router dispatch.
 func CurrentRouter(r *http.Request) *Router {
 	if router, ok := r.Context().Value(routerCtxKey).(*Router); ok {
 		return router
@@ -62,8 +229,13 @@ func CurrentRouter(r *http.Request) *Router {
 }
 
 // CurrentRoute returns the matched route for the current request, if any.
-// This only works when called inside the handler of the matched route
-// because the matched route is stored in the request context.
+// The route is stored in the request context right after matching, before
+// any router, subrouter, or route middleware runs, so even the first
+// middleware registered with Use can read its path template, for example
+// to label metrics. For a 405 Method Not Allowed response it returns the
+// first route whose path matched but whose method did not. For a 404 it
+// returns nil, unless a subrouter's NotFoundHandler handles the request,
+// in which case it returns the route the subrouter is mounted on.
 func CurrentRoute(r *http.Request) *Route {
 	if rc, ok := r.Context().Value(ctxKey).(*routeContext); ok {
 		return rc.route
@@ -180,11 +352,17 @@ type RouteMatch struct {
 	Handler http.Handler
 
 	// Vars contains the extracted path variables from the matched route.
+	// It is left nil when the route has no variables, so matching a static
+	// route does not allocate.
 	Vars map[string]string
 
 	// MatchErr is set to ErrMethodMismatch when the request method
 	// does not match but the path does. This triggers a 405 response
-	// per RFC 9110 Section 15.5.6.
+	// per RFC 9110 Section 15.5.6. When no route matches it is
+	// ErrNotFound, a *ConnMismatchError wrapping it when the request
+	// matched a route except for its protocol or TLS client certificate,
+	// or a *VarMismatchError wrapping it when the request matched a route
+	// except for the value of a path variable.
 	MatchErr error
 
 	// methodNotAllowed signals that the router should respond with
@@ -192,6 +370,24 @@ type RouteMatch struct {
 	// 404 Not Found (RFC 9110 Section 15.5.5).
 	methodNotAllowed bool
 
+	// fallback marks Handler as a subrouter's NotFoundHandler or
+	// MethodNotAllowedHandler, which is wrapped per request and not cached.
+	fallback bool
+
+	// fallbackRouters lists, innermost first, the subrouters whose prefix
+	// matched a request that none of their routes matched. Their
+	// middleware wraps the 404 or 405 handler.
+	fallbackRouters []*Router
+
+	// mismatchRoute is the first route whose method did not match a
+	// request that its other matchers accepted. It is stored in the
+	// request context for the 405 handler.
+	mismatchRoute *Route
+
+	// connMismatch is the first route, with the requirement it failed,
+	// that the request matched except for Proto or TLSClientCert.
+	connMismatch *ConnMismatchError
+
 	// parsedQuery caches the parsed query string to avoid repeated
 	// url.Query() calls during matching and variable extraction.
 	parsedQuery url.Values
@@ -240,6 +436,10 @@ var ErrMethodMismatch = errors.New("method is not allowed")
 // per RFC 9110 Section 15.5.5.
 var ErrNotFound = errors.New("no matching route was found")
 
+// ErrRouteDisabled is returned when building a URL for a route that was
+// removed with Route.Enabled(false).
+var ErrRouteDisabled = errors.New("mux: route is disabled")
+
 // ErrMetadataKeyNotFound is returned when the specified metadata key
 // is not present in the route's metadata map.
 var ErrMetadataKeyNotFound = errors.New("key not found in metadata")
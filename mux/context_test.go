@@ -25,6 +25,50 @@ func TestVars(t *testing.T) {
 		assert.Equal(t, "42", result["id"])
 		assert.Equal(t, "test", result["name"])
 	})
+
+	t.Run("returns usable empty map for static route", func(t *testing.T) {
+		router := NewRouter()
+		var vars map[string]string
+		router.HandleFunc("/health", func(_ http.ResponseWriter, r *http.Request) {
+			vars = Vars(r)
+			vars["written"] = "ok"
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		var match RouteMatch
+		require.True(t, router.Match(req, &match))
+		assert.Nil(t, match.Vars)
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		require.NotNil(t, vars)
+		assert.Equal(t, map[string]string{"written": "ok"}, vars)
+	})
+
+	t.Run("set url vars on static route", func(t *testing.T) {
+		router := NewRouter()
+		var got map[string]string
+		router.HandleFunc("/health", func(_ http.ResponseWriter, r *http.Request) {
+			r = SetURLVars(r, map[string]string{"id": "7"})
+			got = Vars(r)
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, map[string]string{"id": "7"}, got)
+	})
+
+	t.Run("custom matcher vars on static route", func(t *testing.T) {
+		router := NewRouter()
+		var got map[string]string
+		router.HandleFunc("/health", func(_ http.ResponseWriter, r *http.Request) {
+			got = Vars(r)
+		}).MatcherFunc(func(_ *http.Request, m *RouteMatch) bool {
+			m.Vars["tenant"] = "acme"
+			return true
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, map[string]string{"tenant": "acme"}, got)
+	})
 }
 
 func TestVarGet(t *testing.T) {
@@ -83,6 +127,132 @@ func TestVarGet(t *testing.T) {
 	}
 }
 
+func TestVarValues(t *testing.T) {
+	serve := func(t *testing.T, r *Router, target string) ([]string, map[string]string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		var match RouteMatch
+		require.True(t, r.Match(req, &match))
+		req = setRouteContext(req, match.Route, match.Vars)
+		return VarValues(req, "tags"), Vars(req)
+	}
+
+	t.Run("captures every value of a repeated key", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/items", func(http.ResponseWriter, *http.Request) {}).Queries("tag", "{tags}")
+
+		values, vars := serve(t, r, "/items?tag=a&tag=b")
+		assert.Equal(t, []string{"a", "b"}, values)
+		assert.Equal(t, "a", vars["tags"])
+	})
+
+	t.Run("skips values that do not match the pattern", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/items", func(http.ResponseWriter, *http.Request) {}).Queries("tag", "{tags:[a-z]+}")
+
+		values, vars := serve(t, r, "/items?tag=1&tag=a&tag=b")
+		assert.Equal(t, []string{"a", "b"}, values)
+		assert.Equal(t, "a", vars["tags"])
+	})
+
+	t.Run("captures from a subrouter's queries", func(t *testing.T) {
+		r := NewRouter()
+		s := r.PathPrefix("/api").Queries("tag", "{tags}").Subrouter()
+		s.HandleFunc("/items", func(http.ResponseWriter, *http.Request) {})
+
+		values, _ := serve(t, r, "/api/items?tag=x&tag=y")
+		assert.Equal(t, []string{"x", "y"}, values)
+	})
+
+	t.Run("dispatched handler", func(t *testing.T) {
+		r := NewRouter()
+		var got []string
+		r.HandleFunc("/items", func(_ http.ResponseWriter, req *http.Request) {
+			got = VarValues(req, "tags")
+		}).Queries("tag", "{tags}")
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items?tag=a&tag=b", nil))
+		assert.Equal(t, []string{"a", "b"}, got)
+	})
+
+	t.Run("non-query variable", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = setRouteContext(r, nil, map[string]string{"tags": "only"})
+
+		assert.Equal(t, []string{"only"}, VarValues(r, "tags"))
+		assert.Nil(t, VarValues(r, "missing"))
+	})
+
+	t.Run("request without route context", func(t *testing.T) {
+		assert.Nil(t, VarValues(httptest.NewRequest(http.MethodGet, "/", nil), "tags"))
+	})
+}
+
+func TestRequestPaths(t *testing.T) {
+	record := func(original, cleaned *string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*original, *cleaned = RequestPaths(r)
+			w.WriteHeader(http.StatusNotFound)
+		})
+	}
+
+	t.Run("dot segments in the 404 handler", func(t *testing.T) {
+		var original, cleaned string
+		r := NewRouter().SkipClean(true)
+		r.NotFoundHandler = record(&original, &cleaned)
+		r.HandleFunc("/docs/{page}", func(http.ResponseWriter, *http.Request) {})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/../missing//page", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "/docs/../missing//page", original)
+		assert.Equal(t, "/missing/page", cleaned)
+	})
+
+	t.Run("encoded path", func(t *testing.T) {
+		var original, cleaned string
+		r := NewRouter().SkipClean(true).UseEncodedPath()
+		r.NotFoundHandler = record(&original, &cleaned)
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a%2Fb/./c", nil))
+
+		assert.Equal(t, "/a%2Fb/./c", original)
+		assert.Equal(t, "/a%2Fb/c", cleaned)
+	})
+
+	t.Run("subrouter 404 handler", func(t *testing.T) {
+		var original, cleaned string
+		r := NewRouter().SkipClean(true)
+		api := r.PathPrefix("/api").Subrouter()
+		api.NotFoundHandler = record(&original, &cleaned)
+		api.HandleFunc("/users", func(http.ResponseWriter, *http.Request) {})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/./nope", nil))
+
+		assert.Equal(t, "/api/./nope", original)
+		assert.Equal(t, "/api/nope", cleaned)
+	})
+
+	t.Run("405 handler", func(t *testing.T) {
+		var original, cleaned string
+		r := NewRouter()
+		r.MethodNotAllowedHandler = record(&original, &cleaned)
+		r.HandleFunc("/users", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodPost)
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		assert.Equal(t, "/users", original)
+		assert.Equal(t, "/users", cleaned)
+	})
+
+	t.Run("outside a 404 derives from the request", func(t *testing.T) {
+		original, cleaned := RequestPaths(httptest.NewRequest(http.MethodGet, "/a/./b", nil))
+		assert.Equal(t, "/a/./b", original)
+		assert.Equal(t, "/a/b", cleaned)
+	})
+}
+
 func TestCurrentRoute(t *testing.T) {
 	t.Run("returns nil for request without route", func(t *testing.T) {
 		r := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -97,6 +267,94 @@ func TestCurrentRoute(t *testing.T) {
 		require.NotNil(t, result)
 		assert.Equal(t, route, result)
 	})
+
+	templateOf := func(r *http.Request) string {
+		route := CurrentRoute(r)
+		if route == nil {
+			return "<nil>"
+		}
+		tpl, _ := route.GetPathTemplate()
+		return tpl
+	}
+
+	t.Run("first middleware sees matched route", func(t *testing.T) {
+		router := NewRouter()
+		var seen []string
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = append(seen, "router "+templateOf(r))
+				next.ServeHTTP(w, r)
+			})
+		})
+		sub := router.PathPrefix("/api").Subrouter()
+		sub.HandleFunc("/users/{id}", func(_ http.ResponseWriter, r *http.Request) {
+			seen = append(seen, "handler "+templateOf(r))
+		}).Methods(http.MethodGet)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+		assert.Equal(t, []string{"router /api/users/{id}", "handler /api/users/{id}"}, seen)
+	})
+
+	t.Run("method not allowed exposes route", func(t *testing.T) {
+		router := NewRouter()
+		var mw, handler string
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mw = templateOf(r)
+				next.ServeHTTP(w, r)
+			})
+		})
+		router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler = templateOf(r)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		})
+		router.HandleFunc("/users/{id}", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodGet)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/1", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "/users/{id}", handler)
+		// Root-level 405 responses run no router middleware.
+		assert.Empty(t, mw)
+	})
+
+	t.Run("subrouter method not allowed exposes route", func(t *testing.T) {
+		router := NewRouter()
+		sub := router.PathPrefix("/api").Subrouter()
+		var mw, handler string
+		sub.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mw = templateOf(r)
+				next.ServeHTTP(w, r)
+			})
+		})
+		sub.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler = templateOf(r)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		})
+		sub.HandleFunc("/users/{id}", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodGet)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/users/1", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "/api/users/{id}", mw)
+		assert.Equal(t, "/api/users/{id}", handler)
+	})
+
+	t.Run("not found has no route", func(t *testing.T) {
+		router := NewRouter()
+		var handler string
+		router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler = templateOf(r)
+			w.WriteHeader(http.StatusNotFound)
+		})
+		router.HandleFunc("/users", func(http.ResponseWriter, *http.Request) {})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+		assert.Equal(t, "<nil>", handler)
+	})
 }
 
 func TestCurrentRouter(t *testing.T) {
@@ -131,6 +389,112 @@ func TestCurrentRouter(t *testing.T) {
 		router.ServeHTTP(w, req)
 		assert.Equal(t, sub, got)
 	})
+
+	t.Run("available to router middleware", func(t *testing.T) {
+		router := NewRouter()
+		sub := router.PathPrefix("/api").Subrouter()
+		var got *Router
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = CurrentRouter(r)
+				next.ServeHTTP(w, r)
+			})
+		})
+		sub.HandleFunc("/users", func(http.ResponseWriter, *http.Request) {})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+		assert.Equal(t, sub, got)
+	})
+
+	t.Run("subrouter prefix without matching route", func(t *testing.T) {
+		router := NewRouter()
+		sub := router.PathPrefix("/api").Subrouter()
+		sub.HandleFunc("/users", func(http.ResponseWriter, *http.Request) {})
+		var got *Router
+		router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = CurrentRouter(r)
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, sub, got)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+		assert.Equal(t, router, got)
+	})
+}
+
+func TestContextKey(t *testing.T) {
+	type user struct{ Name string }
+
+	t.Run("round trip", func(t *testing.T) {
+		key := NewContextKey[*user]("user")
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		got, ok := key.From(r)
+		assert.False(t, ok)
+		assert.Nil(t, got)
+
+		u := &user{Name: "alice"}
+		r = key.WithValue(r, u)
+		got, ok = key.From(r)
+		assert.True(t, ok)
+		assert.Same(t, u, got)
+
+		got, ok = key.FromContext(r.Context())
+		assert.True(t, ok)
+		assert.Same(t, u, got)
+	})
+
+	t.Run("zero value is present", func(t *testing.T) {
+		key := NewContextKey[int]("count")
+		r := key.WithValue(httptest.NewRequest(http.MethodGet, "/", nil), 0)
+		v, ok := key.From(r)
+		assert.True(t, ok)
+		assert.Equal(t, 0, v)
+	})
+
+	t.Run("keys with the same name do not collide", func(t *testing.T) {
+		a := NewContextKey[string]("id")
+		b := NewContextKey[string]("id")
+		r := a.WithValue(httptest.NewRequest(http.MethodGet, "/", nil), "from-a")
+
+		_, ok := b.From(r)
+		assert.False(t, ok)
+
+		r = b.WithValue(r, "from-b")
+		va, _ := a.From(r)
+		vb, _ := b.From(r)
+		assert.Equal(t, "from-a", va)
+		assert.Equal(t, "from-b", vb)
+	})
+
+	t.Run("set by middleware and read in handler", func(t *testing.T) {
+		key := NewContextKey[*user]("user")
+		router := NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, key.WithValue(r, &user{Name: "bob"}))
+			})
+		})
+		var name string
+		router.HandleFunc("/", func(_ http.ResponseWriter, r *http.Request) {
+			if u, ok := key.From(r); ok {
+				name = u.Name
+			}
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "bob", name)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "mux context key request-id", NewContextKey[string]("request-id").String())
+	})
 }
 
 func TestSetURLVars(t *testing.T) {
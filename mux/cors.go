@@ -0,0 +1,204 @@
+package mux
+
+import (
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSMethodOption configures CORSMethodMiddleware.
+type CORSMethodOption func(*corsMethodOptions)
+
+type corsMethodOptions struct {
+	maxAge time.Duration
+}
+
+// CORSMaxAge sets the Access-Control-Max-Age of preflight responses, the
+// time a browser may cache the result, in whole seconds. Zero omits the
+// header; a negative duration sends "0", which disables caching.
+func CORSMaxAge(d time.Duration) CORSMethodOption {
+	return func(o *corsMethodOptions) {
+		o.maxAge = d
+	}
+}
+
+// CORSMethodMiddleware answers CORS preflight requests, OPTIONS requests
+// with an Access-Control-Request-Method header, from the routes of r:
+//
+//   - Access-Control-Allow-Methods lists the methods of every route whose
+//     host, path, query, and scheme match the request, with HEAD wherever
+//     GET is declared.
+//   - Access-Control-Allow-Headers lists the headers required by those
+//     routes' header matchers together with the headers the browser asked
+//     for in Access-Control-Request-Headers.
+//   - Access-Control-Max-Age is set with CORSMaxAge.
+//
+// Header matchers are ignored when selecting routes, since a preflight
+// does not carry the headers of the actual request. When one of the routes
+// declares OPTIONS, its handler is called after the headers are set, so it
+// can complete the response; otherwise the middleware replies with 204 No
+// Content. Preflights for paths without routes, and all other requests,
+// pass through unchanged.
+//
+// The middleware does not check the Origin or set
+// Access-Control-Allow-Origin; add that in the OPTIONS handler or another
+// middleware, or use the CORS middleware of muxhandlers for complete CORS
+// handling. To answer preflights for routes that do not declare OPTIONS,
+// wrap the router itself, since Use only applies to matched routes:
+//
+//	handler := mux.CORSMethodMiddleware(r, mux.CORSMaxAge(time.Hour))(r)
+//	http.ListenAndServe(":8080", handler)
+func CORSMethodMiddleware(r *Router, opts ...CORSMethodOption) MiddlewareFunc {
+	var o corsMethodOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodOptions || req.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			methods, headers := preflightRoutes(r, req)
+			if len(methods) == 0 {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			for _, value := range req.Header.Values("Access-Control-Request-Headers") {
+				for name := range strings.SplitSeq(value, ",") {
+					if name = strings.TrimSpace(name); name != "" {
+						headers = append(headers, textproto.CanonicalMIMEHeaderKey(name))
+					}
+				}
+			}
+			slices.Sort(headers)
+			headers = slices.Compact(headers)
+
+			h := w.Header()
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			if len(headers) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			}
+			if o.maxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.FormatInt(int64(o.maxAge/time.Second), 10))
+			} else if o.maxAge < 0 {
+				h.Set("Access-Control-Max-Age", "0")
+			}
+
+			if slices.Contains(methods, http.MethodOptions) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// preflightRoutes returns the sorted methods declared by the routes of r
+// that match req apart from their method and header matchers, and the
+// header names those routes require.
+func preflightRoutes(r *Router, req *http.Request) (methods, headers []string) {
+	seenMethods := make(map[string]struct{})
+	seenHeaders := make(map[string]struct{})
+
+	_ = r.Walk(func(route *Route, _ *Router, ancestors []*Route) error {
+		if route.buildOnly || !route.matchesPreflight(req) {
+			return SkipRouter
+		}
+		if _, ok := route.handler.(*Router); ok {
+			return nil
+		}
+
+		var routeMethods []string
+		for _, m := range route.matchers {
+			switch mm := m.(type) {
+			case methodMatcher:
+				routeMethods = append(routeMethods, mm...)
+			case MethodsReporter:
+				routeMethods = append(routeMethods, mm.Methods()...)
+			}
+		}
+		if len(routeMethods) == 0 {
+			return nil
+		}
+		for _, method := range routeMethods {
+			seenMethods[method] = struct{}{}
+			if method == http.MethodGet {
+				seenMethods[http.MethodHead] = struct{}{}
+			}
+		}
+		for _, rt := range ancestors {
+			rt.collectHeaderNames(seenHeaders)
+		}
+		route.collectHeaderNames(seenHeaders)
+		return nil
+	})
+
+	if len(seenMethods) == 0 {
+		return nil, nil
+	}
+	for method := range seenMethods {
+		methods = append(methods, method)
+	}
+	for name := range seenHeaders {
+		headers = append(headers, name)
+	}
+	slices.Sort(methods)
+	return methods, headers
+}
+
+// collectHeaderNames adds the names of the headers required by the route's
+// header matchers to seen.
+func (r *Route) collectHeaderNames(seen map[string]struct{}) {
+	for _, m := range r.matchers {
+		switch mm := m.(type) {
+		case headerMatcher:
+			for name := range mm {
+				seen[name] = struct{}{}
+			}
+		case headerRegexMatcher:
+			for name := range mm {
+				seen[name] = struct{}{}
+			}
+		}
+	}
+}
+
+// matchesPreflight reports whether req matches the route's host, path,
+// query, scheme, and custom matchers. Method and header matchers are not
+// checked.
+func (r *Route) matchesPreflight(req *http.Request) bool {
+	if r.err != nil {
+		return false
+	}
+	var match RouteMatch
+	for _, m := range r.matchers {
+		switch m.(type) {
+		case methodMatcher, headerMatcher, headerRegexMatcher, MethodsReporter:
+			continue
+		}
+		if !m.Match(req, &match) {
+			return false
+		}
+	}
+	if r.regexp.host != nil && !r.regexp.host.Match(req, &match) {
+		return false
+	}
+	if r.regexp.path != nil && !r.regexp.path.Match(req, &match) {
+		return false
+	}
+	for _, q := range r.regexp.queries {
+		if !q.Match(req, &match) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMethodMiddleware(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	newRouter := func() *Router {
+		r := NewRouter()
+		r.HandleFunc("/users", noop).Methods(http.MethodGet, http.MethodPost)
+		r.HandleFunc("/users", noop).Methods(http.MethodPut).Headers("X-Api-Version", "2")
+		r.HandleFunc("/users/{id:[0-9]+}", noop).Methods(http.MethodDelete)
+		r.HandleFunc("/other", noop).Methods(http.MethodPatch)
+		return r
+	}
+
+	preflight := func(path, method, headers string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		return req
+	}
+
+	t.Run("answers preflight", func(t *testing.T) {
+		r := newRouter()
+		handler := CORSMethodMiddleware(r, CORSMaxAge(10*time.Minute))(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, preflight("/users", http.MethodPut, "content-type, x-request-id"))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "GET, HEAD, POST, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type, X-Api-Version, X-Request-Id", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, []string{"Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("path variables", func(t *testing.T) {
+		r := newRouter()
+		handler := CORSMethodMiddleware(r)(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, preflight("/users/42", http.MethodDelete, ""))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, preflight("/users/abc", http.MethodDelete, ""))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("negative max age disables caching", func(t *testing.T) {
+		r := newRouter()
+		handler := CORSMethodMiddleware(r, CORSMaxAge(-1))(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, preflight("/other", http.MethodPatch, ""))
+		assert.Equal(t, "0", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("subrouter headers and methods", func(t *testing.T) {
+		r := NewRouter()
+		api := r.PathPrefix("/api").Headers("X-Tenant", "").Subrouter()
+		api.HandleFunc("/items", noop).Methods(http.MethodPost)
+		api.HandleFunc("/items", noop).Methods(http.MethodOptions)
+		r.HandleFunc("/api/items", noop).Methods(http.MethodGet)
+		handler := CORSMethodMiddleware(r)(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, preflight("/api/items", http.MethodPost, ""))
+		assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "X-Tenant", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("options route completes the response", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/items", noop).Methods(http.MethodPut)
+		r.HandleFunc("/items", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+			w.WriteHeader(http.StatusOK)
+		}).Methods(http.MethodOptions)
+		r.Use(CORSMethodMiddleware(r, CORSMaxAge(time.Hour)))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preflight("/items", http.MethodPut, "Authorization"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "OPTIONS, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("other requests pass through", func(t *testing.T) {
+		r := newRouter()
+		handler := CORSMethodMiddleware(r, CORSMaxAge(time.Hour))(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/users", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, preflight("/missing", http.MethodGet, ""))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("build-only and methodless routes", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/any", noop)
+		r.HandleFunc("/built", noop).Methods(http.MethodGet).BuildOnly()
+		handler := CORSMethodMiddleware(r)(r)
+
+		for _, path := range []string{"/any", "/built"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, preflight(path, http.MethodGet, ""))
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"), path)
+		}
+	})
+}
+
+func BenchmarkCORSMethodMiddleware(b *testing.B) {
+	r := NewRouter()
+	for _, path := range []string{"/a", "/b", "/c", "/users/{id}", "/users/{id}/posts"} {
+		r.HandleFunc(path, func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodGet, http.MethodPost)
+	}
+	handler := CORSMethodMiddleware(r, CORSMaxAge(time.Hour))(r)
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/42/posts", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
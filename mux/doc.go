@@ -18,13 +18,22 @@
 //   - Path variables with optional regexp constraints
 //   - Host-based routing
 //   - Header and query matching
+//   - Protocol and TLS client certificate matching (Proto, RequireClientCert)
 //   - Custom matcher functions
 //   - Subrouters for route grouping
 //   - Inline subrouters (Route and Group) for closure-based route definitions
+//   - Mounting of independently built routers (Mount)
+//   - Read-only routing table after startup (Freeze)
+//   - Request rewrite hooks before matching (PreMatchHook)
+//   - Per-request timing and status events by matched route (Observer)
+//   - Per-route deadlines (Route.Timeout)
+//   - Replayable request bodies (BufferBody)
+//   - CORS preflight answers from registered routes (CORSMethodMiddleware)
 //   - Inline middleware (With) for declaring middleware at route-registration time
 //   - Middleware support
 //   - Reverse URL building
 //   - Walking registered routes
+//   - Detection of routes shadowed by earlier registrations (DetectConflicts)
 //
 // # Router
 //
@@ -48,6 +57,14 @@
 //	vars := mux.Vars(r)
 //	category := vars["category"]
 //
+// Values are percent-decoded. RawVars keeps the named variables in their
+// percent-encoded form instead, for values passed through to another URL;
+// such routes are matched against the escaped path, and URL building uses
+// their values as already encoded:
+//
+//	r.HandleFunc("/proxy/{token}", handler).RawVars("token")
+//	// GET /proxy/a%2Fb -> vars["token"] == "a%2Fb"
+//
 // # Pattern Macros
 //
 // Instead of writing full regex patterns, you can use named macros
@@ -95,11 +112,24 @@
 //	// Scheme matching
 //	r.HandleFunc("/secure", handler).Schemes("https")
 //
+//	// Protocol version and verified TLS client certificate matching
+//	r.HandleFunc("/stream", handler).Proto("HTTP/2.0")
+//	r.HandleFunc("/admin", handler).RequireClientCert()
+//
 //	// Custom matcher function
 //	r.HandleFunc("/custom", handler).MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
 //	    return r.Header.Get("X-Custom") != ""
 //	})
 //
+// Matchers are evaluated in a fixed order: the built-in Methods, Headers,
+// HeadersRegexp, Schemes, Proto, and TLSClientCert matchers, then the Host, Path, and Queries
+// templates, then custom matchers added with MatcherFunc or Matcher in
+// registration order. Custom matchers see the template variables in
+// RouteMatch.Vars, and variables they add are merged into the variables
+// returned by Vars. A custom matcher that constrains the request method can
+// implement MethodsReporter so its methods are listed in the Allow header of
+// 405 responses.
+//
 // # Subrouters
 //
 // Subrouters can be used to group routes under a common path prefix,
@@ -116,6 +146,12 @@
 //	s.NotFoundHandler = http.HandlerFunc(apiNotFoundHandler)
 //	s.HandleFunc("/users", UsersHandler)
 //
+// When a request falls into a subrouter's prefix but no route matches, the
+// NotFoundHandler or MethodNotAllowedHandler that answers it, whether the
+// subrouter's own or an ancestor's, is wrapped with the middleware of the
+// root router and of every subrouter down to the innermost one whose prefix
+// matched, in the same order as for a matched route.
+//
 // # Inline Subrouters
 //
 // Route and Group provide a closure-based API for defining sub-routes
@@ -174,6 +210,36 @@
 //	    })
 //	})
 //
+// # Mounting Routers
+//
+// Mount grafts a router built on its own, such as one returned by a feature
+// package, under a path prefix. The child becomes a subrouter: variables in
+// the prefix reach Vars, its named routes are registered with the parent,
+// Walk descends into it, and its middleware and error handlers apply as
+// for PathPrefix(prefix).Subrouter():
+//
+//	r.Mount("/orgs/{org}/billing", billing.Routes())
+//
+// StrictSlash, SkipClean, UseEncodedPath, and PlusInPathIsSpace enabled on
+// either router apply to the child's routes.
+//
+// # Concurrency and Freeze
+//
+// A Router serves requests from any number of goroutines, but registration
+// is not synchronized with ServeHTTP. Register every route before serving
+// and call Freeze to make the routing table read-only:
+//
+//	r.HandleFunc("/users", listUsers)
+//	r.Freeze()
+//
+// After Freeze, route factories such as HandleFunc and Mount return a
+// detached route whose GetError is ErrRouterFrozen, and Route.Subrouter
+// returns a detached, frozen router. Use, PreMatchHook, and
+// Route.Enabled(false) leave the router unchanged. Each rejected call is
+// logged. Subrouters and mounted routers are frozen along with their
+// parent. Routes registered before Freeze must not be reconfigured
+// afterwards.
+//
 // # Inline Middleware
 //
 // With creates a lightweight carrier that applies middleware to individual
@@ -237,7 +303,7 @@
 //
 // # Error Handling
 //
-// The Router provides two handler fields for error responses:
+// The Router provides five fields for error responses:
 //
 // NotFoundHandler is called when no route matches a request. If nil,
 // http.NotFoundHandler() is used. Corresponds to 404 Not Found per
@@ -247,8 +313,30 @@
 // the method. If nil, a default 405 handler is used. The Allow header is
 // always set before this handler is invoked, per RFC 9110 Section 15.5.6.
 //
+// VarMismatchHandler is called instead of NotFoundHandler when a request
+// matches a route except for the value of a path variable, such as
+// /users/abc for /users/{id:int}. It receives a *VarMismatchError naming
+// the route, the variable, and the value; VarMismatchBadRequest replies
+// with 400 Bad Request. If nil, such requests get the 404 response.
+//
+// ConnMismatchHandler is called instead of NotFoundHandler when a request
+// matches a route except for its Proto or TLSClientCert requirement. It
+// receives a *ConnMismatchError wrapping ErrProtoMismatch or
+// ErrClientCertMismatch; ConnMismatchReply replies with 426 Upgrade
+// Required or 403 Forbidden. If nil, such requests get the 404 response.
+//
+// PanicHandler is called when a panic is raised while matching a request,
+// for example inside a MatcherFunc or MetadataFunc. If nil, the panic is
+// logged and a 500 Internal Server Error is written. Panics raised by the
+// matched handler itself are not recovered by the router.
+//
 //	r.NotFoundHandler = http.HandlerFunc(custom404Handler)
 //	r.MethodNotAllowedHandler = http.HandlerFunc(custom405Handler)
+//	r.VarMismatchHandler = mux.VarMismatchBadRequest
+//	r.ConnMismatchHandler = mux.ConnMismatchReply
+//	r.PanicHandler = func(w http.ResponseWriter, req *http.Request, err any) {
+//	    http.Error(w, "internal error", http.StatusInternalServerError)
+//	}
 //
 // # Route Matching
 //
@@ -261,7 +349,11 @@
 //	}
 //
 // The RouteMatch.MatchErr field indicates the type of match failure:
-// ErrMethodMismatch for 405 errors and ErrNotFound for 404 errors.
+// ErrMethodMismatch for 405 errors and ErrNotFound for 404 errors. When a
+// route matched except for the value of a path variable, MatchErr is a
+// *VarMismatchError wrapping ErrNotFound. When a route matched except for
+// its protocol or TLS client certificate, MatchErr is a *ConnMismatchError
+// wrapping ErrNotFound and ErrProtoMismatch or ErrClientCertMismatch.
 //
 // # Context Functions
 //
@@ -269,13 +361,21 @@
 //
 //	vars := mux.Vars(r)
 //
+// Matching a route without variables does not allocate a map: RouteMatch.Vars
+// is left nil and Vars returns an empty map.
+//
 // VarGet returns a single route variable by name and a boolean indicating
 // whether it exists:
 //
 //	id, ok := mux.VarGet(r, "id")
 //
-// CurrentRoute returns the matched route for the current request. This only
-// works when called inside the handler of the matched route:
+// VarValues returns every value of a variable. For a Queries template on
+// a repeated key, such as ?tag=a&tag=b with Queries("tag", "{tags}"), it
+// returns each matching capture, while Vars and VarGet hold the first:
+//
+//	tags := mux.VarValues(r, "tags") // ["a", "b"]
+//
+// CurrentRoute returns the matched route for the current request:
 //
 //	route := mux.CurrentRoute(r)
 //	tpl, _ := route.GetPathTemplate()
@@ -285,6 +385,12 @@
 //
 //	router := mux.CurrentRouter(r)
 //
+// Both are stored in the request context right after matching, before any
+// router, subrouter, or route middleware runs, so even the first middleware
+// registered with Use can read the matched path template. For a 405
+// response CurrentRoute returns the first route whose path matched but
+// whose method did not; for a 404 it returns nil.
+//
 // Reverse builds a URL path for a named route from inside a handler, using
 // key/value pairs for route variables. Returns ErrNoRouterInContext if the
 // request has no router in context, or an error wrapping ErrRouteNotFound
@@ -301,11 +407,24 @@
 //
 //	scheme := mux.Scheme(r)
 //
+// RequestPaths returns the path an unmatched request was matched against
+// and its cleaned form, for logging in a NotFoundHandler or
+// MethodNotAllowedHandler:
+//
+//	original, cleaned := mux.RequestPaths(r)
+//
 // SetURLVars sets the URL variables for the given request, intended for
 // testing route handlers:
 //
 //	req = mux.SetURLVars(req, map[string]string{"id": "42"})
 //
+// ContextKey is a typed key for request-scoped values set by middleware.
+// Keys from NewContextKey never collide, even with the same name:
+//
+//	var userKey = mux.NewContextKey[*User]("user")
+//	r = userKey.WithValue(r, user)  // in middleware
+//	user, ok := userKey.From(r)     // in a handler
+//
 // # Middleware
 //
 // Middleware can be added to a router or subrouter to wrap matched handlers:
@@ -314,6 +433,13 @@
 //
 // Subrouter middleware is applied after parent router middleware.
 //
+// CORSMethodMiddleware answers CORS preflight requests with the methods and
+// header matchers of the routes matching the path, the requested headers,
+// and an optional Access-Control-Max-Age. Wrap the router so preflights for
+// routes without an OPTIONS method reach it:
+//
+//	handler := mux.CORSMethodMiddleware(r, mux.CORSMaxAge(time.Hour))(r)
+//
 // # URL Building
 //
 // Named routes support reverse URL building:
@@ -327,6 +453,12 @@
 //	hostURL, _ := route.URLHost("subdomain", "api")
 //	pathURL, _ := route.URLPath("category", "tech", "id", "42")
 //
+// Variable values are percent-encoded for their position (path segment,
+// query value), and host values must consist of host name characters.
+// URLWithQuery appends extra query parameters:
+//
+//	u, _ := r.Get("article").URLWithQuery(url.Values{"ref": {"home"}}, "category", "tech", "id", "42")
+//
 // # Route Inspection
 //
 // Routes expose methods to inspect their configuration:
@@ -349,6 +481,7 @@
 //	strictSlash := router.GetStrictSlash()       // trailing slash redirect
 //	skipClean := router.GetSkipClean()           // path cleaning disabled
 //	encodedPath := router.GetUseEncodedPath()    // percent-encoded matching
+//	plusIsSpace := router.GetPlusInPathIsSpace() // '+' in path variables read as space
 //
 // # Strict Slash
 //
@@ -371,6 +504,45 @@
 //
 //	r.UseEncodedPath()
 //
+// PlusInPathIsSpace stores a literal '+' in path variables as a space, for
+// legacy clients that form-encode paths, while %2B still decodes to '+'.
+// It behaves the same with and without UseEncodedPath, and built URLs
+// encode spaces as %20 and, with the option on, '+' as %2B:
+//
+//	r.PlusInPathIsSpace(true)
+//	// GET /search/foo+bar -> Vars: q="foo bar"
+//
+// # Pre-Match Hooks
+//
+// PreMatchHook registers hooks that rewrite the request at the top of
+// ServeHTTP, before path cleaning and matching, for example to strip an
+// ingress prefix or map a legacy version segment. Hooks run in order and
+// returning nil keeps the request. Cleaning (unless SkipClean) and
+// UseEncodedPath then apply to the rewritten path; a URL.RawPath that no
+// longer encodes the rewritten URL.Path is discarded. Hooks only run on the
+// router that serves the request.
+//
+// # Request Observer
+//
+// Router.Observer receives OnRequestStart and OnRequestEnd for every
+// request, with the matched route (nil for 404, 405, redirects, and
+// matching panics), the response status, body bytes, and duration. It
+// suits RED metrics labeled by route template. Hijacked connections are
+// reported at hijack time. The response writer is only wrapped when an
+// observer is set:
+//
+//	r.Observer = metrics{}
+//
+// # Route Timeouts
+//
+// Route.Timeout runs the route's handler and route middleware with a
+// context deadline. When it is exceeded, the buffered response is dropped
+// and Router.TimeoutHandler (inherited from parent routers, 503 Service
+// Unavailable by default) writes the response instead. Other routes are not
+// affected:
+//
+//	r.HandleFunc("/reports", buildReport).Timeout(3 * time.Second)
+//
 // # Request Binding
 //
 // BindJSON and BindXML decode a request body into a Go value. BindJSON
@@ -390,6 +562,43 @@
 //
 //	err := mux.BindJSON(r, &req, true)
 //
+// # Replayable Request Bodies
+//
+// BufferBody reads the request body so that middleware can inspect it and
+// the handler still reads the whole body. r.Body is replaced with a
+// replayable reader and the returned function opens fresh readers of the
+// same bytes. Bodies over the limit fail with ErrBodyTooLarge unless
+// SpillToFile writes them to a temporary file, which is removed when the
+// request context ends:
+//
+//	reread, err := mux.BufferBody(r, 1<<20)
+//	if err != nil {
+//	    http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+//	    return
+//	}
+//	body := reread()
+//
+// # Language Negotiation
+//
+// NegotiateLanguage returns the supported language tag that best matches
+// the Accept-Language header, using RFC 4647 basic filtering and quality
+// values, or the first supported tag when nothing matches:
+//
+//	lang := mux.NegotiateLanguage(r, "en", "fr", "de")
+//
+// # Conditional Requests
+//
+// Conditional evaluates the RFC 9110 preconditions against the current
+// entity tag and modification time, sets the ETag and Last-Modified
+// headers, and writes 304 Not Modified (GET and HEAD) or 412 Precondition
+// Failed when a precondition fails, reporting that the body must be
+// skipped:
+//
+//	if mux.Conditional(w, r, report.ETag, report.UpdatedAt) {
+//	    return
+//	}
+//	mux.ResponseJSON(w, http.StatusOK, report)
+//
 // # Response Helpers
 //
 // ResponseJSON and ResponseXML encode a value and write it to the response
@@ -406,6 +615,21 @@
 //	    mux.ResponseXML(w, http.StatusOK, data)
 //	}
 //
+// ResponseJSONOpts accepts JSONOptions for indented output, disabled HTML
+// escaping, or a custom marshal function; the zero value matches
+// ResponseJSON:
+//
+//	mux.ResponseJSONOpts(w, http.StatusOK, data, mux.JSONOptions{Indent: "  "})
+//
+// NewMultipartWriter starts a multipart/mixed response, setting the
+// Content-Type header with the boundary of the returned multipart.Writer.
+// Flush after each part to stream them:
+//
+//	mw := mux.NewMultipartWriter(w, mux.ContentTypeMultipartMixed)
+//	part, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json"}})
+//	part.Write(data)
+//	mw.Close()
+//
 // # HTML Template Responses
 //
 // SetTemplates registers parsed templates for use by ResponseHTML.
@@ -492,6 +716,15 @@
 //	r.HandleFunc("/old/{id}", handler).Name("old").BuildOnly()
 //	url, _ := r.Get("old").URL("id", "42")
 //
+// # Conditional Routes
+//
+// HandleFuncIf, HandleIf, and Route.Enabled register a route only when a
+// condition holds. A disabled route is removed from matching, Walk, and the
+// named-route map, so its name can be claimed by another route:
+//
+//	r.HandleFuncIf(cfg.Beta, "/beta", betaHandler).Methods(http.MethodGet)
+//	r.HandleFunc("/debug", debugHandler).Enabled(cfg.Debug)
+//
 // # Route Metadata
 //
 // Routes support arbitrary key-value metadata for attaching custom
@@ -527,6 +760,13 @@
 //	    lang := md["lang"]
 //	}
 //
+// # Route Documentation
+//
+// Doc stores a summary and description on a route for documentation
+// generators such as the openapi package; the router ignores them:
+//
+//	r.HandleFunc("/users", listUsers).Doc("List users", "Returns users ordered by creation time.")
+//
 // # Walking Routes
 //
 // Walk traverses the router and all its subrouters, calling a function for
@@ -540,4 +780,47 @@
 //
 // Return SkipRouter from the walk function to skip descending into a
 // subrouter.
+//
+// # Route Conflicts
+//
+// Routes are tried in registration order, so a route repeating an earlier
+// one never runs. DetectConflicts reports each route an earlier route makes
+// unreachable, with the methods involved; routes on the same path with
+// distinct methods are not conflicts:
+//
+//	for _, c := range r.DetectConflicts() {
+//	    log.Println(c) // mux: route "GET /users/{uid}" is unreachable for GET: ...
+//	}
+//
+// # Standard Library Patterns
+//
+// StdAdapter registers routes using net/http.ServeMux pattern syntax, so
+// handlers written for the standard library can be mounted unchanged. Each
+// pattern becomes an ordinary *Route: "GET /users/{id}" is translated to
+// Path("/users/{id}").Methods("GET"), "{path...}" to "{path:.*}", and a
+// trailing slash to PathPrefix. Handlers can read variables with either
+// Vars or http.Request.PathValue:
+//
+//	std := mux.StdAdapter(r)
+//	std.HandleFunc("GET /users/{id}", getUser)
+//	std.Handle("/static/", fileServer)
+//
+//	route, err := std.Register("DELETE /users/{id}", deleteUser)
+//	if err == nil {
+//	    route.Name("deleteUser")
+//	}
+//
+// Handle and HandleFunc panic on patterns that cannot be translated, like
+// ServeMux; Register returns an error wrapping ErrInvalidPattern instead.
+// Routes are tried in registration order, not by pattern specificity.
+//
+// # Testing
+//
+// The muxtest package starts an httptest.Server for a router with panic
+// recovery and request IDs, and provides JSONGet and JSONPost helpers that
+// encode the request, require a 2xx status, and decode the response:
+//
+//	srv := muxtest.NewServer(r)
+//	defer srv.Close()
+//	err := srv.JSONPost("/items", Item{Name: "first"}, &created)
 package mux
@@ -193,8 +193,9 @@ func allowedMethods(router *Router, req *http.Request) []string {
 }
 
 // collectCandidateMethods walks the router tree and gathers every
-// HTTP method declared by any route, descending into subrouters via the
-// route handlers. HEAD is added whenever GET is declared, per RFC 9110
+// HTTP method declared by any route, through Methods or a custom matcher
+// implementing MethodsReporter, descending into subrouters via the route
+// handlers. HEAD is added whenever GET is declared, per RFC 9110
 // Section 9.3.2.
 func collectCandidateMethods(router *Router) []string {
 	seen := make(map[string]struct{})
@@ -216,12 +217,17 @@ func collectCandidateMethodsInto(router *Router, seen map[string]struct{}) {
 		}
 		var hasGet bool
 		for _, m := range route.matchers {
-			if methods, ok := m.(methodMatcher); ok {
-				for _, method := range methods {
-					seen[method] = struct{}{}
-					if method == http.MethodGet {
-						hasGet = true
-					}
+			var methods []string
+			switch mm := m.(type) {
+			case methodMatcher:
+				methods = mm
+			case MethodsReporter:
+				methods = mm.Methods()
+			}
+			for _, method := range methods {
+				seen[method] = struct{}{}
+				if method == http.MethodGet {
+					hasGet = true
 				}
 			}
 		}
@@ -85,6 +85,11 @@ func (mr *MiddlewareRoute) MatcherFunc(f MatcherFunc) *Route {
 	return mr.newRoute().MatcherFunc(f)
 }
 
+// Matcher registers a new route with a custom matcher.
+func (mr *MiddlewareRoute) Matcher(m Matcher) *Route {
+	return mr.newRoute().Matcher(m)
+}
+
 // Name registers a new route with the given name.
 func (mr *MiddlewareRoute) Name(name string) *Route {
 	return mr.newRoute().Name(name)
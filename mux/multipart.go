@@ -0,0 +1,50 @@
+package mux
+
+import (
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// NewMultipartWriter starts a multipart response, such as a batch
+// download, and returns a writer for its parts. It sets the Content-Type
+// header to contentType with the boundary parameter of the returned
+// writer, keeping any other parameters, and does not write the status
+// line; call w.WriteHeader first to send a status other than 200. An
+// empty contentType means "multipart/mixed". It panics if contentType is
+// not a multipart media type.
+//
+// Close the writer to write the closing boundary. To stream parts as they
+// are produced, flush the response after each one:
+//
+//	mw := mux.NewMultipartWriter(w, mux.ContentTypeMultipartMixed)
+//	for _, file := range files {
+//	    part, err := mw.CreatePart(textproto.MIMEHeader{
+//	        "Content-Type":        {file.ContentType},
+//	        "Content-Disposition": {`attachment; filename="` + file.Name + `"`},
+//	    })
+//	    if err != nil {
+//	        return
+//	    }
+//	    part.Write(file.Data)
+//	    http.NewResponseController(w).Flush()
+//	}
+//	mw.Close()
+//
+// See: https://www.rfc-editor.org/rfc/rfc2046#section-5.1
+func NewMultipartWriter(w http.ResponseWriter, contentType string) *multipart.Writer {
+	if contentType == "" {
+		contentType = ContentTypeMultipartMixed
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		panic(fmt.Errorf("mux: NewMultipartWriter: %q is not a multipart media type", contentType))
+	}
+
+	mw := multipart.NewWriter(w)
+	params["boundary"] = mw.Boundary()
+	w.Header().Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	return mw
+}
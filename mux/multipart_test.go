@@ -0,0 +1,88 @@
+package mux
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMultipartWriter(t *testing.T) {
+	t.Run("sets boundary and writes parts", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mw := NewMultipartWriter(w, ContentTypeMultipartMixed)
+
+		for _, body := range []string{`{"id":1}`, "plain text"} {
+			part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain"}})
+			require.NoError(t, err)
+			_, err = io.WriteString(part, body)
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+
+		mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, ContentTypeMultipartMixed, mediaType)
+		assert.Equal(t, mw.Boundary(), params["boundary"])
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		reader := multipart.NewReader(w.Body, params["boundary"])
+		var bodies []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			data, err := io.ReadAll(part)
+			require.NoError(t, err)
+			bodies = append(bodies, string(data))
+		}
+		assert.Equal(t, []string{`{"id":1}`, "plain text"}, bodies)
+	})
+
+	t.Run("default media type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mw := NewMultipartWriter(w, "")
+
+		mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, ContentTypeMultipartMixed, mediaType)
+		assert.Equal(t, mw.Boundary(), params["boundary"])
+	})
+
+	t.Run("keeps other parameters", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mw := NewMultipartWriter(w, `multipart/related; type="application/json"; boundary=ignored`)
+
+		mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/related", mediaType)
+		assert.Equal(t, map[string]string{"type": "application/json", "boundary": mw.Boundary()}, params)
+	})
+
+	t.Run("status set before", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mw := NewMultipartWriter(w, ContentTypeMultipartMixed)
+		w.WriteHeader(http.StatusPartialContent)
+		require.NoError(t, mw.Close())
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "boundary=")
+	})
+
+	t.Run("rejects non-multipart media type", func(t *testing.T) {
+		assert.PanicsWithError(t, `mux: NewMultipartWriter: "application/json" is not a multipart media type`, func() {
+			NewMultipartWriter(httptest.NewRecorder(), ContentTypeApplicationJSON)
+		})
+		assert.Panics(t, func() {
+			NewMultipartWriter(httptest.NewRecorder(), "multipart/")
+		})
+	})
+}
@@ -0,0 +1,213 @@
+// Package muxtest provides an HTTP test server for integration tests of
+// mux routers.
+//
+// NewServer starts an httptest.Server serving the router behind the
+// middleware a production stack usually has (panic recovery and request
+// IDs), and adds JSON helpers that encode the request, check the status,
+// and decode the response:
+//
+//	srv := muxtest.NewServer(r)
+//	defer srv.Close()
+//
+//	var user User
+//	if err := srv.JSONGet("/users/1", &user); err != nil {
+//	    t.Fatal(err)
+//	}
+package muxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+	"github.com/vitalvas/kasper/muxhandlers"
+)
+
+// ErrUnexpectedStatus is wrapped by the *StatusError returned by the JSON
+// helpers when the response status is not 2xx.
+var ErrUnexpectedStatus = errors.New("muxtest: unexpected status")
+
+// StatusError reports a response with a non-2xx status. Body holds the
+// response body for diagnostics.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("muxtest: %s %s: unexpected status %d: %s",
+		e.Method, e.Path, e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *StatusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// Option configures NewServer.
+type Option func(*config)
+
+type config struct {
+	recovery   bool
+	requestID  bool
+	tls        bool
+	middleware []mux.MiddlewareFunc
+	header     http.Header
+}
+
+// WithoutRecovery disables the panic recovery middleware, so a handler
+// panic reaches the test server.
+func WithoutRecovery() Option {
+	return func(c *config) {
+		c.recovery = false
+	}
+}
+
+// WithoutRequestID disables the request ID middleware.
+func WithoutRequestID() Option {
+	return func(c *config) {
+		c.requestID = false
+	}
+}
+
+// WithMiddleware wraps the router with additional middleware, inside the
+// default ones. The first middleware is the outermost.
+func WithMiddleware(mwf ...mux.MiddlewareFunc) Option {
+	return func(c *config) {
+		c.middleware = append(c.middleware, mwf...)
+	}
+}
+
+// WithTLS starts the server with TLS; Client trusts its certificate.
+func WithTLS() Option {
+	return func(c *config) {
+		c.tls = true
+	}
+}
+
+// WithHeader adds a header sent with every request made by the JSON
+// helpers, such as Authorization.
+func WithHeader(name, value string) Option {
+	return func(c *config) {
+		c.header.Add(name, value)
+	}
+}
+
+// Server is an httptest.Server serving a mux router, with JSON request
+// helpers.
+type Server struct {
+	*httptest.Server
+
+	header http.Header
+}
+
+// NewServer starts a test server for r. Unless disabled with options, the
+// router is wrapped with muxhandlers.RequestIDMiddleware (trusting an
+// incoming X-Request-ID) and muxhandlers.RecoverJSONMiddleware, which
+// wrap unmatched requests too. The caller must call Close.
+func NewServer(r *mux.Router, opts ...Option) *Server {
+	cfg := config{
+		recovery:  true,
+		requestID: true,
+		header:    make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var handler http.Handler = r
+	for i := len(cfg.middleware) - 1; i >= 0; i-- {
+		handler = cfg.middleware[i](handler)
+	}
+	if cfg.recovery {
+		handler = muxhandlers.RecoverJSONMiddleware(muxhandlers.RecoveryConfig{})(handler)
+	}
+	if cfg.requestID {
+		handler = muxhandlers.RequestIDMiddleware(muxhandlers.RequestIDConfig{TrustIncoming: true})(handler)
+	}
+
+	s := &Server{header: cfg.header}
+	if cfg.tls {
+		s.Server = httptest.NewTLSServer(handler)
+	} else {
+		s.Server = httptest.NewServer(handler)
+	}
+	return s
+}
+
+// JSONGet sends a GET request for path and decodes the JSON response into
+// out, which may be nil to discard the body. It returns a *StatusError for
+// a non-2xx response.
+func (s *Server) JSONGet(path string, out any) error {
+	return s.JSON(http.MethodGet, path, nil, out)
+}
+
+// JSONPost sends body encoded as JSON in a POST request to path and
+// decodes the JSON response into out, which may be nil. It returns a
+// *StatusError for a non-2xx response.
+func (s *Server) JSONPost(path string, body, out any) error {
+	return s.JSON(http.MethodPost, path, body, out)
+}
+
+// JSON sends a request with the given method to path. A non-nil body is
+// encoded as JSON; the response is decoded into out unless out is nil or
+// the response has no content. It returns a *StatusError for a non-2xx
+// response.
+func (s *Server) JSON(method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("muxtest: encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("muxtest: %w", err)
+	}
+	for name, values := range s.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", mux.ContentTypeApplicationJSON)
+	if body != nil {
+		req.Header.Set("Content-Type", mux.ContentTypeApplicationJSON)
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		return fmt.Errorf("muxtest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("muxtest: read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       data,
+		}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("muxtest: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package muxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitalvas/kasper/mux"
+	"github.com/vitalvas/kasper/muxhandlers"
+)
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/items/{id:[0-9]+}", func(w http.ResponseWriter, _ *http.Request) {
+		mux.ResponseJSON(w, http.StatusOK, item{ID: 1, Name: "first"})
+	}).Methods(http.MethodGet)
+
+	r.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		var in item
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			mux.ResponseJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		in.ID = 2
+		mux.ResponseJSON(w, http.StatusCreated, in)
+	}).Methods(http.MethodPost)
+
+	r.HandleFunc("/request-id", func(w http.ResponseWriter, r *http.Request) {
+		mux.ResponseJSON(w, http.StatusOK, map[string]string{
+			"id":     muxhandlers.RequestIDFromContext(r.Context()),
+			"header": r.Header.Get("X-Test"),
+		})
+	}).Methods(http.MethodGet)
+
+	r.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	r.HandleFunc("/empty", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPost)
+
+	return r
+}
+
+func TestServer(t *testing.T) {
+	t.Run("json get round trip", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		var got item
+		require.NoError(t, srv.JSONGet("/items/1", &got))
+		assert.Equal(t, item{ID: 1, Name: "first"}, got)
+	})
+
+	t.Run("json post round trip", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		var got item
+		require.NoError(t, srv.JSONPost("/items", item{Name: "second"}, &got))
+		assert.Equal(t, item{ID: 2, Name: "second"}, got)
+	})
+
+	t.Run("nil out and no content", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		assert.NoError(t, srv.JSONGet("/items/1", nil))
+		var got item
+		assert.NoError(t, srv.JSONPost("/empty", nil, &got))
+		assert.Zero(t, got)
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		err := srv.JSONGet("/missing", nil)
+		require.ErrorIs(t, err, ErrUnexpectedStatus)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, http.MethodGet, statusErr.Method)
+		assert.Equal(t, "/missing", statusErr.Path)
+		assert.Contains(t, err.Error(), "unexpected status 404")
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		var got []string
+		err := srv.JSONGet("/items/1", &got)
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "muxtest: decode response"))
+	})
+
+	t.Run("encode error", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		err := srv.JSONPost("/items", make(chan int), nil)
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "muxtest: encode request"))
+	})
+
+	t.Run("recovery by default", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		resp, err := srv.Client().Get(srv.URL + "/panic")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+		assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+	})
+
+	t.Run("request id by default", func(t *testing.T) {
+		srv := NewServer(newTestRouter())
+		defer srv.Close()
+
+		var got map[string]string
+		require.NoError(t, srv.JSONGet("/request-id", &got))
+		assert.NotEmpty(t, got["id"])
+
+		resp, err := srv.Client().Get(srv.URL + "/missing")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+	})
+
+	t.Run("without request id", func(t *testing.T) {
+		srv := NewServer(newTestRouter(), WithoutRequestID())
+		defer srv.Close()
+
+		var got map[string]string
+		require.NoError(t, srv.JSONGet("/request-id", &got))
+		assert.Empty(t, got["id"])
+	})
+
+	t.Run("without recovery", func(t *testing.T) {
+		var recovered any
+		catch := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer func() {
+					if recovered = recover(); recovered != nil {
+						w.WriteHeader(http.StatusTeapot)
+					}
+				}()
+				next.ServeHTTP(w, r)
+			})
+		}
+
+		srv := NewServer(newTestRouter(), WithoutRecovery(), WithMiddleware(catch))
+		defer srv.Close()
+
+		err := srv.JSONGet("/panic", nil)
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusTeapot, statusErr.StatusCode)
+		assert.Equal(t, "boom", recovered)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		srv := NewServer(newTestRouter(), WithHeader("X-Test", "value"), WithHeader("X-Request-ID", "fixed"))
+		defer srv.Close()
+
+		var got map[string]string
+		require.NoError(t, srv.JSONGet("/request-id", &got))
+		assert.Equal(t, "value", got["header"])
+		assert.Equal(t, "fixed", got["id"])
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		srv := NewServer(newTestRouter(), WithTLS())
+		defer srv.Close()
+
+		assert.True(t, strings.HasPrefix(srv.URL, "https://"))
+		var got item
+		require.NoError(t, srv.JSONGet("/items/1", &got))
+		assert.Equal(t, 1, got.ID)
+	})
+}
@@ -0,0 +1,111 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NegotiateLanguage returns the supported language tag that best matches
+// the request's Accept-Language header (RFC 9110 Section 12.5.4).
+//
+// Language ranges are matched with basic filtering (RFC 4647 Section
+// 3.3.1): a range matches a tag equal to it or starting with it followed
+// by "-", compared case-insensitively, and "*" matches any tag. Each
+// supported tag takes the quality of the longest range matching it, so
+// "en-US,en;q=0.8" gives "en-US" quality 1 and "en-GB" quality 0.8. The tag
+// with the highest non-zero quality wins, ties going to the tag listed
+// first in supported.
+//
+// When the header is absent or matches no supported tag, the first
+// supported tag is returned. It returns "" when supported is empty.
+func NegotiateLanguage(r *http.Request, supported ...string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+
+	ranges := parseAcceptLanguage(r.Header.Values("Accept-Language"))
+	if len(ranges) == 0 {
+		return supported[0]
+	}
+
+	best := supported[0]
+	bestQuality := 0.0
+	for _, tag := range supported {
+		if q := languageQuality(tag, ranges); q > bestQuality {
+			best, bestQuality = tag, q
+		}
+	}
+	return best
+}
+
+// languageRange is a single entry of an Accept-Language header.
+type languageRange struct {
+	tag     string
+	quality float64
+}
+
+// parseAcceptLanguage parses Accept-Language header values into language
+// ranges with quality values. Entries with an invalid quality are ignored.
+func parseAcceptLanguage(values []string) []languageRange {
+	var ranges []languageRange
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, ",") {
+			tag, params, _ := strings.Cut(part, ";")
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				continue
+			}
+
+			quality := 1.0
+			valid := true
+			for param := range strings.SplitSeq(params, ";") {
+				name, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "q") {
+					continue
+				}
+				q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil || q < 0 || q > 1 {
+					valid = false
+				}
+				quality = q
+				break
+			}
+			if valid {
+				ranges = append(ranges, languageRange{tag: tag, quality: quality})
+			}
+		}
+	}
+	return ranges
+}
+
+// languageQuality returns the quality of the longest range matching tag,
+// with "*" the least specific, or 0 when no range matches.
+func languageQuality(tag string, ranges []languageRange) float64 {
+	quality := 0.0
+	specificity := -1
+	for _, lr := range ranges {
+		var length int
+		switch {
+		case lr.tag == "*":
+			length = 0
+		case matchLanguageRange(lr.tag, tag):
+			length = len(lr.tag)
+		default:
+			continue
+		}
+		if length > specificity {
+			quality, specificity = lr.quality, length
+		}
+	}
+	return quality
+}
+
+// matchLanguageRange reports whether the language range matches tag under
+// RFC 4647 basic filtering.
+func matchLanguageRange(languageRange, tag string) bool {
+	if len(tag) < len(languageRange) || !strings.EqualFold(tag[:len(languageRange)], languageRange) {
+		return false
+	}
+	return len(tag) == len(languageRange) || tag[len(languageRange)] == '-'
+}
@@ -0,0 +1,86 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	newRequest := func(values ...string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, v := range values {
+			req.Header.Add("Accept-Language", v)
+		}
+		return req
+	}
+
+	t.Run("weighted header against supported sets", func(t *testing.T) {
+		const header = "en-US,en;q=0.8,fr;q=0.5"
+		tests := []struct {
+			supported []string
+			want      string
+		}{
+			{[]string{"en-US", "en", "fr"}, "en-US"},
+			{[]string{"fr", "en"}, "en"},
+			{[]string{"fr", "en-GB"}, "en-GB"},
+			{[]string{"de", "fr"}, "fr"},
+			{[]string{"fr-CA", "de"}, "fr-CA"},
+			{[]string{"de", "es"}, "de"},
+			{[]string{"en-us"}, "en-us"},
+			{[]string{"de", "EN"}, "EN"},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.want, NegotiateLanguage(newRequest(header), tt.supported...), "supported %v", tt.supported)
+		}
+	})
+
+	t.Run("more specific range wins", func(t *testing.T) {
+		req := newRequest("en;q=0.9,en-GB;q=0.1")
+		assert.Equal(t, "en-US", NegotiateLanguage(req, "en-GB", "en-US"))
+	})
+
+	t.Run("range does not match shorter tag", func(t *testing.T) {
+		assert.Equal(t, "de", NegotiateLanguage(newRequest("en-US"), "de", "en"))
+	})
+
+	t.Run("prefix must end at subtag boundary", func(t *testing.T) {
+		assert.Equal(t, "de", NegotiateLanguage(newRequest("en"), "de", "eng"))
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		assert.Equal(t, "de", NegotiateLanguage(newRequest("fr,*;q=0.1"), "de", "es"))
+		assert.Equal(t, "es", NegotiateLanguage(newRequest("*;q=0.5,es"), "de", "es"))
+		assert.Equal(t, "es", NegotiateLanguage(newRequest("*,de;q=0"), "de", "es"))
+	})
+
+	t.Run("zero quality excludes", func(t *testing.T) {
+		assert.Equal(t, "de", NegotiateLanguage(newRequest("en;q=0"), "de", "en"))
+		assert.Equal(t, "en", NegotiateLanguage(newRequest("en-GB;q=0,en"), "en-GB", "en"))
+	})
+
+	t.Run("ties keep supported order", func(t *testing.T) {
+		assert.Equal(t, "fr", NegotiateLanguage(newRequest("de;q=0.5,fr;q=0.5"), "fr", "de"))
+	})
+
+	t.Run("multiple header fields", func(t *testing.T) {
+		assert.Equal(t, "fr", NegotiateLanguage(newRequest("de;q=0.2", "fr;q=0.7"), "de", "fr"))
+	})
+
+	t.Run("invalid quality ignored", func(t *testing.T) {
+		assert.Equal(t, "de", NegotiateLanguage(newRequest("fr;q=abc,de;q=0.3"), "fr", "de"))
+		assert.Equal(t, "de", NegotiateLanguage(newRequest("fr;q=2,de;q=0.3"), "fr", "de"))
+		assert.Equal(t, "fr", NegotiateLanguage(newRequest(" fr ; Q=0.4 , ,de;q=0.3"), "de", "fr"))
+	})
+
+	t.Run("absent header", func(t *testing.T) {
+		assert.Equal(t, "en", NegotiateLanguage(newRequest(), "en", "fr"))
+		assert.Equal(t, "en", NegotiateLanguage(newRequest(""), "en", "fr"))
+	})
+
+	t.Run("no supported", func(t *testing.T) {
+		assert.Equal(t, "", NegotiateLanguage(newRequest("en")))
+	})
+}
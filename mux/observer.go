@@ -0,0 +1,188 @@
+package mux
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Observer receives request lifecycle events from a Router, for example to
+// record rate, error, and duration metrics labeled by route template.
+//
+// OnRequestStart is called once the request has been matched, right before
+// the handler chain (router and route middleware included) runs. route is
+// the matched route, or nil when the request is answered without one: 404
+// and 405 responses, path-cleaning redirects, "OPTIONS *", and panics while
+// matching. The request carries the route context, so CurrentRoute and Vars
+// work as in a handler.
+//
+// OnRequestEnd is called exactly once per OnRequestStart with the response
+// status code, the number of body bytes written, and the time elapsed since
+// the router received the request. status is 200 when the handler wrote
+// nothing. When the handler hijacks the connection, OnRequestEnd is called
+// at hijack time with the status and bytes written so far, status being 0
+// when none was written, since the router cannot see what is sent on the
+// hijacked connection. When the handler panics, it is
+// called with status 500 if no status was written, before the panic
+// continues up the stack.
+//
+// Both methods run on the request goroutine and must be safe for
+// concurrent use across requests.
+//
+//	r.Observer = metricsObserver{}
+//
+//	func (metricsObserver) OnRequestEnd(r *http.Request, route *mux.Route, status int, bytes int64, d time.Duration) {
+//	    tpl := "unmatched"
+//	    if route != nil {
+//	        tpl, _ = route.GetPathTemplate()
+//	    }
+//	    requestDuration.WithLabelValues(r.Method, tpl, strconv.Itoa(status)).Observe(d.Seconds())
+//	}
+type Observer interface {
+	OnRequestStart(r *http.Request, route *Route)
+	OnRequestEnd(r *http.Request, route *Route, status int, bytes int64, duration time.Duration)
+}
+
+// observation tracks a single request for an Observer. Its methods are
+// no-ops on a nil receiver, so the router only pays for it when an
+// Observer is set.
+type observation struct {
+	observer Observer
+	started  time.Time
+
+	req    *http.Request
+	route  *Route
+	writer *observedWriter
+
+	begun    bool
+	ended    bool
+	returned bool
+}
+
+// begin reports OnRequestStart and returns w wrapped to capture the status
+// and byte count. Calls after the first return w unchanged.
+func (o *observation) begin(w http.ResponseWriter, req *http.Request, route *Route) http.ResponseWriter {
+	if o == nil || o.begun {
+		return w
+	}
+
+	o.begun = true
+	o.req = req
+	o.route = route
+	o.observer.OnRequestStart(req, route)
+
+	base, wrapped := observeWriter(w, o)
+	o.writer = base
+	return wrapped
+}
+
+// end reports OnRequestEnd once.
+func (o *observation) end(status int) {
+	if o.ended {
+		return
+	}
+	o.ended = true
+	o.observer.OnRequestEnd(o.req, o.route, status, o.writer.bytes, time.Since(o.started))
+}
+
+// finish is deferred by ServeHTTP. It reports the end of a request that
+// was not hijacked, with status 500 when the handler panicked before
+// writing a status.
+func (o *observation) finish() {
+	if !o.begun {
+		return
+	}
+
+	status := o.writer.status
+	if status == 0 {
+		status = http.StatusOK
+		if !o.returned {
+			status = http.StatusInternalServerError
+		}
+	}
+	o.end(status)
+}
+
+// observedWriter captures the status code and body size of a response.
+type observedWriter struct {
+	http.ResponseWriter
+	obs    *observation
+	status int
+	bytes  int64
+}
+
+func (w *observedWriter) WriteHeader(code int) {
+	// Informational responses are not final; keep waiting for the
+	// final status, as net/http does.
+	if w.status == 0 && (code < 100 || code > 199 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *observedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter so
+// http.ResponseController can reach it.
+func (w *observedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *observedWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *observedWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.obs.end(w.status)
+	}
+	return conn, brw, err
+}
+
+// The observed writer variants expose exactly the optional interfaces
+// (Flusher, Hijacker) of the wrapped writer, so type assertions in
+// handlers behave as they would on the bare net/http writer.
+
+type observedFW struct{ *observedWriter }
+
+func (w observedFW) Flush() { w.flush() }
+
+type observedHW struct{ *observedWriter }
+
+func (w observedHW) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+type observedFHW struct{ *observedWriter }
+
+func (w observedFHW) Flush() { w.flush() }
+
+func (w observedFHW) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+// observeWriter wraps w for obs and returns the recorder alongside the
+// writer to hand to the handler.
+func observeWriter(w http.ResponseWriter, obs *observation) (*observedWriter, http.ResponseWriter) {
+	base := &observedWriter{ResponseWriter: w, obs: obs}
+	_, flush := w.(http.Flusher)
+	_, hijack := w.(http.Hijacker)
+	switch {
+	case flush && hijack:
+		return base, observedFHW{base}
+	case flush:
+		return base, observedFW{base}
+	case hijack:
+		return base, observedHW{base}
+	default:
+		return base, base
+	}
+}
@@ -0,0 +1,287 @@
+package mux
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type observedEvent struct {
+	start    bool
+	template string
+	status   int
+	bytes    int64
+	duration time.Duration
+}
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []observedEvent
+}
+
+func (o *recordingObserver) OnRequestStart(_ *http.Request, route *Route) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, observedEvent{start: true, template: observedTemplate(route)})
+}
+
+func (o *recordingObserver) OnRequestEnd(_ *http.Request, route *Route, status int, bytes int64, duration time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, observedEvent{template: observedTemplate(route), status: status, bytes: bytes, duration: duration})
+}
+
+func (o *recordingObserver) recorded() []observedEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]observedEvent(nil), o.events...)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) OnRequestStart(*http.Request, *Route) {}
+
+func (nopObserver) OnRequestEnd(*http.Request, *Route, int, int64, time.Duration) {}
+
+func observedTemplate(route *Route) string {
+	if route == nil {
+		return ""
+	}
+	tpl, _ := route.GetPathTemplate()
+	return tpl
+}
+
+func TestRouterObserver(t *testing.T) {
+	newRouter := func() (*Router, *recordingObserver) {
+		obs := &recordingObserver{}
+		r := NewRouter()
+		r.Observer = obs
+		r.HandleFunc("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("hello"))
+		}).Methods(http.MethodGet)
+		r.HandleFunc("/empty", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodGet)
+		return r, obs
+	}
+
+	serve := func(r *Router, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	t.Run("matched route", func(t *testing.T) {
+		r, obs := newRouter()
+		w := serve(r, http.MethodGet, "/users/42")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "hello", w.Body.String())
+
+		events := obs.recorded()
+		require.Len(t, events, 2)
+		assert.Equal(t, observedEvent{start: true, template: "/users/{id}"}, events[0])
+		assert.Equal(t, "/users/{id}", events[1].template)
+		assert.Equal(t, http.StatusCreated, events[1].status)
+		assert.Equal(t, int64(5), events[1].bytes)
+		assert.Positive(t, events[1].duration)
+	})
+
+	t.Run("implicit ok", func(t *testing.T) {
+		r, obs := newRouter()
+		serve(r, http.MethodGet, "/empty")
+
+		events := obs.recorded()
+		require.Len(t, events, 2)
+		assert.Equal(t, http.StatusOK, events[1].status)
+		assert.Zero(t, events[1].bytes)
+	})
+
+	t.Run("unmatched requests have nil route", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			status int
+		}{
+			{"not found", http.MethodGet, "/missing", http.StatusNotFound},
+			{"method not allowed", http.MethodPost, "/users/42", http.StatusMethodNotAllowed},
+			{"clean path redirect", http.MethodGet, "/users/../empty", http.StatusPermanentRedirect},
+			{"options asterisk", http.MethodOptions, "*", http.StatusNoContent},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r, obs := newRouter()
+				req := httptest.NewRequest(tt.method, "/", nil)
+				req.URL.Path = tt.path
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				assert.Equal(t, tt.status, w.Code)
+
+				events := obs.recorded()
+				require.Len(t, events, 2)
+				assert.Equal(t, observedEvent{start: true}, events[0])
+				assert.Empty(t, events[1].template)
+				assert.Equal(t, tt.status, events[1].status)
+			})
+		}
+	})
+
+	t.Run("subrouter route", func(t *testing.T) {
+		obs := &recordingObserver{}
+		r := NewRouter()
+		r.Observer = obs
+		api := r.PathPrefix("/api").Subrouter()
+		api.HandleFunc("/items/{id}", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		serve(r, http.MethodGet, "/api/items/1")
+		serve(r, http.MethodGet, "/api/missing")
+
+		events := obs.recorded()
+		require.Len(t, events, 4)
+		assert.Equal(t, "/api/items/{id}", events[1].template)
+		assert.Equal(t, http.StatusAccepted, events[1].status)
+		assert.Empty(t, events[3].template)
+		assert.Equal(t, http.StatusNotFound, events[3].status)
+	})
+
+	t.Run("route visible to middleware", func(t *testing.T) {
+		r, obs := newRouter()
+		var fromMiddleware string
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fromMiddleware = observedTemplate(CurrentRoute(req))
+				w.Header().Set("X-Middleware", "1")
+				next.ServeHTTP(w, req)
+			})
+		})
+
+		w := serve(r, http.MethodGet, "/users/7")
+		assert.Equal(t, "1", w.Header().Get("X-Middleware"))
+		assert.Equal(t, "/users/{id}", fromMiddleware)
+		assert.Len(t, obs.recorded(), 2)
+	})
+
+	t.Run("handler panic", func(t *testing.T) {
+		r, obs := newRouter()
+		r.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		})
+
+		assert.PanicsWithValue(t, "boom", func() {
+			serve(r, http.MethodGet, "/panic")
+		})
+
+		events := obs.recorded()
+		require.Len(t, events, 2)
+		assert.Equal(t, "/panic", events[1].template)
+		assert.Equal(t, http.StatusInternalServerError, events[1].status)
+	})
+
+	t.Run("matcher panic", func(t *testing.T) {
+		r, obs := newRouter()
+		r.MatcherFunc(func(*http.Request, *RouteMatch) bool {
+			panic("bad matcher")
+		}).HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+		w := serve(r, http.MethodGet, "/other")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		events := obs.recorded()
+		require.Len(t, events, 2)
+		assert.Empty(t, events[1].template)
+		assert.Equal(t, http.StatusInternalServerError, events[1].status)
+	})
+
+	t.Run("optional interfaces preserved", func(t *testing.T) {
+		r, _ := newRouter()
+		var flusher, hijacker bool
+		r.HandleFunc("/iface", func(w http.ResponseWriter, _ *http.Request) {
+			_, flusher = w.(http.Flusher)
+			_, hijacker = w.(http.Hijacker)
+			assert.NoError(t, http.NewResponseController(w).Flush())
+		})
+
+		serve(r, http.MethodGet, "/iface")
+		assert.True(t, flusher)
+		assert.False(t, hijacker)
+	})
+
+	t.Run("hijacked connection", func(t *testing.T) {
+		r, obs := newRouter()
+		released := make(chan struct{})
+		r.HandleFunc("/upgrade", func(w http.ResponseWriter, _ *http.Request) {
+			conn, brw, err := http.NewResponseController(w).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+			_ = brw.Flush()
+			<-released
+		})
+
+		srv := httptest.NewServer(r)
+		defer srv.Close()
+		defer close(released)
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte("GET /upgrade HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+		require.NoError(t, err)
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		// The end event is reported at hijack time, while the handler
+		// still holds the connection.
+		events := obs.recorded()
+		require.Len(t, events, 2)
+		assert.Equal(t, "/upgrade", events[1].template)
+		assert.Zero(t, events[1].status)
+	})
+
+	t.Run("no observer", func(t *testing.T) {
+		r, _ := newRouter()
+		r.Observer = nil
+		var unwrapped bool
+		r.HandleFunc("/writer", func(w http.ResponseWriter, _ *http.Request) {
+			_, unwrapped = w.(*httptest.ResponseRecorder)
+		})
+
+		serve(r, http.MethodGet, "/writer")
+		assert.True(t, unwrapped, "writer passed through unchanged")
+	})
+}
+
+func BenchmarkRouterObserver(b *testing.B) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+
+	b.Run("without observer", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+
+	b.Run("with observer", func(b *testing.B) {
+		r.Observer = nopObserver{}
+		defer func() { r.Observer = nil }()
+		b.ReportAllocs()
+		for b.Loop() {
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+}
@@ -30,14 +30,28 @@ type routeRegexp struct {
 	strictSlash bool
 	// useEncodedPath indicates using encoded path for matching.
 	useEncodedPath bool
+	// plusAsSpace translates '+' in path variable values to space (see
+	// Router.PlusInPathIsSpace).
+	plusAsSpace bool
+	// rawVars names the path variables delivered percent-encoded (see
+	// Route.RawVars). When set, the escaped path is matched.
+	rawVars map[string]bool
 	// regexp is the compiled regular expression.
 	regexp *regexp.Regexp
 	// reverse is the template with %s placeholders for Sprintf.
 	reverse string
+	// rawReverse is reverse with the literal parts percent-encoded, for
+	// building url.URL.RawPath.
+	rawReverse string
 	// varsN are the variable names in order.
 	varsN []string
 	// varsR are the compiled matchers for validating each variable value.
 	varsR []varMatcher
+	// loose matches the same template with each variable that has its own
+	// pattern also accepting any path segment. It is set for path templates
+	// with such variables, to find requests that fail only a variable's
+	// pattern (see mismatchedVar).
+	loose *regexp.Regexp
 	// needsVarValidation is true when any varsR entry enforces constraints
 	// beyond regex (e.g. length limits), requiring per-variable validation
 	// during route matching.
@@ -55,6 +69,7 @@ type routeRegexp struct {
 type routeRegexpOptions struct {
 	strictSlash    bool
 	useEncodedPath bool
+	plusAsSpace    bool
 }
 
 // newRouteRegexp parses a route template and returns a compiled routeRegexp.
@@ -92,14 +107,29 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 
 	var (
 		pattern  strings.Builder
+		loose    strings.Builder
 		reverse  strings.Builder
+		rawRev   strings.Builder
 		varsN    []string
 		varsR    []varMatcher
 		end      int
 		wildcard bool
+
+		constrained bool
 	)
 
 	pattern.WriteByte('^')
+	loose.WriteByte('^')
+
+	// writeReverse appends literal template text to both reverse
+	// templates. Encoded-path templates are already percent-encoded.
+	writeReverse := func(raw string) {
+		reverse.WriteString(strings.ReplaceAll(raw, "%", "%%"))
+		if !options.useEncodedPath {
+			raw = escapePathValue(raw)
+		}
+		rawRev.WriteString(strings.ReplaceAll(raw, "%", "%%"))
+	}
 
 	for i := 0; i < len(idxs); i += 2 {
 		// Write the raw text between variables.
@@ -121,8 +151,15 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 
 		// Build pattern and reverse template.
 		fmt.Fprintf(&pattern, "%s(%s)", regexp.QuoteMeta(raw), patt)
-		reverse.WriteString(strings.ReplaceAll(raw, "%", "%%"))
+		if len(parts) == 2 && (typ == regexpTypePath || typ == regexpTypePrefix) {
+			constrained = true
+			fmt.Fprintf(&loose, "%s((?:%s)|%s)", regexp.QuoteMeta(raw), patt, defaultPattern)
+		} else {
+			fmt.Fprintf(&loose, "%s(%s)", regexp.QuoteMeta(raw), patt)
+		}
+		writeReverse(raw)
 		reverse.WriteString("%s")
+		rawRev.WriteString("%s")
 
 		varsN = append(varsN, name)
 		if compiledVarR == nil {
@@ -147,16 +184,19 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 	}
 
 	pattern.WriteString(regexp.QuoteMeta(rawForPattern))
-	reverse.WriteString(strings.ReplaceAll(raw, "%", "%%"))
+	loose.WriteString(regexp.QuoteMeta(rawForPattern))
+	writeReverse(raw)
 
 	if typ == regexpTypePrefix {
 		wildcard = true
 	} else if options.strictSlash && typ == regexpTypePath {
 		pattern.WriteString("[/]?")
+		loose.WriteString("[/]?")
 	}
 
 	if !wildcard {
 		pattern.WriteByte('$')
+		loose.WriteByte('$')
 	}
 
 	reg, err := compileRegexp(pattern.String())
@@ -164,6 +204,14 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		return nil, err
 	}
 
+	var looseReg *regexp.Regexp
+	if constrained {
+		looseReg, err = compileRegexp(loose.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := checkDuplicateVars(varsN); err != nil {
 		return nil, err
 	}
@@ -198,8 +246,11 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		matchQuery:         typ == regexpTypeQuery,
 		strictSlash:        options.strictSlash,
 		useEncodedPath:     options.useEncodedPath,
+		plusAsSpace:        options.plusAsSpace && (typ == regexpTypePath || typ == regexpTypePrefix),
 		regexp:             reg,
+		loose:              looseReg,
 		reverse:            reverse.String(),
+		rawReverse:         rawRev.String(),
 		varsN:              varsN,
 		varsR:              varsR,
 		needsVarValidation: needsValidation,
@@ -225,12 +276,21 @@ func (r *routeRegexp) Match(req *http.Request, m *RouteMatch) bool {
 		return r.matchAndValidate(host)
 	}
 
-	p := req.URL.Path
-	// Use percent-encoded path per RFC 3986 Section 2.1 when configured.
+	return r.matchAndValidate(r.matchPath(req))
+}
+
+// matchPath returns the form of the request path the regexp is matched
+// against: the escaped path when the route has raw variables, the
+// percent-encoded path per RFC 3986 Section 2.1 with UseEncodedPath, and
+// the decoded path otherwise.
+func (r *routeRegexp) matchPath(req *http.Request) string {
+	if len(r.rawVars) > 0 {
+		return req.URL.EscapedPath()
+	}
 	if r.useEncodedPath {
-		p = requestURIPath(req.URL)
+		return requestURIPath(req.URL)
 	}
-	return r.matchAndValidate(p)
+	return req.URL.Path
 }
 
 // matchAndValidate checks the full regexp and then validates each
@@ -256,25 +316,167 @@ func (r *routeRegexp) matchAndValidate(input string) bool {
 	return true
 }
 
+// mismatchedVar reports the first variable whose value does not match its
+// pattern when input has the structure of the template but does not match
+// it. It reports false when input matches or differs in its literal text.
+func (r *routeRegexp) mismatchedVar(input string) (name, value string, ok bool) {
+	if r.loose == nil || r.matchAndValidate(input) {
+		return "", "", false
+	}
+	indices := r.loose.FindStringSubmatchIndex(input)
+	if indices == nil {
+		return "", "", false
+	}
+	for i := range r.varsN {
+		start, end := indices[(i+1)*2], indices[(i+1)*2+1]
+		if start >= 0 && !r.varsR[i].MatchString(input[start:end]) {
+			return r.varsN[i], input[start:end], true
+		}
+	}
+	return "", "", false
+}
+
+// matchesStructure reports whether input matches the template, allowing
+// variable values that fail their pattern.
+func (r *routeRegexp) matchesStructure(input string) bool {
+	if r.loose != nil {
+		return r.loose.MatchString(input)
+	}
+	return r.matchAndValidate(input)
+}
+
 // url builds a URL part from the template and the given variable values.
 // For query-type regexps, variable values are percent-encoded per
-// RFC 3986 Section 3.4.
+// RFC 3986 Section 3.4. Path results are in decoded form, as stored in
+// url.URL.Path.
 func (r *routeRegexp) url(values map[string]string) (string, error) {
-	urlValues := make([]interface{}, len(r.varsN))
+	s, _, err := r.urlWithRaw(values)
+	return s, err
+}
+
+// urlWithRaw is url that additionally returns the percent-encoded form of
+// a path for url.URL.RawPath. rawPath is only set when a variable value
+// contains a slash that must be sent as %2F to stay within a single path
+// segment (RFC 3986 Section 3.3); otherwise the encoding url.URL derives
+// from the decoded path is already correct and rawPath is empty.
+//
+// Host variable values are restricted to the characters of DNS labels
+// (RFC 1123 Section 2.1) and IPv6 literals; see validHostValue.
+func (r *routeRegexp) urlWithRaw(values map[string]string) (s, rawPath string, err error) {
+	urlValues := make([]any, len(r.varsN))
+	var rawValues []any
+	// startRaw switches to building a raw path at variable i, encoding the
+	// values before it.
+	startRaw := func(i int) {
+		if rawValues == nil {
+			rawValues = make([]any, len(r.varsN))
+			for j := range i {
+				rawValues[j] = r.escapeValue(urlValues[j].(string))
+			}
+		}
+	}
 	for i, name := range r.varsN {
 		v, ok := values[name]
 		if !ok {
-			return "", fmt.Errorf("mux: missing route variable %q", name)
-		}
-		if !r.varsR[i].MatchString(v) {
-			return "", fmt.Errorf("mux: variable %q doesn't match, expected %q", name, r.varsR[i].String())
+			return "", "", fmt.Errorf("mux: missing route variable %q", name)
 		}
-		if r.matchQuery {
-			v = url.QueryEscape(v)
+		matched := r.varsR[i].MatchString(v)
+		switch {
+		case r.matchQuery:
+			if !matched {
+				return "", "", errVarMismatch(name, r.varsR[i])
+			}
+			urlValues[i] = url.QueryEscape(v)
+			continue
+		case r.matchHost:
+			if !matched {
+				return "", "", errVarMismatch(name, r.varsR[i])
+			}
+			if !validHostValue(v) {
+				return "", "", fmt.Errorf("mux: variable %q has invalid host characters: %q", name, v)
+			}
+		case r.rawVars[name]:
+			// Raw values are already percent-encoded: they go into the
+			// raw path as is and are decoded for the path.
+			if !matched {
+				return "", "", errVarMismatch(name, r.varsR[i])
+			}
+			decoded, err := url.PathUnescape(v)
+			if err != nil {
+				return "", "", fmt.Errorf("mux: variable %q has invalid percent-encoding: %q", name, v)
+			}
+			startRaw(i)
+			rawValues[i] = v
+			urlValues[i] = decoded
+			continue
+		case !matched:
+			// A slash in a single-segment variable is acceptable when
+			// escaped, which is how UseEncodedPath routers match it.
+			escaped := url.PathEscape(v)
+			if !strings.Contains(v, "/") || !r.varsR[i].MatchString(escaped) {
+				return "", "", errVarMismatch(name, r.varsR[i])
+			}
+			startRaw(i)
+			rawValues[i] = r.escapePlus(escaped)
+			urlValues[i] = v
+			continue
 		}
 		urlValues[i] = v
+		if r.plusAsSpace && strings.Contains(v, "+") {
+			startRaw(i)
+		}
+		if rawValues != nil {
+			rawValues[i] = r.escapeValue(v)
+		}
 	}
-	return fmt.Sprintf(r.reverse, urlValues...), nil
+	s = fmt.Sprintf(r.reverse, urlValues...)
+	if rawValues != nil {
+		rawPath = fmt.Sprintf(r.rawReverse, rawValues...)
+	}
+	return s, rawPath, nil
+}
+
+// errVarMismatch reports a variable value rejected by its pattern.
+func errVarMismatch(name string, m varMatcher) error {
+	return fmt.Errorf("mux: variable %q doesn't match, expected %q", name, m.String())
+}
+
+// escapePathValue percent-encodes a path variable value the way
+// url.URL.EscapedPath would, keeping slashes as separators.
+func escapePathValue(v string) string {
+	return (&url.URL{Path: v}).EscapedPath()
+}
+
+// escapeValue is escapePathValue followed by escapePlus.
+func (r *routeRegexp) escapeValue(v string) string {
+	return r.escapePlus(escapePathValue(v))
+}
+
+// escapePlus encodes '+' in an escaped path value as %2B when the route
+// reads '+' as a space, so that a built URL matches back to the same
+// value. Spaces are always encoded as %20.
+func (r *routeRegexp) escapePlus(escaped string) string {
+	if !r.plusAsSpace {
+		return escaped
+	}
+	return strings.ReplaceAll(escaped, "+", "%2B")
+}
+
+// validHostValue reports whether v only contains characters allowed in
+// host names: letters, digits, hyphens, dots, and underscores, plus colons
+// for variables that capture a port and square brackets for IPv6 literals
+// (RFC 3986 Section 3.2.2).
+func validHostValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '.' || c == '_' || c == ':' || c == '[' || c == ']':
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 // getURLVars extracts route variables from the given input string.
@@ -409,19 +611,23 @@ func (v *routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, _ *Route)
 	}
 
 	if v.path != nil && len(v.path.varsN) > 0 {
-		p := req.URL.Path
-		if v.path.useEncodedPath {
-			p = requestURIPath(req.URL)
-		}
-		v.path.setVars(p, m.Vars)
-		if v.path.useEncodedPath {
+		v.path.setVars(v.path.matchPath(req), m.Vars)
+		if v.path.useEncodedPath || len(v.path.rawVars) > 0 {
 			for _, name := range v.path.varsN {
+				if v.path.rawVars[name] {
+					continue
+				}
 				if val, ok := m.Vars[name]; ok {
+					if v.path.plusAsSpace {
+						val = strings.ReplaceAll(val, "+", " ")
+					}
 					if unescaped, err := url.PathUnescape(val); err == nil {
 						m.Vars[name] = unescaped
 					}
 				}
 			}
+		} else if v.path.plusAsSpace {
+			v.path.plusToSpace(req, m.Vars)
 		}
 	}
 
@@ -442,6 +648,48 @@ func (v *routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, _ *Route)
 	}
 }
 
+// plusToSpace translates '+' to space in the path variables of vars that
+// were matched against the decoded path. The decoded path cannot tell a
+// literal '+' from %2B, so each '+' is looked up in the escaped path and
+// only a literal one becomes a space.
+func (r *routeRegexp) plusToSpace(req *http.Request, vars map[string]string) {
+	path := req.URL.Path
+	if !strings.Contains(path, "+") {
+		return
+	}
+	indices := r.regexp.FindStringSubmatchIndex(path)
+	if indices == nil {
+		return
+	}
+
+	// literal marks the bytes of path written as a literal '+' in the
+	// escaped path, which holds one %XX triplet per other encoded byte.
+	escaped := req.URL.EscapedPath()
+	literal := make([]bool, len(path))
+	for i, j := 0, 0; i < len(escaped) && j < len(path); j++ {
+		if escaped[i] == '%' {
+			i += 3
+			continue
+		}
+		literal[j] = escaped[i] == '+'
+		i++
+	}
+
+	for k, name := range r.varsN {
+		start, end := indices[(k+1)*2], indices[(k+1)*2+1]
+		if start < 0 || !strings.Contains(path[start:end], "+") {
+			continue
+		}
+		val := []byte(path[start:end])
+		for i, c := range val {
+			if c == '+' && literal[start+i] {
+				val[i] = ' '
+			}
+		}
+		vars[name] = string(val)
+	}
+}
+
 // setVars extracts variables from input and writes them directly into dst.
 // Returns true if the input matched the regexp.
 func (r *routeRegexp) setVars(input string, dst map[string]string) bool {
@@ -113,15 +113,60 @@ func executeHTMLTemplate(w http.ResponseWriter, code int, tmpl *template.Templat
 // status code. The Content-Type header is set to "application/json".
 // If encoding fails, an HTTP 500 Internal Server Error is written instead.
 func ResponseJSON(w http.ResponseWriter, code int, v any) {
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(v); err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+	ResponseJSONOpts(w, code, v, JSONOptions{})
+}
+
+// JSONOptions configures how ResponseJSONOpts encodes a value. The zero
+// value matches ResponseJSON: compact output with HTML escaping.
+type JSONOptions struct {
+	// Prefix and Indent are passed to json.Encoder.SetIndent. Output is
+	// indented when either is non-empty.
+	Prefix string
+	Indent string
+
+	// DisableHTMLEscape writes <, >, and & in strings as-is instead of
+	// escaping them to \u003c, \u003e, and \u0026.
+	DisableHTMLEscape bool
+
+	// Marshal, when set, replaces encoding/json. Its output is written
+	// as returned, and Prefix, Indent, and DisableHTMLEscape are ignored.
+	Marshal func(v any) ([]byte, error)
+}
+
+// ResponseJSONOpts encodes v as JSON using opts and writes it to the
+// response with the given status code. The Content-Type header is set to
+// "application/json". If encoding fails, an HTTP 500 Internal Server Error
+// is written instead.
+//
+//	mux.ResponseJSONOpts(w, http.StatusOK, user, mux.JSONOptions{Indent: "  "})
+func ResponseJSONOpts(w http.ResponseWriter, code int, v any, opts JSONOptions) {
+	var body []byte
+	if opts.Marshal != nil {
+		data, err := opts.Marshal(v)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		body = data
+	} else {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		if opts.Prefix != "" || opts.Indent != "" {
+			enc.SetIndent(opts.Prefix, opts.Indent)
+		}
+		if opts.DisableHTMLEscape {
+			enc.SetEscapeHTML(false)
+		}
+		if err := enc.Encode(v); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		body = buf.Bytes()
 	}
 
 	w.Header().Set("Content-Type", ContentTypeApplicationJSON)
 	w.WriteHeader(code)
-	w.Write(buf.Bytes())
+	w.Write(body)
 }
 
 // ResponseXML encodes v as XML and writes it to the response with the given
@@ -83,6 +83,76 @@ func TestResponseJSON(t *testing.T) {
 	}
 }
 
+func TestResponseJSONOpts(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name       string
+		data       any
+		opts       JSONOptions
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "zero options match ResponseJSON",
+			data:       item{Name: "<b>"},
+			wantStatus: http.StatusOK,
+			wantBody:   "{\"name\":\"\\u003cb\\u003e\"}\n",
+		},
+		{
+			name:       "indented output",
+			data:       item{Name: "a"},
+			opts:       JSONOptions{Indent: "  "},
+			wantStatus: http.StatusOK,
+			wantBody:   "{\n  \"name\": \"a\"\n}\n",
+		},
+		{
+			name:       "escape HTML disabled",
+			data:       item{Name: "<b>&"},
+			opts:       JSONOptions{DisableHTMLEscape: true},
+			wantStatus: http.StatusOK,
+			wantBody:   "{\"name\":\"<b>&\"}\n",
+		},
+		{
+			name: "custom marshal",
+			data: item{Name: "a"},
+			opts: JSONOptions{
+				Indent: "  ",
+				Marshal: func(any) ([]byte, error) {
+					return []byte(`{"custom":true}`), nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"custom":true}`,
+		},
+		{
+			name: "custom marshal error",
+			data: item{Name: "a"},
+			opts: JSONOptions{
+				Marshal: func(any) ([]byte, error) {
+					return nil, assert.AnError
+				},
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ResponseJSONOpts(w, http.StatusOK, tt.data, tt.opts)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestResponseHTML(t *testing.T) {
 	t.Cleanup(func() { SetTemplates(nil) })
 
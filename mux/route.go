@@ -1,21 +1,36 @@
 package mux
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"maps"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 )
 
-// matcher is the interface implemented by route matchers.
-type matcher interface {
+// Matcher is the interface implemented by route matchers. Custom matchers
+// registered with Route.Matcher or Route.MatcherFunc run after the built-in
+// matchers; see Route.Match for the evaluation order.
+type Matcher interface {
 	Match(*http.Request, *RouteMatch) bool
 }
 
+// MethodsReporter is an optional interface for custom matchers that
+// constrain the request method. The methods it reports take part in the
+// Allow header of 405 Method Not Allowed responses (RFC 9110 Section
+// 15.5.6), and a rejection of a request whose method is not among them is
+// treated as a method mismatch rather than a missing resource. Methods are
+// reported as upper-case tokens (RFC 9110 Section 9.1).
+type MethodsReporter interface {
+	Methods() []string
+}
+
 // parentRoute is the interface implemented by types that can serve as
 // a route's parent (Router or Route via subrouter).
 type parentRoute interface {
@@ -28,19 +43,24 @@ type parentRoute interface {
 type Route struct {
 	parent       parentRoute
 	handler      http.Handler
-	matchers     []matcher
+	matchers     []Matcher
 	middlewares  []MiddlewareFunc
 	regexp       routeRegexpGroup
 	name         string
+	summary      string
+	description  string
 	err          error
 	metadata     map[any]any
 	metadataFunc func(*http.Request) map[any]any
 	namedRoutes  map[string]*Route
 	buildOnly    bool
+	disabled     bool
+	timeout      time.Duration
 
 	strictSlash    bool
 	skipClean      bool
 	useEncodedPath bool
+	plusAsSpace    bool
 	buildVarsFunc  BuildVarsFunc
 	buildScheme    string
 
@@ -50,21 +70,47 @@ type Route struct {
 	staticCtxOnce sync.Once
 }
 
-// Match matches this route against the request.
+// Match matches this route against the request. Matchers are evaluated
+// in a fixed order:
+//
+//  1. built-in matchers: Methods, Headers, HeadersRegexp, and Schemes;
+//  2. the Host template;
+//  3. the Path or PathPrefix template;
+//  4. the Queries templates;
+//  5. custom matchers added with Matcher or MatcherFunc, in the order they
+//     were added.
+//
+// Custom matchers run last and see the variables extracted from the host,
+// path, and query templates in match.Vars. Variables a custom matcher adds
+// to match.Vars are merged with the extracted ones and reach the handler
+// through Vars; if the route does not match, match.Vars is left as it was.
+// A method mismatch, from Methods or a custom matcher, is recorded only when
+// every other matcher accepts the request.
 func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 	if r.err != nil {
 		return false
 	}
 
-	var methodMismatch bool
+	var methodMismatch, hasCustom bool
+	var connMismatch connMatcher
 
-	// Check all matchers.
+	// Check built-in matchers.
 	for _, m := range r.matchers {
+		if !isBuiltinMatcher(m) {
+			hasCustom = true
+			continue
+		}
 		if !m.Match(req, match) {
 			if _, ok := m.(methodMatcher); ok {
 				methodMismatch = true
 				continue
 			}
+			if cm, ok := m.(connMatcher); ok {
+				if connMismatch == nil {
+					connMismatch = cm
+				}
+				continue
+			}
 			if match.MatchErr == ErrMethodMismatch {
 				methodMismatch = true
 				continue
@@ -94,9 +140,33 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 		}
 	}
 
+	// Check custom matchers.
+	saved := match.Vars
+	if hasCustom {
+		ok, mismatch := r.matchCustom(req, match)
+		if !ok {
+			return false
+		}
+		methodMismatch = methodMismatch || mismatch
+	}
+
+	// A connection requirement that failed while everything else,
+	// including the method, matched is recorded for a 403 or 426 reply.
+	if connMismatch != nil {
+		match.Vars = saved
+		if !methodMismatch {
+			r.recordConnMismatch(req, match, connMismatch.mismatch())
+		}
+		return false
+	}
+
 	// If method didn't match but everything else did, record the mismatch.
 	if methodMismatch {
+		match.Vars = saved
 		match.MatchErr = ErrMethodMismatch
+		if match.mismatchRoute == nil {
+			match.mismatchRoute = r
+		}
 		return false
 	}
 
@@ -107,11 +177,17 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 	// Similarly, if the subrouter has a NotFoundHandler and the prefix
 	// matched but no sub-route matched (and it's not a method mismatch),
 	// use the subrouter's NotFoundHandler instead of propagating to the parent.
+	// Either handler is wrapped with the middleware of every subrouter
+	// below this one whose prefix matched, then with this subrouter's.
 	if r.handler != nil {
 		if router, ok := r.handler.(*Router); ok {
+			depth := len(match.fallbackRouters)
+			prevMismatch := match.mismatchRoute
+			match.mismatchRoute = nil
 			if router.Match(req, match) {
 				return true
 			}
+			inner := match.fallbackRouters[depth:]
 			if match.MatchErr == ErrMethodMismatch && router.MethodNotAllowedHandler != nil {
 				allowed := allowedMethods(router, req)
 				match.Route = r
@@ -119,26 +195,46 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 					w.Header().Set("Allow", strings.Join(allowed, ", "))
 					router.MethodNotAllowedHandler.ServeHTTP(w, req)
 				})
-				match.Handler = router.applyMiddleware(mnaHandler)
+				match.Handler = router.applyMiddleware(wrapFallback(mnaHandler, inner))
 				match.MatchErr = nil
 				match.methodNotAllowed = false
+				match.fallback = true
+				match.fallbackRouters = match.fallbackRouters[:depth]
 				r.regexp.setMatch(req, match, r)
 				return true
 			}
 			if router.NotFoundHandler != nil && match.MatchErr != ErrMethodMismatch {
 				match.Route = r
-				match.Handler = router.applyMiddleware(router.NotFoundHandler)
+				match.mismatchRoute = nil
+				match.Handler = router.applyMiddleware(wrapFallback(router.NotFoundHandler, inner))
 				match.MatchErr = nil
+				match.fallback = true
+				match.fallbackRouters = match.fallbackRouters[:depth]
 				r.regexp.setMatch(req, match, r)
 				return true
 			}
+			// Remember the subrouters the request fell into so the
+			// router's own 404 or 405 handler gets their middleware. Only
+			// the first subrouter whose prefix matched is kept.
+			if depth == 0 {
+				match.fallbackRouters = append(match.fallbackRouters, router)
+			} else {
+				match.fallbackRouters = match.fallbackRouters[:depth]
+			}
+			if prevMismatch != nil {
+				match.mismatchRoute = prevMismatch
+			}
+			match.Vars = saved
 			return false
 		}
 	}
 
 	match.Route = r
 	match.Handler = r.handler
-	r.regexp.setMatch(req, match, r)
+	if !hasCustom {
+		// Custom matchers have already populated match.Vars.
+		r.regexp.setMatch(req, match, r)
+	}
 
 	// Apply buildVarsFunc if set.
 	if r.buildVarsFunc != nil {
@@ -151,10 +247,73 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 	return true
 }
 
+// matchCustom runs the custom matchers of the route after the built-in
+// ones have accepted the request. Variables extracted from the route
+// templates are placed in match.Vars first so custom matchers can read and
+// extend them. It reports whether the request matched, ignoring the method,
+// and whether a custom matcher rejected the method. On a non-method
+// rejection match.Vars is restored.
+func (r *Route) matchCustom(req *http.Request, match *RouteMatch) (bool, bool) {
+	saved := match.Vars
+	vars := make(map[string]string, r.regexp.varCount()+len(saved))
+	maps.Copy(vars, saved)
+	match.Vars = vars
+	r.regexp.setMatch(req, match, r)
+
+	var methodMismatch bool
+	for _, m := range r.matchers {
+		if isBuiltinMatcher(m) || m.Match(req, match) {
+			continue
+		}
+		if match.MatchErr == ErrMethodMismatch || rejectsMethod(m, req.Method) {
+			methodMismatch = true
+			continue
+		}
+		match.Vars = saved
+		return false, false
+	}
+
+	// A matcher may have replaced match.Vars; keep the extracted
+	// variables it did not override.
+	if match.Vars == nil {
+		match.Vars = vars
+	} else {
+		for k, v := range vars {
+			if _, ok := match.Vars[k]; !ok {
+				match.Vars[k] = v
+			}
+		}
+	}
+	if len(match.Vars) == 0 && saved == nil {
+		match.Vars = nil
+	}
+	return true, methodMismatch
+}
+
+// isBuiltinMatcher reports whether m is one of the matchers created by the
+// Route methods, as opposed to a custom matcher.
+func isBuiltinMatcher(m Matcher) bool {
+	switch m.(type) {
+	case methodMatcher, headerMatcher, headerRegexMatcher, schemeMatcher, protoMatcher, tlsClientCertMatcher:
+		return true
+	}
+	return false
+}
+
+// rejectsMethod reports whether m is a MethodsReporter that does not allow
+// the request method. GET implies HEAD per RFC 9110 Section 9.3.2.
+func rejectsMethod(m Matcher, method string) bool {
+	mr, ok := m.(MethodsReporter)
+	if !ok {
+		return false
+	}
+	return !methodMatcher(mr.Methods()).allows(method)
+}
+
 // --- Matchers ---
 
 // addMatcher adds a matcher to the route.
-func (r *Route) addMatcher(m matcher) *Route {
+func (r *Route) addMatcher(m Matcher) *Route {
 	if r.err == nil {
 		r.matchers = append(r.matchers, m)
 	}
@@ -195,6 +354,7 @@ func (r *Route) addRegexpMatcher(tpl string, typ regexpType) error {
 	rr, err := newRouteRegexp(tpl, typ, routeRegexpOptions{
 		strictSlash:    r.strictSlash,
 		useEncodedPath: r.useEncodedPath,
+		plusAsSpace:    r.plusAsSpace,
 	})
 	if err != nil {
 		return err
@@ -223,6 +383,36 @@ func (r *Route) addRegexpMatcher(tpl string, typ regexpType) error {
 	return nil
 }
 
+// prefixPath recompiles the path template of the route with prefix in
+// front of it, using the route's current StrictSlash and UseEncodedPath
+// settings. It is used when the route's router is mounted with Mount.
+func (r *Route) prefixPath(prefix string) error {
+	if r.err != nil || r.regexp.path == nil {
+		return nil
+	}
+	old := r.regexp.path
+	typ := regexpTypePath
+	if old.wildcard {
+		typ = regexpTypePrefix
+	}
+	rr, err := newRouteRegexp(prefix+old.template, typ, routeRegexpOptions{
+		strictSlash:    r.strictSlash,
+		useEncodedPath: r.useEncodedPath,
+		plusAsSpace:    r.plusAsSpace,
+	})
+	if err != nil {
+		return err
+	}
+	if r.regexp.host != nil {
+		if err := uniqueVars(rr.varsN, r.regexp.host.varsN); err != nil {
+			return err
+		}
+	}
+	rr.rawVars = old.rawVars
+	r.regexp.path = rr
+	return nil
+}
+
 // Handler sets a handler for the route.
 func (r *Route) Handler(handler http.Handler) *Route {
 	if r.err == nil {
@@ -250,7 +440,7 @@ func (r *Route) Name(name string) *Route {
 	}
 	if r.err == nil {
 		r.name = name
-		if r.namedRoutes != nil {
+		if r.namedRoutes != nil && !r.isDisabled() {
 			r.namedRoutes[name] = r
 		}
 	}
@@ -262,6 +452,20 @@ func (r *Route) GetName() string {
 	return r.name
 }
 
+// Doc sets a short summary and a longer description of the route. The
+// router does not use them; they live next to the route definition so
+// documentation generators such as openapi.Spec.Build can pick them up.
+func (r *Route) Doc(summary, description string) *Route {
+	r.summary = summary
+	r.description = description
+	return r
+}
+
+// GetDoc returns the summary and description set with Doc.
+func (r *Route) GetDoc() (summary, description string) {
+	return r.summary, r.description
+}
+
 // Path adds a path matcher to the route per RFC 3986 Section 3.3.
 func (r *Route) Path(tpl string) *Route {
 	r.err = r.addRegexpMatcher(tpl, regexpTypePath)
@@ -274,6 +478,46 @@ func (r *Route) PathPrefix(tpl string) *Route {
 	return r
 }
 
+// RawVars marks path variables whose values in Vars are the percent-encoded
+// bytes from the request path (RFC 3986 Section 2.1) rather than decoded
+// values, regardless of the router's UseEncodedPath setting. Use it for
+// values passed through to another URL, where decoding would corrupt them:
+//
+//	r.HandleFunc("/proxy/{token}", h).RawVars("token")
+//	// GET /proxy/a%2Fb -> Vars(r)["token"] == "a%2Fb"
+//
+// A route with raw variables is matched against the escaped request path,
+// so an encoded slash stays inside its segment and variable patterns apply
+// to the encoded form. Other variables of the route are still decoded.
+// When building URLs, values of raw variables are taken to be already
+// encoded and are not escaped again.
+//
+// RawVars must be called after the path is set; naming a variable that is
+// not in the path template sets a route error.
+func (r *Route) RawVars(names ...string) *Route {
+	if r.err != nil {
+		return r
+	}
+	if r.regexp.path == nil {
+		r.err = errors.New("mux: RawVars requires a path template")
+		return r
+	}
+	rr := *r.regexp.path
+	rr.rawVars = maps.Clone(rr.rawVars)
+	if rr.rawVars == nil {
+		rr.rawVars = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		if !slices.Contains(rr.varsN, name) {
+			r.err = fmt.Errorf("mux: raw variable %q is not a path variable", name)
+			return r
+		}
+		rr.rawVars[name] = true
+	}
+	r.regexp.path = &rr
+	return r
+}
+
 // Host adds a host matcher to the route per RFC 9110 Section 7.2.
 func (r *Route) Host(tpl string) *Route {
 	r.err = r.addRegexpMatcher(tpl, regexpTypeHost)
@@ -367,6 +611,40 @@ func (r *Route) Schemes(schemes ...string) *Route {
 	return r.addMatcher(schemeMatcher(schemes))
 }
 
+// Proto adds a matcher for the request protocol version, as reported by
+// http.Request.Proto: "HTTP/1.1", "HTTP/2.0", or "HTTP/3.0". Values are
+// compared case-insensitively. A request the route matches except for the
+// protocol fails with a ConnMismatchError wrapping ErrProtoMismatch.
+//
+//	r.HandleFunc("/stream", stream).Proto("HTTP/2.0", "HTTP/3.0")
+func (r *Route) Proto(protos ...string) *Route {
+	return r.addMatcher(protoMatcher(protos))
+}
+
+// TLSClientCert adds a matcher on the TLS connection state of the request,
+// such as the subject of the client certificate. Requests not received
+// over TLS never match, and matcher is not called for them. A request the
+// route matches except for this matcher fails with a ConnMismatchError
+// wrapping ErrClientCertMismatch.
+//
+// The server must ask for client certificates, with tls.Config.ClientAuth
+// set to tls.RequestClientCert or stronger, for the state to carry one.
+func (r *Route) TLSClientCert(matcher func(*tls.ConnectionState) bool) *Route {
+	return r.addMatcher(tlsClientCertMatcher(matcher))
+}
+
+// RequireClientCert is TLSClientCert with a matcher that accepts requests
+// whose client presented at least one certificate verified against the
+// server's client CAs, which requires tls.Config.ClientAuth set to
+// tls.VerifyClientCertIfGiven or tls.RequireAndVerifyClientCert. Set on a
+// subrouter's route, it restricts every route of the subrouter to mTLS
+// clients while other routes share the listener:
+//
+//	admin := r.PathPrefix("/admin").RequireClientCert().Subrouter()
+func (r *Route) RequireClientCert() *Route {
+	return r.TLSClientCert(hasVerifiedClientCert)
+}
+
 // BuildOnly sets the route to be used only for URL building,
 // not for request matching.
 func (r *Route) BuildOnly() *Route {
@@ -380,6 +658,69 @@ func (r *Route) IsBuildOnly() bool {
 	return r.buildOnly
 }
 
+// Enabled keeps the route registered only when enabled is true, so that
+// feature-flagged routes can be declared inline:
+//
+//	r.HandleFunc("/beta", betaHandler).Methods(http.MethodGet).Enabled(cfg.Beta)
+//
+// A disabled route is removed from its router: it no longer matches
+// requests, is skipped by Walk, and URL building returns ErrRouteDisabled.
+// Its name, and the names of routes on its subrouter, are released so that
+// another route can claim them. A disabled route cannot be enabled again;
+// Enabled(true) is a no-op. Disabling a route of a frozen router is logged
+// and leaves the route registered; see Router.Freeze.
+func (r *Route) Enabled(enabled bool) *Route {
+	if enabled || r.disabled {
+		return r
+	}
+	if router, ok := r.parent.(*Router); ok && router.frozen.Load() {
+		_ = frozenErr("disabling a route")
+		return r
+	}
+	r.disabled = true
+	if router, ok := r.parent.(*Router); ok {
+		router.routes = slices.DeleteFunc(router.routes, func(route *Route) bool {
+			return route == r
+		})
+	}
+	r.releaseNames()
+	return r
+}
+
+// IsEnabled reports whether the route is still registered with its router.
+func (r *Route) IsEnabled() bool {
+	return !r.isDisabled()
+}
+
+// isDisabled reports whether the route or any route it is mounted under
+// has been disabled.
+func (r *Route) isDisabled() bool {
+	for route := r; route != nil; {
+		if route.disabled {
+			return true
+		}
+		router, ok := route.parent.(*Router)
+		if !ok {
+			return false
+		}
+		route, _ = router.parent.(*Route)
+	}
+	return false
+}
+
+// releaseNames removes the route and its subrouter's routes from the
+// named-route map.
+func (r *Route) releaseNames() {
+	if r.name != "" && r.namedRoutes[r.name] == r {
+		delete(r.namedRoutes, r.name)
+	}
+	if router, ok := r.handler.(*Router); ok {
+		for _, route := range router.routes {
+			route.releaseNames()
+		}
+	}
+}
+
 // Metadata sets a key-value pair on the route's metadata map.
 func (r *Route) Metadata(key any, value any) *Route {
 	if r.metadata == nil {
@@ -441,14 +782,25 @@ func (r *Route) GetMetadataValueOr(key any, fallback any) any {
 	return value
 }
 
-// Subrouter creates a new Router for the route.
+// Subrouter creates a new Router for the route. When the route belongs to
+// a frozen router, or is a detached route returned after Freeze, the route
+// is left unchanged and a detached, frozen router is returned, so routes
+// registered on it report ErrRouterFrozen.
 func (r *Route) Subrouter() *Router {
+	if errors.Is(r.err, ErrRouterFrozen) {
+		return frozenRouter()
+	}
+	if router, ok := r.parent.(*Router); ok && router.frozen.Load() {
+		_ = frozenErr("Subrouter")
+		return frozenRouter()
+	}
 	router := &Router{
 		parent:         r,
 		namedRoutes:    r.namedRoutes,
 		strictSlash:    r.strictSlash,
 		skipClean:      r.skipClean,
 		useEncodedPath: r.useEncodedPath,
+		plusAsSpace:    r.plusAsSpace,
 	}
 	r.handler = router
 	return router
@@ -459,11 +811,20 @@ func (r *Route) SkipClean() bool {
 	return r.skipClean
 }
 
-// MatcherFunc adds a custom matcher function to the route.
+// MatcherFunc adds a custom matcher function to the route. Custom matchers
+// run after the built-in matchers; see Match for the evaluation order.
 func (r *Route) MatcherFunc(f MatcherFunc) *Route {
 	return r.addMatcher(f)
 }
 
+// Matcher adds a custom matcher to the route. Custom matchers run after the
+// built-in matchers; see Match for the evaluation order. A matcher that
+// constrains the request method can implement MethodsReporter so that its
+// methods are reported in the Allow header of 405 responses.
+func (r *Route) Matcher(m Matcher) *Route {
+	return r.addMatcher(m)
+}
+
 // BuildVarsFunc adds a custom variable builder function to the route.
 func (r *Route) BuildVarsFunc(f BuildVarsFunc) *Route {
 	if r.buildVarsFunc != nil {
@@ -485,16 +846,26 @@ func (r *Route) Use(mwf ...MiddlewareFunc) *Route {
 	return r
 }
 
-// applyMiddleware wraps the handler with all route-level middleware.
+// applyMiddleware wraps the handler with all route-level middleware and,
+// outermost, the route's Timeout.
 func (r *Route) applyMiddleware(handler http.Handler) http.Handler {
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
 		handler = r.middlewares[i].Middleware(handler)
 	}
+	if r.timeout > 0 {
+		handler = &timeoutRouteHandler{route: r, handler: handler}
+	}
 	return handler
 }
 
+// hasRouteWrappers reports whether applyMiddleware changes the handler.
+func (r *Route) hasRouteWrappers() bool {
+	return len(r.middlewares) > 0 || r.timeout > 0
+}
+
 // GetHandlerWithMiddlewares returns the handler wrapped with route-level
-// middleware only (not router middleware). Returns nil if no handler is set.
+// middleware and Timeout only (not router middleware). Returns nil if no
+// handler is set.
 func (r *Route) GetHandlerWithMiddlewares() http.Handler {
 	if r.handler == nil {
 		return nil
@@ -508,15 +879,32 @@ func (r *Route) GetHandlerWithMiddlewares() http.Handler {
 // recomposition). It accepts a sequence of key/value pairs for the route
 // variables. Returns an error if the route has no path template or if a
 // variable is missing/invalid.
+//
+// Variable values are percent-encoded for their position: query values
+// per RFC 3986 Section 3.4, and path values per Section 3.3, where a slash
+// in a single-segment variable is sent as %2F via url.URL.RawPath. Host
+// values must consist of host name characters.
 func (r *Route) URL(pairs ...string) (*url.URL, error) {
+	return r.URLWithQuery(nil, pairs...)
+}
+
+// URLWithQuery is like URL but appends the given query parameters after
+// the ones filled from the route's Queries templates. Parameters are
+// encoded with url.Values.Encode, so they appear sorted by key.
+//
+//	u, err := r.Get("search").URLWithQuery(url.Values{"page": {"2"}}, "category", "books")
+func (r *Route) URLWithQuery(query url.Values, pairs ...string) (*url.URL, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
+	if r.isDisabled() {
+		return nil, ErrRouteDisabled
+	}
 	values, err := r.prepareVars(pairs...)
 	if err != nil {
 		return nil, err
 	}
-	var scheme, host, path string
+	var scheme, host, path, rawPath string
 	if r.regexp.host != nil {
 		if host, err = r.regexp.host.url(values); err != nil {
 			return nil, err
@@ -527,27 +915,27 @@ func (r *Route) URL(pairs ...string) (*url.URL, error) {
 		}
 	}
 	if r.regexp.path != nil {
-		if path, err = r.regexp.path.url(values); err != nil {
+		if path, rawPath, err = r.regexp.path.urlWithRaw(values); err != nil {
 			return nil, err
 		}
 	}
-	var rawQuery string
-	if len(r.regexp.queries) > 0 {
-		queryParts := make([]string, 0, len(r.regexp.queries))
-		for _, q := range r.regexp.queries {
-			qv, qErr := q.url(values)
-			if qErr != nil {
-				return nil, qErr
-			}
-			queryParts = append(queryParts, fmt.Sprintf("%s=%s", q.queryKey, qv))
+	queryParts := make([]string, 0, len(r.regexp.queries)+1)
+	for _, q := range r.regexp.queries {
+		qv, qErr := q.url(values)
+		if qErr != nil {
+			return nil, qErr
 		}
-		rawQuery = strings.Join(queryParts, "&")
+		queryParts = append(queryParts, fmt.Sprintf("%s=%s", q.queryKey, qv))
+	}
+	if len(query) > 0 {
+		queryParts = append(queryParts, query.Encode())
 	}
 	return &url.URL{
 		Scheme:   scheme,
 		Host:     host,
 		Path:     path,
-		RawQuery: rawQuery,
+		RawPath:  rawPath,
+		RawQuery: strings.Join(queryParts, "&"),
 	}, nil
 }
 
@@ -556,6 +944,9 @@ func (r *Route) URLHost(pairs ...string) (*url.URL, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
+	if r.isDisabled() {
+		return nil, ErrRouteDisabled
+	}
 	values, err := r.prepareVars(pairs...)
 	if err != nil {
 		return nil, err
@@ -582,6 +973,9 @@ func (r *Route) URLPath(pairs ...string) (*url.URL, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
+	if r.isDisabled() {
+		return nil, ErrRouteDisabled
+	}
 	values, err := r.prepareVars(pairs...)
 	if err != nil {
 		return nil, err
@@ -589,12 +983,13 @@ func (r *Route) URLPath(pairs ...string) (*url.URL, error) {
 	if r.regexp.path == nil {
 		return nil, errors.New("mux: route doesn't have a path")
 	}
-	path, err := r.regexp.path.url(values)
+	path, rawPath, err := r.regexp.path.urlWithRaw(values)
 	if err != nil {
 		return nil, err
 	}
 	return &url.URL{
-		Path: path,
+		Path:    path,
+		RawPath: rawPath,
 	}, nil
 }
 
@@ -803,13 +1198,18 @@ func (r *Route) buildVars(m map[string]string) map[string]string {
 type methodMatcher []string
 
 func (m methodMatcher) Match(r *http.Request, _ *RouteMatch) bool {
-	if matchInArray([]string(m), r.Method) {
+	return m.allows(r.Method)
+}
+
+// allows reports whether method is in the list, or is HEAD and GET is.
+func (m methodMatcher) allows(method string) bool {
+	if matchInArray([]string(m), method) {
 		return true
 	}
 	// RFC 9110 Section 9.3.2: the HEAD method is identical to GET except
 	// that the server MUST NOT send content in the response. A resource
 	// that supports GET MUST also support HEAD.
-	if r.Method == http.MethodHead && matchInArray([]string(m), http.MethodGet) {
+	if method == http.MethodHead && matchInArray([]string(m), http.MethodGet) {
 		return true
 	}
 	return false
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -431,6 +432,130 @@ func TestRouteBuildOnly(t *testing.T) {
 	})
 }
 
+func TestRouteEnabled(t *testing.T) {
+	noop := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	t.Run("disabled route does not match", func(t *testing.T) {
+		router := NewRouter()
+		router.HandleFunc("/beta", noop).Methods(http.MethodGet).Enabled(false)
+
+		req := httptest.NewRequest(http.MethodGet, "/beta", nil)
+		match := &RouteMatch{}
+		assert.False(t, router.Match(req, match))
+		assert.Equal(t, ErrNotFound, match.MatchErr)
+	})
+
+	t.Run("disabled route does not contribute to 405", func(t *testing.T) {
+		router := NewRouter()
+		router.HandleFunc("/items", noop).Methods(http.MethodGet)
+		router.HandleFunc("/items", noop).Methods(http.MethodPost).Enabled(false)
+
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+	})
+
+	t.Run("enabled route is unaffected", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/beta", noop).Enabled(true)
+
+		req := httptest.NewRequest(http.MethodGet, "/beta", nil)
+		assert.True(t, router.Match(req, &RouteMatch{}))
+		assert.True(t, route.IsEnabled())
+	})
+
+	t.Run("disabled route is skipped by Walk", func(t *testing.T) {
+		router := NewRouter()
+		router.HandleFunc("/a", noop)
+		router.HandleFunc("/b", noop).Enabled(false)
+		router.HandleFunc("/c", noop)
+
+		var paths []string
+		err := router.Walk(func(route *Route, _ *Router, _ []*Route) error {
+			tpl, _ := route.GetPathTemplate()
+			paths = append(paths, tpl)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/a", "/c"}, paths)
+	})
+
+	t.Run("name is released when disabled after naming", func(t *testing.T) {
+		router := NewRouter()
+		old := router.HandleFunc("/v1/users", noop).Name("users").Enabled(false)
+		assert.Nil(t, router.Get("users"))
+
+		replacement := router.HandleFunc("/v2/users", noop).Name("users")
+		assert.Same(t, replacement, router.Get("users"))
+
+		_, err := old.URL()
+		assert.ErrorIs(t, err, ErrRouteDisabled)
+	})
+
+	t.Run("name is not claimed when named after disabling", func(t *testing.T) {
+		router := NewRouter()
+		replacement := router.HandleFunc("/v2/users", noop).Name("users")
+		router.HandleFunc("/v1/users", noop).Enabled(false).Name("users")
+
+		assert.Same(t, replacement, router.Get("users"))
+	})
+
+	t.Run("disabling keeps a later route with the same name", func(t *testing.T) {
+		router := NewRouter()
+		first := router.HandleFunc("/v1/users", noop).Name("users")
+		second := router.HandleFunc("/v2/users", noop).Name("users")
+		first.Enabled(false)
+
+		assert.Same(t, second, router.Get("users"))
+	})
+
+	t.Run("URL building fails on disabled route", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/users/{id}", noop).Host("{sub}.example.com").Enabled(false)
+
+		_, err := route.URL("id", "1", "sub", "api")
+		assert.ErrorIs(t, err, ErrRouteDisabled)
+		_, err = route.URLHost("sub", "api")
+		assert.ErrorIs(t, err, ErrRouteDisabled)
+		_, err = route.URLPath("id", "1")
+		assert.ErrorIs(t, err, ErrRouteDisabled)
+	})
+
+	t.Run("disabling a subrouter route removes nested routes", func(t *testing.T) {
+		router := NewRouter()
+		prefix := router.PathPrefix("/beta")
+		sub := prefix.Subrouter()
+		sub.HandleFunc("/feature", noop).Name("feature")
+		prefix.Enabled(false)
+		late := sub.HandleFunc("/late", noop).Name("late")
+
+		assert.Nil(t, router.Get("feature"))
+		assert.Nil(t, router.Get("late"))
+		assert.False(t, late.IsEnabled())
+
+		req := httptest.NewRequest(http.MethodGet, "/beta/feature", nil)
+		assert.False(t, router.Match(req, &RouteMatch{}))
+
+		var count int
+		_ = router.Walk(func(_ *Route, _ *Router, _ []*Route) error {
+			count++
+			return nil
+		})
+		assert.Zero(t, count)
+	})
+
+	t.Run("enabling a disabled route is a no-op", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/beta", noop).Enabled(false).Enabled(true)
+
+		assert.False(t, route.IsEnabled())
+		req := httptest.NewRequest(http.MethodGet, "/beta", nil)
+		assert.False(t, router.Match(req, &RouteMatch{}))
+	})
+}
+
 func TestRouteMetadata(t *testing.T) {
 	t.Run("set and get metadata", func(t *testing.T) {
 		router := NewRouter()
@@ -955,6 +1080,33 @@ func TestRouteGetNamedRoutes(t *testing.T) {
 	})
 }
 
+func TestRouteDoc(t *testing.T) {
+	t.Run("stores summary and description", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/users", func(_ http.ResponseWriter, _ *http.Request) {}).
+			Doc("List users", "Returns users ordered by creation time.")
+		summary, description := route.GetDoc()
+		assert.Equal(t, "List users", summary)
+		assert.Equal(t, "Returns users ordered by creation time.", description)
+	})
+
+	t.Run("empty by default", func(t *testing.T) {
+		summary, description := NewRouter().NewRoute().GetDoc()
+		assert.Empty(t, summary)
+		assert.Empty(t, description)
+	})
+
+	t.Run("does not affect matching", func(t *testing.T) {
+		router := NewRouter()
+		router.HandleFunc("/users", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}).Doc("List users", "")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
 func TestRouteBuildVarsDirect(t *testing.T) {
 	t.Run("returns vars when no buildVarsFunc and no parent", func(t *testing.T) {
 		route := &Route{}
@@ -1463,6 +1615,146 @@ func TestRouteURLWithQueries(t *testing.T) {
 	})
 }
 
+func TestRouteURLEncoding(t *testing.T) {
+	noop := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	t.Run("safe values are unchanged", func(t *testing.T) {
+		router := NewRouter()
+		route := router.Host("{sub}.example.com").Path("/users/{id}/{rest:.*}").HandlerFunc(noop)
+
+		u, err := route.URL("sub", "api", "id", "42", "rest", "a/b")
+		require.NoError(t, err)
+		assert.Equal(t, "http://api.example.com/users/42/a/b", u.String())
+		assert.Empty(t, u.RawPath)
+	})
+
+	t.Run("path values are percent-encoded", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/search/{q}", noop)
+
+		u, err := route.URL("q", "a b?c#d%")
+		require.NoError(t, err)
+		assert.Equal(t, "/search/a b?c#d%", u.Path)
+		assert.Equal(t, "/search/a%20b%3Fc%23d%25", u.String())
+	})
+
+	t.Run("slash in a segment variable is escaped", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/search/{q}/{page}", noop)
+
+		u, err := route.URL("q", "a b/c", "page", "x y")
+		require.NoError(t, err)
+		assert.Equal(t, "/search/a b/c/x y", u.Path)
+		assert.Equal(t, "/search/a%20b%2Fc/x%20y", u.RawPath)
+		assert.Equal(t, "/search/a%20b%2Fc/x%20y", u.String())
+
+		p, err := route.URLPath("q", "a/b", "page", "1")
+		require.NoError(t, err)
+		assert.Equal(t, "/search/a%2Fb/1", p.String())
+	})
+
+	t.Run("literal parts needing escaping keep the escaped slash", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/my files/café/{name}", noop)
+
+		u, err := route.URL("name", "a/b c")
+		require.NoError(t, err)
+		assert.Equal(t, "/my files/café/a/b c", u.Path)
+		assert.Equal(t, "/my%20files/caf%C3%A9/a%2Fb%20c", u.RawPath)
+		assert.Equal(t, "/my%20files/caf%C3%A9/a%2Fb%20c", u.String())
+	})
+
+	t.Run("escaped slash round-trips with UseEncodedPath", func(t *testing.T) {
+		router := NewRouter().UseEncodedPath()
+		var got string
+		route := router.HandleFunc("/files/{name}", func(_ http.ResponseWriter, r *http.Request) {
+			got = Vars(r)["name"]
+		})
+
+		u, err := route.URL("name", "dir/file.txt")
+		require.NoError(t, err)
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, u.String(), nil))
+		assert.Equal(t, "dir/file.txt", got)
+	})
+
+	t.Run("pattern still rejects invalid values", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/users/{id:[0-9]+}", noop)
+
+		_, err := route.URL("id", "1/2")
+		assert.ErrorContains(t, err, "doesn't match")
+	})
+
+	t.Run("invalid host characters", func(t *testing.T) {
+		router := NewRouter()
+		route := router.Host("{sub}.example.com").Path("/").HandlerFunc(noop)
+
+		_, err := route.URL("sub", "a b")
+		assert.ErrorContains(t, err, "invalid host characters")
+
+		_, err = route.URLHost("sub", "a@b")
+		assert.ErrorContains(t, err, "invalid host characters")
+
+		u, err := route.URLHost("sub", "my-api_v2")
+		require.NoError(t, err)
+		assert.Equal(t, "my-api_v2.example.com", u.Host)
+	})
+
+	t.Run("bracketed IPv6 host variable", func(t *testing.T) {
+		router := NewRouter()
+		route := router.Host("{host}").Path("/").HandlerFunc(noop)
+
+		u, err := route.URL("host", "[2001:db8::1]:8080")
+		require.NoError(t, err)
+		assert.Equal(t, "http://[2001:db8::1]:8080/", u.String())
+
+		_, err = route.URLHost("host", "[::1]/x")
+		assert.ErrorContains(t, err, "invalid host characters")
+	})
+}
+
+func TestRouteURLWithQuery(t *testing.T) {
+	noop := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	t.Run("appends query to a route without query templates", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/users/{id}", noop)
+
+		u, err := route.URLWithQuery(url.Values{"tab": {"a b"}, "expand": {"x", "y"}}, "id", "42")
+		require.NoError(t, err)
+		assert.Equal(t, "/users/42?expand=x&expand=y&tab=a+b", u.String())
+	})
+
+	t.Run("appends after filled query templates", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/search", noop).Queries("q", "{query}")
+
+		u, err := route.URLWithQuery(url.Values{"page": {"2"}}, "query", "go lang")
+		require.NoError(t, err)
+		assert.Equal(t, "q=go+lang&page=2", u.RawQuery)
+	})
+
+	t.Run("nil query matches URL", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/users/{id}", noop)
+
+		withQuery, err := route.URLWithQuery(nil, "id", "1")
+		require.NoError(t, err)
+		plain, err := route.URL("id", "1")
+		require.NoError(t, err)
+		assert.Equal(t, plain, withQuery)
+	})
+
+	t.Run("propagates errors", func(t *testing.T) {
+		router := NewRouter()
+		route := router.HandleFunc("/users/{id}", noop)
+
+		_, err := route.URLWithQuery(url.Values{"a": {"b"}})
+		assert.ErrorContains(t, err, "missing route variable")
+	})
+}
+
 func TestRouteGetVarNamesWithQueries(t *testing.T) {
 	t.Run("includes query variable names", func(t *testing.T) {
 		router := NewRouter()
@@ -1706,3 +1998,306 @@ func TestRouteSubrouter(t *testing.T) {
 		assert.Equal(t, "parent 405", w.Body.String())
 	})
 }
+
+type tenantMethodsMatcher struct {
+	methods []string
+}
+
+func (m tenantMethodsMatcher) Match(r *http.Request, match *RouteMatch) bool {
+	if !matchInArray(m.methods, r.Method) {
+		return false
+	}
+	match.Vars["tenant"] = r.Header.Get("X-Tenant")
+	return true
+}
+
+func (m tenantMethodsMatcher) Methods() []string {
+	return m.methods
+}
+
+func TestRouteCustomMatchers(t *testing.T) {
+	t.Run("runs after path and sees path variables", func(t *testing.T) {
+		r := NewRouter()
+		var seen string
+		r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, match *RouteMatch) bool {
+				seen = match.Vars["id"]
+				return true
+			})
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/users/42", nil), match))
+		assert.Equal(t, "42", seen)
+	})
+
+	t.Run("not called when path does not match", func(t *testing.T) {
+		r := NewRouter()
+		called := false
+		r.HandleFunc("/users", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, _ *RouteMatch) bool {
+				called = true
+				return true
+			})
+
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/other", nil), &RouteMatch{}))
+		assert.False(t, called)
+	})
+
+	t.Run("added variables reach the handler", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+			vars := Vars(req)
+			fmt.Fprintf(w, "%s:%s", vars["id"], vars["version"])
+		}).MatcherFunc(func(req *http.Request, match *RouteMatch) bool {
+			match.Vars["version"] = req.Header.Get("X-Version")
+			return true
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		req.Header.Set("X-Version", "v2")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, "42:v2", w.Body.String())
+	})
+
+	t.Run("replaced vars map is merged", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, match *RouteMatch) bool {
+				match.Vars = map[string]string{"extra": "yes"}
+				return true
+			})
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/users/42", nil), match))
+		assert.Equal(t, map[string]string{"id": "42", "extra": "yes"}, match.Vars)
+	})
+
+	t.Run("custom matcher can override variable", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, match *RouteMatch) bool {
+				match.Vars["id"] = "me"
+				return true
+			})
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/users/42", nil), match))
+		assert.Equal(t, "me", match.Vars["id"])
+	})
+
+	t.Run("rejected route leaves no variables", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, match *RouteMatch) bool {
+				match.Vars["leak"] = "yes"
+				return false
+			})
+		r.HandleFunc("/users/{name}", func(_ http.ResponseWriter, _ *http.Request) {})
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/users/42", nil), match))
+		assert.Equal(t, map[string]string{"name": "42"}, match.Vars)
+	})
+
+	t.Run("static route without added variables keeps nil vars", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/health", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, _ *RouteMatch) bool { return true })
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/health", nil), match))
+		assert.Nil(t, match.Vars)
+	})
+
+	t.Run("subrouter route sees parent variables", func(t *testing.T) {
+		r := NewRouter()
+		s := r.PathPrefix("/orgs/{org}").MatcherFunc(func(_ *http.Request, match *RouteMatch) bool {
+			match.Vars["scope"] = "org-" + match.Vars["org"]
+			return true
+		}).Subrouter()
+		s.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {})
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, "/orgs/acme/users/1", nil), match))
+		assert.Equal(t, map[string]string{"org": "acme", "id": "1", "scope": "org-acme"}, match.Vars)
+	})
+
+	t.Run("Matcher registers custom matcher", func(t *testing.T) {
+		r := NewRouter()
+		r.Path("/items").Matcher(tenantMethodsMatcher{methods: []string{http.MethodPost}})
+
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+		req.Header.Set("X-Tenant", "acme")
+		match := &RouteMatch{}
+		require.True(t, r.Match(req, match))
+		assert.Equal(t, "acme", match.Vars["tenant"])
+	})
+
+	t.Run("MethodsReporter rejection is a method mismatch", func(t *testing.T) {
+		r := NewRouter()
+		r.Path("/items").Matcher(tenantMethodsMatcher{methods: []string{http.MethodPost}})
+
+		match := &RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/items", nil), match))
+		assert.Equal(t, ErrMethodMismatch, match.MatchErr)
+		assert.Nil(t, match.Vars)
+	})
+
+	t.Run("MethodsReporter methods in Allow header", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/items", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+		r.Path("/items").Matcher(tenantMethodsMatcher{methods: []string{http.MethodPost, http.MethodPut}})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/items", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD, POST, PUT", w.Header().Get("Allow"))
+	})
+
+	t.Run("non-reporting matcher rejection is not found", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/items", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MatcherFunc(func(_ *http.Request, _ *RouteMatch) bool { return false })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRouteRawVars(t *testing.T) {
+	serveVars := func(r *Router, target string) (int, map[string]string) {
+		var vars map[string]string
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		r.ServeHTTP(w, req)
+		if m := (&RouteMatch{}); r.Match(req, m) {
+			vars = m.Vars
+		}
+		return w.Code, vars
+	}
+	newRouter := func(encoded bool) *Router {
+		r := NewRouter()
+		if encoded {
+			r.UseEncodedPath()
+		}
+		r.HandleFunc("/proxy/{token}/{name}", func(_ http.ResponseWriter, _ *http.Request) {}).RawVars("token")
+		r.HandleFunc("/decoded/{token}", func(_ http.ResponseWriter, _ *http.Request) {})
+		return r
+	}
+
+	for _, encoded := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encoded path %v", encoded), func(t *testing.T) {
+			r := newRouter(encoded)
+
+			for _, tc := range []struct {
+				target string
+				token  string
+				name   string
+			}{
+				{"/proxy/a%2Fb/x%2Fy", "a%2Fb", "x/y"},
+				{"/proxy/100%25/50%25", "100%25", "50%"},
+				{"/proxy/%E2%9C%93/%E2%9C%93", "%E2%9C%93", "✓"},
+				{"/proxy/%2525/n", "%2525", "n"},
+				{"/proxy/plain/n", "plain", "n"},
+			} {
+				code, vars := serveVars(r, tc.target)
+				assert.Equal(t, http.StatusOK, code, tc.target)
+				assert.Equal(t, tc.token, vars["token"], tc.target)
+				assert.Equal(t, tc.name, vars["name"], tc.target)
+			}
+		})
+	}
+
+	t.Run("unicode sent unescaped", func(t *testing.T) {
+		r := newRouter(false)
+		req := httptest.NewRequest(http.MethodGet, "/proxy/x/n", nil)
+		req.URL.Path = "/proxy/✓/n"
+		req.URL.RawPath = ""
+
+		match := &RouteMatch{}
+		require.True(t, r.Match(req, match))
+		assert.Equal(t, "%E2%9C%93", match.Vars["token"])
+	})
+
+	t.Run("vars in handler", func(t *testing.T) {
+		r := NewRouter()
+		var got string
+		r.HandleFunc("/files/{path:.+}", func(_ http.ResponseWriter, req *http.Request) {
+			got = Vars(req)["path"]
+		}).RawVars("path")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/dir/a%2Fb%20c", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "dir/a%2Fb%20c", got)
+	})
+
+	t.Run("other routes still decode", func(t *testing.T) {
+		r := newRouter(false)
+		_, vars := serveVars(r, "/decoded/100%25")
+		assert.Equal(t, "100%", vars["token"])
+	})
+
+	t.Run("url building keeps encoded input", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/proxy/{token}/{name}", func(_ http.ResponseWriter, _ *http.Request) {}).
+			RawVars("token").
+			Name("proxy")
+
+		for _, tc := range []struct {
+			token, name string
+			want        string
+			path        string
+		}{
+			{"a%2Fb", "x", "/proxy/a%2Fb/x", "/proxy/a/b/x"},
+			{"100%25", "50%", "/proxy/100%25/50%25", "/proxy/100%/50%"},
+			{"%E2%9C%93", "✓", "/proxy/%E2%9C%93/%E2%9C%93", "/proxy/✓/✓"},
+			{"plain", "a b", "/proxy/plain/a%20b", "/proxy/plain/a b"},
+		} {
+			u, err := r.Get("proxy").URL("token", tc.token, "name", tc.name)
+			require.NoError(t, err, tc.token)
+			assert.Equal(t, tc.want, u.String(), tc.token)
+			assert.Equal(t, tc.path, u.Path, tc.token)
+
+			// The built URL routes back to the same values.
+			_, vars := serveVars(r, u.String())
+			assert.Equal(t, tc.token, vars["token"], tc.token)
+			assert.Equal(t, tc.name, vars["name"], tc.token)
+		}
+
+		u, err := r.Get("proxy").URLPath("token", "a%2Fb", "name", "x")
+		require.NoError(t, err)
+		assert.Equal(t, "/proxy/a%2Fb/x", u.String())
+	})
+
+	t.Run("url building rejects invalid encoding", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/proxy/{token}", func(_ http.ResponseWriter, _ *http.Request) {}).RawVars("token").Name("proxy")
+
+		_, err := r.Get("proxy").URL("token", "100%")
+		assert.EqualError(t, err, `mux: variable "token" has invalid percent-encoding: "100%"`)
+
+		_, err = r.Get("proxy").URL("token", "a/b")
+		assert.Error(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		r := NewRouter()
+		route := r.NewRoute().RawVars("token")
+		assert.EqualError(t, route.GetError(), "mux: RawVars requires a path template")
+
+		route = r.HandleFunc("/proxy/{token}", func(_ http.ResponseWriter, _ *http.Request) {}).RawVars("missing")
+		assert.EqualError(t, route.GetError(), `mux: raw variable "missing" is not a path variable`)
+	})
+
+	t.Run("does not affect routes sharing the template", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/a/{v}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodPost).RawVars("v")
+		r.HandleFunc("/a/{v}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+
+		_, vars := serveVars(r, "/a/x%25")
+		assert.Equal(t, "x%", vars["v"])
+	})
+}
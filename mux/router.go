@@ -2,12 +2,17 @@ package mux
 
 import (
 	"context"
+	"errors"
+	"iter"
+	"log"
 	"maps"
 	"net/http"
 	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Router registers routes to be matched and dispatches a handler.
@@ -18,6 +23,13 @@ import (
 //	r := mux.NewRouter()
 //	r.HandleFunc("/", handler)
 //	http.ListenAndServe(":8080", r)
+//
+// A Router is safe for concurrent use by multiple goroutines serving
+// requests, but registration (NewRoute and the helpers built on it, Use,
+// Mount, Route.Enabled, and configuring a route) is not synchronized with
+// serving. Register every route before the router starts serving, then
+// call Freeze to make that contract explicit: later registration fails
+// instead of racing with ServeHTTP.
 type Router struct {
 	// NotFoundHandler is called when no route matches.
 	// If nil, http.NotFoundHandler() is used.
@@ -30,11 +42,51 @@ type Router struct {
 	// this handler is invoked.
 	MethodNotAllowedHandler http.Handler
 
+	// PanicHandler is called when a panic is raised while the router is
+	// matching a request (regexps, MatcherFunc, MetadataFunc), before any
+	// route handler has run. If nil, the panic is logged and a 500 Internal
+	// Server Error is written. Panics raised by the dispatched handler are
+	// not recovered here; use a recovery middleware for those.
+	PanicHandler func(w http.ResponseWriter, req *http.Request, err any)
+
+	// TimeoutHandler writes the response when a route exceeds the deadline
+	// set with Route.Timeout. If nil, the handler of the nearest parent
+	// router is used, and 503 Service Unavailable (RFC 9110 Section 15.6.4)
+	// when no router sets one. The request's context is already done.
+	TimeoutHandler http.Handler
+
+	// VarMismatchHandler, when non-nil, is called instead of
+	// NotFoundHandler for a request that matches a route except for the
+	// value of a path variable, such as /users/abc for /users/{id:int}.
+	// err names the route, the variable, and the offending value;
+	// VarMismatchBadRequest replies with 400 Bad Request. The handler of
+	// the router owning the route or of its nearest ancestor that sets one
+	// is used. See VarMismatchError.
+	VarMismatchHandler func(w http.ResponseWriter, req *http.Request, err *VarMismatchError)
+
+	// ConnMismatchHandler, when non-nil, is called instead of
+	// NotFoundHandler for a request that matches a route except for the
+	// protocol set with Route.Proto or the TLS client certificate checked
+	// by Route.TLSClientCert. errors.Is(err, ErrProtoMismatch) and
+	// errors.Is(err, ErrClientCertMismatch) tell which one failed;
+	// ConnMismatchReply replies with 426 Upgrade Required or 403
+	// Forbidden. The handler of the router owning the route or of its
+	// nearest ancestor that sets one is used. See ConnMismatchError.
+	ConnMismatchHandler func(w http.ResponseWriter, req *http.Request, err *ConnMismatchError)
+
+	// Observer, when non-nil, is notified when the router starts and
+	// finishes serving each request, including 404 and 405 responses,
+	// redirects, and panics while matching. See Observer.
+	Observer Observer
+
 	parent      parentRoute
 	routes      []*Route
 	namedRoutes map[string]*Route
 	middlewares []MiddlewareFunc
 
+	// preMatchHooks rewrite the request before cleaning and matching.
+	preMatchHooks []func(*http.Request) *http.Request
+
 	// handlerCache caches the middleware-wrapped handler per route
 	// to avoid re-wrapping on every request.
 	handlerCache sync.Map // map[*Route]http.Handler
@@ -42,8 +94,16 @@ type Router struct {
 	strictSlash    bool
 	skipClean      bool
 	useEncodedPath bool
+	plusAsSpace    bool
+
+	// frozen is set by Freeze; registration on a frozen router fails.
+	frozen atomic.Bool
 }
 
+// ErrRouterFrozen is set as the error of routes registered on a router
+// after Freeze; see Router.Freeze.
+var ErrRouterFrozen = errors.New("mux: router is frozen")
+
 // NewRouter returns a new router instance.
 func NewRouter() *Router {
 	return &Router{
@@ -54,6 +114,24 @@ func NewRouter() *Router {
 // ServeHTTP dispatches the handler registered in the matched route.
 // Implements http.Handler per RFC 9112 Section 1.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.Observer == nil {
+		r.serve(w, req, nil)
+		return
+	}
+
+	obs := &observation{observer: r.Observer, started: time.Now()}
+	defer obs.finish()
+	r.serve(w, req, obs)
+	obs.returned = true
+}
+
+// serve implements ServeHTTP. A non-nil obs is started with the request
+// and its matched route right before the response is produced.
+func (r *Router) serve(w http.ResponseWriter, req *http.Request, obs *observation) {
+	if len(r.preMatchHooks) > 0 {
+		req = r.runPreMatchHooks(req)
+	}
+
 	// RFC 9112 Section 3.2.4 and RFC 9110 Section 7.1: the asterisk-form
 	// "OPTIONS *" requests server-wide options and does not refer to any
 	// particular resource. Handle it before path cleaning would rewrite
@@ -66,6 +144,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			methods = append(methods, http.MethodOptions)
 		}
 		sort.Strings(methods)
+		w = obs.begin(w, req, nil)
 		w.Header().Set("Allow", strings.Join(methods, ", "))
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -85,20 +164,105 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			u := *req.URL
 			u.Path = cleaned
 			u.RawPath = ""
-			http.Redirect(w, req, u.String(), http.StatusPermanentRedirect)
+			http.Redirect(obs.begin(w, req, nil), req, u.String(), http.StatusPermanentRedirect)
 			return
 		}
 	}
 
 	var match RouteMatch
-	var handler http.Handler
+	handler, req, routed, ok := r.resolve(w, req, &match)
+	w = obs.begin(w, req, routed)
+	if !ok {
+		handler.ServeHTTP(w, req)
+		return
+	}
+
+	// Apply strict slash redirect if needed.
+	if match.Route != nil && match.Route.strictSlash {
+		p := strings.TrimSuffix(req.URL.Path, "/")
+		tpl := match.Route.regexp.path
+		if tpl != nil {
+			tplPath := tpl.template
+			// Check if template ends with slash but URL doesn't, or vice versa.
+			tplHasSlash := strings.HasSuffix(tplPath, "/")
+			urlHasSlash := strings.HasSuffix(req.URL.Path, "/")
+			if tplHasSlash != urlHasSlash && p != "" {
+				u := *req.URL
+				if tplHasSlash {
+					u.Path += "/"
+				} else {
+					u.Path = strings.TrimSuffix(u.Path, "/")
+				}
+				// RFC 9110 Section 15.4.9: 308 preserves the request
+				// method, unlike 301 which allows clients to change
+				// POST to GET.
+				http.Redirect(w, req, u.String(), http.StatusPermanentRedirect)
+				return
+			}
+		}
+	}
+
+	handler.ServeHTTP(w, req)
+}
 
-	if r.Match(req, &match) {
+// runPreMatchHooks passes req through the pre-match hooks in order. A hook
+// returning nil leaves the request unchanged. A RawPath that no longer
+// encodes the rewritten Path is dropped, so UseEncodedPath cannot match a
+// stale encoded path.
+func (r *Router) runPreMatchHooks(req *http.Request) *http.Request {
+	for _, hook := range r.preMatchHooks {
+		if out := hook(req); out != nil {
+			req = out
+		}
+	}
+
+	if req.URL.RawPath != "" && req.URL.EscapedPath() != req.URL.RawPath {
+		u := *req.URL
+		u.RawPath = ""
+		req = req.WithContext(req.Context())
+		req.URL = &u
+	}
+
+	return req
+}
+
+// resolve runs the matching phase of ServeHTTP and returns the handler to
+// dispatch together with the request carrying the route context, and the
+// matched route, which is nil when the request is answered by a 404 or 405
+// handler. A panic raised while matching is recovered, in which case ok is
+// false and the handler passes it to handleMatchPanic.
+func (r *Router) resolve(w http.ResponseWriter, req *http.Request, match *RouteMatch) (handler http.Handler, out *http.Request, routed *Route, ok bool) {
+	in := req
+	defer func() {
+		if err := recover(); err != nil {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r.handleMatchPanic(w, req, err)
+			})
+			out, routed, ok = in, nil, false
+		}
+	}()
+
+	// The route and router are stored in the request context before any
+	// middleware runs, so the outermost middleware can already read them
+	// through CurrentRoute and CurrentRouter. The router is the innermost
+	// one that handled the request: the subrouter owning the route, or the
+	// subrouter whose prefix matched a request that none of its routes did.
+	var route *Route
+	if r.Match(req, match) {
 		handler = match.Handler
 		if handler == nil {
 			handler = defaultNotFoundHandler
 		}
-		req = setRouteContext(req, match.Route, match.Vars)
+		route = match.Route
+		if !match.fallback {
+			routed = route
+		}
+		if match.fallback && match.mismatchRoute != nil {
+			// A subrouter's 405 handler sees the route whose method
+			// did not match rather than the subrouter's mount route.
+			route = match.mismatchRoute
+		}
+		req = setRouteContext(req, route, match.Vars)
 
 		if match.Route != nil && match.Route.metadataFunc != nil {
 			merged := make(map[any]any)
@@ -117,56 +281,80 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			if handler == nil {
 				handler = defaultMethodNotAllowedHandler
 			}
+			if match.mismatchRoute != nil {
+				route = match.mismatchRoute
+				req = setRouteContext(req, route, nil)
+			}
+		} else if respond := connMismatchResponderFor(match.MatchErr); respond != nil {
+			mismatch := match.MatchErr.(*ConnMismatchError)
+			route = mismatch.Route
+			req = setRouteContext(req, route, nil)
+			handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				respond(w, req, mismatch)
+			})
+		} else if respond := varMismatchResponderFor(match.MatchErr); respond != nil {
+			mismatch := match.MatchErr.(*VarMismatchError)
+			route = mismatch.Route
+			req = setRouteContext(req, route, nil)
+			handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				respond(w, req, mismatch)
+			})
 		} else {
 			handler = r.NotFoundHandler
 			if handler == nil {
 				handler = defaultNotFoundHandler
 			}
 		}
+		// A request that fell into a subrouter's prefix gets the middleware
+		// of that subrouter and its ancestors, as a matched route would.
+		if len(match.fallbackRouters) > 0 {
+			handler = r.applyMiddleware(wrapFallback(handler, match.fallbackRouters))
+		}
 	}
 
-	// Store the innermost router in context. When the matched route
-	// belongs to a subrouter, use that subrouter; otherwise use this router.
-	matchedRouter := r
-	if match.Route != nil {
-		if router, ok := match.Route.parent.(*Router); ok {
-			matchedRouter = router
+	router := r
+	if route != nil {
+		if parent, ok := route.parent.(*Router); ok {
+			router = parent
 		}
+	} else if len(match.fallbackRouters) > 0 {
+		router = match.fallbackRouters[0]
 	}
-	req = req.WithContext(context.WithValue(req.Context(), routerCtxKey, matchedRouter))
+	ctx := context.WithValue(req.Context(), routerCtxKey, router)
 
-	// Apply strict slash redirect if needed.
-	if match.Route != nil && match.Route.strictSlash {
-		p := strings.TrimSuffix(req.URL.Path, "/")
-		tpl := match.Route.regexp.path
-		if tpl != nil {
-			tplPath := tpl.template
-			// Check if template ends with slash but URL doesn't, or vice versa.
-			tplHasSlash := strings.HasSuffix(tplPath, "/")
-			urlHasSlash := strings.HasSuffix(req.URL.Path, "/")
-			if tplHasSlash != urlHasSlash && p != "" {
-				u := *req.URL
-				if tplHasSlash {
-					u.Path += "/"
-				} else {
-					u.Path = strings.TrimSuffix(u.Path, "/")
-				}
-				// RFC 9110 Section 15.4.9: 308 preserves the request
-				// method, unlike 301 which allows clients to change
-				// POST to GET.
-				http.Redirect(w, req, u.String(), http.StatusPermanentRedirect)
-				return
-			}
+	// Requests answered by a 404 or 405 handler carry the path they were
+	// matched against, for RequestPaths.
+	if match.Route == nil || match.fallback {
+		original := req.URL.Path
+		if r.useEncodedPath {
+			original = requestURIPath(req.URL)
 		}
+		ctx = context.WithValue(ctx, requestPathsCtxKey, requestPaths{original: original, cleaned: cleanPath(original)})
 	}
+	req = req.WithContext(ctx)
 
-	handler.ServeHTTP(w, req)
+	return handler, req, routed, true
+}
+
+// handleMatchPanic reports a panic recovered during route matching through
+// PanicHandler, or logs it and replies with 500 Internal Server Error.
+func (r *Router) handleMatchPanic(w http.ResponseWriter, req *http.Request, err any) {
+	if r.PanicHandler != nil {
+		r.PanicHandler(w, req, err)
+		return
+	}
+	log.Printf("mux: panic while matching %s %s: %v", req.Method, req.URL.Path, err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 }
 
 // Match attempts to match the given request against the router's routes.
 // Distinguishes between 404 Not Found (RFC 9110 Section 15.5.5) and
 // 405 Method Not Allowed (RFC 9110 Section 15.5.6) by tracking method
-// mismatches independently across route iteration.
+// mismatches independently across route iteration. When no route
+// matches, MatchErr is a *ConnMismatchError if the request matched a
+// route except for its protocol or TLS client certificate; otherwise a
+// root router sets it to a *VarMismatchError if the request matched a
+// route except for the value of a path variable.
 func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
 	var methodNotAllowed bool
 	for _, route := range r.routes {
@@ -183,8 +371,13 @@ func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
 				// router only adds its own router-level middleware on top.
 				ownsRoute := match.Route.parent == r
 				needsWrap := len(r.middlewares) > 0 ||
-					(ownsRoute && len(match.Route.middlewares) > 0)
-				if needsWrap {
+					(ownsRoute && match.Route.hasRouteWrappers())
+				if needsWrap && match.fallback {
+					if ownsRoute {
+						match.Handler = match.Route.applyMiddleware(match.Handler)
+					}
+					match.Handler = r.applyMiddleware(match.Handler)
+				} else if needsWrap {
 					if cached, ok := r.handlerCache.Load(match.Route); ok {
 						match.Handler = cached.(http.Handler)
 					} else {
@@ -205,6 +398,13 @@ func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
 		}
 	}
 
+	// A route that accepts the method but not the connection outranks
+	// routes rejecting the method, whose Allow list would mislead.
+	if match.connMismatch != nil {
+		match.MatchErr = match.connMismatch
+		return false
+	}
+
 	if methodNotAllowed {
 		match.MatchErr = ErrMethodMismatch
 		match.methodNotAllowed = true
@@ -212,6 +412,14 @@ func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
 	}
 
 	match.MatchErr = ErrNotFound
+	// A request that nearly matched a route reports the variable that
+	// failed. The root router searches the whole tree once, so nested
+	// routers do not repeat the search.
+	if r.parent == nil {
+		if err := r.findVarMismatch(req, match); err != nil {
+			match.MatchErr = err
+		}
+	}
 	return false
 }
 
@@ -238,6 +446,27 @@ func (r *Router) UseEncodedPath() *Router {
 	return r
 }
 
+// PlusInPathIsSpace defines how new routes read '+' in path variables.
+// When true, a literal '+' in a matched path variable is stored in Vars as
+// a space, the way legacy clients that form-encode paths mean it, while
+// %2B still decodes to a literal '+'. The translation applies with and
+// without UseEncodedPath and skips variables declared with RawVars. The
+// default, false, keeps '+' as is, following RFC 3986 Section 3.3 where
+// '+' has no special meaning in a path.
+//
+// URL building always encodes spaces as %20, never '+'. When the option is
+// on, a '+' in a variable value is encoded as %2B, so that the built URL
+// matches back to the same value.
+//
+//	r := mux.NewRouter().PlusInPathIsSpace(true)
+//	r.HandleFunc("/search/{q}", search)
+//	// GET /search/foo+bar   -> Vars: q="foo bar"
+//	// GET /search/foo%2Bbar -> Vars: q="foo+bar"
+func (r *Router) PlusInPathIsSpace(value bool) *Router {
+	r.plusAsSpace = value
+	return r
+}
+
 // GetStrictSlash reports whether the router has strict slash behavior enabled.
 func (r *Router) GetStrictSlash() bool {
 	return r.strictSlash
@@ -254,16 +483,83 @@ func (r *Router) GetUseEncodedPath() bool {
 	return r.useEncodedPath
 }
 
+// GetPlusInPathIsSpace reports whether the router stores '+' in path
+// variables as a space.
+func (r *Router) GetPlusInPathIsSpace() bool {
+	return r.plusAsSpace
+}
+
 // --- Route factory methods ---
 
-// NewRoute creates an empty route for configuration.
+// Freeze makes the routing table of r and its subrouters read-only, so
+// that it can be served without synchronization. After Freeze, NewRoute
+// and every helper built on it (Handle, HandleFunc, PathPrefix, Mount, and
+// so on) return a detached route whose GetError is ErrRouterFrozen: it is
+// neither added to the router nor registered by name, so it never
+// matches. Mounting a frozen router sets the same error on the returned
+// route. Route.Subrouter on a route of a frozen router returns a detached,
+// frozen router. Use, PreMatchHook, and Route.Enabled(false) leave the
+// router unchanged. Every rejected registration is also logged, so that
+// late registration does not fail silently. Subrouters created with
+// Route.Subrouter or attached with Mount are frozen along with r.
+//
+// Routes registered before Freeze must not be reconfigured afterwards;
+// Freeze guards the route list, named routes, and middleware chain, not
+// the individual routes. Freeze cannot be undone.
+//
+//	r := mux.NewRouter()
+//	r.HandleFunc("/users", listUsers)
+//	r.Freeze()
+//	http.ListenAndServe(":8080", r)
+func (r *Router) Freeze() *Router {
+	r.frozen.Store(true)
+	for _, route := range r.routes {
+		if sr, ok := route.handler.(*Router); ok {
+			sr.Freeze()
+		}
+	}
+	return r
+}
+
+// frozenErr logs a registration attempt rejected by Freeze and returns
+// ErrRouterFrozen.
+func frozenErr(what string) error {
+	log.Printf("mux: %s after Freeze is ignored", what)
+	return ErrRouterFrozen
+}
+
+// frozenRouter returns a detached router that is already frozen.
+func frozenRouter() *Router {
+	r := &Router{namedRoutes: make(map[string]*Route)}
+	r.frozen.Store(true)
+	return r
+}
+
+// IsFrozen reports whether Freeze has been called on r or on a router it
+// is a subrouter of.
+func (r *Router) IsFrozen() bool {
+	return r.frozen.Load()
+}
+
+// NewRoute creates an empty route for configuration. On a frozen router it
+// returns a detached route with ErrRouterFrozen set; see Freeze.
 func (r *Router) NewRoute() *Route {
+	if r.frozen.Load() {
+		return &Route{
+			err:            frozenErr("route registration"),
+			strictSlash:    r.strictSlash,
+			skipClean:      r.skipClean,
+			useEncodedPath: r.useEncodedPath,
+			plusAsSpace:    r.plusAsSpace,
+		}
+	}
 	route := &Route{
 		parent:         r,
 		namedRoutes:    r.namedRoutes,
 		strictSlash:    r.strictSlash,
 		skipClean:      r.skipClean,
 		useEncodedPath: r.useEncodedPath,
+		plusAsSpace:    r.plusAsSpace,
 	}
 	r.routes = append(r.routes, route)
 	return route
@@ -280,6 +576,20 @@ func (r *Router) HandleFunc(path string, f func(http.ResponseWriter, *http.Reque
 	return r.NewRoute().Path(path).HandlerFunc(f)
 }
 
+// HandleIf registers a new route like Handle, but only when cond is true.
+// The returned route can still be configured; when cond is false it is
+// detached from the router (see Route.Enabled).
+func (r *Router) HandleIf(cond bool, path string, handler http.Handler) *Route {
+	return r.Handle(path, handler).Enabled(cond)
+}
+
+// HandleFuncIf registers a new route like HandleFunc, but only when cond is
+// true. The returned route can still be configured; when cond is false it is
+// detached from the router (see Route.Enabled).
+func (r *Router) HandleFuncIf(cond bool, path string, f func(http.ResponseWriter, *http.Request)) *Route {
+	return r.HandleFunc(path, f).Enabled(cond)
+}
+
 // Path registers a new route with a matcher for the URL path.
 func (r *Router) Path(tpl string) *Route {
 	return r.NewRoute().Path(tpl)
@@ -308,6 +618,104 @@ func (r *Router) Group(fn func(sub *Router)) *Router {
 	return r
 }
 
+// Mount grafts child, a router built on its own with NewRouter, under the
+// given path prefix and returns the route it is mounted on. The child
+// becomes a subrouter of r: its routes, middleware, and NotFoundHandler and
+// MethodNotAllowedHandler behave exactly as if they had been registered on
+// r.PathPrefix(prefix).Subrouter(). Path templates are rebuilt with the
+// prefix, so variables in the prefix reach Vars and URL building; named
+// routes of the child are registered with r, so Get and Reverse find them
+// (a name already used in r is taken over by the child's route, as with
+// Name); and Walk descends into the child.
+//
+//	billing := mux.NewRouter()
+//	billing.HandleFunc("/invoices/{id}", getInvoice).Name("invoice")
+//	billing.Use(billingAuth)
+//
+//	r.Mount("/orgs/{org}/billing", billing)
+//	// GET /orgs/acme/billing/invoices/7 -> Vars: org=acme, id=7
+//
+// StrictSlash, SkipClean, UseEncodedPath, and PlusInPathIsSpace are
+// combined: a setting enabled on either r or child applies to every route
+// of child, including routes registered on child after mounting. Only r's
+// PanicHandler is used, since matching starts at the top-level router.
+//
+// A router can be mounted once. Mounting a router that is already mounted
+// or is a subrouter, mounting r into itself or into one of its own
+// subrouters, or a path conflict such as a variable declared both in the
+// prefix and in a child route sets an error on the returned route.
+// Mounting into a frozen router, or mounting a frozen router, sets
+// ErrRouterFrozen.
+func (r *Router) Mount(prefix string, child *Router) *Route {
+	route := r.PathPrefix(prefix)
+	if route.err != nil {
+		return route
+	}
+	switch {
+	case child == nil:
+		route.err = errors.New("mux: cannot mount a nil router")
+	case child.parent != nil:
+		route.err = errors.New("mux: router is already mounted")
+	case child.contains(r):
+		route.err = errors.New("mux: cannot mount a router into itself")
+	case child.frozen.Load():
+		route.err = frozenErr("mounting a frozen router")
+	}
+	if route.err != nil {
+		return route
+	}
+
+	child.strictSlash = child.strictSlash || route.strictSlash
+	child.skipClean = child.skipClean || route.skipClean
+	child.useEncodedPath = child.useEncodedPath || route.useEncodedPath
+	child.plusAsSpace = child.plusAsSpace || route.plusAsSpace
+	child.parent = route
+	route.handler = child
+	maps.Copy(route.namedRoutes, child.namedRoutes)
+	route.err = child.graft(route.namedRoutes, strings.TrimRight(route.regexp.path.template, "/"))
+	return route
+}
+
+// contains reports whether target is r or one of the subrouters below it.
+func (r *Router) contains(target *Router) bool {
+	if r == target {
+		return true
+	}
+	for _, route := range r.routes {
+		if sr, ok := route.handler.(*Router); ok && sr.contains(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// graft moves the routes of a mounted router and its subrouters under the
+// mount route: they adopt the parent's named-route map and the router's
+// settings, and every path template is prefixed with the mount path.
+func (r *Router) graft(names map[string]*Route, prefix string) error {
+	r.namedRoutes = names
+	for _, route := range r.routes {
+		route.namedRoutes = names
+		route.strictSlash = route.strictSlash || r.strictSlash
+		route.skipClean = route.skipClean || r.skipClean
+		route.useEncodedPath = route.useEncodedPath || r.useEncodedPath
+		route.plusAsSpace = route.plusAsSpace || r.plusAsSpace
+		if err := route.prefixPath(prefix); err != nil {
+			return err
+		}
+		if sr, ok := route.handler.(*Router); ok {
+			sr.strictSlash = sr.strictSlash || route.strictSlash
+			sr.skipClean = sr.skipClean || route.skipClean
+			sr.useEncodedPath = sr.useEncodedPath || route.useEncodedPath
+			sr.plusAsSpace = sr.plusAsSpace || route.plusAsSpace
+			if err := sr.graft(names, prefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Host registers a new route with a matcher for the URL host.
 func (r *Router) Host(tpl string) *Route {
 	return r.NewRoute().Host(tpl)
@@ -344,6 +752,11 @@ func (r *Router) MatcherFunc(f MatcherFunc) *Route {
 	return r.NewRoute().MatcherFunc(f)
 }
 
+// Matcher registers a new route with a custom matcher.
+func (r *Router) Matcher(m Matcher) *Route {
+	return r.NewRoute().Matcher(m)
+}
+
 // Name registers a new route with the given name.
 func (r *Router) Name(name string) *Route {
 	return r.NewRoute().Name(name)
@@ -411,6 +824,33 @@ func (r *Router) buildVars(m map[string]string) map[string]string {
 	return m
 }
 
+// wrapFallback wraps a 404 or 405 handler with the middleware of routers,
+// innermost first, so the outermost router's middleware runs first.
+func wrapFallback(handler http.Handler, routers []*Router) http.Handler {
+	for _, router := range routers {
+		handler = router.applyMiddleware(handler)
+	}
+	return handler
+}
+
+// ownerRouters yields the router owning route and then each of its
+// ancestor routers, innermost first.
+func ownerRouters(route *Route) iter.Seq[*Router] {
+	return func(yield func(*Router) bool) {
+		for parent := route.parent; parent != nil; {
+			router, ok := parent.(*Router)
+			if !ok || !yield(router) || router.parent == nil {
+				return
+			}
+			owner, ok := router.parent.(*Route)
+			if !ok {
+				return
+			}
+			parent = owner.parent
+		}
+	}
+}
+
 // applyMiddleware wraps the handler with all registered middleware.
 func (r *Router) applyMiddleware(handler http.Handler) http.Handler {
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
@@ -420,11 +860,53 @@ func (r *Router) applyMiddleware(handler http.Handler) http.Handler {
 }
 
 // Use appends a MiddlewareFunc to the chain. Middleware is applied to
-// matched handlers only.
+// matched handlers only. After Freeze, Use logs the attempt and leaves the
+// chain unchanged.
 func (r *Router) Use(mwf ...MiddlewareFunc) {
+	if r.frozen.Load() {
+		_ = frozenErr("Use")
+		return
+	}
 	r.middlewares = append(r.middlewares, mwf...)
 }
 
+// PreMatchHook appends hooks that rewrite the request at the top of
+// ServeHTTP, before anything else looks at it. Hooks run in order, each
+// receiving the request returned by the previous one; returning nil keeps
+// the request unchanged. The final request is used for everything
+// downstream: path cleaning, matching, StrictSlash redirects, Vars, and
+// the handler.
+//
+// Hooks run before the SkipClean and UseEncodedPath steps, so those
+// settings apply to the rewritten path: unless SkipClean is set, a hook
+// that produces an unclean path triggers the usual 308 redirect to the
+// cleaned rewritten path. A hook that changes URL.Path should set
+// URL.RawPath to match or clear it; a RawPath that does not encode the new
+// Path is discarded.
+//
+// Hooks only run when this router serves the request directly; a router
+// mounted under another router is matched through its parent's ServeHTTP.
+// After Freeze, PreMatchHook logs the attempt and adds no hooks.
+//
+//	r.PreMatchHook(func(req *http.Request) *http.Request {
+//	    path, ok := strings.CutPrefix(req.URL.Path, "/v1.1/")
+//	    if !ok {
+//	        return nil
+//	    }
+//	    u := *req.URL
+//	    u.Path, u.RawPath = "/v1/"+path, ""
+//	    out := req.WithContext(req.Context())
+//	    out.URL = &u
+//	    return out
+//	})
+func (r *Router) PreMatchHook(hooks ...func(*http.Request) *http.Request) {
+	if r.frozen.Load() {
+		_ = frozenErr("PreMatchHook")
+		return
+	}
+	r.preMatchHooks = append(r.preMatchHooks, hooks...)
+}
+
 // With creates a MiddlewareRoute that carries the given middleware. Routes
 // registered through the returned MiddlewareRoute will have the middleware
 // applied via Route.Use, without affecting other routes on this router.
@@ -0,0 +1,34 @@
+//go:build !race
+
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The race detector instruments the matcher with allocations of its own, so
+// this test only runs without it.
+func TestRouterMatchStaticAllocations(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+	r.HandleFunc("/health", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/status", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	for _, path := range []string{"/health", "/api/status"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		var match RouteMatch
+		allocs := testing.AllocsPerRun(100, func() {
+			match = RouteMatch{}
+			if !r.Match(req, &match) {
+				t.Fatalf("%s did not match", path)
+			}
+		})
+		assert.Zero(t, allocs, path)
+		assert.Nil(t, match.Vars, path)
+	}
+}
@@ -1,10 +1,16 @@
 package mux
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -232,6 +238,112 @@ func TestRouterGetUseEncodedPath(t *testing.T) {
 	})
 }
 
+func TestRouterPlusInPathIsSpace(t *testing.T) {
+	match := func(t *testing.T, r *Router, target string) map[string]string {
+		t.Helper()
+		var m RouteMatch
+		require.True(t, r.Match(httptest.NewRequest(http.MethodGet, target, nil), &m), target)
+		return m.Vars
+	}
+
+	cases := []struct {
+		target string
+		off    string
+		on     string
+	}{
+		{"/search/foo+bar", "foo+bar", "foo bar"},
+		{"/search/foo%2Bbar", "foo+bar", "foo+bar"},
+		{"/search/foo%2bbar", "foo+bar", "foo+bar"},
+		{"/search/foo%20bar", "foo bar", "foo bar"},
+		{"/search/a+b%2Bc", "a+b+c", "a b+c"},
+		{"/search/++", "++", "  "},
+	}
+
+	for _, encoded := range []bool{false, true} {
+		for _, plus := range []bool{false, true} {
+			name := fmt.Sprintf("encoded=%t plus=%t", encoded, plus)
+			t.Run(name, func(t *testing.T) {
+				r := NewRouter().PlusInPathIsSpace(plus)
+				if encoded {
+					r.UseEncodedPath()
+				}
+				r.HandleFunc("/search/{q}", func(http.ResponseWriter, *http.Request) {})
+
+				for _, c := range cases {
+					want := c.off
+					if plus {
+						want = c.on
+					}
+					assert.Equal(t, map[string]string{"q": want}, match(t, r, c.target), c.target)
+				}
+			})
+		}
+	}
+
+	t.Run("default is off", func(t *testing.T) {
+		r := NewRouter()
+		assert.False(t, r.GetPlusInPathIsSpace())
+		r.PlusInPathIsSpace(true)
+		assert.True(t, r.GetPlusInPathIsSpace())
+	})
+
+	t.Run("several variables", func(t *testing.T) {
+		r := NewRouter().PlusInPathIsSpace(true)
+		r.HandleFunc("/{a}/{b:[a-z+ ]+}/{c:.*}", func(http.ResponseWriter, *http.Request) {})
+		assert.Equal(t, map[string]string{"a": "x y", "b": "p+q", "c": "1 2/3+4"}, match(t, r, "/x+y/p%2Bq/1+2/3%2B4"))
+	})
+
+	t.Run("subrouter inherits", func(t *testing.T) {
+		r := NewRouter().PlusInPathIsSpace(true)
+		api := r.PathPrefix("/api").Subrouter()
+		api.HandleFunc("/search/{q}", func(http.ResponseWriter, *http.Request) {})
+		assert.Equal(t, map[string]string{"q": "foo bar"}, match(t, r, "/api/search/foo+bar"))
+	})
+
+	t.Run("mount combines", func(t *testing.T) {
+		r := NewRouter()
+		child := NewRouter().PlusInPathIsSpace(true)
+		child.HandleFunc("/search/{q}", func(http.ResponseWriter, *http.Request) {})
+		r.Mount("/v1/{org}", child)
+		assert.Equal(t, map[string]string{"org": "a b", "q": "c d"}, match(t, r, "/v1/a+b/search/c+d"))
+	})
+
+	t.Run("raw vars keep plus", func(t *testing.T) {
+		r := NewRouter().PlusInPathIsSpace(true)
+		r.HandleFunc("/files/{name}/{ref}", func(http.ResponseWriter, *http.Request) {}).RawVars("name")
+		assert.Equal(t, map[string]string{"name": "a+b%2Bc", "ref": "x y"}, match(t, r, "/files/a+b%2Bc/x+y"))
+	})
+
+	t.Run("query and host are not translated", func(t *testing.T) {
+		r := NewRouter().PlusInPathIsSpace(true)
+		r.Host("{sub}.example.com").Path("/p/{q}").Queries("f", "{f}")
+		var m RouteMatch
+		req := httptest.NewRequest(http.MethodGet, "http://a.example.com/p/x+y?f=1%2B2", nil)
+		require.True(t, r.Match(req, &m))
+		assert.Equal(t, map[string]string{"sub": "a", "q": "x y", "f": "1+2"}, m.Vars)
+	})
+
+	t.Run("url building", func(t *testing.T) {
+		for _, plus := range []bool{false, true} {
+			r := NewRouter().PlusInPathIsSpace(plus)
+			route := r.HandleFunc("/search/{q}/{page}", func(http.ResponseWriter, *http.Request) {})
+
+			u, err := route.URL("q", "a b", "page", "1")
+			require.NoError(t, err)
+			assert.Equal(t, "/search/a%20b/1", u.String())
+
+			u, err = route.URL("q", "a b+c", "page", "x+y")
+			require.NoError(t, err)
+			if plus {
+				assert.Equal(t, "/search/a%20b%2Bc/x%2By", u.String())
+			} else {
+				assert.Equal(t, "/search/a%20b+c/x+y", u.String())
+			}
+			assert.Equal(t, map[string]string{"q": "a b+c", "page": "x+y"}, match(t, r, u.String()))
+		}
+	})
+}
+
 func TestRouterMatch(t *testing.T) {
 	t.Run("matches first registered route", func(t *testing.T) {
 		r := NewRouter()
@@ -738,6 +850,20 @@ func BenchmarkRouterMatch(b *testing.B) {
 	}
 }
 
+func BenchmarkRouterMatchStatic(b *testing.B) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+	r.HandleFunc("/health", func(_ http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	var match RouteMatch
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		match = RouteMatch{}
+		r.Match(req, &match)
+	}
+}
+
 func BenchmarkRouterNotFound(b *testing.B) {
 	r := NewRouter()
 	r.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {})
@@ -909,6 +1035,158 @@ func TestSubrouterNotFoundHandler(t *testing.T) {
 	})
 }
 
+func TestSubrouterFallbackMiddleware(t *testing.T) {
+	tracer := func(order *[]string, name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				*order = append(*order, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	respond := func(order *[]string, name string, code int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			*order = append(*order, name)
+			w.WriteHeader(code)
+		})
+	}
+	serve := func(r *Router, method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+	noop := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	newTree := func(order *[]string) (*Router, *Router, *Router) {
+		r := NewRouter()
+		r.Use(tracer(order, "root"))
+		api := r.PathPrefix("/api").Subrouter()
+		api.Use(tracer(order, "api"))
+		v1 := api.PathPrefix("/v1").Subrouter()
+		v1.Use(tracer(order, "v1"))
+		v1.HandleFunc("/users", noop).Methods(http.MethodGet)
+		return r, api, v1
+	}
+
+	t.Run("inherited not found handler gets subrouter middleware", func(t *testing.T) {
+		var order []string
+		r, _, _ := newTree(&order)
+		r.NotFoundHandler = respond(&order, "root 404", http.StatusNotFound)
+
+		w := serve(r, http.MethodGet, "/api/v1/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, []string{"root", "api", "v1", "root 404"}, order)
+	})
+
+	t.Run("default not found handler gets subrouter middleware", func(t *testing.T) {
+		var order []string
+		r, _, _ := newTree(&order)
+
+		w := serve(r, http.MethodGet, "/api/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, []string{"root", "api"}, order)
+	})
+
+	t.Run("root level not found skips subrouter middleware", func(t *testing.T) {
+		var order []string
+		r, _, _ := newTree(&order)
+		r.NotFoundHandler = respond(&order, "root 404", http.StatusNotFound)
+
+		w := serve(r, http.MethodGet, "/other")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, []string{"root 404"}, order)
+	})
+
+	t.Run("ancestor not found handler includes deeper middleware once", func(t *testing.T) {
+		var order []string
+		r, api, _ := newTree(&order)
+		api.NotFoundHandler = respond(&order, "api 404", http.StatusNotFound)
+
+		serve(r, http.MethodGet, "/api/v1/missing")
+		assert.Equal(t, []string{"root", "api", "v1", "api 404"}, order)
+
+		order = nil
+		serve(r, http.MethodGet, "/api/missing")
+		assert.Equal(t, []string{"root", "api", "api 404"}, order)
+	})
+
+	t.Run("own not found handler nested", func(t *testing.T) {
+		var order []string
+		r, _, v1 := newTree(&order)
+		v1.NotFoundHandler = respond(&order, "v1 404", http.StatusNotFound)
+
+		serve(r, http.MethodGet, "/api/v1/missing")
+		assert.Equal(t, []string{"root", "api", "v1", "v1 404"}, order)
+	})
+
+	t.Run("inherited method not allowed handler gets subrouter middleware", func(t *testing.T) {
+		var order []string
+		r, _, _ := newTree(&order)
+		r.MethodNotAllowedHandler = respond(&order, "root 405", http.StatusMethodNotAllowed)
+
+		w := serve(r, http.MethodPost, "/api/v1/users")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+		assert.Equal(t, []string{"root", "api", "v1", "root 405"}, order)
+	})
+
+	t.Run("ancestor method not allowed handler includes deeper middleware", func(t *testing.T) {
+		var order []string
+		r, api, _ := newTree(&order)
+		api.MethodNotAllowedHandler = respond(&order, "api 405", http.StatusMethodNotAllowed)
+
+		w := serve(r, http.MethodDelete, "/api/v1/users")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, []string{"root", "api", "v1", "api 405"}, order)
+	})
+
+	t.Run("not found and method not allowed are not cached", func(t *testing.T) {
+		var order []string
+		r, api, _ := newTree(&order)
+		api.HandleFunc("/items", noop).Methods(http.MethodPut)
+		api.NotFoundHandler = respond(&order, "api 404", http.StatusNotFound)
+		api.MethodNotAllowedHandler = respond(&order, "api 405", http.StatusMethodNotAllowed)
+
+		assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/api/missing").Code)
+
+		w := serve(r, http.MethodPost, "/api/items")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "PUT", w.Header().Get("Allow"))
+
+		w = serve(r, http.MethodPost, "/api/v1/users")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+
+		order = nil
+		assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/api/v1/missing").Code)
+		assert.Equal(t, []string{"root", "api", "v1", "api 404"}, order)
+	})
+
+	t.Run("later routes still match after subrouter prefix", func(t *testing.T) {
+		var order []string
+		r, _, _ := newTree(&order)
+		r.Handle("/api/health", respond(&order, "health", http.StatusOK))
+
+		w := serve(r, http.MethodGet, "/api/health")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"root", "health"}, order)
+	})
+
+	t.Run("first matching subrouter is used", func(t *testing.T) {
+		var order []string
+		r := NewRouter()
+		first := r.PathPrefix("/api").Subrouter()
+		first.Use(tracer(&order, "first"))
+		first.HandleFunc("/a", noop)
+		second := r.PathPrefix("/api").Subrouter()
+		second.Use(tracer(&order, "second"))
+		second.HandleFunc("/b", noop)
+
+		serve(r, http.MethodGet, "/api/missing")
+		assert.Equal(t, []string{"first"}, order)
+	})
+}
+
 func TestMethodNotAllowedAllowHeader(t *testing.T) {
 	t.Run("sets sorted Allow header on 405", func(t *testing.T) {
 		r := NewRouter()
@@ -1412,6 +1690,358 @@ func TestRouterGroup(t *testing.T) {
 	})
 }
 
+func TestRouterFreeze(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	// captureLog redirects the standard logger for the rest of the test.
+	captureLog := func(t *testing.T) *bytes.Buffer {
+		t.Helper()
+		var buf bytes.Buffer
+		out := log.Writer()
+		log.SetOutput(&buf)
+		t.Cleanup(func() { log.SetOutput(out) })
+		return &buf
+	}
+
+	t.Run("registration after freeze fails", func(t *testing.T) {
+		logs := captureLog(t)
+		r := NewRouter()
+		r.HandleFunc("/users", ok).Name("users")
+		apiRoute := r.PathPrefix("/api")
+		sub := apiRoute.Subrouter()
+		sub.HandleFunc("/items", ok)
+
+		assert.False(t, r.IsFrozen())
+		r.Freeze()
+		assert.True(t, r.IsFrozen())
+		assert.True(t, sub.IsFrozen())
+
+		late := r.HandleFunc("/late", ok).Name("late")
+		assert.ErrorIs(t, late.GetError(), ErrRouterFrozen)
+		assert.Nil(t, r.Get("late"))
+		assert.Contains(t, logs.String(), "mux: route registration after Freeze is ignored")
+
+		subLate := sub.HandleFunc("/late", ok)
+		assert.ErrorIs(t, subLate.GetError(), ErrRouterFrozen)
+
+		lateSub := r.PathPrefix("/late").Subrouter()
+		assert.True(t, lateSub.IsFrozen())
+		assert.ErrorIs(t, lateSub.HandleFunc("/x", ok).GetError(), ErrRouterFrozen)
+
+		detached := apiRoute.Subrouter()
+		assert.NotSame(t, sub, detached)
+		assert.True(t, detached.IsFrozen())
+		assert.ErrorIs(t, detached.HandleFunc("/items", ok).GetError(), ErrRouterFrozen)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/late", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/items", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotNil(t, r.Get("users"))
+	})
+
+	t.Run("mount after freeze fails", func(t *testing.T) {
+		captureLog(t)
+		r := NewRouter().Freeze()
+		assert.ErrorIs(t, r.Mount("/child", NewRouter()).GetError(), ErrRouterFrozen)
+
+		child := NewRouter().Freeze()
+		assert.ErrorIs(t, NewRouter().Mount("/child", child).GetError(), ErrRouterFrozen)
+	})
+
+	t.Run("mounted router is frozen with its parent", func(t *testing.T) {
+		captureLog(t)
+		child := NewRouter()
+		child.HandleFunc("/items", ok)
+		r := NewRouter()
+		require.NoError(t, r.Mount("/child", child).GetError())
+
+		r.Freeze()
+		assert.True(t, child.IsFrozen())
+		assert.ErrorIs(t, child.HandleFunc("/late", ok).GetError(), ErrRouterFrozen)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/child/late", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/child/items", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("use, hooks, and disable are ignored", func(t *testing.T) {
+		logs := captureLog(t)
+		r := NewRouter()
+		route := r.HandleFunc("/users", ok)
+		r.Freeze()
+
+		var called bool
+		assert.NotPanics(t, func() {
+			r.Use(func(next http.Handler) http.Handler {
+				called = true
+				return next
+			})
+			r.PreMatchHook(func(req *http.Request) *http.Request {
+				called = true
+				return req
+			})
+			route.Enabled(false)
+			r.HandleFuncIf(false, "/beta", ok)
+		})
+		assert.True(t, route.IsEnabled())
+		assert.Contains(t, logs.String(), "mux: Use after Freeze is ignored")
+		assert.Contains(t, logs.String(), "mux: PreMatchHook after Freeze is ignored")
+		assert.Contains(t, logs.String(), "mux: disabling a route after Freeze is ignored")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, called)
+	})
+
+	t.Run("registering while serving is race-free", func(t *testing.T) {
+		captureLog(t)
+		r := NewRouter()
+		r.HandleFunc("/users/{id}", ok).Name("user")
+		r.Freeze()
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+					assert.Equal(t, http.StatusOK, w.Code)
+					assert.NotNil(t, r.Get("user"))
+				}
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				route := r.HandleFunc(fmt.Sprintf("/diag/%d", i), ok).Name(fmt.Sprintf("diag%d", i))
+				assert.ErrorIs(t, route.GetError(), ErrRouterFrozen)
+			}
+		}()
+
+		wg.Wait()
+	})
+}
+
+func TestRouterMount(t *testing.T) {
+	newBilling := func() *Router {
+		billing := NewRouter()
+		billing.HandleFunc("/invoices/{id}", func(w http.ResponseWriter, req *http.Request) {
+			vars := Vars(req)
+			fmt.Fprintf(w, "%s/%s", vars["org"], vars["id"])
+		}).Methods(http.MethodGet).Name("invoice")
+		return billing
+	}
+
+	t.Run("routes and vars across the boundary", func(t *testing.T) {
+		r := NewRouter()
+		r.Mount("/orgs/{org}/billing", newBilling())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orgs/acme/billing/invoices/7", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "acme/7", w.Body.String())
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices/7", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("current route router and reverse", func(t *testing.T) {
+		r := NewRouter()
+		billing := NewRouter()
+		var gotRoute *Route
+		var gotRouter *Router
+		var gotURL string
+		billing.HandleFunc("/invoices/{id}", func(_ http.ResponseWriter, req *http.Request) {
+			gotRoute = CurrentRoute(req)
+			gotRouter = CurrentRouter(req)
+			gotURL, _ = Reverse(req, "invoice", "org", "acme", "id", "9")
+		}).Name("invoice")
+		r.Mount("/orgs/{org}/billing", billing)
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orgs/acme/billing/invoices/7", nil))
+		require.NotNil(t, gotRoute)
+		tpl, err := gotRoute.GetPathTemplate()
+		require.NoError(t, err)
+		assert.Equal(t, "/orgs/{org}/billing/invoices/{id}", tpl)
+		assert.Same(t, billing, gotRouter)
+		assert.Equal(t, "/orgs/acme/billing/invoices/9", gotURL)
+	})
+
+	t.Run("named routes registered with parent", func(t *testing.T) {
+		r := NewRouter()
+		billing := newBilling()
+		r.Mount("/orgs/{org}/billing", billing)
+
+		route := r.Get("invoice")
+		require.NotNil(t, route)
+		u, err := route.URL("org", "acme", "id", "1")
+		require.NoError(t, err)
+		assert.Equal(t, "/orgs/acme/billing/invoices/1", u.String())
+
+		// Routes added to the child after mounting are prefixed and named
+		// in the parent as well.
+		billing.HandleFunc("/plans", func(http.ResponseWriter, *http.Request) {}).Name("plans")
+		u, err = r.Get("plans").URL("org", "acme")
+		require.NoError(t, err)
+		assert.Equal(t, "/orgs/acme/billing/plans", u.String())
+	})
+
+	t.Run("walk descends into child", func(t *testing.T) {
+		r := NewRouter()
+		billing := newBilling()
+		billing.Route("/reports", func(sub *Router) {
+			sub.HandleFunc("/{year}", func(http.ResponseWriter, *http.Request) {})
+		})
+		r.Mount("/billing", billing)
+
+		var templates []string
+		require.NoError(t, r.Walk(func(route *Route, _ *Router, _ []*Route) error {
+			tpl, err := route.GetPathTemplate()
+			if err == nil {
+				templates = append(templates, tpl)
+			}
+			return nil
+		}))
+		assert.Equal(t, []string{"/billing", "/billing/invoices/{id}", "/billing/reports", "/billing/reports/{year}"}, templates)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/reports/2024", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("child middleware and not found handler", func(t *testing.T) {
+		r := NewRouter()
+		billing := newBilling()
+		billing.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Billing", "yes")
+				next.ServeHTTP(w, req)
+			})
+		})
+		billing.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		r.HandleFunc("/other", func(http.ResponseWriter, *http.Request) {})
+		r.Mount("/billing", billing)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/invoices/1", nil))
+		assert.Equal(t, "yes", w.Header().Get("X-Billing"))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/missing", nil))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		assert.Equal(t, "yes", w.Header().Get("X-Billing"))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+		assert.Empty(t, w.Header().Get("X-Billing"))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		r := NewRouter()
+		r.Mount("/billing", newBilling())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/billing/invoices/1", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+	})
+
+	t.Run("mount into subrouter", func(t *testing.T) {
+		r := NewRouter()
+		r.Route("/api", func(api *Router) {
+			api.Mount("/billing", newBilling())
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/billing/invoices/3", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		u, err := r.Get("invoice").URL("id", "3")
+		require.NoError(t, err)
+		assert.Equal(t, "/api/billing/invoices/3", u.String())
+	})
+
+	t.Run("parent strict slash applies to child routes", func(t *testing.T) {
+		r := NewRouter().StrictSlash(true)
+		billing := NewRouter()
+		billing.HandleFunc("/plans/", func(http.ResponseWriter, *http.Request) {})
+		r.Mount("/billing", billing)
+
+		assert.True(t, billing.GetStrictSlash())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/plans", nil))
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/billing/plans/", w.Header().Get("Location"))
+	})
+
+	t.Run("child strict slash is kept", func(t *testing.T) {
+		r := NewRouter()
+		billing := NewRouter().StrictSlash(true)
+		billing.HandleFunc("/plans/", func(http.ResponseWriter, *http.Request) {})
+		r.Mount("/billing", billing)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/plans", nil))
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	})
+
+	t.Run("parent encoded path applies to child routes", func(t *testing.T) {
+		r := NewRouter().UseEncodedPath()
+		billing := NewRouter()
+		var got string
+		billing.HandleFunc("/files/{name}", func(_ http.ResponseWriter, req *http.Request) {
+			got = Vars(req)["name"]
+		})
+		r.Mount("/billing", billing)
+
+		assert.True(t, billing.GetUseEncodedPath())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/files/a%2Fb", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "a/b", got)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		r := NewRouter()
+		assert.EqualError(t, r.Mount("/a", nil).GetError(), "mux: cannot mount a nil router")
+		assert.EqualError(t, r.Mount("/b", r).GetError(), "mux: cannot mount a router into itself")
+
+		sub := r.PathPrefix("/c").Subrouter()
+		assert.EqualError(t, r.Mount("/d", sub).GetError(), "mux: router is already mounted")
+
+		child := NewRouter()
+		require.NoError(t, r.Mount("/e", child).GetError())
+		assert.EqualError(t, r.Mount("/f", child).GetError(), "mux: router is already mounted")
+
+		outer := NewRouter()
+		inner := outer.PathPrefix("/inner").Subrouter()
+		assert.EqualError(t, inner.Mount("/loop", outer).GetError(), "mux: cannot mount a router into itself")
+
+		dup := NewRouter()
+		dup.HandleFunc("/{id}", func(http.ResponseWriter, *http.Request) {})
+		assert.Error(t, r.Mount("/items/{id}", dup).GetError())
+
+		assert.Error(t, r.Mount("no-slash", NewRouter()).GetError())
+	})
+}
+
 func TestRouterHEADImplicitFromGET(t *testing.T) {
 	t.Run("HEAD request matches GET-only route", func(t *testing.T) {
 		r := NewRouter()
@@ -1557,3 +2187,251 @@ func BenchmarkMiddlewareChain(b *testing.B) {
 		r.ServeHTTP(httptest.NewRecorder(), req)
 	}
 }
+
+func TestRouterPanicHandler(t *testing.T) {
+	panicking := func(_ *http.Request, _ *RouteMatch) bool {
+		panic("matcher exploded")
+	}
+
+	t.Run("recovers matcher panic with default 500", func(t *testing.T) {
+		r := NewRouter()
+		r.MatcherFunc(panicking).HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		require.NotPanics(t, func() { r.ServeHTTP(w, req) })
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("invokes custom PanicHandler", func(t *testing.T) {
+		var recovered any
+		r := NewRouter()
+		r.PanicHandler = func(w http.ResponseWriter, _ *http.Request, err any) {
+			recovered = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		r.MatcherFunc(panicking).HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "matcher exploded", recovered)
+	})
+
+	t.Run("recovers panic inside subrouter matching", func(t *testing.T) {
+		r := NewRouter()
+		sub := r.PathPrefix("/api").Subrouter()
+		sub.MatcherFunc(panicking).HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("recovers MetadataFunc panic", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/meta", func(_ http.ResponseWriter, _ *http.Request) {}).
+			MetadataFunc(func(_ *http.Request) map[any]any {
+				panic("metadata exploded")
+			})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/meta", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("does not recover handler panic", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/boom", func(_ http.ResponseWriter, _ *http.Request) {
+			panic("handler exploded")
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+		assert.PanicsWithValue(t, "handler exploded", func() { r.ServeHTTP(w, req) })
+	})
+}
+
+func TestRouterHandleIf(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	tests := []struct {
+		name     string
+		register func(r *Router, cond bool) *Route
+	}{
+		{"HandleFuncIf", func(r *Router, cond bool) *Route { return r.HandleFuncIf(cond, "/flag", ok) }},
+		{"HandleIf", func(r *Router, cond bool) *Route { return r.HandleIf(cond, "/flag", http.HandlerFunc(ok)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" registers when true", func(t *testing.T) {
+			router := NewRouter()
+			route := tt.register(router, true).Methods(http.MethodGet).Name("flag")
+			assert.True(t, route.IsEnabled())
+			assert.Same(t, route, router.Get("flag"))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/flag", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run(tt.name+" skips when false", func(t *testing.T) {
+			router := NewRouter()
+			route := tt.register(router, false).Methods(http.MethodGet).Name("flag")
+			assert.False(t, route.IsEnabled())
+			assert.Nil(t, router.Get("flag"))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/flag", nil))
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	}
+}
+
+func TestRouterPreMatchHook(t *testing.T) {
+	rewritePath := func(req *http.Request, path string) *http.Request {
+		u := *req.URL
+		u.Path, u.RawPath = path, ""
+		out := req.WithContext(req.Context())
+		out.URL = &u
+		return out
+	}
+
+	stripPrefix := func(req *http.Request) *http.Request {
+		path, ok := strings.CutPrefix(req.URL.Path, "/deploy-a")
+		if !ok {
+			return nil
+		}
+		return rewritePath(req, path)
+	}
+
+	legacyVersion := func(req *http.Request) *http.Request {
+		path, ok := strings.CutPrefix(req.URL.Path, "/v1.1/")
+		if !ok {
+			return nil
+		}
+		return rewritePath(req, "/v1/"+path)
+	}
+
+	serve := func(r *Router, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	echoVar := func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(Vars(req)["id"] + " " + req.URL.Path))
+	}
+
+	t.Run("hooks run in order before matching", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/v1/users/{id}", echoVar)
+		r.PreMatchHook(stripPrefix, legacyVersion)
+
+		w := serve(r, "/deploy-a/v1.1/users/42")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "42 /v1/users/42", w.Body.String())
+
+		w = serve(r, "/v1/users/7")
+		assert.Equal(t, "7 /v1/users/7", w.Body.String())
+	})
+
+	t.Run("nil keeps request", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/a", echoVar)
+		r.PreMatchHook(func(*http.Request) *http.Request { return nil })
+
+		assert.Equal(t, http.StatusOK, serve(r, "/a").Code)
+	})
+
+	t.Run("path cleaning applies to rewritten path", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/v1/users", echoVar)
+		r.PreMatchHook(stripPrefix)
+
+		w := serve(r, "/deploy-a//v1/users")
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/v1/users", w.Header().Get("Location"))
+	})
+
+	t.Run("hook can collapse slashes before cleaning", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/v1/users", echoVar)
+		r.PreMatchHook(stripPrefix, func(req *http.Request) *http.Request {
+			return rewritePath(req, path.Clean(req.URL.Path))
+		})
+
+		w := serve(r, "/deploy-a//v1//users")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("skip clean honored after rewrite", func(t *testing.T) {
+		r := NewRouter().SkipClean(true)
+		r.HandleFunc("/v1//users", echoVar)
+		r.PreMatchHook(stripPrefix)
+
+		w := serve(r, "/deploy-a/v1//users")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("strict slash redirect uses rewritten path", func(t *testing.T) {
+		r := NewRouter().StrictSlash(true)
+		r.HandleFunc("/v1/users/", echoVar)
+		r.PreMatchHook(legacyVersion)
+
+		w := serve(r, "/v1.1/users")
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/v1/users/", w.Header().Get("Location"))
+	})
+
+	t.Run("stale raw path dropped with encoded path", func(t *testing.T) {
+		r := NewRouter().UseEncodedPath()
+		r.HandleFunc("/v1/files/{name}", func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte(Vars(req)["name"]))
+		})
+		r.PreMatchHook(func(req *http.Request) *http.Request {
+			path, ok := strings.CutPrefix(req.URL.Path, "/v1.1/")
+			if !ok {
+				return nil
+			}
+			// Only Path is rewritten; RawPath still holds the old
+			// encoded path.
+			u := *req.URL
+			u.Path = "/v1/" + path
+			out := req.WithContext(req.Context())
+			out.URL = &u
+			return out
+		})
+
+		w := serve(r, "/v1.1/files/%41bc")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Abc", w.Body.String())
+	})
+
+	t.Run("mounted router hooks do not run", func(t *testing.T) {
+		r := NewRouter()
+		sub := r.PathPrefix("/api").Subrouter()
+		sub.HandleFunc("/items", echoVar)
+		sub.PreMatchHook(func(req *http.Request) *http.Request {
+			return rewritePath(req, "/nowhere")
+		})
+
+		assert.Equal(t, http.StatusOK, serve(r, "/api/items").Code)
+	})
+
+	t.Run("ignored after freeze", func(t *testing.T) {
+		out := log.Writer()
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(out)
+
+		r := NewRouter()
+		r.HandleFunc("/items", echoVar)
+		r.Freeze()
+		r.PreMatchHook(stripPrefix)
+
+		assert.Equal(t, http.StatusNotFound, serve(r, "/deploy-a/items").Code)
+	})
+}
@@ -0,0 +1,241 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidPattern is returned when a net/http.ServeMux style pattern
+// cannot be translated into a route.
+var ErrInvalidPattern = errors.New("mux: invalid pattern")
+
+// StdMux registers routes on a Router using the pattern syntax of
+// net/http.ServeMux, so code written against the standard library mux can
+// be mounted on a Router without rewriting its patterns. Every pattern is
+// translated into an ordinary *Route, which can be named, walked, and
+// documented like any other route.
+type StdMux struct {
+	router *Router
+}
+
+// StdAdapter returns a StdMux that registers routes on r.
+//
+// A pattern has the form "[METHOD ][HOST]/[PATH]" and is translated as
+// follows:
+//
+//   - METHOD becomes a Methods matcher; as with ServeMux, GET also accepts
+//     HEAD.
+//   - HOST becomes a Host matcher. Wildcards are not allowed in the host.
+//   - "{name}" becomes the path variable "{name}" and must span a whole
+//     segment.
+//   - "{name...}" becomes "{name:.*}" and must be the final segment.
+//   - A trailing "/" becomes a PathPrefix matcher, and a trailing "/{$}"
+//     matches the path ending in "/" exactly.
+//
+// Unlike ServeMux, routes are tried in registration order rather than by
+// specificity, so register more specific patterns first. Handlers also see
+// the path variables through http.Request.PathValue.
+func StdAdapter(r *Router) *StdMux {
+	return &StdMux{router: r}
+}
+
+// Router returns the router the adapter registers routes on.
+func (m *StdMux) Router() *Router {
+	return m.router
+}
+
+// Handle registers handler for pattern. Like http.ServeMux.Handle, it
+// panics if the pattern cannot be translated or the handler is nil.
+func (m *StdMux) Handle(pattern string, handler http.Handler) {
+	if _, err := m.Register(pattern, handler); err != nil {
+		panic(err)
+	}
+}
+
+// HandleFunc registers the handler function for pattern. Like
+// http.ServeMux.HandleFunc, it panics if the pattern cannot be translated
+// or the handler is nil.
+func (m *StdMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	if handler == nil {
+		panic(fmt.Errorf("%w %q: nil handler", ErrInvalidPattern, pattern))
+	}
+	m.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Register registers handler for pattern and returns the resulting route,
+// or an error wrapping ErrInvalidPattern if the pattern cannot be
+// translated. No route is added when an error is returned.
+func (m *StdMux) Register(pattern string, handler http.Handler) (*Route, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("%w %q: nil handler", ErrInvalidPattern, pattern)
+	}
+	sp, err := parseStdPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	route := m.router.NewRoute()
+	if sp.host != "" {
+		route.Host(sp.host)
+	}
+	if sp.prefix {
+		route.PathPrefix(sp.path)
+	} else {
+		route.Path(sp.path)
+	}
+	if sp.method != "" {
+		route.Methods(sp.method)
+	}
+	if len(sp.vars) > 0 {
+		handler = pathValueHandler(sp.vars, handler)
+	}
+	route.Handler(handler)
+
+	if err := route.GetError(); err != nil {
+		route.Enabled(false)
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPattern, pattern, err)
+	}
+	return route, nil
+}
+
+// ServeHTTP dispatches the request to the underlying router.
+func (m *StdMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	m.router.ServeHTTP(w, req)
+}
+
+// pathValueHandler copies the route variables into the request so handlers
+// written for ServeMux can read them with http.Request.PathValue.
+func pathValueHandler(names []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		vars := Vars(req)
+		for _, name := range names {
+			if v, ok := vars[name]; ok {
+				req.SetPathValue(name, v)
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// stdPattern is a ServeMux pattern translated into route matchers.
+type stdPattern struct {
+	method string
+	host   string
+	path   string
+	prefix bool
+	vars   []string
+}
+
+// parseStdPattern translates a ServeMux pattern into a mux path template.
+func parseStdPattern(pattern string) (*stdPattern, error) {
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("%w %q: %s", ErrInvalidPattern, pattern, fmt.Sprintf(format, args...))
+	}
+
+	if pattern == "" {
+		return nil, fail("empty pattern")
+	}
+
+	sp := &stdPattern{}
+	rest := pattern
+	if i := strings.IndexAny(rest, " \t"); i >= 0 {
+		sp.method = rest[:i]
+		rest = strings.TrimLeft(rest[i:], " \t")
+		if !isMethodToken(sp.method) {
+			return nil, fail("invalid method %q", sp.method)
+		}
+	}
+
+	i := strings.IndexByte(rest, '/')
+	if i < 0 {
+		return nil, fail("host/path missing /")
+	}
+	sp.host, rest = rest[:i], rest[i:]
+	if strings.ContainsAny(sp.host, "{}") {
+		return nil, fail("host contains '{' or '}'")
+	}
+
+	var (
+		tpl  strings.Builder
+		seen = map[string]bool{}
+	)
+	segments := strings.Split(rest[1:], "/")
+	for n, seg := range segments {
+		last := n == len(segments)-1
+		tpl.WriteByte('/')
+
+		if !strings.ContainsAny(seg, "{}") {
+			tpl.WriteString(seg)
+			continue
+		}
+		if seg[0] != '{' || seg[len(seg)-1] != '}' || strings.Count(seg, "{") != 1 || strings.Count(seg, "}") != 1 {
+			return nil, fail("wildcard must be a full path segment: %q", seg)
+		}
+
+		name := seg[1 : len(seg)-1]
+		if name == "$" {
+			if !last {
+				return nil, fail("{$} not at end")
+			}
+			// "/x/{$}" matches "/x/" only: drop the wildcard and keep the
+			// trailing slash as an exact match.
+			sp.path = tpl.String()
+			return sp, nil
+		}
+
+		name, multi := strings.CutSuffix(name, "...")
+		if multi && !last {
+			return nil, fail("{%s...} wildcard not at end", name)
+		}
+		if !isWildcardName(name) {
+			return nil, fail("bad wildcard name %q", name)
+		}
+		if seen[name] {
+			return nil, fail("duplicate wildcard name %q", name)
+		}
+		seen[name] = true
+		sp.vars = append(sp.vars, name)
+
+		if multi {
+			tpl.WriteString("{" + name + ":.*}")
+		} else {
+			tpl.WriteString("{" + name + "}")
+		}
+	}
+
+	sp.path = tpl.String()
+	sp.prefix = strings.HasSuffix(sp.path, "/")
+	return sp, nil
+}
+
+// isWildcardName reports whether s is a valid ServeMux wildcard name: a Go
+// identifier.
+func isWildcardName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isMethodToken reports whether s is a valid method token per RFC 9110
+// Section 9.1.
+func isMethodToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdAdapter(t *testing.T) {
+	serve := func(h http.Handler, method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+	writeBody := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}
+	}
+
+	t.Run("method and wildcard", func(t *testing.T) {
+		m := StdAdapter(NewRouter())
+		m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.PathValue("id") + "/" + Vars(r)["id"]))
+		})
+
+		w := serve(m, http.MethodGet, "/users/42")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "42/42", w.Body.String())
+
+		assert.Equal(t, http.StatusOK, serve(m, http.MethodHead, "/users/42").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(m, http.MethodPost, "/users/42").Code)
+		assert.Equal(t, http.StatusNotFound, serve(m, http.MethodGet, "/users/42/x").Code)
+	})
+
+	t.Run("remainder wildcard", func(t *testing.T) {
+		m := StdAdapter(NewRouter())
+		m.HandleFunc("/files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.PathValue("path")))
+		})
+
+		assert.Equal(t, "a/b/c.txt", serve(m, http.MethodGet, "/files/a/b/c.txt").Body.String())
+		w := serve(m, http.MethodGet, "/files/")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("trailing slash is a prefix", func(t *testing.T) {
+		m := StdAdapter(NewRouter())
+		m.Handle("/static/", writeBody("static"))
+
+		assert.Equal(t, "static", serve(m, http.MethodGet, "/static/").Body.String())
+		assert.Equal(t, "static", serve(m, http.MethodGet, "/static/css/app.css").Body.String())
+		assert.Equal(t, http.StatusNotFound, serve(m, http.MethodGet, "/other").Code)
+	})
+
+	t.Run("exact match with dollar", func(t *testing.T) {
+		m := StdAdapter(NewRouter())
+		m.Handle("/{$}", writeBody("root"))
+		m.Handle("/docs/{$}", writeBody("docs"))
+
+		assert.Equal(t, "root", serve(m, http.MethodGet, "/").Body.String())
+		assert.Equal(t, "docs", serve(m, http.MethodGet, "/docs/").Body.String())
+		assert.Equal(t, http.StatusNotFound, serve(m, http.MethodGet, "/docs/x").Code)
+		assert.Equal(t, http.StatusNotFound, serve(m, http.MethodGet, "/x").Code)
+	})
+
+	t.Run("host", func(t *testing.T) {
+		m := StdAdapter(NewRouter())
+		m.Handle("POST example.com/items", writeBody("items"))
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/items", nil)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		assert.Equal(t, "items", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "http://other.com/items", nil)
+		w = httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("registered routes are first class", func(t *testing.T) {
+		r := NewRouter()
+		m := StdAdapter(r)
+		assert.Same(t, r, m.Router())
+
+		route, err := m.Register("DELETE /users/{id}", writeBody("deleted"))
+		require.NoError(t, err)
+		route.Name("deleteUser")
+
+		tpl, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		assert.Equal(t, "/users/{id}", tpl)
+
+		methods, err := route.GetMethods()
+		require.NoError(t, err)
+		assert.Equal(t, []string{http.MethodDelete}, methods)
+
+		u, err := r.Get("deleteUser").URL("id", "7")
+		require.NoError(t, err)
+		assert.Equal(t, "/users/7", u.String())
+
+		var walked []string
+		require.NoError(t, r.Walk(func(route *Route, _ *Router, _ []*Route) error {
+			tpl, _ := route.GetPathTemplate()
+			walked = append(walked, tpl)
+			return nil
+		}))
+		assert.Equal(t, []string{"/users/{id}"}, walked)
+	})
+
+	t.Run("remainder template", func(t *testing.T) {
+		route, err := StdAdapter(NewRouter()).Register("/files/{path...}", writeBody(""))
+		require.NoError(t, err)
+		tpl, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		assert.Equal(t, "/files/{path:.*}", tpl)
+	})
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		for _, tc := range []struct {
+			pattern string
+			msg     string
+		}{
+			{"", "empty pattern"},
+			{"GET", "host/path missing /"},
+			{"users", "host/path missing /"},
+			{"GE(T /x", "invalid method"},
+			{"{host}.com/x", "host contains"},
+			{"/users/id{id}", "full path segment"},
+			{"/users/{id}x", "full path segment"},
+			{"/users/{a}{b}", "full path segment"},
+			{"/users/{id", "full path segment"},
+			{"/files/{path...}/x", "not at end"},
+			{"/{$}/x", "{$} not at end"},
+			{"/users/{1d}", "bad wildcard name"},
+			{"/users/{}", "bad wildcard name"},
+			{"/users/{id:[0-9]+}", "bad wildcard name"},
+			{"/a/{id}/b/{id}", "duplicate wildcard name"},
+		} {
+			r := NewRouter()
+			route, err := StdAdapter(r).Register(tc.pattern, writeBody(""))
+			require.Error(t, err, tc.pattern)
+			assert.Nil(t, route)
+			assert.True(t, errors.Is(err, ErrInvalidPattern), tc.pattern)
+			assert.Contains(t, err.Error(), tc.msg, tc.pattern)
+			assert.Empty(t, r.routes, tc.pattern)
+		}
+	})
+
+	t.Run("nil handler", func(t *testing.T) {
+		_, err := StdAdapter(NewRouter()).Register("/x", nil)
+		assert.ErrorIs(t, err, ErrInvalidPattern)
+		assert.Contains(t, err.Error(), "nil handler")
+	})
+
+	t.Run("handle panics on invalid pattern", func(t *testing.T) {
+		m := StdAdapter(NewRouter())
+		assert.PanicsWithError(t, `mux: invalid pattern "/x{id}": wildcard must be a full path segment: "x{id}"`, func() {
+			m.Handle("/x{id}", writeBody(""))
+		})
+		assert.Panics(t, func() { m.HandleFunc("/x", nil) })
+	})
+}
@@ -0,0 +1,148 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout sets a deadline for serving the route. The route's handler and
+// route-level middleware run with a request context that is canceled after
+// d; if they have not finished by then, the client gets the timeout
+// response instead: the TimeoutHandler of the router owning the route or of
+// its nearest ancestor that sets one, or 503 Service Unavailable (RFC 9110
+// Section 15.6.4) by default. Other routes are not affected, and a zero or
+// negative d removes the deadline.
+//
+// Like http.TimeoutHandler, the response is buffered until the handler
+// returns, so the handler cannot flush or hijack the connection, and writes
+// after the deadline fail with http.ErrHandlerTimeout. Handlers should
+// return promptly once the request context is done. Router middleware runs
+// outside the deadline and sees the timeout response.
+//
+//	r.HandleFunc("/reports", buildReport).Timeout(3 * time.Second)
+//
+// For a subrouter or mounted router, Timeout on its parent route does not
+// cover the routes inside it; set it on those routes or use a timeout
+// middleware on the subrouter instead.
+func (r *Route) Timeout(d time.Duration) *Route {
+	r.timeout = d
+	return r
+}
+
+// GetTimeout returns the deadline set with Timeout, or zero when none.
+func (r *Route) GetTimeout() time.Duration {
+	return r.timeout
+}
+
+// defaultTimeoutHandler replies with 503 Service Unavailable when a route
+// exceeds its Timeout.
+var defaultTimeoutHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+})
+
+// timeoutResponder returns the handler writing the timeout response for
+// route: the first TimeoutHandler set on the router owning it or an
+// ancestor router.
+func timeoutResponder(route *Route) http.Handler {
+	for router := range ownerRouters(route) {
+		if router.TimeoutHandler != nil {
+			return router.TimeoutHandler
+		}
+	}
+	return defaultTimeoutHandler
+}
+
+// timeoutRouteHandler runs a route's handler with the route's deadline.
+type timeoutRouteHandler struct {
+	route   *Route
+	handler http.Handler
+}
+
+func (h *timeoutRouteHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), h.route.timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	tw := &timeoutWriter{h: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		h.handler.ServeHTTP(tw, req)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicked:
+		panic(p)
+
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		maps.Copy(w.Header(), tw.h)
+		if tw.code == 0 {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		_, _ = w.Write(tw.buf.Bytes())
+
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.timedOut = true
+		timeoutResponder(h.route).ServeHTTP(w, req)
+	}
+}
+
+// timeoutWriter buffers the response of a handler running under a route
+// deadline, so that nothing reaches the client unless it finishes in time.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	h           http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	// Informational responses cannot be buffered; only the final status
+	// is kept.
+	if tw.wroteHeader || (code >= 100 && code <= 199) {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,204 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	slow := func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+			_, _ = w.Write([]byte("late"))
+		}
+	}
+	fast := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Fast", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("done"))
+	}
+
+	serve := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("slow handler times out", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/slow", slow).Timeout(20 * time.Millisecond)
+
+		start := time.Now()
+		w := serve(r, "/slow")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "Service Unavailable\n", w.Body.String())
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("fast handler completes", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/fast", fast).Timeout(time.Second)
+
+		w := serve(r, "/fast")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "done", w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("X-Fast"))
+	})
+
+	t.Run("implicit ok", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/empty", func(http.ResponseWriter, *http.Request) {}).Timeout(time.Second)
+
+		w := serve(r, "/empty")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("other routes unaffected", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/timed", slow).Timeout(20 * time.Millisecond)
+		r.HandleFunc("/untimed", func(w http.ResponseWriter, req *http.Request) {
+			_, hasDeadline := req.Context().Deadline()
+			assert.False(t, hasDeadline)
+			_, _ = w.Write([]byte("ok"))
+		})
+
+		assert.Equal(t, http.StatusServiceUnavailable, serve(r, "/timed").Code)
+		w := serve(r, "/untimed")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+
+	t.Run("handler sees deadline and route context", func(t *testing.T) {
+		r := NewRouter()
+		var hasDeadline bool
+		var id string
+		r.HandleFunc("/items/{id}", func(_ http.ResponseWriter, req *http.Request) {
+			_, hasDeadline = req.Context().Deadline()
+			id = Vars(req)["id"]
+		}).Timeout(time.Second)
+
+		serve(r, "/items/42")
+		assert.True(t, hasDeadline)
+		assert.Equal(t, "42", id)
+	})
+
+	t.Run("custom timeout handler", func(t *testing.T) {
+		r := NewRouter()
+		r.TimeoutHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.Error(t, req.Context().Err())
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusGatewayTimeout)
+		})
+		r.HandleFunc("/slow", slow).Timeout(20 * time.Millisecond)
+
+		w := serve(r, "/slow")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("subrouter inherits timeout handler", func(t *testing.T) {
+		r := NewRouter()
+		r.TimeoutHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusGatewayTimeout)
+		})
+		api := r.PathPrefix("/api").Subrouter()
+		api.HandleFunc("/slow", slow).Timeout(20 * time.Millisecond)
+
+		assert.Equal(t, http.StatusGatewayTimeout, serve(r, "/api/slow").Code)
+
+		api.TimeoutHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusRequestTimeout)
+		})
+		assert.Equal(t, http.StatusRequestTimeout, serve(r, "/api/slow").Code)
+	})
+
+	t.Run("route middleware runs inside deadline", func(t *testing.T) {
+		r := NewRouter()
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				_, hasDeadline := req.Context().Deadline()
+				assert.False(t, hasDeadline, "router middleware runs outside the deadline")
+				next.ServeHTTP(w, req)
+			})
+		})
+		r.HandleFunc("/slow", fast).Timeout(20 * time.Millisecond).Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				<-req.Context().Done()
+				next.ServeHTTP(w, req)
+			})
+		})
+
+		assert.Equal(t, http.StatusServiceUnavailable, serve(r, "/slow").Code)
+	})
+
+	t.Run("writes after timeout fail", func(t *testing.T) {
+		r := NewRouter()
+		writeErr := make(chan error, 1)
+		r.HandleFunc("/slow", func(w http.ResponseWriter, req *http.Request) {
+			<-req.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			_, err := w.Write([]byte("late"))
+			writeErr <- err
+		}).Timeout(10 * time.Millisecond)
+
+		w := serve(r, "/slow")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.ErrorIs(t, <-writeErr, http.ErrHandlerTimeout)
+		assert.NotContains(t, w.Body.String(), "late")
+	})
+
+	t.Run("handler panic propagates", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}).Timeout(time.Second)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			serve(r, "/panic")
+		})
+	})
+
+	t.Run("zero removes deadline", func(t *testing.T) {
+		r := NewRouter()
+		route := r.HandleFunc("/fast", fast).Timeout(time.Second)
+		assert.Equal(t, time.Second, route.GetTimeout())
+
+		route.Timeout(0)
+		assert.Zero(t, route.GetTimeout())
+		_, wrapped := route.GetHandlerWithMiddlewares().(*timeoutRouteHandler)
+		assert.False(t, wrapped)
+	})
+
+	t.Run("observer sees timeout status", func(t *testing.T) {
+		obs := &recordingObserver{}
+		r := NewRouter()
+		r.Observer = obs
+		r.HandleFunc("/slow", slow).Timeout(20 * time.Millisecond)
+
+		serve(r, "/slow")
+		events := obs.recorded()
+		require.Len(t, events, 2)
+		assert.Equal(t, "/slow", events[1].template)
+		assert.Equal(t, http.StatusServiceUnavailable, events[1].status)
+	})
+}
+
+func BenchmarkRouteTimeout(b *testing.B) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Timeout(time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
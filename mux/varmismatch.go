@@ -0,0 +1,108 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// VarMismatchError reports a request that matches a route except for the
+// value of a path variable, such as "abc" for {id:int} in /users/abc.
+// Router.Match sets it as RouteMatch.MatchErr in place of ErrNotFound,
+// which it wraps.
+type VarMismatchError struct {
+	// Route is the route the request nearly matched.
+	Route *Route
+
+	// Name is the name of the variable whose value failed its pattern.
+	Name string
+
+	// Value is the offending value, as matched in the request path.
+	Value string
+}
+
+func (e *VarMismatchError) Error() string {
+	tpl, _ := e.Route.GetPathTemplate()
+	return fmt.Sprintf("mux: variable %q value %q doesn't match route %q", e.Name, e.Value, tpl)
+}
+
+// Unwrap returns ErrNotFound, so errors.Is(err, ErrNotFound) still holds.
+func (e *VarMismatchError) Unwrap() error {
+	return ErrNotFound
+}
+
+// VarMismatchBadRequest is a Router.VarMismatchHandler that replies with
+// 400 Bad Request (RFC 9110 Section 15.5.1) naming the malformed variable.
+func VarMismatchBadRequest(w http.ResponseWriter, _ *http.Request, err *VarMismatchError) {
+	http.Error(w, fmt.Sprintf("invalid value for %q: %q", err.Name, err.Value), http.StatusBadRequest)
+}
+
+// varMismatchResponderFor returns the VarMismatchHandler that answers a
+// failed match with err: that of the router owning the nearly matched
+// route or of its nearest ancestor that sets one. It returns nil when err
+// is not a *VarMismatchError or no router sets a handler.
+func varMismatchResponderFor(err error) func(http.ResponseWriter, *http.Request, *VarMismatchError) {
+	mismatch, ok := err.(*VarMismatchError)
+	if !ok {
+		return nil
+	}
+	for router := range ownerRouters(mismatch.Route) {
+		if router.VarMismatchHandler != nil {
+			return router.VarMismatchHandler
+		}
+	}
+	return nil
+}
+
+// findVarMismatch returns the first route of r, in matching order and
+// descending into subrouters, that req matches except for the value of a
+// path variable, or nil.
+func (r *Router) findVarMismatch(req *http.Request, match *RouteMatch) *VarMismatchError {
+	for _, route := range r.routes {
+		if route.buildOnly || route.err != nil {
+			continue
+		}
+		if sub, ok := route.handler.(*Router); ok {
+			if route.regexp.path != nil && !route.regexp.path.matchesStructure(route.regexp.path.matchPath(req)) {
+				continue
+			}
+			if !route.matchesExceptPath(req, match) {
+				continue
+			}
+			if err := sub.findVarMismatch(req, match); err != nil {
+				return err
+			}
+			continue
+		}
+
+		path := route.regexp.path
+		if path == nil || path.loose == nil {
+			continue
+		}
+		name, value, ok := path.mismatchedVar(path.matchPath(req))
+		if !ok || !route.matchesExceptPath(req, match) {
+			continue
+		}
+		return &VarMismatchError{Route: route, Name: name, Value: value}
+	}
+	return nil
+}
+
+// matchesExceptPath reports whether req matches the route's method,
+// header, and scheme matchers, host, and queries. Custom matchers are not
+// consulted.
+func (r *Route) matchesExceptPath(req *http.Request, match *RouteMatch) bool {
+	for _, m := range r.matchers {
+		if isBuiltinMatcher(m) && !m.Match(req, match) {
+			return false
+		}
+	}
+	if r.regexp.host != nil && !r.regexp.host.Match(req, match) {
+		return false
+	}
+	for _, q := range r.regexp.queries {
+		if !q.Match(req, match) {
+			return false
+		}
+	}
+	return true
+}
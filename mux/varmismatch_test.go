@@ -0,0 +1,180 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarMismatch(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	t.Run("match error names the variable", func(t *testing.T) {
+		r := NewRouter()
+		route := r.HandleFunc("/users/{id:int}/posts/{slug:slug}", noop).Methods(http.MethodGet)
+
+		var match RouteMatch
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/users/42/posts/bad_slug", nil), &match))
+
+		var mismatch *VarMismatchError
+		require.ErrorAs(t, match.MatchErr, &mismatch)
+		assert.Same(t, route, mismatch.Route)
+		assert.Equal(t, "slug", mismatch.Name)
+		assert.Equal(t, "bad_slug", mismatch.Value)
+		assert.ErrorIs(t, match.MatchErr, ErrNotFound)
+		assert.Equal(t, `mux: variable "slug" value "bad_slug" doesn't match route "/users/{id:int}/posts/{slug:slug}"`, match.MatchErr.Error())
+
+		match = RouteMatch{}
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/users/abc/posts/bad_slug", nil), &match))
+		require.ErrorAs(t, match.MatchErr, &mismatch)
+		assert.Equal(t, "id", mismatch.Name)
+		assert.Equal(t, "abc", mismatch.Value)
+	})
+
+	t.Run("structural mismatch stays not found", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users/{id:int}", noop).Methods(http.MethodGet)
+		r.HandleFunc("/files/{name}", noop).Methods(http.MethodGet)
+		r.Host("api.example.com").Path("/hosts/{id:int}").HandlerFunc(noop)
+
+		requests := []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/accounts/abc", nil),
+			httptest.NewRequest(http.MethodGet, "/users/abc/extra", nil),
+			httptest.NewRequest(http.MethodGet, "/users/", nil),
+			httptest.NewRequest(http.MethodGet, "/files/", nil),
+			httptest.NewRequest(http.MethodPost, "/users/abc", nil),
+			httptest.NewRequest(http.MethodGet, "http://other.example.com/hosts/abc", nil),
+		}
+		for _, req := range requests {
+			var match RouteMatch
+			assert.False(t, r.Match(req, &match), req.URL.String())
+			assert.Equal(t, ErrNotFound, match.MatchErr, req.URL.String())
+		}
+	})
+
+	t.Run("default response is 404", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/users/{id:int}", noop)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("bad request handler", func(t *testing.T) {
+		r := NewRouter()
+		r.VarMismatchHandler = VarMismatchBadRequest
+		r.HandleFunc("/users/{id:int}", noop)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid value for \"id\": \"abc\"\n", w.Body.String())
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/42", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("custom handler receives route", func(t *testing.T) {
+		r := NewRouter()
+		route := r.HandleFunc("/orders/{id:uuid}", noop).Name("order")
+
+		var got *VarMismatchError
+		var current *Route
+		r.VarMismatchHandler = func(w http.ResponseWriter, req *http.Request, err *VarMismatchError) {
+			got = err
+			current = CurrentRoute(req)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/123", nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.NotNil(t, got)
+		assert.Same(t, route, got.Route)
+		assert.Same(t, route, current)
+		assert.Equal(t, "id", got.Name)
+		assert.Equal(t, "123", got.Value)
+	})
+
+	t.Run("method mismatch takes precedence", func(t *testing.T) {
+		r := NewRouter()
+		r.VarMismatchHandler = VarMismatchBadRequest
+		r.HandleFunc("/items/{id:int}", noop).Methods(http.MethodGet)
+		r.HandleFunc("/items/latest", noop).Methods(http.MethodPut)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/latest", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("subrouters", func(t *testing.T) {
+		r := NewRouter()
+		r.VarMismatchHandler = VarMismatchBadRequest
+
+		orgs := r.PathPrefix("/orgs/{org:int}").Subrouter()
+		members := orgs.HandleFunc("/members/{user:alpha}", noop)
+
+		admin := r.PathPrefix("/admin").Subrouter()
+		admin.VarMismatchHandler = func(w http.ResponseWriter, _ *http.Request, err *VarMismatchError) {
+			http.Error(w, "admin: "+err.Name, http.StatusTeapot)
+		}
+		admin.HandleFunc("/jobs/{id:int}", noop)
+
+		var match RouteMatch
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/orgs/acme/members/bob", nil), &match))
+		var mismatch *VarMismatchError
+		require.True(t, errors.As(match.MatchErr, &mismatch))
+		assert.Same(t, members, mismatch.Route)
+		assert.Equal(t, "org", mismatch.Name)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orgs/7/members/b0b", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"user"`)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/jobs/x", nil))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		assert.Equal(t, "admin: id\n", w.Body.String())
+	})
+
+	t.Run("length constrained macro", func(t *testing.T) {
+		r := NewRouter()
+		r.HandleFunc("/zones/{zone:domain}", noop)
+
+		long := "a"
+		for len(long) < 254 {
+			long += ".a"
+		}
+
+		var match RouteMatch
+		assert.False(t, r.Match(httptest.NewRequest(http.MethodGet, "/zones/"+long, nil), &match))
+		var mismatch *VarMismatchError
+		require.ErrorAs(t, match.MatchErr, &mismatch)
+		assert.Equal(t, long, mismatch.Value)
+	})
+}
+
+func BenchmarkVarMismatch(b *testing.B) {
+	r := NewRouter()
+	r.VarMismatchHandler = VarMismatchBadRequest
+	for _, path := range []string{"/a", "/b", "/users/{id:int}", "/users/{id:int}/posts/{post:uuid}"} {
+		r.HandleFunc(path, func(http.ResponseWriter, *http.Request) {})
+	}
+	req := httptest.NewRequest(http.MethodGet, "/users/42/posts/abc", nil)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
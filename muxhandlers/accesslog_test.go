@@ -3,7 +3,6 @@ package muxhandlers
 import (
 	"bufio"
 	"bytes"
-	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -298,7 +297,7 @@ func TestAccessLogMiddleware(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 		}))
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
-		ctx := context.WithValue(req.Context(), requestIDKey{}, "abc-123")
+		ctx := requestIDKey.WithContext(req.Context(), "abc-123")
 		req = req.WithContext(ctx)
 		h.ServeHTTP(httptest.NewRecorder(), req)
 		entry := decodeLogLine(t, buf)
@@ -0,0 +1,171 @@
+package muxhandlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// ErrAllowedHostsEmpty is returned when AllowedHostsConfig.Hosts is empty.
+var ErrAllowedHostsEmpty = errors.New("allowed hosts: hosts list must not be empty")
+
+// ErrAllowedHostsInvalidEntry is returned when a Hosts entry is not a valid
+// host name, IP address, or "*." wildcard.
+var ErrAllowedHostsInvalidEntry = errors.New("allowed hosts: invalid entry")
+
+// AllowedHostsConfig configures the Allowed Hosts middleware.
+type AllowedHostsConfig struct {
+	// Hosts lists the host names accepted in the Host header. Required;
+	// must contain at least one entry. Entries are exact host names or
+	// IP addresses ("example.com", "10.0.0.1", "::1"), or wildcards of
+	// the form "*.example.com" that match any subdomain of example.com
+	// but not example.com itself. Matching is case-insensitive and
+	// ignores the port and a trailing dot.
+	Hosts []string
+
+	// ExemptPaths lists paths that are served regardless of the Host
+	// header, such as health checks hit by load balancers with an IP
+	// address as host. A trailing "*" matches any path with the
+	// preceding prefix.
+	ExemptPaths []string
+
+	// DeniedHandler is called when the host is not allowed. When nil, a
+	// default handler returns 400 Bad Request.
+	DeniedHandler http.Handler
+}
+
+// AllowedHostsMiddleware returns a middleware that rejects requests whose
+// Host header is not in the configured allowlist, protecting handlers that
+// build absolute URLs from r.Host against host header injection.
+//
+// Allowed requests continue with r.Host normalized to lower case without a
+// trailing dot; the port is kept. Register it after ProxyHeadersMiddleware
+// so a trusted X-Forwarded-Host is validated rather than the proxy's own
+// Host.
+//
+// It returns ErrAllowedHostsEmpty when Hosts is empty and an error wrapping
+// ErrAllowedHostsInvalidEntry for malformed entries.
+func AllowedHostsMiddleware(cfg AllowedHostsConfig) (mux.MiddlewareFunc, error) {
+	if len(cfg.Hosts) == 0 {
+		return nil, ErrAllowedHostsEmpty
+	}
+
+	exact := make(map[string]struct{}, len(cfg.Hosts))
+	var suffixes []string
+
+	for _, entry := range cfg.Hosts {
+		name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry)), ".")
+		if wildcard, ok := strings.CutPrefix(name, "*."); ok {
+			if !validAllowedHostName(wildcard) {
+				return nil, fmt.Errorf("%w: %q", ErrAllowedHostsInvalidEntry, entry)
+			}
+			suffixes = append(suffixes, "."+wildcard)
+			continue
+		}
+
+		if ip := net.ParseIP(strings.Trim(name, "[]")); ip != nil {
+			name = ip.String()
+		} else if !validAllowedHostName(name) {
+			return nil, fmt.Errorf("%w: %q", ErrAllowedHostsInvalidEntry, entry)
+		}
+		exact[name] = struct{}{}
+	}
+
+	denied := cfg.DeniedHandler
+	if denied == nil {
+		denied = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isExemptPath(cfg.ExemptPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			host, normalized := normalizeRequestHost(r.Host)
+			if !isAllowedHost(host, exact, suffixes) {
+				denied.ServeHTTP(w, r)
+				return
+			}
+
+			r.Host = normalized
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// normalizeRequestHost splits a Host header value into the lower-cased host
+// name used for matching, with brackets removed from IPv6 literals, and the
+// normalized header value including the port.
+func normalizeRequestHost(value string) (string, string) {
+	value = strings.ToLower(value)
+
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		host, port = strings.Trim(value, "[]"), ""
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	}
+
+	normalized := host
+	if strings.Contains(host, ":") {
+		normalized = "[" + host + "]"
+	}
+	if port != "" {
+		normalized += ":" + port
+	}
+
+	return host, normalized
+}
+
+// isAllowedHost reports whether host equals an exact entry or is a
+// subdomain of a wildcard entry.
+func isAllowedHost(host string, exact map[string]struct{}, suffixes []string) bool {
+	if host == "" {
+		return false
+	}
+
+	if _, ok := exact[host]; ok {
+		return true
+	}
+
+	for _, suffix := range suffixes {
+		if len(host) > len(suffix) && strings.HasSuffix(host, suffix) && validAllowedHostName(host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validAllowedHostName reports whether name is a non-empty sequence of
+// dot-separated labels made of letters, digits, hyphens, and underscores.
+func validAllowedHostName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for label := range strings.SplitSeq(name, ".") {
+		if label == "" {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,176 @@
+package muxhandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+func TestAllowedHostsMiddleware(t *testing.T) {
+	newRouter := func(t *testing.T, cfg AllowedHostsConfig) *mux.Router {
+		t.Helper()
+
+		r := mux.NewRouter()
+		r.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Host", r.Host)
+			w.WriteHeader(http.StatusOK)
+		}).Methods(http.MethodGet)
+		r.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}).Methods(http.MethodGet)
+
+		mw, err := AllowedHostsMiddleware(cfg)
+		require.NoError(t, err)
+		r.Use(mw)
+
+		return r
+	}
+
+	serve := func(r *mux.Router, host, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("allowed exact host", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{Hosts: []string{"example.com"}})
+
+		w := serve(r, "example.com", "/test")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "example.com", w.Header().Get("X-Host"))
+	})
+
+	t.Run("allowed wildcard subdomain", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{Hosts: []string{"*.example.com"}})
+
+		assert.Equal(t, http.StatusOK, serve(r, "api.example.com", "/test").Code)
+		assert.Equal(t, http.StatusOK, serve(r, "v1.api.example.com", "/test").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(r, "example.com", "/test").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(r, "evilexample.com", "/test").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(r, ".example.com", "/test").Code)
+	})
+
+	t.Run("rejected host", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{Hosts: []string{"example.com", "*.example.com"}})
+
+		for _, host := range []string{"evil.com", "example.com.evil.com", "", "example.com/x", "evil.com#.example.com"} {
+			w := serve(r, host, "/test")
+			assert.Equal(t, http.StatusBadRequest, w.Code, host)
+			assert.Empty(t, w.Header().Get("X-Host"), host)
+		}
+	})
+
+	t.Run("normalizes host", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{Hosts: []string{"Example.COM", "::1"}})
+
+		tests := []struct {
+			host string
+			want string
+		}{
+			{"EXAMPLE.com", "example.com"},
+			{"example.com.", "example.com"},
+			{"Example.com:8443", "example.com:8443"},
+			{"example.com.:8443", "example.com:8443"},
+			{"[::1]:8080", "[::1]:8080"},
+			{"[0:0::1]", "[::1]"},
+		}
+		for _, tt := range tests {
+			w := serve(r, tt.host, "/test")
+			assert.Equal(t, http.StatusOK, w.Code, tt.host)
+			assert.Equal(t, tt.want, w.Header().Get("X-Host"), tt.host)
+		}
+	})
+
+	t.Run("IP address host", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{Hosts: []string{"10.0.0.1"}})
+
+		assert.Equal(t, http.StatusOK, serve(r, "10.0.0.1:8080", "/test").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(r, "10.0.0.2", "/test").Code)
+	})
+
+	t.Run("exempt paths bypass check", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{
+			Hosts:       []string{"example.com"},
+			ExemptPaths: []string{"/healthz"},
+		})
+
+		assert.Equal(t, http.StatusOK, serve(r, "10.1.2.3:8080", "/healthz").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(r, "10.1.2.3:8080", "/test").Code)
+	})
+
+	t.Run("custom denied handler", func(t *testing.T) {
+		r := newRouter(t, AllowedHostsConfig{
+			Hosts: []string{"example.com"},
+			DeniedHandler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusMisdirectedRequest)
+			}),
+		})
+
+		assert.Equal(t, http.StatusMisdirectedRequest, serve(r, "evil.com", "/test").Code)
+	})
+
+	t.Run("validates forwarded host", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/test", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}).Methods(http.MethodGet)
+
+		proxy, err := ProxyHeadersMiddleware(ProxyHeadersConfig{TrustedProxies: []string{"10.0.0.0/8"}})
+		require.NoError(t, err)
+		hosts, err := AllowedHostsMiddleware(AllowedHostsConfig{Hosts: []string{"example.com"}})
+		require.NoError(t, err)
+		r.Use(proxy, hosts)
+
+		for forwarded, want := range map[string]int{
+			"example.com": http.StatusOK,
+			"evil.com":    http.StatusBadRequest,
+		} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Host = "backend.internal"
+			req.RemoteAddr = "10.0.0.5:1234"
+			req.Header.Set("X-Forwarded-Host", forwarded)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, want, w.Code, forwarded)
+		}
+	})
+
+	t.Run("config errors", func(t *testing.T) {
+		_, err := AllowedHostsMiddleware(AllowedHostsConfig{})
+		assert.ErrorIs(t, err, ErrAllowedHostsEmpty)
+
+		for _, entry := range []string{"", "*.", "exa mple.com", "example.com/path", "*.*.example.com", "example..com"} {
+			_, err := AllowedHostsMiddleware(AllowedHostsConfig{Hosts: []string{entry}})
+			assert.ErrorIs(t, err, ErrAllowedHostsInvalidEntry, entry)
+		}
+	})
+}
+
+func BenchmarkAllowedHostsMiddleware(b *testing.B) {
+	r := mux.NewRouter()
+	r.HandleFunc("/test", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	mw, err := AllowedHostsMiddleware(AllowedHostsConfig{
+		Hosts: []string{"example.com", "*.example.com"},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	r.Use(mw)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Host = "api.example.com:8443"
+
+	b.ResetTimer()
+	for b.Loop() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
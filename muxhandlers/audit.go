@@ -0,0 +1,420 @@
+package muxhandlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// ErrNoAuditSink is returned when AuditConfig.Sink is nil.
+var ErrNoAuditSink = errors.New("audit: Sink must be set")
+
+// defaultAuditMaxBodySize is the per-body capture cap used when
+// AuditConfig.MaxBodySize is zero.
+const defaultAuditMaxBodySize = 64 << 10
+
+// AuditRecord is the structured record handed to an AuditSink for
+// every audited request. Bodies are private copies capped at
+// AuditConfig.MaxBodySize and already passed through Redact.
+type AuditRecord struct {
+	// Time is when the request started processing.
+	Time time.Time
+
+	// Method is the HTTP request method (RFC 9110 Section 9).
+	Method string
+
+	// Path is r.URL.Path as observed at handler entry.
+	Path string
+
+	// PathTemplate is the path template of the matched route, e.g.
+	// "/accounts/{id}/transfers". Empty when no route was matched.
+	PathTemplate string
+
+	// RouteName is the name set via mux.Route.Name, when the matched
+	// route has one.
+	RouteName string
+
+	// Status is the HTTP status code written by the handler. Defaults
+	// to 200 when the handler completed without calling WriteHeader.
+	// Set to 0 when the connection was hijacked.
+	Status int
+
+	// Duration is the wall-clock time spent in the handler chain.
+	Duration time.Duration
+
+	// RequestID is the value returned by RequestIDFromContext, when the
+	// request flowed through RequestIDMiddleware. Empty otherwise.
+	RequestID string
+
+	// RequestBody holds the captured request body bytes. Only bytes
+	// the handler actually read are captured.
+	RequestBody []byte
+
+	// RequestBodySize is the number of request body bytes the handler
+	// read, including any beyond the capture cap.
+	RequestBodySize int64
+
+	// RequestBodyTruncated reports that RequestBody holds only the
+	// first MaxBodySize bytes of a larger body.
+	RequestBodyTruncated bool
+
+	// ResponseBody holds the captured response body bytes.
+	ResponseBody []byte
+
+	// ResponseBodySize is the total number of response body bytes
+	// written, including any beyond the capture cap.
+	ResponseBodySize int64
+
+	// ResponseBodyTruncated reports that ResponseBody holds only the
+	// first MaxBodySize bytes of a larger body.
+	ResponseBodyTruncated bool
+}
+
+// AuditSink receives audit records. Audit is called on a background
+// goroutine after the response has been sent, so a slow or failing
+// sink never delays or fails the request. Implementations must be
+// safe for concurrent use.
+type AuditSink interface {
+	// Audit persists the record. The context carries the request's
+	// values but is not canceled when the request completes.
+	Audit(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditSinkFunc adapts an ordinary function to the AuditSink
+// interface.
+type AuditSinkFunc func(ctx context.Context, record *AuditRecord) error
+
+// Audit calls f(ctx, record).
+func (f AuditSinkFunc) Audit(ctx context.Context, record *AuditRecord) error {
+	return f(ctx, record)
+}
+
+// AuditConfig configures the Audit middleware.
+type AuditConfig struct {
+	// Sink receives the audit records. Required.
+	Sink AuditSink
+
+	// Routes lists the names of routes (set via mux.Route.Name) whose
+	// requests are audited.
+	Routes []string
+
+	// Match, when non-nil, selects additional requests to audit. It
+	// runs after route matching, so mux.CurrentRoute and mux.Vars are
+	// available. When both Routes and Match are empty, every request
+	// is audited.
+	Match func(*http.Request) bool
+
+	// MaxBodySize caps the number of bytes captured from each of the
+	// request and response bodies. Bodies larger than the cap are
+	// truncated and flagged in the record; nothing beyond the cap is
+	// ever buffered. Defaults to 64 KiB. A negative value disables
+	// body capture while still recording the body sizes.
+	MaxBodySize int
+
+	// Redact, when non-nil, is applied to every captured body before
+	// it reaches the Sink, and returns the body to record. The path
+	// is the matched route's path template, or r.URL.Path when no
+	// route was matched. It runs on the sink goroutine.
+	Redact func(path string, body []byte) []byte
+
+	// ErrorFunc, when non-nil, is called with the record and the error
+	// when the Sink fails or panics. Sink failures are otherwise
+	// ignored.
+	ErrorFunc func(record *AuditRecord, err error)
+
+	// now overrides the clock source used for timestamps and duration
+	// measurement; tests set it. Defaults to time.Now.
+	now func() time.Time
+}
+
+// AuditMiddleware captures the request and response bodies of selected
+// routes and delivers them as AuditRecords to an asynchronous sink, for
+// compliance trails on sensitive operations such as financial
+// mutations.
+//
+// The request body is teed as the handler reads it and the response
+// body as the handler writes it; at most MaxBodySize bytes of each are
+// kept. The response writer wrapper preserves http.Flusher,
+// http.Hijacker, and http.Pusher exactly as the underlying writer
+// advertises them. Records are delivered on a separate goroutine once
+// the handler returns, so sink latency and failures never affect the
+// response.
+//
+// It returns ErrNoAuditSink if Sink is nil.
+func AuditMiddleware(cfg AuditConfig) (mux.MiddlewareFunc, error) {
+	if cfg.Sink == nil {
+		return nil, ErrNoAuditSink
+	}
+
+	maxBodySize := cfg.MaxBodySize
+	switch {
+	case maxBodySize == 0:
+		maxBodySize = defaultAuditMaxBodySize
+	case maxBodySize < 0:
+		maxBodySize = 0
+	}
+
+	now := cfg.now
+	if now == nil {
+		now = time.Now
+	}
+
+	routes := slices.Clone(cfg.Routes)
+	match := cfg.Match
+	selectAll := len(routes) == 0 && match == nil
+
+	selected := func(r *http.Request) bool {
+		if selectAll {
+			return true
+		}
+		if route := mux.CurrentRoute(r); route != nil && slices.Contains(routes, route.GetName()) {
+			return true
+		}
+		return match != nil && match(r)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !selected(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := now()
+
+			reqBody := &auditBodyReader{capture: auditCapture{limit: maxBodySize}}
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody.ReadCloser = r.Body
+				r.Body = reqBody
+			}
+			recorder, wrapped := auditWrap(w, maxBodySize)
+
+			next.ServeHTTP(wrapped, r)
+
+			record := &AuditRecord{
+				Time:                  start,
+				Method:                r.Method,
+				Path:                  r.URL.Path,
+				Duration:              now().Sub(start),
+				RequestID:             RequestIDFromContext(r.Context()),
+				RequestBody:           reqBody.capture.buf,
+				RequestBodySize:       reqBody.capture.size,
+				RequestBodyTruncated:  reqBody.capture.truncated(),
+				ResponseBody:          recorder.capture.buf,
+				ResponseBodySize:      recorder.capture.size,
+				ResponseBodyTruncated: recorder.capture.truncated(),
+			}
+			if !recorder.hijacked {
+				record.Status = recorder.statusOrDefault()
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				record.RouteName = route.GetName()
+				record.PathTemplate, _ = route.GetPathTemplate()
+			}
+
+			go deliverAuditRecord(context.WithoutCancel(r.Context()), cfg, record)
+		})
+	}, nil
+}
+
+// deliverAuditRecord redacts the captured bodies and hands the record
+// to the sink. Errors and panics are reported to ErrorFunc and never
+// propagate.
+func deliverAuditRecord(ctx context.Context, cfg AuditConfig, record *AuditRecord) {
+	defer func() {
+		if p := recover(); p != nil && cfg.ErrorFunc != nil {
+			cfg.ErrorFunc(record, fmt.Errorf("audit: sink panic: %v", p))
+		}
+	}()
+
+	if cfg.Redact != nil {
+		path := record.PathTemplate
+		if path == "" {
+			path = record.Path
+		}
+		if len(record.RequestBody) > 0 {
+			record.RequestBody = cfg.Redact(path, record.RequestBody)
+		}
+		if len(record.ResponseBody) > 0 {
+			record.ResponseBody = cfg.Redact(path, record.ResponseBody)
+		}
+	}
+
+	if err := cfg.Sink.Audit(ctx, record); err != nil && cfg.ErrorFunc != nil {
+		cfg.ErrorFunc(record, err)
+	}
+}
+
+// auditCapture accumulates up to limit bytes of a body stream while
+// counting its full size.
+type auditCapture struct {
+	buf   []byte
+	limit int
+	size  int64
+}
+
+func (c *auditCapture) write(p []byte) {
+	c.size += int64(len(p))
+	if room := c.limit - len(c.buf); room > 0 {
+		c.buf = append(c.buf, p[:min(room, len(p))]...)
+	}
+}
+
+func (c *auditCapture) truncated() bool {
+	return c.size > int64(len(c.buf))
+}
+
+// auditBodyReader tees the request body into a capture as the handler
+// reads it.
+type auditBodyReader struct {
+	io.ReadCloser
+	capture auditCapture
+}
+
+func (r *auditBodyReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.capture.write(p[:n])
+	return n, err
+}
+
+// auditResponseWriter is the base wrapper that records the status code
+// and tees the response body into a capture. It always exposes Unwrap
+// so http.ResponseController can reach optional methods on the
+// underlying writer; Flusher, Hijacker, and Pusher are exposed through
+// auditWrap, which picks a wrapper variant matching only the
+// capabilities the underlying writer actually advertises.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	capture     auditCapture
+	status      int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (w *auditResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.status = http.StatusOK
+		w.wroteHeader = true
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.capture.write(b[:n])
+	return n, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter so
+// http.ResponseController can reach optional methods the embedded
+// writer implements.
+func (w *auditResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusOrDefault returns the recorded status code, falling back to
+// 200 for handlers that completed without writing anything.
+func (w *auditResponseWriter) statusOrDefault() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// The seven audit wrapper variants cover every non-empty subset of
+// {Flusher, Hijacker, Pusher}. auditWrap picks the variant whose
+// method set matches the capabilities of the wrapped writer, so
+// handler-side type assertions like w.(http.Hijacker) observe the
+// same ok value they would on the bare net/http writer.
+
+type auditFW struct{ *auditResponseWriter }
+
+func (w auditFW) Flush() {
+	if !w.wroteHeader {
+		w.status = http.StatusOK
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type auditHW struct{ *auditResponseWriter }
+
+func (w auditHW) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, brw, err
+}
+
+type auditPW struct{ *auditResponseWriter }
+
+func (w auditPW) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type auditFHW struct {
+	*auditResponseWriter
+	auditFW
+	auditHW
+}
+
+type auditFPW struct {
+	*auditResponseWriter
+	auditFW
+	auditPW
+}
+
+type auditHPW struct {
+	*auditResponseWriter
+	auditHW
+	auditPW
+}
+
+type auditFHPW struct {
+	*auditResponseWriter
+	auditFW
+	auditHW
+	auditPW
+}
+
+// auditWrap returns an http.ResponseWriter that wraps inner with status
+// and body capture and exposes exactly the optional interfaces the
+// inner writer supports. The base recorder is returned alongside so
+// callers can read the captured fields after the handler has run.
+func auditWrap(inner http.ResponseWriter, limit int) (*auditResponseWriter, http.ResponseWriter) {
+	base := &auditResponseWriter{ResponseWriter: inner, capture: auditCapture{limit: limit}}
+	_, flush := inner.(http.Flusher)
+	_, hijack := inner.(http.Hijacker)
+	_, push := inner.(http.Pusher)
+	switch {
+	case flush && hijack && push:
+		return base, auditFHPW{auditResponseWriter: base, auditFW: auditFW{base}, auditHW: auditHW{base}, auditPW: auditPW{base}}
+	case flush && hijack:
+		return base, auditFHW{auditResponseWriter: base, auditFW: auditFW{base}, auditHW: auditHW{base}}
+	case flush && push:
+		return base, auditFPW{auditResponseWriter: base, auditFW: auditFW{base}, auditPW: auditPW{base}}
+	case hijack && push:
+		return base, auditHPW{auditResponseWriter: base, auditHW: auditHW{base}, auditPW: auditPW{base}}
+	case flush:
+		return base, auditFW{base}
+	case hijack:
+		return base, auditHW{base}
+	case push:
+		return base, auditPW{base}
+	default:
+		return base, base
+	}
+}
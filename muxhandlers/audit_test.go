@@ -0,0 +1,316 @@
+package muxhandlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+// chanAuditSink forwards records to a channel so tests can wait for the
+// asynchronous delivery.
+type chanAuditSink chan *AuditRecord
+
+func (s chanAuditSink) Audit(_ context.Context, record *AuditRecord) error {
+	s <- record
+	return nil
+}
+
+func awaitAuditRecord(t *testing.T, sink chanAuditSink) *AuditRecord {
+	t.Helper()
+	select {
+	case record := <-sink:
+		return record
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for audit record")
+		return nil
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	t.Run("requires sink", func(t *testing.T) {
+		_, err := AuditMiddleware(AuditConfig{})
+		assert.ErrorIs(t, err, ErrNoAuditSink)
+	})
+
+	t.Run("captures request and response", func(t *testing.T) {
+		sink := make(chanAuditSink, 1)
+		mw, err := AuditMiddleware(AuditConfig{Sink: sink})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		r.Use(RequestIDMiddleware(RequestIDConfig{}))
+		r.Use(mw)
+		r.HandleFunc("/accounts/{id}/transfers", func(w http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(append([]byte("ok:"), body...))
+		}).Methods(http.MethodPost).Name("transfer")
+
+		req := httptest.NewRequest(http.MethodPost, "/accounts/42/transfers", strings.NewReader(`{"amount":10}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, `ok:{"amount":10}`, w.Body.String())
+
+		record := awaitAuditRecord(t, sink)
+		assert.Equal(t, http.MethodPost, record.Method)
+		assert.Equal(t, "/accounts/42/transfers", record.Path)
+		assert.Equal(t, "/accounts/{id}/transfers", record.PathTemplate)
+		assert.Equal(t, "transfer", record.RouteName)
+		assert.Equal(t, http.StatusCreated, record.Status)
+		assert.Equal(t, w.Header().Get("X-Request-ID"), record.RequestID)
+		assert.NotEmpty(t, record.RequestID)
+		assert.Equal(t, `{"amount":10}`, string(record.RequestBody))
+		assert.Equal(t, int64(13), record.RequestBodySize)
+		assert.False(t, record.RequestBodyTruncated)
+		assert.Equal(t, `ok:{"amount":10}`, string(record.ResponseBody))
+		assert.Equal(t, int64(16), record.ResponseBodySize)
+		assert.False(t, record.ResponseBodyTruncated)
+	})
+
+	t.Run("selects routes by name and matcher", func(t *testing.T) {
+		sink := make(chanAuditSink, 4)
+		mw, err := AuditMiddleware(AuditConfig{
+			Sink:   sink,
+			Routes: []string{"transfer"},
+			Match: func(r *http.Request) bool {
+				return r.Header.Get("X-Audit") == "1"
+			},
+		})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		r.Use(mw)
+		r.HandleFunc("/transfer", func(http.ResponseWriter, *http.Request) {}).Name("transfer")
+		r.HandleFunc("/balance", func(http.ResponseWriter, *http.Request) {}).Name("balance")
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance", nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/transfer", nil))
+		assert.Equal(t, "transfer", awaitAuditRecord(t, sink).RouteName)
+
+		req := httptest.NewRequest(http.MethodGet, "/balance", nil)
+		req.Header.Set("X-Audit", "1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, "balance", awaitAuditRecord(t, sink).RouteName)
+
+		select {
+		case record := <-sink:
+			t.Fatalf("unexpected record for %s", record.Path)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+
+	t.Run("truncates bodies at the cap", func(t *testing.T) {
+		sink := make(chanAuditSink, 1)
+		mw, err := AuditMiddleware(AuditConfig{Sink: sink, MaxBodySize: 4})
+		require.NoError(t, err)
+
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			w.Write([]byte("abc"))
+			w.Write([]byte("defgh"))
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+
+		assert.Equal(t, "abcdefgh", w.Body.String())
+
+		record := awaitAuditRecord(t, sink)
+		assert.Equal(t, "0123", string(record.RequestBody))
+		assert.Equal(t, int64(10), record.RequestBodySize)
+		assert.True(t, record.RequestBodyTruncated)
+		assert.Equal(t, "abcd", string(record.ResponseBody))
+		assert.Equal(t, int64(8), record.ResponseBodySize)
+		assert.True(t, record.ResponseBodyTruncated)
+		assert.LessOrEqual(t, cap(record.ResponseBody), 8)
+	})
+
+	t.Run("negative cap records sizes only", func(t *testing.T) {
+		sink := make(chanAuditSink, 1)
+		mw, err := AuditMiddleware(AuditConfig{Sink: sink, MaxBodySize: -1})
+		require.NoError(t, err)
+
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("secret"))
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		record := awaitAuditRecord(t, sink)
+		assert.Empty(t, record.ResponseBody)
+		assert.Equal(t, int64(6), record.ResponseBodySize)
+		assert.True(t, record.ResponseBodyTruncated)
+	})
+
+	t.Run("redacts bodies", func(t *testing.T) {
+		sink := make(chanAuditSink, 1)
+		var paths []string
+		mw, err := AuditMiddleware(AuditConfig{
+			Sink: sink,
+			Redact: func(path string, body []byte) []byte {
+				paths = append(paths, path)
+				return bytes.ReplaceAll(body, []byte("hunter2"), []byte("***"))
+			},
+		})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		r.Use(mw)
+		r.HandleFunc("/login/{user}", func(w http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			w.Write(body)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login/bob", strings.NewReader("pw=hunter2")))
+
+		assert.Equal(t, "pw=hunter2", w.Body.String(), "response to the client is untouched")
+
+		record := awaitAuditRecord(t, sink)
+		assert.Equal(t, "pw=***", string(record.RequestBody))
+		assert.Equal(t, "pw=***", string(record.ResponseBody))
+		assert.Equal(t, []string{"/login/{user}", "/login/{user}"}, paths)
+	})
+
+	t.Run("sink failure does not fail the request", func(t *testing.T) {
+		errCh := make(chan error, 2)
+		failing := AuditSinkFunc(func(context.Context, *AuditRecord) error {
+			return errors.New("disk full")
+		})
+		panicking := AuditSinkFunc(func(context.Context, *AuditRecord) error {
+			panic("boom")
+		})
+
+		for _, sink := range []AuditSink{failing, panicking} {
+			mw, err := AuditMiddleware(AuditConfig{
+				Sink:      sink,
+				ErrorFunc: func(_ *AuditRecord, err error) { errCh <- err },
+			})
+			require.NoError(t, err)
+
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+			assert.Equal(t, http.StatusAccepted, w.Code)
+		}
+
+		var got []string
+		for range 2 {
+			select {
+			case err := <-errCh:
+				got = append(got, err.Error())
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for sink error")
+			}
+		}
+		assert.ElementsMatch(t, []string{"disk full", "audit: sink panic: boom"}, got)
+	})
+
+	t.Run("context survives the request", func(t *testing.T) {
+		ctxErr := make(chan error, 1)
+		mw, err := AuditMiddleware(AuditConfig{
+			Sink: AuditSinkFunc(func(ctx context.Context, _ *AuditRecord) error {
+				ctxErr <- ctx.Err()
+				return nil
+			}),
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		h := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { cancel() }))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+		select {
+		case err := <-ctxErr:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for sink")
+		}
+	})
+
+	t.Run("default status and duration", func(t *testing.T) {
+		sink := make(chanAuditSink, 1)
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		calls := 0
+		mw, err := AuditMiddleware(AuditConfig{
+			Sink: sink,
+			now: func() time.Time {
+				calls++
+				return start.Add(time.Duration(calls-1) * 250 * time.Millisecond)
+			},
+		})
+		require.NoError(t, err)
+
+		h := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/", nil))
+
+		record := awaitAuditRecord(t, sink)
+		assert.Equal(t, http.StatusOK, record.Status)
+		assert.Equal(t, start, record.Time)
+		assert.Equal(t, 250*time.Millisecond, record.Duration)
+		assert.Nil(t, record.RequestBody)
+		assert.Empty(t, record.PathTemplate)
+	})
+}
+
+func TestAuditPreservesOptionalInterfaces(t *testing.T) {
+	sink := make(chanAuditSink, 4)
+	mw, err := AuditMiddleware(AuditConfig{Sink: sink})
+	require.NoError(t, err)
+
+	t.Run("plain writer exposes no optional interfaces", func(t *testing.T) {
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, flush := w.(http.Flusher)
+			_, hijack := w.(http.Hijacker)
+			_, push := w.(http.Pusher)
+			assert.False(t, flush)
+			assert.False(t, hijack)
+			assert.False(t, push)
+		}))
+		h.ServeHTTP(struct{ http.ResponseWriter }{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+		awaitAuditRecord(t, sink)
+	})
+
+	t.Run("flush is forwarded", func(t *testing.T) {
+		inner := &fullResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.(http.Flusher).Flush()
+		}))
+		h.ServeHTTP(inner, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.True(t, inner.flushed)
+		assert.Equal(t, http.StatusOK, awaitAuditRecord(t, sink).Status)
+	})
+
+	t.Run("hijack is forwarded and zeroes status", func(t *testing.T) {
+		inner := &fullResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+		}))
+		h.ServeHTTP(inner, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.True(t, inner.hijacked)
+		assert.Equal(t, 0, awaitAuditRecord(t, sink).Status)
+	})
+
+	t.Run("ResponseController reaches the inner writer", func(t *testing.T) {
+		inner := &fullResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			assert.NoError(t, http.NewResponseController(w).Flush())
+		}))
+		h.ServeHTTP(inner, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.True(t, inner.flushed)
+		awaitAuditRecord(t, sink)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/vitalvas/kasper/mux"
 )
@@ -14,6 +15,10 @@ import (
 // nor Credentials configured.
 var ErrNoAuthSource = errors.New("basic auth: at least one of ValidateFunc or Credentials must be set")
 
+// ErrInvalidBasicAuthCharset is returned when BasicAuthConfig.Charset is
+// set to a value other than "UTF-8", the only one RFC 7617 allows.
+var ErrInvalidBasicAuthCharset = errors.New(`basic auth: Charset must be "UTF-8"`)
+
 // BasicAuthConfig configures the Basic Auth middleware behaviour.
 //
 // Spec reference: https://www.rfc-editor.org/rfc/rfc7617
@@ -22,6 +27,19 @@ type BasicAuthConfig struct {
 	// Defaults to "Restricted" when empty.
 	Realm string
 
+	// RealmFunc returns the realm for a request, for a middleware shared
+	// by subrouters that protect different realms. Takes priority over
+	// Realm; an empty result falls back to Realm.
+	RealmFunc func(r *http.Request) string
+
+	// Charset is sent as the charset auth-param of the WWW-Authenticate
+	// header, telling clients to encode the credentials as UTF-8 so that
+	// non-ASCII usernames and passwords decode reliably. RFC 7617 allows
+	// only "UTF-8" (case-insensitive). Omitted when empty.
+	//
+	// Spec reference: https://www.rfc-editor.org/rfc/rfc7617#section-2.1
+	Charset string
+
 	// ValidateFunc is called to validate credentials dynamically.
 	// Takes priority over Credentials when both are set.
 	ValidateFunc func(username, password string) bool
@@ -36,18 +54,39 @@ type BasicAuthConfig struct {
 // Authentication per RFC 7617. It validates the Authorization header and
 // responds with 401 Unauthorized when credentials are missing or invalid.
 //
-// It returns ErrNoAuthSource if both ValidateFunc and Credentials are nil/empty.
+// It returns ErrNoAuthSource if both ValidateFunc and Credentials are
+// nil/empty, and ErrInvalidBasicAuthCharset if Charset is not "UTF-8".
 func BasicAuthMiddleware(cfg BasicAuthConfig) (mux.MiddlewareFunc, error) {
 	if cfg.ValidateFunc == nil && len(cfg.Credentials) == 0 {
 		return nil, ErrNoAuthSource
 	}
+	if cfg.Charset != "" && !strings.EqualFold(cfg.Charset, "UTF-8") {
+		return nil, ErrInvalidBasicAuthCharset
+	}
 
 	realm := cfg.Realm
 	if realm == "" {
 		realm = "Restricted"
 	}
 
-	wwwAuthenticate := fmt.Sprintf("Basic realm=%q", realm)
+	charset := cfg.Charset
+	challenge := func(realm string) string {
+		if charset == "" {
+			return fmt.Sprintf("Basic realm=%q", realm)
+		}
+		return fmt.Sprintf("Basic realm=%q, charset=%q", realm, charset)
+	}
+
+	staticChallenge := challenge(realm)
+	realmFunc := cfg.RealmFunc
+	challengeFor := func(r *http.Request) string {
+		if realmFunc != nil {
+			if requestRealm := realmFunc(r); requestRealm != "" {
+				return challenge(requestRealm)
+			}
+		}
+		return staticChallenge
+	}
 
 	validate := cfg.ValidateFunc
 	credentials := cfg.Credentials
@@ -56,13 +95,13 @@ func BasicAuthMiddleware(cfg BasicAuthConfig) (mux.MiddlewareFunc, error) {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			username, password, ok := r.BasicAuth()
 			if !ok {
-				unauthorized(w, wwwAuthenticate)
+				unauthorized(w, challengeFor(r))
 				return
 			}
 
 			if validate != nil {
 				if !validate(username, password) {
-					unauthorized(w, wwwAuthenticate)
+					unauthorized(w, challengeFor(r))
 					return
 				}
 			} else {
@@ -71,7 +110,7 @@ func BasicAuthMiddleware(cfg BasicAuthConfig) (mux.MiddlewareFunc, error) {
 				// leaks that reveal whether a username exists in the map.
 				passwordMatch := constantTimeEqual(password, expectedPassword)
 				if !exists || !passwordMatch {
-					unauthorized(w, wwwAuthenticate)
+					unauthorized(w, challengeFor(r))
 					return
 				}
 			}
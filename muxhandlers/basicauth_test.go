@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +24,54 @@ func TestBasicAuth(t *testing.T) {
 		assert.ErrorIs(t, err, ErrNoAuthSource)
 	})
 
+	t.Run("config error invalid charset", func(t *testing.T) {
+		_, err := BasicAuthMiddleware(BasicAuthConfig{
+			Credentials: map[string]string{"admin": "secret"},
+			Charset:     "ISO-8859-1",
+		})
+		assert.ErrorIs(t, err, ErrInvalidBasicAuthCharset)
+	})
+
+	t.Run("realm per subrouter", func(t *testing.T) {
+		mw, err := BasicAuthMiddleware(BasicAuthConfig{
+			Credentials: map[string]string{"admin": "secret"},
+			RealmFunc: func(r *http.Request) string {
+				if strings.HasPrefix(r.URL.Path, "/admin/") {
+					return "Admin"
+				}
+				return ""
+			},
+			Charset: "UTF-8",
+		})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		for _, prefix := range []string{"/admin", "/api"} {
+			sub := r.PathPrefix(prefix).Subrouter()
+			sub.Use(mw)
+			sub.HandleFunc("/jobs", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/jobs", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Basic realm="Admin", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Basic realm="Restricted", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/jobs", nil)
+		req.Header.Set("Authorization", basicAuthHeader("admin", "secret"))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+	})
+
 	tests := []struct {
 		name        string
 		config      BasicAuthConfig
@@ -104,6 +153,28 @@ func TestBasicAuth(t *testing.T) {
 			wantCode:    http.StatusUnauthorized,
 			wantWWWAuth: `Basic realm="Restricted"`,
 		},
+		{
+			name:        "charset",
+			config:      BasicAuthConfig{Realm: "My App", Charset: "UTF-8", Credentials: map[string]string{"admin": "secret"}},
+			wantCode:    http.StatusUnauthorized,
+			wantWWWAuth: `Basic realm="My App", charset="UTF-8"`,
+		},
+		{
+			name:       "non-ASCII password with charset",
+			config:     BasicAuthConfig{Charset: "utf-8", Credentials: map[string]string{"jürgen": "pässwörd"}},
+			authHeader: basicAuthHeader("jürgen", "pässwörd"),
+			wantCode:   http.StatusOK,
+		},
+		{
+			name: "RealmFunc takes priority over Realm",
+			config: BasicAuthConfig{
+				Realm:       "Static",
+				RealmFunc:   func(r *http.Request) string { return "Tenant " + r.Host },
+				Credentials: map[string]string{"admin": "secret"},
+			},
+			wantCode:    http.StatusUnauthorized,
+			wantWWWAuth: `Basic realm="Tenant example.com"`,
+		},
 	}
 
 	for _, tt := range tests {
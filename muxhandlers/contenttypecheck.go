@@ -9,8 +9,8 @@ import (
 	"github.com/vitalvas/kasper/mux"
 )
 
-// ErrNoAllowedTypes is returned when ContentTypeCheckConfig.AllowedTypes is
-// empty.
+// ErrNoAllowedTypes is returned when both ContentTypeCheckConfig.AllowedTypes
+// and ContentTypeCheckConfig.AllowedSuffixes are empty.
 var ErrNoAllowedTypes = errors.New("content type check: at least one allowed content type is required")
 
 // ContentTypeCheckConfig configures the Content-Type Check middleware behaviour.
@@ -18,9 +18,17 @@ type ContentTypeCheckConfig struct {
 	// AllowedTypes is the set of acceptable Content-Type values.
 	// Matching is case-insensitive and ignores parameters
 	// (e.g. "application/json" matches "application/json; charset=utf-8").
-	// Required; at least one must be provided.
+	// At least one AllowedTypes or AllowedSuffixes entry is required.
 	AllowedTypes []string
 
+	// AllowedSuffixes is the set of acceptable structured syntax suffixes
+	// (RFC 6839), such as "+json" or "+xml". A suffix matches any media
+	// type whose subtype ends with it, for example "+json" matches
+	// "application/vnd.api+json" and "application/merge-patch+json", but
+	// not "application/json" itself; list that in AllowedTypes. The
+	// leading "+" is optional and matching is case-insensitive.
+	AllowedSuffixes []string
+
 	// Methods is the set of HTTP methods that require Content-Type
 	// validation. When nil, defaults to POST, PUT, PATCH.
 	Methods []string
@@ -37,11 +45,12 @@ var defaultCheckedMethods = []string{
 // ContentTypeCheckMiddleware returns a middleware that validates the
 // Content-Type header on requests with matching methods. It returns 415
 // Unsupported Media Type when the Content-Type is missing or does not match
-// any of the allowed types.
+// any of the allowed types or suffixes.
 //
-// It returns ErrNoAllowedTypes if AllowedTypes is empty.
+// It returns ErrNoAllowedTypes if AllowedTypes and AllowedSuffixes are both
+// empty.
 func ContentTypeCheckMiddleware(cfg ContentTypeCheckConfig) (mux.MiddlewareFunc, error) {
-	if len(cfg.AllowedTypes) == 0 {
+	if len(cfg.AllowedTypes) == 0 && len(cfg.AllowedSuffixes) == 0 {
 		return nil, ErrNoAllowedTypes
 	}
 
@@ -60,6 +69,14 @@ func ContentTypeCheckMiddleware(cfg ContentTypeCheckConfig) (mux.MiddlewareFunc,
 		allowedSet[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
 	}
 
+	suffixes := make([]string, 0, len(cfg.AllowedSuffixes))
+	for _, s := range cfg.AllowedSuffixes {
+		s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "+")
+		if s != "" {
+			suffixes = append(suffixes, "+"+s)
+		}
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if _, check := methodSet[r.Method]; check {
@@ -75,7 +92,7 @@ func ContentTypeCheckMiddleware(cfg ContentTypeCheckConfig) (mux.MiddlewareFunc,
 					return
 				}
 
-				if _, ok := allowedSet[strings.ToLower(mediaType)]; !ok {
+				if !isAllowedContentType(strings.ToLower(mediaType), allowedSet, suffixes) {
 					http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
 					return
 				}
@@ -85,3 +102,24 @@ func ContentTypeCheckMiddleware(cfg ContentTypeCheckConfig) (mux.MiddlewareFunc,
 		})
 	}, nil
 }
+
+// isAllowedContentType reports whether the lower-cased mediaType is in
+// allowed or its subtype ends with one of the structured syntax suffixes.
+func isAllowedContentType(mediaType string, allowed map[string]struct{}, suffixes []string) bool {
+	if _, ok := allowed[mediaType]; ok {
+		return true
+	}
+
+	_, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok {
+		return false
+	}
+
+	for _, suffix := range suffixes {
+		if len(subtype) > len(suffix) && strings.HasSuffix(subtype, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -204,6 +204,68 @@ func TestContentTypeCheckMiddleware(t *testing.T) {
 		})
 	})
 
+	t.Run("structured syntax suffix", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/test", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		mw, err := ContentTypeCheckMiddleware(ContentTypeCheckConfig{
+			AllowedTypes:    []string{"application/json"},
+			AllowedSuffixes: []string{"+json"},
+		})
+		require.NoError(t, err)
+		r.Use(mw)
+
+		tests := []struct {
+			contentType string
+			want        int
+		}{
+			{"application/vnd.api+json", http.StatusOK},
+			{"application/merge-patch+json; charset=utf-8", http.StatusOK},
+			{"Application/Problem+JSON", http.StatusOK},
+			{"application/json", http.StatusOK},
+			{"application/+json", http.StatusUnsupportedMediaType},
+			{"application/vnd.api+jsonx", http.StatusUnsupportedMediaType},
+			{"application/atom+xml", http.StatusUnsupportedMediaType},
+		}
+		for _, tt := range tests {
+			t.Run(tt.contentType, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/test", nil)
+				req.Header.Set("Content-Type", tt.contentType)
+				r.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.want, w.Code)
+			})
+		}
+	})
+
+	t.Run("suffix without allowed types", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/test", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		mw, err := ContentTypeCheckMiddleware(ContentTypeCheckConfig{
+			AllowedSuffixes: []string{"JSON"},
+		})
+		require.NoError(t, err)
+		r.Use(mw)
+
+		for contentType, want := range map[string]int{
+			"application/vnd.api+json": http.StatusOK,
+			"application/json":         http.StatusUnsupportedMediaType,
+		} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			req.Header.Set("Content-Type", contentType)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, want, w.Code, contentType)
+		}
+	})
+
 	t.Run("PUT and PATCH checked by default", func(t *testing.T) {
 		r := mux.NewRouter()
 		r.HandleFunc("/test", func(w http.ResponseWriter, _ *http.Request) {
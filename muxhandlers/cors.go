@@ -1,6 +1,7 @@
 package muxhandlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -67,6 +68,18 @@ type CORSConfig struct {
 	AllowPrivateNetwork bool
 }
 
+type corsOriginKey struct{}
+
+// corsOriginCheckFromContext returns the origin check of the CORSMiddleware
+// that handled the request, or nil if the request did not pass through one.
+func corsOriginCheckFromContext(ctx context.Context) func(origin string) bool {
+	if check, ok := ctx.Value(corsOriginKey{}).(func(string) bool); ok {
+		return check
+	}
+
+	return nil
+}
+
 // wildcardPattern represents a subdomain wildcard pattern split at the "*".
 type wildcardPattern struct {
 	prefix string
@@ -178,6 +191,12 @@ func CORSMiddleware(r *mux.Router, cfg CORSConfig) (mux.MiddlewareFunc, error) {
 		return false
 	}
 
+	// checkOrigin is exposed through the request context so handlers such
+	// as WebSocketHandler apply the same origin policy.
+	checkOrigin := func(rawOrigin string) bool {
+		return isAllowed(strings.ToLower(rawOrigin), rawOrigin)
+	}
+
 	hasSpecificOrigins := !cfg.hasWildcardOrigin() &&
 		(len(exactOrigins) > 0 || len(wildcardPatterns) > 0 || cfg.AllowOriginFunc != nil)
 
@@ -233,6 +252,7 @@ func CORSMiddleware(r *mux.Router, cfg CORSConfig) (mux.MiddlewareFunc, error) {
 			}
 
 			originLower := strings.ToLower(rawOrigin)
+			req = req.WithContext(context.WithValue(req.Context(), corsOriginKey{}, checkOrigin))
 
 			if !isAllowed(originLower, rawOrigin) {
 				next.ServeHTTP(w, req)
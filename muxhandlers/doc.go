@@ -20,7 +20,8 @@
 // BasicAuthMiddleware implements HTTP Basic Authentication per RFC 7617.
 // Credentials can be validated via a dynamic callback or a static map.
 // Static credential comparison uses constant-time comparison to prevent
-// timing attacks.
+// timing attacks. RealmFunc selects the realm per request, and Charset
+// "UTF-8" adds the charset auth-param to the challenge.
 //
 //	mw, err := muxhandlers.BasicAuthMiddleware(muxhandlers.BasicAuthConfig{
 //	    Realm: "My App",
@@ -86,6 +87,11 @@
 //	    },
 //	}))
 //
+// RecoverJSONMiddleware writes an RFC 9457 application/problem+json body
+// with status 500 instead, reporting the request ID from
+// RequestIDMiddleware as the instance member. The panic value is only
+// sent to the client when ExposePanic is set.
+//
 // # Request ID Middleware
 //
 // RequestIDMiddleware generates or propagates a unique request identifier.
@@ -134,6 +140,9 @@
 //	}
 //	r.Use(mw)
 //
+// To limit only some routes, use mux.Route.Timeout; its response is set with
+// mux.Router.TimeoutHandler.
+//
 // # Compression Middleware
 //
 // CompressionMiddleware compresses response bodies using gzip or deflate when
@@ -177,9 +186,15 @@
 // header value from HeaderNames is uppercased and checked against the allowed
 // set. When allowed, r.Method is updated and the header is removed from the
 // request. By default it checks X-HTTP-Method-Override, X-Method-Override,
-// and X-HTTP-Method in that order.
-//
-//	mw, err := muxhandlers.MethodOverrideMiddleware(muxhandlers.MethodOverrideConfig{})
+// and X-HTTP-Method in that order. Set FormField to also read the override
+// from an application/x-www-form-urlencoded body field such as "_method";
+// the body is buffered and restored so downstream handlers can read it.
+// Targets default to PUT, PATCH, and DELETE; CONNECT and TRACE are
+// rejected. OriginalMethodFromContext returns the method the client sent.
+//
+//	mw, err := muxhandlers.MethodOverrideMiddleware(muxhandlers.MethodOverrideConfig{
+//	    FormField: "_method",
+//	})
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
@@ -191,16 +206,37 @@
 // Content-Type header. Matching is case-insensitive and ignores parameters
 // such as charset. It returns 415 Unsupported Media Type when the
 // Content-Type is missing or does not match any of the allowed types.
-// By default it checks POST, PUT, and PATCH requests.
+// By default it checks POST, PUT, and PATCH requests. AllowedSuffixes
+// matches structured syntax suffixes, so "+json" accepts
+// application/vnd.api+json and application/merge-patch+json.
 //
 //	mw, err := muxhandlers.ContentTypeCheckMiddleware(muxhandlers.ContentTypeCheckConfig{
-//	    AllowedTypes: []string{"application/json"},
+//	    AllowedTypes:    []string{"application/json"},
+//	    AllowedSuffixes: []string{"+json"},
 //	})
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
 //	r.Use(mw)
 //
+// # Require Headers Middleware
+//
+// RequireHeadersMiddleware rejects requests missing any configured header,
+// or whose value does not fully match the header's Pattern, with 400 Bad
+// Request and a problem+json body listing the "missing" and "invalid"
+// header names. Apply it to a subrouter or route to scope it.
+//
+//	mw, err := muxhandlers.RequireHeadersMiddleware(muxhandlers.RequireHeadersConfig{
+//	    Headers: []muxhandlers.RequiredHeader{
+//	        {Name: "X-Tenant-ID"},
+//	        {Name: "X-Api-Version", Pattern: `v[0-9]+`},
+//	    },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	api.Use(mw)
+//
 // # Server Middleware
 //
 // ServerMiddleware sets server identification response headers. It sets
@@ -304,6 +340,26 @@
 //	}
 //	r.Use(mw)
 //
+// # Response Cache Middleware
+//
+// ResponseCacheMiddleware caches complete GET and HEAD responses in a
+// ResponseCacheStore (an in-memory LRU store capped by size by default),
+// with a default TTL and per-route TTLs keyed by route name or path
+// template. Responses that set cookies, are marked no-store or private, or
+// vary on headers outside VaryHeaders are never stored, and a cache status
+// header reports HIT, MISS, or BYPASS. The returned ResponseCache purges
+// entries by key or path prefix:
+//
+//	mw, cache, err := muxhandlers.ResponseCacheMiddleware(muxhandlers.ResponseCacheConfig{
+//	    RouteTTL: map[string]time.Duration{"/reports/{id}": time.Minute},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	r.Use(mw)
+//	// in a write handler:
+//	cache.PurgePrefix("/reports/")
+//
 // # Content Negotiation Middleware
 //
 // ContentNegotiationMiddleware performs proactive content negotiation per
@@ -399,6 +455,26 @@
 //	}
 //	r.Use(mw)
 //
+// # Redirect Policy Middleware
+//
+// RedirectPolicyMiddleware enforces HTTPS, a canonical host, lower-case
+// paths, and a trailing slash policy with at most one redirect per
+// request. The rules apply in that order and their rewrites are combined
+// into a single Location. The original scheme is detected with the same
+// trusted-proxy logic as ProxyHeadersMiddleware. ExemptPaths skips health
+// checks, and only GET and HEAD are redirected unless Methods is set.
+//
+//	mw, err := muxhandlers.RedirectPolicyMiddleware(muxhandlers.RedirectPolicyConfig{
+//	    ForceHTTPS:    true,
+//	    CanonicalHost: "example.com",
+//	    LowercasePath: true,
+//	    ExemptPaths:   []string{"/healthz"},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	r.Use(mw)
+//
 // # IP Allow Middleware
 //
 // IPAllowMiddleware restricts access to requests originating from a configured
@@ -414,6 +490,25 @@
 //	}
 //	r.Use(mw)
 //
+// # Allowed Hosts Middleware
+//
+// AllowedHostsMiddleware rejects requests whose Host header is not in an
+// allowlist of exact hosts and "*.example.com" wildcards with 400 Bad
+// Request, guarding against host header injection. Matching ignores case,
+// the port, and a trailing dot, and allowed requests continue with r.Host
+// normalized. Register it after ProxyHeadersMiddleware so a trusted
+// X-Forwarded-Host is validated. ExemptPaths keeps health checks reachable
+// by IP:
+//
+//	mw, err := muxhandlers.AllowedHostsMiddleware(muxhandlers.AllowedHostsConfig{
+//	    Hosts:       []string{"example.com", "*.example.com"},
+//	    ExemptPaths: []string{"/healthz"},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	r.Use(mw)
+//
 // # Access Log Middleware
 //
 // AccessLogMiddleware records a structured entry for every request,
@@ -441,6 +536,47 @@
 //	    },
 //	}))
 //
+// # Audit Middleware
+//
+// AuditMiddleware captures the request and response bodies of selected
+// routes (by route name or a matcher func) and hands an AuditRecord
+// with the method, path template, status, duration, request ID, and
+// bodies to an AuditSink on a background goroutine. At most
+// MaxBodySize bytes of each body are buffered; larger bodies are
+// flagged as truncated. Redact rewrites captured bodies before
+// delivery, and sink failures are reported to ErrorFunc without
+// affecting the response.
+//
+//	mw, err := muxhandlers.AuditMiddleware(muxhandlers.AuditConfig{
+//	    Sink:   auditSink,
+//	    Routes: []string{"transfer.create"},
+//	    Redact: redactSecrets,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	r.Use(mw)
+//
+// # Tracing Middleware
+//
+// TracingMiddleware starts a server span for every request through a
+// TracerLike, a small local interface, so the toolkit does not depend on
+// an OpenTelemetry SDK. The span is named after the method and the
+// matched route template ("GET /users/{id}") and carries the method,
+// route, response status, and the request ID from RequestIDMiddleware.
+// 5xx responses and panics mark the span as failed. The incoming trace
+// context is extracted by a TracePropagator; the default,
+// W3CTraceContext, parses the traceparent and tracestate headers into a
+// TraceParent that the tracer reads with TraceParentFromContext.
+//
+//	mw, err := muxhandlers.TracingMiddleware(muxhandlers.TracingConfig{
+//	    Tracer: otelTracer{otel.Tracer("api")},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	r.Use(mw)
+//
 // # Graceful Shutdown Middleware
 //
 // GracefulShutdownMiddleware intercepts new requests once Drain has
@@ -497,6 +633,25 @@
 //	    },
 //	}))
 //
+// MaintenanceMiddleware takes a MaintenanceMiddlewareConfig, which embeds
+// MaintenanceConfig. It validates the configuration and also returns a
+// MaintenanceSwitch whose Enable and Disable methods toggle maintenance
+// and are safe for concurrent use. Windows schedule maintenance for a
+// time interval, AllowPaths and AllowIPs keep health checks and operators
+// served, BypassHeader lets through requests carrying BypassSecret
+// (compared in constant time), and Body sets a JSON response body:
+//
+//	mw, maintenance, err := muxhandlers.MaintenanceMiddleware(r, muxhandlers.MaintenanceMiddlewareConfig{
+//	    Body:       map[string]string{"error": "maintenance"},
+//	    AllowPaths: []string{"/healthz"},
+//	    AllowIPs:   []string{"10.0.0.0/8"},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	r.Use(mw)
+//	maintenance.Enable()
+//
 // # No-Cache Middleware
 //
 // NoCacheMiddleware forces responses to be uncacheable. It rewrites
@@ -564,4 +719,45 @@
 //	    }))
 //	    pot.HandleFunc("/", potStatusHandler)
 //	})
+//
+// # WebSocket Handler
+//
+// WebSocketHandler upgrades a matched route to a WebSocket connection and
+// passes it to a callback, closing the connection when the callback
+// returns. When the upgrader has no CheckOrigin and the request passed
+// through CORSMiddleware, the Origin header is checked against the same
+// AllowedOrigins and AllowOriginFunc; disallowed origins receive 403
+// Forbidden.
+//
+//	r.Handle("/ws", muxhandlers.WebSocketHandler(websocket.Upgrader{},
+//	    func(conn *websocket.Conn) {
+//	        // read and write messages
+//	    },
+//	)).Methods(http.MethodGet)
+//	r.Use(corsMiddleware)
+//
+// # Well-Known Handler
+//
+// WellKnownHandler serves /.well-known/security.txt (RFC 9116),
+// /robots.txt (RFC 9309), and an optional /.well-known/change-password
+// redirect. security.txt is validated at construction (Contact and an
+// expiry are required, web URIs must use https), and ExpiresIn keeps the
+// Expires field a fixed horizon ahead of each request. robots.txt is
+// either literal content or structured groups of allow and disallow
+// rules. Responses carry a public Cache-Control header; Mount registers
+// the configured paths for GET and HEAD.
+//
+//	wk, err := muxhandlers.WellKnownHandler(muxhandlers.WellKnownConfig{
+//	    SecurityTxt: &muxhandlers.SecurityTxt{
+//	        Contact:   []string{"mailto:security@example.com"},
+//	        ExpiresIn: 180 * 24 * time.Hour,
+//	    },
+//	    RobotsTxt: &muxhandlers.RobotsTxt{
+//	        Groups: []muxhandlers.RobotsGroup{{Disallow: []string{"/admin"}}},
+//	    },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	wk.Mount(r)
 package muxhandlers
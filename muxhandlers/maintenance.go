@@ -1,13 +1,39 @@
 package muxhandlers
 
 import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/vitalvas/kasper/mux"
 )
 
+// ErrMaintenanceBypassSecret is returned by MaintenanceMiddleware when
+// MaintenanceMiddlewareConfig.BypassHeader is set without a BypassSecret.
+var ErrMaintenanceBypassSecret = errors.New("maintenance: BypassHeader requires a BypassSecret")
+
+// ErrMaintenanceInvalidIP is returned by MaintenanceMiddleware when an
+// AllowIPs entry is neither a valid IP address nor a valid CIDR range.
+var ErrMaintenanceInvalidIP = errors.New("maintenance: invalid AllowIPs entry")
+
+// ErrMaintenanceInvalidWindow is returned by MaintenanceMiddleware when a
+// window does not end after it starts.
+var ErrMaintenanceInvalidWindow = errors.New("maintenance: window must end after it starts")
+
+// MaintenanceWindow is a scheduled maintenance interval. Maintenance is
+// active from Start (inclusive) until End (exclusive).
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
 // MaintenanceConfig configures the MaintenanceMode middleware.
 type MaintenanceConfig struct {
 	// Enabled reports whether the maintenance response should be sent
@@ -52,6 +78,72 @@ type MaintenanceConfig struct {
 	RetryAt time.Time
 }
 
+// MaintenanceMiddlewareConfig configures MaintenanceMiddleware. The
+// embedded MaintenanceConfig fields apply as for MaintenanceModeMiddleware,
+// with two differences: a nil Enabled leaves maintenance to the
+// MaintenanceSwitch and Windows, and Bypass is consulted only for requests
+// that none of the allowlists below let through.
+type MaintenanceMiddlewareConfig struct {
+	MaintenanceConfig
+
+	// Body, when non-nil and Response is nil, is encoded as JSON and
+	// sent as the default response body with StatusCode instead of the
+	// plain-text status text.
+	Body any
+
+	// Windows lists scheduled maintenance intervals. Maintenance is
+	// active while the current time falls inside any of them, in
+	// addition to Enabled and the MaintenanceSwitch. When neither
+	// RetryAfter nor RetryAt is set, Retry-After carries the end of the
+	// active window.
+	Windows []MaintenanceWindow
+
+	// AllowPaths lists request paths that are served normally during
+	// maintenance, such as health checks. An entry ending in "/"
+	// matches every path below it; any other entry matches exactly.
+	AllowPaths []string
+
+	// AllowIPs lists IP addresses and CIDR ranges of operators that are
+	// served normally during maintenance. The client IP is taken from
+	// r.RemoteAddr; place ProxyHeadersMiddleware in front when the
+	// server runs behind a proxy.
+	AllowIPs []string
+
+	// BypassHeader and BypassSecret let a request through maintenance
+	// when the named header carries the shared secret. The comparison
+	// runs in constant time. BypassHeader requires BypassSecret.
+	BypassHeader string
+	BypassSecret string
+
+	// now overrides the clock source used to evaluate Windows; tests set
+	// it. Defaults to time.Now.
+	now func() time.Time
+}
+
+// MaintenanceSwitch is the control surface returned by
+// MaintenanceMiddleware. Enable and Disable toggle maintenance for every
+// request and are safe for concurrent use.
+type MaintenanceSwitch struct {
+	enabled atomic.Bool
+}
+
+// Enable turns maintenance mode on. Idempotent.
+func (s *MaintenanceSwitch) Enable() {
+	s.enabled.Store(true)
+}
+
+// Disable turns maintenance mode off. Requests may still be in
+// maintenance through MaintenanceConfig.Enabled or Windows. Idempotent.
+func (s *MaintenanceSwitch) Disable() {
+	s.enabled.Store(false)
+}
+
+// IsEnabled reports whether Enable has been called without a later
+// Disable.
+func (s *MaintenanceSwitch) IsEnabled() bool {
+	return s.enabled.Load()
+}
+
 // MaintenanceModeMiddleware short-circuits matching requests with a
 // "service unavailable" response while a maintenance window is active.
 // The Enabled predicate is the single source of truth; callers back it
@@ -67,6 +159,9 @@ type MaintenanceConfig struct {
 // The router is accepted so the Bypass predicate can resolve route
 // metadata. Pass the same *mux.Router the middleware is attached to
 // via Use.
+//
+// MaintenanceMiddleware adds a MaintenanceSwitch, scheduled windows,
+// allowlists, and a JSON body on top of this behavior.
 func MaintenanceModeMiddleware(router *mux.Router, cfg MaintenanceConfig) mux.MiddlewareFunc {
 	enabled := cfg.Enabled
 	bypass := cfg.Bypass
@@ -104,6 +199,176 @@ func MaintenanceModeMiddleware(router *mux.Router, cfg MaintenanceConfig) mux.Mi
 	}
 }
 
+// MaintenanceMiddleware returns a maintenance middleware and the
+// MaintenanceSwitch that toggles it. A request is in maintenance while
+// the switch is enabled, Enabled returns true (a checker that may read
+// shared state such as a Redis key), or the current time falls inside
+// one of the Windows.
+//
+// Requests in maintenance are still served normally when their path is
+// in AllowPaths, their client IP is in AllowIPs, they carry the
+// BypassHeader with the BypassSecret, or Bypass returns true. Every
+// other request gets the maintenance response described on
+// MaintenanceModeMiddleware, or the JSON-encoded Body when set.
+//
+//	mw, maintenance, err := muxhandlers.MaintenanceMiddleware(r, muxhandlers.MaintenanceMiddlewareConfig{
+//	    MaintenanceConfig: muxhandlers.MaintenanceConfig{
+//	        RetryAfter: 5 * time.Minute,
+//	    },
+//	    Body:         map[string]string{"error": "maintenance"},
+//	    AllowPaths:   []string{"/healthz"},
+//	    AllowIPs:     []string{"10.0.0.0/8"},
+//	    BypassHeader: "X-Maintenance-Bypass",
+//	    BypassSecret: secret,
+//	})
+//	r.Use(mw)
+//	maintenance.Enable()
+//
+// It returns an error wrapping ErrMaintenanceInvalidIP for an invalid
+// AllowIPs entry, ErrMaintenanceInvalidWindow for a window that does
+// not end after it starts, ErrMaintenanceBypassSecret for a
+// BypassHeader without a secret, or the encoding error for a Body that
+// cannot be marshaled to JSON.
+func MaintenanceMiddleware(router *mux.Router, cfg MaintenanceMiddlewareConfig) (mux.MiddlewareFunc, *MaintenanceSwitch, error) {
+	sw := &MaintenanceSwitch{}
+	enabled := cfg.Enabled
+	bypass := cfg.Bypass
+	response := cfg.Response
+
+	statusCode := cfg.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	retryAfterHeader := formatRetryAfter(cfg.RetryAfter, cfg.RetryAt)
+
+	var body []byte
+	if cfg.Body != nil {
+		data, err := json.Marshal(cfg.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("maintenance: encode body: %w", err)
+		}
+		body = data
+	}
+
+	var nets []*net.IPNet
+	for _, entry := range cfg.AllowIPs {
+		parsed, err := parseIPAllowNets([]string{entry})
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %q", ErrMaintenanceInvalidIP, entry)
+		}
+		nets = append(nets, parsed...)
+	}
+
+	windows := make([]MaintenanceWindow, 0, len(cfg.Windows))
+	for _, win := range cfg.Windows {
+		if !win.End.After(win.Start) {
+			return nil, nil, fmt.Errorf("%w: %s - %s", ErrMaintenanceInvalidWindow,
+				win.Start.Format(time.RFC3339), win.End.Format(time.RFC3339))
+		}
+		windows = append(windows, win)
+	}
+
+	bypassHeader := cfg.BypassHeader
+	bypassSecret := []byte(cfg.BypassSecret)
+	if bypassHeader != "" && len(bypassSecret) == 0 {
+		return nil, nil, ErrMaintenanceBypassSecret
+	}
+
+	allowPaths := cfg.AllowPaths
+
+	now := cfg.now
+	if now == nil {
+		now = time.Now
+	}
+
+	// activeWindow returns the window containing the current time.
+	activeWindow := func() (MaintenanceWindow, bool) {
+		if len(windows) == 0 {
+			return MaintenanceWindow{}, false
+		}
+		t := now()
+		for _, win := range windows {
+			if !t.Before(win.Start) && t.Before(win.End) {
+				return win, true
+			}
+		}
+		return MaintenanceWindow{}, false
+	}
+
+	allowed := func(r *http.Request) bool {
+		if isMaintenanceAllowedPath(r.URL.Path, allowPaths) {
+			return true
+		}
+		if len(nets) > 0 && isAllowedIP(r.RemoteAddr, nets) {
+			return true
+		}
+		if bypassHeader != "" {
+			got := r.Header.Get(bypassHeader)
+			if got != "" && subtle.ConstantTimeCompare([]byte(got), bypassSecret) == 1 {
+				return true
+			}
+		}
+		return bypass != nil && bypass(router, r)
+	}
+
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			retryAfter := retryAfterHeader
+			active := sw.IsEnabled() || (enabled != nil && enabled(r))
+			if !active {
+				win, ok := activeWindow()
+				active = ok
+				if ok && retryAfter == "" {
+					retryAfter = formatRetryAfter(0, win.End)
+				}
+			}
+			if !active || allowed(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+
+			if response != nil {
+				response.ServeHTTP(w, r)
+				return
+			}
+
+			if body != nil {
+				writeMaintenanceJSON(w, statusCode, body)
+				return
+			}
+
+			writeDefaultMaintenanceResponse(w, statusCode)
+		})
+	}
+	return mw, sw, nil
+}
+
+// isMaintenanceAllowedPath reports whether path matches an AllowPaths
+// entry: exactly, or by prefix for entries ending in "/".
+func isMaintenanceAllowedPath(path string, allowPaths []string) bool {
+	for _, p := range allowPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMaintenanceJSON writes the pre-encoded MaintenanceMiddlewareConfig.Body.
+func writeMaintenanceJSON(w http.ResponseWriter, statusCode int, body []byte) {
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
+
 // writeDefaultMaintenanceResponse writes a minimal plain-text 503-style
 // response. Used only when MaintenanceConfig.Response is nil.
 func writeDefaultMaintenanceResponse(w http.ResponseWriter, statusCode int) {
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vitalvas/kasper/mux"
 )
 
@@ -245,6 +247,210 @@ func TestMaintenanceModeMiddleware(t *testing.T) {
 	})
 }
 
+func TestMaintenanceMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Pass", "through")
+		w.WriteHeader(http.StatusOK)
+	})
+	newHandler := func(t *testing.T, cfg MaintenanceMiddlewareConfig) (http.Handler, *MaintenanceSwitch) {
+		t.Helper()
+		mw, sw, err := MaintenanceMiddleware(mux.NewRouter(), cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sw)
+		return mw(next), sw
+	}
+	serve := func(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Enable and Disable toggle maintenance", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{MaintenanceConfig: MaintenanceConfig{RetryAfter: time.Minute}})
+		assert.False(t, sw.IsEnabled())
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+
+		sw.Enable()
+		assert.True(t, sw.IsEnabled())
+		w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+		sw.Disable()
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+	})
+
+	t.Run("concurrent toggling", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{})
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					if i%2 == 0 {
+						sw.Enable()
+					} else {
+						sw.Disable()
+					}
+					serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Enabled acts as a per-request checker", func(t *testing.T) {
+		var flag atomic.Bool
+		h, _ := newHandler(t, MaintenanceMiddlewareConfig{
+			MaintenanceConfig: MaintenanceConfig{
+				Enabled: func(_ *http.Request) bool { return flag.Load() },
+			},
+		})
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+		flag.Store(true)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+	})
+
+	t.Run("JSON body", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{
+			Body: map[string]string{"error": "maintenance"},
+		})
+		sw.Enable()
+		w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"error":"maintenance"}`, w.Body.String())
+	})
+
+	t.Run("AllowPaths", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{AllowPaths: []string{"/healthz", "/internal/"}})
+		sw.Enable()
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/healthz", nil)).Code)
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/internal/status", nil)).Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, httptest.NewRequest(http.MethodGet, "/healthz/deep", nil)).Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, httptest.NewRequest(http.MethodGet, "/internal", nil)).Code)
+	})
+
+	t.Run("AllowIPs", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{AllowIPs: []string{"10.0.0.0/8", "2001:db8::1"}})
+		sw.Enable()
+		for addr, want := range map[string]int{
+			"10.1.2.3:1234":      http.StatusOK,
+			"[2001:db8::1]:443":  http.StatusOK,
+			"192.168.1.1:1234":   http.StatusServiceUnavailable,
+			"[2001:db8::2]:1234": http.StatusServiceUnavailable,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = addr
+			assert.Equal(t, want, serve(h, req).Code, addr)
+		}
+	})
+
+	t.Run("bypass header with shared secret", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{
+			BypassHeader: "X-Maintenance-Bypass",
+			BypassSecret: "s3cret",
+		})
+		sw.Enable()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Maintenance-Bypass", "s3cret")
+		assert.Equal(t, http.StatusOK, serve(h, req).Code)
+
+		for _, value := range []string{"", "wrong", "s3cret2", "s3cre"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if value != "" {
+				req.Header.Set("X-Maintenance-Bypass", value)
+			}
+			assert.Equal(t, http.StatusServiceUnavailable, serve(h, req).Code, value)
+		}
+	})
+
+	t.Run("Bypass predicate still applies", func(t *testing.T) {
+		h, sw := newHandler(t, MaintenanceMiddlewareConfig{
+			MaintenanceConfig: MaintenanceConfig{
+				Bypass: func(_ *mux.Router, r *http.Request) bool { return r.URL.Query().Has("admin") },
+			},
+		})
+		sw.Enable()
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/?admin", nil)).Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+	})
+
+	t.Run("scheduled window", func(t *testing.T) {
+		start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+		end := start.Add(time.Hour)
+		var now time.Time
+		h, _ := newHandler(t, MaintenanceMiddlewareConfig{
+			Windows: []MaintenanceWindow{{Start: start, End: end}},
+			now:     func() time.Time { return now },
+		})
+
+		now = start.Add(-time.Second)
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+
+		now = start
+		w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, end.Format(http.TimeFormat), w.Header().Get("Retry-After"))
+
+		now = end.Add(-time.Second)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+
+		now = end
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+	})
+
+	t.Run("configured Retry-After wins over window end", func(t *testing.T) {
+		start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+		h, _ := newHandler(t, MaintenanceMiddlewareConfig{
+			MaintenanceConfig: MaintenanceConfig{RetryAfter: 30 * time.Second},
+			Windows:           []MaintenanceWindow{{Start: start, End: start.Add(time.Hour)}},
+			now:               func() time.Time { return start.Add(time.Minute) },
+		})
+		w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+		for _, tc := range []struct {
+			name string
+			cfg  MaintenanceMiddlewareConfig
+			err  error
+		}{
+			{"invalid IP", MaintenanceMiddlewareConfig{AllowIPs: []string{"10.0.0.1", "not-an-ip"}}, ErrMaintenanceInvalidIP},
+			{"invalid CIDR", MaintenanceMiddlewareConfig{AllowIPs: []string{"10.0.0.0/33"}}, ErrMaintenanceInvalidIP},
+			{"empty window", MaintenanceMiddlewareConfig{Windows: []MaintenanceWindow{{Start: start, End: start}}}, ErrMaintenanceInvalidWindow},
+			{"bypass header without secret", MaintenanceMiddlewareConfig{BypassHeader: "X-Bypass"}, ErrMaintenanceBypassSecret},
+		} {
+			mw, sw, err := MaintenanceMiddleware(mux.NewRouter(), tc.cfg)
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			assert.Nil(t, mw, tc.name)
+			assert.Nil(t, sw, tc.name)
+		}
+
+		_, _, err := MaintenanceMiddleware(mux.NewRouter(), MaintenanceMiddlewareConfig{Body: make(chan int)})
+		assert.ErrorContains(t, err, "maintenance: encode body")
+	})
+
+	t.Run("MaintenanceModeMiddleware consults Bypass only when enabled", func(t *testing.T) {
+		var bypassCalls int
+		h := newMaintenanceHandler(MaintenanceConfig{
+			Enabled: func(_ *http.Request) bool { return false },
+			Bypass: func(_ *mux.Router, _ *http.Request) bool {
+				bypassCalls++
+				return false
+			},
+		})
+		assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+		assert.Zero(t, bypassCalls)
+	})
+}
+
 func TestFormatRetryAfter(t *testing.T) {
 	t.Run("zero values return empty", func(t *testing.T) {
 		assert.Empty(t, formatRetryAfter(0, time.Time{}))
@@ -1,8 +1,13 @@
 package muxhandlers
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/vitalvas/kasper/mux"
@@ -12,6 +17,27 @@ import (
 // or MethodOverrideConfig.OriginalMethods contains an invalid HTTP method.
 var ErrInvalidOverrideMethod = errors.New("method override: allowed methods must be valid HTTP methods")
 
+// ErrForbiddenOverrideMethod is returned when MethodOverrideConfig.AllowedMethods
+// contains CONNECT or TRACE, which must never be reachable through an override.
+var ErrForbiddenOverrideMethod = errors.New("method override: CONNECT and TRACE cannot be override targets")
+
+// ErrInvalidOverrideFormSize is returned when MethodOverrideConfig.MaxFormSize
+// is negative.
+var ErrInvalidOverrideFormSize = errors.New("method override: max form size must not be negative")
+
+type originalMethodKey struct{}
+
+// OriginalMethodFromContext returns the request method as received from the
+// client, stored in the context by MethodOverrideMiddleware when it replaced
+// the method. Returns an empty string if the method was not overridden.
+func OriginalMethodFromContext(ctx context.Context) string {
+	if m, ok := ctx.Value(originalMethodKey{}).(string); ok {
+		return m
+	}
+
+	return ""
+}
+
 // MethodOverrideConfig configures the Method Override middleware behaviour.
 type MethodOverrideConfig struct {
 	// HeaderNames is the list of header names checked in order.
@@ -24,9 +50,22 @@ type MethodOverrideConfig struct {
 	// When nil, defaults to [POST].
 	OriginalMethods []string
 
-	// AllowedMethods restricts which methods can be used as overrides.
-	// When nil, defaults to PUT, PATCH, DELETE, HEAD, OPTIONS.
+	// AllowedMethods restricts which methods can be used as override
+	// targets; overrides to any other method are ignored. CONNECT and
+	// TRACE are rejected. When nil, defaults to PUT, PATCH, DELETE.
 	AllowedMethods []string
+
+	// FormField is the name of an application/x-www-form-urlencoded body
+	// field, such as "_method", checked when no override header is
+	// present. The body is buffered to read the field and then restored,
+	// so downstream handlers can still read it in full. When empty, the
+	// body is never inspected.
+	FormField string
+
+	// MaxFormSize is the maximum number of body bytes buffered when
+	// looking for FormField. Larger bodies are passed through without
+	// override. When zero, defaults to 1 MiB.
+	MaxFormSize int64
 }
 
 // defaultOverrideHeaders is the default set of header names checked for
@@ -47,19 +86,26 @@ var defaultOverrideMethods = []string{
 	http.MethodPut,
 	http.MethodPatch,
 	http.MethodDelete,
-	http.MethodHead,
-	http.MethodOptions,
 }
 
+// defaultOverrideFormSize is the body buffering limit used when
+// MaxFormSize is zero.
+const defaultOverrideFormSize = 1 << 20
+
 // MethodOverrideMiddleware returns a middleware that allows clients to override
 // the HTTP method via a configurable header. The first non-empty header value
 // from HeaderNames is uppercased and checked against the allowed set. When
 // allowed, r.Method is set to the override value and the header is removed.
-// Override is only applied when the original request method is in
-// OriginalMethods (defaults to POST).
+// When no header is present and FormField is set, the field is read from an
+// application/x-www-form-urlencoded body without consuming it. Override is
+// only applied when the original request method is in OriginalMethods
+// (defaults to POST). The original method is available to downstream
+// handlers through OriginalMethodFromContext.
 //
 // It returns ErrInvalidOverrideMethod if AllowedMethods or OriginalMethods
-// contains an invalid method.
+// contains an invalid method, ErrForbiddenOverrideMethod if AllowedMethods
+// contains CONNECT or TRACE, and ErrInvalidOverrideFormSize if MaxFormSize
+// is negative.
 func MethodOverrideMiddleware(cfg MethodOverrideConfig) (mux.MiddlewareFunc, error) {
 	headers := cfg.HeaderNames
 	if len(headers) == 0 {
@@ -86,6 +132,19 @@ func MethodOverrideMiddleware(cfg MethodOverrideConfig) (mux.MiddlewareFunc, err
 		if m == "" || m != strings.ToUpper(m) {
 			return nil, ErrInvalidOverrideMethod
 		}
+		if m == http.MethodConnect || m == http.MethodTrace {
+			return nil, ErrForbiddenOverrideMethod
+		}
+	}
+
+	if cfg.MaxFormSize < 0 {
+		return nil, ErrInvalidOverrideFormSize
+	}
+
+	formField := cfg.FormField
+	maxFormSize := cfg.MaxFormSize
+	if maxFormSize == 0 {
+		maxFormSize = defaultOverrideFormSize
 	}
 
 	headerNames := make([]string, len(headers))
@@ -103,21 +162,79 @@ func MethodOverrideMiddleware(cfg MethodOverrideConfig) (mux.MiddlewareFunc, err
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if _, ok := originalSet[r.Method]; ok {
-				for _, h := range headerNames {
-					if v := r.Header.Get(h); v != "" {
-						override := strings.ToUpper(v)
-						if _, ok := allowed[override]; ok {
-							r.Method = override
-							r.Header.Del(h)
-						}
-
-						break
+			if _, ok := originalSet[r.Method]; !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var override string
+			found := false
+			for _, h := range headerNames {
+				if v := r.Header.Get(h); v != "" {
+					override = strings.ToUpper(v)
+					if _, ok := allowed[override]; ok {
+						r.Header.Del(h)
 					}
+					found = true
+
+					break
 				}
 			}
 
+			if !found && formField != "" && isURLEncodedForm(r) {
+				override = strings.ToUpper(peekFormField(r, formField, maxFormSize))
+			}
+
+			if _, ok := allowed[override]; ok {
+				r = r.WithContext(context.WithValue(r.Context(), originalMethodKey{}, r.Method))
+				r.Method = override
+			}
+
 			next.ServeHTTP(w, r)
 		})
 	}, nil
 }
+
+// isURLEncodedForm reports whether the request body is declared as
+// application/x-www-form-urlencoded.
+func isURLEncodedForm(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// peekFormField reads up to maxSize bytes of the request body, returns the
+// value of field, and restores r.Body so that it yields the full original
+// body. An empty string is returned when the body is larger than maxSize,
+// cannot be read, or is not a valid form.
+func peekFormField(r *http.Request, field string, maxSize int64) string {
+	body := r.Body
+	buf, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+
+	r.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(buf), body),
+		Closer: body,
+	}
+
+	if err != nil || int64(len(buf)) > maxSize {
+		return ""
+	}
+
+	values, err := url.ParseQuery(string(buf))
+	if err != nil {
+		return ""
+	}
+
+	return values.Get(field)
+}
+
+// peekedBody replays the buffered prefix of a request body followed by the
+// unread remainder, and closes the original body.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
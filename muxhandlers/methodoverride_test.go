@@ -1,8 +1,11 @@
 package muxhandlers
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,6 +55,27 @@ func TestMethodOverrideMiddleware(t *testing.T) {
 				},
 				ErrInvalidOverrideMethod,
 			},
+			{
+				"CONNECT in allowed methods",
+				MethodOverrideConfig{
+					AllowedMethods: []string{http.MethodPut, http.MethodConnect},
+				},
+				ErrForbiddenOverrideMethod,
+			},
+			{
+				"TRACE in allowed methods",
+				MethodOverrideConfig{
+					AllowedMethods: []string{http.MethodTrace},
+				},
+				ErrForbiddenOverrideMethod,
+			},
+			{
+				"negative max form size",
+				MethodOverrideConfig{
+					MaxFormSize: -1,
+				},
+				ErrInvalidOverrideFormSize,
+			},
 		}
 
 		for _, tt := range tests {
@@ -319,6 +343,180 @@ func TestMethodOverrideMiddleware(t *testing.T) {
 		assert.Equal(t, http.MethodPatch, gotMethod)
 	})
 
+	t.Run("default allowed methods exclude HEAD OPTIONS TRACE CONNECT", func(t *testing.T) {
+		mw, err := MethodOverrideMiddleware(MethodOverrideConfig{})
+		require.NoError(t, err)
+
+		for _, method := range []string{http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodConnect, http.MethodGet} {
+			var gotMethod string
+			handler := mw(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				gotMethod = req.Method
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			req.Header.Set("X-HTTP-Method-Override", method)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, http.MethodPost, gotMethod, method)
+		}
+	})
+
+	t.Run("original method stored in context", func(t *testing.T) {
+		mw, err := MethodOverrideMiddleware(MethodOverrideConfig{})
+		require.NoError(t, err)
+
+		var original string
+		handler := mw(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			original = OriginalMethodFromContext(req.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/test", nil)
+		req.Header.Set("X-HTTP-Method-Override", "PATCH")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, http.MethodPost, original)
+
+		req = httptest.NewRequest(http.MethodPost, "/test", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Empty(t, original)
+	})
+
+	t.Run("OriginalMethodFromContext empty context", func(t *testing.T) {
+		assert.Empty(t, OriginalMethodFromContext(context.Background()))
+	})
+
+	t.Run("form field", func(t *testing.T) {
+		newHandler := func(t *testing.T, cfg MethodOverrideConfig) (http.Handler, *string, *string) {
+			t.Helper()
+			mw, err := MethodOverrideMiddleware(cfg)
+			require.NoError(t, err)
+
+			var gotMethod, gotBody string
+			return mw(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				gotMethod = req.Method
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				gotBody = string(body)
+			})), &gotMethod, &gotBody
+		}
+
+		formRequest := func(body string) *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+			return req
+		}
+
+		t.Run("overrides and keeps body readable", func(t *testing.T) {
+			handler, gotMethod, gotBody := newHandler(t, MethodOverrideConfig{FormField: "_method"})
+
+			body := "name=alice&_method=delete"
+			handler.ServeHTTP(httptest.NewRecorder(), formRequest(body))
+
+			assert.Equal(t, http.MethodDelete, *gotMethod)
+			assert.Equal(t, body, *gotBody)
+		})
+
+		t.Run("downstream ParseForm sees all fields", func(t *testing.T) {
+			mw, err := MethodOverrideMiddleware(MethodOverrideConfig{FormField: "_method"})
+			require.NoError(t, err)
+
+			var name string
+			handler := mw(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				name = req.PostFormValue("name")
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), formRequest("name=alice&_method=PUT"))
+
+			assert.Equal(t, "alice", name)
+		})
+
+		t.Run("disabled by default", func(t *testing.T) {
+			handler, gotMethod, gotBody := newHandler(t, MethodOverrideConfig{})
+
+			handler.ServeHTTP(httptest.NewRecorder(), formRequest("_method=PUT"))
+
+			assert.Equal(t, http.MethodPost, *gotMethod)
+			assert.Equal(t, "_method=PUT", *gotBody)
+		})
+
+		t.Run("header takes precedence", func(t *testing.T) {
+			handler, gotMethod, _ := newHandler(t, MethodOverrideConfig{FormField: "_method"})
+
+			req := formRequest("_method=DELETE")
+			req.Header.Set("X-HTTP-Method-Override", "PATCH")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, http.MethodPatch, *gotMethod)
+		})
+
+		t.Run("ignored for other content types", func(t *testing.T) {
+			handler, gotMethod, gotBody := newHandler(t, MethodOverrideConfig{FormField: "_method"})
+
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("_method=PUT"))
+			req.Header.Set("Content-Type", "text/plain")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, http.MethodPost, *gotMethod)
+			assert.Equal(t, "_method=PUT", *gotBody)
+		})
+
+		t.Run("ignored for non-eligible methods", func(t *testing.T) {
+			handler, gotMethod, _ := newHandler(t, MethodOverrideConfig{FormField: "_method"})
+
+			req := formRequest("_method=DELETE")
+			req.Method = http.MethodPut
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, http.MethodPut, *gotMethod)
+		})
+
+		t.Run("disallowed target ignored", func(t *testing.T) {
+			handler, gotMethod, _ := newHandler(t, MethodOverrideConfig{FormField: "_method"})
+
+			handler.ServeHTTP(httptest.NewRecorder(), formRequest("_method=TRACE"))
+
+			assert.Equal(t, http.MethodPost, *gotMethod)
+		})
+
+		t.Run("body over limit passes through intact", func(t *testing.T) {
+			handler, gotMethod, gotBody := newHandler(t, MethodOverrideConfig{
+				FormField:   "_method",
+				MaxFormSize: 16,
+			})
+
+			body := "_method=DELETE&data=" + strings.Repeat("x", 64)
+			handler.ServeHTTP(httptest.NewRecorder(), formRequest(body))
+
+			assert.Equal(t, http.MethodPost, *gotMethod)
+			assert.Equal(t, body, *gotBody)
+		})
+
+		t.Run("malformed form passes through intact", func(t *testing.T) {
+			handler, gotMethod, gotBody := newHandler(t, MethodOverrideConfig{FormField: "_method"})
+
+			body := "_method=DELETE&bad=%zz"
+			handler.ServeHTTP(httptest.NewRecorder(), formRequest(body))
+
+			assert.Equal(t, http.MethodPost, *gotMethod)
+			assert.Equal(t, body, *gotBody)
+		})
+
+		t.Run("closing body closes original", func(t *testing.T) {
+			mw, err := MethodOverrideMiddleware(MethodOverrideConfig{FormField: "_method"})
+			require.NoError(t, err)
+
+			orig := &closeRecorder{Reader: strings.NewReader("_method=PUT")}
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			req.Body = orig
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			handler := mw(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				assert.NoError(t, req.Body.Close())
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.True(t, orig.closed)
+		})
+	})
+
 	t.Run("POST without override header passes through", func(t *testing.T) {
 		var gotMethod string
 
@@ -342,6 +540,16 @@ func TestMethodOverrideMiddleware(t *testing.T) {
 	})
 }
 
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
 func BenchmarkMethodOverrideMiddleware(b *testing.B) {
 	b.Run("with override", func(b *testing.B) {
 		r := mux.NewRouter()
@@ -1,6 +1,7 @@
 package muxhandlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/vitalvas/kasper/mux"
@@ -11,12 +12,43 @@ type RecoveryConfig struct {
 	// LogFunc is an optional callback invoked with the request and the
 	// recovered value when a panic occurs. When nil, no logging is performed.
 	LogFunc func(r *http.Request, err any)
+
+	// ExposePanic includes the recovered value, formatted with fmt.Sprint,
+	// as the detail member of the problem+json body written by
+	// RecoverJSONMiddleware. Panic values often reveal internals, so keep
+	// it disabled outside development. RecoveryMiddleware ignores it.
+	ExposePanic bool
 }
 
 // RecoveryMiddleware returns a middleware that recovers from panics in
 // downstream handlers. When a panic occurs it returns 500 Internal Server
 // Error to the client and optionally invokes LogFunc.
 func RecoveryMiddleware(cfg RecoveryConfig) mux.MiddlewareFunc {
+	return recoveryMiddleware(cfg, func(w http.ResponseWriter, _ *http.Request, _ any) {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	})
+}
+
+// RecoverJSONMiddleware is like RecoveryMiddleware but answers a panic
+// with an RFC 9457 Problem Details body of type application/problem+json
+// and status 500. The request ID set by RequestIDMiddleware, if any, is
+// reported as the instance member so clients can quote it. The panic value
+// is passed to LogFunc but is not sent to the client unless ExposePanic is
+// set.
+func RecoverJSONMiddleware(cfg RecoveryConfig) mux.MiddlewareFunc {
+	return recoveryMiddleware(cfg, func(w http.ResponseWriter, r *http.Request, err any) {
+		problem := NewProblemDetails(http.StatusInternalServerError)
+		problem.Instance = RequestIDFromContext(r.Context())
+		if cfg.ExposePanic {
+			problem.Detail = fmt.Sprint(err)
+		}
+		WriteProblemDetails(w, problem)
+	})
+}
+
+// recoveryMiddleware recovers from panics in downstream handlers, invokes
+// LogFunc, and writes the response with respond.
+func recoveryMiddleware(cfg RecoveryConfig, respond func(w http.ResponseWriter, r *http.Request, err any)) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
@@ -25,7 +57,7 @@ func RecoveryMiddleware(cfg RecoveryConfig) mux.MiddlewareFunc {
 						cfg.LogFunc(r, err)
 					}
 
-					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					respond(w, r, err)
 				}
 			}()
 
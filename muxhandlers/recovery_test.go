@@ -1,6 +1,7 @@
 package muxhandlers
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -114,6 +115,84 @@ func TestRecoveryMiddleware(t *testing.T) {
 	})
 }
 
+func TestRecoverJSONMiddleware(t *testing.T) {
+	newRouter := func(cfg RecoveryConfig, mws ...mux.MiddlewareFunc) *mux.Router {
+		r := mux.NewRouter()
+		r.HandleFunc("/test", func(_ http.ResponseWriter, _ *http.Request) {
+			panic("db password is hunter2")
+		}).Methods(http.MethodGet)
+		r.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodGet)
+		for _, mw := range mws {
+			r.Use(mw)
+		}
+		r.Use(RecoverJSONMiddleware(cfg))
+		return r
+	}
+
+	t.Run("writes problem json without panic value", func(t *testing.T) {
+		var loggedValue any
+		r := newRouter(RecoveryConfig{
+			LogFunc: func(_ *http.Request, err any) {
+				loggedValue = err
+			},
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, mux.ContentTypeApplicationProblemJSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, "db password is hunter2", loggedValue)
+		assert.NotContains(t, w.Body.String(), "hunter2")
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, map[string]any{
+			"type":   "about:blank",
+			"title":  http.StatusText(http.StatusInternalServerError),
+			"status": float64(http.StatusInternalServerError),
+		}, body)
+	})
+
+	t.Run("request id as instance", func(t *testing.T) {
+		r := newRouter(RecoveryConfig{}, RequestIDMiddleware(RequestIDConfig{TrustIncoming: true}))
+
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("X-Request-ID", "req-123")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body ProblemDetails
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "req-123", body.Instance)
+		assert.Equal(t, http.StatusInternalServerError, body.Status)
+	})
+
+	t.Run("expose panic", func(t *testing.T) {
+		r := newRouter(RecoveryConfig{ExposePanic: true})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		var body ProblemDetails
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "db password is hunter2", body.Detail)
+	})
+
+	t.Run("no panic passes through", func(t *testing.T) {
+		logCalled := false
+		r := newRouter(RecoveryConfig{LogFunc: func(*http.Request, any) { logCalled = true }})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.False(t, logCalled)
+	})
+}
+
 func BenchmarkRecoveryMiddleware(b *testing.B) {
 	b.Run("no panic", func(b *testing.B) {
 		r := mux.NewRouter()
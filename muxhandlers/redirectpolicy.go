@@ -0,0 +1,247 @@
+package muxhandlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// ErrRedirectPolicyEmpty is returned when RedirectPolicyConfig enables no
+// redirect.
+var ErrRedirectPolicyEmpty = errors.New("redirect policy: no redirect enabled")
+
+// ErrRedirectPolicyInvalidHost is returned when
+// RedirectPolicyConfig.CanonicalHost is not a bare host or host:port.
+var ErrRedirectPolicyInvalidHost = errors.New("redirect policy: canonical host must be a host or host:port")
+
+// TrailingSlashPolicy selects how RedirectPolicyMiddleware treats a
+// trailing slash on the request path.
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashIgnore leaves the trailing slash as it is.
+	TrailingSlashIgnore TrailingSlashPolicy = iota
+
+	// TrailingSlashAdd redirects paths without a trailing slash to the
+	// same path with one.
+	TrailingSlashAdd
+
+	// TrailingSlashRemove redirects paths with a trailing slash to the
+	// same path without it. The root path "/" is never changed.
+	TrailingSlashRemove
+)
+
+// RedirectPolicyConfig configures the RedirectPolicy middleware.
+type RedirectPolicyConfig struct {
+	// ForceHTTPS redirects plain HTTP requests to HTTPS. The original
+	// scheme is r.URL.Scheme when set (for example by
+	// ProxyHeadersMiddleware), then X-Forwarded-Proto,
+	// X-Forwarded-Scheme, or, with EnableForwarded, the RFC 7239
+	// Forwarded proto= directive from a trusted proxy, then r.TLS.
+	ForceHTTPS bool
+
+	// TrustedProxies is the list of IP addresses and CIDR ranges whose
+	// forwarding headers are honoured when detecting the original
+	// scheme. When empty, DefaultTrustedProxies is used.
+	TrustedProxies []string
+
+	// EnableForwarded also reads the scheme from the RFC 7239 Forwarded
+	// header, after X-Forwarded-Proto and X-Forwarded-Scheme.
+	EnableForwarded bool
+
+	// CanonicalHost is the host (with an optional port) that requests
+	// are redirected to when r.Host differs, compared case-insensitively.
+	// Empty disables the host redirect.
+	CanonicalHost string
+
+	// LowercasePath redirects paths containing upper-case letters to
+	// their lower-case form.
+	LowercasePath bool
+
+	// TrailingSlash selects the trailing slash policy. Defaults to
+	// TrailingSlashIgnore.
+	TrailingSlash TrailingSlashPolicy
+
+	// PermanentRedirects selects 308 Permanent Redirect instead of the
+	// default 307 Temporary Redirect.
+	PermanentRedirects bool
+
+	// ExemptPaths lists paths that are never redirected, such as health
+	// checks and probes. A trailing "*" matches any path with the
+	// preceding prefix, as in RedirectRule.From.
+	ExemptPaths []string
+
+	// Methods lists the request methods that are redirected. Requests
+	// with other methods pass through unchanged. Defaults to GET and
+	// HEAD.
+	Methods []string
+}
+
+// RedirectPolicyMiddleware returns a middleware that enforces a URL policy
+// with at most one redirect per request. The rules are applied in this
+// order, and all rewrites are combined into a single Location:
+//
+//  1. ForceHTTPS switches the scheme to https, dropping an explicit :80
+//     port from the host.
+//  2. CanonicalHost replaces the host.
+//  3. LowercasePath lower-cases the path.
+//  4. TrailingSlash adds or removes the trailing slash.
+//
+// The query string is preserved. When the scheme or host changes the
+// Location is an absolute URL, otherwise it is the rewritten path and
+// query. Requests to ExemptPaths and with methods outside Methods are
+// never redirected.
+//
+// It returns ErrRedirectPolicyEmpty when no rule is enabled,
+// ErrRedirectPolicyInvalidHost for a malformed CanonicalHost, and an
+// error wrapping ErrInvalidProxy for unparseable TrustedProxies entries.
+func RedirectPolicyMiddleware(cfg RedirectPolicyConfig) (mux.MiddlewareFunc, error) {
+	if !cfg.ForceHTTPS && cfg.CanonicalHost == "" && !cfg.LowercasePath && cfg.TrailingSlash == TrailingSlashIgnore {
+		return nil, ErrRedirectPolicyEmpty
+	}
+
+	canonicalHost := strings.ToLower(cfg.CanonicalHost)
+	if strings.ContainsAny(canonicalHost, "/?#@ ") {
+		return nil, ErrRedirectPolicyInvalidHost
+	}
+
+	var ts *proxyTrustSet
+	if cfg.ForceHTTPS {
+		proxies := cfg.TrustedProxies
+		if len(proxies) == 0 {
+			proxies = DefaultTrustedProxies
+		}
+
+		var err error
+		ts, err = parseTrustedProxies(proxies)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	methods := cfg.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead}
+	}
+
+	statusCode := http.StatusTemporaryRedirect
+	if cfg.PermanentRedirects {
+		statusCode = http.StatusPermanentRedirect
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(methods, r.Method) || isExemptPath(cfg.ExemptPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme := redirectPolicyScheme(r, ts, cfg.EnableForwarded)
+			host := r.Host
+			absolute := false
+
+			if cfg.ForceHTTPS && scheme != "https" {
+				scheme = "https"
+				host = strings.TrimSuffix(host, ":80")
+				absolute = true
+			}
+
+			if canonicalHost != "" && !strings.EqualFold(host, canonicalHost) {
+				host = canonicalHost
+				absolute = true
+			}
+
+			u := *r.URL
+			changed := absolute
+
+			if cfg.LowercasePath {
+				if lower := strings.ToLower(u.Path); lower != u.Path {
+					u.Path = lower
+					u.RawPath = strings.ToLower(u.RawPath)
+					changed = true
+				}
+			}
+
+			switch cfg.TrailingSlash {
+			case TrailingSlashAdd:
+				if !strings.HasSuffix(u.Path, "/") {
+					u.Path += "/"
+					if u.RawPath != "" {
+						u.RawPath += "/"
+					}
+					changed = true
+				}
+			case TrailingSlashRemove:
+				if u.Path != "/" && strings.HasSuffix(u.Path, "/") {
+					u.Path = strings.TrimRight(u.Path, "/")
+					u.RawPath = strings.TrimRight(u.RawPath, "/")
+					if u.Path == "" {
+						u.Path = "/"
+						u.RawPath = ""
+					}
+					changed = true
+				}
+			}
+
+			if !changed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			location := u.RequestURI()
+			if absolute {
+				u.Scheme = scheme
+				u.Host = host
+				location = u.String()
+			}
+
+			http.Redirect(w, r, location, statusCode)
+		})
+	}, nil
+}
+
+// redirectPolicyScheme returns the scheme the client used for r. It
+// prefers r.URL.Scheme, then the forwarding headers of a trusted proxy,
+// then r.TLS.
+func redirectPolicyScheme(r *http.Request, ts *proxyTrustSet, enableForwarded bool) string {
+	if r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+
+	if ts != nil && isTrustedPeer(r.RemoteAddr, ts) {
+		if scheme := proxyScheme(r); scheme != "" {
+			return scheme
+		}
+
+		if enableForwarded {
+			if fwd := parseForwarded(r.Header.Get("Forwarded")); fwd.proto != "" {
+				return fwd.proto
+			}
+		}
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// isExemptPath reports whether path matches one of the exempt patterns.
+// A trailing "*" in a pattern matches any path with that prefix.
+func isExemptPath(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+
+	return false
+}
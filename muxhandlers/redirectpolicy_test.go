@@ -0,0 +1,197 @@
+package muxhandlers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectPolicyMiddleware(t *testing.T) {
+	serve := func(t *testing.T, cfg RedirectPolicyConfig, req *http.Request) *httptest.ResponseRecorder {
+		t.Helper()
+
+		mw, err := RedirectPolicyMiddleware(cfg)
+		require.NoError(t, err)
+
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("config errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config RedirectPolicyConfig
+			err    error
+		}{
+			{"nothing enabled", RedirectPolicyConfig{PermanentRedirects: true}, ErrRedirectPolicyEmpty},
+			{"host with scheme", RedirectPolicyConfig{CanonicalHost: "https://example.com"}, ErrRedirectPolicyInvalidHost},
+			{"host with path", RedirectPolicyConfig{CanonicalHost: "example.com/app"}, ErrRedirectPolicyInvalidHost},
+			{"invalid proxy", RedirectPolicyConfig{ForceHTTPS: true, TrustedProxies: []string{"bad"}}, ErrInvalidProxy},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := RedirectPolicyMiddleware(tt.config)
+				assert.ErrorIs(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("force https", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com:80/docs?page=2", nil)
+		req.Host = "example.com:80"
+
+		rec := serve(t, RedirectPolicyConfig{ForceHTTPS: true}, req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "https://example.com/docs?page=2", rec.Header().Get("Location"))
+	})
+
+	t.Run("tls request passes through", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.TLS = &tls.ConnectionState{}
+
+		rec := serve(t, RedirectPolicyConfig{ForceHTTPS: true}, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("forwarded proto from trusted proxy", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-Proto", "https")
+
+		rec := serve(t, RedirectPolicyConfig{ForceHTTPS: true}, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("forwarded proto from untrusted peer is ignored", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.RemoteAddr = "203.0.113.5:1234"
+		req.Header.Set("X-Forwarded-Proto", "https")
+
+		rec := serve(t, RedirectPolicyConfig{ForceHTTPS: true}, req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "https://example.com/docs", rec.Header().Get("Location"))
+	})
+
+	t.Run("rfc 7239 forwarded proto", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Forwarded", "for=192.0.2.1;proto=https")
+
+		rec := serve(t, RedirectPolicyConfig{ForceHTTPS: true, EnableForwarded: true}, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(t, RedirectPolicyConfig{ForceHTTPS: true}, req)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+
+	t.Run("canonical host", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/about", nil)
+		req.Host = "www.example.com"
+
+		rec := serve(t, RedirectPolicyConfig{CanonicalHost: "example.com"}, req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "http://example.com/about", rec.Header().Get("Location"))
+	})
+
+	t.Run("canonical host matches case-insensitively", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/about", nil)
+		req.Host = "Example.COM"
+
+		rec := serve(t, RedirectPolicyConfig{CanonicalHost: "example.com"}, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("lowercase path is relative", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/Docs/Intro?Q=A", nil)
+
+		rec := serve(t, RedirectPolicyConfig{LowercasePath: true}, req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "/docs/intro?Q=A", rec.Header().Get("Location"))
+	})
+
+	t.Run("trailing slash", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			policy   TrailingSlashPolicy
+			target   string
+			code     int
+			location string
+		}{
+			{"add", TrailingSlashAdd, "/docs", http.StatusTemporaryRedirect, "/docs/"},
+			{"add keeps existing", TrailingSlashAdd, "/docs/", http.StatusOK, ""},
+			{"remove", TrailingSlashRemove, "/docs/", http.StatusTemporaryRedirect, "/docs"},
+			{"remove keeps root", TrailingSlashRemove, "/", http.StatusOK, ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+
+				rec := serve(t, RedirectPolicyConfig{TrailingSlash: tt.policy}, req)
+
+				assert.Equal(t, tt.code, rec.Code)
+				assert.Equal(t, tt.location, rec.Header().Get("Location"))
+			})
+		}
+	})
+
+	t.Run("all rewrites combined into one redirect", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/Docs/?x=1", nil)
+		req.Host = "www.example.com"
+
+		rec := serve(t, RedirectPolicyConfig{
+			ForceHTTPS:         true,
+			CanonicalHost:      "example.com",
+			LowercasePath:      true,
+			TrailingSlash:      TrailingSlashRemove,
+			PermanentRedirects: true,
+		}, req)
+
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, "https://example.com/docs?x=1", rec.Header().Get("Location"))
+	})
+
+	t.Run("exempt paths", func(t *testing.T) {
+		cfg := RedirectPolicyConfig{
+			ForceHTTPS:  true,
+			ExemptPaths: []string{"/healthz", "/probes/*"},
+		}
+
+		for _, target := range []string{"/healthz", "/probes/ready"} {
+			rec := serve(t, cfg, httptest.NewRequest(http.MethodGet, target, nil))
+			assert.Equal(t, http.StatusOK, rec.Code, target)
+		}
+
+		rec := serve(t, cfg, httptest.NewRequest(http.MethodGet, "/healthz/extra", nil))
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		rec := serve(t, RedirectPolicyConfig{ForceHTTPS: true}, httptest.NewRequest(http.MethodPost, "/docs", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(t, RedirectPolicyConfig{ForceHTTPS: true}, httptest.NewRequest(http.MethodHead, "/docs", nil))
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+
+		rec = serve(t, RedirectPolicyConfig{ForceHTTPS: true, Methods: []string{http.MethodPost}}, httptest.NewRequest(http.MethodPost, "/docs", nil))
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+}
@@ -8,16 +8,14 @@ import (
 	"github.com/vitalvas/kasper/mux"
 )
 
-type requestIDKey struct{}
+// requestIDKey holds the request ID set by RequestIDMiddleware.
+var requestIDKey = mux.NewContextKey[string]("request-id")
 
 // RequestIDFromContext returns the request ID stored in the context by
 // RequestIDMiddleware. Returns an empty string if no ID is present.
 func RequestIDFromContext(ctx context.Context) string {
-	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
-		return id
-	}
-
-	return ""
+	id, _ := requestIDKey.FromContext(ctx)
+	return id
 }
 
 // RequestIDConfig configures the Request ID middleware behaviour.
@@ -66,7 +64,7 @@ func RequestIDMiddleware(cfg RequestIDConfig) mux.MiddlewareFunc {
 			if id != "" {
 				r.Header.Set(headerName, id)
 				w.Header().Set(headerName, id)
-				r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+				r = requestIDKey.WithValue(r, id)
 			}
 
 			next.ServeHTTP(w, r)
@@ -0,0 +1,119 @@
+package muxhandlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// ErrNoRequiredHeaders is returned when RequireHeadersConfig.Headers is
+// empty.
+var ErrNoRequiredHeaders = errors.New("require headers: at least one header is required")
+
+// ErrRequiredHeaderEmptyName is returned when a RequiredHeader has an empty
+// Name.
+var ErrRequiredHeaderEmptyName = errors.New("require headers: header name must not be empty")
+
+// ErrRequiredHeaderInvalidPattern is returned when a RequiredHeader.Pattern
+// is not a valid regular expression.
+var ErrRequiredHeaderInvalidPattern = errors.New("require headers: invalid pattern")
+
+// RequiredHeader describes a request header that must be present.
+type RequiredHeader struct {
+	// Name is the header name. Matching is case-insensitive. Required.
+	Name string
+
+	// Pattern is an optional regular expression the header value must
+	// match in full. When empty, any non-empty value is accepted.
+	Pattern string
+}
+
+// RequireHeadersConfig configures the Require Headers middleware.
+type RequireHeadersConfig struct {
+	// Headers is the list of headers every request must carry.
+	// Required; at least one must be provided.
+	Headers []RequiredHeader
+}
+
+type compiledRequiredHeader struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// RequireHeadersMiddleware returns a middleware that rejects requests
+// missing any of the configured headers, or carrying a value that does not
+// match the header's Pattern, before the handler runs. Rejected requests
+// get 400 Bad Request with an RFC 9457 problem+json body whose "missing"
+// and "invalid" members list the offending header names.
+//
+// Apply it to a subrouter or a single route to scope the requirement to
+// the endpoints that need it.
+//
+// It returns ErrNoRequiredHeaders if Headers is empty,
+// ErrRequiredHeaderEmptyName for a header without a name, and an error
+// wrapping ErrRequiredHeaderInvalidPattern for a pattern that does not
+// compile.
+func RequireHeadersMiddleware(cfg RequireHeadersConfig) (mux.MiddlewareFunc, error) {
+	if len(cfg.Headers) == 0 {
+		return nil, ErrNoRequiredHeaders
+	}
+
+	headers := make([]compiledRequiredHeader, len(cfg.Headers))
+	for i, h := range cfg.Headers {
+		name := strings.TrimSpace(h.Name)
+		if name == "" {
+			return nil, ErrRequiredHeaderEmptyName
+		}
+
+		headers[i].name = http.CanonicalHeaderKey(name)
+
+		if h.Pattern != "" {
+			re, err := regexp.Compile("^(?:" + h.Pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("%w for %s: %w", ErrRequiredHeaderInvalidPattern, name, err)
+			}
+
+			headers[i].pattern = re
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var missing, invalid []string
+
+			for _, h := range headers {
+				value := r.Header.Get(h.name)
+				switch {
+				case value == "":
+					missing = append(missing, h.name)
+				case h.pattern != nil && !h.pattern.MatchString(value):
+					invalid = append(invalid, h.name)
+				}
+			}
+
+			if len(missing) == 0 && len(invalid) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			problem := NewProblemDetails(http.StatusBadRequest)
+			problem.Detail = "missing or invalid request headers: " + strings.Join(slices.Concat(missing, invalid), ", ")
+			problem.Extensions = make(map[string]any, 2)
+
+			if len(missing) > 0 {
+				problem.Extensions["missing"] = missing
+			}
+
+			if len(invalid) > 0 {
+				problem.Extensions["invalid"] = invalid
+			}
+
+			WriteProblemDetails(w, problem)
+		})
+	}, nil
+}
@@ -0,0 +1,129 @@
+package muxhandlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+func TestRequireHeadersMiddleware(t *testing.T) {
+	t.Run("config errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config RequireHeadersConfig
+			err    error
+		}{
+			{"no headers", RequireHeadersConfig{}, ErrNoRequiredHeaders},
+			{"empty name", RequireHeadersConfig{Headers: []RequiredHeader{{Name: " "}}}, ErrRequiredHeaderEmptyName},
+			{"invalid pattern", RequireHeadersConfig{Headers: []RequiredHeader{{Name: "X-Version", Pattern: "["}}}, ErrRequiredHeaderInvalidPattern},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := RequireHeadersMiddleware(tt.config)
+				assert.ErrorIs(t, err, tt.err)
+			})
+		}
+	})
+
+	mw, err := RequireHeadersMiddleware(RequireHeadersConfig{
+		Headers: []RequiredHeader{
+			{Name: "x-tenant-id"},
+			{Name: "X-Api-Version", Pattern: `v[0-9]+`},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+		t.Helper()
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		return body
+	}
+
+	t.Run("all present passes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant-ID", "acme")
+		req.Header.Set("X-Api-Version", "v2")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Version", "v2")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := decode(t, rec)
+		assert.Equal(t, []any{"X-Tenant-Id"}, body["missing"])
+		assert.NotContains(t, body, "invalid")
+		assert.Contains(t, body["detail"], "X-Tenant-Id")
+	})
+
+	t.Run("value not matching pattern", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant-ID", "acme")
+		req.Header.Set("X-Api-Version", "v2-beta")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := decode(t, rec)
+		assert.Equal(t, []any{"X-Api-Version"}, body["invalid"])
+		assert.NotContains(t, body, "missing")
+	})
+
+	t.Run("missing and invalid together", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Version", "latest")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := decode(t, rec)
+		assert.Equal(t, []any{"X-Tenant-Id"}, body["missing"])
+		assert.Equal(t, []any{"X-Api-Version"}, body["invalid"])
+		assert.Equal(t, "missing or invalid request headers: X-Tenant-Id, X-Api-Version", body["detail"])
+	})
+
+	t.Run("scoped to a subrouter", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		api := r.PathPrefix("/api").Subrouter()
+		api.Use(mw)
+		api.HandleFunc("/items", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/items", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,458 @@
+package muxhandlers
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// ErrResponseCacheNoTTL is returned when ResponseCacheConfig sets neither
+// TTL nor RouteTTL, so no response could ever be cached.
+var ErrResponseCacheNoTTL = errors.New("response cache: TTL or RouteTTL must be set")
+
+// ErrResponseCacheInvalidTTL is returned when a TTL in ResponseCacheConfig
+// is negative.
+var ErrResponseCacheInvalidTTL = errors.New("response cache: TTL must not be negative")
+
+// Cache status values written to ResponseCacheConfig.StatusHeader.
+const (
+	// ResponseCacheHit marks a response served from the cache.
+	ResponseCacheHit = "HIT"
+
+	// ResponseCacheMiss marks a cacheable request that was served by the
+	// handler.
+	ResponseCacheMiss = "MISS"
+
+	// ResponseCacheBypass marks a request the cache did not consider, such
+	// as a non-GET/HEAD method or a route without a TTL.
+	ResponseCacheBypass = "BYPASS"
+)
+
+// defaultResponseCacheMaxBytes is the size cap of the default in-memory
+// store.
+const defaultResponseCacheMaxBytes = 32 << 20
+
+// CachedResponse is a complete response held by a ResponseCacheStore.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// StoredAt is when the response was stored; it drives the Age header.
+	StoredAt time.Time
+}
+
+// size returns the approximate memory footprint of the response in bytes.
+func (c *CachedResponse) size() int64 {
+	n := int64(len(c.Body))
+	for k, vals := range c.Header {
+		n += int64(len(k))
+		for _, v := range vals {
+			n += int64(len(v))
+		}
+	}
+	return n
+}
+
+// ResponseCacheStore is the interface for storing cached responses.
+// Implementations must be safe for concurrent use. Keys start with the
+// request path, so DeletePrefix can be implemented as a key prefix scan.
+type ResponseCacheStore interface {
+	// Get returns the response stored under key, or false when there is
+	// none or it has expired.
+	Get(ctx context.Context, key string) (*CachedResponse, bool)
+
+	// Set stores resp under key for ttl.
+	Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration)
+
+	// Delete removes the response stored under key.
+	Delete(ctx context.Context, key string)
+
+	// DeletePrefix removes every response whose key starts with prefix.
+	DeletePrefix(ctx context.Context, prefix string)
+}
+
+// ResponseCacheConfig configures the ResponseCache middleware.
+type ResponseCacheConfig struct {
+	// Store holds the cached responses. Defaults to an in-memory LRU
+	// store capped at MaxBytes.
+	Store ResponseCacheStore
+
+	// MaxBytes caps the size of the default in-memory store. Defaults to
+	// 32 MiB. Ignored when Store is set.
+	MaxBytes int64
+
+	// TTL is how long responses are cached on routes without a RouteTTL
+	// entry. Zero leaves those routes uncached.
+	TTL time.Duration
+
+	// RouteTTL overrides TTL per route, keyed by route name or path
+	// template (for example "/items/{id}"); the name is looked up first.
+	// A zero value disables caching for the route.
+	RouteTTL map[string]time.Duration
+
+	// VaryHeaders lists request headers whose values are part of the
+	// cache key, such as Accept or Accept-Language. Responses with a Vary
+	// header naming any other request header are not cached.
+	VaryHeaders []string
+
+	// KeyFunc builds the part of the cache key that follows the request
+	// path. Defaults to the method, the raw query, and the values of
+	// VaryHeaders. The path prefix is always kept so PurgePrefix works.
+	KeyFunc func(r *http.Request) string
+
+	// CacheableStatusCodes lists the status codes that are cached.
+	// Defaults to 200, 203, 300, 301, and 404.
+	CacheableStatusCodes []int
+
+	// StatusHeader names the response header that reports HIT, MISS, or
+	// BYPASS. Defaults to "X-Cache".
+	StatusHeader string
+}
+
+// ResponseCache is the control surface returned by ResponseCacheMiddleware.
+// Its methods are safe for concurrent use and can be called from write
+// handlers to invalidate stale responses.
+type ResponseCache struct {
+	store   ResponseCacheStore
+	keyFunc func(r *http.Request) string
+}
+
+// Key returns the cache key for r.
+func (c *ResponseCache) Key(r *http.Request) string {
+	return r.URL.Path + "\x00" + c.keyFunc(r)
+}
+
+// Purge removes the response cached under key, as returned by Key.
+func (c *ResponseCache) Purge(key string) {
+	c.store.Delete(context.Background(), key)
+}
+
+// PurgePrefix removes every cached response whose request path starts
+// with pathPrefix, across methods, queries, and header variants.
+func (c *ResponseCache) PurgePrefix(pathPrefix string) {
+	c.store.DeletePrefix(context.Background(), pathPrefix)
+}
+
+// ResponseCacheMiddleware returns a middleware that caches complete
+// responses (status, headers, and body) of GET and HEAD requests, and the
+// ResponseCache used to invalidate them:
+//
+//	mw, cache, err := muxhandlers.ResponseCacheMiddleware(muxhandlers.ResponseCacheConfig{
+//	    RouteTTL: map[string]time.Duration{"/reports/{id}": time.Minute},
+//	})
+//	r.Use(mw)
+//	// in a write handler:
+//	cache.PurgePrefix("/reports/")
+//
+// Responses with a status outside CacheableStatusCodes, a Set-Cookie
+// header, a Cache-Control of no-store or private, or a Vary header that
+// is not covered by VaryHeaders are never stored. Cached responses are
+// replayed with an Age header. Every response carries the StatusHeader.
+//
+// Handler responses are buffered before they are sent, so streaming
+// endpoints should not be cached.
+//
+// It returns ErrResponseCacheNoTTL when neither TTL nor RouteTTL is set,
+// and ErrResponseCacheInvalidTTL for a negative TTL.
+func ResponseCacheMiddleware(cfg ResponseCacheConfig) (mux.MiddlewareFunc, *ResponseCache, error) {
+	if cfg.TTL == 0 && len(cfg.RouteTTL) == 0 {
+		return nil, nil, ErrResponseCacheNoTTL
+	}
+	if cfg.TTL < 0 {
+		return nil, nil, ErrResponseCacheInvalidTTL
+	}
+	for _, ttl := range cfg.RouteTTL {
+		if ttl < 0 {
+			return nil, nil, ErrResponseCacheInvalidTTL
+		}
+	}
+
+	store := cfg.Store
+	if store == nil {
+		maxBytes := cfg.MaxBytes
+		if maxBytes <= 0 {
+			maxBytes = defaultResponseCacheMaxBytes
+		}
+		store = NewLRUResponseCacheStore(maxBytes)
+	}
+
+	varyHeaders := make([]string, len(cfg.VaryHeaders))
+	for i, h := range cfg.VaryHeaders {
+		varyHeaders[i] = http.CanonicalHeaderKey(h)
+	}
+
+	keyFunc := cfg.KeyFunc
+	if keyFunc == nil {
+		keyFunc = func(r *http.Request) string {
+			var b strings.Builder
+			b.WriteString(r.Method)
+			b.WriteByte(0)
+			b.WriteString(r.URL.RawQuery)
+			for _, h := range varyHeaders {
+				b.WriteByte(0)
+				b.WriteString(strings.Join(r.Header.Values(h), ","))
+			}
+			return b.String()
+		}
+	}
+
+	cacheable := cfg.CacheableStatusCodes
+	if len(cacheable) == 0 {
+		cacheable = []int{
+			http.StatusOK,
+			http.StatusNonAuthoritativeInfo,
+			http.StatusMultipleChoices,
+			http.StatusMovedPermanently,
+			http.StatusNotFound,
+		}
+	}
+
+	statusHeader := cfg.StatusHeader
+	if statusHeader == "" {
+		statusHeader = "X-Cache"
+	}
+
+	defaultTTL := cfg.TTL
+	routeTTL := cfg.RouteTTL
+
+	ttlFor := func(r *http.Request) time.Duration {
+		route := mux.CurrentRoute(r)
+		if route == nil || len(routeTTL) == 0 {
+			return defaultTTL
+		}
+		if name := route.GetName(); name != "" {
+			if ttl, ok := routeTTL[name]; ok {
+				return ttl
+			}
+		}
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			if ttl, ok := routeTTL[tpl]; ok {
+				return ttl
+			}
+		}
+		return defaultTTL
+	}
+
+	cache := &ResponseCache{store: store, keyFunc: keyFunc}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				w.Header().Set(statusHeader, ResponseCacheBypass)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ttl := ttlFor(r)
+			if ttl <= 0 {
+				w.Header().Set(statusHeader, ResponseCacheBypass)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := cache.Key(r)
+			if cached, ok := store.Get(r.Context(), key); ok {
+				h := w.Header()
+				for k, vals := range cached.Header {
+					h[k] = slices.Clone(vals)
+				}
+				age := max(int64(time.Since(cached.StoredAt)/time.Second), 0)
+				h.Set("Age", strconv.FormatInt(age, 10))
+				h.Set(statusHeader, ResponseCacheHit)
+				w.WriteHeader(cached.StatusCode)
+				w.Write(cached.Body) //nolint:errcheck
+				return
+			}
+
+			rec := &responseRecorder{
+				header: make(http.Header),
+				body:   &bytes.Buffer{},
+			}
+			next.ServeHTTP(rec, r)
+			if rec.statusCode == 0 {
+				rec.statusCode = http.StatusOK
+			}
+
+			if slices.Contains(cacheable, rec.statusCode) && isStorableResponse(rec.header, varyHeaders) {
+				store.Set(r.Context(), key, &CachedResponse{
+					StatusCode: rec.statusCode,
+					Header:     rec.header.Clone(),
+					Body:       bytes.Clone(rec.body.Bytes()),
+					StoredAt:   time.Now(),
+				}, ttl)
+			}
+
+			h := w.Header()
+			for k, vals := range rec.header {
+				h[k] = vals
+			}
+			h.Set(statusHeader, ResponseCacheMiss)
+			w.WriteHeader(rec.statusCode)
+			w.Write(rec.body.Bytes()) //nolint:errcheck
+		})
+	}, cache, nil
+}
+
+// isStorableResponse reports whether a response with header h may be
+// shared between clients. varyHeaders are the canonical request headers
+// that are part of the cache key.
+func isStorableResponse(h http.Header, varyHeaders []string) bool {
+	if len(h.Values("Set-Cookie")) > 0 {
+		return false
+	}
+
+	for _, value := range h.Values("Cache-Control") {
+		for directive := range strings.SplitSeq(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if strings.EqualFold(name, "no-store") || strings.EqualFold(name, "private") {
+				return false
+			}
+		}
+	}
+
+	for _, value := range h.Values("Vary") {
+		for name := range strings.SplitSeq(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" || !slices.Contains(varyHeaders, http.CanonicalHeaderKey(name)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// LRUResponseCacheStore is an in-memory ResponseCacheStore that evicts the
+// least recently used responses once the stored bytes exceed its cap.
+// Expired responses are dropped when they are looked up or evicted.
+type LRUResponseCacheStore struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+// lruResponseEntry is the list element value of LRUResponseCacheStore.
+type lruResponseEntry struct {
+	key     string
+	resp    *CachedResponse
+	size    int64
+	expires time.Time
+}
+
+// NewLRUResponseCacheStore returns an in-memory store holding at most
+// maxBytes of response bodies, headers, and keys. A response larger than
+// maxBytes is never stored.
+func NewLRUResponseCacheStore(maxBytes int64) *LRUResponseCacheStore {
+	return &LRUResponseCacheStore{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the response stored under key.
+func (s *LRUResponseCacheStore) Get(_ context.Context, key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruResponseEntry)
+	if time.Now().After(entry.expires) {
+		s.remove(elem)
+		return nil, false
+	}
+
+	s.order.MoveToFront(elem)
+	return entry.resp, true
+}
+
+// Set stores resp under key for ttl, evicting least recently used
+// responses to stay within the size cap.
+func (s *LRUResponseCacheStore) Set(_ context.Context, key string, resp *CachedResponse, ttl time.Duration) {
+	size := int64(len(key)) + resp.size()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	if size > s.maxBytes {
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&lruResponseEntry{
+		key:     key,
+		resp:    resp,
+		size:    size,
+		expires: time.Now().Add(ttl),
+	})
+	s.size += size
+
+	for s.size > s.maxBytes {
+		s.remove(s.order.Back())
+	}
+}
+
+// Delete removes the response stored under key.
+func (s *LRUResponseCacheStore) Delete(_ context.Context, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+}
+
+// DeletePrefix removes every response whose key starts with prefix.
+func (s *LRUResponseCacheStore) DeletePrefix(_ context.Context, prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, elem := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(elem)
+		}
+	}
+}
+
+// Len returns the number of stored responses, including expired ones that
+// have not been dropped yet.
+func (s *LRUResponseCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Size returns the number of bytes accounted to stored responses.
+func (s *LRUResponseCacheStore) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// remove drops elem from the store. The caller must hold s.mu.
+func (s *LRUResponseCacheStore) remove(elem *list.Element) {
+	entry := s.order.Remove(elem).(*lruResponseEntry)
+	delete(s.entries, entry.key)
+	s.size -= entry.size
+}
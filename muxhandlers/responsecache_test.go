@@ -0,0 +1,357 @@
+package muxhandlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	t.Run("config errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config ResponseCacheConfig
+			err    error
+		}{
+			{"no ttl", ResponseCacheConfig{}, ErrResponseCacheNoTTL},
+			{"negative ttl", ResponseCacheConfig{TTL: -time.Second}, ErrResponseCacheInvalidTTL},
+			{"negative route ttl", ResponseCacheConfig{RouteTTL: map[string]time.Duration{"/a": -1}}, ErrResponseCacheInvalidTTL},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, _, err := ResponseCacheMiddleware(tt.config)
+				assert.ErrorIs(t, err, tt.err)
+			})
+		}
+	})
+
+	type setup struct {
+		router *mux.Router
+		cache  *ResponseCache
+		calls  *atomic.Int32
+	}
+
+	newSetup := func(t *testing.T, cfg ResponseCacheConfig, handler http.HandlerFunc) setup {
+		t.Helper()
+
+		mw, cache, err := ResponseCacheMiddleware(cfg)
+		require.NoError(t, err)
+
+		calls := &atomic.Int32{}
+		r := mux.NewRouter()
+		r.Use(mw)
+		wrapped := func(w http.ResponseWriter, req *http.Request) {
+			calls.Add(1)
+			handler(w, req)
+		}
+		r.HandleFunc("/items/{id}", wrapped).Methods(http.MethodGet, http.MethodPost).Name("item")
+		r.HandleFunc("/reports/{id}", wrapped)
+		r.HandleFunc("/live", wrapped)
+
+		return setup{router: r, cache: cache, calls: calls}
+	}
+
+	serve := func(r *mux.Router, method, target string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s %s", r.URL.Path, r.Header.Get("Accept-Language"))
+	}
+
+	t.Run("miss then hit", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, echo)
+
+		w := serve(s.router, http.MethodGet, "/items/1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ResponseCacheMiss, w.Header().Get("X-Cache"))
+		assert.Equal(t, "/items/1 ", w.Body.String())
+
+		w = serve(s.router, http.MethodGet, "/items/1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ResponseCacheHit, w.Header().Get("X-Cache"))
+		assert.Equal(t, "/items/1 ", w.Body.String())
+		assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+		assert.Equal(t, "0", w.Header().Get("Age"))
+		assert.Equal(t, int32(1), s.calls.Load())
+	})
+
+	t.Run("query is part of key", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, echo)
+
+		serve(s.router, http.MethodGet, "/items/1?a=1")
+		w := serve(s.router, http.MethodGet, "/items/1?a=2")
+		assert.Equal(t, ResponseCacheMiss, w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(2), s.calls.Load())
+	})
+
+	t.Run("non-GET bypasses", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, echo)
+
+		serve(s.router, http.MethodPost, "/items/1")
+		w := serve(s.router, http.MethodPost, "/items/1")
+		assert.Equal(t, ResponseCacheBypass, w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(2), s.calls.Load())
+	})
+
+	t.Run("HEAD is cached separately", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, echo)
+
+		assert.Equal(t, ResponseCacheMiss, serve(s.router, http.MethodHead, "/items/1").Header().Get("X-Cache"))
+		assert.Equal(t, ResponseCacheHit, serve(s.router, http.MethodHead, "/items/1").Header().Get("X-Cache"))
+		assert.Equal(t, ResponseCacheMiss, serve(s.router, http.MethodGet, "/items/1").Header().Get("X-Cache"))
+	})
+
+	t.Run("route ttl by template and name", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{
+			RouteTTL: map[string]time.Duration{
+				"/reports/{id}": time.Minute,
+				"item":          0,
+			},
+		}, echo)
+
+		serve(s.router, http.MethodGet, "/reports/1")
+		assert.Equal(t, ResponseCacheHit, serve(s.router, http.MethodGet, "/reports/1").Header().Get("X-Cache"))
+
+		serve(s.router, http.MethodGet, "/items/1")
+		assert.Equal(t, ResponseCacheBypass, serve(s.router, http.MethodGet, "/items/1").Header().Get("X-Cache"))
+
+		assert.Equal(t, ResponseCacheBypass, serve(s.router, http.MethodGet, "/live").Header().Get("X-Cache"))
+	})
+
+	t.Run("expired entry is a miss", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{TTL: 20 * time.Millisecond}, echo)
+
+		serve(s.router, http.MethodGet, "/items/1")
+		time.Sleep(40 * time.Millisecond)
+		assert.Equal(t, ResponseCacheMiss, serve(s.router, http.MethodGet, "/items/1").Header().Get("X-Cache"))
+	})
+
+	t.Run("cacheable status codes", func(t *testing.T) {
+		tests := []struct {
+			status int
+			cached bool
+		}{
+			{http.StatusOK, true},
+			{http.StatusNonAuthoritativeInfo, true},
+			{http.StatusMultipleChoices, true},
+			{http.StatusMovedPermanently, true},
+			{http.StatusNotFound, true},
+			{http.StatusCreated, false},
+			{http.StatusFound, false},
+			{http.StatusInternalServerError, false},
+		}
+
+		for _, tt := range tests {
+			t.Run(http.StatusText(tt.status), func(t *testing.T) {
+				s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(tt.status)
+				})
+
+				serve(s.router, http.MethodGet, "/items/1")
+				w := serve(s.router, http.MethodGet, "/items/1")
+				assert.Equal(t, tt.status, w.Code)
+				if tt.cached {
+					assert.Equal(t, ResponseCacheHit, w.Header().Get("X-Cache"))
+				} else {
+					assert.Equal(t, ResponseCacheMiss, w.Header().Get("X-Cache"))
+				}
+			})
+		}
+	})
+
+	t.Run("uncacheable responses", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			header string
+			value  string
+		}{
+			{"set-cookie", "Set-Cookie", "session=1"},
+			{"no-store", "Cache-Control", "max-age=60, no-store"},
+			{"private", "Cache-Control", "private"},
+			{"vary star", "Vary", "*"},
+			{"vary unknown header", "Vary", "Authorization"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set(tt.header, tt.value)
+					w.WriteHeader(http.StatusOK)
+				})
+
+				serve(s.router, http.MethodGet, "/items/1")
+				w := serve(s.router, http.MethodGet, "/items/1")
+				assert.Equal(t, ResponseCacheMiss, w.Header().Get("X-Cache"))
+				assert.Equal(t, int32(2), s.calls.Load())
+			})
+		}
+	})
+
+	t.Run("vary headers are part of key", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{
+			TTL:         time.Minute,
+			VaryHeaders: []string{"accept-language"},
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Vary", "Accept-Language")
+			echo(w, r)
+		})
+
+		serve(s.router, http.MethodGet, "/items/1", "Accept-Language", "en")
+		w := serve(s.router, http.MethodGet, "/items/1", "Accept-Language", "de")
+		assert.Equal(t, ResponseCacheMiss, w.Header().Get("X-Cache"))
+		assert.Equal(t, "/items/1 de", w.Body.String())
+
+		w = serve(s.router, http.MethodGet, "/items/1", "Accept-Language", "en")
+		assert.Equal(t, ResponseCacheHit, w.Header().Get("X-Cache"))
+		assert.Equal(t, "/items/1 en", w.Body.String())
+	})
+
+	t.Run("custom key func and status header", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{
+			TTL:          time.Minute,
+			KeyFunc:      func(*http.Request) string { return "shared" },
+			StatusHeader: "X-Cache-Status",
+		}, echo)
+
+		serve(s.router, http.MethodGet, "/items/1?a=1")
+		w := serve(s.router, http.MethodGet, "/items/1?a=2")
+		assert.Equal(t, ResponseCacheHit, w.Header().Get("X-Cache-Status"))
+		assert.Empty(t, w.Header().Get("X-Cache"))
+	})
+
+	t.Run("purge key", func(t *testing.T) {
+		s := newSetup(t, ResponseCacheConfig{TTL: time.Minute}, echo)
+
+		serve(s.router, http.MethodGet, "/items/1")
+		serve(s.router, http.MethodGet, "/items/2")
+
+		s.cache.Purge(s.cache.Key(httptest.NewRequest(http.MethodGet, "/items/1", nil)))
+
+		assert.Equal(t, ResponseCacheMiss, serve(s.router, http.MethodGet, "/items/1").Header().Get("X-Cache"))
+		assert.Equal(t, ResponseCacheHit, serve(s.router, http.MethodGet, "/items/2").Header().Get("X-Cache"))
+	})
+
+	t.Run("purge prefix from write handler", func(t *testing.T) {
+		mw, cache, err := ResponseCacheMiddleware(ResponseCacheConfig{TTL: time.Minute})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		r.Use(mw)
+		r.HandleFunc("/reports/{id}", echo).Methods(http.MethodGet)
+		r.HandleFunc("/reports/{id}", func(w http.ResponseWriter, _ *http.Request) {
+			cache.PurgePrefix("/reports/")
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodPut)
+		r.HandleFunc("/other", echo)
+
+		serve(r, http.MethodGet, "/reports/1")
+		serve(r, http.MethodGet, "/reports/2?full=1")
+		serve(r, http.MethodGet, "/other")
+
+		assert.Equal(t, http.StatusNoContent, serve(r, http.MethodPut, "/reports/1").Code)
+
+		assert.Equal(t, ResponseCacheMiss, serve(r, http.MethodGet, "/reports/1").Header().Get("X-Cache"))
+		assert.Equal(t, ResponseCacheMiss, serve(r, http.MethodGet, "/reports/2?full=1").Header().Get("X-Cache"))
+		assert.Equal(t, ResponseCacheHit, serve(r, http.MethodGet, "/other").Header().Get("X-Cache"))
+	})
+
+	t.Run("custom store", func(t *testing.T) {
+		store := NewLRUResponseCacheStore(1 << 20)
+		s := newSetup(t, ResponseCacheConfig{TTL: time.Minute, Store: store}, echo)
+
+		serve(s.router, http.MethodGet, "/items/1")
+		assert.Equal(t, 1, store.Len())
+	})
+}
+
+func TestLRUResponseCacheStore(t *testing.T) {
+	ctx := context.Background()
+	resp := func(body string) *CachedResponse {
+		return &CachedResponse{StatusCode: http.StatusOK, Body: []byte(body)}
+	}
+
+	t.Run("get and set", func(t *testing.T) {
+		s := NewLRUResponseCacheStore(1024)
+
+		_, ok := s.Get(ctx, "a")
+		assert.False(t, ok)
+
+		s.Set(ctx, "a", resp("hello"), time.Minute)
+		got, ok := s.Get(ctx, "a")
+		require.True(t, ok)
+		assert.Equal(t, []byte("hello"), got.Body)
+		assert.Equal(t, int64(len("a")+len("hello")), s.Size())
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		s := NewLRUResponseCacheStore(25)
+
+		s.Set(ctx, "a", resp(strings.Repeat("x", 9)), time.Minute)
+		s.Set(ctx, "b", resp(strings.Repeat("x", 9)), time.Minute)
+		s.Get(ctx, "a")
+		s.Set(ctx, "c", resp(strings.Repeat("x", 9)), time.Minute)
+
+		_, ok := s.Get(ctx, "b")
+		assert.False(t, ok)
+		_, ok = s.Get(ctx, "a")
+		assert.True(t, ok)
+		_, ok = s.Get(ctx, "c")
+		assert.True(t, ok)
+		assert.LessOrEqual(t, s.Size(), int64(25))
+	})
+
+	t.Run("oversized response not stored", func(t *testing.T) {
+		s := NewLRUResponseCacheStore(10)
+		s.Set(ctx, "a", resp(strings.Repeat("x", 20)), time.Minute)
+		assert.Equal(t, 0, s.Len())
+	})
+
+	t.Run("replace updates size", func(t *testing.T) {
+		s := NewLRUResponseCacheStore(1024)
+		s.Set(ctx, "a", resp("long body"), time.Minute)
+		s.Set(ctx, "a", resp("x"), time.Minute)
+		assert.Equal(t, 1, s.Len())
+		assert.Equal(t, int64(2), s.Size())
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		s := NewLRUResponseCacheStore(1024)
+		s.Set(ctx, "a", resp("x"), -time.Second)
+		_, ok := s.Get(ctx, "a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, s.Len())
+	})
+
+	t.Run("delete and delete prefix", func(t *testing.T) {
+		s := NewLRUResponseCacheStore(1024)
+		s.Set(ctx, "/a/1", resp("x"), time.Minute)
+		s.Set(ctx, "/a/2", resp("x"), time.Minute)
+		s.Set(ctx, "/b/1", resp("x"), time.Minute)
+
+		s.Delete(ctx, "/b/1")
+		assert.Equal(t, 2, s.Len())
+
+		s.DeletePrefix(ctx, "/a/")
+		assert.Equal(t, 0, s.Len())
+		assert.Equal(t, int64(0), s.Size())
+	})
+}
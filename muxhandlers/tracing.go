@@ -0,0 +1,251 @@
+package muxhandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// ErrNoTracer is returned when TracingConfig.Tracer is nil.
+var ErrNoTracer = errors.New("tracing: Tracer must be set")
+
+// Span attribute keys set by TracingMiddleware. The HTTP keys follow the
+// OpenTelemetry semantic conventions for HTTP server spans.
+const (
+	TraceAttrMethod    = "http.request.method"
+	TraceAttrRoute     = "http.route"
+	TraceAttrStatus    = "http.response.status_code"
+	TraceAttrRequestID = "http.request.id"
+)
+
+// SpanStatusCode is the status of a span. The values match the
+// OpenTelemetry codes.Code constants, so an adapter can convert with
+// codes.Code(code).
+type SpanStatusCode uint32
+
+const (
+	// SpanStatusUnset is the default status of a span.
+	SpanStatusUnset SpanStatusCode = iota
+
+	// SpanStatusError marks a span as failed.
+	SpanStatusError
+
+	// SpanStatusOK marks a span as explicitly successful.
+	SpanStatusOK
+)
+
+// SpanLike is the subset of a tracing span used by TracingMiddleware.
+type SpanLike interface {
+	// SetAttribute records an attribute on the span. TracingMiddleware
+	// passes string and int values only.
+	SetAttribute(key string, value any)
+
+	// SetStatus sets the status of the span.
+	SetStatus(code SpanStatusCode, description string)
+
+	// End completes the span.
+	End()
+}
+
+// TracerLike starts spans for TracingMiddleware.
+type TracerLike interface {
+	// Start starts a server span named name as a child of any span
+	// context in ctx, and returns a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, SpanLike)
+}
+
+// TracePropagator extracts an incoming trace context from request
+// headers into a context, such as the parent span of the request.
+type TracePropagator interface {
+	Extract(ctx context.Context, header http.Header) context.Context
+}
+
+// TracingConfig configures the Tracing middleware.
+type TracingConfig struct {
+	// Tracer starts a span for every request. Required.
+	Tracer TracerLike
+
+	// Propagator extracts the incoming trace context before the span is
+	// started. Defaults to W3CTraceContext.
+	Propagator TracePropagator
+}
+
+// TracingMiddleware returns a middleware that wraps every request in a
+// server span. The span is named after the method and the path template
+// of the matched route ("GET /users/{id}"), which keeps span names
+// low-cardinality, or after the method alone when no route matched. It
+// carries the method, route template, response status, and the request
+// ID set by an earlier RequestIDMiddleware as attributes. 5xx responses
+// and panics set SpanStatusError; other responses leave the status
+// unset, as OpenTelemetry recommends for server spans.
+//
+// The tracer and propagator are small local interfaces, so the
+// middleware does not depend on an OpenTelemetry SDK; an adapter takes a
+// few lines in the application. Attach the middleware with Router.Use so
+// that the matched route is known when the span starts.
+//
+// It returns ErrNoTracer if Tracer is nil.
+func TracingMiddleware(cfg TracingConfig) (mux.MiddlewareFunc, error) {
+	if cfg.Tracer == nil {
+		return nil, ErrNoTracer
+	}
+
+	tracer := cfg.Tracer
+	propagator := cfg.Propagator
+	if propagator == nil {
+		propagator = W3CTraceContext{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.Method
+			var template string
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					template = tpl
+					name += " " + tpl
+				}
+			}
+
+			ctx := propagator.Extract(r.Context(), r.Header)
+			ctx, span := tracer.Start(ctx, name)
+
+			span.SetAttribute(TraceAttrMethod, r.Method)
+			if template != "" {
+				span.SetAttribute(TraceAttrRoute, template)
+			}
+			if id := RequestIDFromContext(ctx); id != "" {
+				span.SetAttribute(TraceAttrRequestID, id)
+			}
+
+			recorder, wrapped := accessLogWrap(w)
+			defer func() {
+				if p := recover(); p != nil {
+					// Record the failure and let the panic continue to
+					// the recovery middleware.
+					span.SetStatus(SpanStatusError, fmt.Sprint(p))
+					span.End()
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			if !recorder.hijacked {
+				status := recorder.statusOrDefault()
+				span.SetAttribute(TraceAttrStatus, status)
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(SpanStatusError, http.StatusText(status))
+				}
+			}
+			span.End()
+		})
+	}, nil
+}
+
+// TraceParent is an incoming W3C trace context.
+//
+// Spec reference: https://www.w3.org/TR/trace-context/#traceparent-header
+type TraceParent struct {
+	// TraceID is the 32 lowercase hex digit ID of the whole trace.
+	TraceID string
+
+	// ParentID is the 16 lowercase hex digit ID of the caller's span.
+	ParentID string
+
+	// Flags holds the trace flags; bit 0 is the sampled flag.
+	Flags byte
+
+	// TraceState is the combined tracestate header value, carrying
+	// vendor-specific trace data.
+	TraceState string
+}
+
+// Sampled reports whether the caller recorded its span.
+func (p TraceParent) Sampled() bool {
+	return p.Flags&0x01 != 0
+}
+
+// traceParentKey holds the TraceParent extracted by W3CTraceContext.
+var traceParentKey = mux.NewContextKey[TraceParent]("trace-parent")
+
+// TraceParentFromContext returns the TraceParent stored in the context
+// by W3CTraceContext, and whether one was present.
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	return traceParentKey.FromContext(ctx)
+}
+
+// W3CTraceContext is a TracePropagator that parses the traceparent and
+// tracestate headers of the W3C Trace Context recommendation and stores
+// the result in the context, where a TracerLike reads it with
+// TraceParentFromContext to continue the caller's trace. Invalid or
+// repeated traceparent headers are ignored, together with tracestate.
+//
+// Spec reference: https://www.w3.org/TR/trace-context/
+type W3CTraceContext struct{}
+
+// Extract implements TracePropagator.
+func (W3CTraceContext) Extract(ctx context.Context, header http.Header) context.Context {
+	values := header.Values("Traceparent")
+	if len(values) != 1 {
+		return ctx
+	}
+	parent, ok := parseTraceParent(values[0])
+	if !ok {
+		return ctx
+	}
+	parent.TraceState = strings.Join(header.Values("Tracestate"), ",")
+	return traceParentKey.WithContext(ctx, parent)
+}
+
+// parseTraceParent parses a traceparent header value of the form
+// version "-" trace-id "-" parent-id "-" trace-flags.
+func parseTraceParent(value string) (TraceParent, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return TraceParent{}, false
+	}
+
+	version := value[:2]
+	if !isLowerHex(version) || version == "ff" {
+		return TraceParent{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append
+	// more, which this parser ignores.
+	if len(value) > 55 && (version == "00" || value[55] != '-') {
+		return TraceParent{}, false
+	}
+
+	traceID, parentID, flags := value[3:35], value[36:52], value[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return TraceParent{}, false
+	}
+
+	return TraceParent{
+		TraceID:  traceID,
+		ParentID: parentID,
+		Flags:    hexValue(flags[0])<<4 | hexValue(flags[1]),
+	}, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func hexValue(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
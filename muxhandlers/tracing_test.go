@@ -0,0 +1,289 @@
+package muxhandlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type testSpan struct {
+	name        string
+	parent      TraceParent
+	hasParent   bool
+	attrs       map[string]any
+	status      SpanStatusCode
+	description string
+	ended       int
+}
+
+func (s *testSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+
+func (s *testSpan) SetStatus(code SpanStatusCode, description string) {
+	s.status, s.description = code, description
+}
+
+func (s *testSpan) End() { s.ended++ }
+
+type testSpanKey struct{}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, SpanLike) {
+	span := &testSpan{name: name, attrs: make(map[string]any)}
+	span.parent, span.hasParent = TraceParentFromContext(ctx)
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+type headerPropagator string
+
+func (p headerPropagator) Extract(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, p, header.Get(string(p)))
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	newRouter := func(t *testing.T, tracer *testTracer, cfg TracingConfig) *mux.Router {
+		t.Helper()
+		cfg.Tracer = tracer
+		mw, err := TracingMiddleware(cfg)
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		r.Use(mw)
+		r.HandleFunc("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}).Methods(http.MethodPost)
+		r.HandleFunc("/fail", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		r.HandleFunc("/missing", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		return r
+	}
+
+	t.Run("requires tracer", func(t *testing.T) {
+		mw, err := TracingMiddleware(TracingConfig{})
+		assert.ErrorIs(t, err, ErrNoTracer)
+		assert.Nil(t, mw)
+	})
+
+	t.Run("span per request", func(t *testing.T) {
+		tracer := &testTracer{}
+		r := newRouter(t, tracer, TracingConfig{})
+
+		var spanInHandler any
+		r.HandleFunc("/ctx", func(_ http.ResponseWriter, req *http.Request) {
+			spanInHandler = req.Context().Value(testSpanKey{})
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/42", nil))
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		assert.Equal(t, "POST /users/{id}", span.name)
+		assert.Equal(t, map[string]any{
+			TraceAttrMethod: http.MethodPost,
+			TraceAttrRoute:  "/users/{id}",
+			TraceAttrStatus: http.StatusCreated,
+		}, span.attrs)
+		assert.Equal(t, SpanStatusUnset, span.status)
+		assert.Equal(t, 1, span.ended)
+		assert.False(t, span.hasParent)
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ctx", nil))
+		require.Len(t, tracer.spans, 2)
+		assert.Same(t, tracer.spans[1], spanInHandler)
+		assert.Equal(t, http.StatusOK, tracer.spans[1].attrs[TraceAttrStatus])
+	})
+
+	t.Run("server errors", func(t *testing.T) {
+		tracer := &testTracer{}
+		r := newRouter(t, tracer, TracingConfig{})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		require.Len(t, tracer.spans, 2)
+		assert.Equal(t, SpanStatusError, tracer.spans[0].status)
+		assert.Equal(t, "Bad Gateway", tracer.spans[0].description)
+		assert.Equal(t, SpanStatusUnset, tracer.spans[1].status)
+		assert.Equal(t, http.StatusNotFound, tracer.spans[1].attrs[TraceAttrStatus])
+	})
+
+	t.Run("request id", func(t *testing.T) {
+		tracer := &testTracer{}
+		mw, err := TracingMiddleware(TracingConfig{Tracer: tracer})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		r.Use(RequestIDMiddleware(RequestIDConfig{
+			GenerateFunc: func(*http.Request) string { return "req-1" },
+		}))
+		r.Use(mw)
+		r.HandleFunc("/", func(http.ResponseWriter, *http.Request) {})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, "req-1", tracer.spans[0].attrs[TraceAttrRequestID])
+	})
+
+	t.Run("unmatched route", func(t *testing.T) {
+		tracer := &testTracer{}
+		mw, err := TracingMiddleware(TracingConfig{Tracer: tracer})
+		require.NoError(t, err)
+
+		handler := mw(http.NotFoundHandler())
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/nowhere", nil))
+
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, http.MethodDelete, tracer.spans[0].name)
+		assert.NotContains(t, tracer.spans[0].attrs, TraceAttrRoute)
+	})
+
+	t.Run("w3c trace context", func(t *testing.T) {
+		tracer := &testTracer{}
+		r := newRouter(t, tracer, TracingConfig{})
+
+		req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+		req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		req.Header.Add("Tracestate", "rojo=00f067aa0ba902b7")
+		req.Header.Add("Tracestate", "congo=t61rcWkgMzE")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		require.True(t, span.hasParent)
+		assert.Equal(t, TraceParent{
+			TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+			ParentID:   "00f067aa0ba902b7",
+			Flags:      0x01,
+			TraceState: "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE",
+		}, span.parent)
+		assert.True(t, span.parent.Sampled())
+	})
+
+	t.Run("custom propagator", func(t *testing.T) {
+		tracer := &testTracer{}
+		r := newRouter(t, tracer, TracingConfig{Propagator: headerPropagator("X-Trace")})
+
+		var got any
+		r.HandleFunc("/p", func(_ http.ResponseWriter, req *http.Request) {
+			got = req.Context().Value(headerPropagator("X-Trace"))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/p", nil)
+		req.Header.Set("X-Trace", "abc")
+		req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "abc", got)
+		require.Len(t, tracer.spans, 1)
+		assert.False(t, tracer.spans[0].hasParent)
+	})
+
+	t.Run("panic ends span", func(t *testing.T) {
+		tracer := &testTracer{}
+		mw, err := TracingMiddleware(TracingConfig{Tracer: tracer})
+		require.NoError(t, err)
+
+		handler := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}))
+		assert.PanicsWithValue(t, "boom", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, SpanStatusError, tracer.spans[0].status)
+		assert.Equal(t, "boom", tracer.spans[0].description)
+		assert.Equal(t, 1, tracer.spans[0].ended)
+	})
+}
+
+func TestW3CTraceContext(t *testing.T) {
+	valid := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+
+	tests := []struct {
+		name   string
+		values []string
+		ok     bool
+	}{
+		{name: "valid", values: []string{valid}, ok: true},
+		{name: "surrounding whitespace", values: []string{" " + valid + " "}, ok: true},
+		{name: "future version with extra fields", values: []string{"cc" + valid[2:] + "-what-the-future-will-be"}, ok: true},
+		{name: "future version exact length", values: []string{"cc" + valid[2:]}, ok: true},
+		{name: "missing", values: nil},
+		{name: "repeated", values: []string{valid, valid}},
+		{name: "version ff", values: []string{"ff" + valid[2:]}},
+		{name: "version 00 with extra fields", values: []string{valid + "-extra"}},
+		{name: "future version bad separator", values: []string{"cc" + valid[2:] + "x"}},
+		{name: "uppercase", values: []string{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-00"}},
+		{name: "zero trace id", values: []string{"00-00000000000000000000000000000000-00f067aa0ba902b7-00"}},
+		{name: "zero parent id", values: []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-00"}},
+		{name: "bad separator", values: []string{"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}},
+		{name: "short", values: []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0"}},
+		{name: "bad flags", values: []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, v := range tt.values {
+				header.Add("Traceparent", v)
+			}
+			header.Set("Tracestate", "vendor=value")
+
+			parent, ok := TraceParentFromContext(W3CTraceContext{}.Extract(context.Background(), header))
+			require.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parent.TraceID)
+				assert.Equal(t, "00f067aa0ba902b7", parent.ParentID)
+				assert.False(t, parent.Sampled())
+				assert.Equal(t, "vendor=value", parent.TraceState)
+			}
+		})
+	}
+}
+
+func BenchmarkTracingMiddleware(b *testing.B) {
+	mw, err := TracingMiddleware(TracingConfig{Tracer: noopTracer{}})
+	require.NoError(b, err)
+
+	r := mux.NewRouter()
+	r.Use(mw)
+	r.HandleFunc("/users/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, SpanLike) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, any)         {}
+func (noopSpan) SetStatus(SpanStatusCode, string) {}
+func (noopSpan) End()                             {}
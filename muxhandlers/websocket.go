@@ -0,0 +1,49 @@
+package muxhandlers
+
+import (
+	"net/http"
+
+	"github.com/vitalvas/kasper/websocket"
+)
+
+// WebSocketHandler returns a handler that upgrades the request to a
+// WebSocket connection with upgrader and passes the connection to fn. The
+// connection is closed when fn returns. If the upgrade fails, the upgrader
+// has already written the HTTP error response and fn is not called.
+//
+// When upgrader.CheckOrigin is nil and the request passed through a
+// CORSMiddleware, the Origin header is checked against the same
+// AllowedOrigins and AllowOriginFunc as CORS requests, so a single origin
+// policy covers both; requests without an Origin header are accepted.
+// Otherwise the upgrader's own origin check applies: CheckOrigin when set,
+// or the same-origin check of websocket.Upgrader. A rejected origin
+// receives 403 Forbidden.
+//
+// All requests share the handler's copy of upgrader, so
+// upgrader.MaxConnections caps the connections open through the handler.
+//
+// Spec reference: https://www.rfc-editor.org/rfc/rfc6455#section-10.2
+func WebSocketHandler(upgrader websocket.Upgrader, fn func(*websocket.Conn)) http.Handler {
+	// The upgrader is shared by all requests rather than copied per
+	// request, so that MaxConnections counts every connection.
+	if upgrader.CheckOrigin == nil {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			allowed := corsOriginCheckFromContext(r.Context())
+			if allowed == nil {
+				return websocket.CheckSameOrigin(r)
+			}
+			origin := r.Header.Get("Origin")
+			return origin == "" || allowed(origin)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fn(conn)
+	})
+}
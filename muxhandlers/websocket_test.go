@@ -0,0 +1,133 @@
+package muxhandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+	"github.com/vitalvas/kasper/websocket"
+)
+
+func TestWebSocketHandler(t *testing.T) {
+	echo := func(conn *websocket.Conn) {
+		mt, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMessage(mt, data)
+	}
+
+	newServer := func(t *testing.T, cors *CORSConfig, upgrader websocket.Upgrader) string {
+		t.Helper()
+		r := mux.NewRouter()
+		r.Handle("/ws", WebSocketHandler(upgrader, echo)).Methods(http.MethodGet)
+		if cors != nil {
+			mw, err := CORSMiddleware(r, *cors)
+			require.NoError(t, err)
+			r.Use(mw)
+		}
+		srv := httptest.NewServer(r)
+		t.Cleanup(srv.Close)
+		return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	}
+
+	dial := func(t *testing.T, url, origin string) (*websocket.Conn, *http.Response, error) {
+		t.Helper()
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		if conn != nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+		return conn, resp, err
+	}
+
+	cors := &CORSConfig{
+		AllowedOrigins:  []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginFunc: func(origin string) bool { return origin == "https://dynamic.example.net" },
+	}
+
+	t.Run("allowed origin upgrades", func(t *testing.T) {
+		url := newServer(t, cors, websocket.Upgrader{})
+		for _, origin := range []string{"https://app.example.com", "https://API.example.org", "https://dynamic.example.net"} {
+			conn, resp, err := dial(t, url, origin)
+			require.NoError(t, err, origin)
+			assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+			mt, data, err := conn.ReadMessage()
+			require.NoError(t, err)
+			assert.Equal(t, websocket.TextMessage, mt)
+			assert.Equal(t, "ping", string(data))
+		}
+	})
+
+	t.Run("disallowed origin rejected", func(t *testing.T) {
+		url := newServer(t, cors, websocket.Upgrader{})
+		conn, resp, err := dial(t, url, "https://evil.example.com")
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		assert.Nil(t, conn)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("missing origin accepted", func(t *testing.T) {
+		url := newServer(t, cors, websocket.Upgrader{})
+		_, resp, err := dial(t, url, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	})
+
+	t.Run("explicit check origin wins", func(t *testing.T) {
+		url := newServer(t, cors, websocket.Upgrader{
+			CheckOrigin: func(*http.Request) bool { return false },
+		})
+		_, resp, err := dial(t, url, "https://app.example.com")
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("without cors uses same origin check", func(t *testing.T) {
+		url := newServer(t, nil, websocket.Upgrader{})
+		_, resp, err := dial(t, url, "https://app.example.com")
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		_, resp, err = dial(t, url, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	})
+
+	t.Run("max connections shared across requests", func(t *testing.T) {
+		url := newServer(t, cors, websocket.Upgrader{MaxConnections: 1})
+		_, resp, err := dial(t, url, "https://app.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		_, resp, err = dial(t, url, "https://app.example.com")
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+
+	t.Run("failed upgrade does not call fn", func(t *testing.T) {
+		called := false
+		h := WebSocketHandler(websocket.Upgrader{}, func(*websocket.Conn) { called = true })
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,502 @@
+package muxhandlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// Paths served by WellKnownHandler.
+const (
+	SecurityTxtPath    = "/.well-known/security.txt"
+	RobotsTxtPath      = "/robots.txt"
+	ChangePasswordPath = "/.well-known/change-password"
+)
+
+// DefaultWellKnownCacheMaxAge is the Cache-Control max-age used when
+// WellKnownConfig.CacheMaxAge is zero.
+const DefaultWellKnownCacheMaxAge = 24 * time.Hour
+
+// WellKnown handler configuration errors.
+var (
+	// ErrWellKnownEmpty is returned when WellKnownConfig configures none of
+	// security.txt, robots.txt, and the change-password redirect.
+	ErrWellKnownEmpty = errors.New("well-known: at least one of SecurityTxt, RobotsTxt, or ChangePasswordURL is required")
+
+	// ErrWellKnownChangePasswordURL is returned when ChangePasswordURL is
+	// neither an absolute path nor an absolute URL.
+	ErrWellKnownChangePasswordURL = errors.New("well-known: change-password URL must be an absolute path or an absolute URL")
+
+	// ErrSecurityTxtNoContact is returned when SecurityTxt.Contact is empty.
+	ErrSecurityTxtNoContact = errors.New("security.txt: at least one Contact is required")
+
+	// ErrSecurityTxtNoExpires is returned when neither SecurityTxt.Expires
+	// nor SecurityTxt.ExpiresIn is set.
+	ErrSecurityTxtNoExpires = errors.New("security.txt: Expires or ExpiresIn is required")
+
+	// ErrSecurityTxtExpiresConflict is returned when both SecurityTxt.Expires
+	// and SecurityTxt.ExpiresIn are set.
+	ErrSecurityTxtExpiresConflict = errors.New("security.txt: Expires and ExpiresIn are mutually exclusive")
+
+	// ErrSecurityTxtExpired is returned when SecurityTxt.Expires is not in
+	// the future or SecurityTxt.ExpiresIn is negative.
+	ErrSecurityTxtExpired = errors.New("security.txt: Expires must be in the future")
+
+	// ErrSecurityTxtInvalidValue is returned when a security.txt field value
+	// is not a valid URI or language tag.
+	ErrSecurityTxtInvalidValue = errors.New("security.txt: invalid field value")
+
+	// ErrRobotsTxtConflict is returned when both RobotsTxt.Content and
+	// RobotsTxt.Groups are set.
+	ErrRobotsTxtConflict = errors.New("robots.txt: Content and Groups are mutually exclusive")
+
+	// ErrRobotsTxtInvalidRule is returned when a robots.txt user agent, path
+	// rule, or sitemap URL is invalid.
+	ErrRobotsTxtInvalidRule = errors.New("robots.txt: invalid rule")
+)
+
+// SecurityTxt describes the fields of a security.txt file. Each slice
+// field produces one line per value, in the order given.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9116#section-2.5
+type SecurityTxt struct {
+	// Contact lists the URIs for reporting vulnerabilities, such as
+	// "mailto:security@example.com" or "https://example.com/security".
+	// Required. Web URIs must use https.
+	Contact []string
+
+	// Expires is the fixed date after which the file should be considered
+	// stale. It must be in the future when the handler is created.
+	// Exactly one of Expires and ExpiresIn is required.
+	Expires time.Time
+
+	// ExpiresIn sets Expires to the time of each request plus ExpiresIn, so
+	// that the file never goes stale. RFC 9116 recommends less than a year.
+	ExpiresIn time.Duration
+
+	// Encryption lists URIs of keys for encrypted reports.
+	Encryption []string
+
+	// Acknowledgments lists URIs of pages recognizing reporters.
+	Acknowledgments []string
+
+	// PreferredLanguages lists language tags, such as "en" or "de-CH",
+	// emitted as one comma-separated Preferred-Languages field.
+	PreferredLanguages []string
+
+	// Canonical lists the URIs the file is published at, such as
+	// "https://example.com/.well-known/security.txt".
+	Canonical []string
+
+	// Policy lists URIs of the vulnerability disclosure policy.
+	Policy []string
+
+	// Hiring lists URIs of security-related job openings.
+	Hiring []string
+}
+
+// RobotsTxt describes a robots.txt file, either as literal Content or as
+// structured Groups.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9309
+type RobotsTxt struct {
+	// Content is served as is, with a trailing newline added when missing.
+	// Mutually exclusive with Groups.
+	Content string
+
+	// Groups are rendered as robots.txt groups, separated by blank lines.
+	Groups []RobotsGroup
+
+	// Sitemaps lists absolute sitemap URLs, emitted as Sitemap lines after
+	// the groups.
+	Sitemaps []string
+}
+
+// RobotsGroup is a robots.txt group: the rules for a set of crawlers.
+type RobotsGroup struct {
+	// UserAgents lists the crawler product tokens the group applies to.
+	// Defaults to "*" (all crawlers).
+	UserAgents []string
+
+	// Allow lists path patterns crawlers may access. Each must start with
+	// "/" or "*".
+	Allow []string
+
+	// Disallow lists path patterns crawlers must not access. Each must
+	// start with "/" or "*". A group without rules emits an empty
+	// Disallow line, which allows everything.
+	Disallow []string
+}
+
+// WellKnownConfig configures the WellKnown handler. At least one of
+// SecurityTxt, RobotsTxt, and ChangePasswordURL is required.
+type WellKnownConfig struct {
+	// SecurityTxt is served at /.well-known/security.txt when non-nil.
+	SecurityTxt *SecurityTxt
+
+	// RobotsTxt is served at /robots.txt when non-nil.
+	RobotsTxt *RobotsTxt
+
+	// ChangePasswordURL, when non-empty, is the target of a 302 redirect
+	// served at /.well-known/change-password. It must be an absolute path
+	// or an absolute URL.
+	//
+	// See: https://w3c.github.io/webappsec-change-password-url/
+	ChangePasswordURL string
+
+	// CacheMaxAge is the max-age of the public Cache-Control header set on
+	// every response. Defaults to DefaultWellKnownCacheMaxAge. A negative
+	// value disables the header.
+	CacheMaxAge time.Duration
+
+	// now overrides the clock source used to validate and compute
+	// Expires; tests set it. Defaults to time.Now.
+	now func() time.Time
+}
+
+// WellKnown serves security.txt, robots.txt, and the change-password
+// redirect. It is an http.Handler that dispatches on the request path;
+// Mount registers it on a router.
+type WellKnown struct {
+	securityTxt    string // rendered without the Expires field
+	expires        string // fixed Expires value, empty with expiresIn
+	expiresIn      time.Duration
+	robotsTxt      string
+	changePassword string
+	cacheControl   string
+	now            func() time.Time
+}
+
+// WellKnownHandler returns a handler serving the configured well-known
+// resources at their canonical paths:
+//
+//   - /.well-known/security.txt per RFC 9116, validated at construction:
+//     Contact and Expires are required, and web URIs must use https.
+//   - /robots.txt per RFC 9309, from literal content or structured groups.
+//   - /.well-known/change-password, a 302 redirect to the password change
+//     page.
+//
+// The text files are served as "text/plain; charset=utf-8" and every
+// response carries a public Cache-Control header. Only GET and HEAD are
+// allowed; other methods get 405 Method Not Allowed, and unknown paths 404.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9116
+// See: https://www.rfc-editor.org/rfc/rfc9309
+func WellKnownHandler(cfg WellKnownConfig) (*WellKnown, error) {
+	if cfg.SecurityTxt == nil && cfg.RobotsTxt == nil && cfg.ChangePasswordURL == "" {
+		return nil, ErrWellKnownEmpty
+	}
+
+	h := &WellKnown{now: cfg.now}
+	if h.now == nil {
+		h.now = time.Now
+	}
+
+	if cfg.SecurityTxt != nil {
+		if err := h.buildSecurityTxt(cfg.SecurityTxt); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.RobotsTxt != nil {
+		robots, err := buildRobotsTxt(cfg.RobotsTxt)
+		if err != nil {
+			return nil, err
+		}
+		h.robotsTxt = robots
+	}
+
+	if cfg.ChangePasswordURL != "" {
+		u, err := url.Parse(cfg.ChangePasswordURL)
+		if err != nil || (!u.IsAbs() || u.Host == "") && !strings.HasPrefix(cfg.ChangePasswordURL, "/") ||
+			strings.HasPrefix(cfg.ChangePasswordURL, "//") {
+			return nil, fmt.Errorf("%w: %q", ErrWellKnownChangePasswordURL, cfg.ChangePasswordURL)
+		}
+		h.changePassword = cfg.ChangePasswordURL
+	}
+
+	maxAge := cfg.CacheMaxAge
+	if maxAge == 0 {
+		maxAge = DefaultWellKnownCacheMaxAge
+	}
+	if maxAge > 0 {
+		h.cacheControl = "public, max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	}
+
+	return h, nil
+}
+
+// buildSecurityTxt validates st and renders its fields other than Expires.
+func (h *WellKnown) buildSecurityTxt(st *SecurityTxt) error {
+	if len(st.Contact) == 0 {
+		return ErrSecurityTxtNoContact
+	}
+	switch {
+	case st.Expires.IsZero() && st.ExpiresIn == 0:
+		return ErrSecurityTxtNoExpires
+	case !st.Expires.IsZero() && st.ExpiresIn != 0:
+		return ErrSecurityTxtExpiresConflict
+	case st.ExpiresIn < 0, !st.Expires.IsZero() && !st.Expires.After(h.now()):
+		return ErrSecurityTxtExpired
+	}
+	if !st.Expires.IsZero() {
+		h.expires = formatSecurityTxtTime(st.Expires)
+	}
+	h.expiresIn = st.ExpiresIn
+
+	var b strings.Builder
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"Contact", st.Contact},
+		{"Encryption", st.Encryption},
+		{"Acknowledgments", st.Acknowledgments},
+		{"Canonical", st.Canonical},
+		{"Policy", st.Policy},
+		{"Hiring", st.Hiring},
+	} {
+		for _, v := range field.values {
+			if !validSecurityTxtURI(v) {
+				return fmt.Errorf("%w: %s %q", ErrSecurityTxtInvalidValue, field.name, v)
+			}
+			b.WriteString(field.name + ": " + v + "\n")
+		}
+	}
+	if len(st.PreferredLanguages) > 0 {
+		for _, tag := range st.PreferredLanguages {
+			if !validLanguageTag(tag) {
+				return fmt.Errorf("%w: Preferred-Languages %q", ErrSecurityTxtInvalidValue, tag)
+			}
+		}
+		b.WriteString("Preferred-Languages: " + strings.Join(st.PreferredLanguages, ", ") + "\n")
+	}
+	h.securityTxt = b.String()
+	return nil
+}
+
+// securityTxtBody returns the security.txt file with Expires after the
+// Contact fields, as in the examples of RFC 9116.
+func (h *WellKnown) securityTxtBody() string {
+	expires := h.expires
+	if h.expiresIn > 0 {
+		expires = formatSecurityTxtTime(h.now().Add(h.expiresIn))
+	}
+	line := "Expires: " + expires + "\n"
+
+	// Contact is always first, so the field goes after its last line.
+	body := h.securityTxt
+	idx := 0
+	for strings.HasPrefix(body[idx:], "Contact: ") {
+		idx += strings.IndexByte(body[idx:], '\n') + 1
+	}
+	return body[:idx] + line + body[idx:]
+}
+
+// formatSecurityTxtTime formats t as the RFC 3339 timestamp RFC 9116
+// requires for Expires.
+func formatSecurityTxtTime(t time.Time) string {
+	return t.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// validSecurityTxtURI reports whether v is an absolute URI without
+// whitespace. Web URIs must use https (RFC 9116 Section 2.5).
+func validSecurityTxtURI(v string) bool {
+	if v == "" || strings.ContainsAny(v, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme == "" {
+		return false
+	}
+	return !strings.EqualFold(u.Scheme, "http")
+}
+
+// validLanguageTag reports whether tag has the shape of a BCP 47 language
+// tag: alphanumeric subtags separated by hyphens.
+func validLanguageTag(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for subtag := range strings.SplitSeq(tag, "-") {
+		if subtag == "" || len(subtag) > 8 {
+			return false
+		}
+		for i := 0; i < len(subtag); i++ {
+			c := subtag[i]
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// buildRobotsTxt validates rt and renders the robots.txt file.
+func buildRobotsTxt(rt *RobotsTxt) (string, error) {
+	if rt.Content != "" && len(rt.Groups) > 0 {
+		return "", ErrRobotsTxtConflict
+	}
+
+	var b strings.Builder
+	if rt.Content != "" {
+		b.WriteString(rt.Content)
+		if !strings.HasSuffix(rt.Content, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+
+	for i, group := range rt.Groups {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		agents := group.UserAgents
+		if len(agents) == 0 {
+			agents = []string{"*"}
+		}
+		for _, agent := range agents {
+			if !validRobotsUserAgent(agent) {
+				return "", fmt.Errorf("%w: user agent %q", ErrRobotsTxtInvalidRule, agent)
+			}
+			b.WriteString("User-agent: " + agent + "\n")
+		}
+		for _, rule := range []struct {
+			name  string
+			paths []string
+		}{
+			{"Allow", group.Allow},
+			{"Disallow", group.Disallow},
+		} {
+			for _, path := range rule.paths {
+				if !validRobotsPath(path) {
+					return "", fmt.Errorf("%w: %s %q", ErrRobotsTxtInvalidRule, rule.name, path)
+				}
+				b.WriteString(rule.name + ": " + path + "\n")
+			}
+		}
+		if len(group.Allow) == 0 && len(group.Disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+	}
+
+	for i, sitemap := range rt.Sitemaps {
+		u, err := url.Parse(sitemap)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") ||
+			strings.ContainsAny(sitemap, " \t\r\n") {
+			return "", fmt.Errorf("%w: Sitemap %q", ErrRobotsTxtInvalidRule, sitemap)
+		}
+		if i == 0 && b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("Sitemap: " + sitemap + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// validRobotsUserAgent reports whether agent is "*" or a product token of
+// letters, digits, hyphens, and underscores (RFC 9309 Section 2.2.1).
+func validRobotsUserAgent(agent string) bool {
+	if agent == "*" {
+		return true
+	}
+	if agent == "" {
+		return false
+	}
+	for i := 0; i < len(agent); i++ {
+		c := agent[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validRobotsPath reports whether path is a robots.txt path pattern: it
+// starts with "/" or "*" and has no whitespace or control characters.
+func validRobotsPath(path string) bool {
+	if !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "*") {
+		return false
+	}
+	for i := 0; i < len(path); i++ {
+		if c := path[i]; c <= ' ' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeHTTP serves the well-known resource for the request path.
+func (h *WellKnown) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body string
+	switch {
+	case r.URL.Path == SecurityTxtPath && h.securityTxt != "":
+	case r.URL.Path == RobotsTxtPath && h.robotsTxt != "":
+		body = h.robotsTxt
+	case r.URL.Path == ChangePasswordPath && h.changePassword != "":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.cacheControl)
+	}
+
+	switch r.URL.Path {
+	case ChangePasswordPath:
+		http.Redirect(w, r, h.changePassword, http.StatusFound)
+		return
+	case SecurityTxtPath:
+		body = h.securityTxtBody()
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+// Mount registers the handler on r for GET and HEAD at the canonical path
+// of each configured resource.
+//
+//	wk, err := muxhandlers.WellKnownHandler(cfg)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	wk.Mount(r)
+func (h *WellKnown) Mount(r *mux.Router) {
+	for _, path := range h.paths() {
+		r.Handle(path, h).Methods(http.MethodGet, http.MethodHead)
+	}
+}
+
+// paths returns the canonical paths of the configured resources.
+func (h *WellKnown) paths() []string {
+	var paths []string
+	if h.securityTxt != "" {
+		paths = append(paths, SecurityTxtPath)
+	}
+	if h.robotsTxt != "" {
+		paths = append(paths, RobotsTxtPath)
+	}
+	if h.changePassword != "" {
+		paths = append(paths, ChangePasswordPath)
+	}
+	return paths
+}
@@ -0,0 +1,245 @@
+package muxhandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+func TestWellKnownHandler(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 30, 45, 500, time.UTC)
+	clock := func() time.Time { return now }
+
+	serve := func(h http.Handler, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	t.Run("security.txt with expires in", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{
+			SecurityTxt: &SecurityTxt{
+				Contact:            []string{"mailto:security@example.com", "https://example.com/security"},
+				ExpiresIn:          180 * 24 * time.Hour,
+				Encryption:         []string{"https://example.com/pgp-key.txt"},
+				Canonical:          []string{"https://example.com/.well-known/security.txt"},
+				PreferredLanguages: []string{"en", "de-CH"},
+				Policy:             []string{"https://example.com/disclosure"},
+			},
+			now: clock,
+		})
+		require.NoError(t, err)
+
+		w := serve(h, http.MethodGet, SecurityTxtPath)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Contact: mailto:security@example.com\n"+
+			"Contact: https://example.com/security\n"+
+			"Expires: 2026-08-28T12:30:45Z\n"+
+			"Encryption: https://example.com/pgp-key.txt\n"+
+			"Canonical: https://example.com/.well-known/security.txt\n"+
+			"Policy: https://example.com/disclosure\n"+
+			"Preferred-Languages: en, de-CH\n", w.Body.String())
+
+		now = now.Add(24 * time.Hour)
+		defer func() { now = now.Add(-24 * time.Hour) }()
+		assert.Contains(t, serve(h, http.MethodGet, SecurityTxtPath).Body.String(), "Expires: 2026-08-29T12:30:45Z\n")
+	})
+
+	t.Run("security.txt with fixed expires", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{
+			SecurityTxt: &SecurityTxt{
+				Contact: []string{"tel:+1-201-555-0123"},
+				Expires: time.Date(2027, 1, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600)),
+			},
+			now: clock,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Contact: tel:+1-201-555-0123\nExpires: 2026-12-31T23:00:00Z\n",
+			serve(h, http.MethodGet, SecurityTxtPath).Body.String())
+	})
+
+	t.Run("robots.txt from content", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{
+			RobotsTxt: &RobotsTxt{Content: "User-agent: *\nDisallow: /admin"},
+		})
+		require.NoError(t, err)
+
+		w := serve(h, http.MethodGet, RobotsTxtPath)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "User-agent: *\nDisallow: /admin\n", w.Body.String())
+	})
+
+	t.Run("robots.txt from groups", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{
+			RobotsTxt: &RobotsTxt{
+				Groups: []RobotsGroup{
+					{Allow: []string{"/public"}, Disallow: []string{"/admin", "/*.json$"}},
+					{UserAgents: []string{"GPTBot", "CCBot"}, Disallow: []string{"/"}},
+					{UserAgents: []string{"Googlebot"}},
+				},
+				Sitemaps: []string{"https://example.com/sitemap.xml"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "User-agent: *\n"+
+			"Allow: /public\n"+
+			"Disallow: /admin\n"+
+			"Disallow: /*.json$\n"+
+			"\n"+
+			"User-agent: GPTBot\n"+
+			"User-agent: CCBot\n"+
+			"Disallow: /\n"+
+			"\n"+
+			"User-agent: Googlebot\n"+
+			"Disallow:\n"+
+			"\n"+
+			"Sitemap: https://example.com/sitemap.xml\n", serve(h, http.MethodGet, RobotsTxtPath).Body.String())
+	})
+
+	t.Run("change password redirect", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{ChangePasswordURL: "/account/password"})
+		require.NoError(t, err)
+
+		w := serve(h, http.MethodGet, ChangePasswordPath)
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "/account/password", w.Header().Get("Location"))
+		assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("head", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{RobotsTxt: &RobotsTxt{Content: "User-agent: *\nDisallow:\n"}})
+		require.NoError(t, err)
+
+		w := serve(h, http.MethodHead, RobotsTxtPath)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "24", w.Header().Get("Content-Length"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{RobotsTxt: &RobotsTxt{Content: "User-agent: *"}})
+		require.NoError(t, err)
+
+		w := serve(h, http.MethodPost, RobotsTxtPath)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+	})
+
+	t.Run("unconfigured path", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{RobotsTxt: &RobotsTxt{Content: "User-agent: *"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, serve(h, http.MethodGet, SecurityTxtPath).Code)
+		assert.Equal(t, http.StatusNotFound, serve(h, http.MethodGet, ChangePasswordPath).Code)
+	})
+
+	t.Run("cache max age", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{
+			RobotsTxt:   &RobotsTxt{Content: "User-agent: *"},
+			CacheMaxAge: time.Hour,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "public, max-age=3600", serve(h, http.MethodGet, RobotsTxtPath).Header().Get("Cache-Control"))
+
+		h, err = WellKnownHandler(WellKnownConfig{
+			RobotsTxt:   &RobotsTxt{Content: "User-agent: *"},
+			CacheMaxAge: -1,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, serve(h, http.MethodGet, RobotsTxtPath).Header().Get("Cache-Control"))
+	})
+
+	t.Run("mount", func(t *testing.T) {
+		h, err := WellKnownHandler(WellKnownConfig{
+			SecurityTxt:       &SecurityTxt{Contact: []string{"mailto:security@example.com"}, ExpiresIn: time.Hour},
+			RobotsTxt:         &RobotsTxt{Content: "User-agent: *"},
+			ChangePasswordURL: "https://accounts.example.com/password",
+			now:               clock,
+		})
+		require.NoError(t, err)
+
+		r := mux.NewRouter()
+		h.Mount(r)
+
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, SecurityTxtPath).Code)
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodHead, RobotsTxtPath).Code)
+		assert.Equal(t, http.StatusFound, serve(r, http.MethodGet, ChangePasswordPath).Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(r, http.MethodPost, RobotsTxtPath).Code)
+		assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/.well-known/other").Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name string
+			cfg  WellKnownConfig
+			err  error
+		}{
+			{"empty", WellKnownConfig{}, ErrWellKnownEmpty},
+			{"no contact", WellKnownConfig{SecurityTxt: &SecurityTxt{ExpiresIn: time.Hour}}, ErrSecurityTxtNoContact},
+			{"no expires", WellKnownConfig{SecurityTxt: &SecurityTxt{Contact: []string{"mailto:a@example.com"}}}, ErrSecurityTxtNoExpires},
+			{"expires conflict", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"mailto:a@example.com"}, Expires: now.Add(time.Hour), ExpiresIn: time.Hour,
+			}, now: clock}, ErrSecurityTxtExpiresConflict},
+			{"expired", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"mailto:a@example.com"}, Expires: now.Add(-time.Hour),
+			}, now: clock}, ErrSecurityTxtExpired},
+			{"negative expires in", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"mailto:a@example.com"}, ExpiresIn: -time.Hour,
+			}}, ErrSecurityTxtExpired},
+			{"http contact", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"http://example.com/security"}, ExpiresIn: time.Hour,
+			}}, ErrSecurityTxtInvalidValue},
+			{"relative canonical", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"mailto:a@example.com"}, ExpiresIn: time.Hour, Canonical: []string{"/security.txt"},
+			}}, ErrSecurityTxtInvalidValue},
+			{"newline in value", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"mailto:a@example.com\nHiring: https://evil.example"}, ExpiresIn: time.Hour,
+			}}, ErrSecurityTxtInvalidValue},
+			{"language tag", WellKnownConfig{SecurityTxt: &SecurityTxt{
+				Contact: []string{"mailto:a@example.com"}, ExpiresIn: time.Hour, PreferredLanguages: []string{"en us"},
+			}}, ErrSecurityTxtInvalidValue},
+			{"robots conflict", WellKnownConfig{RobotsTxt: &RobotsTxt{
+				Content: "User-agent: *", Groups: []RobotsGroup{{}},
+			}}, ErrRobotsTxtConflict},
+			{"robots relative path", WellKnownConfig{RobotsTxt: &RobotsTxt{
+				Groups: []RobotsGroup{{Disallow: []string{"admin"}}},
+			}}, ErrRobotsTxtInvalidRule},
+			{"robots newline in path", WellKnownConfig{RobotsTxt: &RobotsTxt{
+				Groups: []RobotsGroup{{Disallow: []string{"/a\nAllow: /"}}},
+			}}, ErrRobotsTxtInvalidRule},
+			{"robots user agent", WellKnownConfig{RobotsTxt: &RobotsTxt{
+				Groups: []RobotsGroup{{UserAgents: []string{"Mozilla/5.0"}}},
+			}}, ErrRobotsTxtInvalidRule},
+			{"robots sitemap", WellKnownConfig{RobotsTxt: &RobotsTxt{
+				Sitemaps: []string{"/sitemap.xml"},
+			}}, ErrRobotsTxtInvalidRule},
+			{"change password relative", WellKnownConfig{ChangePasswordURL: "account/password"}, ErrWellKnownChangePasswordURL},
+			{"change password protocol relative", WellKnownConfig{ChangePasswordURL: "//evil.example/password"}, ErrWellKnownChangePasswordURL},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				h, err := WellKnownHandler(tt.cfg)
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, h)
+			})
+		}
+	})
+}
+
+func TestValidLanguageTag(t *testing.T) {
+	assert.True(t, validLanguageTag("en"))
+	assert.True(t, validLanguageTag("zh-Hant-TW"))
+	assert.False(t, validLanguageTag(""))
+	assert.False(t, validLanguageTag("en-"))
+	assert.False(t, validLanguageTag("en_US"))
+	assert.False(t, validLanguageTag("abcdefghi"))
+}
@@ -0,0 +1,121 @@
+package openapi
+
+import "reflect"
+
+// typeDocs holds long-form descriptions registered for Go types and their
+// fields. Registered descriptions take precedence over `openapi` struct tag
+// descriptions, and may contain commas and Markdown that are awkward to
+// express in a tag. It also holds enum values registered with RegisterEnum.
+type typeDocs struct {
+	types  map[reflect.Type]string
+	fields map[reflect.Type]map[string]string
+	enums  map[reflect.Type]typeEnumValues
+}
+
+// describeType records a description for the type of value.
+func (d *typeDocs) describeType(value any, description string) {
+	t := derefType(reflect.TypeOf(value))
+	if t == nil {
+		return
+	}
+	if d.types == nil {
+		d.types = make(map[reflect.Type]string)
+	}
+	d.types[t] = description
+}
+
+// describeField records a description for a field of the struct type of
+// value, identified by its JSON property name.
+func (d *typeDocs) describeField(value any, jsonFieldName, description string) {
+	t := derefType(reflect.TypeOf(value))
+	if t == nil {
+		return
+	}
+	if d.fields == nil {
+		d.fields = make(map[reflect.Type]map[string]string)
+	}
+	if d.fields[t] == nil {
+		d.fields[t] = make(map[string]string)
+	}
+	d.fields[t][jsonFieldName] = description
+}
+
+// typeDescription returns the description registered for t, if any.
+func (d *typeDocs) typeDescription(t reflect.Type) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	desc, ok := d.types[t]
+	return desc, ok
+}
+
+// fieldDescription returns the description registered for the named field,
+// looking at each of the given struct types in order.
+func (d *typeDocs) fieldDescription(jsonFieldName string, types ...reflect.Type) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	for _, t := range types {
+		if desc, ok := d.fields[t][jsonFieldName]; ok {
+			return desc, true
+		}
+	}
+	return "", false
+}
+
+// derefType unwraps pointer types. It returns nil for a nil type.
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// DescribeType registers a description for the schema of value's type,
+// overriding any description from struct tags. For named struct types the
+// description is set on the component schema. Register descriptions before
+// generating schemas that use the type.
+//
+//	const userDoc = `A registered user.
+//
+//	Users are created by **admins**, and can be suspended.`
+//
+//	gen.DescribeType(User{}, userDoc)
+//
+// See: https://spec.openapis.org/oas/v3.1.0#schema-object (description)
+func (g *SchemaGenerator) DescribeType(value any, description string) *SchemaGenerator {
+	g.docs.describeType(value, description)
+	return g
+}
+
+// DescribeField registers a description for the property with the given
+// JSON name on value's struct type, overriding the `openapi` tag
+// description. Fields promoted from embedded structs can be registered on
+// either the outer or the embedded type.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#schema-object (description)
+func (g *SchemaGenerator) DescribeField(value any, jsonFieldName, description string) *SchemaGenerator {
+	g.docs.describeField(value, jsonFieldName, description)
+	return g
+}
+
+// DescribeType registers a description for the schema of value's type. It
+// overrides descriptions from struct tags and is applied when Build
+// generates schemas. See SchemaGenerator.DescribeType.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#schema-object (description)
+func (s *Spec) DescribeType(value any, description string) *Spec {
+	s.docs.describeType(value, description)
+	return s
+}
+
+// DescribeField registers a description for the property with the given
+// JSON name on value's struct type. It overrides the `openapi` tag
+// description and is applied when Build generates schemas. See
+// SchemaGenerator.DescribeField.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#schema-object (description)
+func (s *Spec) DescribeField(value any, jsonFieldName, description string) *Spec {
+	s.docs.describeField(value, jsonFieldName, description)
+	return s
+}
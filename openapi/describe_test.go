@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+type DescribedAudit struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type describedStatus string
+
+type describedAccount struct {
+	DescribedAudit
+	ID     string          `json:"id" openapi:"description=Tag ID"`
+	Name   string          `json:"name"`
+	Status describedStatus `json:"status,omitempty"`
+	Legacy describedStatus `json:"legacy,omitempty" openapi:"description=Legacy status"`
+	Owner  *describedOwner `json:"owner,omitempty"`
+}
+
+type describedOwner struct {
+	Email string `json:"email"`
+}
+
+const describedAccountDoc = "An account.\n\nAccounts are **billed** monthly, pro-rated, in USD."
+
+func TestSchemaGeneratorDescribe(t *testing.T) {
+	t.Run("type description on component schema", func(t *testing.T) {
+		gen := NewSchemaGenerator().DescribeType(describedAccount{}, describedAccountDoc)
+		gen.Generate(describedAccount{})
+
+		assert.Equal(t, describedAccountDoc, gen.Schemas()["describedAccount"].Description)
+	})
+
+	t.Run("pointer values register the element type", func(t *testing.T) {
+		gen := NewSchemaGenerator().
+			DescribeType(&describedOwner{}, "The owner").
+			DescribeField(&describedOwner{}, "email", "Contact, primary")
+		gen.Generate(describedAccount{})
+
+		owner := gen.Schemas()["describedOwner"]
+		require.NotNil(t, owner)
+		assert.Equal(t, "The owner", owner.Description)
+		assert.Equal(t, "Contact, primary", owner.Properties["email"].Description)
+	})
+
+	t.Run("field description overrides tag", func(t *testing.T) {
+		gen := NewSchemaGenerator().
+			DescribeField(describedAccount{}, "id", "Registered ID")
+		gen.Generate(describedAccount{})
+
+		props := gen.Schemas()["describedAccount"].Properties
+		assert.Equal(t, "Registered ID", props["id"].Description)
+		assert.Empty(t, props["name"].Description)
+	})
+
+	t.Run("field description on reference property", func(t *testing.T) {
+		gen := NewSchemaGenerator().
+			DescribeField(describedAccount{}, "owner", "Who owns the account")
+		gen.Generate(describedAccount{})
+
+		owner := gen.Schemas()["describedAccount"].Properties["owner"]
+		assert.Equal(t, "Who owns the account", owner.Description)
+		assert.Len(t, owner.AnyOf, 2)
+	})
+
+	t.Run("promoted field on outer or embedded type", func(t *testing.T) {
+		outer := NewSchemaGenerator().
+			DescribeField(describedAccount{}, "createdBy", "Outer")
+		outer.Generate(describedAccount{})
+		assert.Equal(t, "Outer", outer.Schemas()["describedAccount"].Properties["createdBy"].Description)
+
+		embedded := NewSchemaGenerator().
+			DescribeField(DescribedAudit{}, "createdBy", "Embedded")
+		embedded.Generate(describedAccount{})
+		assert.Equal(t, "Embedded", embedded.Schemas()["describedAccount"].Properties["createdBy"].Description)
+	})
+
+	t.Run("named non-struct type", func(t *testing.T) {
+		gen := NewSchemaGenerator().
+			DescribeType(describedStatus(""), "Lifecycle status")
+		gen.Generate(describedAccount{})
+
+		props := gen.Schemas()["describedAccount"].Properties
+		assert.Equal(t, "Lifecycle status", props["status"].Description)
+		// A field tag is more specific than the type description.
+		assert.Equal(t, "Legacy status", props["legacy"].Description)
+	})
+
+	t.Run("unnamed types are not described", func(t *testing.T) {
+		gen := NewSchemaGenerator().DescribeType("", "Any string")
+		schema := gen.Generate(describedAccount{})
+		require.NotNil(t, schema)
+
+		assert.Empty(t, gen.Schemas()["describedAccount"].Properties["name"].Description)
+	})
+
+	t.Run("nil value is ignored", func(t *testing.T) {
+		gen := NewSchemaGenerator().DescribeType(nil, "x").DescribeField(nil, "id", "x")
+		gen.Generate(describedAccount{})
+
+		assert.Equal(t, "Tag ID", gen.Schemas()["describedAccount"].Properties["id"].Description)
+	})
+}
+
+func TestSpecDescribe(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/accounts", dummyHandler).Methods(http.MethodGet).Name("listAccounts")
+
+	spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+		DescribeType(describedAccount{}, describedAccountDoc).
+		DescribeField(describedAccount{}, "id", "Account ID, immutable")
+	spec.Op("listAccounts").Response(http.StatusOK, []describedAccount{})
+
+	doc := spec.Build(r)
+	require.NotNil(t, doc.Components)
+	account := doc.Components.Schemas["describedAccount"]
+	require.NotNil(t, account)
+	assert.Equal(t, describedAccountDoc, account.Description)
+	assert.Equal(t, "Account ID, immutable", account.Properties["id"].Description)
+}
+
+func TestOpenAPITagEscapedComma(t *testing.T) {
+	type input struct {
+		Name string `json:"name" openapi:"description=Full name\\, as printed,example=Ada"`
+	}
+
+	gen := NewSchemaGenerator()
+	gen.Generate(input{})
+
+	var name *Schema
+	for _, s := range gen.Schemas() {
+		name = s.Properties["name"]
+	}
+	require.NotNil(t, name)
+	assert.Equal(t, "Full name, as printed", name.Description)
+	assert.Equal(t, "Ada", name.Example)
+}
+
+func TestSplitTagParts(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		expected []string
+	}{
+		{"plain", "description=a,example=b", []string{"description=a", "example=b"}},
+		{"escaped comma", `description=a\, b,example=c`, []string{"description=a, b", "example=c"}},
+		{"trailing escaped comma", `description=a\,`, []string{"description=a,"}},
+		{"lone backslash kept", `pattern=^\d+$`, []string{`pattern=^\d+$`}},
+		{"empty parts", `a,,b\,c`, []string{"a", "", "b,c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitTagParts(tt.tag))
+		})
+	}
+}
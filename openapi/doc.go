@@ -100,6 +100,15 @@
 //   - Responses: merge (group responses + operation responses; operation overrides per status code)
 //   - ExternalDocs: replace (operation-level ExternalDocs call overrides group value)
 //
+// Headers common to all operations, such as X-Request-ID, can be declared
+// once with Spec.GlobalResponseHeader. They are added to every response of
+// every route operation unless the operation or its group documents a
+// header of the same name:
+//
+//	spec.GlobalResponseHeader("X-Request-ID", &openapi.Header{
+//	    Schema: &openapi.Schema{Type: openapi.SchemaTypeString},
+//	})
+//
 // Groups also support Op for named routes:
 //
 //	users.Op("listUsers").Summary("List users")
@@ -130,12 +139,21 @@
 //	})
 //	spec.SetSecurity(openapi.SecurityRequirement{"bearerAuth": {}})
 //
+// AddCookieAuth registers an apiKey scheme read from a cookie, for browser
+// sessions:
+//
+//	spec.AddCookieAuth("sessionAuth", "session")
+//
 // Override security per operation (empty Security() marks an endpoint as public):
 //
 //	spec.Route(r.HandleFunc("/health", healthHandler).Methods(http.MethodGet)).
 //	    Summary("Health check").
 //	    Security()
 //
+// ApplySecurityToOperations(true) repeats the document-level requirements
+// on every route operation without its own, for tools that ignore the
+// document-level default. Public endpoints keep their empty list.
+//
 // # External Documentation
 //
 // Attach external docs at the document level:
@@ -161,6 +179,21 @@
 // User-defined tags take precedence over auto-collected tags. Tags defined
 // via AddTag but not used by any operation are still included in the output.
 //
+// AutoTagByPrefix tags operations without explicit tags by path prefix,
+// the longest matching prefix winning; with nil rules the first path
+// segment becomes the tag:
+//
+//	spec.AutoTagByPrefix(map[string]string{"/users": "users", "/orders": "orders"})
+//
+// AddTagGroup organizes tags into named sections, emitted as the
+// x-tagGroups extension, and SetTagOrder lists tags to place first in the
+// tags list; unlisted tags follow alphabetically. Document.Warnings reports
+// tag groups that reference tags missing from the document:
+//
+//	spec.AddTagGroup("Accounts", "users", "sessions").
+//	    SetTagOrder("users", "orders")
+//	warnings := spec.Build(r).Warnings()
+//
 // # Reusable Components
 //
 // Register reusable objects in components:
@@ -208,6 +241,14 @@
 //	    },
 //	})
 //
+// DeriveServersFromRoutes adds an operation-level server for routes with a
+// host constraint, built from the host template and schemes. Host variables
+// become server variables:
+//
+//	spec.DeriveServersFromRoutes(true)
+//	spec.Route(r.HandleFunc("/users", listUsers).Host("{tenant}.example.com").Schemes("https"))
+//	// servers: [{url: "https://{tenant}.example.com", variables: {tenant: ...}}]
+//
 // # Media Types
 //
 // Request and Response are JSON shortcuts. Use RequestContent and
@@ -239,6 +280,32 @@
 //
 // Pass a *Schema directly for explicit schema control (binary, text, etc.)
 // or a Go type for automatic schema generation via reflection.
+// Structured syntax suffix types such as application/vnd.api+json and
+// application/merge-patch+json get reflected schemas from json tags, the
+// same as application/json.
+//
+// # Server-Sent Events
+//
+// ResponseSSE documents a text/event-stream response. OpenAPI cannot
+// describe individual events, so the media type schema is a string and the
+// schema of each event's data is generated from the given type and placed
+// under the x-sse-event-schema extension of the media type:
+//
+//	spec.Route(r.HandleFunc("/orders/events", stream).Methods(http.MethodGet)).
+//	    ResponseSSE(http.StatusOK, OrderEvent{})
+//
+// # Multipart Responses
+//
+// ResponseMultipart documents a multipart/mixed response. Each named part
+// becomes a property of the media type schema, and its content type, from
+// MultipartPart or derived from the part's type, is recorded in the
+// encoding map:
+//
+//	spec.Route(r.HandleFunc("/exports/{id}", export).Methods(http.MethodGet)).
+//	    ResponseMultipart(http.StatusOK, map[string]any{
+//	        "manifest": Manifest{},
+//	        "archive":  openapi.MultipartPart{ContentType: "application/zip"},
+//	    })
 //
 // # Request Body Metadata
 //
@@ -287,6 +354,30 @@
 //	    OperationID("listAllUsers").
 //	    Summary("List users")
 //
+// AutoOperationIDs derives an operationId from the method and path for
+// route operations without a name or explicit ID, e.g. "getUsersId" for
+// GET /users/{id}. Collisions are resolved by appending a number.
+//
+// # Route Summaries
+//
+// mux.Route.Doc stores a summary and description on the route itself.
+// Build uses them when the builder sets neither, so builder calls win over
+// the route and the route wins over generated docs:
+//
+//	spec.Route(r.HandleFunc("/users", listUsers).Methods(http.MethodGet).
+//	    Doc("List users", "Returns users ordered by creation time."))
+//
+// # Generated Summaries
+//
+// The openapi/gen command extracts summaries and descriptions from handler
+// doc comments into a map that is registered with ApplyGenerated. Entries
+// are matched by operationId or by handler symbol qualified with its import
+// path, and never override values set explicitly:
+//
+//	//go:generate go run github.com/vitalvas/kasper/openapi/gen -output openapi_docs_gen.go
+//
+//	spec.ApplyGenerated(openAPIDocs)
+//
 // # Response Descriptions
 //
 // Response descriptions are auto-generated from HTTP status text. Override
@@ -298,6 +389,20 @@
 //	    DefaultResponse(ErrorResponse{}).
 //	    DefaultResponseDescription("Unexpected error")
 //
+// # Deprecation and Sunset
+//
+// DeprecatedSince marks an operation as deprecated and records the date;
+// Sunset records when the operation stops responding, with an optional
+// migration link:
+//
+//	spec.Op("getUserV1").
+//	    DeprecatedSince(since).
+//	    Sunset(sunset, "https://docs.example.com/migrate-v2")
+//
+// The dates are emitted as the x-deprecated-since and x-sunset operation
+// extensions, and every response documents Deprecation and Sunset headers
+// unless ResponseHeader registers one under the same name.
+//
 // # Webhooks
 //
 // Webhooks describe API-initiated callbacks not tied to a specific path
@@ -320,6 +425,14 @@
 //	cb := openapi.Callback{"{$request.body#/callbackUrl}": &openapi.PathItem{...}}
 //	spec.Op("subscribe").Callback("onEvent", &cb)
 //
+// CallbackOp describes one callback operation with the usual builder
+// methods, merging it into any raw Callback registered under the same name:
+//
+//	spec.Op("subscribe").
+//	    CallbackOp("onEvent", "{$request.body#/callback_url}", http.MethodPost).
+//	    Request(Event{}).
+//	    Response(http.StatusNoContent, nil)
+//
 // # Struct Tags
 //
 // Use the "openapi" struct tag to enrich JSON Schema output:
@@ -334,7 +447,28 @@
 // Supported tag keys: description, example, format, title, minimum, maximum,
 // exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern,
 // multipleOf, minItems, maxItems, uniqueItems, minProperties, maxProperties,
-// const, enum (pipe-separated), deprecated, readOnly, writeOnly.
+// const, default, enum (pipe-separated), enumVarNames, enumDescriptions,
+// deprecated, deprecatedMessage, readOnly, writeOnly. deprecatedMessage
+// marks the field as deprecated and emits its value as the
+// x-deprecated-message extension, a migration hint for generators.
+// enumVarNames and enumDescriptions label the enum values, pipe-separated
+// in the same order, as the x-enum-varnames and x-enum-descriptions
+// extensions.
+// Values of example, const, and default are parsed according to the field
+// type, so `openapi:"default=20"` on an int field yields the number 20.
+// Escape commas inside values with a backslash (`\\,` in tag source).
+//
+// readOnly and writeOnly fields are never listed as required, because the
+// component schema of a type is shared by requests and responses.
+// Spec.SplitReadWriteSchemas generates "<Name>Request" and "<Name>Response"
+// variants instead, omitting readOnly fields from request bodies and
+// writeOnly fields from responses, for types that have such fields.
+//
+// Long or Markdown descriptions can be registered in code instead, and
+// override tag descriptions:
+//
+//	spec.DescribeType(User{}, userDoc)
+//	spec.DescribeField(User{}, "email", "Primary contact address, verified on signup.")
 //
 // # Path Parameter Typing
 //
@@ -356,6 +490,7 @@
 //   - string -> {type: "string"}
 //   - []byte -> {type: "string", format: "byte"}
 //   - time.Time -> {type: "string", format: "date-time"}
+//   - json.RawMessage and other json.Marshaler types -> {} (any JSON value)
 //   - *T -> nullable type using type arrays (e.g., ["string", "null"])
 //   - []T -> {type: "array", items: schema(T)}
 //   - map[string]V -> {type: "object", additionalProperties: schema(V)}
@@ -364,6 +499,30 @@
 // Named struct types are deduplicated into #/components/schemas/{TypeName}
 // and referenced via $ref.
 //
+// # Enum Types
+//
+// Named non-struct types list their allowed values once, either registered
+// or through the Enumer interface:
+//
+//	spec.RegisterEnum(RoleAdmin, RoleEditor, RoleViewer)
+//
+//	func (Role) OpenAPIEnum() []any {
+//	    return []any{RoleAdmin, RoleEditor, RoleViewer}
+//	}
+//
+// The enum appears wherever the type is used, including slice items, map
+// values and keys, and pointers. Registrations take precedence over
+// Enumer, and an `openapi:"enum=..."` field tag overrides both.
+//
+// Wrap values in EnumValue to give client generators constant names and
+// descriptions, emitted as the x-enum-varnames and x-enum-descriptions
+// extensions alongside the enum:
+//
+//	spec.RegisterEnum(
+//	    openapi.EnumValue{Value: RoleAdmin, Name: "Admin", Description: "Full access"},
+//	    openapi.EnumValue{Value: RoleUser, Name: "User", Description: "Own resources only"},
+//	)
+//
 // # Type-Level Examples
 //
 // Implement the Exampler interface to provide a complete example value
@@ -376,6 +535,34 @@
 // The returned value is serialized as the "example" field on the component
 // schema. This works alongside field-level examples set via struct tags.
 //
+// # Example Validation
+//
+// Spec.ValidateExamples checks every example in the built document against
+// its schema: types, required properties, enum and const values, and the
+// date-time, date, uuid, and email formats. The mode selects where
+// violations go; ExampleValidationPartial reports missing required
+// properties as warnings, for intentionally partial examples, and the rest
+// as BuildE errors wrapping ErrInvalidExample:
+//
+//	spec.ValidateExamples(openapi.ExampleValidationPartial)
+//	doc, err := spec.BuildE(r)
+//	// doc.Warnings(): example #/components/schemas/User/example: missing required property "name"
+//
+// Document.ValidateExamples returns the violations of any document as
+// ExampleViolation values naming the example location and a JSON pointer
+// into the example.
+//
+// # Type-Level Schemas
+//
+// Implement the SchemaProvider interface when a type's JSON encoding does
+// not follow its fields, for example because of a custom MarshalJSON. The
+// returned schema replaces reflection entirely and is still stored as a
+// component schema under the type name:
+//
+//	func (Money) OpenAPISchema() *openapi.Schema {
+//	    return &openapi.Schema{Type: openapi.SchemaTypeString, Pattern: `^\d+\.\d{2} [A-Z]{3}$`}
+//	}
+//
 // # Generic Response Wrappers
 //
 // Go generics work naturally with the schema generator. Each concrete
@@ -393,6 +580,39 @@
 //	spec.Op("listUsers").Response(http.StatusOK, ResponseData[[]User]{})
 //	// → schema "ResponseDataUserList" with Result typed as array of $ref User
 //
+// # Response Envelopes
+//
+// ResponseEnvelope wraps every 2xx JSON response body of the spec's route
+// operations in a generic envelope instantiated with the Payload marker.
+// The wrapped body gets the same component as the hand-written
+// instantiation, so existing documents are unchanged:
+//
+//	spec.ResponseEnvelope(ResponseData[openapi.Payload]{})
+//	spec.Op("getUser").Response(http.StatusOK, User{})
+//	// → schema "ResponseDataUser", as with ResponseData[User]{}
+//
+// Error, binary, and event stream responses are not wrapped. A RouteGroup
+// can set its own envelope, and NoEnvelope opts a single operation out:
+//
+//	spec.Op("download").NoEnvelope().
+//	    ResponseContent(http.StatusOK, "application/octet-stream", nil)
+//
+// # Paginated Lists
+//
+// Paginated describes the standard list envelope {items, next_cursor,
+// total} around an item type. The envelope is registered as a component
+// named the way a generic Paginated[User] would be:
+//
+//	spec.Op("listUsers").Response(http.StatusOK, openapi.Paginated(User{}))
+//	// → schema "PaginatedUser" with items typed as array of $ref User
+//
+// The Paginated builder method also adds the cursor and limit query
+// parameters, and with WithNextCursorHeader the X-Next-Cursor response
+// header:
+//
+//	spec.Route(r.HandleFunc("/users", listUsers).Methods(http.MethodGet)).
+//	    Paginated(User{}, openapi.WithMaxLimit(100), openapi.WithNextCursorHeader())
+//
 // # Custom Schema Names
 //
 // Implement the Namer interface to override the default component schema
@@ -420,6 +640,35 @@
 //	jsonBytes, _ := doc.JSON()
 //	yamlBytes, _ := doc.YAML()
 //
+// # Standalone JSON Schemas
+//
+// SchemaFor generates a self-contained JSON Schema (draft 2020-12) for a
+// single type, with the component schemas it depends on bundled under
+// $defs and references rewritten to "#/$defs/{name}". Spec.ExportSchemas
+// returns the spec's component schemas (those used by registered operations
+// and webhooks) in the same form:
+//
+//	root, defs := openapi.SchemaFor(OrderCreated{},
+//	    openapi.WithSchemaID("https://example.com/schemas/order-created.json"),
+//	    openapi.WithSchemaDescriptions(spec))
+//
+//	schemas, err := spec.ExportSchemas("OrderCreated", "OrderShipped")
+//
+// # Versioned Documents
+//
+// Scope derives a spec limited to the operations under a path prefix, so
+// several API versions served by one router get separate documents:
+//
+//	v1 := spec.Scope("/api/v1", openapi.Info{Title: "API", Version: "1.0.0"})
+//	v2 := spec.Scope("/api/v2", openapi.Info{Title: "API", Version: "2.0.0"}).
+//	    StripScopePrefix()
+//	v1.Handle(r, "/swagger/v1", nil)
+//	v2.Handle(r, "/swagger/v2", nil)
+//
+// Operations and components are read from the parent at build time; servers,
+// tags, security, and external docs are inherited unless set on the scoped
+// spec. StripScopePrefix moves the prefix from the paths into the server URLs.
+//
 // # Parsing Documents
 //
 // Use DocumentFromJSON or DocumentFromYAML to parse existing OpenAPI
@@ -470,6 +719,14 @@
 //	// /swagger/              -> docs UI pointing to /api/v1/swagger.json
 //	// /api/v1/swagger.json   -> JSON spec
 //
+// SingleEndpoint adds one path serving both formats, chosen by the Accept
+// header: JSON by default, YAML for application/yaml, application/x-yaml,
+// or text/yaml, and 406 Not Acceptable when neither is accepted. Each
+// representation keeps its own ETag:
+//
+//	spec.Handle(r, "/swagger", &openapi.HandleConfig{SingleEndpoint: "schema"})
+//	// /swagger/schema -> JSON or YAML, with Vary: Accept
+//
 // Choose the docs UI via HandleConfig:
 //
 //	openapi.DocsSwaggerUI (default)
@@ -496,6 +753,19 @@
 //	    },
 //	})
 //
+// HandleConfig.Middleware wraps only the routes registered by Handle, for
+// example to put BasicAuth in front of internal docs. DocumentSelf adds the
+// JSON and YAML spec endpoints to the document as GET operations tagged
+// "documentation", public unless DocumentSelfSecurity is set:
+//
+//	spec.Handle(r, "/swagger", &openapi.HandleConfig{
+//	    DocumentSelf: true,
+//	    Middleware:   []mux.MiddlewareFunc{basicAuth},
+//	})
+//
+// HandleConfig.Authorize gates the same routes with a predicate; requests it
+// rejects get 403 Forbidden.
+//
 // # Building the Document
 //
 // Build walks the mux router and assembles a complete *Document. This is
@@ -504,6 +774,12 @@
 //	doc := spec.Build(r)
 //	data, _ := json.MarshalIndent(doc, "", "  ")
 //
+// BuildE also returns the mux registration errors of routes that carry
+// OpenAPI metadata, which Build leaves out of the document. Each error
+// wraps ErrInvalidRoute:
+//
+//	doc, err := spec.BuildE(r)
+//
 // # Exporting to JSON or YAML
 //
 // Any *Document (from Build, SchemaGenerator.Document, DocumentFromJSON,
@@ -526,6 +802,29 @@
 //	merged, _ := openapi.MergeDocuments(info, usersDoc, billingDoc)
 //	data, _ := merged.YAML()
 //
+// # OpenAPI 3.0 Output
+//
+// TargetVersion converts the built document to OpenAPI 3.0.x for tooling
+// that does not read 3.1. Type arrays with null become nullable, numeric
+// exclusive bounds become the boolean form, const becomes a single-value
+// enum, and webhooks are emitted as x-webhooks. Features 3.0 cannot
+// express are reported by Document.Warnings:
+//
+//	spec.TargetVersion("3.0.3")
+//	doc, err := spec.BuildE(r)
+//	for _, w := range doc.Warnings() {
+//	    log.Print(w)
+//	}
+//
+// # Splitting by Tag
+//
+// BuildSplit returns the root document and a map of file name to document:
+// the paths of each tag live in a file named after the tag, components in
+// SplitComponentsFile, and the root references them with external $ref:
+//
+//	root, files, err := spec.BuildSplit(r)
+//	// root.Paths["/users"].Ref == "users.json#/paths/~1users"
+//
 // # Subrouter Integration
 //
 // The openapi package works with mux subrouters. Build walks the entire
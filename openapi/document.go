@@ -2,6 +2,8 @@ package openapi
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,11 +15,72 @@ func (d *Document) JSON() ([]byte, error) {
 	return json.MarshalIndent(d, "", "  ")
 }
 
+// MarshalJSON encodes the document. A document converted to OpenAPI 3.0 by
+// Spec.TargetVersion always has a paths object, which 3.0 requires, and
+// carries its webhooks as "x-webhooks".
+func (d Document) MarshalJSON() ([]byte, error) {
+	type document Document
+	if d.v30 == nil {
+		return json.Marshal(document(d))
+	}
+	webhooks := d.v30.webhooks
+	if len(d.Paths) > 0 {
+		return json.Marshal(struct {
+			document
+			Webhooks map[string]*PathItem `json:"x-webhooks,omitempty"`
+		}{document(d), webhooks})
+	}
+	return json.Marshal(struct {
+		document
+		Paths    map[string]*PathItem `json:"paths"`
+		Webhooks map[string]*PathItem `json:"x-webhooks,omitempty"`
+	}{document(d), map[string]*PathItem{}, webhooks})
+}
+
+// copyDocument returns a deep copy of an OpenAPI 3.1 document, so that it
+// can be modified without touching the schemas and parameters the document
+// shares with its Spec.
+func copyDocument(doc *Document) (*Document, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	out := &Document{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	out.buildWarnings = slices.Clone(doc.buildWarnings)
+	return out, nil
+}
+
 // YAML serializes the document as YAML bytes.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-object
 func (d *Document) YAML() ([]byte, error) {
-	return yaml.Marshal(d)
+	if d.v30 == nil {
+		return yaml.Marshal(d)
+	}
+	// The OpenAPI 3.0 keywords are only known to MarshalJSON, so a
+	// converted document is encoded through its JSON form.
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearNodeStyle(&node)
+	return yaml.Marshal(&node)
+}
+
+// clearNodeStyle resets the flow and quoting styles that parsing JSON
+// leaves on node, so it encodes as block YAML.
+func clearNodeStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearNodeStyle(child)
+	}
 }
 
 // DocumentFromJSON parses a JSON-encoded OpenAPI document.
@@ -41,3 +104,31 @@ func DocumentFromYAML(data []byte) (*Document, error) {
 	}
 	return &doc, nil
 }
+
+// Warnings reports problems in the document that do not make it invalid
+// but are likely mistakes, such as a tag group in "x-tagGroups" that
+// references a tag missing from the document's tags list, the example
+// violations reported by Spec.ValidateExamples in warning mode, and the
+// features lost when Spec.TargetVersion converted the document to OpenAPI
+// 3.0. It returns nil when there is nothing to report.
+func (d *Document) Warnings() []string {
+	var warnings []string
+
+	if len(d.TagGroups) > 0 {
+		known := make(map[string]bool, len(d.Tags))
+		for _, tag := range d.Tags {
+			known[tag.Name] = true
+		}
+		for _, group := range d.TagGroups {
+			for _, name := range group.Tags {
+				if !known[name] {
+					warnings = append(warnings, fmt.Sprintf("x-tagGroups: group %q references unknown tag %q", group.Name, name))
+				}
+			}
+		}
+	}
+
+	warnings = append(warnings, d.buildWarnings...)
+
+	return warnings
+}
@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Enumer can be implemented by named non-struct types (typically string or
+// integer constants) to list their allowed values. The values are set as
+// the "enum" keyword on every schema generated for the type, including
+// slice items, map values, and pointers. Values may be wrapped in
+// EnumValue to label them. Values registered with RegisterEnum take
+// precedence, and an `openapi:"enum=..."` field tag overrides both.
+//
+//	type Role string
+//
+//	func (Role) OpenAPIEnum() []any {
+//	    return []any{RoleAdmin, RoleEditor, RoleViewer}
+//	}
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-6.1.2
+type Enumer interface {
+	OpenAPIEnum() []any
+}
+
+// Schema extensions that label the values of an enum, in the order of the
+// "enum" keyword. Client generators such as OpenAPI Generator use them to
+// name enum constants and document them.
+const (
+	EnumVarNamesExtension     = "x-enum-varnames"
+	EnumDescriptionsExtension = "x-enum-descriptions"
+)
+
+// EnumValue labels a value passed to RegisterEnum or returned by
+// Enumer.OpenAPIEnum. Name is emitted in the x-enum-varnames extension
+// and Description in x-enum-descriptions, alongside the "enum" keyword.
+//
+//	spec.RegisterEnum(
+//	    openapi.EnumValue{Value: RoleAdmin, Name: "Admin", Description: "Full access"},
+//	    openapi.EnumValue{Value: RoleUser, Name: "User", Description: "Own resources only"},
+//	)
+type EnumValue struct {
+	// Value is the enum value; its type is the enum type.
+	Value any
+
+	// Name is the constant name client generators give the value.
+	Name string
+
+	// Description is a human-readable label for the value.
+	Description string
+}
+
+// typeEnumValues holds the values of an enum type with their labels.
+// names and descriptions are nil when no value has one.
+type typeEnumValues struct {
+	values       []any
+	names        []string
+	descriptions []string
+}
+
+// add appends value, unwrapping an EnumValue. Nil values are skipped.
+func (e *typeEnumValues) add(value any) {
+	label, ok := value.(EnumValue)
+	if ok {
+		value = label.Value
+	}
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return
+	}
+	if label.Name != "" && e.names == nil {
+		e.names = make([]string, len(e.values))
+	}
+	if label.Description != "" && e.descriptions == nil {
+		e.descriptions = make([]string, len(e.values))
+	}
+	e.values = append(e.values, enumValue(v))
+	if e.names != nil {
+		e.names = append(e.names, label.Name)
+	}
+	if e.descriptions != nil {
+		e.descriptions = append(e.descriptions, label.Description)
+	}
+}
+
+// apply sets the enum and its label extensions on schema. A value without
+// a name is named after the value itself.
+func (e typeEnumValues) apply(schema *Schema) {
+	schema.Enum = slices.Clone(e.values)
+	if e.names == nil && e.descriptions == nil {
+		return
+	}
+	if schema.Extensions == nil {
+		schema.Extensions = make(Extensions)
+	}
+	if e.names != nil {
+		names := make([]string, len(e.names))
+		for i, name := range e.names {
+			if name == "" {
+				name = fmt.Sprint(e.values[i])
+			}
+			names[i] = name
+		}
+		schema.Extensions[EnumVarNamesExtension] = names
+	}
+	if e.descriptions != nil {
+		schema.Extensions[EnumDescriptionsExtension] = slices.Clone(e.descriptions)
+	}
+}
+
+// registerEnum records the given values as the allowed values of their
+// type. Values are grouped by type, so each distinct type in values gets
+// its enum replaced with the values of that type, in order. Values may be
+// wrapped in EnumValue to label them.
+func (d *typeDocs) registerEnum(values ...any) {
+	grouped := make(map[reflect.Type]*typeEnumValues)
+	for _, value := range values {
+		inner := value
+		if l, ok := value.(EnumValue); ok {
+			inner = l.Value
+		}
+		t := reflect.TypeOf(inner)
+		if t == nil || t.Kind() == reflect.Struct || t.Kind() == reflect.Pointer {
+			continue
+		}
+		if grouped[t] == nil {
+			grouped[t] = &typeEnumValues{}
+		}
+		grouped[t].add(value)
+	}
+	if len(grouped) == 0 {
+		return
+	}
+	if d.enums == nil {
+		d.enums = make(map[reflect.Type]typeEnumValues)
+	}
+	for t, vals := range grouped {
+		d.enums[t] = *vals
+	}
+}
+
+// typeEnum returns the allowed values for t, either registered with
+// RegisterEnum or reported by the Enumer interface. Only named non-struct
+// types are considered.
+func (d *typeDocs) typeEnum(t reflect.Type) (typeEnumValues, bool) {
+	if t.PkgPath() == "" || t.Kind() == reflect.Struct {
+		return typeEnumValues{}, false
+	}
+	if d != nil {
+		if vals, ok := d.enums[t]; ok {
+			return vals, true
+		}
+	}
+	en, ok := reflect.Zero(t).Interface().(Enumer)
+	if !ok {
+		en, ok = reflect.New(t).Interface().(Enumer)
+	}
+	if !ok {
+		return typeEnumValues{}, false
+	}
+	var vals typeEnumValues
+	for _, value := range en.OpenAPIEnum() {
+		vals.add(value)
+	}
+	return vals, len(vals.values) > 0
+}
+
+// enumValue converts v to its underlying basic value so that the enum is
+// serialized the same way regardless of the named type or any custom
+// marshaling methods on it.
+func enumValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return v.Interface()
+}
+
+// RegisterEnum registers the allowed values of a named non-struct type.
+// All values of the same type form its enum, which is set on every schema
+// generated for the type, including slice items, map values and keys, and
+// pointers. A later registration for the same type replaces the earlier
+// one. Register enums before generating schemas that use the type. Wrap
+// values in EnumValue to label them with the x-enum-varnames and
+// x-enum-descriptions extensions.
+//
+//	gen.RegisterEnum(RoleAdmin, RoleEditor, RoleViewer)
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-6.1.2
+func (g *SchemaGenerator) RegisterEnum(values ...any) *SchemaGenerator {
+	g.docs.registerEnum(values...)
+	return g
+}
+
+// RegisterEnum registers the allowed values of a named non-struct type.
+// The enum is applied when Build generates schemas. See
+// SchemaGenerator.RegisterEnum.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-6.1.2
+func (s *Spec) RegisterEnum(values ...any) *Spec {
+	s.docs.registerEnum(values...)
+	return s
+}
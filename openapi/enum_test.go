@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+type enumRole string
+
+const (
+	enumRoleAdmin  enumRole = "admin"
+	enumRoleEditor enumRole = "editor"
+	enumRoleViewer enumRole = "viewer"
+)
+
+type enumPriority int
+
+func (enumPriority) OpenAPIEnum() []any {
+	return []any{enumPriority(1), enumPriority(2), enumPriority(3)}
+}
+
+type enumMember struct {
+	Role     enumRole            `json:"role"`
+	Roles    []enumRole          `json:"roles"`
+	Previous *enumRole           `json:"previous,omitempty"`
+	Grants   map[enumRole]bool   `json:"grants"`
+	ByName   map[string]enumRole `json:"byName"`
+	Legacy   enumRole            `json:"legacy" openapi:"enum=owner|guest"`
+	Priority enumPriority        `json:"priority"`
+}
+
+func TestRegisterEnum(t *testing.T) {
+	roles := []any{"admin", "editor", "viewer"}
+
+	t.Run("applies to every occurrence of the type", func(t *testing.T) {
+		gen := NewSchemaGenerator().RegisterEnum(enumRoleAdmin, enumRoleEditor, enumRoleViewer)
+		gen.Generate(enumMember{})
+
+		props := gen.Schemas()["enumMember"].Properties
+		assert.Equal(t, roles, props["role"].Enum)
+		assert.Equal(t, roles, props["roles"].Items.Enum)
+		assert.Equal(t, roles, props["byName"].AdditionalProperties.Enum)
+		require.NotNil(t, props["grants"].PropertyNames)
+		assert.Equal(t, roles, props["grants"].PropertyNames.Enum)
+	})
+
+	t.Run("pointer adds null", func(t *testing.T) {
+		gen := NewSchemaGenerator().RegisterEnum(enumRoleAdmin, enumRoleEditor, enumRoleViewer)
+		gen.Generate(enumMember{})
+
+		previous := gen.Schemas()["enumMember"].Properties["previous"]
+		assert.Equal(t, TypeArray("string", "null"), previous.Type)
+		assert.Equal(t, []any{"admin", "editor", "viewer", nil}, previous.Enum)
+	})
+
+	t.Run("field tag overrides type enum", func(t *testing.T) {
+		gen := NewSchemaGenerator().RegisterEnum(enumRoleAdmin, enumRoleEditor, enumRoleViewer)
+		gen.Generate(enumMember{})
+
+		assert.Equal(t, []any{"owner", "guest"}, gen.Schemas()["enumMember"].Properties["legacy"].Enum)
+	})
+
+	t.Run("enumer interface", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		gen.Generate(enumMember{})
+
+		props := gen.Schemas()["enumMember"].Properties
+		assert.Equal(t, []any{int64(1), int64(2), int64(3)}, props["priority"].Enum)
+		assert.Nil(t, props["role"].Enum)
+	})
+
+	t.Run("registration overrides enumer interface", func(t *testing.T) {
+		gen := NewSchemaGenerator().RegisterEnum(enumPriority(10), enumPriority(20))
+		gen.Generate(enumMember{})
+
+		assert.Equal(t, []any{int64(10), int64(20)}, gen.Schemas()["enumMember"].Properties["priority"].Enum)
+	})
+
+	t.Run("later registration replaces earlier", func(t *testing.T) {
+		gen := NewSchemaGenerator().
+			RegisterEnum(enumRoleAdmin).
+			RegisterEnum(enumRoleEditor, enumRoleViewer)
+		gen.Generate(enumMember{})
+
+		assert.Equal(t, []any{"editor", "viewer"}, gen.Schemas()["enumMember"].Properties["role"].Enum)
+	})
+
+	t.Run("unnamed and invalid values are ignored", func(t *testing.T) {
+		gen := NewSchemaGenerator().RegisterEnum("a", "b", nil, enumMember{})
+		schema := gen.Generate("")
+
+		assert.Nil(t, schema.Enum)
+	})
+}
+
+func TestSpecRegisterEnum(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/members", dummyHandler).Methods(http.MethodGet).Name("listMembers")
+
+	spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+		RegisterEnum(enumRoleAdmin, enumRoleEditor, enumRoleViewer)
+	spec.Op("listMembers").Response(http.StatusOK, []enumMember{})
+
+	doc := spec.Build(r)
+	require.NotNil(t, doc.Components)
+	member := doc.Components.Schemas["enumMember"]
+	require.NotNil(t, member)
+	assert.Equal(t, []any{"admin", "editor", "viewer"}, member.Properties["role"].Enum)
+}
+
+type enumLevel string
+
+func (enumLevel) OpenAPIEnum() []any {
+	return []any{
+		EnumValue{Value: enumLevel("low"), Name: "LevelLow"},
+		enumLevel("high"),
+	}
+}
+
+type enumLabeled struct {
+	Role   enumRole          `json:"role"`
+	Grants map[enumRole]bool `json:"grants"`
+	Level  enumLevel         `json:"level"`
+	Legacy enumRole          `json:"legacy" openapi:"enumVarNames=Owner|Guest,enum=owner|guest"`
+	Plain  enumRole          `json:"plain" openapi:"enum=owner|guest"`
+}
+
+func TestEnumLabels(t *testing.T) {
+	register := func(gen *SchemaGenerator) *SchemaGenerator {
+		return gen.RegisterEnum(
+			EnumValue{Value: enumRoleAdmin, Name: "RoleAdmin", Description: "Full access"},
+			EnumValue{Value: enumRoleViewer, Name: "RoleUser", Description: "Own resources only"},
+		)
+	}
+
+	t.Run("registered labels", func(t *testing.T) {
+		gen := register(NewSchemaGenerator())
+		gen.Generate(enumLabeled{})
+
+		props := gen.Schemas()["enumLabeled"].Properties
+		role := props["role"]
+		assert.Equal(t, []any{"admin", "viewer"}, role.Enum)
+		assert.Equal(t, []string{"RoleAdmin", "RoleUser"}, role.Extensions[EnumVarNamesExtension])
+		assert.Equal(t, []string{"Full access", "Own resources only"}, role.Extensions[EnumDescriptionsExtension])
+
+		require.NotNil(t, props["grants"].PropertyNames)
+		assert.Equal(t, []string{"RoleAdmin", "RoleUser"}, props["grants"].PropertyNames.Extensions[EnumVarNamesExtension])
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		gen := register(NewSchemaGenerator())
+		gen.Generate(enumLabeled{})
+
+		data, err := json.Marshal(gen.Schemas()["enumLabeled"].Properties["role"])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"type": "string",
+			"enum": ["admin", "viewer"],
+			"x-enum-varnames": ["RoleAdmin", "RoleUser"],
+			"x-enum-descriptions": ["Full access", "Own resources only"]
+		}`, string(data))
+	})
+
+	t.Run("enumer labels fill missing names", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		gen.Generate(enumLabeled{})
+
+		level := gen.Schemas()["enumLabeled"].Properties["level"]
+		assert.Equal(t, []any{"low", "high"}, level.Enum)
+		assert.Equal(t, []string{"LevelLow", "high"}, level.Extensions[EnumVarNamesExtension])
+		assert.NotContains(t, level.Extensions, EnumDescriptionsExtension)
+	})
+
+	t.Run("unlabeled enum has no extensions", func(t *testing.T) {
+		gen := NewSchemaGenerator().RegisterEnum(enumRoleAdmin, enumRoleViewer)
+		gen.Generate(enumLabeled{})
+
+		assert.Nil(t, gen.Schemas()["enumLabeled"].Properties["role"].Extensions)
+	})
+
+	t.Run("field tag", func(t *testing.T) {
+		gen := register(NewSchemaGenerator())
+		gen.Generate(enumLabeled{})
+
+		props := gen.Schemas()["enumLabeled"].Properties
+		assert.Equal(t, []any{"owner", "guest"}, props["legacy"].Enum)
+		assert.Equal(t, []string{"Owner", "Guest"}, props["legacy"].Extensions[EnumVarNamesExtension])
+		assert.NotContains(t, props["legacy"].Extensions, EnumDescriptionsExtension)
+
+		assert.Equal(t, []any{"owner", "guest"}, props["plain"].Enum)
+		assert.Empty(t, props["plain"].Extensions)
+	})
+}
@@ -0,0 +1,282 @@
+package openapi
+
+import (
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Payload marks where a response envelope template holds the operation's
+// response body. It is used as the type argument of a generic envelope
+// passed to ResponseEnvelope:
+//
+//	spec.ResponseEnvelope(ResponseData[openapi.Payload]{})
+//
+// Payload is never serialized; its schema is replaced by the schema of
+// each wrapped body.
+type Payload struct{}
+
+var payloadType = reflect.TypeFor[Payload]()
+
+// envelopePayload is the body substituted for Payload while an envelope
+// template is generated: a Go type, or a schema for *Schema and Paginated
+// bodies.
+type envelopePayload struct {
+	typ    reflect.Type
+	schema *Schema
+}
+
+// ResponseEnvelope wraps the body of every 2xx JSON response of the
+// spec's route operations in the given envelope template, so operations
+// can document their payload without repeating the wrapper:
+//
+//	type ResponseData[T any] struct {
+//	    Success bool `json:"success"`
+//	    Result  T    `json:"result"`
+//	}
+//
+//	spec.ResponseEnvelope(ResponseData[openapi.Payload]{})
+//	spec.Op("getUser").Response(http.StatusOK, User{})
+//
+// The template is a generic struct instantiated with Payload. A wrapped
+// body produces the same component schema as writing the instantiation
+// by hand: Response(http.StatusOK, User{}) above documents
+// ResponseData[User] as "ResponseDataUser", and []User as
+// "ResponseDataUserList", so both forms can be mixed in one document. A
+// *Schema or Paginated body that references a component is named after
+// that component; other bodies without a type name produce an inline
+// envelope.
+//
+// Only 2xx responses with a JSON media type (application/json or a +json
+// suffix) are wrapped; error, binary, and event stream responses are left
+// as registered. Webhooks and callbacks are not wrapped. RouteGroup has
+// its own ResponseEnvelope, and OperationBuilder.NoEnvelope opts a single
+// operation out. A template without a Payload field is ignored. Pass nil to
+// disable wrapping, for example on a spec returned by Scope.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#response-object
+func (s *Spec) ResponseEnvelope(template any) *Spec {
+	s.responseEnvelope = template
+	s.responseEnvelopeSet = true
+	return s
+}
+
+// ResponseEnvelope sets the envelope template that wraps the 2xx JSON
+// responses of operations created through this group, overriding the
+// spec-level envelope. Pass nil to leave the group's responses unwrapped.
+// See Spec.ResponseEnvelope for how bodies are wrapped.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#response-object
+func (g *RouteGroup) ResponseEnvelope(template any) *RouteGroup {
+	g.defaults.envelope = template
+	g.defaults.envelopeSet = true
+	return g
+}
+
+// NoEnvelope documents the operation's responses as registered, without
+// the envelope set by Spec.ResponseEnvelope or RouteGroup.ResponseEnvelope.
+// Use it for raw or binary responses that are not wrapped at runtime.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#response-object
+func (b *OperationBuilder) NoEnvelope() *OperationBuilder {
+	b.meta.noEnvelope = true
+	return b
+}
+
+// applyResponseEnvelope replaces the schema of each 2xx JSON response of
+// op with the envelope that applies to the operation built by b.
+func (s *Spec) applyResponseEnvelope(gen *SchemaGenerator, b *OperationBuilder, op *Operation) {
+	if b.meta.noEnvelope {
+		return
+	}
+	template := s.responseEnvelope
+	if b.meta.envelopeSet {
+		template = b.meta.envelope
+	}
+	if template == nil {
+		return
+	}
+	t := reflect.TypeOf(template)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	for key, contents := range b.meta.responseContents {
+		if len(key) != 3 || key[0] != '2' || op.Responses[key] == nil {
+			continue
+		}
+		for ct, body := range contents {
+			mt := op.Responses[key].Content[ct]
+			if mt == nil || mt.Schema == nil || !isJSONContentType(ct) {
+				continue
+			}
+			if _, ok := body.(sseEvent); ok {
+				continue
+			}
+			if schema := gen.envelopeSchema(t, body); schema != nil {
+				mt.Schema = schema
+			}
+		}
+	}
+}
+
+// isJSONContentType reports whether ct is application/json or a media type
+// with the +json structured syntax suffix.
+func isJSONContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// envelopeSchema returns the schema of the envelope template t with body
+// in place of Payload, registering it as a component when it has a name.
+// It returns nil when t is not a struct with a Payload type argument.
+func (g *SchemaGenerator) envelopeSchema(t reflect.Type, body any) *Schema {
+	marker := payloadType.PkgPath() + "." + payloadType.Name()
+	if t.Kind() != reflect.Struct || !strings.Contains(t.Name(), marker) {
+		return nil
+	}
+
+	var payload envelopePayload
+	var arg string
+	switch body := body.(type) {
+	case *Schema, paginatedBody:
+		payload.schema = resolveSchema(g, body)
+		if payload.schema == nil {
+			return nil
+		}
+		arg, _ = strings.CutPrefix(payload.schema.Ref, componentSchemaRefPrefix)
+		if arg == payload.schema.Ref {
+			arg = ""
+		}
+	default:
+		payload.typ = reflect.TypeOf(body)
+		arg = typeArgString(payload.typ)
+	}
+
+	if arg == "" {
+		return g.generateEnvelope(t, payload)
+	}
+
+	// The instantiated type name, as reflect reports it for a generic type
+	// written out by hand, keeps the component name identical to that form.
+	typeName := strings.ReplaceAll(t.Name(), marker, arg)
+	key := t.PkgPath() + "." + typeName
+	name, ok := g.envelopeTypes[key]
+	if !ok {
+		name = g.namedTypeByKey(key)
+		if name == "" {
+			name = g.reserveEnvelopeName(sanitizeSchemaName(typeName), pkgPrefix(t.PkgPath()))
+		}
+		if g.envelopeTypes == nil {
+			g.envelopeTypes = make(map[string]string)
+		}
+		g.envelopeTypes[key] = name
+		g.schemas[name] = g.generateEnvelope(t, payload)
+	}
+	return &Schema{Ref: componentSchemaRefPrefix + name}
+}
+
+// generateEnvelope generates the struct schema of the template t with the
+// schema of payload wherever the template refers to Payload.
+func (g *SchemaGenerator) generateEnvelope(t reflect.Type, payload envelopePayload) *Schema {
+	prev := g.envelopePayload
+	g.envelopePayload = &payload
+	defer func() { g.envelopePayload = prev }()
+	return g.generateStructSchema(t)
+}
+
+// payloadSchema returns the schema substituted for a Payload field of the
+// envelope template being generated.
+func (g *SchemaGenerator) payloadSchema(nullable bool) *Schema {
+	payload := g.envelopePayload
+	g.envelopePayload = nil
+	defer func() { g.envelopePayload = payload }()
+
+	if payload.typ != nil {
+		t := payload.typ
+		if nullable {
+			t = reflect.PointerTo(t)
+		}
+		return g.generateType(t)
+	}
+
+	if nullable && payload.schema.Ref != "" {
+		return componentRef(strings.TrimPrefix(payload.schema.Ref, componentSchemaRefPrefix), true)
+	}
+	schema := *payload.schema
+	if nullable {
+		applyNullable(&schema)
+	}
+	return &schema
+}
+
+// namedTypeByKey returns the component name of a generated Go type whose
+// package path and name are key, or "" when there is none.
+func (g *SchemaGenerator) namedTypeByKey(key string) string {
+	for t, name := range g.typeNames {
+		if name != "" && t.PkgPath()+"."+t.Name() == key {
+			return name
+		}
+	}
+	return ""
+}
+
+// reserveEnvelopeName claims a component name for an envelope schema,
+// resolving collisions like schemaName does: with the package prefix
+// first, then a numeric suffix. The name is reserved so a Go type that
+// sanitizes to it later is renamed instead of overwriting the envelope.
+func (g *SchemaGenerator) reserveEnvelopeName(simple, prefix string) string {
+	name := simple
+	if _, taken := g.nameTypes[name]; taken {
+		name = prefix + simple
+		base := name
+		for i := 2; ; i++ {
+			if _, taken := g.nameTypes[name]; !taken {
+				break
+			}
+			name = base + strconv.Itoa(i)
+		}
+	}
+	g.nameTypes[name] = nil
+	return name
+}
+
+// typeArgString returns t as reflect spells it in the name of a generic
+// type instantiated with t, or "" when t has no such spelling that names a
+// component, such as an unnamed struct or interface.
+func typeArgString(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if elem := typeArgString(t.Elem()); elem != "" {
+			return "*" + elem
+		}
+	case reflect.Slice:
+		if elem := typeArgString(t.Elem()); elem != "" {
+			return "[]" + elem
+		}
+	case reflect.Array:
+		if elem := typeArgString(t.Elem()); elem != "" {
+			return "[" + strconv.Itoa(t.Len()) + "]" + elem
+		}
+	case reflect.Map:
+		key, elem := typeArgString(t.Key()), typeArgString(t.Elem())
+		if key != "" && elem != "" {
+			return "map[" + key + "]" + elem
+		}
+	}
+	return ""
+}
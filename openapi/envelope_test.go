@@ -0,0 +1,283 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type EnvelopeUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type EnvelopeError struct {
+	Code string `json:"code"`
+}
+
+type nullableEnvelope[T any] struct {
+	Result *T `json:"result,omitempty"`
+}
+
+type notAnEnvelope struct {
+	Result string `json:"result"`
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	buildJSON := func(t *testing.T, spec *Spec, r *mux.Router) string {
+		t.Helper()
+		data, err := json.Marshal(spec.Build(r))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	responseSchema := func(doc *Document, path, status, ct string) *Schema {
+		return doc.Paths[path].Get.Responses[status].Content[ct].Schema
+	}
+
+	t.Run("identical to hand-written generic", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Name("listUsers")
+		r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet).Name("getUser")
+		r.HandleFunc("/users/{id}/tags", dummyHandler).Methods(http.MethodGet).Name("getTags")
+
+		manual := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		manual.Op("listUsers").Response(http.StatusOK, ResponseWrapper[[]EnvelopeUser]{})
+		manual.Op("getUser").
+			Response(http.StatusOK, ResponseWrapper[EnvelopeUser]{}).
+			Response(http.StatusNotFound, EnvelopeError{})
+		manual.Op("getTags").Response(http.StatusOK, ResponseWrapper[map[string]int]{})
+
+		wrapped := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+		wrapped.Op("listUsers").Response(http.StatusOK, []EnvelopeUser{})
+		wrapped.Op("getUser").
+			Response(http.StatusOK, EnvelopeUser{}).
+			Response(http.StatusNotFound, EnvelopeError{})
+		wrapped.Op("getTags").Response(http.StatusOK, map[string]int{})
+
+		assert.JSONEq(t, buildJSON(t, manual, r), buildJSON(t, wrapped, r))
+
+		doc := wrapped.Build(r)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUser", responseSchema(doc, "/users/{id}", "200", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUserList", responseSchema(doc, "/users", "200", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/EnvelopeError", responseSchema(doc, "/users/{id}", "404", "application/json").Ref)
+	})
+
+	t.Run("shares component with hand-written form", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/a", dummyHandler).Methods(http.MethodGet).Name("a")
+		r.HandleFunc("/b", dummyHandler).Methods(http.MethodGet).Name("b")
+
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(&ResponseWrapper[Payload]{})
+		spec.Op("a").Response(http.StatusOK, EnvelopeUser{})
+		spec.Op("b").NoEnvelope().Response(http.StatusOK, ResponseWrapper[EnvelopeUser]{})
+
+		doc := spec.Build(r)
+		assert.Equal(t, responseSchema(doc, "/a", "200", "application/json"), responseSchema(doc, "/b", "200", "application/json"))
+		assert.NotContains(t, doc.Components.Schemas, "ResponseWrapperEnvelopeUser2")
+		assert.NotContains(t, doc.Components.Schemas, "OpenapiResponseWrapperEnvelopeUser")
+
+		r = mux.NewRouter()
+		r.HandleFunc("/b", dummyHandler).Methods(http.MethodGet).Name("b")
+		r.HandleFunc("/a", dummyHandler).Methods(http.MethodGet).Name("a")
+
+		doc = spec.Build(r)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUser", responseSchema(doc, "/a", "200", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUser", responseSchema(doc, "/b", "200", "application/json").Ref)
+		assert.NotContains(t, doc.Components.Schemas, "OpenapiResponseWrapperEnvelopeUser")
+	})
+
+	t.Run("only 2xx JSON responses", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/files", dummyHandler).Methods(http.MethodGet).Name("files")
+
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+		spec.Op("files").
+			Response(http.StatusOK, EnvelopeUser{}).
+			ResponseContent(http.StatusOK, "application/problem+json; charset=utf-8", EnvelopeUser{}).
+			ResponseContent(http.StatusOK, "application/octet-stream", &Schema{Type: SchemaTypeString, Format: "binary"}).
+			ResponseContent(http.StatusOK, "text/event-stream", nil).
+			Response(http.StatusNoContent, nil).
+			Response(http.StatusBadRequest, EnvelopeError{}).
+			DefaultResponse(EnvelopeError{})
+
+		doc := spec.Build(r)
+		envelope := "#/components/schemas/ResponseWrapperEnvelopeUser"
+		assert.Equal(t, envelope, responseSchema(doc, "/files", "200", "application/json").Ref)
+		assert.Equal(t, envelope, responseSchema(doc, "/files", "200", "application/problem+json; charset=utf-8").Ref)
+		assert.Equal(t, "binary", responseSchema(doc, "/files", "200", "application/octet-stream").Format)
+		assert.Nil(t, responseSchema(doc, "/files", "200", "text/event-stream"))
+		assert.Empty(t, doc.Paths["/files"].Get.Responses["204"].Content)
+		assert.Equal(t, "#/components/schemas/EnvelopeError", responseSchema(doc, "/files", "400", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/EnvelopeError", responseSchema(doc, "/files", "default", "application/json").Ref)
+	})
+
+	t.Run("no envelope", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/raw", dummyHandler).Methods(http.MethodGet).Name("raw")
+
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+		spec.Op("raw").Response(http.StatusOK, EnvelopeUser{}).NoEnvelope()
+
+		doc := spec.Build(r)
+		assert.Equal(t, "#/components/schemas/EnvelopeUser", responseSchema(doc, "/raw", "200", "application/json").Ref)
+		assert.NotContains(t, doc.Components.Schemas, "ResponseWrapperEnvelopeUser")
+	})
+
+	t.Run("group envelope", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+
+		wrapped := spec.Group().ResponseEnvelope(nullableEnvelope[Payload]{})
+		wrapped.Route(r.HandleFunc("/wrapped", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, EnvelopeUser{})
+
+		raw := wrapped.Group().ResponseEnvelope(nil)
+		raw.Route(r.HandleFunc("/raw", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, EnvelopeUser{})
+
+		spec.Group().Route(r.HandleFunc("/default", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, EnvelopeUser{})
+
+		doc := spec.Build(r)
+		assert.Equal(t, "#/components/schemas/nullableEnvelopeEnvelopeUser", responseSchema(doc, "/wrapped", "200", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/EnvelopeUser", responseSchema(doc, "/raw", "200", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUser", responseSchema(doc, "/default", "200", "application/json").Ref)
+
+		nullable := doc.Components.Schemas["nullableEnvelopeEnvelopeUser"]
+		require.NotNil(t, nullable)
+		assert.Equal(t, &Schema{AnyOf: []*Schema{
+			{Ref: "#/components/schemas/EnvelopeUser"},
+			{Type: SchemaTypeNull},
+		}}, nullable.Properties["result"])
+		assert.Empty(t, nullable.Required)
+	})
+
+	t.Run("schema and paginated payloads", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/ref", dummyHandler).Methods(http.MethodGet).Name("ref")
+		r.HandleFunc("/inline", dummyHandler).Methods(http.MethodGet).Name("inline")
+		r.HandleFunc("/page", dummyHandler).Methods(http.MethodGet).Name("page")
+
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(nullableEnvelope[Payload]{})
+		spec.Op("ref").Response(http.StatusOK, &Schema{Ref: "#/components/schemas/Money"})
+		spec.Op("inline").Response(http.StatusOK, &Schema{Type: SchemaTypeString})
+		spec.Op("page").Response(http.StatusOK, Paginated(EnvelopeUser{}))
+
+		doc := spec.Build(r)
+		assert.Equal(t, "#/components/schemas/nullableEnvelopeMoney", responseSchema(doc, "/ref", "200", "application/json").Ref)
+		assert.Equal(t, &Schema{AnyOf: []*Schema{
+			{Ref: "#/components/schemas/Money"},
+			{Type: SchemaTypeNull},
+		}}, doc.Components.Schemas["nullableEnvelopeMoney"].Properties["result"])
+
+		inline := responseSchema(doc, "/inline", "200", "application/json")
+		assert.Empty(t, inline.Ref)
+		assert.Equal(t, &Schema{Type: TypeArray("string", "null")}, inline.Properties["result"])
+
+		assert.Equal(t, "#/components/schemas/nullableEnvelopePaginatedEnvelopeUser", responseSchema(doc, "/page", "200", "application/json").Ref)
+		assert.Contains(t, doc.Components.Schemas, "PaginatedEnvelopeUser")
+	})
+
+	t.Run("name collision", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/a", dummyHandler).Methods(http.MethodGet).Name("a")
+		r.HandleFunc("/b", dummyHandler).Methods(http.MethodGet).Name("b")
+
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+		spec.Op("a").Response(http.StatusOK, EnvelopeUser{})
+		spec.Op("b").Response(http.StatusOK, &EnvelopeUser{})
+
+		doc := spec.Build(r)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUser", responseSchema(doc, "/a", "200", "application/json").Ref)
+		assert.Equal(t, "#/components/schemas/OpenapiResponseWrapperEnvelopeUser", responseSchema(doc, "/b", "200", "application/json").Ref)
+	})
+
+	t.Run("template without payload is ignored", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/a", dummyHandler).Methods(http.MethodGet).Name("a")
+
+		for _, template := range []any{notAnEnvelope{}, ResponseWrapper[EnvelopeUser]{}, "text"} {
+			spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).ResponseEnvelope(template)
+			spec.Op("a").Response(http.StatusOK, EnvelopeError{})
+
+			doc := spec.Build(r)
+			assert.Equal(t, "#/components/schemas/EnvelopeError", responseSchema(doc, "/a", "200", "application/json").Ref)
+		}
+	})
+
+	t.Run("scope inherits envelope", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/v1/users", dummyHandler).Methods(http.MethodGet).Name("users")
+
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+		spec.Op("users").Response(http.StatusOK, EnvelopeUser{})
+
+		doc := spec.Scope("/v1", Info{Title: "v1", Version: "1.0.0"}).Build(r)
+		assert.Equal(t, "#/components/schemas/ResponseWrapperEnvelopeUser", responseSchema(doc, "/v1/users", "200", "application/json").Ref)
+
+		doc = spec.Scope("/v1", Info{Title: "v1", Version: "1.0.0"}).ResponseEnvelope(nil).Build(r)
+		assert.Equal(t, "#/components/schemas/EnvelopeUser", responseSchema(doc, "/v1/users", "200", "application/json").Ref)
+	})
+
+	t.Run("exported schemas include envelope", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			ResponseEnvelope(ResponseWrapper[Payload]{})
+		spec.Op("getUser").Response(http.StatusOK, EnvelopeUser{})
+
+		schemas, err := spec.ExportSchemas()
+		require.NoError(t, err)
+		assert.Contains(t, schemas, "ResponseWrapperEnvelopeUser")
+	})
+}
+
+func TestTypeArgString(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      reflect.Type
+		expected string
+	}{
+		{"named", reflect.TypeFor[EnvelopeUser](), "github.com/vitalvas/kasper/openapi.EnvelopeUser"},
+		{"builtin", reflect.TypeFor[int](), "int"},
+		{"byte slice", reflect.TypeFor[[]byte](), "[]uint8"},
+		{"pointer slice", reflect.TypeFor[[]*EnvelopeUser](), "[]*github.com/vitalvas/kasper/openapi.EnvelopeUser"},
+		{"array", reflect.TypeFor[[3]string](), "[3]string"},
+		{"map", reflect.TypeFor[map[string]EnvelopeUser](), "map[string]github.com/vitalvas/kasper/openapi.EnvelopeUser"},
+		{"generic", reflect.TypeFor[ResponseWrapper[int]](), "github.com/vitalvas/kasper/openapi.ResponseWrapper[int]"},
+		{"unnamed struct", reflect.TypeFor[struct{ A int }](), ""},
+		{"interface", reflect.TypeFor[[]any](), ""},
+		{"nil", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, typeArgString(tt.typ))
+		})
+	}
+
+	t.Run("matches reflect names", func(t *testing.T) {
+		for _, v := range []any{
+			ResponseWrapper[EnvelopeUser]{},
+			ResponseWrapper[[]*EnvelopeUser]{},
+			ResponseWrapper[map[string][2]EnvelopeUser]{},
+			ResponseWrapper[ResponseWrapper[int]]{},
+		} {
+			typ := reflect.TypeOf(v)
+			arg := typeArgString(typ.Field(3).Type)
+			assert.Equal(t, "ResponseWrapper["+arg+"]", typ.Name())
+		}
+	})
+}
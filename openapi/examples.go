@@ -0,0 +1,611 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExample is wrapped by the BuildE errors that report examples
+// not conforming to their schemas.
+var ErrInvalidExample = errors.New("openapi: invalid example")
+
+// ExampleValidation selects how Build checks examples against the schemas
+// they are attached to. See Spec.ValidateExamples.
+type ExampleValidation int
+
+const (
+	// ExampleValidationOff skips example validation. It is the default.
+	ExampleValidationOff ExampleValidation = iota
+
+	// ExampleValidationWarn reports every violation through
+	// Document.Warnings.
+	ExampleValidationWarn
+
+	// ExampleValidationPartial reports missing required properties
+	// through Document.Warnings, for intentionally partial examples, and
+	// every other violation as a BuildE error.
+	ExampleValidationPartial
+
+	// ExampleValidationStrict reports every violation as a BuildE error.
+	ExampleValidationStrict
+)
+
+// ValidateExamples checks every example in the built document against the
+// schema it is attached to, as Document.ValidateExamples does, and reports
+// the violations according to mode. Errors wrap ErrInvalidExample and are
+// combined with errors.Join; Build discards them, so use BuildE in tests
+// or at startup:
+//
+//	spec.ValidateExamples(openapi.ExampleValidationPartial)
+//	if _, err := spec.BuildE(r); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// See: https://spec.openapis.org/oas/v3.1.0#example-object
+func (s *Spec) ValidateExamples(mode ExampleValidation) *Spec {
+	s.exampleValidation = mode
+	s.exampleValidationSet = true
+	return s
+}
+
+// applyExampleValidation validates the examples of doc according to the
+// spec's mode, records warnings on doc, and returns the errors.
+func (s *Spec) applyExampleValidation(doc *Document) []error {
+	if s.exampleValidation == ExampleValidationOff {
+		return nil
+	}
+	var errs []error
+	for _, v := range doc.ValidateExamples() {
+		switch {
+		case s.exampleValidation == ExampleValidationWarn,
+			s.exampleValidation == ExampleValidationPartial && v.MissingRequired:
+			doc.buildWarnings = append(doc.buildWarnings, "example "+v.String())
+		default:
+			errs = append(errs, fmt.Errorf("%w %s", ErrInvalidExample, v))
+		}
+	}
+	return errs
+}
+
+// ExampleViolation reports a value in an example that does not conform to
+// the schema the example is attached to.
+type ExampleViolation struct {
+	// Location is a JSON pointer into the document to the example, such
+	// as "#/components/schemas/User/example" or
+	// "#/paths/~1users/post/requestBody/content/application~1json/examples/full/value".
+	Location string
+
+	// Pointer is a JSON pointer into the example to the offending value;
+	// empty when it is the whole example.
+	Pointer string
+
+	// Message describes the violation.
+	Message string
+
+	// MissingRequired reports that the violation is a missing required
+	// property, which an intentionally partial example may have.
+	MissingRequired bool
+}
+
+func (v ExampleViolation) String() string {
+	if v.Pointer == "" {
+		return v.Location + ": " + v.Message
+	}
+	return v.Location + ": " + v.Pointer + ": " + v.Message
+}
+
+// ValidateExamples checks the examples of the document against the schemas
+// they are attached to: schema examples in components and inline schemas,
+// including field-level examples on properties, and the example and named
+// examples of media types, parameters, and headers. Values are checked for
+// their type, required properties, enum and const membership, and the
+// date-time, date, uuid, and email string formats, following $ref to
+// component schemas. Violations are returned in document order; the
+// result is nil when every example conforms.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#example-object
+// See: https://json-schema.org/draft/2020-12/json-schema-validation
+func (d *Document) ValidateExamples() []ExampleViolation {
+	w := &exampleWalker{doc: d, visited: make(map[*Schema]bool)}
+
+	if c := d.Components; c != nil {
+		for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+			w.schema(c.Schemas[name], "#/components/schemas/"+escapePointer(name))
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(d.Paths)) {
+		w.pathItem(d.Paths[path], "#/paths/"+escapePointer(path))
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.Webhooks)) {
+		w.pathItem(d.Webhooks[name], "#/webhooks/"+escapePointer(name))
+	}
+	if c := d.Components; c != nil {
+		for _, name := range slices.Sorted(maps.Keys(c.Responses)) {
+			w.response(c.Responses[name], "#/components/responses/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(c.Parameters)) {
+			w.parameter(c.Parameters[name], "#/components/parameters/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(c.RequestBodies)) {
+			w.content(c.RequestBodies[name].Content, "#/components/requestBodies/"+escapePointer(name)+"/content")
+		}
+		for _, name := range slices.Sorted(maps.Keys(c.Headers)) {
+			w.header(c.Headers[name], "#/components/headers/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(c.Callbacks)) {
+			w.callback(c.Callbacks[name], "#/components/callbacks/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(c.PathItems)) {
+			w.pathItem(c.PathItems[name], "#/components/pathItems/"+escapePointer(name))
+		}
+	}
+	return w.violations
+}
+
+// exampleWalker finds the examples of a document and validates them.
+type exampleWalker struct {
+	doc        *Document
+	visited    map[*Schema]bool
+	violations []ExampleViolation
+}
+
+func (w *exampleWalker) pathItem(item *PathItem, loc string) {
+	if item == nil {
+		return
+	}
+	w.parameters(item.Parameters, loc)
+	for _, op := range []struct {
+		method string
+		op     *Operation
+	}{
+		{"get", item.Get}, {"put", item.Put}, {"post", item.Post}, {"delete", item.Delete},
+		{"options", item.Options}, {"head", item.Head}, {"patch", item.Patch}, {"trace", item.Trace},
+	} {
+		if op.op != nil {
+			w.operation(op.op, loc+"/"+op.method)
+		}
+	}
+}
+
+func (w *exampleWalker) operation(op *Operation, loc string) {
+	w.parameters(op.Parameters, loc)
+	if op.RequestBody != nil {
+		w.content(op.RequestBody.Content, loc+"/requestBody/content")
+	}
+	for _, status := range slices.Sorted(maps.Keys(op.Responses)) {
+		w.response(op.Responses[status], loc+"/responses/"+escapePointer(status))
+	}
+	for _, name := range slices.Sorted(maps.Keys(op.Callbacks)) {
+		w.callback(op.Callbacks[name], loc+"/callbacks/"+escapePointer(name))
+	}
+}
+
+func (w *exampleWalker) callback(cb *Callback, loc string) {
+	if cb == nil {
+		return
+	}
+	for _, expr := range slices.Sorted(maps.Keys(*cb)) {
+		w.pathItem((*cb)[expr], loc+"/"+escapePointer(expr))
+	}
+}
+
+func (w *exampleWalker) parameters(params []*Parameter, loc string) {
+	for i, p := range params {
+		w.parameter(p, loc+"/parameters/"+strconv.Itoa(i))
+	}
+}
+
+func (w *exampleWalker) parameter(p *Parameter, loc string) {
+	if p == nil {
+		return
+	}
+	w.examples(p.Schema, p.Example, p.Examples, loc)
+	w.content(p.Content, loc+"/content")
+}
+
+func (w *exampleWalker) header(h *Header, loc string) {
+	if h == nil {
+		return
+	}
+	w.examples(h.Schema, h.Example, h.Examples, loc)
+	w.content(h.Content, loc+"/content")
+}
+
+func (w *exampleWalker) response(resp *Response, loc string) {
+	if resp == nil {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(resp.Headers)) {
+		w.header(resp.Headers[name], loc+"/headers/"+escapePointer(name))
+	}
+	w.content(resp.Content, loc+"/content")
+}
+
+func (w *exampleWalker) content(content map[string]*MediaType, loc string) {
+	for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+		mt := content[mediaType]
+		if mt == nil {
+			continue
+		}
+		w.examples(mt.Schema, mt.Example, mt.Examples, loc+"/"+escapePointer(mediaType))
+	}
+}
+
+// examples validates the example and named examples of a media type,
+// parameter, or header at loc against schema, and walks the schema.
+func (w *exampleWalker) examples(schema *Schema, example any, examples map[string]*Example, loc string) {
+	if schema == nil {
+		return
+	}
+	w.schema(schema, loc+"/schema")
+	if example != nil {
+		w.validate(schema, example, loc+"/example")
+	}
+	for _, name := range slices.Sorted(maps.Keys(examples)) {
+		if ex := examples[name]; ex != nil && ex.Value != nil {
+			w.validate(schema, ex.Value, loc+"/examples/"+escapePointer(name)+"/value")
+		}
+	}
+}
+
+// schema validates the examples of schema and of the schemas nested in
+// it. References are not followed; component schemas are walked on
+// their own.
+func (w *exampleWalker) schema(s *Schema, loc string) {
+	if s == nil || w.visited[s] {
+		return
+	}
+	w.visited[s] = true
+
+	if s.Example != nil {
+		w.validate(s, s.Example, loc+"/example")
+	}
+	for i, ex := range s.Examples {
+		w.validate(s, ex, loc+"/examples/"+strconv.Itoa(i))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		w.schema(s.Properties[name], loc+"/properties/"+escapePointer(name))
+	}
+	w.schema(s.Items, loc+"/items")
+	w.schema(s.AdditionalProperties, loc+"/additionalProperties")
+	for _, list := range []struct {
+		keyword string
+		schemas []*Schema
+	}{
+		{"prefixItems", s.PrefixItems}, {"allOf", s.AllOf}, {"anyOf", s.AnyOf}, {"oneOf", s.OneOf},
+	} {
+		for i, sub := range list.schemas {
+			w.schema(sub, loc+"/"+list.keyword+"/"+strconv.Itoa(i))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Defs)) {
+		w.schema(s.Defs[name], loc+"/$defs/"+escapePointer(name))
+	}
+}
+
+// validate checks example against schema and records the violations
+// under loc.
+func (w *exampleWalker) validate(schema *Schema, example any, loc string) {
+	value, err := normalizeJSON(example)
+	if err != nil {
+		w.violations = append(w.violations, ExampleViolation{
+			Location: loc,
+			Message:  "cannot be encoded as JSON: " + err.Error(),
+		})
+		return
+	}
+	v := &exampleValidator{doc: w.doc}
+	v.validate(schema, value, "", 0)
+	for _, violation := range v.violations {
+		violation.Location = loc
+		w.violations = append(w.violations, violation)
+	}
+}
+
+// maxExampleDepth bounds the $ref and composition depth followed while
+// validating a value, guarding against schemas that refer to themselves
+// without consuming any of the value.
+const maxExampleDepth = 64
+
+// exampleValidator checks a JSON value, as decoded by normalizeJSON,
+// against a schema.
+type exampleValidator struct {
+	doc        *Document
+	violations []ExampleViolation
+}
+
+func (v *exampleValidator) report(pointer, format string, args ...any) {
+	v.violations = append(v.violations, ExampleViolation{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// matches reports whether value conforms to schema, without recording
+// violations.
+func (v *exampleValidator) matches(schema *Schema, value any, depth int) bool {
+	sub := &exampleValidator{doc: v.doc}
+	sub.validate(schema, value, "", depth)
+	return len(sub.violations) == 0
+}
+
+func (v *exampleValidator) validate(schema *Schema, value any, pointer string, depth int) {
+	if schema == nil || depth > maxExampleDepth {
+		return
+	}
+
+	if schema.Ref != "" {
+		v.validate(v.resolve(schema.Ref), value, pointer, depth+1)
+	}
+
+	if !schema.Type.IsEmpty() {
+		actual := jsonTypeOf(value)
+		if !slices.ContainsFunc(schema.Type.Values(), func(t string) bool {
+			return t == actual || t == "number" && actual == "integer"
+		}) {
+			v.report(pointer, "expected %s, got %s", strings.Join(schema.Type.Values(), " or "), actual)
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(e any) bool {
+		return equalJSONValue(e, value)
+	}) {
+		v.report(pointer, "value %s is not one of the enum values", formatJSONValue(value))
+	}
+	if schema.Const != nil && !equalJSONValue(schema.Const, value) {
+		v.report(pointer, "value %s does not equal const %s", formatJSONValue(value), formatJSONValue(schema.Const))
+	}
+
+	switch val := value.(type) {
+	case string:
+		if msg := checkStringFormat(schema.Format, val); msg != "" {
+			v.report(pointer, "%s", msg)
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := val[name]; !ok {
+				v.violations = append(v.violations, ExampleViolation{
+					Pointer:         pointer,
+					Message:         fmt.Sprintf("missing required property %q", name),
+					MissingRequired: true,
+				})
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(val)) {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				prop = schema.AdditionalProperties
+			}
+			v.validate(prop, val[name], pointer+"/"+escapePointer(name), depth)
+		}
+	case []any:
+		for i, item := range val {
+			itemSchema := schema.Items
+			if i < len(schema.PrefixItems) {
+				itemSchema = schema.PrefixItems[i]
+			}
+			v.validate(itemSchema, item, pointer+"/"+strconv.Itoa(i), depth)
+		}
+	}
+
+	for _, sub := range schema.AllOf {
+		v.validate(sub, value, pointer, depth+1)
+	}
+	if len(schema.AnyOf) > 0 {
+		v.validateAlternatives(schema.AnyOf, "anyOf", value, pointer, depth+1)
+	}
+	if len(schema.OneOf) > 0 {
+		v.validateAlternatives(schema.OneOf, "oneOf", value, pointer, depth+1)
+	}
+}
+
+// validateAlternatives checks value against the schemas of an anyOf or
+// oneOf. When only one alternative accepts the JSON type of the value, as
+// for a nullable $ref, its violations are reported directly.
+func (v *exampleValidator) validateAlternatives(alternatives []*Schema, keyword string, value any, pointer string, depth int) {
+	var candidates []*Schema
+	matched := 0
+	for _, alt := range alternatives {
+		if v.acceptsType(alt, value, depth) {
+			candidates = append(candidates, alt)
+		}
+		if v.matches(alt, value, depth) {
+			matched++
+		}
+	}
+
+	switch {
+	case matched == 0 && len(candidates) == 1:
+		v.validate(candidates[0], value, pointer, depth)
+	case matched == 0:
+		v.report(pointer, "value does not match any schema in %s", keyword)
+	case keyword == "oneOf" && matched > 1:
+		v.report(pointer, "value matches %d schemas in oneOf, expected exactly one", matched)
+	}
+}
+
+// acceptsType reports whether the type keyword of schema, following
+// $ref, admits the JSON type of value.
+func (v *exampleValidator) acceptsType(schema *Schema, value any, depth int) bool {
+	for schema != nil && schema.Ref != "" && schema.Type.IsEmpty() && depth <= maxExampleDepth {
+		schema = v.resolve(schema.Ref)
+		depth++
+	}
+	if schema == nil || schema.Type.IsEmpty() {
+		return true
+	}
+	actual := jsonTypeOf(value)
+	return slices.ContainsFunc(schema.Type.Values(), func(t string) bool {
+		return t == actual || t == "number" && actual == "integer"
+	})
+}
+
+// resolve returns the component schema a local $ref points to, or nil.
+func (v *exampleValidator) resolve(ref string) *Schema {
+	name, ok := strings.CutPrefix(ref, componentSchemaRefPrefix)
+	if !ok || v.doc.Components == nil {
+		return nil
+	}
+	return v.doc.Components.Schemas[unescapePointer(name)]
+}
+
+// normalizeJSON converts an example to the value encoding/json decodes
+// from its encoding, with numbers as json.Number, so that typed Go values
+// and values read from documents are checked alike.
+func normalizeJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// jsonTypeOf returns the JSON Schema type of a normalized value. Numbers
+// with a zero fractional part are integers.
+func jsonTypeOf(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if !strings.ContainsAny(val.String(), ".eE") {
+			return "integer"
+		}
+		if f, err := val.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// equalJSONValue reports whether two values are equal as JSON, comparing
+// numbers by value.
+func equalJSONValue(a, b any) bool {
+	na, errA := normalizeJSON(a)
+	nb, errB := normalizeJSON(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return equalNormalized(na, nb)
+}
+
+func equalNormalized(a, b any) bool {
+	switch va := a.(type) {
+	case json.Number:
+		vb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if va == vb {
+			return true
+		}
+		fa, errA := va.Float64()
+		fb, errB := vb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	case []any:
+		vb, ok := b.([]any)
+		return ok && slices.EqualFunc(va, vb, equalNormalized)
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		return ok && maps.EqualFunc(va, vb, equalNormalized)
+	default:
+		return a == b
+	}
+}
+
+// formatJSONValue returns value encoded as JSON, for messages.
+func formatJSONValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// checkStringFormat returns a message when s does not satisfy one of the
+// date-time, date, uuid, or email formats, or an empty string. Other
+// formats are not checked.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-7.3
+func checkStringFormat(format, s string) string {
+	var ok bool
+	switch format {
+	case FormatDateTime:
+		_, err := time.Parse(time.RFC3339, s)
+		ok = err == nil
+	case FormatDate:
+		_, err := time.Parse(time.DateOnly, s)
+		ok = err == nil
+	case FormatUUID:
+		ok = isUUID(s)
+	case FormatEmail:
+		addr, err := mail.ParseAddress(s)
+		ok = err == nil && addr.Address == s
+	default:
+		return ""
+	}
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("value %q is not a valid %s", s, format)
+}
+
+// isUUID reports whether s is a UUID in its 8-4-4-4-12 hex form.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9562#section-4
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// escapePointer escapes a JSON pointer reference token.
+//
+// See: https://www.rfc-editor.org/rfc/rfc6901#section-3
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// unescapePointer reverses escapePointer.
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
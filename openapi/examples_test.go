@@ -0,0 +1,296 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type exvUser struct {
+	ID    string `json:"id" openapi:"format=uuid"`
+	Email string `json:"email" openapi:"format=email"`
+	Role  string `json:"role" openapi:"enum=admin|member"`
+	Age   int    `json:"age,omitempty" openapi:"example=old"`
+}
+
+type exvPartialUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (exvPartialUser) OpenAPIExample() any {
+	return map[string]any{"id": "u1"}
+}
+
+type exvBrokenUser struct {
+	ID   string `json:"id"`
+	Tags []int  `json:"tags"`
+}
+
+func (exvBrokenUser) OpenAPIExample() any {
+	return map[string]any{"id": 1, "tags": []any{1, "two"}}
+}
+
+func TestDocumentValidateExamples(t *testing.T) {
+	t.Run("conforming examples", func(t *testing.T) {
+		doc := &Document{
+			Components: &Components{Schemas: map[string]*Schema{
+				"User": {
+					Type:       SchemaTypeObject,
+					Required:   []string{"id"},
+					Properties: map[string]*Schema{"id": {Type: SchemaTypeString, Format: FormatUUID}},
+					Example:    map[string]any{"id": "550e8400-e29b-41d4-a716-446655440000"},
+				},
+			}},
+			Paths: map[string]*PathItem{"/users": {Get: &Operation{
+				Parameters: []*Parameter{{
+					Name: "limit", In: "query",
+					Schema:  &Schema{Type: SchemaTypeInteger},
+					Example: 10,
+				}},
+				Responses: map[string]*Response{"200": {Content: map[string]*MediaType{
+					"application/json": {
+						Schema: &Schema{Type: SchemaTypeArray, Items: &Schema{Ref: "#/components/schemas/User"}},
+						Examples: map[string]*Example{
+							"one": {Value: []any{map[string]any{"id": "550e8400-e29b-41d4-a716-446655440000"}}},
+						},
+					},
+				}}},
+			}}},
+		}
+
+		assert.Empty(t, doc.ValidateExamples())
+	})
+
+	t.Run("component schema example", func(t *testing.T) {
+		doc := &Document{Components: &Components{Schemas: map[string]*Schema{
+			"Event": {
+				Type:     SchemaTypeObject,
+				Required: []string{"id", "at"},
+				Properties: map[string]*Schema{
+					"id":     {Type: SchemaTypeInteger},
+					"at":     {Type: SchemaTypeString, Format: FormatDateTime},
+					"kind":   {Type: SchemaTypeString, Enum: []any{"a", "b"}},
+					"scores": {Type: SchemaTypeArray, Items: &Schema{Type: SchemaTypeNumber}},
+				},
+				Example: map[string]any{"id": 1.5, "kind": "c", "scores": []any{1, "x"}},
+			},
+		}}}
+
+		violations := doc.ValidateExamples()
+		require.Len(t, violations, 4)
+		for _, v := range violations {
+			assert.Equal(t, "#/components/schemas/Event/example", v.Location)
+		}
+		assert.Equal(t, ExampleViolation{
+			Location: "#/components/schemas/Event/example", Message: `missing required property "at"`, MissingRequired: true,
+		}, violations[0])
+		assert.Equal(t, "/id", violations[1].Pointer)
+		assert.Equal(t, "expected integer, got number", violations[1].Message)
+		assert.Equal(t, "/kind", violations[2].Pointer)
+		assert.Equal(t, `value "c" is not one of the enum values`, violations[2].Message)
+		assert.Equal(t, "#/components/schemas/Event/example: /scores/1: expected number, got string", violations[3].String())
+	})
+
+	t.Run("formats", func(t *testing.T) {
+		tests := []struct {
+			format string
+			valid  string
+			bad    string
+		}{
+			{FormatDateTime, "2026-01-02T15:04:05Z", "2026-01-02 15:04"},
+			{FormatDate, "2026-01-02", "02/01/2026"},
+			{FormatUUID, "550E8400-e29b-41d4-a716-446655440000", "550e8400e29b41d4a716446655440000"},
+			{FormatEmail, "alice@example.com", "Alice <alice@example.com>"},
+			{"hostname", "any value", ""},
+		}
+		for _, tt := range tests {
+			assert.Empty(t, checkStringFormat(tt.format, tt.valid), tt.format)
+			if tt.bad != "" {
+				assert.Equal(t, `value "`+tt.bad+`" is not a valid `+tt.format, checkStringFormat(tt.format, tt.bad))
+			}
+		}
+	})
+
+	t.Run("named media type examples", func(t *testing.T) {
+		doc := &Document{Paths: map[string]*PathItem{"/users/{id}": {Put: &Operation{
+			RequestBody: &RequestBody{Content: map[string]*MediaType{
+				"application/json": {
+					Schema: &Schema{Type: SchemaTypeObject, Required: []string{"name"}},
+					Examples: map[string]*Example{
+						"full":    {Value: map[string]any{"name": "Alice"}},
+						"partial": {Value: map[string]any{}},
+						"remote":  {ExternalValue: "https://example.com/user.json"},
+					},
+				},
+			}},
+		}}}}
+
+		violations := doc.ValidateExamples()
+		require.Len(t, violations, 1)
+		assert.Equal(t, "#/paths/~1users~1{id}/put/requestBody/content/application~1json/examples/partial/value", violations[0].Location)
+		assert.True(t, violations[0].MissingRequired)
+	})
+
+	t.Run("parameters and headers", func(t *testing.T) {
+		doc := &Document{Paths: map[string]*PathItem{"/items": {
+			Parameters: []*Parameter{{Name: "page", In: "query", Schema: &Schema{Type: SchemaTypeInteger}, Example: "first"}},
+			Get: &Operation{Responses: map[string]*Response{"200": {Headers: map[string]*Header{
+				"X-Rate-Limit": {Schema: &Schema{Type: SchemaTypeInteger}, Examples: map[string]*Example{"max": {Value: true}}},
+			}}}},
+		}}}
+
+		violations := doc.ValidateExamples()
+		require.Len(t, violations, 2)
+		assert.Equal(t, "#/paths/~1items/parameters/0/example", violations[0].Location)
+		assert.Equal(t, "expected integer, got string", violations[0].Message)
+		assert.Equal(t, "#/paths/~1items/get/responses/200/headers/X-Rate-Limit/examples/max/value", violations[1].Location)
+	})
+
+	t.Run("composition", func(t *testing.T) {
+		pet := &Schema{
+			Type:       SchemaTypeObject,
+			Required:   []string{"name"},
+			Properties: map[string]*Schema{"name": {Type: SchemaTypeString}},
+		}
+		doc := &Document{Components: &Components{Schemas: map[string]*Schema{
+			"Pet": pet,
+			"Owner": {
+				Type: SchemaTypeObject,
+				Properties: map[string]*Schema{
+					"pet":  {AnyOf: []*Schema{{Ref: "#/components/schemas/Pet"}, {Type: SchemaTypeNull}}},
+					"id":   {OneOf: []*Schema{{Type: SchemaTypeString}, {Type: SchemaTypeInteger}}},
+					"code": {OneOf: []*Schema{{Type: SchemaTypeNumber}, {Type: SchemaTypeInteger}}},
+					"base": {AllOf: []*Schema{{Ref: "#/components/schemas/Pet"}}},
+					"mode": {Const: "auto"},
+				},
+				Examples: []any{
+					map[string]any{"pet": nil, "id": "a", "code": 1.5, "base": map[string]any{"name": "Rex"}, "mode": "auto"},
+					map[string]any{"pet": map[string]any{}, "id": true, "code": 2, "base": map[string]any{}, "mode": "manual"},
+				},
+			},
+		}}}
+
+		violations := doc.ValidateExamples()
+		require.Len(t, violations, 5)
+		for _, v := range violations {
+			assert.Equal(t, "#/components/schemas/Owner/examples/1", v.Location)
+		}
+		assert.Equal(t, []string{"/base", "/code", "/id", "/mode", "/pet"}, []string{
+			violations[0].Pointer, violations[1].Pointer, violations[2].Pointer, violations[3].Pointer, violations[4].Pointer,
+		})
+		assert.True(t, violations[0].MissingRequired)
+		assert.Equal(t, "value matches 2 schemas in oneOf, expected exactly one", violations[1].Message)
+		assert.Equal(t, "value does not match any schema in oneOf", violations[2].Message)
+		assert.Equal(t, `value "manual" does not equal const "auto"`, violations[3].Message)
+		assert.True(t, violations[4].MissingRequired)
+	})
+
+	t.Run("self-referencing schema", func(t *testing.T) {
+		doc := &Document{Components: &Components{Schemas: map[string]*Schema{
+			"Loop": {Ref: "#/components/schemas/Loop", Example: 1},
+			"Node": {
+				Type: SchemaTypeObject,
+				Properties: map[string]*Schema{
+					"next": {Ref: "#/components/schemas/Node"},
+				},
+				Example: map[string]any{"next": map[string]any{"next": "end"}},
+			},
+		}}}
+
+		violations := doc.ValidateExamples()
+		require.Len(t, violations, 1)
+		assert.Equal(t, "/next/next", violations[0].Pointer)
+	})
+
+	t.Run("unencodable example", func(t *testing.T) {
+		doc := &Document{Components: &Components{Schemas: map[string]*Schema{
+			"Bad": {Example: make(chan int)},
+		}}}
+
+		violations := doc.ValidateExamples()
+		require.Len(t, violations, 1)
+		assert.Contains(t, violations[0].Message, "cannot be encoded as JSON")
+	})
+}
+
+func TestSpecValidateExamples(t *testing.T) {
+	newSpec := func() (*Spec, *mux.Router) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		noop := func(http.ResponseWriter, *http.Request) {}
+		spec.Route(r.HandleFunc("/users", noop).Methods(http.MethodPost)).
+			Request(exvUser{}).
+			Response(http.StatusOK, exvPartialUser{})
+		spec.Route(r.HandleFunc("/broken", noop).Methods(http.MethodGet)).
+			Response(http.StatusOK, exvBrokenUser{})
+		return spec, r
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		spec, r := newSpec()
+		doc, err := spec.BuildE(r)
+		require.NoError(t, err)
+		assert.Empty(t, doc.Warnings())
+		assert.Len(t, doc.ValidateExamples(), 4)
+	})
+
+	t.Run("field-level tag example", func(t *testing.T) {
+		spec, r := newSpec()
+		violations := spec.Build(r).ValidateExamples()
+		require.NotEmpty(t, violations)
+		assert.Equal(t, "#/components/schemas/exvUser/properties/age/example", violations[len(violations)-1].Location)
+		assert.Equal(t, "expected integer, got string", violations[len(violations)-1].Message)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.ValidateExamples(ExampleValidationWarn)
+		doc, err := spec.BuildE(r)
+		require.NoError(t, err)
+		assert.Len(t, doc.Warnings(), 4)
+		assert.Contains(t, doc.Warnings(), `example #/components/schemas/exvPartialUser/example: missing required property "name"`)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.ValidateExamples(ExampleValidationPartial)
+		doc, err := spec.BuildE(r)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidExample)
+		assert.Equal(t, []string{
+			`example #/components/schemas/exvPartialUser/example: missing required property "name"`,
+		}, doc.Warnings())
+
+		var joined interface{ Unwrap() []error }
+		require.True(t, errors.As(err, &joined))
+		assert.Len(t, joined.Unwrap(), 3)
+		assert.Contains(t, err.Error(), "openapi: invalid example #/components/schemas/exvBrokenUser/example: /id: expected string, got integer")
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.ValidateExamples(ExampleValidationStrict)
+		doc, err := spec.BuildE(r)
+		require.Error(t, err)
+		assert.Empty(t, doc.Warnings())
+
+		var joined interface{ Unwrap() []error }
+		require.True(t, errors.As(err, &joined))
+		assert.Len(t, joined.Unwrap(), 4)
+	})
+
+	t.Run("inherited by scope", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.ValidateExamples(ExampleValidationStrict)
+		_, err := spec.Scope("/broken", Info{Title: "Broken", Version: "1.0.0"}).BuildE(r)
+		require.ErrorIs(t, err, ErrInvalidExample)
+
+		_, err = spec.Scope("/broken", Info{Title: "Broken", Version: "1.0.0"}).ValidateExamples(ExampleValidationOff).BuildE(r)
+		require.NoError(t, err)
+	})
+}
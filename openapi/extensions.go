@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Extensions holds specification extensions: fields whose names start with
+// "x-", serialized alongside the fixed fields of the object that carries
+// them.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#specification-extensions
+type Extensions map[string]any
+
+// extensionPrefix is the required prefix of specification extension names.
+const extensionPrefix = "x-"
+
+// marshalWithExtensions encodes v, which must encode as a JSON object, and
+// appends the extension fields to it.
+func marshalWithExtensions(v any, ext Extensions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(ext) == 0 {
+		return data, err
+	}
+	for name := range ext {
+		if !strings.HasPrefix(name, extensionPrefix) {
+			return nil, fmt.Errorf("openapi: extension %q must start with %q", name, extensionPrefix)
+		}
+	}
+	extData, err := json.Marshal(map[string]any(ext))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 2 { // "{}"
+		return extData, nil
+	}
+	out := make([]byte, 0, len(data)+len(extData))
+	out = append(out, data[:len(data)-1]...)
+	out = append(out, ',')
+	return append(out, extData[1:]...), nil
+}
+
+// unmarshalExtensions returns the "x-" fields of a JSON object, or nil when
+// there are none.
+func unmarshalExtensions(data []byte) (Extensions, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var ext Extensions
+	for name, raw := range fields {
+		if !strings.HasPrefix(name, extensionPrefix) {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		if ext == nil {
+			ext = make(Extensions)
+		}
+		ext[name] = v
+	}
+	return ext, nil
+}
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaTypeExtensions(t *testing.T) {
+	t.Run("marshal inlines extensions", func(t *testing.T) {
+		mt := MediaType{
+			Schema:     &Schema{Type: SchemaTypeString},
+			Extensions: Extensions{"x-b": 2, "x-a": "one"},
+		}
+		data, err := json.Marshal(mt)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"schema":{"type":"string"},"x-a":"one","x-b":2}`, string(data))
+	})
+
+	t.Run("marshal without fields", func(t *testing.T) {
+		data, err := json.Marshal(MediaType{Extensions: Extensions{"x-a": true}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"x-a":true}`, string(data))
+
+		data, err = json.Marshal(MediaType{})
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(data))
+	})
+
+	t.Run("marshal rejects names without prefix", func(t *testing.T) {
+		_, err := json.Marshal(MediaType{Extensions: Extensions{"sse": 1}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `openapi: extension "sse" must start with "x-"`)
+	})
+
+	t.Run("unmarshal collects extensions", func(t *testing.T) {
+		var mt MediaType
+		require.NoError(t, json.Unmarshal([]byte(`{"schema":{"type":"string"},"x-a":"one","other":1}`), &mt))
+		assert.Equal(t, SchemaTypeString, mt.Schema.Type)
+		assert.Equal(t, Extensions{"x-a": "one"}, mt.Extensions)
+
+		mt = MediaType{}
+		require.NoError(t, json.Unmarshal([]byte(`{"example":1}`), &mt))
+		assert.Nil(t, mt.Extensions)
+	})
+
+	t.Run("unmarshal invalid", func(t *testing.T) {
+		var mt MediaType
+		assert.Error(t, json.Unmarshal([]byte(`[]`), &mt))
+	})
+}
+
+func TestOperationAndSchemaExtensions(t *testing.T) {
+	t.Run("operation round trip", func(t *testing.T) {
+		op := Operation{OperationID: "listUsers", Extensions: Extensions{"x-a": "one"}}
+		data, err := json.Marshal(op)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"operationId":"listUsers","x-a":"one"}`, string(data))
+
+		var parsed Operation
+		require.NoError(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, op, parsed)
+	})
+
+	t.Run("schema round trip", func(t *testing.T) {
+		s := Schema{Type: SchemaTypeString, Deprecated: true, Extensions: Extensions{"x-a": "one"}}
+		data, err := json.Marshal(s)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"string","deprecated":true,"x-a":"one"}`, string(data))
+
+		var parsed Schema
+		require.NoError(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, s, parsed)
+	})
+
+	t.Run("nested schema", func(t *testing.T) {
+		s := Schema{Properties: map[string]*Schema{"old": {Extensions: Extensions{"x-a": true}}}}
+		data, err := json.Marshal(&s)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"properties":{"old":{"x-a":true}}}`, string(data))
+	})
+
+	t.Run("unmarshal invalid", func(t *testing.T) {
+		var op Operation
+		assert.Error(t, json.Unmarshal([]byte(`[]`), &op))
+		var s Schema
+		assert.Error(t, json.Unmarshal([]byte(`[]`), &s))
+	})
+}
@@ -0,0 +1,48 @@
+// Command gen extracts operation summaries and descriptions from handler
+// doc comments and writes them to a generated Go file for use with
+// openapi.Spec.ApplyGenerated.
+//
+// The generator parses the non-test Go files of a package and collects the
+// handlers that are attached to a spec, either through Route:
+//
+//	spec.Route(r.HandleFunc("/users", listUsers).Methods(http.MethodGet))
+//
+// or through a named route that is also passed to Op:
+//
+//	r.HandleFunc("/users/{id}", srv.getUser).Name("getUser")
+//	spec.Op("getUser").Response(http.StatusOK, User{})
+//
+// Handlers may be package-level functions or methods. The first sentence of
+// the doc comment becomes the summary (a leading handler name is dropped),
+// and the paragraphs after the first one become the description. Handlers
+// without a doc comment are skipped.
+//
+// Usage with go:generate:
+//
+//	//go:generate go run github.com/vitalvas/kasper/openapi/gen -output openapi_docs_gen.go
+//
+// The generated file declares a map keyed by handler symbol, qualified by
+// the import path of the package so that maps generated for several
+// packages can be applied to the same spec:
+//
+//	var openAPIDocs = map[string]openapi.OperationDoc{
+//	    "example.com/api.listUsers":         {Summary: "Returns all users."},
+//	    "example.com/api.(*Server).getUser": {Summary: "Returns a single user.", Description: "..."},
+//	}
+//
+// The import path is resolved from the nearest go.mod; handlers of package
+// main are keyed as "main.listUsers". Methods of generic types use "[...]"
+// for the type parameters, e.g. "example.com/api.(*Store[...]).list", as
+// reported by runtime.FuncForPC.
+//
+// which is then registered on the spec:
+//
+//	spec.ApplyGenerated(openAPIDocs)
+//
+// Flags:
+//
+//	-dir     package directory to parse (default ".")
+//	-output  generated file name, relative to -dir (default "openapi_docs_gen.go")
+//	-var     name of the generated map variable (default "openAPIDocs")
+//	-pkg     import path of the package (default: resolved from go.mod)
+package main
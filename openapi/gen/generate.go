@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// config holds the generator options.
+type config struct {
+	dir     string
+	output  string
+	varName string
+	pkgPath string // import path of the package; resolved from go.mod if empty
+}
+
+// operationDoc mirrors openapi.OperationDoc for the generated output.
+type operationDoc struct {
+	summary     string
+	description string
+}
+
+// generate parses the package in cfg.dir and returns the formatted source
+// of the generated docs file.
+func generate(cfg config) ([]byte, error) {
+	fset := token.NewFileSet()
+	files, pkgName, err := parsePackage(fset, cfg.dir, cfg.output)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgPath := cfg.pkgPath
+	if pkgName == "main" {
+		pkgPath = "main"
+	}
+	if pkgPath == "" {
+		if pkgPath, err = importPath(cfg.dir); err != nil {
+			return nil, err
+		}
+	}
+
+	decls := collectFuncDecls(files)
+	docs := make(map[string]operationDoc)
+	for _, expr := range collectHandlerExprs(files) {
+		fd := resolveHandler(expr, decls)
+		if fd == nil || fd.Doc == nil {
+			continue
+		}
+		od, ok := extractDoc(fd.Name.Name, fd.Doc.Text())
+		if !ok {
+			continue
+		}
+		sym := funcSymbol(fd)
+		if sym == "" {
+			continue
+		}
+		docs[pathToPrefix(pkgPath)+"."+sym] = od
+	}
+
+	return render(pkgName, cfg.varName, docs)
+}
+
+// parsePackage parses the non-test Go files in dir, skipping the generated
+// output file. Files belonging to a different package than the first parsed
+// file are ignored.
+func parsePackage(fset *token.FileSet, dir, output string) ([]*ast.File, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var files []*ast.File
+	var pkgName string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, "", err
+		}
+		if pkgName == "" {
+			pkgName = f.Name.Name
+		}
+		if f.Name.Name != pkgName {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, "", errors.New("no Go files found")
+	}
+	return files, pkgName, nil
+}
+
+// importPath returns the import path of the package in dir: the module path
+// from the nearest go.mod joined with the location of dir inside the module.
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			mod := modulePath(data)
+			if mod == "" {
+				return "", fmt.Errorf("%s: no module directive", filepath.Join(root, "go.mod"))
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+			if rel == "." {
+				return mod, nil
+			}
+			return mod + "/" + filepath.ToSlash(rel), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", errors.New("no go.mod found; set the import path with -pkg")
+		}
+		root = parent
+	}
+}
+
+// modulePath returns the path of the module directive in a go.mod file.
+func modulePath(data []byte) string {
+	for line := range strings.Lines(string(data)) {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if path, err := strconv.Unquote(fields[1]); err == nil {
+			return path
+		}
+		return fields[1]
+	}
+	return ""
+}
+
+// pathToPrefix escapes an import path the way the linker does in symbol
+// names, so that keys match runtime.FuncForPC: dots in the last path
+// element, '%', '"', spaces, control characters, and non-ASCII bytes are
+// written as %xx.
+func pathToPrefix(path string) string {
+	slash := strings.LastIndex(path, "/")
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c <= ' ' || (c == '.' && i > slash) || c == '%' || c == '"' || c >= 0x7F {
+			fmt.Fprintf(&b, "%%%02x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// collectFuncDecls indexes function and method declarations by name.
+func collectFuncDecls(files []*ast.File) map[string][]*ast.FuncDecl {
+	decls := make(map[string][]*ast.FuncDecl)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if fd, ok := d.(*ast.FuncDecl); ok {
+				decls[fd.Name.Name] = append(decls[fd.Name.Name], fd)
+			}
+		}
+	}
+	return decls
+}
+
+// collectHandlerExprs returns the handler expressions of routes that are
+// attached to a spec, either wrapped in a single-argument Route call or
+// named with a name that is also passed to Op.
+func collectHandlerExprs(files []*ast.File) []ast.Expr {
+	opNames := make(map[string]bool)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if name, ok := selectorCallArg(n, "Op"); ok {
+				opNames[name] = true
+			}
+			return true
+		})
+	}
+
+	var exprs []ast.Expr
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			switch sel.Sel.Name {
+			case "Route":
+				if h := findHandlerInChain(call.Args[0]); h != nil {
+					exprs = append(exprs, h)
+				}
+			case "Name":
+				if name, ok := stringLit(call.Args[0]); ok && opNames[name] {
+					if h := findHandlerInChain(sel.X); h != nil {
+						exprs = append(exprs, h)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return exprs
+}
+
+// findHandlerInChain walks down a fluent call chain such as
+// r.HandleFunc("/x", h).Methods("GET") and returns the handler argument.
+func findHandlerInChain(expr ast.Expr) ast.Expr {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return nil
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return nil
+		}
+		switch {
+		case sel.Sel.Name == "HandleFunc" && len(call.Args) == 2:
+			return call.Args[1]
+		case sel.Sel.Name == "HandlerFunc" && len(call.Args) == 1:
+			return call.Args[0]
+		case sel.Sel.Name == "Handle" && len(call.Args) == 2:
+			return unwrapHandlerFunc(call.Args[1])
+		case sel.Sel.Name == "Handler" && len(call.Args) == 1:
+			return unwrapHandlerFunc(call.Args[0])
+		}
+		expr = sel.X
+	}
+}
+
+// unwrapHandlerFunc returns h for an http.HandlerFunc(h) conversion.
+func unwrapHandlerFunc(expr ast.Expr) ast.Expr {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "HandlerFunc" {
+		return call.Args[0]
+	}
+	return nil
+}
+
+// resolveHandler maps a handler expression to its declaration. Identifiers
+// resolve to package-level functions; selectors resolve to methods, provided
+// the method name is unambiguous within the package.
+func resolveHandler(expr ast.Expr, decls map[string][]*ast.FuncDecl) *ast.FuncDecl {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		for _, fd := range decls[e.Name] {
+			if fd.Recv == nil {
+				return fd
+			}
+		}
+	case *ast.SelectorExpr:
+		var found *ast.FuncDecl
+		for _, fd := range decls[e.Sel.Name] {
+			if fd.Recv == nil {
+				continue
+			}
+			if found != nil {
+				return nil
+			}
+			found = fd
+		}
+		return found
+	}
+	return nil
+}
+
+// funcSymbol returns the package-relative symbol for a declaration. Prefixed
+// with the package path, it matches the keys used by
+// openapi.Spec.ApplyGenerated. Methods of generic types are written with
+// "[...]" in place of the type parameters, as runtime.FuncForPC reports
+// them: "(*Store[...]).list". It returns an empty string for receivers it
+// cannot name.
+func funcSymbol(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	recv := fd.Recv.List[0].Type
+	star, ptr := recv.(*ast.StarExpr)
+	if ptr {
+		recv = star.X
+	}
+	var typeParams string
+	switch idx := recv.(type) {
+	case *ast.IndexExpr:
+		recv, typeParams = idx.X, "[...]"
+	case *ast.IndexListExpr:
+		recv, typeParams = idx.X, "[...]"
+	}
+	id, ok := recv.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if ptr {
+		return fmt.Sprintf("(*%s%s).%s", id.Name, typeParams, fd.Name.Name)
+	}
+	return fmt.Sprintf("%s%s.%s", id.Name, typeParams, fd.Name.Name)
+}
+
+// extractDoc splits a doc comment into summary and description. The summary
+// is the first sentence with a leading function name removed; the
+// description is everything after the first paragraph.
+func extractDoc(funcName, text string) (operationDoc, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return operationDoc{}, false
+	}
+
+	first, rest, _ := strings.Cut(text, "\n\n")
+
+	var p doc.Package
+	summary := p.Synopsis(first)
+	if after, ok := strings.CutPrefix(summary, funcName+" "); ok {
+		summary = upperFirst(after)
+	}
+
+	od := operationDoc{
+		summary:     summary,
+		description: strings.TrimSpace(rest),
+	}
+	return od, od.summary != "" || od.description != ""
+}
+
+// upperFirst capitalizes the first rune of s.
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// selectorCallArg reports the string literal argument of a call to a method
+// with the given name and a single argument.
+func selectorCallArg(n ast.Node, method string) (string, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != method {
+		return "", false
+	}
+	return stringLit(call.Args[0])
+}
+
+// stringLit returns the value of a string literal expression.
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// render produces the gofmt-formatted generated file.
+func render(pkgName, varName string, docs map[string]operationDoc) ([]byte, error) {
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by github.com/vitalvas/kasper/openapi/gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	buf.WriteString("import \"github.com/vitalvas/kasper/openapi\"\n\n")
+	fmt.Fprintf(&buf, "// %s holds operation docs extracted from handler doc comments.\n", varName)
+	fmt.Fprintf(&buf, "var %s = map[string]openapi.OperationDoc{\n", varName)
+	for _, k := range keys {
+		d := docs[k]
+		fmt.Fprintf(&buf, "%s: {", strconv.Quote(k))
+		var fields []string
+		if d.summary != "" {
+			fields = append(fields, fmt.Sprintf("Summary: %s", strconv.Quote(d.summary)))
+		}
+		if d.description != "" {
+			fields = append(fields, fmt.Sprintf("Description: %s", strconv.Quote(d.description)))
+		}
+		buf.WriteString(strings.Join(fields, ", "))
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
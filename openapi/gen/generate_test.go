@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const handlersSource = `package api
+
+import (
+	"net/http"
+
+	"github.com/vitalvas/kasper/mux"
+	"github.com/vitalvas/kasper/openapi"
+)
+
+type Server struct{}
+
+// listUsers returns all users.
+//
+// Results are ordered by creation time.
+// Pagination is not supported.
+func listUsers(http.ResponseWriter, *http.Request) {}
+
+// Creates a user. The body must be JSON.
+func createUser(http.ResponseWriter, *http.Request) {}
+
+// getUser fetches a single user by ID.
+func (s *Server) getUser(http.ResponseWriter, *http.Request) {}
+
+// deleteUser removes a user.
+func (s Server) deleteUser(http.ResponseWriter, *http.Request) {}
+
+// health is not attached to the spec.
+func health(http.ResponseWriter, *http.Request) {}
+
+func undocumented(http.ResponseWriter, *http.Request) {}
+
+func register(r *mux.Router, spec *openapi.Spec, s *Server) {
+	spec.Route(r.HandleFunc("/users", listUsers).Methods(http.MethodGet))
+	spec.Route(r.Handle("/users", http.HandlerFunc(createUser)).Methods(http.MethodPost))
+	r.HandleFunc("/users/{id}", s.getUser).Methods(http.MethodGet).Name("getUser")
+	spec.Op("getUser")
+	spec.Route(r.NewRoute().Path("/users/{id}").Methods(http.MethodDelete).HandlerFunc(s.deleteUser))
+	spec.Route(r.HandleFunc("/undocumented", undocumented))
+	r.HandleFunc("/health", health).Name("health")
+}
+`
+
+func writePackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644))
+	}
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("emits docs for handlers attached to a spec", func(t *testing.T) {
+		dir := writePackage(t, map[string]string{
+			"go.mod":      "module example.com/api\n",
+			"handlers.go": handlersSource,
+		})
+
+		out, err := generate(config{dir: dir, output: "openapi_docs_gen.go", varName: "openAPIDocs"})
+		require.NoError(t, err)
+
+		expected := `// Code generated by github.com/vitalvas/kasper/openapi/gen; DO NOT EDIT.
+
+package api
+
+import "github.com/vitalvas/kasper/openapi"
+
+// openAPIDocs holds operation docs extracted from handler doc comments.
+var openAPIDocs = map[string]openapi.OperationDoc{
+	"example.com/api.(*Server).getUser": {Summary: "Fetches a single user by ID."},
+	"example.com/api.Server.deleteUser": {Summary: "Removes a user."},
+	"example.com/api.createUser":        {Summary: "Creates a user."},
+	"example.com/api.listUsers":         {Summary: "Returns all users.", Description: "Results are ordered by creation time.\nPagination is not supported."},
+}
+`
+		assert.Equal(t, expected, string(out))
+	})
+
+	t.Run("ignores test files, output file, and foreign packages", func(t *testing.T) {
+		dir := writePackage(t, map[string]string{
+			"go.mod":              "module example.com/api\n",
+			"a.go":                "package api\n",
+			"a_test.go":           "package api\n\nthis is not valid Go",
+			"openapi_docs_gen.go": "package api\n\nthis is not valid Go either",
+			"tool.go":             "//go:build ignore\n\npackage main\n",
+		})
+
+		out, err := generate(config{dir: dir, output: "openapi_docs_gen.go", varName: "docs"})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "package api")
+		assert.Contains(t, string(out), "var docs = map[string]openapi.OperationDoc{}")
+	})
+
+	t.Run("keys are qualified by import path", func(t *testing.T) {
+		const src = "package %s\n\nfunc register(spec *openapi.Spec, r *mux.Router) {\n\tspec.Route(r.HandleFunc(\"/users\", listUsers))\n}\n\n// listUsers returns all users.\nfunc listUsers(http.ResponseWriter, *http.Request) {}\n"
+
+		root := writePackage(t, map[string]string{"go.mod": "module \"example.com/shop\" // comment\n"})
+		nested := filepath.Join(root, "internal", "users.v2")
+		require.NoError(t, os.MkdirAll(nested, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(nested, "h.go"), []byte(fmt.Sprintf(src, "users")), 0o644))
+
+		out, err := generate(config{dir: nested, output: "gen.go", varName: "docs"})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"example.com/shop/internal/users%2ev2.listUsers"`)
+
+		out, err = generate(config{dir: nested, output: "gen.go", varName: "docs", pkgPath: "example.org/users"})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"example.org/users.listUsers"`)
+
+		cmd := writePackage(t, map[string]string{"main.go": fmt.Sprintf(src, "main")})
+		out, err = generate(config{dir: cmd, output: "gen.go", varName: "docs"})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"main.listUsers"`)
+	})
+
+	t.Run("generic receiver does not collide with a function", func(t *testing.T) {
+		dir := writePackage(t, map[string]string{
+			"go.mod": "module example.com/api\n",
+			"h.go": `package api
+
+type Store[T any] struct{}
+
+type Pair[K comparable, V any] struct{}
+
+// list returns all users.
+func list(http.ResponseWriter, *http.Request) {}
+
+// list returns the stored items.
+func (s *Store[T]) list(http.ResponseWriter, *http.Request) {}
+
+// get returns one pair.
+func (p Pair[K, V]) get(http.ResponseWriter, *http.Request) {}
+
+func register(spec *openapi.Spec, r *mux.Router, s *Store[int], p Pair[string, int]) {
+	spec.Route(r.HandleFunc("/users", list))
+	spec.Route(r.HandleFunc("/items", s.list))
+	spec.Route(r.HandleFunc("/pairs", p.get))
+}
+`,
+		})
+
+		out, err := generate(config{dir: dir, output: "gen.go", varName: "docs"})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `var docs = map[string]openapi.OperationDoc{
+	"example.com/api.(*Store[...]).list": {Summary: "Returns the stored items."},
+	"example.com/api.Pair[...].get":      {Summary: "Returns one pair."},
+	"example.com/api.list":               {Summary: "Returns all users."},
+}`)
+	})
+
+	t.Run("missing go.mod", func(t *testing.T) {
+		dir := writePackage(t, map[string]string{"a.go": "package api\n"})
+		_, err := generate(config{dir: dir, output: "gen.go", varName: "docs"})
+		assert.ErrorContains(t, err, "no go.mod found")
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		_, err := generate(config{dir: t.TempDir(), output: "gen.go", varName: "docs"})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := generate(config{dir: filepath.Join(t.TempDir(), "missing"), output: "gen.go", varName: "docs"})
+		assert.Error(t, err)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		dir := writePackage(t, map[string]string{"bad.go": "package api\n\nfunc {"})
+		_, err := generate(config{dir: dir, output: "gen.go", varName: "docs"})
+		assert.Error(t, err)
+	})
+}
+
+func TestPathToPrefix(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"main", "main"},
+		{"example.com/api", "example.com/api"},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml%2ev3"},
+		{"example.com/a b%", "example.com/a%20b%25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, pathToPrefix(tt.path))
+		})
+	}
+}
+
+func TestExtractDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		funcName string
+		text     string
+		expected operationDoc
+		ok       bool
+	}{
+		{"strips leading function name", "listUsers", "listUsers returns users.\n", operationDoc{summary: "Returns users."}, true},
+		{"keeps text without function name", "listUsers", "Lists users. Sorted by name.\n", operationDoc{summary: "Lists users."}, true},
+		{"splits description", "x", "Summary line.\n\nMore detail.\n\nEven more.\n", operationDoc{summary: "Summary line.", description: "More detail.\n\nEven more."}, true},
+		{"empty", "x", "  \n", operationDoc{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od, ok := extractDoc(tt.funcName, tt.text)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, od)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "package directory to parse")
+	output := flag.String("output", "openapi_docs_gen.go", "generated file name, relative to -dir")
+	varName := flag.String("var", "openAPIDocs", "name of the generated map variable")
+	pkgPath := flag.String("pkg", "", "import path of the package (default: resolved from go.mod)")
+	flag.Parse()
+
+	src, err := generate(config{
+		dir:     *dir,
+		output:  *output,
+		varName: *varName,
+		pkgPath: *pkgPath,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "openapi/gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "openapi/gen: %v\n", err)
+		os.Exit(1)
+	}
+}
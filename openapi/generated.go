@@ -0,0 +1,105 @@
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// OperationDoc holds operation text produced by the openapi/gen code
+// generator from handler doc comments.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object (summary, description)
+type OperationDoc struct {
+	Summary     string
+	Description string
+}
+
+// ApplyGenerated registers generated operation docs. Keys are either an
+// operationId or a handler symbol qualified by its package import path, in
+// the form "example.com/api.listUsers" for functions and
+// "example.com/api.(*Server).listUsers" for methods, so that handlers with
+// the same name in different packages do not share docs. Handlers of
+// package main use "main" as the path. During Build, each operation looks up
+// its operationId first and its handler symbol second; a match fills Summary
+// and Description only when they were not set explicitly through the
+// builder. Multiple calls merge, with later entries overriding earlier ones.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object
+func (s *Spec) ApplyGenerated(docs map[string]OperationDoc) *Spec {
+	if s.generatedDocs == nil {
+		s.generatedDocs = make(map[string]OperationDoc, len(docs))
+	}
+	maps.Copy(s.generatedDocs, docs)
+	return s
+}
+
+// applyGeneratedDoc fills empty Summary and Description fields of op from
+// the generated docs registered via ApplyGenerated.
+func (s *Spec) applyGeneratedDoc(op *Operation, route *mux.Route) {
+	if len(s.generatedDocs) == 0 {
+		return
+	}
+
+	doc, ok := s.generatedDocs[op.OperationID]
+	if !ok || op.OperationID == "" {
+		sym := handlerSymbol(route.GetHandler())
+		if sym == "" {
+			return
+		}
+		if doc, ok = s.generatedDocs[sym]; !ok {
+			return
+		}
+	}
+
+	if op.Summary == "" {
+		op.Summary = doc.Summary
+	}
+	if op.Description == "" {
+		op.Description = doc.Description
+	}
+}
+
+// handlerSymbol returns the package-qualified symbol of a function handler,
+// e.g. "example.com/api.listUsers" or "example.com/api.(*Server).listUsers".
+// It returns an empty string for non-function handlers and anonymous
+// functions.
+func handlerSymbol(h http.Handler) string {
+	if h == nil {
+		return ""
+	}
+	v := reflect.ValueOf(h)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	// Method values are wrapped in a synthetic "-fm" function:
+	// "example.com/api.(*Server).list-fm" -> "example.com/api.(*Server).list".
+	name := strings.TrimSuffix(fn.Name(), "-fm")
+
+	// The symbol starts after the first dot following the last slash of the
+	// import path.
+	_, sym, ok := strings.Cut(name[strings.LastIndex(name, "/")+1:], ".")
+	if !ok {
+		return ""
+	}
+
+	// Closures are named "outer.func1" or "outer.func1.2" and carry no doc
+	// comment.
+	if closureSuffix.MatchString(sym) {
+		return ""
+	}
+	return name
+}
+
+// closureSuffix matches the name the compiler gives to function literals.
+var closureSuffix = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
@@ -0,0 +1,145 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+const testPkgPath = "github.com/vitalvas/kasper/openapi"
+
+func listWidgets(http.ResponseWriter, *http.Request) {}
+
+type widgetServer struct{}
+
+func (s *widgetServer) getWidget(http.ResponseWriter, *http.Request) {}
+
+func (s *widgetServer) funcs(http.ResponseWriter, *http.Request) {}
+
+type widgetStore[T any] struct{}
+
+func (s *widgetStore[T]) listWidgets(http.ResponseWriter, *http.Request) {}
+
+func TestSpecApplyGenerated(t *testing.T) {
+	t.Run("matches function handler symbol", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/widgets", listWidgets).Methods(http.MethodGet))
+		spec.ApplyGenerated(map[string]OperationDoc{
+			testPkgPath + ".listWidgets": {Summary: "List widgets.", Description: "Returns every widget."},
+		})
+
+		op := spec.Build(r).Paths["/widgets"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, "List widgets.", op.Summary)
+		assert.Equal(t, "Returns every widget.", op.Description)
+	})
+
+	t.Run("matches method value handler symbol", func(t *testing.T) {
+		r := mux.NewRouter()
+		srv := &widgetServer{}
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/widgets/{id}", srv.getWidget).Methods(http.MethodGet))
+		spec.ApplyGenerated(map[string]OperationDoc{
+			testPkgPath + ".(*widgetServer).getWidget": {Summary: "Get a widget."},
+		})
+
+		op := spec.Build(r).Paths["/widgets/{id}"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, "Get a widget.", op.Summary)
+	})
+
+	t.Run("operationId takes precedence over handler symbol", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/widgets", listWidgets).Methods(http.MethodGet).Name("listAll")
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Op("listAll")
+		spec.ApplyGenerated(map[string]OperationDoc{
+			testPkgPath + ".listWidgets": {Summary: "By symbol."},
+			"listAll":                    {Summary: "By operationId."},
+		})
+
+		op := spec.Build(r).Paths["/widgets"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, "By operationId.", op.Summary)
+	})
+
+	t.Run("explicit builder values win", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/widgets", listWidgets).Methods(http.MethodGet)).
+			Summary("Explicit summary")
+		spec.ApplyGenerated(map[string]OperationDoc{
+			testPkgPath + ".listWidgets": {Summary: "Generated.", Description: "Generated description."},
+		})
+
+		op := spec.Build(r).Paths["/widgets"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, "Explicit summary", op.Summary)
+		assert.Equal(t, "Generated description.", op.Description)
+	})
+
+	t.Run("same name in another package is not matched", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/widgets", listWidgets).Methods(http.MethodGet))
+		spec.ApplyGenerated(map[string]OperationDoc{
+			"example.com/other.listWidgets": {Summary: "Other package."},
+			"listWidgets":                   {Summary: "Unqualified."},
+		})
+
+		op := spec.Build(r).Paths["/widgets"].Get
+		require.NotNil(t, op)
+		assert.Empty(t, op.Summary)
+	})
+
+	t.Run("later calls merge and override", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.ApplyGenerated(map[string]OperationDoc{"a": {Summary: "A1"}, "b": {Summary: "B"}})
+		spec.ApplyGenerated(map[string]OperationDoc{"a": {Summary: "A2"}})
+
+		assert.Equal(t, "A2", spec.generatedDocs["a"].Summary)
+		assert.Equal(t, "B", spec.generatedDocs["b"].Summary)
+	})
+
+	t.Run("anonymous handlers are not matched", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/anon", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodGet))
+		spec.ApplyGenerated(map[string]OperationDoc{"": {Summary: "Never"}})
+
+		op := spec.Build(r).Paths["/anon"].Get
+		require.NotNil(t, op)
+		assert.Empty(t, op.Summary)
+	})
+}
+
+func TestHandlerSymbol(t *testing.T) {
+	srv := &widgetServer{}
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		expected string
+	}{
+		{"function", http.HandlerFunc(listWidgets), testPkgPath + ".listWidgets"},
+		{"method value", http.HandlerFunc(srv.getWidget), testPkgPath + ".(*widgetServer).getWidget"},
+		{"generic method value", http.HandlerFunc((&widgetStore[int]{}).listWidgets), testPkgPath + ".(*widgetStore[...]).listWidgets"},
+		{"method named like a closure", http.HandlerFunc(srv.funcs), testPkgPath + ".(*widgetServer).funcs"},
+		{"closure", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), ""},
+		{"nested closure", func() http.HandlerFunc {
+			return func(http.ResponseWriter, *http.Request) {}
+		}(), ""},
+		{"non-function handler", mux.NewRouter(), ""},
+		{"nil", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, handlerSymbol(tt.handler))
+		})
+	}
+}
@@ -25,6 +25,9 @@ type groupDefaults struct {
 	responseDescriptions map[string]string             // statusKey -> custom description
 	responseHeaders      map[string]map[string]*Header // statusKey -> headerName -> header
 	responseLinks        map[string]map[string]*Link   // statusKey -> linkName -> link
+
+	envelope    any  // response envelope template
+	envelopeSet bool // distinguishes unset (use the spec envelope) from nil
 }
 
 // RouteGroup provides shared OpenAPI metadata defaults for a logical group
@@ -59,6 +62,8 @@ func (d groupDefaults) clone() groupDefaults {
 		securitySet:  d.securitySet,
 		deprecated:   d.deprecated,
 		externalDocs: d.externalDocs,
+		envelope:     d.envelope,
+		envelopeSet:  d.envelopeSet,
 	}
 	if d.tags != nil {
 		out.tags = append([]string(nil), d.tags...)
@@ -210,6 +215,22 @@ func (g *RouteGroup) ResponseContent(statusCode int, contentType string, body an
 	return g
 }
 
+// ResponseSSE adds a shared Server-Sent Events response documented like
+// OperationBuilder.ResponseSSE.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#specification-extensions
+func (g *RouteGroup) ResponseSSE(statusCode int, eventType any) *RouteGroup {
+	return g.ResponseContent(statusCode, mux.ContentTypeTextEventStream, sseEvent{eventType: eventType})
+}
+
+// ResponseMultipart adds a shared multipart/mixed response documented like
+// OperationBuilder.ResponseMultipart.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#special-considerations-for-multipart-content
+func (g *RouteGroup) ResponseMultipart(statusCode int, parts map[string]any) *RouteGroup {
+	return g.ResponseContent(statusCode, mux.ContentTypeMultipartMixed, multipartBody{parts: parts})
+}
+
 // ResponseDescription sets a custom description for a shared group response.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#response-object (description)
@@ -373,6 +394,11 @@ func (g *RouteGroup) newBuilderWithDefaults() *OperationBuilder {
 		b.meta.externalDocs = g.defaults.externalDocs
 	}
 
+	if g.defaults.envelopeSet {
+		b.meta.envelope = g.defaults.envelope
+		b.meta.envelopeSet = true
+	}
+
 	for key, contents := range g.defaults.responseContents {
 		if contents != nil {
 			if b.meta.responseContents[key] == nil {
@@ -517,6 +517,38 @@ func TestRouteGroup(t *testing.T) {
 		assert.Contains(t, op.Responses["404"].Content, "application/xml")
 	})
 
+	t.Run("shared SSE response from group", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+
+		type Tick struct {
+			At int64 `json:"at"`
+		}
+
+		g := spec.Group().ResponseSSE(http.StatusOK, Tick{})
+		g.Route(r.HandleFunc("/ticks", dummyHandler).Methods(http.MethodGet))
+
+		op := spec.Build(r).Paths["/ticks"].Get
+		require.NotNil(t, op)
+		mt := op.Responses["200"].Content["text/event-stream"]
+		require.NotNil(t, mt)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/Tick"}, mt.Extensions[SSEEventSchemaExtension])
+	})
+
+	t.Run("shared multipart response from group", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+
+		g := spec.Group().ResponseMultipart(http.StatusOK, map[string]any{"file": []byte{}})
+		g.Route(r.HandleFunc("/downloads", dummyHandler).Methods(http.MethodGet))
+
+		op := spec.Build(r).Paths["/downloads"].Get
+		require.NotNil(t, op)
+		mt := op.Responses["200"].Content["multipart/mixed"]
+		require.NotNil(t, mt)
+		assert.Equal(t, map[string]*Encoding{"file": {ContentType: "application/octet-stream"}}, mt.Encoding)
+	})
+
 	t.Run("shared response header from group", func(t *testing.T) {
 		r := mux.NewRouter()
 		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
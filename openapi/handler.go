@@ -6,6 +6,7 @@ import (
 	"html"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -58,6 +59,15 @@ type HandleConfig struct {
 	// Follows the same absolute/relative rules as JSONFilename.
 	YAMLFilename string
 
+	// SingleEndpoint is the path of an additional spec endpoint that
+	// serves both formats, chosen by the Accept header: JSON for
+	// application/json (and when no preference is given), YAML for
+	// application/yaml, application/x-yaml, or text/yaml, and 406 Not
+	// Acceptable otherwise. Empty (the default) disables it. Follows the
+	// same absolute/relative rules as JSONFilename, and works alongside
+	// the JSONFilename and YAMLFilename endpoints.
+	SingleEndpoint string
+
 	// DisableDocs disables the interactive HTML docs UI endpoint.
 	DisableDocs bool
 
@@ -86,8 +96,35 @@ type HandleConfig struct {
 	//
 	// See: https://swagger.io/docs/open-source-tools/swagger-ui/usage/oauth2/
 	InitOAuth map[string]any
+
+	// DocumentSelf adds the JSON and YAML spec endpoints to the generated
+	// document as GET operations tagged "documentation". The routes are
+	// restricted to GET (and HEAD) so they can be documented.
+	DocumentSelf bool
+
+	// DocumentSelfSecurity sets the security requirements of the
+	// self-documented spec endpoints. When nil, the operations are marked
+	// public, overriding document-level security.
+	//
+	// See: https://spec.openapis.org/oas/v3.1.0#security-requirement-object
+	DocumentSelfSecurity []SecurityRequirement
+
+	// Middleware wraps only the routes registered by Handle, for example to
+	// put BasicAuth in front of internal docs. The first middleware is the
+	// outermost, as with mux.Router.Use.
+	Middleware []mux.MiddlewareFunc
+
+	// Authorize, when set, is called for every request to the docs UI and
+	// spec endpoints. Requests it rejects get 403 Forbidden. It runs after
+	// Middleware, so an authentication middleware can populate the request
+	// context it inspects.
+	Authorize func(*http.Request) bool
 }
 
+// DocumentationTag is the tag of the spec endpoints added with
+// HandleConfig.DocumentSelf.
+const DocumentationTag = "documentation"
+
 // jsonFilename returns the configured JSON spec filename, defaulting to "schema.json".
 func (cfg HandleConfig) jsonFilename() string {
 	if cfg.JSONFilename == "" {
@@ -104,6 +141,106 @@ func (cfg HandleConfig) yamlFilename() string {
 	return cfg.YAMLFilename
 }
 
+// wrap applies cfg.Authorize and cfg.Middleware to h, the first
+// middleware outermost and the Authorize check innermost.
+func (cfg HandleConfig) wrap(h http.HandlerFunc) http.Handler {
+	var handler http.Handler = h
+	if authorize := cfg.Authorize; authorize != nil {
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !authorize(r) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			h(w, r)
+		})
+	}
+	for i := len(cfg.Middleware) - 1; i >= 0; i-- {
+		handler = cfg.Middleware[i](handler)
+	}
+	return handler
+}
+
+// documentSelf documents a spec endpoint route when cfg.DocumentSelf is
+// set. It returns nil when the route is not documented.
+func (s *Spec) documentSelf(route *mux.Route, cfg *HandleConfig, format string, contentTypes ...string) *OperationBuilder {
+	if !cfg.DocumentSelf {
+		return nil
+	}
+	route.Methods(http.MethodGet)
+	op := s.Route(route).
+		Summary(fmt.Sprintf("OpenAPI document (%s)", format)).
+		Tags(DocumentationTag).
+		Security(cfg.DocumentSelfSecurity...)
+	for _, ct := range contentTypes {
+		op.ResponseContent(http.StatusOK, ct, &Schema{Type: SchemaTypeObject})
+	}
+	if !cfg.DisableETag {
+		op.Response(http.StatusNotModified, nil)
+	}
+	return op
+}
+
+// specCache builds and serializes the document on first use and keeps the
+// bytes and their ETag. The endpoints registered by one Handle call share
+// a cache per format.
+type specCache struct {
+	once    sync.Once
+	marshal func(*Document) ([]byte, error)
+	data    []byte
+	etag    string
+	err     error
+}
+
+func newSpecCache(marshal func(*Document) ([]byte, error)) *specCache {
+	return &specCache{marshal: marshal}
+}
+
+// load returns the serialized document and its ETag, which is empty when
+// cfg.DisableETag is set.
+func (c *specCache) load(s *Spec, r *mux.Router, cfg *HandleConfig) ([]byte, string, error) {
+	c.once.Do(func() {
+		defer func() {
+			if rv := recover(); rv != nil {
+				c.err = fmt.Errorf("%v", rv)
+			}
+		}()
+		doc := s.Build(r)
+		c.data, c.err = c.marshal(doc)
+		if c.err == nil && !cfg.DisableETag {
+			c.etag = computeETag(c.data)
+		}
+	})
+	return c.data, c.etag, c.err
+}
+
+// serve writes the cached document with the given content type, answering
+// a matching If-None-Match with 304 Not Modified.
+func (c *specCache) serve(w http.ResponseWriter, req *http.Request, s *Spec, r *mux.Router, cfg *HandleConfig, contentType, format string) {
+	data, etag, err := c.load(s, r, cfg)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to serialize OpenAPI spec as %s", format), http.StatusInternalServerError)
+		return
+	}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+		if etagMatch(req.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func marshalSpecJSON(doc *Document) ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func marshalSpecYAML(doc *Document) ([]byte, error) {
+	return yaml.Marshal(doc)
+}
+
 // resolvePath returns the full route path for a filename.
 // Absolute filenames (starting with "/") are returned as-is.
 // Relative filenames are joined under basePath.
@@ -142,6 +279,14 @@ func resolvePath(basePath, filename string) string {
 // Both <basePath> and <basePath>/ serve the docs UI. The spec is built once
 // on first request and cached.
 //
+// HandleConfig.Middleware wraps only these routes, and DocumentSelf adds the
+// spec endpoints to the document:
+//
+//	spec.Handle(r, "/swagger", &HandleConfig{
+//	    DocumentSelf: true,
+//	    Middleware:   []mux.MiddlewareFunc{basicAuth},
+//	})
+//
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-document
 func (s *Spec) Handle(r *mux.Router, basePath string, cfg *HandleConfig) {
 	if cfg == nil {
@@ -152,24 +297,34 @@ func (s *Spec) Handle(r *mux.Router, basePath string, cfg *HandleConfig) {
 	jsonFile := cfg.jsonFilename()
 	yamlFile := cfg.yamlFilename()
 
-	var jsonPath, yamlPath string
+	var jsonPath, yamlPath, singlePath string
+	jsonCache := newSpecCache(marshalSpecJSON)
+	yamlCache := newSpecCache(marshalSpecYAML)
 
 	if jsonFile != SchemaDisabled {
 		jsonPath = resolvePath(basePath, jsonFile)
-		s.registerJSON(r, jsonPath, cfg)
+		s.registerJSON(r, jsonPath, cfg, jsonCache)
 	}
 
 	if yamlFile != SchemaDisabled {
 		yamlPath = resolvePath(basePath, yamlFile)
-		s.registerYAML(r, yamlPath, cfg)
+		s.registerYAML(r, yamlPath, cfg, yamlCache)
+	}
+
+	if cfg.SingleEndpoint != "" {
+		singlePath = resolvePath(basePath, cfg.SingleEndpoint)
+		s.registerSingle(r, singlePath, cfg, jsonCache, yamlCache)
 	}
 
 	if !cfg.DisableDocs {
-		// The docs UI references the JSON or YAML spec path.
+		// The docs UI references the JSON, YAML, or negotiated spec path.
 		specURL := jsonPath
 		if specURL == "" {
 			specURL = yamlPath
 		}
+		if specURL == "" {
+			specURL = singlePath
+		}
 
 		// Skip docs registration when no spec endpoint is available.
 		if specURL != "" {
@@ -181,81 +336,113 @@ func (s *Spec) Handle(r *mux.Router, basePath string, cfg *HandleConfig) {
 // registerJSON registers a handler that serves the OpenAPI Document as JSON.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-document
-func (s *Spec) registerJSON(r *mux.Router, path string, cfg *HandleConfig) {
-	var (
-		once     sync.Once
-		data     []byte
-		etag     string
-		buildErr error
-	)
-	r.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
-		once.Do(func() {
-			defer func() {
-				if rv := recover(); rv != nil {
-					buildErr = fmt.Errorf("%v", rv)
-				}
-			}()
-			doc := s.Build(r)
-			data, buildErr = json.MarshalIndent(doc, "", "  ")
-			if buildErr == nil && !cfg.DisableETag {
-				etag = computeETag(data)
-			}
-		})
-		if buildErr != nil {
-			http.Error(w, "failed to serialize OpenAPI spec as JSON", http.StatusInternalServerError)
-			return
-		}
-		if etag != "" {
-			w.Header().Set("ETag", etag)
-			if etagMatch(req.Header.Get("If-None-Match"), etag) {
-				w.WriteHeader(http.StatusNotModified)
-				return
-			}
-		}
-		w.Header().Set("Content-Type", mux.ContentTypeApplicationJSON)
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(data)
-	})
+func (s *Spec) registerJSON(r *mux.Router, path string, cfg *HandleConfig, cache *specCache) {
+	route := r.Handle(path, cfg.wrap(func(w http.ResponseWriter, req *http.Request) {
+		cache.serve(w, req, s, r, cfg, mux.ContentTypeApplicationJSON, "JSON")
+	}))
+	s.documentSelf(route, cfg, "JSON", mux.ContentTypeApplicationJSON)
 }
 
 // registerYAML registers a handler that serves the OpenAPI Document as YAML.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-document
-func (s *Spec) registerYAML(r *mux.Router, path string, cfg *HandleConfig) {
-	var (
-		once     sync.Once
-		data     []byte
-		etag     string
-		buildErr error
-	)
-	r.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
-		once.Do(func() {
-			defer func() {
-				if rv := recover(); rv != nil {
-					buildErr = fmt.Errorf("%v", rv)
-				}
-			}()
-			doc := s.Build(r)
-			data, buildErr = yaml.Marshal(doc)
-			if buildErr == nil && !cfg.DisableETag {
-				etag = computeETag(data)
-			}
-		})
-		if buildErr != nil {
-			http.Error(w, "failed to serialize OpenAPI spec as YAML", http.StatusInternalServerError)
-			return
+func (s *Spec) registerYAML(r *mux.Router, path string, cfg *HandleConfig, cache *specCache) {
+	route := r.Handle(path, cfg.wrap(func(w http.ResponseWriter, req *http.Request) {
+		cache.serve(w, req, s, r, cfg, mux.ContentTypeApplicationYAML, "YAML")
+	}))
+	s.documentSelf(route, cfg, "YAML", mux.ContentTypeApplicationYAML)
+}
+
+// specMediaTypes lists the media types served by the SingleEndpoint route,
+// in order of preference when the Accept header ranks them equally.
+var specMediaTypes = []string{
+	mux.ContentTypeApplicationJSON,
+	"application/yaml",
+	mux.ContentTypeApplicationYAML,
+	"text/yaml",
+}
+
+// registerSingle registers a handler that serves the OpenAPI Document as
+// JSON or YAML depending on the Accept header.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9110#section-12.5.1
+func (s *Spec) registerSingle(r *mux.Router, path string, cfg *HandleConfig, jsonCache, yamlCache *specCache) {
+	route := r.Handle(path, cfg.wrap(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		contentType := negotiateSpecMediaType(req.Header.Values("Accept"))
+		switch contentType {
+		case "":
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		case mux.ContentTypeApplicationJSON:
+			jsonCache.serve(w, req, s, r, cfg, contentType, "JSON")
+		default:
+			yamlCache.serve(w, req, s, r, cfg, contentType, "YAML")
 		}
-		if etag != "" {
-			w.Header().Set("ETag", etag)
-			if etagMatch(req.Header.Get("If-None-Match"), etag) {
-				w.WriteHeader(http.StatusNotModified)
-				return
+	}))
+	if op := s.documentSelf(route, cfg, "JSON or YAML", specMediaTypes...); op != nil {
+		op.Response(http.StatusNotAcceptable, nil)
+	}
+}
+
+// negotiateSpecMediaType returns the entry of specMediaTypes preferred by
+// the Accept header values, JSON when there is no Accept header, or "" when
+// none is acceptable.
+func negotiateSpecMediaType(accept []string) string {
+	if len(accept) == 0 {
+		return mux.ContentTypeApplicationJSON
+	}
+
+	best := ""
+	bestQuality := 0.0
+	for _, mediaType := range specMediaTypes {
+		if q := acceptQuality(accept, mediaType); q > bestQuality {
+			best, bestQuality = mediaType, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality the Accept header values give to
+// mediaType, taken from the most specific matching media range, or 0 when
+// no range matches.
+func acceptQuality(accept []string, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality := 0.0
+	specificity := -1
+	for _, value := range accept {
+		for part := range strings.SplitSeq(value, ",") {
+			mediaRange, params, _ := strings.Cut(part, ";")
+			mediaRange = strings.ToLower(strings.TrimSpace(mediaRange))
+
+			var rank int
+			switch {
+			case mediaRange == mediaType:
+				rank = 2
+			case mediaRange == mainType+"/*":
+				rank = 1
+			case mediaRange == "*/*":
+				rank = 0
+			default:
+				continue
+			}
+			if rank <= specificity {
+				continue
 			}
+
+			q := 1.0
+			for param := range strings.SplitSeq(params, ";") {
+				name, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "q") {
+					if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && parsed >= 0 && parsed <= 1 {
+						q = parsed
+					}
+					break
+				}
+			}
+			quality, specificity = q, rank
 		}
-		w.Header().Set("Content-Type", mux.ContentTypeApplicationYAML)
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(data)
-	})
+	}
+	return quality
 }
 
 // registerDocs registers a handler that serves the interactive HTML documentation UI.
@@ -288,10 +475,10 @@ func (s *Spec) registerDocs(r *mux.Router, basePath string, cfg *HandleConfig, s
 	}
 	if basePath == "" {
 		// Root base path: register only "/" to avoid empty path "".
-		r.HandleFunc("/", handler)
+		r.Handle("/", cfg.wrap(handler))
 	} else {
-		r.HandleFunc(basePath, handler)
-		r.HandleFunc(fmt.Sprintf("%s/", basePath), handler)
+		r.Handle(basePath, cfg.wrap(handler))
+		r.Handle(fmt.Sprintf("%s/", basePath), cfg.wrap(handler))
 	}
 }
 
@@ -667,3 +667,367 @@ func TestHandleXSSSafe(t *testing.T) {
 		assert.Contains(t, body, "&lt;script&gt;")
 	})
 }
+
+func TestHandleDocumentSelf(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", nil)
+
+		doc := spec.Build(r)
+		assert.NotContains(t, doc.Paths, "/swagger/schema.json")
+	})
+
+	t.Run("documents spec endpoints as public", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.SetSecurity(SecurityRequirement{"bearer": {}})
+		spec.Handle(r, "/swagger", &HandleConfig{
+			YAMLFilename: "schema.yaml",
+			DocumentSelf: true,
+		})
+
+		doc := spec.Build(r)
+		require.Contains(t, doc.Paths, "/swagger/schema.json")
+		require.Contains(t, doc.Paths, "/swagger/schema.yaml")
+		assert.NotContains(t, doc.Paths, "/swagger/")
+
+		op := doc.Paths["/swagger/schema.json"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, []string{DocumentationTag}, op.Tags)
+		assert.NotNil(t, op.Security)
+		assert.Empty(t, op.Security)
+		require.Contains(t, op.Responses, "200")
+		assert.Contains(t, op.Responses["200"].Content, mux.ContentTypeApplicationJSON)
+		assert.Contains(t, op.Responses, "304")
+
+		yamlOp := doc.Paths["/swagger/schema.yaml"].Get
+		require.NotNil(t, yamlOp)
+		assert.Contains(t, yamlOp.Responses["200"].Content, mux.ContentTypeApplicationYAML)
+
+		w := serveRequest(r, http.MethodGet, "/swagger/schema.json")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &raw))
+		assert.Contains(t, raw["paths"], "/swagger/schema.json")
+	})
+
+	t.Run("custom security requirement", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{
+			DocumentSelf:         true,
+			DocumentSelfSecurity: []SecurityRequirement{{"basic": {}}},
+		})
+
+		doc := spec.Build(r)
+		op := doc.Paths["/swagger/schema.json"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, []SecurityRequirement{{"basic": {}}}, op.Security)
+	})
+
+	t.Run("no 304 response without ETag", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{DocumentSelf: true, DisableETag: true})
+
+		op := spec.Build(r).Paths["/swagger/schema.json"].Get
+		require.NotNil(t, op)
+		assert.NotContains(t, op.Responses, "304")
+	})
+
+	t.Run("HEAD still served", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{DocumentSelf: true})
+
+		w := serveRequest(r, http.MethodHead, "/swagger/schema.json")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestHandleMiddleware(t *testing.T) {
+	requireAuth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	newRouter := func() *mux.Router {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{
+			YAMLFilename: "schema.yaml",
+			Middleware:   []mux.MiddlewareFunc{requireAuth},
+		})
+		return r
+	}
+
+	t.Run("protects swagger routes", func(t *testing.T) {
+		r := newRouter()
+		for _, path := range []string{"/swagger", "/swagger/", "/swagger/schema.json", "/swagger/schema.yaml"} {
+			w := serveRequest(r, http.MethodGet, path)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		}
+	})
+
+	t.Run("authorized requests served", func(t *testing.T) {
+		r := newRouter()
+
+		req := httptest.NewRequest(http.MethodGet, "/swagger/", nil)
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"/swagger/schema.json"`)
+
+		req = httptest.NewRequest(http.MethodGet, "/swagger/schema.json", nil)
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, mux.ContentTypeApplicationJSON, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("API routes unaffected", func(t *testing.T) {
+		r := newRouter()
+		w := serveRequest(r, http.MethodGet, "/items")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("first middleware outermost", func(t *testing.T) {
+		var order []string
+		mw := func(name string) mux.MiddlewareFunc {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					order = append(order, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{Middleware: []mux.MiddlewareFunc{mw("a"), mw("b")}})
+		serveRequest(r, http.MethodGet, "/swagger/schema.json")
+		assert.Equal(t, []string{"a", "b"}, order)
+	})
+}
+
+func TestHandleAuthorize(t *testing.T) {
+	allowInternal := func(r *http.Request) bool {
+		return r.Header.Get("X-Internal") == "1"
+	}
+
+	paths := []string{"/swagger", "/swagger/", "/swagger/schema.json", "/swagger/schema.yaml"}
+
+	t.Run("denying predicate blocks docs and schema", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{
+			YAMLFilename: "schema.yaml",
+			Authorize:    allowInternal,
+		})
+
+		for _, path := range paths {
+			w := serveRequest(r, http.MethodGet, path)
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+			assert.NotContains(t, w.Body.String(), "Test API", path)
+		}
+	})
+
+	t.Run("allowing predicate serves docs and schema", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{
+			YAMLFilename: "schema.yaml",
+			Authorize:    allowInternal,
+		})
+
+		for _, path := range paths {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("X-Internal", "1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+		}
+	})
+
+	t.Run("API routes unaffected", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{
+			Authorize: func(*http.Request) bool { return false },
+		})
+
+		w := serveRequest(r, http.MethodGet, "/items")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("runs inside middleware", func(t *testing.T) {
+		var called bool
+		mw := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				next.ServeHTTP(w, r)
+			})
+		}
+
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{
+			Middleware: []mux.MiddlewareFunc{mw},
+			Authorize:  func(*http.Request) bool { return false },
+		})
+
+		w := serveRequest(r, http.MethodGet, "/swagger/schema.json")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.True(t, called)
+	})
+}
+
+func TestHandleSingleEndpoint(t *testing.T) {
+	serveAccept := func(r *mux.Router, accept ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/swagger/schema", nil)
+		for _, v := range accept {
+			req.Header.Add("Accept", v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", nil)
+
+		w := serveRequest(r, http.MethodGet, "/swagger/schema")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("negotiates on Accept", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{SingleEndpoint: "schema"})
+
+		tests := []struct {
+			accept []string
+			want   string
+		}{
+			{nil, mux.ContentTypeApplicationJSON},
+			{[]string{"*/*"}, mux.ContentTypeApplicationJSON},
+			{[]string{"application/json"}, mux.ContentTypeApplicationJSON},
+			{[]string{"application/*"}, mux.ContentTypeApplicationJSON},
+			{[]string{"application/yaml"}, "application/yaml"},
+			{[]string{"application/x-yaml"}, mux.ContentTypeApplicationYAML},
+			{[]string{"text/yaml"}, "text/yaml"},
+			{[]string{"text/*"}, "text/yaml"},
+			{[]string{"Application/YAML"}, "application/yaml"},
+			{[]string{"application/json;q=0.5, application/yaml"}, "application/yaml"},
+			{[]string{"application/yaml;q=0.9, */*;q=0.1"}, "application/yaml"},
+			{[]string{"application/*;q=0.2, application/yaml;q=0"}, mux.ContentTypeApplicationJSON},
+			{[]string{"text/html, */*;q=0.8"}, mux.ContentTypeApplicationJSON},
+			{[]string{"text/yaml;q=0.3", "application/json;q=0.2"}, "text/yaml"},
+		}
+		for _, tt := range tests {
+			w := serveAccept(r, tt.accept...)
+			require.Equal(t, http.StatusOK, w.Code, "accept %v", tt.accept)
+			assert.Equal(t, tt.want, w.Header().Get("Content-Type"), "accept %v", tt.accept)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"), "accept %v", tt.accept)
+		}
+	})
+
+	t.Run("serves the same bytes as the filename routes", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{YAMLFilename: "schema.yaml", SingleEndpoint: "schema"})
+
+		jsonFile := serveRequest(r, http.MethodGet, "/swagger/schema.json")
+		yamlFile := serveRequest(r, http.MethodGet, "/swagger/schema.yaml")
+		require.Equal(t, http.StatusOK, jsonFile.Code)
+		require.Equal(t, http.StatusOK, yamlFile.Code)
+		assert.Equal(t, mux.ContentTypeApplicationJSON, jsonFile.Header().Get("Content-Type"))
+		assert.Equal(t, mux.ContentTypeApplicationYAML, yamlFile.Header().Get("Content-Type"))
+		assert.Empty(t, jsonFile.Header().Get("Vary"))
+
+		jsonSingle := serveAccept(r, "application/json")
+		yamlSingle := serveAccept(r, "application/yaml")
+		assert.Equal(t, jsonFile.Body.String(), jsonSingle.Body.String())
+		assert.Equal(t, yamlFile.Body.String(), yamlSingle.Body.String())
+		assert.Equal(t, jsonFile.Header().Get("ETag"), jsonSingle.Header().Get("ETag"))
+		assert.Equal(t, yamlFile.Header().Get("ETag"), yamlSingle.Header().Get("ETag"))
+
+		var doc map[string]any
+		require.NoError(t, yaml.Unmarshal(yamlSingle.Body.Bytes(), &doc))
+		assert.Equal(t, OpenAPIVersion, doc["openapi"])
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{SingleEndpoint: "schema"})
+
+		for _, accept := range []string{"text/html", "application/xml", "application/json;q=0, */*;q=0"} {
+			w := serveAccept(r, accept)
+			assert.Equal(t, http.StatusNotAcceptable, w.Code, accept)
+			assert.Empty(t, w.Header().Get("ETag"), accept)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"), accept)
+		}
+	})
+
+	t.Run("ETag per representation", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{SingleEndpoint: "schema"})
+
+		jsonResp := serveAccept(r, "application/json")
+		yamlResp := serveAccept(r, "text/yaml")
+		jsonETag := jsonResp.Header().Get("ETag")
+		yamlETag := yamlResp.Header().Get("ETag")
+		require.NotEmpty(t, jsonETag)
+		require.NotEmpty(t, yamlETag)
+		assert.NotEqual(t, jsonETag, yamlETag)
+
+		req := httptest.NewRequest(http.MethodGet, "/swagger/schema", nil)
+		req.Header.Set("Accept", "text/yaml")
+		req.Header.Set("If-None-Match", yamlETag)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "/swagger/schema", nil)
+		req.Header.Set("If-None-Match", yamlETag)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, jsonETag, w.Header().Get("ETag"))
+	})
+
+	t.Run("ETag disabled", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{SingleEndpoint: "schema", DisableETag: true})
+
+		w := serveAccept(r, "application/yaml")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+	})
+
+	t.Run("docs UI falls back to single endpoint", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{JSONFilename: SchemaDisabled, SingleEndpoint: "/openapi"})
+
+		w := serveRequest(r, http.MethodGet, "/swagger/")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"/openapi"`)
+
+		w = serveRequest(r, http.MethodGet, "/openapi")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("documented", func(t *testing.T) {
+		r, spec := setupTestRouter()
+		spec.Handle(r, "/swagger", &HandleConfig{SingleEndpoint: "schema", DocumentSelf: true})
+
+		doc := spec.Build(r)
+		require.Contains(t, doc.Paths, "/swagger/schema")
+		op := doc.Paths["/swagger/schema"].Get
+		require.NotNil(t, op)
+		assert.Equal(t, []string{DocumentationTag}, op.Tags)
+		for _, ct := range []string{mux.ContentTypeApplicationJSON, "application/yaml", mux.ContentTypeApplicationYAML, "text/yaml"} {
+			assert.Contains(t, op.Responses["200"].Content, ct)
+		}
+		assert.Contains(t, op.Responses, "304")
+		assert.Contains(t, op.Responses, "406")
+	})
+}
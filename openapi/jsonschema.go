@@ -0,0 +1,258 @@
+package openapi
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// JSONSchemaDraft202012 is the meta-schema URI of JSON Schema draft
+// 2020-12, set as "$schema" on standalone schemas.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-core#section-8.1.1
+const JSONSchemaDraft202012 = "https://json-schema.org/draft/2020-12/schema"
+
+const (
+	componentSchemaRefPrefix = "#/components/schemas/"
+	defsRefPrefix            = "#/$defs/"
+)
+
+// SchemaOption configures SchemaFor.
+type SchemaOption func(*schemaOptions)
+
+type schemaOptions struct {
+	id   string
+	spec *Spec
+}
+
+// WithSchemaID sets the "$id" of the root schema returned by SchemaFor.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.1
+func WithSchemaID(id string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.id = id
+	}
+}
+
+// WithSchemaDescriptions makes SchemaFor use the type and field
+// descriptions registered on spec with DescribeType and DescribeField, so
+// standalone schemas match the ones in the spec's document.
+func WithSchemaDescriptions(spec *Spec) SchemaOption {
+	return func(o *schemaOptions) {
+		o.spec = spec
+	}
+}
+
+// SchemaFor generates a standalone JSON Schema (draft 2020-12) for value,
+// using the same reflection rules as Spec.Build. It returns the root schema
+// and the component schemas it depends on, directly or transitively, keyed
+// by component name. References between them point to "#/$defs/{name}",
+// and the same map is set as the root's $defs, so the root can be
+// marshaled as a self-contained document:
+//
+//	root, _ := openapi.SchemaFor(OrderCreated{},
+//	    openapi.WithSchemaID("https://example.com/schemas/order-created.json"))
+//	data, _ := json.MarshalIndent(root, "", "  ")
+//
+// Pointers are dereferenced, and when value is a named struct the root is
+// the struct schema itself rather than a reference to it. The OpenAPI
+// "example" keyword is converted to the JSON Schema "examples" array.
+// SchemaFor returns nil for a nil value.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.4
+func SchemaFor(value any, opts ...SchemaOption) (*Schema, map[string]*Schema) {
+	if value == nil {
+		return nil, nil
+	}
+	var o schemaOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	gen := NewSchemaGenerator()
+	if o.spec != nil {
+		gen.docs = &o.spec.resolve().docs
+	}
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	root := gen.generateType(t)
+	if root == nil {
+		return nil, nil
+	}
+
+	root, defs := bundleSchema(root, gen.Schemas())
+	root.ID = o.id
+	return root, defs
+}
+
+// ExportSchemas returns the named component schemas as standalone JSON
+// Schema documents in the form produced by SchemaFor: each one carries the
+// draft 2020-12 "$schema" and the components it depends on under "$defs".
+// Schemas are generated from the request bodies, responses, and parameters
+// of every operation and webhook registered on the spec, so component names
+// match those in the built document. With no names, every component schema
+// is exported. An unknown name is an error.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#components-object (schemas)
+// See: https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.4
+func (s *Spec) ExportSchemas(names ...string) (map[string]*Schema, error) {
+	s = s.resolve()
+	gen := NewSchemaGenerator()
+	gen.docs = &s.docs
+	s.generateSchemas(gen)
+	components := gen.Schemas()
+
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(components))
+	}
+
+	out := make(map[string]*Schema, len(names))
+	for _, name := range names {
+		if _, ok := components[name]; !ok {
+			return nil, fmt.Errorf("openapi: unknown component schema %q", name)
+		}
+		root, _ := bundleSchema(&Schema{Ref: componentSchemaRefPrefix + name}, components)
+		out[name] = root
+	}
+	return out, nil
+}
+
+// generateSchemas runs every registered operation builder against gen in a
+// stable order: webhooks by name and method, named operations by name, then
+// route operations by path template and methods.
+func (s *Spec) generateSchemas(gen *SchemaGenerator) {
+	for _, name := range slices.Sorted(maps.Keys(s.webhooks)) {
+		methods := s.webhooks[name]
+		for _, method := range slices.Sorted(maps.Keys(methods)) {
+			methods[method].buildOperation(gen, "", nil)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.operations)) {
+		builder := s.operations[name]
+		s.applyResponseEnvelope(gen, builder, builder.buildOperation(gen, "", nil))
+	}
+
+	routes := slices.SortedFunc(maps.Keys(s.routeOps), func(a, b *mux.Route) int {
+		return cmp.Compare(routeSortKey(a), routeSortKey(b))
+	})
+	for _, route := range routes {
+		builder := s.routeOps[route]
+		s.applyResponseEnvelope(gen, builder, builder.buildOperation(gen, "", nil))
+	}
+}
+
+func routeSortKey(route *mux.Route) string {
+	tpl, _ := route.GetPathTemplate()
+	methods, _ := route.GetMethods()
+	return tpl + " " + strings.Join(methods, ",")
+}
+
+// bundleSchema copies root and the component schemas it references into a
+// standalone draft 2020-12 schema. A root that is a bare component reference
+// is replaced by the component itself.
+func bundleSchema(root *Schema, components map[string]*Schema) (*Schema, map[string]*Schema) {
+	defs := make(map[string]*Schema)
+
+	var rewrite func(ref string) string
+	rewrite = func(ref string) string {
+		name, ok := strings.CutPrefix(ref, componentSchemaRefPrefix)
+		if !ok {
+			return ref
+		}
+		if _, done := defs[name]; !done {
+			if comp, ok := components[name]; ok {
+				defs[name] = nil // guards against recursive types
+				defs[name] = copySchema(comp, rewrite)
+			}
+		}
+		return defsRefPrefix + name
+	}
+
+	if name, ok := strings.CutPrefix(root.Ref, componentSchemaRefPrefix); ok && components[name] != nil {
+		root = copySchema(components[name], rewrite)
+	} else {
+		root = copySchema(root, rewrite)
+	}
+
+	root.SchemaURI = JSONSchemaDraft202012
+	if len(defs) > 0 {
+		root.Defs = defs
+	}
+	return root, defs
+}
+
+// copySchema returns a deep copy of s with every $ref and discriminator
+// mapping passed through rewrite. The OpenAPI "example" keyword is moved to
+// "examples" when the latter is empty.
+func copySchema(s *Schema, rewrite func(string) string) *Schema {
+	if s == nil {
+		return nil
+	}
+	out := *s
+	if out.Ref != "" {
+		out.Ref = rewrite(out.Ref)
+	}
+	if out.Example != nil && len(out.Examples) == 0 {
+		out.Examples = []any{out.Example}
+		out.Example = nil
+	}
+
+	out.Defs = copySchemaMap(s.Defs, rewrite)
+	out.Items = copySchema(s.Items, rewrite)
+	out.PrefixItems = copySchemaSlice(s.PrefixItems, rewrite)
+	out.Contains = copySchema(s.Contains, rewrite)
+	out.UnevaluatedItems = copySchema(s.UnevaluatedItems, rewrite)
+	out.Properties = copySchemaMap(s.Properties, rewrite)
+	out.PatternProperties = copySchemaMap(s.PatternProperties, rewrite)
+	out.AdditionalProperties = copySchema(s.AdditionalProperties, rewrite)
+	out.UnevaluatedProperties = copySchema(s.UnevaluatedProperties, rewrite)
+	out.PropertyNames = copySchema(s.PropertyNames, rewrite)
+	out.DependentSchemas = copySchemaMap(s.DependentSchemas, rewrite)
+	out.AllOf = copySchemaSlice(s.AllOf, rewrite)
+	out.OneOf = copySchemaSlice(s.OneOf, rewrite)
+	out.AnyOf = copySchemaSlice(s.AnyOf, rewrite)
+	out.Not = copySchema(s.Not, rewrite)
+	out.If = copySchema(s.If, rewrite)
+	out.Then = copySchema(s.Then, rewrite)
+	out.Else = copySchema(s.Else, rewrite)
+	out.ContentSchema = copySchema(s.ContentSchema, rewrite)
+
+	if s.Discriminator != nil && len(s.Discriminator.Mapping) > 0 {
+		d := *s.Discriminator
+		d.Mapping = make(map[string]string, len(s.Discriminator.Mapping))
+		for k, ref := range s.Discriminator.Mapping {
+			d.Mapping[k] = rewrite(ref)
+		}
+		out.Discriminator = &d
+	}
+	return &out
+}
+
+func copySchemaMap(m map[string]*Schema, rewrite func(string) string) map[string]*Schema {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]*Schema, len(m))
+	for k, s := range m {
+		out[k] = copySchema(s, rewrite)
+	}
+	return out
+}
+
+func copySchemaSlice(list []*Schema, rewrite func(string) string) []*Schema {
+	if list == nil {
+		return nil
+	}
+	out := make([]*Schema, len(list))
+	for i, s := range list {
+		out[i] = copySchema(s, rewrite)
+	}
+	return out
+}
@@ -0,0 +1,317 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type bundleAddress struct {
+	City string `json:"city"`
+}
+
+type bundleCustomer struct {
+	Name    string         `json:"name"`
+	Address *bundleAddress `json:"address,omitempty"`
+}
+
+type bundleOrder struct {
+	ID       string            `json:"id" openapi:"format=uuid"`
+	Customer bundleCustomer    `json:"customer"`
+	Items    []bundleOrderItem `json:"items"`
+}
+
+type bundleOrderItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity" openapi:"minimum=1"`
+}
+
+type bundleNode struct {
+	Value    string       `json:"value"`
+	Children []bundleNode `json:"children,omitempty"`
+}
+
+type bundleExample struct {
+	Code string `json:"code"`
+}
+
+func (bundleExample) OpenAPIExample() any {
+	return bundleExample{Code: "A1"}
+}
+
+// validateDraft202012 checks a marshaled schema against the structural
+// rules of the JSON Schema draft 2020-12 meta-schema for the keywords the
+// generator emits, and that every $ref resolves within the document.
+func validateDraft202012(t *testing.T, data []byte) {
+	t.Helper()
+
+	var doc any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	root, ok := doc.(map[string]any)
+	require.True(t, ok, "root must be an object")
+	assert.Equal(t, JSONSchemaDraft202012, root["$schema"])
+
+	defs, _ := root["$defs"].(map[string]any)
+	var errs []string
+	var check func(path string, v any)
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+	checkSchemaMap := func(path string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			fail(path, "must be an object")
+			return
+		}
+		for k, s := range m {
+			check(path+"/"+k, s)
+		}
+	}
+	checkSchemaArray := func(path string, v any) {
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			fail(path, "must be a non-empty array")
+			return
+		}
+		for i, s := range list {
+			check(fmt.Sprintf("%s/%d", path, i), s)
+		}
+	}
+	nonNegativeInt := func(path string, v any) {
+		n, ok := v.(float64)
+		if !ok || n < 0 || n != float64(int64(n)) {
+			fail(path, "must be a non-negative integer")
+		}
+	}
+	simpleTypes := []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+
+	check = func(path string, v any) {
+		if _, ok := v.(bool); ok {
+			return
+		}
+		s, ok := v.(map[string]any)
+		if !ok {
+			fail(path, "schema must be an object or boolean")
+			return
+		}
+		for key, val := range s {
+			p := path + "/" + key
+			switch key {
+			case "$schema", "$id", "$comment", "$anchor", "$dynamicAnchor",
+				"title", "description", "format", "pattern", "contentEncoding", "contentMediaType":
+				if _, ok := val.(string); !ok {
+					fail(p, "must be a string")
+				}
+			case "$ref":
+				ref, _ := val.(string)
+				name, ok := strings.CutPrefix(ref, "#/$defs/")
+				if !ok {
+					fail(p, "unresolvable reference %q", ref)
+				} else if _, ok := defs[name]; !ok {
+					fail(p, "missing definition %q", name)
+				}
+			case "type":
+				switch tv := val.(type) {
+				case string:
+					if !slices.Contains(simpleTypes, tv) {
+						fail(p, "invalid type %q", tv)
+					}
+				case []any:
+					seen := map[any]bool{}
+					for _, item := range tv {
+						if str, ok := item.(string); !ok || !slices.Contains(simpleTypes, str) || seen[item] {
+							fail(p, "invalid type list %v", tv)
+						}
+						seen[item] = true
+					}
+				default:
+					fail(p, "must be a string or array")
+				}
+			case "$defs", "properties", "patternProperties", "dependentSchemas":
+				checkSchemaMap(p, val)
+			case "items", "contains", "additionalProperties", "unevaluatedItems", "unevaluatedProperties",
+				"propertyNames", "not", "if", "then", "else", "contentSchema":
+				check(p, val)
+			case "allOf", "anyOf", "oneOf", "prefixItems":
+				checkSchemaArray(p, val)
+			case "required":
+				list, ok := val.([]any)
+				seen := map[any]bool{}
+				for _, item := range list {
+					if _, isStr := item.(string); !isStr || seen[item] {
+						ok = false
+					}
+					seen[item] = true
+				}
+				if !ok {
+					fail(p, "must be an array of unique strings")
+				}
+			case "enum", "examples":
+				if _, ok := val.([]any); !ok {
+					fail(p, "must be an array")
+				}
+			case "minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties":
+				nonNegativeInt(p, val)
+			case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+				if _, ok := val.(float64); !ok {
+					fail(p, "must be a number")
+				}
+			case "multipleOf":
+				if n, ok := val.(float64); !ok || n <= 0 {
+					fail(p, "must be a positive number")
+				}
+			case "uniqueItems", "deprecated", "readOnly", "writeOnly":
+				if _, ok := val.(bool); !ok {
+					fail(p, "must be a boolean")
+				}
+			case "example":
+				fail(p, "OpenAPI keyword must be converted to examples")
+			}
+		}
+	}
+
+	check("#", root)
+	assert.Empty(t, errs)
+	assert.NotContains(t, string(data), "#/components/")
+}
+
+func TestSchemaFor(t *testing.T) {
+	t.Run("bundles transitive dependencies", func(t *testing.T) {
+		root, defs := SchemaFor(bundleOrder{})
+		require.NotNil(t, root)
+
+		assert.Equal(t, JSONSchemaDraft202012, root.SchemaURI)
+		assert.Empty(t, root.Ref)
+		assert.Equal(t, SchemaTypeObject, root.Type)
+		assert.Contains(t, root.Properties, "customer")
+
+		assert.ElementsMatch(t, []string{"bundleAddress", "bundleCustomer", "bundleOrderItem"}, slices.Collect(maps.Keys(defs)))
+		assert.Equal(t, defs, root.Defs)
+		assert.Equal(t, "#/$defs/bundleCustomer", root.Properties["customer"].Ref)
+		assert.Equal(t, "#/$defs/bundleOrderItem", root.Properties["items"].Items.Ref)
+		assert.Equal(t, "#/$defs/bundleAddress", defs["bundleCustomer"].Properties["address"].AnyOf[0].Ref)
+
+		data, err := json.Marshal(root)
+		require.NoError(t, err)
+		validateDraft202012(t, data)
+	})
+
+	t.Run("recursive type", func(t *testing.T) {
+		root, defs := SchemaFor(&bundleNode{})
+		require.NotNil(t, root)
+		require.Contains(t, defs, "bundleNode")
+		assert.Equal(t, "#/$defs/bundleNode", root.Properties["children"].Items.Ref)
+
+		data, err := json.Marshal(root)
+		require.NoError(t, err)
+		validateDraft202012(t, data)
+	})
+
+	t.Run("non struct value", func(t *testing.T) {
+		root, defs := SchemaFor([]bundleOrderItem{})
+		require.NotNil(t, root)
+		assert.Equal(t, SchemaTypeArray, root.Type)
+		assert.Equal(t, "#/$defs/bundleOrderItem", root.Items.Ref)
+		assert.Len(t, defs, 1)
+
+		root, defs = SchemaFor("")
+		assert.Equal(t, SchemaTypeString, root.Type)
+		assert.Empty(t, defs)
+		assert.Nil(t, root.Defs)
+	})
+
+	t.Run("example converted to examples", func(t *testing.T) {
+		root, _ := SchemaFor(bundleExample{})
+		assert.Nil(t, root.Example)
+		assert.Equal(t, []any{bundleExample{Code: "A1"}}, root.Examples)
+
+		data, err := json.Marshal(root)
+		require.NoError(t, err)
+		validateDraft202012(t, data)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"}).
+			DescribeType(bundleCustomer{}, "A customer").
+			DescribeField(bundleCustomer{}, "name", "Full name")
+
+		root, defs := SchemaFor(bundleOrder{},
+			WithSchemaID("https://example.com/order.json"),
+			WithSchemaDescriptions(spec))
+		assert.Equal(t, "https://example.com/order.json", root.ID)
+		assert.Equal(t, "A customer", defs["bundleCustomer"].Description)
+		assert.Equal(t, "Full name", defs["bundleCustomer"].Properties["name"].Description)
+	})
+
+	t.Run("nil value", func(t *testing.T) {
+		root, defs := SchemaFor(nil)
+		assert.Nil(t, root)
+		assert.Nil(t, defs)
+	})
+}
+
+func TestSpecExportSchemas(t *testing.T) {
+	newSpec := func() (*Spec, *mux.Router) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		spec.Webhook("orderCreated", http.MethodPost).Request(bundleOrder{})
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/nodes", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, bundleNode{})
+		return spec, r
+	}
+
+	t.Run("exports named components", func(t *testing.T) {
+		spec, _ := newSpec()
+		schemas, err := spec.ExportSchemas("bundleOrder", "bundleCustomer")
+		require.NoError(t, err)
+		require.Len(t, schemas, 2)
+
+		order := schemas["bundleOrder"]
+		assert.Equal(t, JSONSchemaDraft202012, order.SchemaURI)
+		assert.Equal(t, "#/$defs/bundleCustomer", order.Properties["customer"].Ref)
+		assert.ElementsMatch(t, []string{"bundleAddress", "bundleCustomer", "bundleOrderItem"}, slices.Collect(maps.Keys(order.Defs)))
+
+		customer := schemas["bundleCustomer"]
+		assert.ElementsMatch(t, []string{"bundleAddress"}, slices.Collect(maps.Keys(customer.Defs)))
+
+		for name, schema := range schemas {
+			data, err := json.Marshal(schema)
+			require.NoError(t, err, name)
+			validateDraft202012(t, data)
+		}
+	})
+
+	t.Run("exports all components", func(t *testing.T) {
+		spec, r := newSpec()
+		schemas, err := spec.ExportSchemas()
+		require.NoError(t, err)
+
+		doc := spec.Build(r)
+		assert.ElementsMatch(t, slices.Collect(maps.Keys(doc.Components.Schemas)), slices.Collect(maps.Keys(schemas)))
+	})
+
+	t.Run("does not modify document schemas", func(t *testing.T) {
+		spec, r := newSpec()
+		_, err := spec.ExportSchemas()
+		require.NoError(t, err)
+
+		doc := spec.Build(r)
+		assert.Equal(t, "#/components/schemas/bundleCustomer", doc.Components.Schemas["bundleOrder"].Properties["customer"].Ref)
+		assert.Empty(t, doc.Components.Schemas["bundleOrder"].SchemaURI)
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		spec, _ := newSpec()
+		schemas, err := spec.ExportSchemas("Missing")
+		assert.Nil(t, schemas)
+		assert.EqualError(t, err, `openapi: unknown component schema "Missing"`)
+	})
+}
@@ -17,7 +17,8 @@ import (
 // normalized JSON representations are identical (string-only arrays such as
 // "required" are sorted before comparison); differing entries produce a
 // conflict error. Tags are deduplicated by name (first non-empty description
-// wins) and sorted alphabetically. Security requirements are unioned and
+// wins) and sorted alphabetically. Tag groups with the same name are
+// combined. Security requirements are unioned and
 // deduplicated (scope ordering is ignored). Servers from source documents
 // are dropped.
 //
@@ -44,6 +45,7 @@ func MergeDocuments(info Info, docs ...*Document) (*Document, error) {
 	}
 
 	result.Tags = mergeTags(docs, &conflicts)
+	result.TagGroups = mergeTagGroups(docs)
 	result.Security = mergeSecurity(docs)
 
 	if len(conflicts) > 0 {
@@ -177,6 +179,33 @@ func mergeTags(docs []*Document, conflicts *[]string) []Tag {
 	return tags
 }
 
+// mergeTagGroups combines tag groups across documents. Groups keep the
+// order in which they are first seen, and groups sharing a name are
+// combined into one with the union of their tags.
+func mergeTagGroups(docs []*Document) []TagGroup {
+	var groups []TagGroup
+	index := make(map[string]int)
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, group := range doc.TagGroups {
+			i, ok := index[group.Name]
+			if !ok {
+				index[group.Name] = len(groups)
+				groups = append(groups, TagGroup{Name: group.Name, Tags: slices.Clone(group.Tags)})
+				continue
+			}
+			for _, tag := range group.Tags {
+				if !slices.Contains(groups[i].Tags, tag) {
+					groups[i].Tags = append(groups[i].Tags, tag)
+				}
+			}
+		}
+	}
+	return groups
+}
+
 // mergeSecurity unions security requirements across documents,
 // deduplicating via normalized JSON serialization. Scope slices
 // are sorted before comparison so that order does not affect dedup.
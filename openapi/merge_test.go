@@ -31,6 +31,24 @@ func TestMergeDocuments(t *testing.T) {
 		assert.Contains(t, result.Paths, "/billing")
 	})
 
+	t.Run("tag groups combined by name", func(t *testing.T) {
+		doc1 := &Document{TagGroups: []TagGroup{
+			{Name: "Accounts", Tags: []string{"users"}},
+		}}
+		doc2 := &Document{TagGroups: []TagGroup{
+			{Name: "Commerce", Tags: []string{"orders"}},
+			{Name: "Accounts", Tags: []string{"admin", "users"}},
+		}}
+
+		result, err := MergeDocuments(info, doc1, doc2)
+		require.NoError(t, err)
+		assert.Equal(t, []TagGroup{
+			{Name: "Accounts", Tags: []string{"users", "admin"}},
+			{Name: "Commerce", Tags: []string{"orders"}},
+		}, result.TagGroups)
+		assert.Equal(t, []string{"users"}, doc1.TagGroups[0].Tags)
+	})
+
 	t.Run("duplicate paths error", func(t *testing.T) {
 		doc1 := &Document{
 			Paths: map[string]*PathItem{
@@ -1,9 +1,13 @@
 package openapi
 
 import (
+	"maps"
 	"net/http"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vitalvas/kasper/mux"
 )
@@ -18,12 +22,19 @@ type operationMeta struct {
 	description  string
 	tags         []string
 	deprecated   bool
+	deprecatedAt time.Time
+	sunsetAt     time.Time
+	sunsetLink   string
 	parameters   []*Parameter
 	security     []SecurityRequirement
 	externalDocs *ExternalDocs
 	callbacks    map[string]*Callback
 	servers      []Server
 
+	// callbackOps holds operations registered with CallbackOp, keyed by
+	// callback name, expression, and method.
+	callbackOps map[string]map[string]map[string]*OperationBuilder
+
 	requestContents      map[string]any                // contentType -> body
 	requestDescription   string                        // request body description
 	requestRequired      *bool                         // nil = default (true), non-nil = explicit
@@ -31,6 +42,10 @@ type operationMeta struct {
 	responseDescriptions map[string]string             // statusKey -> custom description
 	responseHeaders      map[string]map[string]*Header // statusKey -> headerName -> header
 	responseLinks        map[string]map[string]*Link   // statusKey -> linkName -> link
+
+	envelope    any  // response envelope template set by a group
+	envelopeSet bool // distinguishes unset (use the spec envelope) from nil
+	noEnvelope  bool
 }
 
 // OperationBuilder provides a fluent API for attaching OpenAPI metadata
@@ -91,6 +106,88 @@ func (b *OperationBuilder) Deprecated() *OperationBuilder {
 	return b
 }
 
+// Deprecation extension names set on operations by DeprecatedSince and
+// Sunset.
+const (
+	DeprecatedSinceExtension = "x-deprecated-since"
+	SunsetExtension          = "x-sunset"
+)
+
+// DeprecatedSince marks the operation as deprecated since the given date.
+// The date is emitted as the x-deprecated-since extension, and a
+// Deprecation response header (RFC 9745) is documented on every response
+// of the operation.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9745
+func (b *OperationBuilder) DeprecatedSince(date time.Time) *OperationBuilder {
+	b.meta.deprecated = true
+	b.meta.deprecatedAt = date
+	return b
+}
+
+// Sunset records the date after which the operation is expected to stop
+// responding, with an optional link to migration documentation. It is
+// emitted as the x-sunset extension (an object with "date" and "link"
+// fields), and a Sunset response header (RFC 8594) is documented on every
+// response of the operation. Sunset does not mark the operation as
+// deprecated; combine it with Deprecated or DeprecatedSince.
+//
+// See: https://www.rfc-editor.org/rfc/rfc8594
+func (b *OperationBuilder) Sunset(date time.Time, link string) *OperationBuilder {
+	b.meta.sunsetAt = date
+	b.meta.sunsetLink = link
+	return b
+}
+
+// deprecationExtensions returns the x-deprecated-since and x-sunset
+// extensions of the operation, or nil when neither date is set.
+func (b *OperationBuilder) deprecationExtensions() Extensions {
+	if b.meta.deprecatedAt.IsZero() && b.meta.sunsetAt.IsZero() {
+		return nil
+	}
+	ext := make(Extensions, 2)
+	if !b.meta.deprecatedAt.IsZero() {
+		ext[DeprecatedSinceExtension] = b.meta.deprecatedAt.UTC().Format(time.RFC3339)
+	}
+	if !b.meta.sunsetAt.IsZero() {
+		sunset := map[string]any{"date": b.meta.sunsetAt.UTC().Format(time.RFC3339)}
+		if b.meta.sunsetLink != "" {
+			sunset["link"] = b.meta.sunsetLink
+		}
+		ext[SunsetExtension] = sunset
+	}
+	return ext
+}
+
+// deprecationHeaders returns the Deprecation and Sunset response headers
+// documented on every response of the operation, or nil when neither date
+// is set.
+func (b *OperationBuilder) deprecationHeaders() map[string]*Header {
+	if b.meta.deprecatedAt.IsZero() && b.meta.sunsetAt.IsZero() {
+		return nil
+	}
+	headers := make(map[string]*Header, 2)
+	if !b.meta.deprecatedAt.IsZero() {
+		headers["Deprecation"] = &Header{
+			Description: "Date the operation was deprecated, as a Unix timestamp prefixed with \"@\" (RFC 9745).",
+			Schema:      &Schema{Type: SchemaTypeString},
+			Example:     "@" + strconv.FormatInt(b.meta.deprecatedAt.Unix(), 10),
+		}
+	}
+	if !b.meta.sunsetAt.IsZero() {
+		desc := "Date after which the operation is expected to stop responding (RFC 8594)."
+		if b.meta.sunsetLink != "" {
+			desc += " Migration details: " + b.meta.sunsetLink
+		}
+		headers["Sunset"] = &Header{
+			Description: desc,
+			Schema:      &Schema{Type: SchemaTypeString},
+			Example:     b.meta.sunsetAt.UTC().Format(http.TimeFormat),
+		}
+	}
+	return headers
+}
+
 // Request registers an application/json request body type for the operation.
 // This is a shortcut for RequestContent("application/json", body).
 //
@@ -161,6 +258,131 @@ func (b *OperationBuilder) ResponseContent(statusCode int, contentType string, b
 	return b
 }
 
+// SSEEventSchemaExtension is the media type extension under which
+// ResponseSSE documents the schema of each event's data.
+const SSEEventSchemaExtension = "x-sse-event-schema"
+
+// sseEvent marks a text/event-stream response body registered with
+// ResponseSSE.
+type sseEvent struct {
+	eventType any
+}
+
+// ResponseSSE registers a Server-Sent Events response (text/event-stream)
+// for the given HTTP status code. OpenAPI has no native way to describe the
+// events of a stream, so the media type schema is a plain string and the
+// schema of each event's data field, generated from eventType (a Go type or
+// a *Schema), is documented under the x-sse-event-schema extension:
+//
+//	spec.Route(r.HandleFunc("/events", stream).Methods(http.MethodGet)).
+//	    ResponseSSE(http.StatusOK, OrderEvent{})
+//
+// See: https://html.spec.whatwg.org/multipage/server-sent-events.html
+// See: https://spec.openapis.org/oas/v3.1.0#specification-extensions
+func (b *OperationBuilder) ResponseSSE(statusCode int, eventType any) *OperationBuilder {
+	return b.ResponseContent(statusCode, mux.ContentTypeTextEventStream, sseEvent{eventType: eventType})
+}
+
+// MultipartPart documents a part of a multipart response registered with
+// ResponseMultipart whose content type is not the one derived from its
+// body.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#encoding-object
+type MultipartPart struct {
+	// ContentType is the media type of the part, such as "image/png".
+	ContentType string
+
+	// Body is a Go type or a *Schema describing the part, or nil for a
+	// part of any content.
+	Body any
+}
+
+// multipartBody marks a multipart/mixed response body registered with
+// ResponseMultipart.
+type multipartBody struct {
+	parts map[string]any
+}
+
+// ResponseMultipart registers a multipart/mixed response, such as a batch
+// download, for the given HTTP status code. The media type schema is an
+// object with a property per part, keyed by part name, and the encoding
+// map documents the content type of each part. Parts are Go types or
+// *Schema values: byte slices are sent as application/octet-stream,
+// strings, numbers, and booleans as text/plain, and other values as
+// application/json. Wrap a part in MultipartPart to set its content type:
+//
+//	spec.Route(r.HandleFunc("/exports/{id}", export).Methods(http.MethodGet)).
+//	    ResponseMultipart(http.StatusOK, map[string]any{
+//	        "manifest": Manifest{},
+//	        "archive":  openapi.MultipartPart{ContentType: "application/zip", Body: []byte{}},
+//	    })
+//
+// Write the response with mux.NewMultipartWriter.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#special-considerations-for-multipart-content
+// See: https://www.rfc-editor.org/rfc/rfc2046#section-5.1.3
+func (b *OperationBuilder) ResponseMultipart(statusCode int, parts map[string]any) *OperationBuilder {
+	return b.ResponseContent(statusCode, mux.ContentTypeMultipartMixed, multipartBody{parts: parts})
+}
+
+// multipartMediaType returns the media type documenting the parts of a
+// multipart response.
+func multipartMediaType(gen *SchemaGenerator, body multipartBody) *MediaType {
+	schema := &Schema{Type: SchemaTypeObject}
+	if len(body.parts) == 0 {
+		return &MediaType{Schema: schema}
+	}
+
+	schema.Properties = make(map[string]*Schema, len(body.parts))
+	encoding := make(map[string]*Encoding, len(body.parts))
+	for name, part := range body.parts {
+		contentType := ""
+		if p, ok := part.(MultipartPart); ok {
+			contentType, part = p.ContentType, p.Body
+		}
+
+		var partSchema *Schema
+		raw := part == nil || isByteSlice(part)
+		if raw {
+			// Raw bytes in a multipart body are not base64-encoded.
+			partSchema = &Schema{}
+		} else {
+			partSchema = resolveSchema(gen, part)
+		}
+		if contentType == "" {
+			contentType = defaultPartContentType(partSchema, raw)
+		}
+		if raw {
+			partSchema.ContentMediaType = contentType
+		}
+
+		schema.Properties[name] = partSchema
+		encoding[name] = &Encoding{ContentType: contentType}
+	}
+	return &MediaType{Schema: schema, Encoding: encoding}
+}
+
+// isByteSlice reports whether body is a []byte value or type.
+func isByteSlice(body any) bool {
+	t := reflect.TypeOf(body)
+	return t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// defaultPartContentType returns the content type OpenAPI assumes for a
+// multipart part with the given schema, or for raw bytes.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#encoding-object (contentType)
+func defaultPartContentType(schema *Schema, raw bool) string {
+	if raw {
+		return mux.ContentTypeApplicationOctetStream
+	}
+	types := slices.DeleteFunc(schema.Type.Values(), func(t string) bool { return t == "null" })
+	if len(types) == 1 && types[0] != "object" && types[0] != "array" {
+		return mux.ContentTypeTextPlain
+	}
+	return mux.ContentTypeApplicationJSON
+}
+
 // DefaultResponse registers an application/json response for the "default"
 // status key. The default response catches any status code not covered by
 // specific responses. Pass nil body for a default response with no content.
@@ -321,6 +543,46 @@ func (b *OperationBuilder) Callback(name string, cb *Callback) *OperationBuilder
 	return b
 }
 
+// CallbackOp registers an operation of the named callback and returns its
+// builder, so the request the API sends and the response it expects are
+// described with the same methods as a regular operation. The expression
+// is the runtime expression evaluated to the callback URL:
+//
+//	spec.Op("subscribe").
+//	    CallbackOp("onEvent", "{$request.body#/callback_url}", http.MethodPost).
+//	    Request(Event{}).
+//	    Response(http.StatusNoContent, nil)
+//
+// Schemas are registered in components like those of any other operation.
+// Operations added with CallbackOp are merged into a Callback with the same
+// name set with Callback, and take precedence for the same expression and
+// method. Calling CallbackOp again with the same arguments returns the
+// same builder.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#callback-object
+// See: https://spec.openapis.org/oas/v3.1.0#runtime-expressions
+func (b *OperationBuilder) CallbackOp(name, expression, method string) *OperationBuilder {
+	if b.meta.callbackOps == nil {
+		b.meta.callbackOps = make(map[string]map[string]map[string]*OperationBuilder)
+	}
+	expressions := b.meta.callbackOps[name]
+	if expressions == nil {
+		expressions = make(map[string]map[string]*OperationBuilder)
+		b.meta.callbackOps[name] = expressions
+	}
+	methods := expressions[expression]
+	if methods == nil {
+		methods = make(map[string]*OperationBuilder)
+		expressions[expression] = methods
+	}
+	if cb, ok := methods[method]; ok {
+		return cb
+	}
+	cb := newOperationBuilder()
+	methods[method] = cb
+	return cb
+}
+
 // Server adds a server override for the operation.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#operation-object (servers)
@@ -381,16 +643,19 @@ func isFormContentType(ct string) bool {
 }
 
 // resolveSchema returns a Schema for the given body value. If body is a
-// *Schema it is used directly; otherwise the schema generator produces one
-// via reflection.
+// *Schema it is used directly, a Paginated body becomes the list envelope;
+// otherwise the schema generator produces one via reflection.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#schema-object
 func resolveSchema(gen *SchemaGenerator, body any) *Schema {
 	if body == nil {
 		return nil
 	}
-	if s, ok := body.(*Schema); ok {
-		return s
+	switch body := body.(type) {
+	case *Schema:
+		return body
+	case paginatedBody:
+		return gen.paginatedSchema(body.itemType)
 	}
 	return gen.Generate(body)
 }
@@ -427,8 +692,9 @@ func (b *OperationBuilder) buildOperation(gen *SchemaGenerator, operationID stri
 		Deprecated:   b.meta.deprecated,
 		Security:     b.meta.security,
 		ExternalDocs: b.meta.externalDocs,
-		Callbacks:    b.meta.callbacks,
+		Callbacks:    b.buildCallbacks(gen),
 		Servers:      b.meta.servers,
+		Extensions:   b.deprecationExtensions(),
 	}
 
 	// Merge path parameters with custom parameters. Custom parameters
@@ -461,6 +727,7 @@ func (b *OperationBuilder) buildOperation(gen *SchemaGenerator, operationID stri
 	}
 
 	// Build responses.
+	deprecationHeaders := b.deprecationHeaders()
 	if len(b.meta.responseContents) > 0 {
 		op.Responses = make(map[string]*Response, len(b.meta.responseContents))
 		for key, contents := range b.meta.responseContents {
@@ -475,7 +742,14 @@ func (b *OperationBuilder) buildOperation(gen *SchemaGenerator, operationID stri
 				resp.Content = make(map[string]*MediaType, len(contents))
 				for ct, body := range contents {
 					mt := &MediaType{}
-					if schema := resolveSchema(gen, body); schema != nil {
+					if sse, ok := body.(sseEvent); ok {
+						mt.Schema = &Schema{Type: SchemaTypeString}
+						if schema := resolveSchema(gen, sse.eventType); schema != nil {
+							mt.Extensions = Extensions{SSEEventSchemaExtension: schema}
+						}
+					} else if multipart, ok := body.(multipartBody); ok {
+						mt = multipartMediaType(gen, multipart)
+					} else if schema := resolveSchema(gen, body); schema != nil {
 						mt.Schema = schema
 					}
 					resp.Content[ct] = mt
@@ -484,6 +758,7 @@ func (b *OperationBuilder) buildOperation(gen *SchemaGenerator, operationID stri
 			if headers, ok := b.meta.responseHeaders[key]; ok && len(headers) > 0 {
 				resp.Headers = headers
 			}
+			resp.Headers = mergeHeaderDefaults(resp.Headers, deprecationHeaders)
 			if links, ok := b.meta.responseLinks[key]; ok && len(links) > 0 {
 				resp.Links = links
 			}
@@ -493,3 +768,49 @@ func (b *OperationBuilder) buildOperation(gen *SchemaGenerator, operationID stri
 
 	return op
 }
+
+// mergeHeaderDefaults adds the default headers, such as deprecation or
+// global response headers, to a copy of headers, keeping any header already
+// documented under the same name.
+func mergeHeaderDefaults(headers, defaults map[string]*Header) map[string]*Header {
+	if len(defaults) == 0 {
+		return headers
+	}
+	out := make(map[string]*Header, len(headers)+len(defaults))
+	maps.Copy(out, defaults)
+	maps.Copy(out, headers)
+	return out
+}
+
+// buildCallbacks returns the operation's callbacks: those set with Callback
+// merged with the operations registered through CallbackOp. Callback
+// objects passed to Callback are copied, never modified.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#callback-object
+func (b *OperationBuilder) buildCallbacks(gen *SchemaGenerator) map[string]*Callback {
+	if len(b.meta.callbackOps) == 0 {
+		return b.meta.callbacks
+	}
+
+	out := make(map[string]*Callback, len(b.meta.callbacks)+len(b.meta.callbackOps))
+	maps.Copy(out, b.meta.callbacks)
+	for name, expressions := range b.meta.callbackOps {
+		cb := make(Callback, len(expressions))
+		if raw := out[name]; raw != nil {
+			maps.Copy(cb, *raw)
+		}
+		for expression, methods := range expressions {
+			item := &PathItem{}
+			if raw := cb[expression]; raw != nil {
+				copied := *raw
+				item = &copied
+			}
+			for method, builder := range methods {
+				assignOperation(item, method, builder.buildOperation(gen, "", nil))
+			}
+			cb[expression] = item
+		}
+		out[name] = &cb
+	}
+	return out
+}
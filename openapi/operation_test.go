@@ -1,10 +1,14 @@
 package openapi
 
 import (
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
 )
 
 func TestOperationBuilder(t *testing.T) {
@@ -491,6 +495,28 @@ func TestRequestContent(t *testing.T) {
 		require.NotNil(t, op.RequestBody)
 		assert.Contains(t, op.RequestBody.Content, "application/vnd.mycompany.myapp.v2+json")
 	})
+
+	t.Run("structured syntax suffix types get reflected schemas", func(t *testing.T) {
+		type Patch struct {
+			Name string `form:"form_name" json:"name"`
+		}
+		b := newOperationBuilder().
+			RequestContent("application/vnd.api+json", Employee{}).
+			RequestContent("application/merge-patch+json", Patch{})
+
+		gen := NewSchemaGenerator()
+		op := b.buildOperation(gen, "update", nil)
+
+		require.NotNil(t, op.RequestBody)
+		require.Contains(t, op.RequestBody.Content, "application/vnd.api+json")
+		assert.Equal(t, "#/components/schemas/Employee", op.RequestBody.Content["application/vnd.api+json"].Schema.Ref)
+		require.Contains(t, op.RequestBody.Content, "application/merge-patch+json")
+		assert.Equal(t, "#/components/schemas/Patch", op.RequestBody.Content["application/merge-patch+json"].Schema.Ref)
+
+		// +json types use json tags, not form tags.
+		require.Contains(t, gen.Schemas(), "Patch")
+		assert.Contains(t, gen.Schemas()["Patch"].Properties, "name")
+	})
 }
 
 func TestResponseContent(t *testing.T) {
@@ -511,6 +537,19 @@ func TestResponseContent(t *testing.T) {
 		assert.NotNil(t, op.Responses["200"].Content["application/xml"].Schema)
 	})
 
+	t.Run("structured syntax suffix response", func(t *testing.T) {
+		b := newOperationBuilder().
+			ResponseContent(200, "application/vnd.api+json", Employee{})
+
+		gen := NewSchemaGenerator()
+		op := b.buildOperation(gen, "getEmployee", nil)
+
+		require.Contains(t, op.Responses, "200")
+		require.Contains(t, op.Responses["200"].Content, "application/vnd.api+json")
+		assert.Equal(t, "#/components/schemas/Employee", op.Responses["200"].Content["application/vnd.api+json"].Schema.Ref)
+		assert.Contains(t, gen.Schemas(), "Employee")
+	})
+
 	t.Run("multiple content types for same status", func(t *testing.T) {
 		b := newOperationBuilder().
 			Response(200, Employee{}).
@@ -617,6 +656,373 @@ func TestResponseContent(t *testing.T) {
 	})
 }
 
+func TestResponseSSE(t *testing.T) {
+	type OrderEvent struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+
+	t.Run("media type and event schema extension", func(t *testing.T) {
+		b := newOperationBuilder().ResponseSSE(200, OrderEvent{})
+
+		gen := NewSchemaGenerator()
+		op := b.buildOperation(gen, "streamOrders", nil)
+
+		require.Contains(t, op.Responses, "200")
+		require.Contains(t, op.Responses["200"].Content, "text/event-stream")
+		mt := op.Responses["200"].Content["text/event-stream"]
+		assert.Equal(t, &Schema{Type: SchemaTypeString}, mt.Schema)
+		require.Contains(t, mt.Extensions, SSEEventSchemaExtension)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/OrderEvent"}, mt.Extensions[SSEEventSchemaExtension])
+		assert.Contains(t, gen.Schemas(), "OrderEvent")
+	})
+
+	t.Run("explicit schema", func(t *testing.T) {
+		b := newOperationBuilder().ResponseSSE(200, &Schema{Type: SchemaTypeObject})
+
+		op := b.buildOperation(NewSchemaGenerator(), "stream", nil)
+		mt := op.Responses["200"].Content["text/event-stream"]
+		assert.Equal(t, &Schema{Type: SchemaTypeObject}, mt.Extensions[SSEEventSchemaExtension])
+	})
+
+	t.Run("nil event type", func(t *testing.T) {
+		b := newOperationBuilder().ResponseSSE(200, nil)
+
+		op := b.buildOperation(NewSchemaGenerator(), "stream", nil)
+		mt := op.Responses["200"].Content["text/event-stream"]
+		assert.Equal(t, &Schema{Type: SchemaTypeString}, mt.Schema)
+		assert.Nil(t, mt.Extensions)
+	})
+
+	t.Run("serialized on the operation", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/events", dummyHandler).Methods(http.MethodGet)).
+			ResponseSSE(http.StatusOK, OrderEvent{})
+
+		data, err := spec.Build(r).JSON()
+		require.NoError(t, err)
+
+		var raw struct {
+			Paths map[string]struct {
+				Get struct {
+					Responses map[string]struct {
+						Content map[string]map[string]any `json:"content"`
+					} `json:"responses"`
+				} `json:"get"`
+			} `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(data, &raw))
+		content := raw.Paths["/events"].Get.Responses["200"].Content
+		require.Contains(t, content, "text/event-stream")
+		assert.Equal(t, map[string]any{"type": "string"}, content["text/event-stream"]["schema"])
+		assert.Equal(t, map[string]any{"$ref": "#/components/schemas/OrderEvent"}, content["text/event-stream"]["x-sse-event-schema"])
+
+		parsed, err := DocumentFromJSON(data)
+		require.NoError(t, err)
+		mt := parsed.Paths["/events"].Get.Responses["200"].Content["text/event-stream"]
+		assert.Equal(t, map[string]any{"$ref": "#/components/schemas/OrderEvent"}, mt.Extensions[SSEEventSchemaExtension])
+	})
+}
+
+func TestResponseMultipart(t *testing.T) {
+	type Manifest struct {
+		Files []string `json:"files"`
+	}
+
+	t.Run("parts and encoding", func(t *testing.T) {
+		b := newOperationBuilder().ResponseMultipart(200, map[string]any{
+			"manifest": Manifest{},
+			"data":     []byte{},
+			"note":     "",
+			"count":    0,
+			"items":    []string{},
+			"archive":  MultipartPart{ContentType: "application/zip", Body: []byte{}},
+			"preview":  MultipartPart{ContentType: "image/png"},
+			"meta":     MultipartPart{ContentType: "application/xml", Body: &Schema{Type: SchemaTypeObject}},
+		})
+
+		gen := NewSchemaGenerator()
+		op := b.buildOperation(gen, "export", nil)
+
+		require.Contains(t, op.Responses["200"].Content, "multipart/mixed")
+		mt := op.Responses["200"].Content["multipart/mixed"]
+		assert.Equal(t, &Schema{
+			Type: SchemaTypeObject,
+			Properties: map[string]*Schema{
+				"manifest": {Ref: "#/components/schemas/Manifest"},
+				"data":     {ContentMediaType: "application/octet-stream"},
+				"note":     {Type: SchemaTypeString},
+				"count":    {Type: SchemaTypeInteger},
+				"items":    {Type: SchemaTypeArray, Items: &Schema{Type: SchemaTypeString}},
+				"archive":  {ContentMediaType: "application/zip"},
+				"preview":  {ContentMediaType: "image/png"},
+				"meta":     {Type: SchemaTypeObject},
+			},
+		}, mt.Schema)
+		assert.Equal(t, map[string]*Encoding{
+			"manifest": {ContentType: "application/json"},
+			"data":     {ContentType: "application/octet-stream"},
+			"note":     {ContentType: "text/plain"},
+			"count":    {ContentType: "text/plain"},
+			"items":    {ContentType: "application/json"},
+			"archive":  {ContentType: "application/zip"},
+			"preview":  {ContentType: "image/png"},
+			"meta":     {ContentType: "application/xml"},
+		}, mt.Encoding)
+		assert.Contains(t, gen.Schemas(), "Manifest")
+	})
+
+	t.Run("no parts", func(t *testing.T) {
+		op := newOperationBuilder().ResponseMultipart(200, nil).buildOperation(NewSchemaGenerator(), "export", nil)
+		mt := op.Responses["200"].Content["multipart/mixed"]
+		assert.Equal(t, &Schema{Type: SchemaTypeObject}, mt.Schema)
+		assert.Nil(t, mt.Encoding)
+	})
+
+	t.Run("serialized on the operation", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/exports/{id}", dummyHandler).Methods(http.MethodGet)).
+			ResponseMultipart(http.StatusOK, map[string]any{
+				"manifest": Manifest{},
+				"archive":  MultipartPart{ContentType: "application/zip"},
+			})
+
+		data, err := spec.Build(r).JSON()
+		require.NoError(t, err)
+
+		var raw struct {
+			Paths map[string]struct {
+				Get struct {
+					Responses map[string]struct {
+						Content map[string]map[string]any `json:"content"`
+					} `json:"responses"`
+				} `json:"get"`
+			} `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(data, &raw))
+		content := raw.Paths["/exports/{id}"].Get.Responses["200"].Content
+		require.Contains(t, content, "multipart/mixed")
+		assert.Equal(t, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"manifest": map[string]any{"$ref": "#/components/schemas/Manifest"},
+				"archive":  map[string]any{"contentMediaType": "application/zip"},
+			},
+		}, content["multipart/mixed"]["schema"])
+		assert.Equal(t, map[string]any{
+			"manifest": map[string]any{"contentType": "application/json"},
+			"archive":  map[string]any{"contentType": "application/zip"},
+		}, content["multipart/mixed"]["encoding"])
+	})
+}
+
+func TestDeprecationMetadata(t *testing.T) {
+	since := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("deprecated since", func(t *testing.T) {
+		b := newOperationBuilder().
+			DeprecatedSince(since).
+			Response(http.StatusOK, nil).
+			Response(http.StatusNotFound, nil)
+
+		op := b.buildOperation(NewSchemaGenerator(), "getUser", nil)
+		assert.True(t, op.Deprecated)
+		assert.Equal(t, Extensions{DeprecatedSinceExtension: "2025-03-01T00:00:00Z"}, op.Extensions)
+		for _, key := range []string{"200", "404"} {
+			require.Contains(t, op.Responses[key].Headers, "Deprecation")
+			assert.Equal(t, "@1740787200", op.Responses[key].Headers["Deprecation"].Example)
+			assert.NotContains(t, op.Responses[key].Headers, "Sunset")
+		}
+	})
+
+	t.Run("sunset", func(t *testing.T) {
+		b := newOperationBuilder().
+			Sunset(sunset, "https://docs.example.com/migrate").
+			Response(http.StatusOK, nil)
+
+		op := b.buildOperation(NewSchemaGenerator(), "getUser", nil)
+		assert.False(t, op.Deprecated)
+		assert.Equal(t, Extensions{SunsetExtension: map[string]any{
+			"date": "2025-09-01T12:00:00Z",
+			"link": "https://docs.example.com/migrate",
+		}}, op.Extensions)
+		h := op.Responses["200"].Headers["Sunset"]
+		require.NotNil(t, h)
+		assert.Equal(t, "Mon, 01 Sep 2025 12:00:00 GMT", h.Example)
+		assert.Contains(t, h.Description, "https://docs.example.com/migrate")
+	})
+
+	t.Run("sunset without link", func(t *testing.T) {
+		op := newOperationBuilder().Sunset(sunset, "").buildOperation(NewSchemaGenerator(), "getUser", nil)
+		assert.Equal(t, Extensions{SunsetExtension: map[string]any{"date": "2025-09-01T12:00:00Z"}}, op.Extensions)
+	})
+
+	t.Run("explicit headers kept", func(t *testing.T) {
+		custom := &Header{Description: "custom"}
+		b := newOperationBuilder().
+			DeprecatedSince(since).
+			Sunset(sunset, "").
+			Response(http.StatusOK, nil).
+			ResponseHeader(http.StatusOK, "Sunset", custom).
+			ResponseHeader(http.StatusOK, "X-Rate-Limit", &Header{})
+
+		op := b.buildOperation(NewSchemaGenerator(), "getUser", nil)
+		headers := op.Responses["200"].Headers
+		assert.Same(t, custom, headers["Sunset"])
+		assert.Contains(t, headers, "Deprecation")
+		assert.Contains(t, headers, "X-Rate-Limit")
+		assert.Len(t, b.meta.responseHeaders["200"], 2)
+	})
+
+	t.Run("not set", func(t *testing.T) {
+		op := newOperationBuilder().Deprecated().Response(http.StatusOK, nil).buildOperation(NewSchemaGenerator(), "getUser", nil)
+		assert.Nil(t, op.Extensions)
+		assert.Nil(t, op.Responses["200"].Headers)
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)).
+			DeprecatedSince(since).
+			Sunset(sunset, "https://docs.example.com/migrate").
+			Response(http.StatusOK, nil)
+
+		data, err := spec.Build(r).JSON()
+		require.NoError(t, err)
+
+		var raw struct {
+			Paths map[string]struct {
+				Get map[string]any `json:"get"`
+			} `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(data, &raw))
+		get := raw.Paths["/users"].Get
+		assert.Equal(t, true, get["deprecated"])
+		assert.Equal(t, "2025-03-01T00:00:00Z", get["x-deprecated-since"])
+		assert.Equal(t, map[string]any{"date": "2025-09-01T12:00:00Z", "link": "https://docs.example.com/migrate"}, get["x-sunset"])
+
+		parsed, err := DocumentFromJSON(data)
+		require.NoError(t, err)
+		op := parsed.Paths["/users"].Get
+		assert.Equal(t, "2025-03-01T00:00:00Z", op.Extensions[DeprecatedSinceExtension])
+		assert.Contains(t, op.Responses["200"].Headers, "Deprecation")
+		assert.Contains(t, op.Responses["200"].Headers, "Sunset")
+	})
+}
+
+func TestCallbackOp(t *testing.T) {
+	type CallbackEvent struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"`
+	}
+	type CallbackAck struct {
+		Received bool `json:"received"`
+	}
+	const expr = "{$request.body#/callback_url}"
+
+	t.Run("builds callback operation", func(t *testing.T) {
+		b := newOperationBuilder()
+		b.CallbackOp("onEvent", expr, http.MethodPost).
+			Summary("Event notification").
+			Request(CallbackEvent{}).
+			Response(http.StatusOK, CallbackAck{})
+
+		gen := NewSchemaGenerator()
+		op := b.buildOperation(gen, "subscribe", nil)
+
+		require.Contains(t, op.Callbacks, "onEvent")
+		item := (*op.Callbacks["onEvent"])[expr]
+		require.NotNil(t, item)
+		require.NotNil(t, item.Post)
+		assert.Equal(t, "Event notification", item.Post.Summary)
+		assert.Empty(t, item.Post.OperationID)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/CallbackEvent"}, item.Post.RequestBody.Content["application/json"].Schema)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/CallbackAck"}, item.Post.Responses["200"].Content["application/json"].Schema)
+		assert.Contains(t, gen.Schemas(), "CallbackEvent")
+		assert.Contains(t, gen.Schemas(), "CallbackAck")
+	})
+
+	t.Run("same arguments return the same builder", func(t *testing.T) {
+		b := newOperationBuilder()
+		first := b.CallbackOp("onEvent", expr, http.MethodPost)
+		assert.Same(t, first, b.CallbackOp("onEvent", expr, http.MethodPost))
+		assert.NotSame(t, first, b.CallbackOp("onEvent", expr, http.MethodPut))
+		assert.NotSame(t, first, b.CallbackOp("onOther", expr, http.MethodPost))
+	})
+
+	t.Run("multiple methods and expressions", func(t *testing.T) {
+		b := newOperationBuilder()
+		b.CallbackOp("onEvent", expr, http.MethodPost).Summary("post")
+		b.CallbackOp("onEvent", expr, http.MethodPut).Summary("put")
+		b.CallbackOp("onEvent", "{$request.query.fallback}", http.MethodPost).Summary("fallback")
+
+		op := b.buildOperation(NewSchemaGenerator(), "subscribe", nil)
+		cb := *op.Callbacks["onEvent"]
+		require.Len(t, cb, 2)
+		assert.Equal(t, "post", cb[expr].Post.Summary)
+		assert.Equal(t, "put", cb[expr].Put.Summary)
+		assert.Equal(t, "fallback", cb["{$request.query.fallback}"].Post.Summary)
+	})
+
+	t.Run("merges with raw callback of the same name", func(t *testing.T) {
+		raw := Callback{
+			expr: &PathItem{
+				Summary: "raw item",
+				Post:    &Operation{Summary: "raw post"},
+				Delete:  &Operation{Summary: "raw delete"},
+			},
+			"{$request.query.other}": &PathItem{Get: &Operation{Summary: "raw other"}},
+		}
+		b := newOperationBuilder().Callback("onEvent", &raw).Callback("untouched", &Callback{})
+		b.CallbackOp("onEvent", expr, http.MethodPost).Summary("built post")
+
+		op := b.buildOperation(NewSchemaGenerator(), "subscribe", nil)
+		require.Len(t, op.Callbacks, 2)
+		cb := *op.Callbacks["onEvent"]
+		require.Len(t, cb, 2)
+		assert.Equal(t, "raw item", cb[expr].Summary)
+		assert.Equal(t, "built post", cb[expr].Post.Summary)
+		assert.Equal(t, "raw delete", cb[expr].Delete.Summary)
+		assert.Equal(t, "raw other", cb["{$request.query.other}"].Get.Summary)
+
+		// The raw callback passed to Callback is not modified.
+		assert.Equal(t, "raw post", raw[expr].Post.Summary)
+		assert.Len(t, raw, 2)
+	})
+
+	t.Run("raw callbacks alone are kept as is", func(t *testing.T) {
+		raw := &Callback{expr: &PathItem{Post: &Operation{Summary: "raw"}}}
+		op := newOperationBuilder().Callback("onEvent", raw).buildOperation(NewSchemaGenerator(), "subscribe", nil)
+		assert.Same(t, raw, op.Callbacks["onEvent"])
+	})
+
+	t.Run("serialized in built document", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/subscriptions", dummyHandler).Methods(http.MethodPost)).
+			CallbackOp("onEvent", expr, http.MethodPost).
+			Request(CallbackEvent{}).
+			Response(http.StatusNoContent, nil)
+
+		doc := spec.Build(r)
+		require.NotNil(t, doc.Components)
+		assert.Contains(t, doc.Components.Schemas, "CallbackEvent")
+
+		data, err := doc.JSON()
+		require.NoError(t, err)
+		parsed, err := DocumentFromJSON(data)
+		require.NoError(t, err)
+		post := (*parsed.Paths["/subscriptions"].Post.Callbacks["onEvent"])[expr].Post
+		require.NotNil(t, post)
+		assert.Equal(t, "#/components/schemas/CallbackEvent", post.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Contains(t, post.Responses, "204")
+	})
+}
+
 func TestDefaultResponse(t *testing.T) {
 	type ErrorBody struct {
 		Message string `json:"message"`
@@ -0,0 +1,193 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Query parameters and response header documented by
+// OperationBuilder.Paginated.
+const (
+	PaginationCursorParam  = "cursor"
+	PaginationLimitParam   = "limit"
+	PaginationCursorHeader = "X-Next-Cursor"
+)
+
+// paginatedBody marks a response body registered with Paginated. Its schema
+// is the list envelope around the item schema.
+type paginatedBody struct {
+	itemType any
+}
+
+// Paginated returns a response body value describing the standard list
+// envelope around items of the given type:
+//
+//	{"items": [...], "next_cursor": "...", "total": 42}
+//
+// Pass it to Response or ResponseContent like any other body:
+//
+//	spec.Op("listUsers").Response(http.StatusOK, openapi.Paginated(User{}))
+//
+// itemType is a Go value or a *Schema. The envelope is registered as a
+// component schema named after the item type the same way a generic
+// Paginated[User] would be ("PaginatedUser"), with the usual collision
+// handling. Items without a name, such as slices, maps, or a *Schema that
+// is not a component reference, produce an inline envelope.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#components-object (schemas)
+func Paginated(itemType any) any {
+	return paginatedBody{itemType: itemType}
+}
+
+// PaginationOption configures OperationBuilder.Paginated.
+type PaginationOption func(*paginationOptions)
+
+type paginationOptions struct {
+	defaultLimit int
+	maxLimit     int
+	cursorHeader bool
+}
+
+// WithDefaultLimit documents the page size used when the limit query
+// parameter is omitted.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-9.2
+func WithDefaultLimit(n int) PaginationOption {
+	return func(o *paginationOptions) {
+		o.defaultLimit = n
+	}
+}
+
+// WithMaxLimit documents the largest page size accepted by the limit query
+// parameter.
+//
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-6.2.2
+func WithMaxLimit(n int) PaginationOption {
+	return func(o *paginationOptions) {
+		o.maxLimit = n
+	}
+}
+
+// WithNextCursorHeader documents the X-Next-Cursor response header, which
+// repeats the envelope's next_cursor for clients that page without parsing
+// the body.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#response-object (headers)
+func WithNextCursorHeader() PaginationOption {
+	return func(o *paginationOptions) {
+		o.cursorHeader = true
+	}
+}
+
+// Paginated documents a cursor-paginated list operation: it adds the cursor
+// and limit query parameters and registers Paginated(itemType) as the
+// application/json response for 200 OK.
+//
+//	spec.Route(r.HandleFunc("/users", listUsers).Methods(http.MethodGet)).
+//	    Paginated(User{}, openapi.WithMaxLimit(100), openapi.WithNextCursorHeader())
+//
+// See: https://spec.openapis.org/oas/v3.1.0#parameter-object
+func (b *OperationBuilder) Paginated(itemType any, opts ...PaginationOption) *OperationBuilder {
+	var o paginationOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	minLimit := 1.0
+	limit := &Schema{Type: SchemaTypeInteger, Minimum: &minLimit}
+	if o.maxLimit > 0 {
+		maxLimit := float64(o.maxLimit)
+		limit.Maximum = &maxLimit
+	}
+	if o.defaultLimit > 0 {
+		limit.Default = o.defaultLimit
+	}
+
+	b.Parameter(&Parameter{
+		Name:        PaginationCursorParam,
+		In:          ParameterInQuery,
+		Description: "Opaque cursor returned as next_cursor by the previous page.",
+		Schema:      &Schema{Type: SchemaTypeString},
+	})
+	b.Parameter(&Parameter{
+		Name:        PaginationLimitParam,
+		In:          ParameterInQuery,
+		Description: "Maximum number of items to return.",
+		Schema:      limit,
+	})
+	b.Response(http.StatusOK, Paginated(itemType))
+	if o.cursorHeader {
+		b.ResponseHeader(http.StatusOK, PaginationCursorHeader, &Header{
+			Description: "Cursor of the next page; absent on the last page.",
+			Schema:      &Schema{Type: SchemaTypeString},
+		})
+	}
+	return b
+}
+
+// paginatedSchema returns the envelope schema for items of the given type,
+// registering it as a component when the item type has a name.
+func (g *SchemaGenerator) paginatedSchema(itemType any) *Schema {
+	var items *Schema
+	var itemName string
+	if s, ok := itemType.(*Schema); ok {
+		items = s
+	} else if itemType != nil {
+		t := reflect.TypeOf(itemType)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		items = g.generateType(t)
+		itemName = t.Name()
+	}
+	if items == nil {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(items.Ref, componentSchemaRefPrefix); ok {
+		itemName = name
+	}
+
+	minTotal := 0.0
+	envelope := &Schema{
+		Type: SchemaTypeObject,
+		Properties: map[string]*Schema{
+			"items": {Type: SchemaTypeArray, Items: items},
+			"next_cursor": {
+				Type:        SchemaTypeString,
+				Description: "Cursor of the next page; absent on the last page.",
+			},
+			"total": {
+				Type:        SchemaTypeInteger,
+				Description: "Total number of items across all pages.",
+				Minimum:     &minTotal,
+			},
+		},
+		Required: []string{"items"},
+	}
+	if itemName == "" {
+		return envelope
+	}
+
+	base := sanitizeSchemaName("Paginated[" + itemName + "]")
+	name, ok := g.envelopeNames[base]
+	if !ok {
+		name = base
+		for i := 2; ; i++ {
+			if _, taken := g.nameTypes[name]; !taken {
+				break
+			}
+			name = base + strconv.Itoa(i)
+		}
+		// Reserve the name so a Go type that sanitizes to it later is
+		// renamed by schemaName instead of overwriting the envelope.
+		g.nameTypes[name] = nil
+		if g.envelopeNames == nil {
+			g.envelopeNames = make(map[string]string)
+		}
+		g.envelopeNames[base] = name
+		g.schemas[name] = envelope
+	}
+	return &Schema{Ref: componentSchemaRefPrefix + name}
+}
@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type PageItem struct {
+	ID string `json:"id"`
+}
+
+type pageNamedItem struct {
+	ID string `json:"id"`
+}
+
+func (pageNamedItem) OpenAPIName() string { return "Account" }
+
+type PaginatedPageItem struct {
+	Other bool `json:"other"`
+}
+
+func TestPaginated(t *testing.T) {
+	t.Run("envelope component", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		op := newOperationBuilder().Response(http.StatusOK, Paginated(PageItem{})).buildOperation(gen, "listUsers", nil)
+
+		mt := op.Responses["200"].Content["application/json"]
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedPageItem"}, mt.Schema)
+
+		envelope := gen.Schemas()["PaginatedPageItem"]
+		require.NotNil(t, envelope)
+		assert.Equal(t, SchemaTypeObject, envelope.Type)
+		assert.Equal(t, []string{"items"}, envelope.Required)
+		assert.Equal(t, &Schema{Type: SchemaTypeArray, Items: &Schema{Ref: "#/components/schemas/PageItem"}}, envelope.Properties["items"])
+		assert.Equal(t, SchemaTypeString, envelope.Properties["next_cursor"].Type)
+		assert.Equal(t, SchemaTypeInteger, envelope.Properties["total"].Type)
+		assert.Contains(t, gen.Schemas(), "PageItem")
+	})
+
+	t.Run("reused across operations", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		newOperationBuilder().Response(http.StatusOK, Paginated(PageItem{})).buildOperation(gen, "a", nil)
+		op := newOperationBuilder().Response(http.StatusOK, Paginated(&PageItem{})).buildOperation(gen, "b", nil)
+
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedPageItem"}, op.Responses["200"].Content["application/json"].Schema)
+		assert.Len(t, gen.Schemas(), 2)
+	})
+
+	t.Run("name follows item component name", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		op := newOperationBuilder().Response(http.StatusOK, Paginated(pageNamedItem{})).buildOperation(gen, "list", nil)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedAccount"}, op.Responses["200"].Content["application/json"].Schema)
+
+		op = newOperationBuilder().Response(http.StatusOK, Paginated("")).buildOperation(gen, "list", nil)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedString"}, op.Responses["200"].Content["application/json"].Schema)
+		assert.Equal(t, SchemaTypeString, gen.Schemas()["PaginatedString"].Properties["items"].Items.Type)
+	})
+
+	t.Run("collision with go type", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		gen.Generate(PaginatedPageItem{})
+		op := newOperationBuilder().Response(http.StatusOK, Paginated(PageItem{})).buildOperation(gen, "list", nil)
+
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedPageItem2"}, op.Responses["200"].Content["application/json"].Schema)
+		assert.Contains(t, gen.Schemas()["PaginatedPageItem"].Properties, "other")
+		assert.Contains(t, gen.Schemas()["PaginatedPageItem2"].Properties, "items")
+	})
+
+	t.Run("go type registered after envelope", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		newOperationBuilder().Response(http.StatusOK, Paginated(PageItem{})).buildOperation(gen, "list", nil)
+		ref := gen.Generate(PaginatedPageItem{})
+
+		assert.NotEqual(t, "#/components/schemas/PaginatedPageItem", ref.Ref)
+		assert.Contains(t, gen.Schemas()["PaginatedPageItem"].Properties, "items")
+	})
+
+	t.Run("inline envelope", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		op := newOperationBuilder().
+			Response(http.StatusOK, Paginated(&Schema{Type: SchemaTypeObject})).
+			buildOperation(gen, "list", nil)
+
+		schema := op.Responses["200"].Content["application/json"].Schema
+		assert.Empty(t, schema.Ref)
+		assert.Equal(t, &Schema{Type: SchemaTypeObject}, schema.Properties["items"].Items)
+		assert.Empty(t, gen.Schemas())
+
+		op = newOperationBuilder().
+			Response(http.StatusOK, Paginated(&Schema{Ref: "#/components/schemas/Remote"})).
+			buildOperation(gen, "list", nil)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedRemote"}, op.Responses["200"].Content["application/json"].Schema)
+	})
+
+	t.Run("nil item type", func(t *testing.T) {
+		op := newOperationBuilder().Response(http.StatusOK, Paginated(nil)).buildOperation(NewSchemaGenerator(), "list", nil)
+		assert.Nil(t, op.Responses["200"].Content["application/json"].Schema)
+	})
+}
+
+func TestOperationBuilderPaginated(t *testing.T) {
+	t.Run("parameters and response", func(t *testing.T) {
+		gen := NewSchemaGenerator()
+		op := newOperationBuilder().Paginated(PageItem{}).buildOperation(gen, "listUsers", nil)
+
+		require.Len(t, op.Parameters, 2)
+		cursor, limit := op.Parameters[0], op.Parameters[1]
+		assert.Equal(t, PaginationCursorParam, cursor.Name)
+		assert.Equal(t, ParameterInQuery, cursor.In)
+		assert.False(t, cursor.Required)
+		assert.Equal(t, SchemaTypeString, cursor.Schema.Type)
+
+		assert.Equal(t, PaginationLimitParam, limit.Name)
+		assert.Equal(t, ParameterInQuery, limit.In)
+		assert.Equal(t, SchemaTypeInteger, limit.Schema.Type)
+		require.NotNil(t, limit.Schema.Minimum)
+		assert.InDelta(t, 1, *limit.Schema.Minimum, 0)
+		assert.Nil(t, limit.Schema.Maximum)
+		assert.Nil(t, limit.Schema.Default)
+
+		resp := op.Responses["200"]
+		require.NotNil(t, resp)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/PaginatedPageItem"}, resp.Content["application/json"].Schema)
+		assert.Empty(t, resp.Headers)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		op := newOperationBuilder().
+			Paginated(PageItem{}, WithDefaultLimit(20), WithMaxLimit(100), WithNextCursorHeader()).
+			buildOperation(NewSchemaGenerator(), "listUsers", nil)
+
+		limit := op.Parameters[1].Schema
+		require.NotNil(t, limit.Maximum)
+		assert.InDelta(t, 100, *limit.Maximum, 0)
+		assert.Equal(t, 20, limit.Default)
+
+		header := op.Responses["200"].Headers[PaginationCursorHeader]
+		require.NotNil(t, header)
+		assert.Equal(t, SchemaTypeString, header.Schema.Type)
+	})
+
+	t.Run("built document", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "1.0.0"})
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)).
+			Paginated(PageItem{}, WithNextCursorHeader())
+
+		doc := spec.Build(r)
+		require.NotNil(t, doc.Components)
+		assert.Contains(t, doc.Components.Schemas, "PaginatedPageItem")
+		assert.Contains(t, doc.Components.Schemas, "PageItem")
+
+		get := doc.Paths["/users"].Get
+		require.Len(t, get.Parameters, 2)
+		assert.Contains(t, get.Responses["200"].Headers, PaginationCursorHeader)
+
+		data, err := doc.JSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"$ref": "#/components/schemas/PaginatedPageItem"`)
+	})
+}
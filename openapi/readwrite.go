@@ -0,0 +1,251 @@
+package openapi
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SplitReadWriteSchemas enables request and response variants of component
+// schemas that contain readOnly or writeOnly properties.
+//
+// By default a Go type maps to one component schema shared by requests and
+// responses. Its readOnly and writeOnly properties are documented but never
+// listed as required, since a readOnly property is absent from requests and
+// a writeOnly one from responses.
+//
+// When enabled, a request body that references such a component gets a
+// "<Name>Request" variant without the readOnly properties, and a response
+// gets a "<Name>Response" variant without the writeOnly properties. In each
+// variant the remaining readOnly or writeOnly properties are required again
+// unless their json tag has omitempty. Components that reference a split
+// component are split too, so nested types are covered. Types without such
+// properties keep their single shared component.
+//
+// Only route operation request bodies and responses use the variants; the
+// shared component is kept for webhooks, callbacks, and any other reference.
+// A variant name already taken by another component gets a numeric suffix
+// ("UserRequest2").
+//
+// See: https://spec.openapis.org/oas/v3.1.0#fixed-fields-20 (readOnly, writeOnly)
+// See: https://json-schema.org/draft/2020-12/json-schema-validation#section-9.4
+func (s *Spec) SplitReadWriteSchemas(enabled bool) *Spec {
+	s.splitReadWrite = enabled
+	s.splitReadWriteSet = true
+	return s
+}
+
+// splitReadWriteSchemas points the request bodies and responses of route
+// operations at request and response variants of the component schemas
+// that need them, adding the variants to the document's components.
+func splitReadWriteSchemas(doc *Document, gen *SchemaGenerator) {
+	var components map[string]*Schema
+	if doc.Components != nil {
+		components = doc.Components.Schemas
+	}
+
+	request := newSchemaSplitter(components, gen.directionalRequired, "Request", func(p *Schema) bool { return p.ReadOnly })
+	response := newSchemaSplitter(components, gen.directionalRequired, "Response", func(p *Schema) bool { return p.WriteOnly })
+
+	for _, pathItem := range doc.Paths {
+		for _, op := range pathItem.operations() {
+			if op.RequestBody != nil {
+				for _, mt := range op.RequestBody.Content {
+					mt.Schema = request.split(mt.Schema)
+				}
+			}
+			for _, resp := range op.Responses {
+				if resp == nil {
+					continue
+				}
+				for _, mt := range resp.Content {
+					mt.Schema = response.split(mt.Schema)
+				}
+			}
+		}
+	}
+}
+
+// schemaSplitter derives the variants of component schemas for one
+// direction, requests or responses.
+type schemaSplitter struct {
+	components map[string]*Schema
+	required   map[*Schema][]string // directional required properties, see SchemaGenerator
+	suffix     string
+	omit       func(property *Schema) bool
+
+	needs    map[string]bool   // component name -> needs a variant
+	variants map[string]string // component name -> variant name
+}
+
+func newSchemaSplitter(components map[string]*Schema, required map[*Schema][]string, suffix string, omit func(*Schema) bool) *schemaSplitter {
+	sp := &schemaSplitter{
+		components: components,
+		required:   required,
+		suffix:     suffix,
+		omit:       omit,
+		needs:      make(map[string]bool, len(components)),
+		variants:   make(map[string]string),
+	}
+
+	// A component needs a variant when it has a property to omit or
+	// required properties to restore, or references a component that
+	// needs one. References can be cyclic, so propagate to a fixed point.
+	refs := make(map[string][]string, len(components))
+	for name, schema := range components {
+		sp.needs[name] = sp.direct(schema, func(ref string) {
+			refs[name] = append(refs[name], ref)
+		})
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, targets := range refs {
+			if sp.needs[name] {
+				continue
+			}
+			for _, target := range targets {
+				if sp.needs[target] {
+					sp.needs[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return sp
+}
+
+// direct reports whether s or an inline subschema has a property to omit
+// or restore, calling ref for each component it references.
+func (sp *schemaSplitter) direct(s *Schema, ref func(name string)) bool {
+	if s == nil {
+		return false
+	}
+	found := false
+	if name, ok := strings.CutPrefix(s.Ref, componentSchemaRefPrefix); ok {
+		ref(name)
+	}
+	if len(sp.required[s]) > 0 {
+		found = true
+	}
+	for _, p := range s.Properties {
+		if p != nil && sp.omit(p) {
+			found = true
+		}
+	}
+	for _, sub := range subschemas(s) {
+		if sp.direct(sub, ref) {
+			found = true
+		}
+	}
+	return found
+}
+
+// needsVariant reports whether s, an inline schema, differs in this
+// direction: it has a property to omit or restore, or references a
+// component that needs a variant.
+func (sp *schemaSplitter) needsVariant(s *Schema) bool {
+	refsVariant := false
+	direct := sp.direct(s, func(name string) {
+		if sp.needs[name] {
+			refsVariant = true
+		}
+	})
+	return direct || refsVariant
+}
+
+// split returns s for this direction: s itself when nothing differs,
+// otherwise a copy with the omitted properties removed, the directional
+// required properties restored, and references to split components
+// pointing at their variants. s is never modified.
+func (sp *schemaSplitter) split(s *Schema) *Schema {
+	if s == nil || !sp.needsVariant(s) {
+		return s
+	}
+
+	out := *s
+	if name, ok := strings.CutPrefix(s.Ref, componentSchemaRefPrefix); ok && sp.needs[name] {
+		out.Ref = componentSchemaRefPrefix + sp.variant(name)
+	}
+
+	if s.Properties != nil {
+		out.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, p := range s.Properties {
+			if p != nil && sp.omit(p) {
+				continue
+			}
+			out.Properties[name] = sp.split(p)
+		}
+		out.Required = nil
+		for _, name := range slices.Concat(s.Required, sp.required[s]) {
+			if _, ok := out.Properties[name]; ok {
+				out.Required = append(out.Required, name)
+			}
+		}
+	}
+
+	out.Items = sp.split(s.Items)
+	out.AdditionalProperties = sp.split(s.AdditionalProperties)
+	out.PrefixItems = sp.splitSlice(s.PrefixItems)
+	out.AllOf = sp.splitSlice(s.AllOf)
+	out.OneOf = sp.splitSlice(s.OneOf)
+	out.AnyOf = sp.splitSlice(s.AnyOf)
+	if s.PatternProperties != nil {
+		out.PatternProperties = make(map[string]*Schema, len(s.PatternProperties))
+		for pattern, p := range s.PatternProperties {
+			out.PatternProperties[pattern] = sp.split(p)
+		}
+	}
+	return &out
+}
+
+func (sp *schemaSplitter) splitSlice(list []*Schema) []*Schema {
+	if list == nil {
+		return nil
+	}
+	out := make([]*Schema, len(list))
+	for i, s := range list {
+		out[i] = sp.split(s)
+	}
+	return out
+}
+
+// variant returns the name of the variant of component name, adding the
+// variant to the components on first use.
+func (sp *schemaSplitter) variant(name string) string {
+	if v, ok := sp.variants[name]; ok {
+		return v
+	}
+
+	base := name + sp.suffix
+	v := base
+	for n := 2; sp.components[v] != nil; n++ {
+		v = base + strconv.Itoa(n)
+	}
+
+	// Register the name before splitting so recursive types refer to
+	// the variant being built.
+	sp.variants[name] = v
+	sp.components[v] = &Schema{}
+	*sp.components[v] = *sp.split(sp.components[name])
+	return v
+}
+
+// subschemas returns the inline subschemas of s that the splitter walks,
+// other than its properties.
+func subschemas(s *Schema) []*Schema {
+	subs := make([]*Schema, 0, len(s.Properties)+len(s.PatternProperties)+len(s.PrefixItems)+len(s.AllOf)+len(s.OneOf)+len(s.AnyOf)+2)
+	for _, p := range s.Properties {
+		subs = append(subs, p)
+	}
+	for _, p := range s.PatternProperties {
+		subs = append(subs, p)
+	}
+	subs = append(subs, s.Items, s.AdditionalProperties)
+	subs = append(subs, s.PrefixItems...)
+	subs = append(subs, s.AllOf...)
+	subs = append(subs, s.OneOf...)
+	subs = append(subs, s.AnyOf...)
+	return subs
+}
@@ -0,0 +1,210 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type rwUser struct {
+	ID        string    `json:"id" openapi:"readOnly"`
+	CreatedAt time.Time `json:"created_at" openapi:"readOnly"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Password  string    `json:"password" openapi:"writeOnly"`
+}
+
+type rwOrder struct {
+	ID    string   `json:"id"`
+	Owner rwUser   `json:"owner"`
+	Tags  []string `json:"tags"`
+}
+
+type rwNode struct {
+	ID       string    `json:"id" openapi:"readOnly"`
+	Children []*rwNode `json:"children"`
+}
+
+type rwPlain struct {
+	Name string `json:"name"`
+}
+
+func TestBuildReadWriteSchemas(t *testing.T) {
+	build := func(split bool) *Document {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		if split {
+			spec.SplitReadWriteSchemas(true)
+		}
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodPost)).
+			Request(rwUser{}).
+			Response(http.StatusCreated, rwUser{})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, []rwUser{})
+		spec.Route(r.HandleFunc("/orders", dummyHandler).Methods(http.MethodPut)).
+			Request(rwOrder{}).
+			Response(http.StatusOK, &rwOrder{})
+		spec.Route(r.HandleFunc("/nodes", dummyHandler).Methods(http.MethodPost)).
+			Request(rwNode{}).
+			Response(http.StatusOK, rwNode{})
+		spec.Route(r.HandleFunc("/plain", dummyHandler).Methods(http.MethodPost)).
+			Request(rwPlain{}).
+			Response(http.StatusOK, rwPlain{})
+
+		return spec.Build(r)
+	}
+
+	schemaRef := func(name string) string { return "#/components/schemas/" + name }
+
+	t.Run("shared schema leaves directional properties optional", func(t *testing.T) {
+		doc := build(false)
+		schemas := doc.Components.Schemas
+
+		user := schemas["rwUser"]
+		require.NotNil(t, user)
+		assert.Equal(t, []string{"name"}, user.Required)
+		assert.True(t, user.Properties["id"].ReadOnly)
+		assert.True(t, user.Properties["password"].WriteOnly)
+
+		assert.NotContains(t, schemas, "rwUserRequest")
+		assert.NotContains(t, schemas, "rwUserResponse")
+		assert.Equal(t, schemaRef("rwUser"), doc.Paths["/users"].Post.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, schemaRef("rwUser"), doc.Paths["/users"].Post.Responses["201"].Content["application/json"].Schema.Ref)
+	})
+
+	t.Run("request variant omits readOnly properties", func(t *testing.T) {
+		doc := build(true)
+		schemas := doc.Components.Schemas
+
+		assert.Equal(t, schemaRef("rwUserRequest"), doc.Paths["/users"].Post.RequestBody.Content["application/json"].Schema.Ref)
+
+		req := schemas["rwUserRequest"]
+		require.NotNil(t, req)
+		assert.NotContains(t, req.Properties, "id")
+		assert.NotContains(t, req.Properties, "created_at")
+		assert.Contains(t, req.Properties, "password")
+		assert.ElementsMatch(t, []string{"name", "password"}, req.Required)
+	})
+
+	t.Run("response variant omits writeOnly properties", func(t *testing.T) {
+		doc := build(true)
+		schemas := doc.Components.Schemas
+
+		assert.Equal(t, schemaRef("rwUserResponse"), doc.Paths["/users"].Post.Responses["201"].Content["application/json"].Schema.Ref)
+		assert.Equal(t, schemaRef("rwUserResponse"), doc.Paths["/users"].Get.Responses["200"].Content["application/json"].Schema.Items.Ref)
+
+		resp := schemas["rwUserResponse"]
+		require.NotNil(t, resp)
+		assert.NotContains(t, resp.Properties, "password")
+		assert.Contains(t, resp.Properties, "id")
+		assert.ElementsMatch(t, []string{"name", "id", "created_at"}, resp.Required)
+	})
+
+	t.Run("shared component is kept unchanged", func(t *testing.T) {
+		doc := build(true)
+
+		user := doc.Components.Schemas["rwUser"]
+		require.NotNil(t, user)
+		assert.Len(t, user.Properties, 5)
+		assert.Equal(t, []string{"name"}, user.Required)
+	})
+
+	t.Run("referencing components are split", func(t *testing.T) {
+		doc := build(true)
+		schemas := doc.Components.Schemas
+
+		assert.Equal(t, schemaRef("rwOrderRequest"), doc.Paths["/orders"].Put.RequestBody.Content["application/json"].Schema.Ref)
+		orderReq := schemas["rwOrderRequest"]
+		require.NotNil(t, orderReq)
+		assert.Equal(t, schemaRef("rwUserRequest"), orderReq.Properties["owner"].Ref)
+		assert.Equal(t, schemas["rwOrder"].Properties["tags"], orderReq.Properties["tags"])
+
+		nullable := doc.Paths["/orders"].Put.Responses["200"].Content["application/json"].Schema
+		require.Len(t, nullable.AnyOf, 2)
+		assert.Equal(t, schemaRef("rwOrderResponse"), nullable.AnyOf[0].Ref)
+		assert.Equal(t, schemaRef("rwUserResponse"), schemas["rwOrderResponse"].Properties["owner"].Ref)
+	})
+
+	t.Run("recursive types", func(t *testing.T) {
+		doc := build(true)
+		schemas := doc.Components.Schemas
+
+		nodeReq := schemas["rwNodeRequest"]
+		require.NotNil(t, nodeReq)
+		assert.NotContains(t, nodeReq.Properties, "id")
+		assert.Equal(t, schemaRef("rwNodeRequest"), nodeReq.Properties["children"].Items.AnyOf[0].Ref)
+
+		nodeResp := schemas["rwNodeResponse"]
+		require.NotNil(t, nodeResp)
+		assert.Equal(t, []string{"children", "id"}, nodeResp.Required)
+		assert.Equal(t, schemaRef("rwNodeResponse"), nodeResp.Properties["children"].Items.AnyOf[0].Ref)
+	})
+
+	t.Run("types without directional properties are not split", func(t *testing.T) {
+		doc := build(true)
+		schemas := doc.Components.Schemas
+
+		assert.NotContains(t, schemas, "rwPlainRequest")
+		assert.NotContains(t, schemas, "rwPlainResponse")
+		assert.Equal(t, schemaRef("rwPlain"), doc.Paths["/plain"].Post.RequestBody.Content["application/json"].Schema.Ref)
+	})
+
+	t.Run("variant name collision", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).SplitReadWriteSchemas(true)
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodPost)).
+			Request(rwUser{}).
+			Response(http.StatusOK, &Schema{Ref: schemaRef("rwUserRequest")})
+		spec.Route(r.HandleFunc("/other", dummyHandler).Methods(http.MethodPost)).
+			Request(rwUserRequest{})
+
+		doc := spec.Build(r)
+		assert.Equal(t, schemaRef("rwUserRequest2"), doc.Paths["/users"].Post.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Contains(t, doc.Components.Schemas["rwUserRequest"].Properties, "other")
+	})
+
+	t.Run("explicit schemas", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).SplitReadWriteSchemas(true)
+		explicit := &Schema{
+			Type: SchemaTypeObject,
+			Properties: map[string]*Schema{
+				"id":   {Type: SchemaTypeString, ReadOnly: true},
+				"name": {Type: SchemaTypeString},
+			},
+			Required: []string{"id", "name"},
+		}
+		spec.Route(r.HandleFunc("/items", dummyHandler).Methods(http.MethodPost)).
+			Request(explicit).
+			Response(http.StatusOK, explicit)
+
+		doc := spec.Build(r)
+		req := doc.Paths["/items"].Post.RequestBody.Content["application/json"].Schema
+		assert.NotContains(t, req.Properties, "id")
+		assert.Equal(t, []string{"name"}, req.Required)
+		assert.Same(t, explicit, doc.Paths["/items"].Post.Responses["200"].Content["application/json"].Schema)
+		assert.Len(t, explicit.Properties, 2, "explicit schema not modified")
+	})
+
+	t.Run("inherited by scope", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).SplitReadWriteSchemas(true)
+		spec.Route(r.HandleFunc("/v1/users", dummyHandler).Methods(http.MethodPost)).
+			Request(rwUser{})
+
+		doc := spec.Scope("/v1", Info{Title: "v1", Version: "1.0.0"}).Build(r)
+		assert.Equal(t, schemaRef("rwUserRequest"), doc.Paths["/v1/users"].Post.RequestBody.Content["application/json"].Schema.Ref)
+
+		doc = spec.Scope("/v1", Info{Title: "v1", Version: "1.0.0"}).SplitReadWriteSchemas(false).Build(r)
+		assert.Equal(t, schemaRef("rwUser"), doc.Paths["/v1/users"].Post.RequestBody.Content["application/json"].Schema.Ref)
+	})
+}
+
+type rwUserRequest struct {
+	Other string `json:"other"`
+}
@@ -1,7 +1,7 @@
 package openapi
 
 import (
-	"fmt"
+	"encoding/json"
 	"maps"
 	"reflect"
 	"slices"
@@ -37,6 +37,23 @@ type Exampler interface {
 	OpenAPIExample() any
 }
 
+// SchemaProvider can be implemented by types whose JSON encoding does not
+// follow their Go fields, such as types with a custom MarshalJSON, to
+// supply their schema instead of having it reflected. The returned schema
+// is used as is: struct fields, field tags, Exampler, and DescribeType are
+// not applied. Named types are still stored as a component schema under
+// the type name (or its Namer name) and referenced via $ref; returning nil
+// falls back to reflection.
+//
+//	func (Money) OpenAPISchema() *openapi.Schema {
+//	    return &openapi.Schema{Type: openapi.SchemaTypeString, Pattern: `^\d+\.\d{2} [A-Z]{3}$`}
+//	}
+//
+// See: https://spec.openapis.org/oas/v3.1.0#schema-object
+type SchemaProvider interface {
+	OpenAPISchema() *Schema
+}
+
 // SchemaGenerator converts Go types to JSON Schema objects and collects
 // named types into a component schemas map for $ref deduplication.
 //
@@ -48,6 +65,19 @@ type SchemaGenerator struct {
 	typeNames map[reflect.Type]string // type -> chosen schema name
 	nameTypes map[string]reflect.Type // schema name -> type that claimed it
 
+	// envelopeNames maps the base name of a Paginated envelope to the
+	// component name it was registered under.
+	envelopeNames map[string]string
+
+	// envelopeTypes maps the package path and instantiated type name of a
+	// response envelope to the component name it was registered under, so
+	// the same generic type written out by hand shares the component.
+	envelopeTypes map[string]string
+
+	// envelopePayload is the body substituted for Payload while a response
+	// envelope template is generated.
+	envelopePayload *envelopePayload
+
 	// fieldTag overrides the struct tag used for property names. When empty
 	// (default), the "json" tag is used. When set (e.g. "form"), the
 	// generator reads that tag first and falls back to "json" if absent.
@@ -55,6 +85,15 @@ type SchemaGenerator struct {
 	// (never stored as $ref components) because their property names may
 	// differ from the canonical JSON representation.
 	fieldTag string
+
+	// docs holds descriptions and enums registered with DescribeType,
+	// DescribeField, and RegisterEnum.
+	docs *typeDocs
+
+	// directionalRequired maps an object schema to its readOnly and
+	// writeOnly properties that would be required but are left out of
+	// Required, so split request and response variants can restore them.
+	directionalRequired map[*Schema][]string
 }
 
 // NewSchemaGenerator creates a new schema generator.
@@ -67,6 +106,7 @@ func NewSchemaGenerator() *SchemaGenerator {
 		visited:   make(map[reflect.Type]bool),
 		typeNames: make(map[reflect.Type]string),
 		nameTypes: make(map[string]reflect.Type),
+		docs:      &typeDocs{},
 	}
 }
 
@@ -139,10 +179,19 @@ func (g *SchemaGenerator) generateType(t reflect.Type) *Schema {
 		t = t.Elem()
 	}
 
-	// Named struct types → $ref (except time.Time which is a special case).
+	if t == payloadType && g.envelopePayload != nil {
+		return g.payloadSchema(nullable)
+	}
+
+	if schema := g.providedSchema(t, nullable); schema != nil {
+		return schema
+	}
+
+	// Named struct types → $ref (except time.Time which is a special case,
+	// and json.Marshaler implementers whose encoding is opaque).
 	// When fieldTag is set, property names differ from the canonical JSON
 	// representation, so we skip $ref and always generate inline.
-	if t.Kind() == reflect.Struct && t != reflect.TypeFor[time.Time]() && g.fieldTag == "" {
+	if t.Kind() == reflect.Struct && t != reflect.TypeFor[time.Time]() && !isJSONMarshaler(t) && g.fieldTag == "" {
 		name := g.schemaName(t)
 		if name != "" {
 			// Generate the schema if not already visited.
@@ -155,29 +204,83 @@ func (g *SchemaGenerator) generateType(t reflect.Type) *Schema {
 					schema.Example = ex.OpenAPIExample()
 				}
 
+				if desc, ok := g.docs.typeDescription(t); ok {
+					schema.Description = desc
+				}
+
 				g.schemas[name] = schema
 			}
 
-			ref := &Schema{Ref: fmt.Sprintf("#/components/schemas/%s", name)}
-			if nullable {
-				return &Schema{
-					AnyOf: []*Schema{
-						ref,
-						{Type: SchemaTypeNull},
-					},
-				}
-			}
-			return ref
+			return componentRef(name, nullable)
 		}
 	}
 
 	schema := g.generateInlineType(t)
-	if nullable && schema != nil {
+	if schema == nil {
+		return nil
+	}
+	if desc, ok := g.docs.typeDescription(t); ok && t.PkgPath() != "" {
+		schema.Description = desc
+	}
+	if enum, ok := g.docs.typeEnum(t); ok {
+		enum.apply(schema)
+	}
+	if nullable {
 		applyNullable(schema)
+		if schema.Enum != nil {
+			schema.Enum = append(schema.Enum, nil)
+		}
 	}
 	return schema
 }
 
+// providedSchema returns the schema of a type implementing SchemaProvider:
+// a $ref to its component schema, or an inline copy when the type has no
+// component name or fieldTag is set. It returns nil for other types and
+// when the provider returns nil.
+func (g *SchemaGenerator) providedSchema(t reflect.Type, nullable bool) *Schema {
+	provider, ok := reflect.New(t).Interface().(SchemaProvider)
+	if !ok {
+		return nil
+	}
+	provided := provider.OpenAPISchema()
+	if provided == nil {
+		return nil
+	}
+
+	if g.fieldTag == "" {
+		if name := g.schemaName(t); name != "" {
+			if !g.visited[t] {
+				g.visited[t] = true
+				schema := *provided
+				g.schemas[name] = &schema
+			}
+			return componentRef(name, nullable)
+		}
+	}
+
+	schema := *provided
+	if nullable {
+		applyNullable(&schema)
+	}
+	return &schema
+}
+
+// componentRef returns a $ref to the named component schema, allowing null
+// when nullable.
+func componentRef(name string, nullable bool) *Schema {
+	ref := &Schema{Ref: componentSchemaRefPrefix + name}
+	if nullable {
+		return &Schema{
+			AnyOf: []*Schema{
+				ref,
+				{Type: SchemaTypeNull},
+			},
+		}
+	}
+	return ref
+}
+
 // generateInlineType maps Go primitive and composite types to JSON Schema types.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#data-types
@@ -191,6 +294,12 @@ func (g *SchemaGenerator) generateInlineType(t reflect.Type) *Schema {
 		}
 	}
 
+	// json.RawMessage and other json.Marshaler implementers may encode
+	// any JSON value, so they accept anything.
+	if isJSONMarshaler(t) {
+		return &Schema{}
+	}
+
 	switch t.Kind() {
 	case reflect.Bool:
 		return &Schema{Type: SchemaTypeBoolean}
@@ -226,10 +335,17 @@ func (g *SchemaGenerator) generateInlineType(t reflect.Type) *Schema {
 		}
 
 	case reflect.Map:
-		return &Schema{
+		schema := &Schema{
 			Type:                 SchemaTypeObject,
 			AdditionalProperties: g.generateType(t.Elem()),
 		}
+		// JSON object keys are strings, so only string-kind key enums
+		// translate directly to propertyNames.
+		if enum, ok := g.docs.typeEnum(t.Key()); ok && t.Key().Kind() == reflect.String {
+			schema.PropertyNames = &Schema{}
+			enum.apply(schema.PropertyNames)
+		}
+		return schema
 
 	case reflect.Struct:
 		return g.generateStructSchema(t)
@@ -241,6 +357,17 @@ func (g *SchemaGenerator) generateInlineType(t reflect.Type) *Schema {
 	return nil
 }
 
+// isJSONMarshaler reports whether t or *t implements json.Marshaler, which
+// makes its JSON encoding independent of its Go shape. time.Time is
+// excluded because its encoding is well known.
+func isJSONMarshaler(t reflect.Type) bool {
+	if t == reflect.TypeFor[time.Time]() {
+		return false
+	}
+	marshaler := reflect.TypeFor[json.Marshaler]()
+	return t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler)
+}
+
 // generateStructSchema builds an object schema from struct fields.
 //
 // See: https://json-schema.org/draft/2020-12/json-schema-core#section-10.3.2 (properties)
@@ -251,7 +378,7 @@ func (g *SchemaGenerator) generateStructSchema(t reflect.Type) *Schema {
 		Properties: make(map[string]*Schema),
 	}
 
-	g.collectFields(t, schema, false)
+	g.collectFields(t, t, schema, false)
 
 	if len(schema.Properties) == 0 {
 		schema.Properties = nil
@@ -267,7 +394,7 @@ func (g *SchemaGenerator) generateStructSchema(t reflect.Type) *Schema {
 //
 // See: https://json-schema.org/draft/2020-12/json-schema-core#section-10.3.2.1 (properties)
 // See: https://json-schema.org/draft/2020-12/json-schema-validation#section-6.5.3 (required)
-func (g *SchemaGenerator) collectFields(t reflect.Type, schema *Schema, allOptional bool) {
+func (g *SchemaGenerator) collectFields(t, root reflect.Type, schema *Schema, allOptional bool) {
 	for i := range t.NumField() {
 		field := t.Field(i)
 
@@ -291,7 +418,7 @@ func (g *SchemaGenerator) collectFields(t reflect.Type, schema *Schema, allOptio
 					// Pointer-embedded structs: all inlined fields become
 					// optional because the pointer can be nil, omitting
 					// all fields from JSON output.
-					g.collectFields(ft, schema, allOptional || isPtr)
+					g.collectFields(ft, root, schema, allOptional || isPtr)
 					continue
 				}
 			}
@@ -314,6 +441,14 @@ func (g *SchemaGenerator) collectFields(t reflect.Type, schema *Schema, allOptio
 
 		applyOpenAPITag(fieldSchema, field.Tag.Get("openapi"))
 
+		jsonName, _ := parseJSONTag(field.Tag.Get("json"))
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if desc, ok := g.docs.fieldDescription(jsonName, root, t); ok {
+			fieldSchema.Description = desc
+		}
+
 		// The encoding/json ",string" option encodes numeric and boolean
 		// values as JSON strings. Override the schema type accordingly.
 		if opts.stringEncode && fieldSchema.Ref == "" && len(fieldSchema.AnyOf) == 0 {
@@ -323,7 +458,17 @@ func (g *SchemaGenerator) collectFields(t reflect.Type, schema *Schema, allOptio
 		schema.Properties[name] = fieldSchema
 
 		if !opts.omitempty && !allOptional {
-			schema.Required = append(schema.Required, name)
+			// A readOnly property is absent from requests and a writeOnly
+			// one from responses, so listing either in the required list
+			// of a schema shared by both would reject valid messages.
+			if fieldSchema.ReadOnly || fieldSchema.WriteOnly {
+				if g.directionalRequired == nil {
+					g.directionalRequired = make(map[*Schema][]string)
+				}
+				g.directionalRequired[schema] = append(g.directionalRequired[schema], name)
+			} else {
+				schema.Required = append(schema.Required, name)
+			}
 		}
 	}
 }
@@ -355,8 +500,15 @@ func parseJSONTag(tag string) (string, jsonTagOpts) {
 	}
 }
 
+// DeprecatedMessageExtension is the schema extension under which the
+// deprecatedMessage struct tag key records a migration hint for a
+// deprecated field.
+const DeprecatedMessageExtension = "x-deprecated-message"
+
 // applyOpenAPITag parses the `openapi` struct tag and applies constraints to the schema.
-// Tag keys map to JSON Schema and OpenAPI Schema Object keywords.
+// Tag keys map to JSON Schema and OpenAPI Schema Object keywords. Entries are
+// separated by commas; a comma inside a value is escaped with a backslash,
+// written as `\\,` in Go struct tag source.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#schema-object
 // See: https://json-schema.org/draft/2020-12/json-schema-validation
@@ -365,7 +517,10 @@ func applyOpenAPITag(schema *Schema, tag string) {
 		return
 	}
 
-	for part := range strings.SplitSeq(tag, ",") {
+	// Enum labels are set after the loop so that an enum entry, which
+	// drops the labels of the type's enum, may appear in any position.
+	var enumVarNames, enumDescriptions []string
+	for _, part := range splitTagParts(tag) {
 		key, value, hasValue := strings.Cut(part, "=")
 		key = strings.TrimSpace(key)
 		if hasValue {
@@ -411,8 +566,21 @@ func applyOpenAPITag(schema *Schema, tag string) {
 			for i, v := range values {
 				schema.Enum[i] = v
 			}
+			// Labels of the type's enum do not describe these values.
+			delete(schema.Extensions, EnumVarNamesExtension)
+			delete(schema.Extensions, EnumDescriptionsExtension)
+		case "enumVarNames":
+			enumVarNames = strings.Split(value, "|")
+		case "enumDescriptions":
+			enumDescriptions = strings.Split(value, "|")
 		case "deprecated":
 			schema.Deprecated = true
+		case "deprecatedMessage":
+			schema.Deprecated = true
+			if schema.Extensions == nil {
+				schema.Extensions = make(Extensions)
+			}
+			schema.Extensions[DeprecatedMessageExtension] = value
 		case "readOnly":
 			schema.ReadOnly = true
 		case "writeOnly":
@@ -443,10 +611,48 @@ func applyOpenAPITag(schema *Schema, tag string) {
 			}
 		case "const":
 			schema.Const = parseExampleValue(schema, value)
+		case "default":
+			schema.Default = parseExampleValue(schema, value)
+		}
+	}
+
+	if enumVarNames != nil || enumDescriptions != nil {
+		if schema.Extensions == nil {
+			schema.Extensions = make(Extensions)
+		}
+		if enumVarNames != nil {
+			schema.Extensions[EnumVarNamesExtension] = enumVarNames
+		}
+		if enumDescriptions != nil {
+			schema.Extensions[EnumDescriptionsExtension] = enumDescriptions
 		}
 	}
 }
 
+// splitTagParts splits an `openapi` tag on commas that are not escaped with a
+// backslash, and turns each escaped `\,` into a plain comma.
+func splitTagParts(tag string) []string {
+	if !strings.Contains(tag, `\,`) {
+		return strings.Split(tag, ",")
+	}
+
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	return append(parts, b.String())
+}
+
 // parseExampleValue converts a string tag value to the appropriate Go type
 // based on the schema's type field.
 //
@@ -489,6 +695,14 @@ func (g *SchemaGenerator) schemaName(t reflect.Type) string {
 	if name, ok := g.typeNames[t]; ok {
 		return name
 	}
+	if g.envelopeTypes != nil {
+		// A generic type already registered as a response envelope.
+		if name, ok := g.envelopeTypes[t.PkgPath()+"."+t.Name()]; ok {
+			g.typeNames[t] = name
+			g.nameTypes[name] = t
+			return name
+		}
+	}
 
 	// Check if the type implements Namer for a custom base name.
 	// Skip promoted methods from embedded fields: if an anonymous field
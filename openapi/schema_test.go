@@ -2,6 +2,7 @@ package openapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
@@ -526,6 +527,25 @@ func TestGenerateOpenAPITags(t *testing.T) {
 		assert.True(t, schema.Properties["id"].ReadOnly)
 	})
 
+	t.Run("deprecatedMessage tag", func(t *testing.T) {
+		type Contact struct {
+			Email        string `json:"email" openapi:"deprecatedMessage=use contact_email instead"`
+			ContactEmail string `json:"contact_email"`
+		}
+		g := NewSchemaGenerator()
+		g.Generate(Contact{})
+		schema := g.Schemas()["Contact"]
+		require.NotNil(t, schema)
+		email := schema.Properties["email"]
+		assert.True(t, email.Deprecated)
+		assert.Equal(t, Extensions{DeprecatedMessageExtension: "use contact_email instead"}, email.Extensions)
+		assert.Nil(t, schema.Properties["contact_email"].Extensions)
+
+		data, err := json.Marshal(email)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"string","deprecated":true,"x-deprecated-message":"use contact_email instead"}`, string(data))
+	})
+
 	t.Run("writeOnly tag", func(t *testing.T) {
 		type Secret struct {
 			Password string `json:"password" openapi:"writeOnly"`
@@ -1138,6 +1158,194 @@ func TestGenerateInterface(t *testing.T) {
 	})
 }
 
+type testOpaqueJSON struct {
+	Inner string
+}
+
+func (o *testOpaqueJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Inner)
+}
+
+type providedMoney struct {
+	Units    int64
+	Currency string
+}
+
+func (m providedMoney) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%d.%02d %s", m.Units/100, m.Units%100, m.Currency))
+}
+
+func (providedMoney) OpenAPISchema() *Schema {
+	return &Schema{
+		Type:        SchemaTypeString,
+		Pattern:     `^\d+\.\d{2} [A-Z]{3}$`,
+		Description: "Amount and ISO 4217 currency code.",
+	}
+}
+
+func (providedMoney) OpenAPIExample() any {
+	return "ignored"
+}
+
+type providedCode string
+
+func (*providedCode) OpenAPISchema() *Schema {
+	return &Schema{Type: SchemaTypeInteger}
+}
+
+type providedFallback struct {
+	Name string `json:"name"`
+}
+
+func (providedFallback) OpenAPISchema() *Schema {
+	return nil
+}
+
+type providedAccount struct {
+	Balance providedMoney  `json:"balance"`
+	Limit   *providedMoney `json:"limit"`
+	Codes   []providedCode `json:"codes"`
+}
+
+func (providedAccount) OpenAPIName() string {
+	return "Account"
+}
+
+func (providedAccount) OpenAPISchema() *Schema {
+	return &Schema{
+		Type: SchemaTypeObject,
+		Properties: map[string]*Schema{
+			"balance": {Ref: "#/components/schemas/providedMoney"},
+		},
+	}
+}
+
+func TestSchemaProvider(t *testing.T) {
+	t.Run("controls component schema", func(t *testing.T) {
+		g := NewSchemaGenerator()
+		s := g.Generate(providedMoney{})
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/providedMoney"}, s)
+
+		schema := g.Schemas()["providedMoney"]
+		require.NotNil(t, schema)
+		assert.Equal(t, providedMoney{}.OpenAPISchema(), schema)
+		assert.Nil(t, schema.Example, "Exampler is not applied")
+	})
+
+	t.Run("deduplicated across uses", func(t *testing.T) {
+		type Invoice struct {
+			Total    providedMoney   `json:"total"`
+			Discount *providedMoney  `json:"discount"`
+			Lines    []providedMoney `json:"lines"`
+		}
+
+		g := NewSchemaGenerator()
+		g.Generate(Invoice{})
+		invoice := g.Schemas()["Invoice"]
+		require.NotNil(t, invoice)
+
+		assert.Equal(t, "#/components/schemas/providedMoney", invoice.Properties["total"].Ref)
+		require.Len(t, invoice.Properties["discount"].AnyOf, 2)
+		assert.Equal(t, "#/components/schemas/providedMoney", invoice.Properties["discount"].AnyOf[0].Ref)
+		assert.Equal(t, SchemaTypeNull, invoice.Properties["discount"].AnyOf[1].Type)
+		assert.Equal(t, "#/components/schemas/providedMoney", invoice.Properties["lines"].Items.Ref)
+		assert.Len(t, g.Schemas(), 2)
+	})
+
+	t.Run("non-struct type with pointer receiver", func(t *testing.T) {
+		g := NewSchemaGenerator()
+		s := g.Generate(providedCode(""))
+		assert.Equal(t, "#/components/schemas/providedCode", s.Ref)
+		assert.Equal(t, &Schema{Type: SchemaTypeInteger}, g.Schemas()["providedCode"])
+	})
+
+	t.Run("bypasses reflection and honors Namer", func(t *testing.T) {
+		g := NewSchemaGenerator()
+		s := g.Generate(&providedAccount{})
+		require.Len(t, s.AnyOf, 2)
+		assert.Equal(t, "#/components/schemas/Account", s.AnyOf[0].Ref)
+
+		account := g.Schemas()["Account"]
+		require.NotNil(t, account)
+		assert.Len(t, account.Properties, 1)
+		assert.Contains(t, account.Properties, "balance")
+		assert.Empty(t, account.Required)
+		assert.NotContains(t, g.Schemas(), "providedMoney", "fields are not reflected")
+	})
+
+	t.Run("stored schema is a copy", func(t *testing.T) {
+		provided := providedMoney{}.OpenAPISchema()
+		g := NewSchemaGenerator()
+		g.Generate(providedMoney{})
+		g.Schemas()["providedMoney"].Description = "changed"
+		assert.Equal(t, "Amount and ISO 4217 currency code.", provided.Description)
+	})
+
+	t.Run("nil falls back to reflection", func(t *testing.T) {
+		g := NewSchemaGenerator()
+		g.Generate(providedFallback{})
+		schema := g.Schemas()["providedFallback"]
+		require.NotNil(t, schema)
+		assert.Contains(t, schema.Properties, "name")
+	})
+
+	t.Run("inline with field tag", func(t *testing.T) {
+		g := NewSchemaGenerator()
+		g.fieldTag = "form"
+		s := g.Generate(&providedMoney{})
+		assert.Empty(t, s.Ref)
+		assert.Equal(t, TypeArray("string", "null"), s.Type)
+		assert.Empty(t, g.Schemas())
+	})
+}
+
+func TestGenerateJSONMarshaler(t *testing.T) {
+	type Payload struct {
+		Raw      json.RawMessage  `json:"raw"`
+		RawPtr   *json.RawMessage `json:"raw_ptr"`
+		Bytes    []byte           `json:"bytes"`
+		Opaque   testOpaqueJSON   `json:"opaque"`
+		RawSlice []json.RawMessage
+	}
+
+	g := NewSchemaGenerator()
+	g.Generate(Payload{})
+	schema := g.Schemas()["Payload"]
+	require.NotNil(t, schema)
+
+	t.Run("json.RawMessage is any", func(t *testing.T) {
+		assert.Equal(t, &Schema{}, schema.Properties["raw"])
+		assert.Equal(t, &Schema{}, schema.Properties["raw_ptr"])
+	})
+
+	t.Run("[]byte is byte string", func(t *testing.T) {
+		assert.Equal(t, &Schema{Type: SchemaTypeString, Format: FormatByte}, schema.Properties["bytes"])
+	})
+
+	t.Run("marshaler struct is any without component", func(t *testing.T) {
+		assert.Equal(t, &Schema{}, schema.Properties["opaque"])
+		assert.NotContains(t, g.Schemas(), "testOpaqueJSON")
+	})
+
+	t.Run("slice of raw messages", func(t *testing.T) {
+		prop := schema.Properties["RawSlice"]
+		require.NotNil(t, prop)
+		assert.Equal(t, SchemaTypeArray, prop.Type)
+		assert.Equal(t, &Schema{}, prop.Items)
+	})
+
+	t.Run("time.Time keeps date-time", func(t *testing.T) {
+		s := NewSchemaGenerator().Generate(time.Time{})
+		assert.Equal(t, FormatDateTime, s.Format)
+	})
+
+	t.Run("serializes as empty object", func(t *testing.T) {
+		data, err := json.Marshal(schema.Properties["raw"])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{}`, string(data))
+	})
+}
+
 func TestSchemaGeneratorJSON(t *testing.T) {
 	t.Run("generated schema serializes correctly", func(t *testing.T) {
 		g := NewSchemaGenerator()
@@ -1282,6 +1490,34 @@ func TestGenerateNewTagKeys(t *testing.T) {
 		require.NotNil(t, schema)
 		assert.Equal(t, int64(2), schema.Properties["version"].Const)
 	})
+
+	t.Run("default tag", func(t *testing.T) {
+		type WithDefaults struct {
+			Sort    string   `json:"sort" openapi:"default=created_at"`
+			Limit   int      `json:"limit" openapi:"default=20"`
+			Ratio   float64  `json:"ratio" openapi:"default=0.5"`
+			Archive bool     `json:"archive" openapi:"default=false"`
+			Page    *int     `json:"page,omitempty" openapi:"default=1"`
+			Name    string   `json:"name"`
+			Tags    []string `json:"tags" openapi:"default=none"`
+		}
+		g := NewSchemaGenerator()
+		g.Generate(WithDefaults{})
+		schema := g.Schemas()["WithDefaults"]
+		require.NotNil(t, schema)
+		assert.Equal(t, "created_at", schema.Properties["sort"].Default)
+		assert.Equal(t, int64(20), schema.Properties["limit"].Default)
+		assert.Equal(t, 0.5, schema.Properties["ratio"].Default)
+		assert.Equal(t, false, schema.Properties["archive"].Default)
+		assert.Equal(t, int64(1), schema.Properties["page"].Default)
+		assert.Nil(t, schema.Properties["name"].Default)
+		assert.Equal(t, "none", schema.Properties["tags"].Default)
+
+		data, err := json.Marshal(schema)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"archive":{"type":"boolean","default":false}`)
+		assert.Contains(t, string(data), `"name":{"type":"string"}`)
+	})
 }
 
 func TestJSONStringTagOverride(t *testing.T) {
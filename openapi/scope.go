@@ -0,0 +1,216 @@
+package openapi
+
+import (
+	"maps"
+	"strings"
+)
+
+// specScope restricts a derived Spec to the operations under a path prefix.
+type specScope struct {
+	parent *Spec
+	prefix string // normalized: leading slash, no trailing slash
+	strip  bool
+}
+
+// matches reports whether the OpenAPI path lies under the scope prefix.
+// Matching is done on whole segments, so "/api/v1" matches "/api/v1" and
+// "/api/v1/users" but not "/api/v10".
+func (sc *specScope) matches(path string) bool {
+	if sc.prefix == "" {
+		return true
+	}
+	return path == sc.prefix || strings.HasPrefix(path, sc.prefix+"/")
+}
+
+// Scope returns a derived Spec that documents only the operations whose
+// final path starts with pathPrefix, under its own Info. It is intended for
+// serving several API versions from one router:
+//
+//	v1 := spec.Scope("/api/v1", openapi.Info{Title: "API", Version: "1.0.0"})
+//	v2 := spec.Scope("/api/v2", openapi.Info{Title: "API", Version: "2.0.0"})
+//	v1.Handle(r, "/swagger/v1", nil)
+//	v2.Handle(r, "/swagger/v2", nil)
+//
+// The derived spec reads operations, webhooks, path metadata, components,
+// and type descriptions from the parent at Build time, so routes described
+// on the parent before or after Scope is called are included. Schemas are
+// generated with the same rules and descriptions as the parent, so a type
+// shared by several versions gets the same component name and definition
+// in each document; each document contains only the schemas its operations
+// reference.
+//
+// Servers, tags, security, and external docs are inherited from the parent
+// unless set on the derived spec. Operations, components, webhooks, and
+// global response headers registered on the derived spec are added to the
+// inherited ones and apply to that document only.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#openapi-object
+func (s *Spec) Scope(pathPrefix string, info Info) *Spec {
+	child := NewSpec(info)
+	child.scope = &specScope{
+		parent: s,
+		prefix: normalizeScopePrefix(pathPrefix),
+	}
+	return child
+}
+
+// StripScopePrefix removes the scope prefix from every path in documents
+// built by a Spec returned from Scope, and appends it to each server URL so
+// the paths still resolve to the same endpoints. When no servers are
+// configured, a single server with the prefix as a relative URL is added.
+// It has no effect on a Spec that is not scoped.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#paths-object
+// See: https://spec.openapis.org/oas/v3.1.0#server-object
+func (s *Spec) StripScopePrefix() *Spec {
+	if s.scope != nil {
+		s.scope.strip = true
+	}
+	return s
+}
+
+// normalizeScopePrefix ensures a leading slash and removes trailing slashes.
+func normalizeScopePrefix(prefix string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+// resolve returns the Spec that Build assembles. For a scoped spec this is
+// a copy of the spec with unset fields inherited from its parent chain and
+// registries merged with the parent's; an unscoped spec is returned as is.
+func (s *Spec) resolve() *Spec {
+	if s.scope == nil {
+		return s
+	}
+	p := s.scope.parent.resolve()
+
+	out := *s
+	out.scope = s.scope
+
+	if len(out.servers) == 0 {
+		out.servers = p.servers
+	}
+	if len(out.tags) == 0 {
+		out.tags = p.tags
+	}
+	if len(out.tagGroups) == 0 {
+		out.tagGroups = p.tagGroups
+	}
+	if len(out.tagOrder) == 0 {
+		out.tagOrder = p.tagOrder
+	}
+	if !out.securitySet {
+		out.security = p.security
+		out.securitySet = p.securitySet
+	}
+	if !out.autoOperationIDsSet {
+		out.autoOperationIDs = p.autoOperationIDs
+		out.autoOperationIDsSet = p.autoOperationIDsSet
+	}
+	if !out.deriveServersSet {
+		out.deriveServers = p.deriveServers
+		out.deriveServersSet = p.deriveServersSet
+	}
+	if !out.splitReadWriteSet {
+		out.splitReadWrite = p.splitReadWrite
+		out.splitReadWriteSet = p.splitReadWriteSet
+	}
+	if !out.operationSecuritySet {
+		out.operationSecurity = p.operationSecurity
+		out.operationSecuritySet = p.operationSecuritySet
+	}
+	if !out.exampleValidationSet {
+		out.exampleValidation = p.exampleValidation
+		out.exampleValidationSet = p.exampleValidationSet
+	}
+	if !out.targetVersionSet {
+		out.targetVersion = p.targetVersion
+		out.targetVersionSet = p.targetVersionSet
+	}
+	if !out.responseEnvelopeSet {
+		out.responseEnvelope = p.responseEnvelope
+		out.responseEnvelopeSet = p.responseEnvelopeSet
+	}
+	if !out.autoTags {
+		out.autoTags = p.autoTags
+		out.autoTagRules = p.autoTagRules
+	}
+	if out.externalDocs == nil {
+		out.externalDocs = p.externalDocs
+	}
+
+	out.operations = inheritMap(p.operations, s.operations)
+	out.routeOps = inheritMap(p.routeOps, s.routeOps)
+	out.webhooks = inheritMap(p.webhooks, s.webhooks)
+	out.pathServers = inheritMap(p.pathServers, s.pathServers)
+	out.pathSummaries = inheritMap(p.pathSummaries, s.pathSummaries)
+	out.pathDescriptions = inheritMap(p.pathDescriptions, s.pathDescriptions)
+	out.pathParameters = inheritMap(p.pathParameters, s.pathParameters)
+	out.securitySchemes = inheritMap(p.securitySchemes, s.securitySchemes)
+	out.compResponses = inheritMap(p.compResponses, s.compResponses)
+	out.compParameters = inheritMap(p.compParameters, s.compParameters)
+	out.compExamples = inheritMap(p.compExamples, s.compExamples)
+	out.compReqBodies = inheritMap(p.compReqBodies, s.compReqBodies)
+	out.compHeaders = inheritMap(p.compHeaders, s.compHeaders)
+	out.compLinks = inheritMap(p.compLinks, s.compLinks)
+	out.compCallbacks = inheritMap(p.compCallbacks, s.compCallbacks)
+	out.compPathItems = inheritMap(p.compPathItems, s.compPathItems)
+	out.generatedDocs = inheritMap(p.generatedDocs, s.generatedDocs)
+	out.globalResponseHeaders = inheritMap(p.globalResponseHeaders, s.globalResponseHeaders)
+
+	out.docs = typeDocs{
+		types:  inheritMap(p.docs.types, s.docs.types),
+		fields: inheritMap(p.docs.fields, s.docs.fields),
+		enums:  inheritMap(p.docs.enums, s.docs.enums),
+	}
+
+	return &out
+}
+
+// inheritMap returns the union of parent and child, with child entries
+// taking precedence. Either map is returned unchanged when the other is
+// empty, so no copy is made in the common case.
+func inheritMap[M ~map[K]V, K comparable, V any](parent, child M) M {
+	if len(child) == 0 {
+		return parent
+	}
+	if len(parent) == 0 {
+		return child
+	}
+	out := make(M, len(parent)+len(child))
+	maps.Copy(out, parent)
+	maps.Copy(out, child)
+	return out
+}
+
+// stripScope removes the scope prefix from the document paths and moves it
+// into the server URLs.
+func (sc *specScope) stripScope(doc *Document) {
+	if sc.prefix == "" {
+		return
+	}
+
+	paths := make(map[string]*PathItem, len(doc.Paths))
+	for path, item := range doc.Paths {
+		stripped := strings.TrimPrefix(path, sc.prefix)
+		if stripped == "" {
+			stripped = "/"
+		}
+		paths[stripped] = item
+	}
+	doc.Paths = paths
+
+	if len(doc.Servers) == 0 {
+		doc.Servers = []Server{{URL: sc.prefix}}
+		return
+	}
+	servers := make([]Server, len(doc.Servers))
+	for i, srv := range doc.Servers {
+		srv.URL = strings.TrimRight(srv.URL, "/") + sc.prefix
+		servers[i] = srv
+	}
+	doc.Servers = servers
+}
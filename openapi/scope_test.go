@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type scopeUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type scopeUserV2 struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+func newVersionedRouter(spec *Spec) *mux.Router {
+	r := mux.NewRouter()
+	spec.Route(r.HandleFunc("/api/v1/users", dummyHandler).Methods(http.MethodGet)).
+		Tags("users").
+		Response(http.StatusOK, []scopeUser{})
+	spec.Route(r.HandleFunc("/api/v2/users", dummyHandler).Methods(http.MethodGet)).
+		Tags("users").
+		Response(http.StatusOK, []scopeUserV2{})
+	spec.Route(r.HandleFunc("/api/v2/users/{id}", dummyHandler).Methods(http.MethodGet)).
+		Tags("users").
+		Response(http.StatusOK, scopeUser{})
+	spec.Route(r.HandleFunc("/api/v10/status", dummyHandler).Methods(http.MethodGet))
+	return r
+}
+
+func TestSpecScope(t *testing.T) {
+	t.Run("filters paths by prefix", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		r := newVersionedRouter(spec)
+
+		v1 := spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"})
+		v2 := spec.Scope("/api/v2/", Info{Title: "API", Version: "2.0.0"})
+
+		doc1 := v1.Build(r)
+		assert.Equal(t, "1.0.0", doc1.Info.Version)
+		assert.Len(t, doc1.Paths, 1)
+		assert.Contains(t, doc1.Paths, "/api/v1/users")
+
+		doc2 := v2.Build(r)
+		assert.Equal(t, "2.0.0", doc2.Info.Version)
+		assert.Len(t, doc2.Paths, 2)
+		assert.Contains(t, doc2.Paths, "/api/v2/users")
+		assert.Contains(t, doc2.Paths, "/api/v2/users/{id}")
+	})
+
+	t.Run("parent document is unchanged", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		r := newVersionedRouter(spec)
+		spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"})
+
+		doc := spec.Build(r)
+		assert.Len(t, doc.Paths, 4)
+		assert.Equal(t, "0.0.0", doc.Info.Version)
+	})
+
+	t.Run("schemas limited to scope and consistent", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		r := newVersionedRouter(spec)
+
+		doc1 := spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"}).Build(r)
+		doc2 := spec.Scope("/api/v2", Info{Title: "API", Version: "2.0.0"}).Build(r)
+
+		require.NotNil(t, doc1.Components)
+		require.NotNil(t, doc2.Components)
+		assert.Len(t, doc1.Components.Schemas, 1)
+		assert.Len(t, doc2.Components.Schemas, 2)
+
+		for name, schema := range doc1.Components.Schemas {
+			other, ok := doc2.Components.Schemas[name]
+			require.True(t, ok, "shared schema %s missing from v2", name)
+			assert.Equal(t, schema, other)
+		}
+	})
+
+	t.Run("routes described after Scope are included", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		v1 := spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"})
+
+		r := mux.NewRouter()
+		spec.Route(r.HandleFunc("/api/v1/late", dummyHandler).Methods(http.MethodGet))
+
+		assert.Contains(t, v1.Build(r).Paths, "/api/v1/late")
+	})
+
+	t.Run("inherits tags security and servers", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"}).
+			AddServer(Server{URL: "https://api.example.com"}).
+			AddTag(Tag{Name: "users", Description: "User operations"}).
+			AddSecurityScheme("bearer", &SecurityScheme{Type: "http", Scheme: "bearer"}).
+			SetSecurity(SecurityRequirement{"bearer": {}}).
+			SetExternalDocs("https://docs.example.com", "Docs")
+		r := newVersionedRouter(spec)
+
+		doc := spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"}).Build(r)
+		assert.Equal(t, []Server{{URL: "https://api.example.com"}}, doc.Servers)
+		assert.Equal(t, []SecurityRequirement{{"bearer": {}}}, doc.Security)
+		require.NotNil(t, doc.ExternalDocs)
+		assert.Equal(t, "https://docs.example.com", doc.ExternalDocs.URL)
+		require.Len(t, doc.Tags, 1)
+		assert.Equal(t, "User operations", doc.Tags[0].Description)
+		assert.Contains(t, doc.Components.SecuritySchemes, "bearer")
+	})
+
+	t.Run("overrides tags security and servers", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"}).
+			AddServer(Server{URL: "https://api.example.com"}).
+			AddTag(Tag{Name: "users", Description: "Parent"}).
+			SetSecurity(SecurityRequirement{"bearer": {}})
+		r := newVersionedRouter(spec)
+
+		v2 := spec.Scope("/api/v2", Info{Title: "API", Version: "2.0.0"}).
+			AddServer(Server{URL: "https://v2.example.com"}).
+			AddTag(Tag{Name: "users", Description: "Version 2"}).
+			SetSecurity()
+
+		doc := v2.Build(r)
+		assert.Equal(t, []Server{{URL: "https://v2.example.com"}}, doc.Servers)
+		assert.Empty(t, doc.Security)
+		require.Len(t, doc.Tags, 1)
+		assert.Equal(t, "Version 2", doc.Tags[0].Description)
+
+		assert.Equal(t, "Parent", spec.Build(r).Tags[0].Description)
+	})
+
+	t.Run("scoped registrations do not leak to parent", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		r := mux.NewRouter()
+		route := r.HandleFunc("/api/v1/only", dummyHandler).Methods(http.MethodGet)
+
+		v1 := spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"})
+		v1.Route(route).Summary("Scoped only")
+
+		doc := v1.Build(r)
+		require.Contains(t, doc.Paths, "/api/v1/only")
+		assert.Equal(t, "Scoped only", doc.Paths["/api/v1/only"].Get.Summary)
+		assert.Empty(t, spec.Build(r).Paths)
+	})
+
+	t.Run("strip prefix", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		r := newVersionedRouter(spec)
+		spec.SetPathSummary("/api/v2/users", "Users")
+
+		doc := spec.Scope("/api/v2", Info{Title: "API", Version: "2.0.0"}).
+			StripScopePrefix().
+			Build(r)
+
+		assert.Contains(t, doc.Paths, "/users")
+		assert.Contains(t, doc.Paths, "/users/{id}")
+		assert.Equal(t, "Users", doc.Paths["/users"].Summary)
+		assert.Equal(t, []Server{{URL: "/api/v2"}}, doc.Servers)
+	})
+
+	t.Run("strip prefix appends to servers", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"}).
+			AddServer(Server{URL: "https://api.example.com/", Description: "Production"})
+		r := newVersionedRouter(spec)
+
+		doc := spec.Scope("api/v1", Info{Title: "API", Version: "1.0.0"}).
+			StripScopePrefix().
+			Build(r)
+
+		assert.Contains(t, doc.Paths, "/users")
+		assert.Equal(t, []Server{{URL: "https://api.example.com/api/v1", Description: "Production"}}, doc.Servers)
+		assert.Equal(t, "https://api.example.com/", spec.servers[0].URL)
+	})
+
+	t.Run("strip prefix on unscoped spec is a no-op", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"}).StripScopePrefix()
+		r := newVersionedRouter(spec)
+		assert.Contains(t, spec.Build(r).Paths, "/api/v1/users")
+	})
+
+	t.Run("handle serves separate documents", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "API", Version: "0.0.0"})
+		r := newVersionedRouter(spec)
+
+		spec.Scope("/api/v1", Info{Title: "API", Version: "1.0.0"}).Handle(r, "/swagger/v1", nil)
+		spec.Scope("/api/v2", Info{Title: "API", Version: "2.0.0"}).Handle(r, "/swagger/v2", nil)
+
+		for _, tc := range []struct {
+			path    string
+			version string
+			paths   int
+		}{
+			{"/swagger/v1/schema.json", "1.0.0", 1},
+			{"/swagger/v2/schema.json", "2.0.0", 2},
+		} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var doc Document
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+			assert.Equal(t, tc.version, doc.Info.Version)
+			assert.Len(t, doc.Paths, tc.paths)
+		}
+	})
+}
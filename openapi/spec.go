@@ -1,11 +1,16 @@
 package openapi
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/vitalvas/kasper/mux"
 )
@@ -45,7 +50,10 @@ type Spec struct {
 
 	externalDocs    *ExternalDocs
 	security        []SecurityRequirement
+	securitySet     bool // distinguishes unset (inherit in Scope) from empty
 	tags            []Tag
+	tagGroups       []TagGroup
+	tagOrder        []string
 	securitySchemes map[string]*SecurityScheme
 	compResponses   map[string]*Response
 	compParameters  map[string]*Parameter
@@ -55,6 +63,37 @@ type Spec struct {
 	compLinks       map[string]*Link
 	compCallbacks   map[string]*Callback
 	compPathItems   map[string]*PathItem
+
+	autoOperationIDs    bool
+	autoOperationIDsSet bool // distinguishes unset (inherit in Scope) from false
+
+	deriveServers    bool
+	deriveServersSet bool // distinguishes unset (inherit in Scope) from false
+
+	autoTags     bool
+	autoTagRules map[string]string // path prefix (normalized) -> tag; nil = first path segment
+
+	globalResponseHeaders map[string]*Header // added to every route operation response
+
+	responseEnvelope    any  // template wrapping 2xx JSON route responses
+	responseEnvelopeSet bool // distinguishes unset (inherit in Scope) from nil
+
+	splitReadWrite    bool
+	splitReadWriteSet bool // distinguishes unset (inherit in Scope) from false
+
+	operationSecurity    bool
+	operationSecuritySet bool // distinguishes unset (inherit in Scope) from false
+
+	exampleValidation    ExampleValidation
+	exampleValidationSet bool // distinguishes unset (inherit in Scope) from off
+
+	targetVersion    string
+	targetVersionSet bool // distinguishes unset (inherit in Scope) from the default
+
+	generatedDocs map[string]OperationDoc // keyed by operationId or package-qualified handler symbol
+	docs          typeDocs                // registered via DescribeType, DescribeField, and RegisterEnum
+
+	scope *specScope // set on specs returned by Scope
 }
 
 // NewSpec creates a new spec builder with the given API info.
@@ -141,14 +180,166 @@ func (s *Spec) SetExternalDocs(url, description string) *Spec {
 }
 
 // SetSecurity sets the document-level security requirements.
+// Operations without their own security inherit them; use
+// ApplySecurityToOperations to repeat them on each operation.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-object (security)
 // See: https://spec.openapis.org/oas/v3.1.0#security-requirement-object
 func (s *Spec) SetSecurity(reqs ...SecurityRequirement) *Spec {
 	s.security = reqs
+	s.securitySet = true
+	return s
+}
+
+// ApplySecurityToOperations copies the document-level security
+// requirements set with SetSecurity onto every route operation that does
+// not set its own, for tools that ignore the document-level default.
+// Operations marked public with an empty Security() keep their explicit
+// empty list, and operations with their own or a group's security keep
+// theirs. The document-level requirements are still emitted.
+//
+//	spec.SetSecurity(openapi.SecurityRequirement{"bearerAuth": {}}).
+//	    ApplySecurityToOperations(true)
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object (security)
+func (s *Spec) ApplySecurityToOperations(enabled bool) *Spec {
+	s.operationSecurity = enabled
+	s.operationSecuritySet = true
+	return s
+}
+
+// applyOperationSecurity copies the document-level security requirements
+// onto the route operations of doc that have none.
+func applyOperationSecurity(doc *Document) {
+	if len(doc.Security) == 0 {
+		return
+	}
+	for _, item := range doc.Paths {
+		for _, op := range item.operations() {
+			if op.Security == nil {
+				op.Security = slices.Clone(doc.Security)
+			}
+		}
+	}
+}
+
+// AutoOperationIDs enables deriving an operationId for route operations
+// that have neither a mux route name nor an explicit OperationID. The ID is
+// built from the method and the OpenAPI path, e.g. "getUsersId" for
+// "GET /users/{id}". Derived IDs never reuse an explicit operationId in the
+// document; collisions between derived IDs are resolved by appending a
+// number ("getUsersId2") in route registration order.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object (operationId)
+func (s *Spec) AutoOperationIDs(enabled bool) *Spec {
+	s.autoOperationIDs = enabled
+	s.autoOperationIDsSet = true
+	return s
+}
+
+// DeriveServersFromRoutes enables operation-level servers derived from
+// route host constraints. An operation on a route registered with
+// Host("api.example.com").Schemes("https") gets a server with the URL
+// "https://api.example.com"; without Schemes, one server per scheme is
+// listed ("https" and "http"). Host variables such as "{tenant}" become
+// server variables: a pattern of literal alternatives ("{region:eu|us}")
+// becomes an enum whose first value is the default, and any other variable
+// defaults to its own name.
+//
+// Derived servers replace document and path servers for the operation;
+// servers set with OperationBuilder.Server take precedence over them.
+// Routes without a host constraint are not affected.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object (servers)
+func (s *Spec) DeriveServersFromRoutes(enabled bool) *Spec {
+	s.deriveServers = enabled
+	s.deriveServersSet = true
+	return s
+}
+
+// AutoTagByPrefix tags route operations that have no tags of their own by
+// the path they are served under. Each key of rules is a path prefix,
+// matched on whole segments against the final OpenAPI path, and its value
+// is the tag; when several prefixes match, the longest wins, and paths
+// under no prefix stay untagged:
+//
+//	spec.AutoTagByPrefix(map[string]string{
+//	    "/users":       "users",
+//	    "/orders":      "orders",
+//	    "/admin/users": "admin",
+//	})
+//
+// With nil rules, the tag is the first segment of the path, so "/users/{id}"
+// is tagged "users"; paths whose first segment is a variable stay untagged.
+// Tags set on the operation or its group take precedence. Auto-tags are
+// collected into the document tags list like any other tag.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object (tags)
+func (s *Spec) AutoTagByPrefix(rules map[string]string) *Spec {
+	s.autoTags = true
+	s.autoTagRules = nil
+	if rules != nil {
+		s.autoTagRules = make(map[string]string, len(rules))
+		for prefix, tag := range rules {
+			s.autoTagRules[normalizeScopePrefix(prefix)] = tag
+		}
+	}
+	return s
+}
+
+// autoTag returns the tag AutoTagByPrefix assigns to an operation on the
+// given OpenAPI path, or "" when none applies.
+func (s *Spec) autoTag(path string) string {
+	if s.autoTagRules == nil {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if strings.HasPrefix(segment, "{") {
+			return ""
+		}
+		return segment
+	}
+
+	tag := ""
+	longest := -1
+	for prefix, t := range s.autoTagRules {
+		if len(prefix) > longest && (prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")) {
+			tag, longest = t, len(prefix)
+		}
+	}
+	return tag
+}
+
+// GlobalResponseHeader documents a header sent with every response of
+// every route operation, such as X-Request-ID or RateLimit-Limit. A header
+// with the same name set on the operation or its group, for example with
+// ResponseHeader or RouteGroup.DefaultResponseHeader, takes precedence.
+// Webhook operations are not affected, since their responses come from the
+// receiver:
+//
+//	spec.GlobalResponseHeader("X-Request-ID", &openapi.Header{
+//	    Description: "Request identifier for tracing",
+//	    Schema:      &openapi.Schema{Type: openapi.SchemaTypeString},
+//	})
+//
+// See: https://spec.openapis.org/oas/v3.1.0#response-object (headers)
+func (s *Spec) GlobalResponseHeader(name string, h *Header) *Spec {
+	if s.globalResponseHeaders == nil {
+		s.globalResponseHeaders = make(map[string]*Header)
+	}
+	s.globalResponseHeaders[name] = h
 	return s
 }
 
+// applyGlobalResponseHeaders adds the global response headers to every
+// response of op that does not document a header of the same name.
+func (s *Spec) applyGlobalResponseHeaders(op *Operation) {
+	if len(s.globalResponseHeaders) == 0 {
+		return
+	}
+	for _, resp := range op.Responses {
+		resp.Headers = mergeHeaderDefaults(resp.Headers, s.globalResponseHeaders)
+	}
+}
+
 // AddTag adds a user-defined tag with optional description and external docs.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#tag-object
@@ -157,6 +348,27 @@ func (s *Spec) AddTag(tag Tag) *Spec {
 	return s
 }
 
+// AddTagGroup adds a named group of tags, emitted in the document's
+// "x-tagGroups" extension in the order the groups are added. Use
+// Document.Warnings to detect groups that reference tags missing from the
+// built document.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#specification-extensions
+func (s *Spec) AddTagGroup(name string, tags ...string) *Spec {
+	s.tagGroups = append(s.tagGroups, TagGroup{Name: name, Tags: tags})
+	return s
+}
+
+// SetTagOrder sets the order of the document's tags list. Listed tags come
+// first in the given order; tags that are not listed follow alphabetically.
+// Without an order, all tags are sorted alphabetically.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#openapi-object (tags)
+func (s *Spec) SetTagOrder(tags ...string) *Spec {
+	s.tagOrder = tags
+	return s
+}
+
 // AddSecurityScheme registers a reusable security scheme in components.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#security-scheme-object
@@ -168,6 +380,21 @@ func (s *Spec) AddSecurityScheme(name string, scheme *SecurityScheme) *Spec {
 	return s
 }
 
+// AddCookieAuth registers an apiKey security scheme that reads the
+// credential from the named cookie, as used for browser sessions:
+//
+//	spec.AddCookieAuth("sessionAuth", "session").
+//	    SetSecurity(openapi.SecurityRequirement{"sessionAuth": {}})
+//
+// See: https://spec.openapis.org/oas/v3.1.0#security-scheme-object
+func (s *Spec) AddCookieAuth(name, cookieName string) *Spec {
+	return s.AddSecurityScheme(name, &SecurityScheme{
+		Type: SecurityTypeAPIKey,
+		In:   SecurityInCookie,
+		Name: cookieName,
+	})
+}
+
 // AddComponentResponse registers a reusable response in components.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#components-object (responses)
@@ -307,11 +534,53 @@ func (s *Spec) Route(route *mux.Route) *OperationBuilder {
 	return b
 }
 
+// ErrInvalidRoute is wrapped by the errors BuildE returns for routes that
+// carry OpenAPI metadata but failed mux registration.
+var ErrInvalidRoute = errors.New("openapi: invalid route")
+
 // Build walks the router and assembles a complete OpenAPI Document.
+// Routes with a mux registration error are left out; use BuildE to find
+// out which documented routes were dropped.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-object
 func (s *Spec) Build(r *mux.Router) *Document {
+	doc, _ := s.BuildE(r)
+	return doc
+}
+
+// BuildE is like Build but also reports routes that have OpenAPI metadata
+// attached (via Route, Op, or a group) and a mux registration error, such
+// as an invalid regexp or an odd number of Queries pairs. Such routes
+// never match requests and are left out of the document. Each error wraps
+// ErrInvalidRoute and the route error; they are combined with
+// errors.Join. Routes without metadata are skipped silently. The document
+// is returned even when the error is non-nil.
+//
+//	doc, err := spec.BuildE(r)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// See: https://spec.openapis.org/oas/v3.1.0#openapi-object
+func (s *Spec) BuildE(r *mux.Router) (*Document, error) {
+	s = s.resolve()
+	doc, routeErrs := s.build(r)
+
+	doc, err := s.applyTargetVersion(doc)
+	if err != nil {
+		routeErrs = append(routeErrs, err)
+	}
+
+	return doc, errors.Join(routeErrs...)
+}
+
+// build assembles the OpenAPI 3.1 document of a resolved spec and returns
+// it with the errors BuildE reports.
+func (s *Spec) build(r *mux.Router) (*Document, []error) {
+	var routeErrs []error
 	gen := NewSchemaGenerator()
+	gen.docs = &s.docs
+	var unnamed []unnamedOperation
 	doc := &Document{
 		OpenAPI:      OpenAPIVersion,
 		Info:         s.info,
@@ -321,23 +590,13 @@ func (s *Spec) Build(r *mux.Router) *Document {
 		Security:     s.security,
 	}
 
-	_ = r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+	_ = r.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
 		// Skip build-only routes: they are used only for URL building
 		// and should not appear in the generated spec.
 		if route.IsBuildOnly() {
 			return nil
 		}
 
-		pathTpl, err := route.GetPathTemplate()
-		if err != nil {
-			return nil
-		}
-
-		methods, err := route.GetMethods()
-		if err != nil {
-			return nil
-		}
-
 		// Look up builder: first by route pointer, then by route name.
 		builder, hasOp := s.routeOps[route]
 		if !hasOp {
@@ -348,8 +607,26 @@ func (s *Spec) Build(r *mux.Router) *Document {
 			}
 		}
 
+		if err := route.GetError(); err != nil {
+			routeErrs = append(routeErrs, fmt.Errorf("%w %s: %w", ErrInvalidRoute, routeLabel(route), err))
+			return nil
+		}
+
+		pathTpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
 		// Parse path variables and convert to OpenAPI path.
 		openAPIPath, pathParams := parsePath(pathTpl)
+		if s.scope != nil && !s.scope.matches(openAPIPath) {
+			return nil
+		}
 
 		// Auto-generate header parameters from route header matchers.
 		if headers, err := route.GetHeaders(); err == nil {
@@ -376,6 +653,10 @@ func (s *Spec) Build(r *mux.Router) *Document {
 
 		// Collect route-level schemes for auto-generating operation servers.
 		schemes, _ := route.GetSchemes()
+		var hostServers []Server
+		if s.deriveServers {
+			hostServers = routeHostServers(route, ancestors)
+		}
 
 		// Build one operation per method. When a route registers multiple
 		// methods, each gets a distinct operationId to satisfy the OpenAPI
@@ -389,14 +670,31 @@ func (s *Spec) Build(r *mux.Router) *Document {
 				opID = fmt.Sprintf("%s%s%s", opID, strings.ToUpper(method[:1]), strings.ToLower(method[1:]))
 			}
 			op := builder.buildOperation(gen, opID, pathParams)
+			s.applyResponseEnvelope(gen, builder, op)
+			s.applyGlobalResponseHeaders(op)
+			applyRouteDoc(op, route)
+			s.applyGeneratedDoc(op, route)
+			if s.autoTags && len(op.Tags) == 0 {
+				if tag := s.autoTag(openAPIPath); tag != "" {
+					op.Tags = []string{tag}
+				}
+			}
+			if s.autoOperationIDs && op.OperationID == "" {
+				unnamed = append(unnamed, unnamedOperation{op: op, method: method, path: openAPIPath})
+			}
 
-			// Auto-populate operation servers from route scheme constraints
-			// only when no servers are configured at any level (operation,
-			// path, or document). Operation-level servers in OpenAPI override
-			// path and document servers, so adding incomplete scheme-only
-			// URLs would discard configured base URLs.
-			if len(schemes) > 0 && len(builder.meta.servers) == 0 &&
-				len(s.pathServers[openAPIPath]) == 0 && len(s.servers) == 0 {
+			// Derived host servers are complete URLs, so they apply whenever
+			// the operation has no explicit servers. Otherwise auto-populate
+			// operation servers from route scheme constraints only when no
+			// servers are configured at any level (operation, path, or
+			// document). Operation-level servers in OpenAPI override path
+			// and document servers, so adding incomplete scheme-only URLs
+			// would discard configured base URLs.
+			switch {
+			case len(builder.meta.servers) > 0:
+			case len(hostServers) > 0:
+				op.Servers = append(op.Servers, hostServers...)
+			case len(schemes) > 0 && len(s.pathServers[openAPIPath]) == 0 && len(s.servers) == 0:
 				for _, scheme := range schemes {
 					op.Servers = append(op.Servers, Server{URL: fmt.Sprintf("%s://", scheme)})
 				}
@@ -421,6 +719,10 @@ func (s *Spec) Build(r *mux.Router) *Document {
 		}
 	}
 
+	if len(unnamed) > 0 {
+		assignDerivedOperationIDs(doc, unnamed)
+	}
+
 	// Apply path-level metadata.
 	for path, summary := range s.pathSummaries {
 		if pathItem, ok := doc.Paths[path]; ok {
@@ -443,13 +745,88 @@ func (s *Spec) Build(r *mux.Router) *Document {
 		}
 	}
 
+	if s.operationSecurity {
+		applyOperationSecurity(doc)
+	}
+
 	// Build components.
 	doc.Components = s.buildComponents(gen)
+	if s.splitReadWrite {
+		splitReadWriteSchemas(doc, gen)
+	}
 
 	// Merge tags: user-defined tags take precedence over auto-collected.
 	doc.Tags = s.mergeTags(doc.Paths, doc.Webhooks)
+	doc.TagGroups = s.tagGroups
 
-	return doc
+	if s.scope != nil && s.scope.strip {
+		s.scope.stripScope(doc)
+	}
+
+	routeErrs = append(routeErrs, s.applyExampleValidation(doc)...)
+
+	return doc, routeErrs
+}
+
+// routeLabel identifies a route in BuildE errors by its name, falling back
+// to its path template.
+func routeLabel(route *mux.Route) string {
+	if name := route.GetName(); name != "" {
+		return fmt.Sprintf("%q", name)
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		return fmt.Sprintf("%q", tpl)
+	}
+	return "(unnamed)"
+}
+
+// unnamedOperation is a route operation without an operationId, recorded
+// for AutoOperationIDs.
+type unnamedOperation struct {
+	op     *Operation
+	method string
+	path   string
+}
+
+// assignDerivedOperationIDs sets a derived operationId on each unnamed
+// operation, skipping IDs already used in the document.
+func assignDerivedOperationIDs(doc *Document, unnamed []unnamedOperation) {
+	used := make(map[string]bool)
+	for _, paths := range []map[string]*PathItem{doc.Paths, doc.Webhooks} {
+		for _, pathItem := range paths {
+			for _, op := range pathItem.operations() {
+				if op.OperationID != "" {
+					used[op.OperationID] = true
+				}
+			}
+		}
+	}
+
+	for _, u := range unnamed {
+		base := deriveOperationID(u.method, u.path)
+		id := base
+		for n := 2; used[id]; n++ {
+			id = base + strconv.Itoa(n)
+		}
+		used[id] = true
+		u.op.OperationID = id
+	}
+}
+
+// deriveOperationID builds a camelCase operationId from a method and an
+// OpenAPI path: the lowercase method followed by every alphanumeric word of
+// the path with its first letter upper-cased.
+func deriveOperationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for word := range strings.FieldsFuncSeq(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+	return b.String()
 }
 
 // buildComponents assembles the Components Object from generated schemas
@@ -512,7 +889,8 @@ func (s *Spec) buildComponents(gen *SchemaGenerator) *Components {
 // mergeTags combines auto-collected tags from operations with user-defined tags.
 // User-defined tags take precedence (their description and externalDocs are kept).
 // Tags not seen in operations but defined by the user are still included.
-// The result is sorted alphabetically.
+// The result is sorted by the tag order set with SetTagOrder, with unlisted
+// tags sorted alphabetically after the listed ones.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#openapi-object (tags)
 // See: https://spec.openapis.org/oas/v3.1.0#tag-object
@@ -529,14 +907,7 @@ func (s *Spec) mergeTags(pathMaps ...map[string]*PathItem) []Tag {
 
 	for _, paths := range pathMaps {
 		for _, pathItem := range paths {
-			for _, op := range []*Operation{
-				pathItem.Get, pathItem.Post, pathItem.Put,
-				pathItem.Delete, pathItem.Patch, pathItem.Head,
-				pathItem.Options, pathItem.Trace,
-			} {
-				if op == nil {
-					continue
-				}
+			for _, op := range pathItem.operations() {
 				for _, tagName := range op.Tags {
 					if seen[tagName] {
 						continue
@@ -560,11 +931,61 @@ func (s *Spec) mergeTags(pathMaps ...map[string]*PathItem) []Tag {
 		}
 	}
 
+	sortTags(tags, s.tagOrder)
+
+	return tags
+}
+
+// sortTags sorts tags by their position in order. Tags missing from order
+// are placed after the listed ones, sorted alphabetically.
+func sortTags(tags []Tag, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+
 	sort.Slice(tags, func(i, j int) bool {
-		return tags[i].Name < tags[j].Name
+		ri, iListed := rank[tags[i].Name]
+		rj, jListed := rank[tags[j].Name]
+		switch {
+		case iListed && jListed:
+			return ri < rj
+		case iListed != jListed:
+			return iListed
+		default:
+			return tags[i].Name < tags[j].Name
+		}
 	})
+}
 
-	return tags
+// operations returns the non-nil operations of the path item in a fixed
+// method order.
+func (p *PathItem) operations() []*Operation {
+	ops := make([]*Operation, 0, 8)
+	for _, op := range []*Operation{
+		p.Get, p.Post, p.Put, p.Delete, p.Patch, p.Head, p.Options, p.Trace,
+	} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// applyRouteDoc fills an empty summary or description of op from the
+// route's Doc, so builder calls override the route definition.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#operation-object (summary, description)
+func applyRouteDoc(op *Operation, route *mux.Route) {
+	summary, description := route.GetDoc()
+	if op.Summary == "" {
+		op.Summary = summary
+	}
+	if op.Description == "" {
+		op.Description = description
+	}
 }
 
 // assignOperation assigns an operation to the correct HTTP method field
@@ -592,6 +1013,58 @@ func assignOperation(pathItem *PathItem, method string, op *Operation) {
 	}
 }
 
+// hostEnumRegexp matches a host variable pattern made only of literal
+// alternatives, such as "eu|us", which is documented as a server enum.
+var hostEnumRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+(\|[A-Za-z0-9_-]+)*$`)
+
+// routeHostServers returns the servers described by the host template and
+// schemes of the route, or of the nearest subrouter route that sets them,
+// or nil when there is no host constraint.
+func routeHostServers(route *mux.Route, ancestors []*mux.Route) []Server {
+	var hostTpl string
+	var schemes []string
+	for i := len(ancestors); i >= 0; i-- {
+		r := route
+		if i < len(ancestors) {
+			r = ancestors[i]
+		}
+		if hostTpl == "" {
+			hostTpl, _ = r.GetHostTemplate()
+		}
+		if len(schemes) == 0 {
+			schemes, _ = r.GetSchemes()
+		}
+	}
+	if hostTpl == "" {
+		return nil
+	}
+
+	var vars map[string]*ServerVariable
+	host := pathVarRegexp.ReplaceAllStringFunc(hostTpl, func(match string) string {
+		inner := match[1 : len(match)-1]
+		name, pattern, _ := strings.Cut(inner, ":")
+		v := &ServerVariable{Default: name}
+		if hostEnumRegexp.MatchString(pattern) {
+			v.Enum = strings.Split(pattern, "|")
+			v.Default = v.Enum[0]
+		}
+		if vars == nil {
+			vars = make(map[string]*ServerVariable)
+		}
+		vars[name] = v
+		return "{" + name + "}"
+	})
+
+	if len(schemes) == 0 {
+		schemes = []string{"https", "http"}
+	}
+	servers := make([]Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, Server{URL: scheme + "://" + host, Variables: vars})
+	}
+	return servers
+}
+
 // parsePath extracts variables from a mux path template, converts it to
 // OpenAPI format, and generates parameter objects.
 //
@@ -379,6 +379,63 @@ func TestBuildRouteWithoutOp(t *testing.T) {
 	})
 }
 
+func TestBuildE(t *testing.T) {
+	t.Run("valid routes return no error", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Name("listUsers")
+
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Op("listUsers").Summary("List users")
+
+		doc, err := spec.BuildE(r)
+		require.NoError(t, err)
+		assert.Contains(t, doc.Paths, "/users")
+	})
+
+	t.Run("documented routes with errors are reported", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Name("listUsers")
+		r.HandleFunc("/search", dummyHandler).Methods(http.MethodGet).Name("search").Queries("q")
+		bad := r.HandleFunc("/items/{id:[}", dummyHandler).Methods(http.MethodGet)
+
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Op("listUsers").Summary("List users")
+		spec.Op("search").Summary("Search")
+		spec.Route(bad).Summary("Get item")
+
+		doc, err := spec.BuildE(r)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidRoute)
+		assert.ErrorIs(t, err, bad.GetError())
+		assert.Contains(t, err.Error(), `"search"`)
+		assert.Contains(t, err.Error(), "mux: number of parameters must be multiple of 2")
+
+		require.NotNil(t, doc)
+		assert.Contains(t, doc.Paths, "/users")
+		assert.Len(t, doc.Paths, 1)
+	})
+
+	t.Run("undocumented routes with errors are skipped quietly", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/search", dummyHandler).Methods(http.MethodGet).Queries("q")
+
+		_, err := NewSpec(Info{Title: "Test", Version: "1.0.0"}).BuildE(r)
+		assert.NoError(t, err)
+	})
+
+	t.Run("build keeps returning the document", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/search", dummyHandler).Methods(http.MethodGet).Name("search").Queries("q")
+
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Op("search").Summary("Search")
+
+		doc := spec.Build(r)
+		require.NotNil(t, doc)
+		assert.Empty(t, doc.Paths)
+	})
+}
+
 func TestBuildDocumentJSON(t *testing.T) {
 	t.Run("full document serializes to valid JSON", func(t *testing.T) {
 		r := mux.NewRouter()
@@ -445,6 +502,30 @@ func TestSpecBuilderMethods(t *testing.T) {
 		assert.Contains(t, spec.securitySchemes, "bearerAuth")
 	})
 
+	t.Run("AddCookieAuth", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			AddCookieAuth("sessionAuth", "session").
+			SetSecurity(SecurityRequirement{"sessionAuth": {}})
+		assert.Equal(t, &SecurityScheme{Type: SecurityTypeAPIKey, In: SecurityInCookie, Name: "session"}, spec.securitySchemes["sessionAuth"])
+
+		data, err := spec.Build(mux.NewRouter()).JSON()
+		require.NoError(t, err)
+
+		var raw struct {
+			Components struct {
+				SecuritySchemes map[string]map[string]any `json:"securitySchemes"`
+			} `json:"components"`
+			Security []map[string][]string `json:"security"`
+		}
+		require.NoError(t, json.Unmarshal(data, &raw))
+		assert.Equal(t, map[string]any{"type": "apiKey", "in": "cookie", "name": "session"}, raw.Components.SecuritySchemes["sessionAuth"])
+		assert.Equal(t, []map[string][]string{{"sessionAuth": {}}}, raw.Security)
+
+		parsed, err := DocumentFromJSON(data)
+		require.NoError(t, err)
+		assert.Equal(t, SecurityInCookie, parsed.Components.SecuritySchemes["sessionAuth"].In)
+	})
+
 	t.Run("AddComponentResponse", func(t *testing.T) {
 		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
 			AddComponentResponse("NotFound", &Response{Description: "Not found"})
@@ -626,6 +707,81 @@ func TestBuildSecurity(t *testing.T) {
 	})
 }
 
+func TestSpecApplySecurityToOperations(t *testing.T) {
+	bearer := SecurityRequirement{"bearerAuth": {}}
+	apiKey := SecurityRequirement{"apiKey": {}}
+
+	newSpec := func() (*Spec, *mux.Router) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			SetSecurity(bearer).
+			AddSecurityScheme("bearerAuth", &SecurityScheme{Type: SecurityTypeHTTP, Scheme: SchemeBearer}).
+			AddSecurityScheme("apiKey", &SecurityScheme{Type: SecurityTypeAPIKey, Name: "X-API-Key", In: SecurityInHeader})
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet, http.MethodPost)).
+			Summary("Users")
+		spec.Route(r.HandleFunc("/health", dummyHandler).Methods(http.MethodGet)).
+			Summary("Health check").
+			Security()
+		spec.Route(r.HandleFunc("/keys", dummyHandler).Methods(http.MethodGet)).
+			Security(apiKey)
+		spec.Group().Security(apiKey).
+			Route(r.HandleFunc("/admin", dummyHandler).Methods(http.MethodGet))
+		spec.Webhook("userCreated", http.MethodPost).Summary("User created")
+		return spec, r
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		spec, r := newSpec()
+		doc := spec.Build(r)
+		assert.Nil(t, doc.Paths["/users"].Get.Security)
+	})
+
+	t.Run("operations inherit document security", func(t *testing.T) {
+		spec, r := newSpec()
+		doc := spec.ApplySecurityToOperations(true).Build(r)
+
+		assert.Equal(t, []SecurityRequirement{bearer}, doc.Security)
+		assert.Equal(t, []SecurityRequirement{bearer}, doc.Paths["/users"].Get.Security)
+		assert.Equal(t, []SecurityRequirement{bearer}, doc.Paths["/users"].Post.Security)
+		assert.Equal(t, []SecurityRequirement{apiKey}, doc.Paths["/keys"].Get.Security)
+		assert.Equal(t, []SecurityRequirement{apiKey}, doc.Paths["/admin"].Get.Security)
+		assert.Nil(t, doc.Webhooks["userCreated"].Post.Security)
+	})
+
+	t.Run("public endpoint stays public", func(t *testing.T) {
+		spec, r := newSpec()
+		doc := spec.ApplySecurityToOperations(true).Build(r)
+
+		health := doc.Paths["/health"].Get
+		require.NotNil(t, health.Security)
+		assert.Empty(t, health.Security)
+
+		data, err := json.Marshal(health)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"security":[]`)
+		data, err = json.Marshal(doc.Paths["/users"].Get)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"security":[{"bearerAuth":[]}]`)
+	})
+
+	t.Run("no document security", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).ApplySecurityToOperations(true)
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+		assert.Nil(t, spec.Build(r).Paths["/users"].Get.Security)
+	})
+
+	t.Run("inherited by scope", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.ApplySecurityToOperations(true)
+		scoped := spec.Scope("/users", Info{Title: "Users", Version: "1.0.0"})
+		doc := scoped.Build(r)
+		require.Contains(t, doc.Paths, "/users")
+		assert.Equal(t, []SecurityRequirement{bearer}, doc.Paths["/users"].Get.Security)
+	})
+}
+
 func TestBuildSecuritySchemes(t *testing.T) {
 	t.Run("in doc.Components.SecuritySchemes", func(t *testing.T) {
 		r := mux.NewRouter()
@@ -688,6 +844,92 @@ func TestBuildUserDefinedTags(t *testing.T) {
 	})
 }
 
+func TestBuildTagGroupsAndOrder(t *testing.T) {
+	newSpec := func() (*Spec, *mux.Router) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			AddTag(Tag{Name: "billing", Description: "Billing operations"})
+		for _, tag := range []string{"users", "admin", "orders", "audit"} {
+			spec.Route(r.HandleFunc("/"+tag, dummyHandler).Methods(http.MethodGet)).Tags(tag)
+		}
+		return spec, r
+	}
+	tagNames := func(doc *Document) []string {
+		var names []string
+		for _, tag := range doc.Tags {
+			names = append(names, tag.Name)
+		}
+		return names
+	}
+
+	t.Run("tag groups", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.AddTagGroup("Accounts", "users", "admin").
+			AddTagGroup("Commerce", "orders", "billing")
+
+		doc := spec.Build(r)
+		assert.Equal(t, []TagGroup{
+			{Name: "Accounts", Tags: []string{"users", "admin"}},
+			{Name: "Commerce", Tags: []string{"orders", "billing"}},
+		}, doc.TagGroups)
+		assert.Empty(t, doc.Warnings())
+
+		data, err := doc.JSON()
+		require.NoError(t, err)
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal(data, &raw))
+		assert.Equal(t, []any{
+			map[string]any{"name": "Accounts", "tags": []any{"users", "admin"}},
+			map[string]any{"name": "Commerce", "tags": []any{"orders", "billing"}},
+		}, raw["x-tagGroups"])
+
+		parsed, err := DocumentFromJSON(data)
+		require.NoError(t, err)
+		assert.Equal(t, doc.TagGroups, parsed.TagGroups)
+	})
+
+	t.Run("no tag groups omitted", func(t *testing.T) {
+		spec, r := newSpec()
+		data, err := spec.Build(r).JSON()
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "x-tagGroups")
+	})
+
+	t.Run("tag order", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.SetTagOrder("users", "orders", "missing", "users")
+
+		doc := spec.Build(r)
+		assert.Equal(t, []string{"users", "orders", "admin", "audit", "billing"}, tagNames(doc))
+		assert.Equal(t, "Billing operations", doc.Tags[4].Description)
+	})
+
+	t.Run("default order is alphabetical", func(t *testing.T) {
+		spec, r := newSpec()
+		assert.Equal(t, []string{"admin", "audit", "billing", "orders", "users"}, tagNames(spec.Build(r)))
+	})
+
+	t.Run("warns on unknown tags", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.AddTagGroup("Accounts", "users", "sessions").
+			AddTagGroup("Internal", "debug")
+
+		assert.Equal(t, []string{
+			`x-tagGroups: group "Accounts" references unknown tag "sessions"`,
+			`x-tagGroups: group "Internal" references unknown tag "debug"`,
+		}, spec.Build(r).Warnings())
+	})
+
+	t.Run("scoped spec inherits groups and order", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.AddTagGroup("Accounts", "users").SetTagOrder("users")
+
+		doc := spec.Scope("/", Info{Title: "Scoped", Version: "1.0.0"}).Build(r)
+		assert.Equal(t, []TagGroup{{Name: "Accounts", Tags: []string{"users"}}}, doc.TagGroups)
+		assert.Equal(t, "users", doc.Tags[0].Name)
+	})
+}
+
 func TestBuildComponents(t *testing.T) {
 	cb := Callback{"{$url}": &PathItem{Post: &Operation{Summary: "cb"}}}
 
@@ -1152,6 +1394,240 @@ func TestBuildOperationIDOverride(t *testing.T) {
 	})
 }
 
+func TestBuildRouteDoc(t *testing.T) {
+	t.Run("route doc populates operation", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).
+			Doc("List users", "Returns users ordered by creation time."))
+
+		op := spec.Build(r).Paths["/users"].Get
+		assert.Equal(t, "List users", op.Summary)
+		assert.Equal(t, "Returns users ordered by creation time.", op.Description)
+	})
+
+	t.Run("builder overrides route doc", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).
+			Doc("List users", "Route description.")).
+			Summary("Search users")
+
+		op := spec.Build(r).Paths["/users"].Get
+		assert.Equal(t, "Search users", op.Summary)
+		assert.Equal(t, "Route description.", op.Description)
+	})
+
+	t.Run("named operation", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet).Name("getUser").
+			Doc("Get user", "")
+		r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodDelete).Name("deleteUser").
+			Doc("Delete user", "")
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Op("getUser")
+		spec.Op("deleteUser").Summary("Remove user").Description("Soft-deletes the user.")
+
+		item := spec.Build(r).Paths["/users/{id}"]
+		assert.Equal(t, "Get user", item.Get.Summary)
+		assert.Equal(t, "Remove user", item.Delete.Summary)
+		assert.Equal(t, "Soft-deletes the user.", item.Delete.Description)
+	})
+
+	t.Run("route doc wins over generated docs", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			ApplyGenerated(map[string]OperationDoc{
+				"listUsers": {Summary: "Generated summary", Description: "Generated description."},
+			})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Name("listUsers").
+			Doc("List users", ""))
+
+		op := spec.Build(r).Paths["/users"].Get
+		assert.Equal(t, "List users", op.Summary)
+		assert.Equal(t, "Generated description.", op.Description)
+	})
+
+	t.Run("undocumented routes stay out of the spec", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc("/internal", dummyHandler).Methods(http.MethodGet).Doc("Internal", "")
+		doc := NewSpec(Info{Title: "Test", Version: "1.0.0"}).Build(r)
+		assert.NotContains(t, doc.Paths, "/internal")
+	})
+}
+
+func TestBuildAutoOperationIDs(t *testing.T) {
+	t.Run("derives from method and path", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoOperationIDs(true)
+		spec.Route(r.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/user-profiles/{profile_id}/avatar", dummyHandler).Methods(http.MethodPut))
+		spec.Route(r.HandleFunc("/", dummyHandler).Methods(http.MethodGet))
+
+		doc := spec.Build(r)
+		assert.Equal(t, "getUsersId", doc.Paths["/users/{id}"].Get.OperationID)
+		assert.Equal(t, "putUserProfilesProfileIdAvatar", doc.Paths["/user-profiles/{profile_id}/avatar"].Put.OperationID)
+		assert.Equal(t, "get", doc.Paths["/"].Get.OperationID)
+	})
+
+	t.Run("methods on same path get distinct ids", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoOperationIDs(true)
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet, http.MethodDelete))
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodPatch))
+
+		doc := spec.Build(r)
+		item := doc.Paths["/users/{id}"]
+		assert.Equal(t, "getUsersId", item.Get.OperationID)
+		assert.Equal(t, "deleteUsersId", item.Delete.OperationID)
+		assert.Equal(t, "patchUsersId", item.Patch.OperationID)
+	})
+
+	t.Run("explicit name wins", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoOperationIDs(true)
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodPost).Name("createUser"))
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet)).
+			OperationID("getUser")
+
+		doc := spec.Build(r)
+		assert.Equal(t, "getUsers", doc.Paths["/users"].Get.OperationID)
+		assert.Equal(t, "createUser", doc.Paths["/users"].Post.OperationID)
+		assert.Equal(t, "getUser", doc.Paths["/users/{id}"].Get.OperationID)
+	})
+
+	t.Run("collisions are suffixed", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoOperationIDs(true)
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/users/id", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/users-id", dummyHandler).Methods(http.MethodGet))
+		// A later explicit ID keeps its name; derived IDs avoid it.
+		spec.Route(r.HandleFunc("/accounts", dummyHandler).Methods(http.MethodGet)).
+			OperationID("getUsersId2")
+
+		doc := spec.Build(r)
+		assert.Equal(t, "getUsersId", doc.Paths["/users/{id}"].Get.OperationID)
+		assert.Equal(t, "getUsersId3", doc.Paths["/users/id"].Get.OperationID)
+		assert.Equal(t, "getUsersId4", doc.Paths["/users-id"].Get.OperationID)
+		assert.Equal(t, "getUsersId2", doc.Paths["/accounts"].Get.OperationID)
+	})
+
+	t.Run("stable across builds", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoOperationIDs(true)
+		spec.Route(r.HandleFunc("/a/{x}", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/a/x", dummyHandler).Methods(http.MethodGet))
+
+		first, err := spec.Build(r).JSON()
+		require.NoError(t, err)
+		second, err := spec.Build(r).JSON()
+		require.NoError(t, err)
+		assert.Equal(t, string(first), string(second))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+
+		assert.Empty(t, spec.Build(r).Paths["/users"].Get.OperationID)
+	})
+
+	t.Run("scoped spec inherits setting", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoOperationIDs(true)
+		spec.Route(r.HandleFunc("/v1/users", dummyHandler).Methods(http.MethodGet))
+
+		v1 := spec.Scope("/v1", Info{Title: "v1", Version: "1.0.0"})
+		assert.Equal(t, "getV1Users", v1.Build(r).Paths["/v1/users"].Get.OperationID)
+
+		v1.AutoOperationIDs(false)
+		assert.Empty(t, v1.Build(r).Paths["/v1/users"].Get.OperationID)
+	})
+}
+
+func TestBuildAutoTagByPrefix(t *testing.T) {
+	t.Run("rules", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoTagByPrefix(map[string]string{
+			"/users":        "users",
+			"orders/":       "orders",
+			"/admin/users/": "admin",
+		})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/orders/{id}/items", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/admin/users/{id}", dummyHandler).Methods(http.MethodDelete))
+		spec.Route(r.HandleFunc("/users-export", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/health", dummyHandler).Methods(http.MethodGet))
+
+		doc := spec.Build(r)
+		assert.Equal(t, []string{"users"}, doc.Paths["/users"].Get.Tags)
+		assert.Equal(t, []string{"users"}, doc.Paths["/users/{id}"].Get.Tags)
+		assert.Equal(t, []string{"orders"}, doc.Paths["/orders/{id}/items"].Get.Tags)
+		assert.Equal(t, []string{"admin"}, doc.Paths["/admin/users/{id}"].Delete.Tags)
+		assert.Nil(t, doc.Paths["/users-export"].Get.Tags)
+		assert.Nil(t, doc.Paths["/health"].Get.Tags)
+		assert.Equal(t, []Tag{{Name: "admin"}, {Name: "orders"}, {Name: "users"}}, doc.Tags)
+	})
+
+	t.Run("explicit tags take precedence", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoTagByPrefix(map[string]string{
+			"/users": "users",
+		})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet)).Tags("profiles")
+		spec.Group().Tags("bulk").Route(r.HandleFunc("/users/bulk", dummyHandler).Methods(http.MethodPost))
+
+		doc := spec.Build(r)
+		assert.Equal(t, []string{"users"}, doc.Paths["/users"].Get.Tags)
+		assert.Equal(t, []string{"profiles"}, doc.Paths["/users/{id}"].Get.Tags)
+		assert.Equal(t, []string{"bulk"}, doc.Paths["/users/bulk"].Post.Tags)
+	})
+
+	t.Run("first path segment", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoTagByPrefix(nil)
+		spec.AddTag(Tag{Name: "orders", Description: "Order management"})
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/orders", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/{tenant}/settings", dummyHandler).Methods(http.MethodGet))
+		spec.Route(r.HandleFunc("/", dummyHandler).Methods(http.MethodGet))
+
+		doc := spec.Build(r)
+		assert.Equal(t, []string{"users"}, doc.Paths["/users/{id}"].Get.Tags)
+		assert.Equal(t, []string{"orders"}, doc.Paths["/orders"].Get.Tags)
+		assert.Nil(t, doc.Paths["/{tenant}/settings"].Get.Tags)
+		assert.Nil(t, doc.Paths["/"].Get.Tags)
+		assert.Equal(t, []Tag{{Name: "orders", Description: "Order management"}, {Name: "users"}}, doc.Tags)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+
+		doc := spec.Build(r)
+		assert.Nil(t, doc.Paths["/users"].Get.Tags)
+		assert.Nil(t, doc.Tags)
+	})
+
+	t.Run("inherited by scope", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).AutoTagByPrefix(map[string]string{
+			"/api/v1/users": "users",
+		})
+		spec.Route(r.HandleFunc("/api/v1/users", dummyHandler).Methods(http.MethodGet))
+
+		v1 := spec.Scope("/api/v1", Info{Title: "Test", Version: "1.0.0"}).StripScopePrefix()
+		doc := v1.Build(r)
+		assert.Equal(t, []string{"users"}, doc.Paths["/users"].Get.Tags)
+	})
+}
+
 func TestBuildWebhooks(t *testing.T) {
 	t.Run("single webhook", func(t *testing.T) {
 		r := mux.NewRouter()
@@ -1366,6 +1842,24 @@ func TestMultiMethodOperationID(t *testing.T) {
 	})
 }
 
+func TestBuildSkipsDisabledRoutes(t *testing.T) {
+	r := mux.NewRouter()
+	beta := r.HandleFuncIf(false, "/beta", dummyHandler).
+		Methods(http.MethodGet).
+		Name("beta")
+	r.HandleFunc("/stable", dummyHandler).
+		Methods(http.MethodGet).
+		Name("stable")
+
+	spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+	spec.Route(beta).Summary("Should not appear")
+	spec.Op("stable").Summary("Stable endpoint")
+
+	doc := spec.Build(r)
+	require.Len(t, doc.Paths, 1)
+	assert.Contains(t, doc.Paths, "/stable")
+}
+
 func TestBuildSkipsBuildOnlyRoutes(t *testing.T) {
 	t.Run("build-only route with Op is skipped", func(t *testing.T) {
 		r := mux.NewRouter()
@@ -1518,6 +2012,119 @@ func TestBuildSchemesAutoServers(t *testing.T) {
 	})
 }
 
+func TestBuildDeriveServersFromRoutes(t *testing.T) {
+	t.Run("scheme and host produce operation server", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			AddServer(Server{URL: "https://www.example.com"}).
+			DeriveServersFromRoutes(true)
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).
+			Methods(http.MethodGet).
+			Host("api.example.com").
+			Schemes("https"))
+
+		doc := spec.Build(r)
+
+		get := doc.Paths["/users"].Get
+		require.NotNil(t, get)
+		assert.Equal(t, []Server{{URL: "https://api.example.com"}}, get.Servers)
+		assert.Equal(t, []Server{{URL: "https://www.example.com"}}, doc.Servers)
+	})
+
+	t.Run("host without schemes lists https and http", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).DeriveServersFromRoutes(true)
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Host("api.example.com"))
+
+		doc := spec.Build(r)
+		assert.Equal(t, []Server{
+			{URL: "https://api.example.com"},
+			{URL: "http://api.example.com"},
+		}, doc.Paths["/users"].Get.Servers)
+	})
+
+	t.Run("host variables become server variables", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).DeriveServersFromRoutes(true)
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).
+			Methods(http.MethodGet).
+			Host("{tenant}.{region:eu|us}.example.com").
+			Schemes("https"))
+
+		doc := spec.Build(r)
+
+		servers := doc.Paths["/users"].Get.Servers
+		require.Len(t, servers, 1)
+		assert.Equal(t, "https://{tenant}.{region}.example.com", servers[0].URL)
+		assert.Equal(t, map[string]*ServerVariable{
+			"tenant": {Default: "tenant"},
+			"region": {Enum: []string{"eu", "us"}, Default: "eu"},
+		}, servers[0].Variables)
+	})
+
+	t.Run("host inherited from subrouter", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).DeriveServersFromRoutes(true)
+
+		api := r.Host("api.example.com").Schemes("https").Subrouter()
+		spec.Route(api.HandleFunc("/users", dummyHandler).Methods(http.MethodGet))
+
+		doc := spec.Build(r)
+		assert.Equal(t, []Server{{URL: "https://api.example.com"}}, doc.Paths["/users"].Get.Servers)
+	})
+
+	t.Run("explicit operation servers take precedence", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).DeriveServersFromRoutes(true)
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).
+			Methods(http.MethodGet).
+			Host("api.example.com")).
+			Server(Server{URL: "https://custom.example.com"})
+
+		doc := spec.Build(r)
+		assert.Equal(t, []Server{{URL: "https://custom.example.com"}}, doc.Paths["/users"].Get.Servers)
+	})
+
+	t.Run("routes without host keep scheme behavior", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).DeriveServersFromRoutes(true)
+
+		spec.Route(r.HandleFunc("/secure", dummyHandler).Methods(http.MethodGet).Schemes("https"))
+		spec.Route(r.HandleFunc("/plain", dummyHandler).Methods(http.MethodGet))
+
+		doc := spec.Build(r)
+		assert.Equal(t, []Server{{URL: "https://"}}, doc.Paths["/secure"].Get.Servers)
+		assert.Empty(t, doc.Paths["/plain"].Get.Servers)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Host("api.example.com"))
+
+		doc := spec.Build(r)
+		assert.Empty(t, doc.Paths["/users"].Get.Servers)
+	})
+
+	t.Run("inherited by scope", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).DeriveServersFromRoutes(true)
+
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet).Host("api.example.com"))
+
+		doc := spec.Scope("", Info{Title: "Scoped", Version: "1.0.0"}).Build(r)
+		assert.Len(t, doc.Paths["/users"].Get.Servers, 2)
+
+		doc = spec.Scope("", Info{Title: "Scoped", Version: "1.0.0"}).DeriveServersFromRoutes(false).Build(r)
+		assert.Empty(t, doc.Paths["/users"].Get.Servers)
+	})
+}
+
 func TestBuildHeadersAutoParameters(t *testing.T) {
 	t.Run("headers auto-populate parameters", func(t *testing.T) {
 		r := mux.NewRouter()
@@ -1640,3 +2247,95 @@ func TestBuildHeadersAutoParameters(t *testing.T) {
 		assert.Equal(t, ParameterInHeader, params[1].In)
 	})
 }
+
+func TestBuildGlobalResponseHeader(t *testing.T) {
+	requestID := &Header{
+		Description: "Request identifier",
+		Schema:      &Schema{Type: SchemaTypeString},
+	}
+	rateLimit := &Header{Schema: &Schema{Type: SchemaTypeInteger}}
+
+	t.Run("applies to operations in multiple groups", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			GlobalResponseHeader("X-Request-ID", requestID).
+			GlobalResponseHeader("RateLimit-Limit", rateLimit)
+
+		users := spec.Group().Tags("users")
+		users.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, []string{}).
+			Response(http.StatusUnauthorized, nil)
+
+		orders := spec.Group().Tags("orders").
+			DefaultResponseHeader("X-Error-Code", &Header{Schema: &Schema{Type: SchemaTypeString}})
+		orders.Route(r.HandleFunc("/orders", dummyHandler).Methods(http.MethodPost)).
+			Response(http.StatusCreated, nil).
+			DefaultResponse(nil)
+
+		spec.Route(r.HandleFunc("/health", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusNoContent, nil)
+
+		doc := spec.Build(r)
+		responses := []*Response{
+			doc.Paths["/users"].Get.Responses["200"],
+			doc.Paths["/users"].Get.Responses["401"],
+			doc.Paths["/orders"].Post.Responses["201"],
+			doc.Paths["/orders"].Post.Responses[ResponseDefault],
+			doc.Paths["/health"].Get.Responses["204"],
+		}
+		for _, resp := range responses {
+			require.NotNil(t, resp)
+			assert.Same(t, requestID, resp.Headers["X-Request-ID"])
+			assert.Same(t, rateLimit, resp.Headers["RateLimit-Limit"])
+		}
+		assert.Contains(t, doc.Paths["/orders"].Post.Responses[ResponseDefault].Headers, "X-Error-Code")
+		assert.NotContains(t, doc.Paths["/orders"].Post.Responses["201"].Headers, "X-Error-Code")
+	})
+
+	t.Run("operation header overrides global", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			GlobalResponseHeader("X-Request-ID", requestID)
+
+		override := &Header{Description: "Correlation ID echoed from the request", Schema: &Schema{Type: SchemaTypeString}}
+		spec.Route(r.HandleFunc("/items", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, nil).
+			ResponseHeader(http.StatusOK, "X-Request-ID", override).
+			Response(http.StatusNotFound, nil)
+
+		groupOverride := &Header{Description: "Group request ID", Schema: &Schema{Type: SchemaTypeString}}
+		spec.Group().DefaultResponseHeader("X-Request-ID", groupOverride).
+			Route(r.HandleFunc("/things", dummyHandler).Methods(http.MethodGet)).
+			DefaultResponse(nil)
+
+		doc := spec.Build(r)
+		op := doc.Paths["/items"].Get
+		assert.Same(t, override, op.Responses["200"].Headers["X-Request-ID"])
+		assert.Same(t, requestID, op.Responses["404"].Headers["X-Request-ID"])
+		assert.Same(t, groupOverride, doc.Paths["/things"].Get.Responses[ResponseDefault].Headers["X-Request-ID"])
+	})
+
+	t.Run("builder headers are not modified", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		op := spec.Route(r.HandleFunc("/items", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, nil).
+			ResponseHeader(http.StatusOK, "ETag", &Header{Schema: &Schema{Type: SchemaTypeString}})
+
+		scoped := spec.Scope("/", Info{Title: "Scoped", Version: "1.0.0"}).
+			GlobalResponseHeader("X-Request-ID", requestID)
+
+		assert.Contains(t, scoped.Build(r).Paths["/items"].Get.Responses["200"].Headers, "X-Request-ID")
+		assert.NotContains(t, spec.Build(r).Paths["/items"].Get.Responses["200"].Headers, "X-Request-ID")
+		assert.Len(t, op.meta.responseHeaders["200"], 1)
+	})
+
+	t.Run("webhooks unaffected", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).
+			GlobalResponseHeader("X-Request-ID", requestID)
+		spec.Webhook("orderCreated", http.MethodPost).Response(http.StatusOK, nil)
+
+		doc := spec.Build(mux.NewRouter())
+		assert.Empty(t, doc.Webhooks["orderCreated"].Post.Responses["200"].Headers)
+	})
+}
@@ -0,0 +1,327 @@
+package openapi
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vitalvas/kasper/mux"
+)
+
+// SplitComponentsFile is the name of the file BuildSplit places the shared
+// components in.
+const SplitComponentsFile = "components.json"
+
+// BuildSplit is like BuildE but splits the document into several files for
+// tooling that struggles with one large specification. It returns the root
+// document and the other files keyed by file name:
+//
+//   - the paths of each tag move to a file named after the tag, such as
+//     "users.json", and the root references them with an external $ref.
+//     A path belongs to the first tag of its first tagged operation;
+//     untagged paths stay in the root.
+//   - components move to SplitComponentsFile, and references to them from
+//     the root and the tag files point into that file. Security schemes
+//     stay in the root, where security requirements look them up.
+//
+// References are relative, so the root and the files must be served or
+// written next to each other:
+//
+//	root, files, err := spec.BuildSplit(r)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	data, _ := root.JSON()
+//	os.WriteFile("schema.json", data, 0o644)
+//	for name, doc := range files {
+//	    data, _ := doc.JSON()
+//	    os.WriteFile(name, data, 0o644)
+//	}
+//
+// Every file is a complete document with the openapi version and info of
+// the root. Warnings are reported by the root document.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#reference-object
+func (s *Spec) BuildSplit(r *mux.Router) (*Document, map[string]*Document, error) {
+	s = s.resolve()
+	built, routeErrs := s.build(r)
+
+	doc, err := s.applyTargetVersion(built)
+	if err != nil {
+		routeErrs = append(routeErrs, err)
+	}
+	if doc == built {
+		if doc, err = copyDocument(built); err != nil {
+			return built, nil, errors.Join(append(routeErrs, err)...)
+		}
+	}
+
+	files := splitDocument(doc)
+	return doc, files, errors.Join(routeErrs...)
+}
+
+// splitDocument moves the tagged paths and the components of root into
+// separate documents and returns them keyed by file name.
+func splitDocument(root *Document) map[string]*Document {
+	files := make(map[string]*Document)
+	newFile := func() *Document {
+		file := &Document{OpenAPI: root.OpenAPI, Info: root.Info}
+		if root.v30 != nil {
+			file.v30 = &document30{}
+		}
+		return file
+	}
+
+	var shared string
+	if comp := root.Components; comp != nil {
+		moved := *comp
+		moved.SecuritySchemes = nil
+		if !reflect.ValueOf(moved).IsZero() {
+			shared = SplitComponentsFile
+			file := newFile()
+			file.Components = &moved
+			files[shared] = file
+
+			root.Components = nil
+			if len(comp.SecuritySchemes) > 0 {
+				root.Components = &Components{SecuritySchemes: comp.SecuritySchemes}
+			}
+		}
+	}
+
+	// The components file keeps its local references; the other documents
+	// point into it.
+	rewrite := func(ref string) string {
+		rest, ok := strings.CutPrefix(ref, "#/components/")
+		if !ok || shared == "" || strings.HasPrefix(rest, "securitySchemes/") {
+			return ref
+		}
+		return shared + ref
+	}
+	rewriter := &refRewriter{rewrite: rewrite, visited: make(map[*Schema]bool)}
+	rewriter.document(root)
+
+	tagFiles := make(map[string]string)
+	used := map[string]bool{strings.TrimSuffix(SplitComponentsFile, ".json"): true}
+	for _, path := range slices.Sorted(maps.Keys(root.Paths)) {
+		item := root.Paths[path]
+		tag := pathItemTag(item)
+		if tag == "" {
+			continue
+		}
+		name, ok := tagFiles[tag]
+		if !ok {
+			name = splitFileName(tag, used)
+			tagFiles[tag] = name
+			file := newFile()
+			file.Paths = make(map[string]*PathItem)
+			files[name] = file
+		}
+		files[name].Paths[path] = item
+		root.Paths[path] = &PathItem{Ref: name + "#/paths/" + escapePointer(path)}
+	}
+
+	return files
+}
+
+// pathItemTag returns the first tag of the first tagged operation of item.
+func pathItemTag(item *PathItem) string {
+	if item == nil {
+		return ""
+	}
+	for _, op := range item.operations() {
+		if len(op.Tags) > 0 {
+			return op.Tags[0]
+		}
+	}
+	return ""
+}
+
+// splitFileName returns an unused file name for tag, made of its lowercase
+// letters and digits separated by dashes.
+func splitFileName(tag string, used map[string]bool) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(tag) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	base := b.String()
+	if base == "" {
+		base = "tag"
+	}
+	name := base
+	for i := 2; used[name]; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name + ".json"
+}
+
+// refRewriter passes every $ref and discriminator mapping of a document
+// through rewrite.
+type refRewriter struct {
+	rewrite func(string) string
+	visited map[*Schema]bool
+}
+
+func (w *refRewriter) document(d *Document) {
+	for _, item := range d.Paths {
+		w.pathItem(item)
+	}
+	for _, item := range d.Webhooks {
+		w.pathItem(item)
+	}
+	if d.v30 != nil {
+		for _, item := range d.v30.webhooks {
+			w.pathItem(item)
+		}
+	}
+	if comp := d.Components; comp != nil {
+		for _, s := range comp.Schemas {
+			w.schema(s)
+		}
+		for _, resp := range comp.Responses {
+			w.response(resp)
+		}
+		for _, p := range comp.Parameters {
+			w.parameter(p)
+		}
+		for _, body := range comp.RequestBodies {
+			if body != nil {
+				w.content(body.Content)
+			}
+		}
+		for _, h := range comp.Headers {
+			w.header(h)
+		}
+		for _, cb := range comp.Callbacks {
+			w.callback(cb)
+		}
+		for _, item := range comp.PathItems {
+			w.pathItem(item)
+		}
+	}
+}
+
+func (w *refRewriter) pathItem(item *PathItem) {
+	if item == nil {
+		return
+	}
+	if item.Ref != "" {
+		item.Ref = w.rewrite(item.Ref)
+	}
+	for _, p := range item.Parameters {
+		w.parameter(p)
+	}
+	for _, op := range item.operations() {
+		for _, p := range op.Parameters {
+			w.parameter(p)
+		}
+		if op.RequestBody != nil {
+			w.content(op.RequestBody.Content)
+		}
+		for _, resp := range op.Responses {
+			w.response(resp)
+		}
+		for _, cb := range op.Callbacks {
+			w.callback(cb)
+		}
+	}
+}
+
+func (w *refRewriter) callback(cb *Callback) {
+	if cb == nil {
+		return
+	}
+	for _, item := range *cb {
+		w.pathItem(item)
+	}
+}
+
+func (w *refRewriter) parameter(p *Parameter) {
+	if p == nil {
+		return
+	}
+	w.schema(p.Schema)
+	w.content(p.Content)
+}
+
+func (w *refRewriter) header(h *Header) {
+	if h == nil {
+		return
+	}
+	w.schema(h.Schema)
+	w.content(h.Content)
+}
+
+func (w *refRewriter) response(resp *Response) {
+	if resp == nil {
+		return
+	}
+	for _, h := range resp.Headers {
+		w.header(h)
+	}
+	w.content(resp.Content)
+}
+
+func (w *refRewriter) content(content map[string]*MediaType) {
+	for _, mt := range content {
+		if mt == nil {
+			continue
+		}
+		w.schema(mt.Schema)
+		for _, enc := range mt.Encoding {
+			if enc != nil {
+				for _, h := range enc.Headers {
+					w.header(h)
+				}
+			}
+		}
+	}
+}
+
+func (w *refRewriter) schema(s *Schema) {
+	if s == nil || w.visited[s] {
+		return
+	}
+	w.visited[s] = true
+
+	if s.Ref != "" {
+		s.Ref = w.rewrite(s.Ref)
+	}
+	if s.Discriminator != nil {
+		for k, ref := range s.Discriminator.Mapping {
+			s.Discriminator.Mapping[k] = w.rewrite(ref)
+		}
+	}
+
+	for _, sub := range []*Schema{
+		s.Items, s.Contains, s.UnevaluatedItems, s.AdditionalProperties,
+		s.UnevaluatedProperties, s.PropertyNames, s.Not, s.If, s.Then, s.Else,
+		s.ContentSchema,
+	} {
+		w.schema(sub)
+	}
+	for _, list := range [][]*Schema{s.PrefixItems, s.AllOf, s.OneOf, s.AnyOf} {
+		for _, sub := range list {
+			w.schema(sub)
+		}
+	}
+	for _, m := range []map[string]*Schema{s.Defs, s.Properties, s.PatternProperties, s.DependentSchemas} {
+		for _, sub := range m {
+			w.schema(sub)
+		}
+	}
+}
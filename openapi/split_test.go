@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type splitUser struct {
+	ID   string     `json:"id"`
+	Team *splitTeam `json:"team"`
+}
+
+type splitTeam struct {
+	Name string `json:"name"`
+}
+
+func TestSpecBuildSplit(t *testing.T) {
+	newSpec := func() (*Spec, *mux.Router) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.AddSecurityScheme("bearer", &SecurityScheme{Type: "http", Scheme: "bearer"})
+		spec.Route(r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)).
+			Tags("users").
+			Security(SecurityRequirement{"bearer": {}}).
+			Response(http.StatusOK, []splitUser{})
+		spec.Route(r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet)).
+			Tags("users").
+			Response(http.StatusOK, splitUser{})
+		spec.Route(r.HandleFunc("/teams", dummyHandler).Methods(http.MethodPost)).
+			Tags("Team Admin").
+			Request(splitTeam{}).
+			Response(http.StatusCreated, splitTeam{})
+		spec.Route(r.HandleFunc("/health", dummyHandler).Methods(http.MethodGet)).
+			Response(http.StatusOK, nil)
+		return spec, r
+	}
+
+	decode := func(t *testing.T, doc *Document) map[string]any {
+		t.Helper()
+		data, err := doc.JSON()
+		require.NoError(t, err)
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(data, &out))
+		return out
+	}
+
+	t.Run("one file per tag", func(t *testing.T) {
+		spec, r := newSpec()
+		root, files, err := spec.BuildSplit(r)
+		require.NoError(t, err)
+
+		require.Len(t, files, 3)
+		assert.Contains(t, files, "users.json")
+		assert.Contains(t, files, "team-admin.json")
+		assert.Contains(t, files, SplitComponentsFile)
+
+		assert.Equal(t, "users.json#/paths/~1users", root.Paths["/users"].Ref)
+		assert.Equal(t, "users.json#/paths/~1users~1{id}", root.Paths["/users/{id}"].Ref)
+		assert.Equal(t, "team-admin.json#/paths/~1teams", root.Paths["/teams"].Ref)
+		require.NotNil(t, root.Paths["/health"].Get)
+		assert.Empty(t, root.Paths["/health"].Ref)
+
+		users := files["users.json"]
+		assert.Equal(t, OpenAPIVersion, users.OpenAPI)
+		assert.Equal(t, root.Info, users.Info)
+		assert.Len(t, users.Paths, 2)
+		assert.Nil(t, users.Components)
+		schema := users.Paths["/users/{id}"].Get.Responses["200"].Content["application/json"].Schema
+		assert.Equal(t, "components.json#/components/schemas/splitUser", schema.Ref)
+		assert.Len(t, files["team-admin.json"].Paths, 1)
+	})
+
+	t.Run("shared components", func(t *testing.T) {
+		spec, r := newSpec()
+		root, files, err := spec.BuildSplit(r)
+		require.NoError(t, err)
+
+		comp := files[SplitComponentsFile]
+		assert.Empty(t, comp.Paths)
+		require.NotNil(t, comp.Components)
+		assert.Contains(t, comp.Components.Schemas, "splitUser")
+		assert.Contains(t, comp.Components.Schemas, "splitTeam")
+		assert.Empty(t, comp.Components.SecuritySchemes)
+
+		team := comp.Components.Schemas["splitUser"].Properties["team"]
+		assert.Contains(t, decode(t, comp)["components"].(map[string]any)["schemas"], "splitTeam")
+		require.Len(t, team.AnyOf, 2)
+		assert.Equal(t, "#/components/schemas/splitTeam", team.AnyOf[0].Ref)
+
+		require.NotNil(t, root.Components)
+		assert.Equal(t, map[string]*SecurityScheme{"bearer": {Type: "http", Scheme: "bearer"}}, root.Components.SecuritySchemes)
+		assert.Empty(t, root.Components.Schemas)
+	})
+
+	t.Run("build is unchanged", func(t *testing.T) {
+		spec, r := newSpec()
+		_, _, err := spec.BuildSplit(r)
+		require.NoError(t, err)
+
+		doc := spec.Build(r)
+		assert.Empty(t, doc.Paths["/users"].Ref)
+		assert.Contains(t, doc.Components.Schemas, "splitUser")
+		schema := doc.Paths["/users/{id}"].Get.Responses["200"].Content["application/json"].Schema
+		assert.Equal(t, "#/components/schemas/splitUser", schema.Ref)
+	})
+
+	t.Run("untagged without components", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"})
+		spec.Route(r.HandleFunc("/health", dummyHandler).Methods(http.MethodGet)).Response(http.StatusOK, nil)
+
+		root, files, err := spec.BuildSplit(r)
+		require.NoError(t, err)
+		assert.Empty(t, files)
+		assert.NotNil(t, root.Paths["/health"].Get)
+	})
+
+	t.Run("target version", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.TargetVersion("3.0.3")
+		root, files, err := spec.BuildSplit(r)
+		require.NoError(t, err)
+
+		assert.Equal(t, "3.0.3", root.OpenAPI)
+		comp := decode(t, files[SplitComponentsFile])
+		assert.Equal(t, "3.0.3", comp["openapi"])
+		assert.Equal(t, map[string]any{}, comp["paths"])
+		team := comp["components"].(map[string]any)["schemas"].(map[string]any)["splitUser"].(map[string]any)["properties"].(map[string]any)["team"]
+		assert.Equal(t, map[string]any{
+			"nullable": true,
+			"allOf":    []any{map[string]any{"$ref": "#/components/schemas/splitTeam"}},
+		}, team)
+	})
+
+	t.Run("reports build errors", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.TargetVersion("2.0")
+		root, files, err := spec.BuildSplit(r)
+		require.ErrorIs(t, err, ErrUnsupportedTargetVersion)
+		assert.Equal(t, OpenAPIVersion, root.OpenAPI)
+		assert.Len(t, files, 3)
+	})
+}
+
+func TestSplitFileName(t *testing.T) {
+	used := map[string]bool{"components": true}
+	assert.Equal(t, "users.json", splitFileName("Users", used))
+	assert.Equal(t, "users-2.json", splitFileName("users", used))
+	assert.Equal(t, "team-admin.json", splitFileName(" Team / Admin ", used))
+	assert.Equal(t, "components-2.json", splitFileName("components", used))
+	assert.Equal(t, "tag.json", splitFileName("!!", used))
+}
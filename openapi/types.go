@@ -19,8 +19,12 @@ type Document struct {
 	Webhooks          map[string]*PathItem  `json:"webhooks,omitempty"`
 	Components        *Components           `json:"components,omitempty"`
 	Tags              []Tag                 `json:"tags,omitempty"`
+	TagGroups         []TagGroup            `json:"x-tagGroups,omitempty"`
 	Security          []SecurityRequirement `json:"security,omitempty"`
 	ExternalDocs      *ExternalDocs         `json:"externalDocs,omitempty"`
+
+	buildWarnings []string    // reported by Warnings; set by Spec.ValidateExamples and Spec.TargetVersion
+	v30           *document30 // set when converted to OpenAPI 3.0; see Spec.TargetVersion
 }
 
 // Info provides metadata about the API.
@@ -105,8 +109,35 @@ type Operation struct {
 	Responses    map[string]*Response  `json:"responses,omitempty"`
 	Callbacks    map[string]*Callback  `json:"callbacks,omitempty"`
 	Deprecated   bool                  `json:"deprecated,omitempty"`
-	Security     []SecurityRequirement `json:"security,omitempty"`
+	Security     []SecurityRequirement `json:"security,omitzero"` // empty, not nil, marks the operation public
 	Servers      []Server              `json:"servers,omitempty"`
+
+	// Extensions holds "x-" specification extensions, such as the
+	// deprecation dates set by OperationBuilder.DeprecatedSince and Sunset.
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON encodes the operation with its extensions inlined.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+	return marshalWithExtensions(operation(o), o.Extensions)
+}
+
+// UnmarshalJSON decodes the operation and collects its "x-" fields into
+// Extensions.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+	var v operation
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	ext, err := unmarshalExtensions(data)
+	if err != nil {
+		return err
+	}
+	v.Extensions = ext
+	*o = Operation(v)
+	return nil
 }
 
 // Parameter describes a single operation parameter.
@@ -161,6 +192,33 @@ type MediaType struct {
 	Example  any                  `json:"example,omitempty"`
 	Examples map[string]*Example  `json:"examples,omitempty"`
 	Encoding map[string]*Encoding `json:"encoding,omitempty"`
+
+	// Extensions holds "x-" specification extensions, such as the event
+	// schema set by OperationBuilder.ResponseSSE.
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON encodes the media type with its extensions inlined.
+func (m MediaType) MarshalJSON() ([]byte, error) {
+	type mediaType MediaType
+	return marshalWithExtensions(mediaType(m), m.Extensions)
+}
+
+// UnmarshalJSON decodes the media type and collects its "x-" fields into
+// Extensions.
+func (m *MediaType) UnmarshalJSON(data []byte) error {
+	type mediaType MediaType
+	var v mediaType
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	ext, err := unmarshalExtensions(data)
+	if err != nil {
+		return err
+	}
+	v.Extensions = ext
+	*m = MediaType(v)
+	return nil
 }
 
 // Header describes a single header. Header Object follows the same structure
@@ -478,6 +536,43 @@ type Schema struct {
 	Discriminator *Discriminator `json:"discriminator,omitempty"`
 	ExternalDocs  *ExternalDocs  `json:"externalDocs,omitempty"`
 	XML           *XML           `json:"xml,omitempty"`
+
+	// Extensions holds "x-" specification extensions, such as the
+	// migration hint set by the deprecatedMessage struct tag key.
+	Extensions Extensions `json:"-" yaml:",inline"`
+
+	v30 *schema30 // OpenAPI 3.0 keywords; see Spec.TargetVersion
+}
+
+// MarshalJSON encodes the schema with its extensions inlined.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if s.v30 != nil {
+		return marshalWithExtensions(struct {
+			schema
+			Nullable         bool `json:"nullable,omitempty"`
+			ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty"`
+			ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty"`
+		}{schema(s), s.v30.nullable, s.v30.exclusiveMinimum, s.v30.exclusiveMaximum}, s.Extensions)
+	}
+	return marshalWithExtensions(schema(s), s.Extensions)
+}
+
+// UnmarshalJSON decodes the schema and collects its "x-" fields into
+// Extensions.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema
+	var v schema
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	ext, err := unmarshalExtensions(data)
+	if err != nil {
+		return err
+	}
+	v.Extensions = ext
+	*s = Schema(v)
+	return nil
 }
 
 // Components holds reusable OpenAPI objects. All objects defined within the
@@ -507,6 +602,16 @@ type Tag struct {
 	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
 }
 
+// TagGroup groups tags into a named section of the documentation. Tag
+// groups are emitted as the "x-tagGroups" specification extension, which
+// documentation renderers such as Redoc use to organize large APIs.
+//
+// See: https://spec.openapis.org/oas/v3.1.0#specification-extensions
+type TagGroup struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
 // SecurityRequirement lists required security schemes for an operation.
 // Each key maps to a list of scope names required for execution (can be
 // empty for schemes not using scopes, such as HTTP basic auth).
@@ -535,7 +640,8 @@ type Example struct {
 
 // Encoding describes encoding for a single property in a media type.
 // Only applies to Request Body Objects when the media type is
-// "multipart" or "application/x-www-form-urlencoded".
+// "multipart" or "application/x-www-form-urlencoded", and to the
+// multipart responses documented by ResponseMultipart.
 //
 // See: https://spec.openapis.org/oas/v3.1.0#encoding-object
 type Encoding struct {
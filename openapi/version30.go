@@ -0,0 +1,508 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedTargetVersion is wrapped by the error BuildE returns when
+// TargetVersion is set to a version the package cannot produce.
+var ErrUnsupportedTargetVersion = errors.New("openapi: unsupported target version")
+
+// version30Regexp matches the OpenAPI 3.0.x versions TargetVersion accepts.
+var version30Regexp = regexp.MustCompile(`^3\.0\.\d+$`)
+
+// TargetVersion selects the OpenAPI version of the document produced by
+// Build, BuildE, and Handle. The default, "3.1.0", is the version the
+// package generates natively. A "3.0.x" version, such as "3.0.3", converts
+// the built document for tooling that only reads OpenAPI 3.0:
+//
+//   - type arrays with "null" become a single type with "nullable: true",
+//     and an anyOf or oneOf alternative of type null becomes "nullable:
+//     true" on the parent; other type arrays become an anyOf of types
+//   - numeric exclusiveMinimum and exclusiveMaximum become minimum and
+//     maximum with the boolean exclusive flag
+//   - const becomes a single-value enum, and the examples array becomes
+//     example
+//   - $ref with sibling keywords is wrapped in allOf, since 3.0 ignores
+//     the siblings
+//   - contentEncoding base64 becomes format byte and contentMediaType
+//     becomes format binary
+//   - webhooks are emitted as "x-webhooks", references to
+//     components.pathItems are inlined, and a license identifier without a
+//     URL becomes the SPDX license URL
+//
+// Keywords that OpenAPI 3.0 cannot express, such as prefixItems, if/then/
+// else, or the info summary, are dropped, and each loss is reported by
+// Document.Warnings rather than silently. An unsupported version makes
+// BuildE return an error wrapping ErrUnsupportedTargetVersion, with the
+// document left at 3.1.0.
+//
+//	spec.TargetVersion("3.0.3")
+//	doc := spec.Build(r)
+//	for _, w := range doc.Warnings() {
+//	    log.Print(w)
+//	}
+//
+// See: https://spec.openapis.org/oas/v3.0.3
+func (s *Spec) TargetVersion(version string) *Spec {
+	s.targetVersion = version
+	s.targetVersionSet = true
+	return s
+}
+
+// applyTargetVersion returns doc converted to the target version.
+func (s *Spec) applyTargetVersion(doc *Document) (*Document, error) {
+	switch {
+	case s.targetVersion == "" || s.targetVersion == OpenAPIVersion:
+		return doc, nil
+	case version30Regexp.MatchString(s.targetVersion):
+		return convertTo30(doc, s.targetVersion)
+	default:
+		return doc, fmt.Errorf("%w %q", ErrUnsupportedTargetVersion, s.targetVersion)
+	}
+}
+
+// document30 holds the parts of a document converted to OpenAPI 3.0 that
+// have no field in Document.
+type document30 struct {
+	webhooks map[string]*PathItem // emitted as "x-webhooks"
+}
+
+// schema30 holds the OpenAPI 3.0 keywords of a converted schema.
+type schema30 struct {
+	nullable         bool
+	exclusiveMinimum bool
+	exclusiveMaximum bool
+}
+
+// convertTo30 returns a copy of doc converted to the given OpenAPI 3.0.x
+// version. The copy leaves doc and the schemas and parameters it shares
+// with the Spec untouched.
+func convertTo30(doc *Document, version string) (*Document, error) {
+	out, err := copyDocument(doc)
+	if err != nil {
+		return doc, err
+	}
+
+	c := &converter30{doc: out, version: version, visited: make(map[*Schema]bool)}
+	c.convert()
+	return out, nil
+}
+
+// converter30 rewrites a document in place for OpenAPI 3.0.
+type converter30 struct {
+	doc     *Document
+	version string
+	visited map[*Schema]bool
+}
+
+// warn records a feature lost at loc.
+func (c *converter30) warn(loc, format string, args ...any) {
+	c.doc.buildWarnings = append(c.doc.buildWarnings, fmt.Sprintf("openapi %s: %s: %s", c.version, loc, fmt.Sprintf(format, args...)))
+}
+
+func (c *converter30) convert() {
+	d := c.doc
+	d.OpenAPI = c.version
+	d.v30 = &document30{}
+
+	if d.JSONSchemaDialect != "" {
+		c.warn("#/jsonSchemaDialect", "jsonSchemaDialect is not supported; dropped")
+		d.JSONSchemaDialect = ""
+	}
+	if d.Info.Summary != "" {
+		c.warn("#/info/summary", "info summary is not supported; dropped")
+		d.Info.Summary = ""
+	}
+	if l := d.Info.License; l != nil && l.Identifier != "" {
+		if l.URL == "" {
+			l.URL = "https://spdx.org/licenses/" + l.Identifier + ".html"
+		} else {
+			c.warn("#/info/license/identifier", "license identifier is not supported; dropped in favor of the license URL")
+		}
+		l.Identifier = ""
+	}
+
+	if comp := d.Components; comp != nil {
+		if len(comp.PathItems) > 0 {
+			c.inlinePathItems()
+			c.warn("#/components/pathItems", "components.pathItems is not supported; references were inlined and the definitions dropped")
+			comp.PathItems = nil
+		}
+		for _, name := range slices.Sorted(maps.Keys(comp.SecuritySchemes)) {
+			if scheme := comp.SecuritySchemes[name]; scheme != nil && scheme.Type == "mutualTLS" {
+				c.warn("#/components/securitySchemes/"+escapePointer(name), "security scheme type mutualTLS is not supported")
+			}
+		}
+	}
+
+	c.walk()
+
+	if len(d.Webhooks) > 0 {
+		c.warn("#/webhooks", "webhooks are not supported; emitted as x-webhooks")
+		d.v30.webhooks = d.Webhooks
+		d.Webhooks = nil
+	}
+}
+
+// inlinePathItems replaces references to components.pathItems in paths,
+// webhooks, and callbacks with the referenced path item.
+func (c *converter30) inlinePathItems() {
+	const prefix = "#/components/pathItems/"
+	items := c.doc.Components.PathItems
+	inline := func(item *PathItem) {
+		if item == nil || !strings.HasPrefix(item.Ref, prefix) {
+			return
+		}
+		if target := items[unescapePointer(strings.TrimPrefix(item.Ref, prefix))]; target != nil {
+			*item = *target
+		}
+	}
+	var pathItems func(map[string]*PathItem)
+	pathItems = func(m map[string]*PathItem) {
+		for _, item := range m {
+			inline(item)
+			if item == nil {
+				continue
+			}
+			for _, op := range item.operations() {
+				for _, cb := range op.Callbacks {
+					if cb != nil {
+						pathItems(*cb)
+					}
+				}
+			}
+		}
+	}
+	pathItems(c.doc.Paths)
+	pathItems(c.doc.Webhooks)
+	for _, cb := range c.doc.Components.Callbacks {
+		if cb != nil {
+			pathItems(*cb)
+		}
+	}
+}
+
+// walk converts every schema in the document.
+func (c *converter30) walk() {
+	d := c.doc
+	if comp := d.Components; comp != nil {
+		for _, name := range slices.Sorted(maps.Keys(comp.Schemas)) {
+			c.schema(comp.Schemas[name], "#/components/schemas/"+escapePointer(name))
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(d.Paths)) {
+		c.pathItem(d.Paths[path], "#/paths/"+escapePointer(path))
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.Webhooks)) {
+		c.pathItem(d.Webhooks[name], "#/webhooks/"+escapePointer(name))
+	}
+	if comp := d.Components; comp != nil {
+		for _, name := range slices.Sorted(maps.Keys(comp.Responses)) {
+			c.response(comp.Responses[name], "#/components/responses/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(comp.Parameters)) {
+			c.parameter(comp.Parameters[name], "#/components/parameters/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(comp.RequestBodies)) {
+			if body := comp.RequestBodies[name]; body != nil {
+				c.content(body.Content, "#/components/requestBodies/"+escapePointer(name)+"/content")
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(comp.Headers)) {
+			c.header(comp.Headers[name], "#/components/headers/"+escapePointer(name))
+		}
+		for _, name := range slices.Sorted(maps.Keys(comp.Callbacks)) {
+			c.callback(comp.Callbacks[name], "#/components/callbacks/"+escapePointer(name))
+		}
+	}
+}
+
+func (c *converter30) pathItem(item *PathItem, loc string) {
+	if item == nil {
+		return
+	}
+	c.parameters(item.Parameters, loc)
+	for _, op := range []struct {
+		method string
+		op     *Operation
+	}{
+		{"get", item.Get}, {"put", item.Put}, {"post", item.Post}, {"delete", item.Delete},
+		{"options", item.Options}, {"head", item.Head}, {"patch", item.Patch}, {"trace", item.Trace},
+	} {
+		if op.op != nil {
+			c.operation(op.op, loc+"/"+op.method)
+		}
+	}
+}
+
+func (c *converter30) operation(op *Operation, loc string) {
+	c.parameters(op.Parameters, loc)
+	if op.RequestBody != nil {
+		c.content(op.RequestBody.Content, loc+"/requestBody/content")
+	}
+	for _, status := range slices.Sorted(maps.Keys(op.Responses)) {
+		c.response(op.Responses[status], loc+"/responses/"+escapePointer(status))
+	}
+	for _, name := range slices.Sorted(maps.Keys(op.Callbacks)) {
+		c.callback(op.Callbacks[name], loc+"/callbacks/"+escapePointer(name))
+	}
+}
+
+func (c *converter30) callback(cb *Callback, loc string) {
+	if cb == nil {
+		return
+	}
+	for _, expr := range slices.Sorted(maps.Keys(*cb)) {
+		c.pathItem((*cb)[expr], loc+"/"+escapePointer(expr))
+	}
+}
+
+func (c *converter30) parameters(params []*Parameter, loc string) {
+	for i, p := range params {
+		c.parameter(p, loc+"/parameters/"+strconv.Itoa(i))
+	}
+}
+
+func (c *converter30) parameter(p *Parameter, loc string) {
+	if p == nil {
+		return
+	}
+	c.schema(p.Schema, loc+"/schema")
+	c.content(p.Content, loc+"/content")
+}
+
+func (c *converter30) header(h *Header, loc string) {
+	if h == nil {
+		return
+	}
+	c.schema(h.Schema, loc+"/schema")
+	c.content(h.Content, loc+"/content")
+}
+
+func (c *converter30) response(resp *Response, loc string) {
+	if resp == nil {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(resp.Headers)) {
+		c.header(resp.Headers[name], loc+"/headers/"+escapePointer(name))
+	}
+	c.content(resp.Content, loc+"/content")
+}
+
+func (c *converter30) content(content map[string]*MediaType, loc string) {
+	for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+		mt := content[mediaType]
+		if mt == nil {
+			continue
+		}
+		mtLoc := loc + "/" + escapePointer(mediaType)
+		c.schema(mt.Schema, mtLoc+"/schema")
+		for _, prop := range slices.Sorted(maps.Keys(mt.Encoding)) {
+			if enc := mt.Encoding[prop]; enc != nil {
+				for _, name := range slices.Sorted(maps.Keys(enc.Headers)) {
+					c.header(enc.Headers[name], mtLoc+"/encoding/"+escapePointer(prop)+"/headers/"+escapePointer(name))
+				}
+			}
+		}
+	}
+}
+
+// schema converts s and the schemas nested in it.
+func (c *converter30) schema(s *Schema, loc string) {
+	if s == nil || c.visited[s] {
+		return
+	}
+	c.visited[s] = true
+
+	var v30 schema30
+
+	// OpenAPI 3.0 ignores the siblings of $ref.
+	if s.Ref != "" {
+		rest := *s
+		rest.Ref = ""
+		if !reflect.ValueOf(rest).IsZero() {
+			s.AllOf = append([]*Schema{{Ref: s.Ref}}, s.AllOf...)
+			s.Ref = ""
+		}
+	}
+
+	c.dropUnsupported(s, loc)
+	c.convertContent(s, loc)
+
+	if s.Const != nil {
+		s.Enum = []any{s.Const}
+		s.Const = nil
+	}
+	if len(s.Examples) > 0 {
+		if s.Example == nil {
+			s.Example = s.Examples[0]
+			if len(s.Examples) > 1 {
+				c.warn(loc+"/examples", "only the first of %d examples is kept as example", len(s.Examples))
+			}
+		} else {
+			c.warn(loc+"/examples", "examples is not supported alongside example; dropped")
+		}
+		s.Examples = nil
+	}
+
+	if s.ExclusiveMinimum != nil {
+		if s.Minimum == nil || *s.Minimum <= *s.ExclusiveMinimum {
+			s.Minimum = s.ExclusiveMinimum
+			v30.exclusiveMinimum = true
+		}
+		s.ExclusiveMinimum = nil
+	}
+	if s.ExclusiveMaximum != nil {
+		if s.Maximum == nil || *s.Maximum >= *s.ExclusiveMaximum {
+			s.Maximum = s.ExclusiveMaximum
+			v30.exclusiveMaximum = true
+		}
+		s.ExclusiveMaximum = nil
+	}
+
+	c.convertType(s, &v30, loc)
+	s.AnyOf = c.dropNullAlternative(s, s.AnyOf, &v30)
+	s.OneOf = c.dropNullAlternative(s, s.OneOf, &v30)
+
+	if v30 != (schema30{}) {
+		s.v30 = &v30
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		c.schema(s.Properties[name], loc+"/properties/"+escapePointer(name))
+	}
+	c.schema(s.Items, loc+"/items")
+	c.schema(s.AdditionalProperties, loc+"/additionalProperties")
+	c.schema(s.Not, loc+"/not")
+	for _, list := range []struct {
+		keyword string
+		schemas []*Schema
+	}{
+		{"allOf", s.AllOf}, {"anyOf", s.AnyOf}, {"oneOf", s.OneOf},
+	} {
+		for i, sub := range list.schemas {
+			c.schema(sub, loc+"/"+list.keyword+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+// dropUnsupported removes the JSON Schema keywords OpenAPI 3.0 has no
+// equivalent for, with a warning for each.
+func (c *converter30) dropUnsupported(s *Schema, loc string) {
+	drop := func(keyword string, set bool) {
+		if set {
+			c.warn(loc, "%s is not supported; dropped", keyword)
+		}
+	}
+	drop("$id", s.ID != "")
+	drop("$schema", s.SchemaURI != "")
+	drop("$dynamicAnchor", s.DynamicAnchor != "")
+	drop("$comment", s.Comment != "")
+	drop("$defs", len(s.Defs) > 0)
+	drop("prefixItems", len(s.PrefixItems) > 0)
+	drop("contains", s.Contains != nil)
+	drop("unevaluatedItems", s.UnevaluatedItems != nil)
+	drop("unevaluatedProperties", s.UnevaluatedProperties != nil)
+	drop("patternProperties", len(s.PatternProperties) > 0)
+	drop("propertyNames", s.PropertyNames != nil)
+	drop("dependentRequired", len(s.DependentRequired) > 0)
+	drop("dependentSchemas", len(s.DependentSchemas) > 0)
+	drop("if/then/else", s.If != nil || s.Then != nil || s.Else != nil)
+	drop("contentSchema", s.ContentSchema != nil)
+
+	s.ID, s.SchemaURI, s.DynamicAnchor, s.Comment, s.Defs = "", "", "", "", nil
+	s.PrefixItems, s.Contains, s.UnevaluatedItems, s.UnevaluatedProperties = nil, nil, nil, nil
+	s.PatternProperties, s.PropertyNames, s.DependentRequired, s.DependentSchemas = nil, nil, nil, nil
+	s.If, s.Then, s.Else, s.ContentSchema = nil, nil, nil, nil
+}
+
+// convertContent maps contentEncoding and contentMediaType to the string
+// formats OpenAPI 3.0 uses for encoded and binary data.
+func (c *converter30) convertContent(s *Schema, loc string) {
+	if s.ContentEncoding == "" && s.ContentMediaType == "" {
+		return
+	}
+	format := "binary"
+	if s.ContentEncoding != "" {
+		if !strings.EqualFold(s.ContentEncoding, "base64") {
+			c.warn(loc, "contentEncoding %q is not supported; dropped", s.ContentEncoding)
+			s.ContentEncoding, s.ContentMediaType = "", ""
+			return
+		}
+		format = "byte"
+	}
+	if s.Type.IsEmpty() {
+		s.Type = SchemaTypeString
+	}
+	if s.Format == "" {
+		s.Format = format
+	}
+	s.ContentEncoding, s.ContentMediaType = "", ""
+}
+
+// convertType replaces a type array with a single type and nullable, or
+// with an anyOf of the types.
+func (c *converter30) convertType(s *Schema, v30 *schema30, loc string) {
+	types := s.Type.Values()
+	if len(types) == 0 || len(types) == 1 && types[0] != "null" {
+		return
+	}
+	nonNull := slices.DeleteFunc(slices.Clone(types), func(t string) bool { return t == "null" })
+	v30.nullable = len(nonNull) < len(types)
+
+	switch len(nonNull) {
+	case 0:
+		c.warn(loc+"/type", "type null is not supported; emitted as nullable without a type")
+		s.Type = SchemaType{}
+	case 1:
+		s.Type = TypeString(nonNull[0])
+	default:
+		alternatives := make([]*Schema, 0, len(nonNull))
+		for _, t := range nonNull {
+			alternatives = append(alternatives, &Schema{Type: TypeString(t)})
+		}
+		s.Type = SchemaType{}
+		if len(s.AnyOf) == 0 {
+			s.AnyOf = alternatives
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{AnyOf: alternatives})
+		}
+	}
+}
+
+// dropNullAlternative removes the alternatives of type null from an anyOf
+// or oneOf list of s, marking s nullable instead. A single remaining
+// alternative moves to allOf.
+func (c *converter30) dropNullAlternative(s *Schema, alternatives []*Schema, v30 *schema30) []*Schema {
+	if len(alternatives) == 0 {
+		return alternatives
+	}
+	rest := slices.DeleteFunc(slices.Clone(alternatives), isNullSchema)
+	if len(rest) == len(alternatives) || len(rest) == 0 {
+		return alternatives
+	}
+	v30.nullable = true
+	if len(rest) == 1 {
+		s.AllOf = append(s.AllOf, rest[0])
+		return nil
+	}
+	return rest
+}
+
+// isNullSchema reports whether s is exactly {"type": "null"}.
+func isNullSchema(s *Schema) bool {
+	if s == nil || !slices.Equal(s.Type.Values(), []string{"null"}) {
+		return false
+	}
+	rest := *s
+	rest.Type = SchemaType{}
+	return reflect.ValueOf(rest).IsZero()
+}
@@ -0,0 +1,232 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/mux"
+)
+
+type v30Owner struct {
+	Name string `json:"name"`
+}
+
+type v30Pet struct {
+	Name     string    `json:"name"`
+	Nickname *string   `json:"nickname"`
+	Age      int       `json:"age" openapi:"exclusiveMinimum=0,exclusiveMaximum=100"`
+	Kind     string    `json:"kind" openapi:"const=pet"`
+	Owner    *v30Owner `json:"owner"`
+}
+
+func TestSpecTargetVersion(t *testing.T) {
+	newSpec := func() (*Spec, *mux.Router) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Pets", Summary: "Pet store", Version: "1.0.0", License: &License{Name: "MIT", Identifier: "MIT"}})
+		spec.Route(r.HandleFunc("/pets", dummyHandler).Methods(http.MethodPost)).
+			Request(v30Pet{}).
+			Response(http.StatusOK, v30Pet{})
+		spec.Webhook("petAdded", http.MethodPost).Request(v30Pet{})
+		return spec, r
+	}
+
+	decode := func(t *testing.T, doc *Document) map[string]any {
+		t.Helper()
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(data, &out))
+		return out
+	}
+
+	petSchema := func(t *testing.T, out map[string]any) map[string]any {
+		t.Helper()
+		schemas := out["components"].(map[string]any)["schemas"].(map[string]any)
+		return schemas["v30Pet"].(map[string]any)["properties"].(map[string]any)
+	}
+
+	t.Run("default is 3.1.0", func(t *testing.T) {
+		spec, r := newSpec()
+		doc, err := spec.BuildE(r)
+		require.NoError(t, err)
+		assert.Equal(t, OpenAPIVersion, doc.OpenAPI)
+		assert.Empty(t, doc.Warnings())
+
+		out := decode(t, doc)
+		assert.Contains(t, out, "webhooks")
+		props := petSchema(t, out)
+		assert.Equal(t, []any{"string", "null"}, props["nickname"].(map[string]any)["type"])
+		assert.InDelta(t, 0, props["age"].(map[string]any)["exclusiveMinimum"], 0)
+		assert.Equal(t, "pet", props["kind"].(map[string]any)["const"])
+	})
+
+	t.Run("3.0.3", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.TargetVersion("3.0.3")
+		doc, err := spec.BuildE(r)
+		require.NoError(t, err)
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+		out := decode(t, doc)
+		assert.NotContains(t, out, "webhooks")
+		assert.Contains(t, out["x-webhooks"], "petAdded")
+		info := out["info"].(map[string]any)
+		assert.NotContains(t, info, "summary")
+		assert.Equal(t, map[string]any{"name": "MIT", "url": "https://spdx.org/licenses/MIT.html"}, info["license"])
+
+		props := petSchema(t, out)
+		assert.Equal(t, map[string]any{"type": "string", "nullable": true}, props["nickname"])
+		assert.Equal(t, map[string]any{
+			"type":             "integer",
+			"minimum":          float64(0),
+			"maximum":          float64(100),
+			"exclusiveMinimum": true,
+			"exclusiveMaximum": true,
+		}, props["age"])
+		assert.Equal(t, map[string]any{"type": "string", "enum": []any{"pet"}}, props["kind"])
+		assert.Equal(t, map[string]any{
+			"nullable": true,
+			"allOf":    []any{map[string]any{"$ref": "#/components/schemas/v30Owner"}},
+		}, props["owner"])
+
+		assert.Equal(t, []string{
+			"openapi 3.0.3: #/info/summary: info summary is not supported; dropped",
+			"openapi 3.0.3: #/webhooks: webhooks are not supported; emitted as x-webhooks",
+		}, doc.Warnings())
+	})
+
+	t.Run("does not modify shared schemas", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.TargetVersion("3.0.0")
+		_, err := spec.BuildE(r)
+		require.NoError(t, err)
+
+		spec.TargetVersion("")
+		doc, err := spec.BuildE(r)
+		require.NoError(t, err)
+		assert.Equal(t, OpenAPIVersion, doc.OpenAPI)
+		props := petSchema(t, decode(t, doc))
+		assert.Equal(t, []any{"string", "null"}, props["nickname"].(map[string]any)["type"])
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		for _, version := range []string{"2.0", "3.0", "3.2.0", "3.0.x"} {
+			spec, r := newSpec()
+			spec.TargetVersion(version)
+			doc, err := spec.BuildE(r)
+			require.ErrorIs(t, err, ErrUnsupportedTargetVersion, version)
+			assert.Equal(t, OpenAPIVersion, doc.OpenAPI, version)
+		}
+	})
+
+	t.Run("inherited by scope", func(t *testing.T) {
+		r := mux.NewRouter()
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).TargetVersion("3.0.3")
+		scoped := spec.Scope("/public", Info{Title: "Public", Version: "1.0.0"})
+		scoped.Route(r.HandleFunc("/items", dummyHandler).Methods(http.MethodGet)).Response(http.StatusOK, v30Owner{})
+		assert.Equal(t, "3.0.3", scoped.Build(r).OpenAPI)
+	})
+
+	t.Run("empty paths", func(t *testing.T) {
+		spec := NewSpec(Info{Title: "Test", Version: "1.0.0"}).TargetVersion("3.0.3")
+		out := decode(t, spec.Build(mux.NewRouter()))
+		assert.Equal(t, map[string]any{}, out["paths"])
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		spec, r := newSpec()
+		spec.TargetVersion("3.0.3")
+		data, err := spec.Build(r).YAML()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "openapi: 3.0.3\n")
+		assert.Contains(t, string(data), "nullable: true")
+		assert.Contains(t, string(data), "x-webhooks:")
+
+		doc, err := DocumentFromYAML(data)
+		require.NoError(t, err)
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+	})
+}
+
+func TestConvertTo30Schema(t *testing.T) {
+	convert := func(t *testing.T, s *Schema) (map[string]any, []string) {
+		t.Helper()
+		doc := &Document{OpenAPI: OpenAPIVersion, Components: &Components{Schemas: map[string]*Schema{"S": s}}}
+		converted, err := convertTo30(doc, "3.0.3")
+		require.NoError(t, err)
+		data, err := json.Marshal(converted.Components.Schemas["S"])
+		require.NoError(t, err)
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(data, &out))
+		return out, converted.Warnings()
+	}
+
+	t.Run("type array without null", func(t *testing.T) {
+		out, warnings := convert(t, &Schema{Type: TypeArray("string", "integer", "null")})
+		assert.Equal(t, map[string]any{
+			"nullable": true,
+			"anyOf":    []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}},
+		}, out)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("null only", func(t *testing.T) {
+		out, warnings := convert(t, &Schema{Type: SchemaTypeNull})
+		assert.Equal(t, map[string]any{"nullable": true}, out)
+		assert.Equal(t, []string{"openapi 3.0.3: #/components/schemas/S/type: type null is not supported; emitted as nullable without a type"}, warnings)
+	})
+
+	t.Run("nullable oneOf", func(t *testing.T) {
+		out, _ := convert(t, &Schema{OneOf: []*Schema{
+			{Ref: "#/components/schemas/A"},
+			{Ref: "#/components/schemas/B"},
+			{Type: SchemaTypeNull},
+		}})
+		assert.Equal(t, map[string]any{
+			"nullable": true,
+			"oneOf": []any{
+				map[string]any{"$ref": "#/components/schemas/A"},
+				map[string]any{"$ref": "#/components/schemas/B"},
+			},
+		}, out)
+	})
+
+	t.Run("redundant exclusive bound", func(t *testing.T) {
+		minimum, exclusive := 10.0, 5.0
+		out, _ := convert(t, &Schema{Type: SchemaTypeNumber, Minimum: &minimum, ExclusiveMinimum: &exclusive})
+		assert.Equal(t, map[string]any{"type": "number", "minimum": float64(10)}, out)
+	})
+
+	t.Run("ref with siblings", func(t *testing.T) {
+		out, _ := convert(t, &Schema{Ref: "#/components/schemas/A", Description: "The A"})
+		assert.Equal(t, map[string]any{
+			"description": "The A",
+			"allOf":       []any{map[string]any{"$ref": "#/components/schemas/A"}},
+		}, out)
+	})
+
+	t.Run("examples and content", func(t *testing.T) {
+		out, warnings := convert(t, &Schema{ContentEncoding: "base64", Examples: []any{"YQ==", "Yg=="}})
+		assert.Equal(t, map[string]any{"type": "string", "format": "byte", "example": "YQ=="}, out)
+		assert.Equal(t, []string{"openapi 3.0.3: #/components/schemas/S/examples: only the first of 2 examples is kept as example"}, warnings)
+
+		out, _ = convert(t, &Schema{Type: SchemaTypeString, ContentMediaType: "image/png"})
+		assert.Equal(t, map[string]any{"type": "string", "format": "binary"}, out)
+	})
+
+	t.Run("unsupported keywords", func(t *testing.T) {
+		out, warnings := convert(t, &Schema{
+			Type:        SchemaTypeArray,
+			PrefixItems: []*Schema{{Type: SchemaTypeString}},
+			Items:       &Schema{Type: SchemaTypeInteger, If: &Schema{Type: SchemaTypeInteger}},
+		})
+		assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "integer"}}, out)
+		assert.Equal(t, []string{
+			"openapi 3.0.3: #/components/schemas/S: prefixItems is not supported; dropped",
+			"openapi 3.0.3: #/components/schemas/S/items: if/then/else is not supported; dropped",
+		}, warnings)
+	})
+}
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/base64"
@@ -10,7 +11,9 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"os"
 	"slices"
 	"strings"
 	"time"
@@ -40,6 +43,11 @@ type Dialer struct {
 	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
 
 	// Proxy specifies a function to return a proxy for a given request.
+	// The request URL carries the target with the ws and wss schemes mapped
+	// to http and https, so http.ProxyFromEnvironment and http.ProxyURL work
+	// as-is. Both schemes are tunneled with HTTP CONNECT; for wss the TLS
+	// handshake with the target runs inside the tunnel. Credentials in the
+	// proxy URL are sent as Proxy-Authorization basic auth.
 	// Takes precedence over HTTPClient.Transport.(*http.Transport).Proxy.
 	// If nil, falls back to the transport's Proxy function.
 	Proxy func(*http.Request) (*url.URL, error)
@@ -53,11 +61,20 @@ type Dialer struct {
 	// If zero, no timeout is applied.
 	HandshakeTimeout time.Duration
 
+	// Trace, when set, receives the DNS, connect, TLS, and upgrade phases
+	// of each handshake. See HandshakeTrace.
+	Trace *HandshakeTrace
+
 	// ReadBufferSize and WriteBufferSize specify I/O buffer sizes in bytes.
 	ReadBufferSize  int
 	WriteBufferSize int
 
-	// WriteBufferPool is a pool of buffers for write operations.
+	// WriteBufferPool is a pool of buffers for write operations. When set,
+	// a connection borrows a buffer only for the duration of each frame
+	// write and returns it afterwards, so idle connections hold no write
+	// buffer. Buffers are put into the pool as *[]byte values. A *sync.Pool
+	// satisfies this interface. The pool should only be shared by
+	// connections using the same WriteBufferSize.
 	WriteBufferPool BufferPool
 
 	// Subprotocols specifies the client's requested subprotocols.
@@ -74,6 +91,27 @@ type Dialer struct {
 	// MaxFrameSize sets the maximum payload size in bytes for a single
 	// WebSocket frame. Zero disables the limit.
 	MaxFrameSize int64
+
+	// EnableHTTP2 lets wss:// connections use WebSocket over HTTP/2
+	// (RFC 8441). The client offers h2 through TLS ALPN; when the server
+	// selects it and advertises SETTINGS_ENABLE_CONNECT_PROTOCOL, the
+	// connection is opened with an extended CONNECT request carrying
+	// :protocol=websocket. If the server does not negotiate h2, the
+	// HTTP/1.1 handshake runs on the same connection; if the HTTP/2
+	// attempt fails before the server responds, for example because
+	// extended CONNECT is not enabled, the dialer retries over a new
+	// HTTP/1.1 connection. A response other than 200 from an HTTP/2
+	// server is a handshake failure, as with HTTP/1.1. It applies to
+	// direct connections only; proxied and ws:// connections always use
+	// HTTP/1.1.
+	EnableHTTP2 bool
+
+	// BindContext ties the connection to the context passed to DialContext
+	// for its whole lifetime: when the context is done, the connection is
+	// closed and pending and later reads and writes fail with an error
+	// wrapping the context's cause. By default the context only bounds the
+	// handshake.
+	BindContext bool
 }
 
 // Dial creates a new client connection to the WebSocket server.
@@ -84,6 +122,14 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (*Conn, *http.Re
 // DialContext creates a new client connection with the provided context.
 // This implements the client-side opening handshake per RFC 6455, section 4.1,
 // and RFC 8441 for HTTP/2 WebSocket bootstrapping.
+//
+// Cancelling ctx aborts the TCP connect, the proxy CONNECT exchange, the TLS
+// handshake, and the HTTP upgrade exchange, in which case ctx.Err() is
+// returned. Once the connection is established, ctx no longer affects it
+// unless BindContext is set; its values remain available from Conn.Context.
+//
+// Handshake phases are reported to Trace and to an httptrace.ClientTrace in
+// ctx; see HandshakeTrace.
 func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (*Conn, *http.Response, error) {
 	u, err := url.Parse(urlStr)
 	if err != nil {
@@ -111,16 +157,20 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 	var conn *Conn
 	var resp *http.Response
 
+	// The trace hooks only apply to the handshake, so the connection keeps
+	// the caller's ctx.
+	dialCtx := d.Trace.withClientTrace(ctx)
+
 	// Check if transport is HTTP/2.
 	if d.isHTTP2(client) {
-		conn, resp, err = d.dialHTTP2(ctx, client, u, requestHeader)
+		conn, resp, err = d.dialHTTP2(dialCtx, client, u, requestHeader)
 	} else if proxyURL := d.getProxyURL(u); proxyURL != nil {
 		// Check if proxy is configured - need special handling for WebSocket.
-		conn, resp, err = d.dialWithProxy(ctx, u, proxyURL, requestHeader)
+		conn, resp, err = d.dialWithProxy(dialCtx, u, proxyURL, requestHeader)
 	} else {
 		// Direct dial with raw net.Conn, preserving the connection for
 		// LocalAddr, RemoteAddr, UnderlyingConn, and deadline access.
-		conn, resp, err = d.dialDirect(ctx, u, requestHeader)
+		conn, resp, err = d.dialDirect(dialCtx, u, requestHeader)
 	}
 
 	if err != nil {
@@ -129,6 +179,7 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 	if d.MaxFrameSize > 0 {
 		conn.SetMaxFrameSize(d.MaxFrameSize)
 	}
+	conn.setContext(ctx, d.BindContext)
 	return conn, resp, nil
 }
 
@@ -194,8 +245,10 @@ func (d *Dialer) tlsConfig(serverName string) *tls.Config {
 
 // dial establishes a TCP or TLS connection using the resolution chain:
 // TLS fast-path (transport.DialTLSContext), then TCP dial (d.NetDialContext ->
-// transport.DialContext -> net.Dialer), then TLS handshake if needed.
-func (d *Dialer) dial(ctx context.Context, isTLS bool, hostPort, serverName string) (net.Conn, error) {
+// transport.DialContext -> net.Dialer), then TLS handshake if needed. When
+// nextProtos is not empty it replaces the ALPN protocols offered in the TLS
+// handshake.
+func (d *Dialer) dial(ctx context.Context, isTLS bool, hostPort, serverName string, nextProtos []string) (net.Conn, error) {
 	var transport *http.Transport
 	if d.HTTPClient != nil {
 		transport, _ = d.HTTPClient.Transport.(*http.Transport)
@@ -229,8 +282,11 @@ func (d *Dialer) dial(ctx context.Context, isTLS bool, hostPort, serverName stri
 
 	// TLS handshake.
 	tlsConf := d.tlsConfig(serverName)
+	if len(nextProtos) > 0 {
+		tlsConf.NextProtos = nextProtos
+	}
 	tlsConn := tls.Client(netConn, tlsConf)
-	if err := tlsConn.HandshakeContext(ctx); err != nil {
+	if err := tlsHandshake(ctx, tlsConn); err != nil {
 		netConn.Close()
 		return nil, err
 	}
@@ -238,16 +294,44 @@ func (d *Dialer) dial(ctx context.Context, isTLS bool, hostPort, serverName stri
 }
 
 // dialDirect establishes a WebSocket connection by dialing TCP directly,
-// preserving the net.Conn for address and deadline access.
+// preserving the net.Conn for address and deadline access. With EnableHTTP2
+// set, wss:// connections offer h2 through ALPN and use RFC 8441 when the
+// server selects it.
 func (d *Dialer) dialDirect(ctx context.Context, u *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
 	hostPort := hostPortFromURL(u)
 	isTLS := u.Scheme == "https"
 
-	netConn, err := d.dial(ctx, isTLS, hostPort, u.Hostname())
+	var nextProtos []string
+	if isTLS && d.EnableHTTP2 {
+		nextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+	}
+
+	netConn, err := d.dial(ctx, isTLS, hostPort, u.Hostname(), nextProtos)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if nextProtos != nil && negotiatedProtocol(netConn) == http2.NextProtoTLS {
+		conn, resp, err := d.dialHTTP2Conn(ctx, netConn, u, requestHeader)
+		if err == nil || resp != nil || ctx.Err() != nil || errors.Is(err, os.ErrDeadlineExceeded) {
+			return conn, resp, err
+		}
+
+		// The server speaks HTTP/2 but the extended CONNECT request
+		// failed before a response, typically because the server does not
+		// advertise SETTINGS_ENABLE_CONNECT_PROTOCOL. Retry over HTTP/1.1.
+		netConn, err = d.dial(ctx, isTLS, hostPort, u.Hostname(), []string{"http/1.1"})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return d.handshakeHTTP1(ctx, netConn, u, requestHeader)
+}
+
+// handshakeHTTP1 runs the HTTP/1.1 opening handshake on a freshly dialed
+// connection, applying HandshakeTimeout, and closes netConn on failure.
+func (d *Dialer) handshakeHTTP1(ctx context.Context, netConn net.Conn, u *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
 	if d.HandshakeTimeout > 0 {
 		deadline := time.Now().Add(d.HandshakeTimeout)
 		if err := netConn.SetDeadline(deadline); err != nil {
@@ -272,6 +356,36 @@ func (d *Dialer) dialDirect(ctx context.Context, u *url.URL, requestHeader http.
 	return conn, resp, nil
 }
 
+// negotiatedProtocol returns the ALPN protocol selected on a TLS connection,
+// or an empty string for other connections.
+func negotiatedProtocol(netConn net.Conn) string {
+	if tc, ok := netConn.(interface{ ConnectionState() tls.ConnectionState }); ok {
+		return tc.ConnectionState().NegotiatedProtocol
+	}
+	return ""
+}
+
+// dialHTTP2Conn opens a WebSocket stream per RFC 8441 on a TLS connection
+// that negotiated h2. The HTTP/2 connection is dedicated to the WebSocket
+// and is closed together with it. netConn is closed on failure.
+func (d *Dialer) dialHTTP2Conn(ctx context.Context, netConn net.Conn, u *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
+	cc, err := (&http2.Transport{}).NewClientConn(netConn)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	conn, resp, err := d.dialHTTP2(ctx, &http.Client{Transport: cc}, u, requestHeader)
+	if err != nil {
+		cc.Close()
+		return nil, resp, err
+	}
+	if stream, ok := conn.rwc.(*http2ClientStream); ok {
+		stream.closeFns = append(stream.closeFns, cc.Close)
+	}
+	return conn, resp, nil
+}
+
 // dialWithProxy establishes a WebSocket connection through an HTTP proxy.
 func (d *Dialer) dialWithProxy(ctx context.Context, u *url.URL, proxyURL *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
 	var deadline time.Time
@@ -357,14 +471,16 @@ func (d *Dialer) dialProxy(ctx context.Context, proxyURL *url.URL, targetURL *ur
 		connectReq.Header.Set("Proxy-Authorization", fmt.Sprintf("Basic %s", auth))
 	}
 
-	if err := connectReq.Write(proxyConn); err != nil {
-		proxyConn.Close()
-		return nil, err
+	release := interruptOnDone(ctx, proxyConn)
+	var resp *http.Response
+	err = connectReq.Write(proxyConn)
+	if err == nil {
+		// Read proxy response.
+		resp, err = http.ReadResponse(bufio.NewReader(proxyConn), connectReq)
+	}
+	if ctxErr := release(); ctxErr != nil {
+		err = ctxErr
 	}
-
-	// Read proxy response.
-	br := bufio.NewReader(proxyConn)
-	resp, err := http.ReadResponse(br, connectReq)
 	if err != nil {
 		proxyConn.Close()
 		return nil, err
@@ -381,7 +497,7 @@ func (d *Dialer) dialProxy(ctx context.Context, proxyURL *url.URL, targetURL *ur
 		tlsConf := d.tlsConfig(targetURL.Hostname())
 
 		tlsConn := tls.Client(proxyConn, tlsConf)
-		if err := tlsConn.HandshakeContext(ctx); err != nil {
+		if err := tlsHandshake(ctx, tlsConn); err != nil {
 			proxyConn.Close()
 			return nil, err
 		}
@@ -393,7 +509,7 @@ func (d *Dialer) dialProxy(ctx context.Context, proxyURL *url.URL, targetURL *ur
 
 // doHandshake performs the client-side opening handshake per RFC 6455, section 4.1.
 // Used for connections established via raw net.Conn (direct dial and proxy paths).
-func (d *Dialer) doHandshake(_ context.Context, netConn net.Conn, u *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
+func (d *Dialer) doHandshake(ctx context.Context, netConn net.Conn, u *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
 	challengeKey, err := generateChallengeKey()
 	if err != nil {
 		return nil, nil, err
@@ -417,12 +533,34 @@ func (d *Dialer) doHandshake(_ context.Context, netConn net.Conn, u *url.URL, re
 		}
 	}
 
-	if err := req.Write(netConn); err != nil {
-		return nil, nil, err
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: netConn})
 	}
 
+	release := interruptOnDone(ctx, netConn)
 	br := bufio.NewReader(netConn)
-	resp, err := http.ReadResponse(br, req)
+	var resp *http.Response
+	err = req.Write(netConn)
+	if trace != nil {
+		if trace.WroteHeaders != nil && err == nil {
+			trace.WroteHeaders()
+		}
+		if trace.WroteRequest != nil {
+			trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+		}
+	}
+	if err == nil {
+		if trace != nil && trace.GotFirstResponseByte != nil {
+			if _, peekErr := br.Peek(1); peekErr == nil {
+				trace.GotFirstResponseByte()
+			}
+		}
+		resp, err = http.ReadResponse(br, req)
+	}
+	if ctxErr := release(); ctxErr != nil {
+		err = ctxErr
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -435,7 +573,9 @@ func (d *Dialer) doHandshake(_ context.Context, netConn net.Conn, u *url.URL, re
 
 	subprotocol, compress, err := d.validateHTTP1Response(resp, challengeKey)
 	if err != nil {
-		defer resp.Body.Close()
+		release := interruptOnDone(ctx, netConn)
+		bufferErrorBody(resp)
+		_ = release()
 		return nil, resp, err
 	}
 
@@ -443,6 +583,8 @@ func (d *Dialer) doHandshake(_ context.Context, netConn net.Conn, u *url.URL, re
 	// The WebSocket Conn now owns the stream; resp is only useful for reading headers.
 	resp.Body = http.NoBody
 
+	d.Trace.gotSwitchingProtocols(resp)
+
 	conn := newConnWithPool(netConn, false, d.ReadBufferSize, d.WriteBufferSize, d.WriteBufferPool)
 	conn.subprotocol = subprotocol
 	conn.compressionEnabled = compress
@@ -528,23 +670,45 @@ func (d *Dialer) validateHTTP1Response(resp *http.Response, challengeKey string)
 
 // dialHTTP2 establishes a WebSocket connection over HTTP/2 per RFC 8441.
 // RFC 8441 defines bootstrapping WebSockets with HTTP/2 using extended CONNECT.
+//
+// The request body is a pipe that carries frames to the server and the
+// response body carries frames from it. A transport that returns a response
+// body which is itself writable is used as is.
 func (d *Dialer) dialHTTP2(ctx context.Context, client *http.Client, u *url.URL, requestHeader http.Header) (*Conn, *http.Response, error) {
 	// Build the request with extended CONNECT method per RFC 8441, section 4.
-	// The :protocol pseudo-header is set to "websocket".
+	// The :protocol pseudo-header is set to "websocket"; the x/net HTTP/2
+	// transport reads it from the ":protocol" header entry.
+	pr, pw := io.Pipe()
 	req := &http.Request{
 		Method: http.MethodConnect,
 		URL:    u,
 		Host:   u.Host,
 		Proto:  "websocket", // :protocol pseudo-header value
 		Header: make(http.Header),
+		Body:   pr,
+	}
+
+	// The stream outlives the handshake, so cancelling ctx only aborts the
+	// request until the response arrives.
+	reqCtx, cancelCause := context.WithCancelCause(context.WithoutCancel(ctx))
+	cancel := func() { cancelCause(nil) }
+	stop := context.AfterFunc(ctx, cancel)
+	if d.HandshakeTimeout > 0 {
+		timer := time.AfterFunc(d.HandshakeTimeout, func() { cancelCause(os.ErrDeadlineExceeded) })
+		defer timer.Stop()
 	}
-	req = req.WithContext(ctx)
+	req = req.WithContext(reqCtx)
 
 	// Copy request headers directly to preserve original key casing (see buildHandshakeHeaders).
 	for k, vs := range requestHeader {
 		req.Header[k] = append(req.Header[k], vs...)
 	}
 
+	req.Header[":protocol"] = []string{"websocket"}
+
+	// RFC 8441, section 5: Sec-WebSocket-Version is sent as in RFC 6455.
+	req.Header.Set("Sec-WebSocket-Version", websocketVersion)
+
 	if len(d.Subprotocols) > 0 {
 		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
 	}
@@ -560,10 +724,26 @@ func (d *Dialer) dialHTTP2(ctx context.Context, client *http.Client, u *url.URL,
 		}
 	}
 
+	fail := func(resp *http.Response, err error) (*Conn, *http.Response, error) {
+		stop()
+		cancel()
+		pw.Close()
+		return nil, resp, err
+	}
+
 	// Send the request.
 	resp, err := client.Do(req)
+	if !stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return fail(nil, ctx.Err())
+	}
 	if err != nil {
-		return nil, nil, err
+		if cause := context.Cause(reqCtx); errors.Is(cause, os.ErrDeadlineExceeded) {
+			err = cause
+		}
+		return fail(nil, err)
 	}
 
 	if d.Jar != nil {
@@ -574,8 +754,8 @@ func (d *Dialer) dialHTTP2(ctx context.Context, client *http.Client, u *url.URL,
 
 	// RFC 8441, section 4: Response should be 200 OK for successful upgrade.
 	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, resp, ErrBadHandshake
+		bufferErrorBody(resp)
+		return fail(resp, ErrBadHandshake)
 	}
 
 	// Validate subprotocol per RFC 6455, section 4.1: if the server sends a
@@ -586,7 +766,8 @@ func (d *Dialer) dialHTTP2(ctx context.Context, client *http.Client, u *url.URL,
 	if subprotocol != "" {
 		if len(d.Subprotocols) == 0 || !slices.Contains(d.Subprotocols, subprotocol) {
 			resp.Body.Close()
-			return nil, resp, ErrBadHandshake
+			resp.Body = http.NoBody
+			return fail(resp, ErrBadHandshake)
 		}
 	}
 
@@ -600,14 +781,23 @@ func (d *Dialer) dialHTTP2(ctx context.Context, client *http.Client, u *url.URL,
 			continue
 		}
 		resp.Body.Close()
-		return nil, resp, ErrBadHandshake
+		resp.Body = http.NoBody
+		return fail(resp, ErrBadHandshake)
 	}
 
+	d.Trace.gotSwitchingProtocols(resp)
+
 	// Create a connection wrapper around the response body.
-	rwc, ok := resp.Body.(io.ReadWriteCloser)
-	if !ok {
-		resp.Body.Close()
-		return nil, resp, errors.New("websocket: response body is not ReadWriteCloser")
+	var rwc io.ReadWriteCloser
+	if body, ok := resp.Body.(io.ReadWriteCloser); ok {
+		pw.Close()
+		rwc = body
+	} else {
+		rwc = &http2ClientStream{
+			body:     resp.Body,
+			pw:       pw,
+			closeFns: []func() error{func() error { cancel(); return nil }},
+		}
 	}
 
 	conn := newConnFromRWC(connConfig{
@@ -622,6 +812,65 @@ func (d *Dialer) dialHTTP2(ctx context.Context, client *http.Client, u *url.URL,
 	return conn, resp, nil
 }
 
+// http2ClientStream is the client end of an RFC 8441 WebSocket stream:
+// frames are read from the response body and written to the request body.
+type http2ClientStream struct {
+	body     io.ReadCloser
+	pw       *io.PipeWriter
+	closeFns []func() error
+}
+
+func (s *http2ClientStream) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+func (s *http2ClientStream) Write(p []byte) (int, error) {
+	return s.pw.Write(p)
+}
+
+// Close ends the request stream, closes the response body, and releases
+// the HTTP/2 connection when it is dedicated to this stream.
+func (s *http2ClientStream) Close() error {
+	err := s.pw.Close()
+	if bErr := s.body.Close(); err == nil {
+		err = bErr
+	}
+	for _, fn := range s.closeFns {
+		if fErr := fn(); err == nil {
+			err = fErr
+		}
+	}
+	return err
+}
+
+// maxErrorBodySize limits how much of a rejected handshake response body is
+// kept for the caller.
+const maxErrorBodySize = 64 << 10
+
+// bufferErrorBody replaces resp.Body with an in-memory copy of up to
+// maxErrorBodySize bytes and closes the original, so the body of a rejected
+// handshake remains readable after the connection is closed.
+func bufferErrorBody(resp *http.Response) {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+}
+
+// interruptOnDone expires the deadline of netConn when ctx is done, so that a
+// blocked handshake read or write returns promptly. The returned function
+// detaches the watcher and reports ctx.Err() if the context fired.
+func interruptOnDone(ctx context.Context, netConn net.Conn) func() error {
+	stop := context.AfterFunc(ctx, func() {
+		_ = netConn.SetDeadline(time.Unix(1, 0))
+	})
+	return func() error {
+		if !stop() {
+			return ctx.Err()
+		}
+		return nil
+	}
+}
+
 // hostPortFromURL returns host:port from URL, adding default port if needed.
 func hostPortFromURL(u *url.URL) string {
 	if u.Port() != "" {
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
@@ -11,7 +12,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"os/exec"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -403,6 +407,95 @@ func TestDialerContextTimeout(t *testing.T) {
 	})
 }
 
+func TestDialerContextCancelHandshake(t *testing.T) {
+	// silentListener accepts connections and never responds, stalling the
+	// HTTP exchange until the client gives up.
+	silentListener := func(t *testing.T) net.Listener {
+		t.Helper()
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				c, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer c.Close()
+					_, _ = io.Copy(io.Discard, c)
+				}()
+			}
+		}()
+		return ln
+	}
+
+	t.Run("Cancel during upgrade exchange", func(t *testing.T) {
+		ln := silentListener(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, _, err := (&Dialer{}).DialContext(ctx, "ws://"+ln.Addr().String(), nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Deadline during proxy CONNECT", func(t *testing.T) {
+		ln := silentListener(t)
+		proxyURL := &url.URL{Scheme: "http", Host: ln.Addr().String()}
+
+		d := &Dialer{
+			Proxy: func(_ *http.Request) (*url.URL, error) { return proxyURL, nil },
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, _, err := d.DialContext(ctx, "wss://example.com/ws", nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Already canceled context", func(t *testing.T) {
+		ln := silentListener(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, err := (&Dialer{}).DialContext(ctx, "ws://"+ln.Addr().String(), nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Context does not affect established connection", func(t *testing.T) {
+		upgrader := &Upgrader{CheckOrigin: func(_ *http.Request) bool { return true }}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(msgType, msg)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		conn, _, err := (&Dialer{}).DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		cancel()
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("still open")))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("still open"), msg)
+	})
+}
+
 func TestDialerWithTLSServer(t *testing.T) {
 	upgrader := &Upgrader{
 		CheckOrigin: func(_ *http.Request) bool { return true },
@@ -990,10 +1083,50 @@ func TestDialerDialHTTP2(t *testing.T) {
 			}),
 		}
 
+		// HTTP/2 transports return a read-only body and carry client data
+		// on the request body, so the body is wrapped instead of rejected.
 		u, _ := url.Parse("https://example.com/ws")
-		_, _, err := d.dialHTTP2(context.Background(), httpClient, u, nil)
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "not ReadWriteCloser")
+		conn, _, err := d.dialHTTP2(context.Background(), httpClient, u, nil)
+		require.NoError(t, err)
+
+		stream, ok := conn.rwc.(*http2ClientStream)
+		require.True(t, ok)
+		assert.NoError(t, stream.Close())
+	})
+
+	t.Run("Read-only body writes to request stream", func(t *testing.T) {
+		sent := make(chan []byte, 1)
+		d := &Dialer{}
+		httpClient := &http.Client{
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, []string{"websocket"}, req.Header[":protocol"])
+				assert.Equal(t, "13", req.Header.Get("Sec-WebSocket-Version"))
+				go func() {
+					data, _ := io.ReadAll(req.Body)
+					sent <- data
+				}()
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader("")),
+				}, nil
+			}),
+		}
+
+		u, _ := url.Parse("https://example.com/ws")
+		conn, _, err := d.dialHTTP2(context.Background(), httpClient, u, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("hi")))
+		require.NoError(t, conn.Close())
+
+		mock := newMockConn()
+		mock.readBuf.Write(<-sent)
+		peer := newConn(mock, true, 0, 0)
+		msgType, p, err := peer.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, []byte("hi"), p)
 	})
 
 	t.Run("With compression", func(t *testing.T) {
@@ -1435,6 +1568,44 @@ func TestDialerProxyField(t *testing.T) {
 	})
 }
 
+func TestDialerProxyConsulted(t *testing.T) {
+	upgrader := &Upgrader{
+		CheckOrigin: func(_ *http.Request) bool { return true },
+	}
+
+	wsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer wsServer.Close()
+
+	proxyServer := newTestCONNECTProxy(t)
+	defer proxyServer.Close()
+
+	proxyURL, _ := url.Parse(proxyServer.URL)
+	wsURL := fmt.Sprintf("ws%s/chat", strings.TrimPrefix(wsServer.URL, "http"))
+
+	var seen []*url.URL
+	d := &Dialer{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			seen = append(seen, req.URL)
+			return proxyURL, nil
+		},
+	}
+
+	conn, _, err := d.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Len(t, seen, 1)
+	assert.Equal(t, "http", seen[0].Scheme)
+	assert.Equal(t, strings.TrimPrefix(wsServer.URL, "http://"), seen[0].Host)
+	assert.Equal(t, "/chat", seen[0].Path)
+}
+
 func TestDialerDefaultPathPreservesNetConn(t *testing.T) {
 	upgrader := &Upgrader{
 		CheckOrigin: func(_ *http.Request) bool { return true },
@@ -2517,3 +2688,357 @@ func TestDoHandshakeRandError(t *testing.T) {
 	_, _, err := d.Dial("ws://example.com", nil)
 	require.ErrorIs(t, err, testErr)
 }
+
+func TestDialerBindContext(t *testing.T) {
+	upgrader := &Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, p); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	wsURL := fmt.Sprintf("ws%s", strings.TrimPrefix(server.URL, "http"))
+
+	t.Run("Context bounds only the handshake by default", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		conn, _, err := (&Dialer{}).DialContext(ctx, wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		cancel()
+		assert.NoError(t, conn.Context().Err())
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("hello")))
+		_, p, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(p))
+	})
+
+	t.Run("Bound context cancel unblocks read", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "v"))
+		conn, _, err := (&Dialer{BindContext: true}).DialContext(ctx, wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, "v", conn.Context().Value(ctxKey{}))
+
+		time.AfterFunc(20*time.Millisecond, cancel)
+		_, _, err = conn.ReadMessage()
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Context of connection without origin", func(t *testing.T) {
+		conn := newConn(newMockConn(), false, 0, 0)
+		assert.Equal(t, context.Background(), conn.Context())
+	})
+}
+
+func TestDialerHandshakeResponse(t *testing.T) {
+	upgrader := &Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "ok" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		h := http.Header{}
+		h.Set("X-Session-Id", "sess-42")
+		h.Add("Set-Cookie", "session=abc; Path=/")
+		conn, err := upgrader.Upgrade(w, r, h)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	wsURL := fmt.Sprintf("ws%s", strings.TrimPrefix(server.URL, "http"))
+
+	t.Run("Response headers accessible", func(t *testing.T) {
+		conn, resp, err := (&Dialer{}).Dial(wsURL+"?token=ok", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, "sess-42", resp.Header.Get("X-Session-Id"))
+		require.Len(t, resp.Cookies(), 1)
+		assert.Equal(t, "abc", resp.Cookies()[0].Value)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Empty(t, body)
+	})
+
+	t.Run("Failure exposes error body", func(t *testing.T) {
+		conn, resp, err := (&Dialer{}).Dial(wsURL, nil)
+		assert.ErrorIs(t, err, ErrBadHandshake)
+		assert.Nil(t, conn)
+
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "invalid token\n", string(body))
+		assert.NoError(t, resp.Body.Close())
+	})
+}
+
+func TestBufferErrorBody(t *testing.T) {
+	t.Run("Body limited", func(t *testing.T) {
+		resp := &http.Response{Body: io.NopCloser(bytes.NewReader(make([]byte, maxErrorBodySize+10)))}
+		bufferErrorBody(resp)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Len(t, body, maxErrorBodySize)
+	})
+
+	t.Run("Original body closed", func(t *testing.T) {
+		rc := &closeTrackingBody{Reader: strings.NewReader("denied")}
+		resp := &http.Response{Body: rc}
+		bufferErrorBody(resp)
+
+		assert.True(t, rc.closed)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "denied", string(body))
+	})
+}
+
+type closeTrackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// newHTTP2EchoServer starts a TLS server with HTTP/2 enabled whose handler
+// echoes WebSocket messages and reports the protocol of each upgrade.
+func newHTTP2EchoServer(t *testing.T) (*httptest.Server, <-chan int) {
+	t.Helper()
+	protos := make(chan int, 4)
+	upgrader := &Upgrader{CheckOrigin: func(_ *http.Request) bool { return true }}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		protos <- r.ProtoMajor
+		for {
+			msgType, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, p); err != nil {
+				return
+			}
+		}
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, protos
+}
+
+func http2TestDialer(server *httptest.Server) *Dialer {
+	transport := server.Client().Transport.(*http.Transport)
+	return &Dialer{
+		EnableHTTP2:     true,
+		TLSClientConfig: transport.TLSClientConfig,
+	}
+}
+
+func assertEcho(t *testing.T, conn *Conn) {
+	t.Helper()
+	require.NoError(t, conn.WriteMessage(TextMessage, []byte("ping")))
+	msgType, p, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, TextMessage, msgType)
+	assert.Equal(t, []byte("ping"), p)
+}
+
+func TestDialerEnableHTTP2(t *testing.T) {
+	t.Run("Falls back to HTTP/1.1 without extended CONNECT", func(t *testing.T) {
+		if strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1") {
+			t.Skip("extended CONNECT enabled in this process")
+		}
+		server, protos := newHTTP2EchoServer(t)
+
+		wsURL := "wss" + strings.TrimPrefix(server.URL, "https")
+		conn, resp, err := http2TestDialer(server).Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, 1, <-protos)
+		assertEcho(t, conn)
+	})
+
+	t.Run("HTTP/1.1 server", func(t *testing.T) {
+		upgrader := &Upgrader{CheckOrigin: func(_ *http.Request) bool { return true }}
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, p, _ := conn.ReadMessage()
+			_ = conn.WriteMessage(TextMessage, p)
+		}))
+		defer server.Close()
+
+		wsURL := "wss" + strings.TrimPrefix(server.URL, "https")
+		conn, _, err := http2TestDialer(server).Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assertEcho(t, conn)
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		server, protos := newHTTP2EchoServer(t)
+
+		d := http2TestDialer(server)
+		d.EnableHTTP2 = false
+		conn, _, err := d.Dial("wss"+strings.TrimPrefix(server.URL, "https"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, 1, <-protos)
+	})
+}
+
+// TestDialerHTTP2ExtendedConnect runs against a server that advertises
+// SETTINGS_ENABLE_CONNECT_PROTOCOL. HTTP/2 servers only do so with
+// GODEBUG=http2xconnect=1, which is read at program start, so the test
+// re-runs itself in a child process with the setting.
+func TestDialerHTTP2ExtendedConnect(t *testing.T) {
+	if !strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1") {
+		if testing.Short() {
+			t.Skip("requires a child test process")
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDialerHTTP2ExtendedConnect$", "-test.v")
+		godebug := "http2xconnect=1"
+		if v := os.Getenv("GODEBUG"); v != "" {
+			godebug = v + "," + godebug
+		}
+		cmd.Env = append(os.Environ(), "GODEBUG="+godebug)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		require.NotContains(t, string(out), "no tests to run")
+		require.Contains(t, string(out), "--- PASS: TestDialerHTTP2ExtendedConnect")
+		return
+	}
+
+	t.Run("Uses HTTP/2", func(t *testing.T) {
+		server, protos := newHTTP2EchoServer(t)
+
+		d := http2TestDialer(server)
+		d.Subprotocols = []string{"chat"}
+		conn, resp, err := d.Dial("wss"+strings.TrimPrefix(server.URL, "https"), nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, resp.ProtoMajor)
+		assert.Equal(t, 2, <-protos)
+		assert.Nil(t, conn.UnderlyingConn())
+
+		assertEcho(t, conn)
+		assertEcho(t, conn)
+		require.NoError(t, conn.Close())
+	})
+
+	t.Run("Compression over HTTP/2", func(t *testing.T) {
+		protos := make(chan int, 1)
+		upgrader := &Upgrader{
+			CheckOrigin:       func(_ *http.Request) bool { return true },
+			EnableCompression: true,
+		}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			protos <- r.ProtoMajor
+			msgType, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(msgType, p)
+			_, _, _ = conn.ReadMessage()
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		d := http2TestDialer(server)
+		d.EnableCompression = true
+		conn, _, err := d.Dial("wss"+strings.TrimPrefix(server.URL, "https"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, 2, <-protos)
+		assert.True(t, conn.compressionEnabled)
+		assertEcho(t, conn)
+	})
+
+	t.Run("Rejected handshake is not retried", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			attempts.Add(1)
+			http.Error(w, "no", http.StatusForbidden)
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		_, resp, err := http2TestDialer(server).Dial("wss"+strings.TrimPrefix(server.URL, "https"), nil)
+		assert.ErrorIs(t, err, ErrBadHandshake)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "no\n", string(body))
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("Server closes stream", func(t *testing.T) {
+		upgrader := &Upgrader{CheckOrigin: func(_ *http.Request) bool { return true }}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			_ = conn.CloseWithMessage(CloseGoingAway, "bye")
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		conn, _, err := http2TestDialer(server).Dial("wss"+strings.TrimPrefix(server.URL, "https"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, _, err = conn.ReadMessage()
+		assert.True(t, IsCloseError(err, CloseGoingAway))
+	})
+}
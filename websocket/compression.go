@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"bytes"
 	"compress/flate"
 	"io"
 	"sync"
@@ -60,9 +61,15 @@ type compressedReader struct {
 	r  io.Reader
 }
 
+// finalBlock is an empty final stored DEFLATE block (RFC 1951, section 3.2.4).
+// Senders terminate messages with a sync flush rather than a final block, so
+// it is appended after the RFC 7692 suffix to let the decompressor reach a
+// clean io.EOF instead of io.ErrUnexpectedEOF.
+var finalBlock = []byte{0x01, 0x00, 0x00, 0xff, 0xff}
+
 func newCompressedReader(r io.Reader) *compressedReader {
 	return &compressedReader{
-		r: io.MultiReader(r, newSuffixReader()),
+		r: io.MultiReader(r, newSuffixReader(), bytes.NewReader(finalBlock)),
 	}
 }
 
@@ -133,8 +140,11 @@ func (cw *compressedWriter) Write(p []byte) (int, error) {
 }
 
 func (cw *compressedWriter) Close() error {
+	// Flush rather than Close the flate writer: a sync flush always ends with
+	// the empty stored block 0x00 0x00 0xff 0xff, whereas the final block
+	// emitted by Close is encoder-dependent.
 	if cw.fw != nil {
-		if err := cw.fw.Close(); err != nil {
+		if err := cw.fw.Flush(); err != nil {
 			return err
 		}
 		cw.fw = nil
@@ -276,7 +276,7 @@ func TestFlateReadWrapper(t *testing.T) {
 		require.NoError(t, err)
 
 		br := &byteReader{data: compressed}
-		suffixed := io.MultiReader(br, newSuffixReader())
+		suffixed := io.MultiReader(br, newSuffixReader(), bytes.NewReader(finalBlock))
 		fr := getFlateReader(suffixed)
 		w := &flateReadWrapper{fr: fr}
 
@@ -292,7 +292,7 @@ func TestFlateReadWrapper(t *testing.T) {
 		require.NoError(t, err)
 
 		br := &byteReader{data: compressed}
-		suffixed := io.MultiReader(br, newSuffixReader())
+		suffixed := io.MultiReader(br, newSuffixReader(), bytes.NewReader(finalBlock))
 		fr := getFlateReader(suffixed)
 		w := &flateReadWrapper{fr: fr}
 
@@ -359,6 +359,71 @@ func TestCompressedWriterClose(t *testing.T) {
 	})
 }
 
+// TestCompressionInterop checks the permessage-deflate framing against
+// payloads produced by other implementations: the examples of RFC 7692,
+// section 7.2.3, and zlib (used by browsers) with Z_SYNC_FLUSH and the
+// trailing 0x00 0x00 0xff 0xff removed.
+func TestCompressionInterop(t *testing.T) {
+	t.Run("Decode peer payloads", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			payload []byte
+			want    []byte
+		}{
+			{
+				name:    "RFC 7692 compressed",
+				payload: []byte{0xf2, 0x48, 0xcd, 0xc9, 0xc9, 0x07, 0x00},
+				want:    []byte("Hello"),
+			},
+			{
+				name:    "RFC 7692 no compression",
+				payload: []byte{0x00, 0x05, 0x00, 0xfa, 0xff, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x00},
+				want:    []byte("Hello"),
+			},
+			{
+				name:    "RFC 7692 BFINAL set",
+				payload: []byte{0xf3, 0x48, 0xcd, 0xc9, 0xc9, 0x07, 0x00, 0x00},
+				want:    []byte("Hello"),
+			},
+			{
+				name: "zlib",
+				payload: []byte{
+					0xf2, 0x48, 0xcd, 0xc9, 0xc9, 0xd7, 0x51, 0x08, 0x4f, 0x4d, 0x0a, 0xce,
+					0x4f, 0xce, 0x4e, 0x2d, 0x51, 0x04, 0x00,
+				},
+				want: []byte("Hello, WebSocket!"),
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := decompressData(tt.payload)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, result)
+			})
+		}
+	})
+
+	t.Run("Encoded payload has no final block", func(t *testing.T) {
+		// A peer decodes the payload with only the RFC 7692 suffix appended
+		// and must not find the stream truncated or already terminated.
+		input := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog. "), 200)
+		for level := minCompressionLevel; level <= maxCompressionLevel; level++ {
+			compressed, err := compressData(input, level)
+			require.NoError(t, err)
+
+			fr := flate.NewReader(io.MultiReader(bytes.NewReader(compressed), newSuffixReader()))
+			result := make([]byte, len(input))
+			_, err = io.ReadFull(fr, result)
+			require.NoError(t, err, "level %d", level)
+			assert.Equal(t, input, result)
+
+			_, err = fr.Read(make([]byte, 1))
+			assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "level %d: stream must stay open for the next message", level)
+		}
+	})
+}
+
 func TestDecompressDataLimited(t *testing.T) {
 	t.Run("Within limit", func(t *testing.T) {
 		original := bytes.Repeat([]byte("a"), 100)
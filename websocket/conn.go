@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -66,12 +67,18 @@ var (
 	ErrExpectedContinuation      = errors.New("websocket: expected continuation frame")
 	ErrMaskViolation             = errors.New("websocket: mask bit violation")
 	ErrInvalidClosePayload       = errors.New("websocket: invalid close frame payload length")
+	ErrInvalidCloseReason        = errors.New("websocket: invalid UTF-8 in close reason")
 	ErrPayloadLengthOverflow     = errors.New("websocket: payload length overflow")
 	ErrInvalidUTF8               = errors.New("websocket: invalid UTF-8 in text message")
 	ErrDeadlineNotSupported      = errors.New("websocket: deadline not supported on this connection")
 	ErrMessageTypeForbidden      = errors.New("websocket: message type forbidden by policy")
 	ErrFrameSizeExceeded         = errors.New("websocket: frame payload exceeds size limit")
 	ErrNonEmptyPingPayload       = errors.New("websocket: non-empty ping payload not allowed")
+	ErrMessageDeadline           = errors.New("websocket: message deadline exceeded")
+	ErrTooManyFragments          = errors.New("websocket: too many message fragments")
+	ErrCloseTimeout              = errors.New("websocket: timed out waiting for peer close frame")
+	ErrReadTimeout               = errors.New("websocket: read timeout")
+	ErrUnexpectedMessageType     = errors.New("websocket: unexpected message type")
 )
 
 // CloseError represents a WebSocket close error.
@@ -84,6 +91,35 @@ func (e *CloseError) Error() string {
 	return fmt.Sprintf("websocket: close %s %s", closeCodeString(e.Code), e.Text)
 }
 
+// MessageTypeError is returned by ReadText and ReadBinary when the next
+// data message is not of the expected type. It unwraps to
+// ErrUnexpectedMessageType. The message has been consumed, so the
+// connection remains usable.
+type MessageTypeError struct {
+	Expected int
+	Got      int
+}
+
+func (e *MessageTypeError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", ErrUnexpectedMessageType,
+		messageTypeString(e.Expected), messageTypeString(e.Got))
+}
+
+// Unwrap returns ErrUnexpectedMessageType.
+func (e *MessageTypeError) Unwrap() error {
+	return ErrUnexpectedMessageType
+}
+
+func messageTypeString(messageType int) string {
+	switch messageType {
+	case TextMessage:
+		return "text"
+	case BinaryMessage:
+		return "binary"
+	}
+	return strconv.Itoa(messageType)
+}
+
 func closeCodeString(code int) string {
 	switch code {
 	case CloseNormalClosure:
@@ -119,10 +155,12 @@ func closeCodeString(code int) string {
 	}
 }
 
-// isValidCloseCode reports whether the close code is valid on the wire
-// per RFC 6455, section 7.4.1. Codes 1004, 1005, 1006, and 1015 must
-// never appear in a close frame sent by an endpoint.
-func isValidCloseCode(code int) bool {
+// IsValidCloseCode reports whether the close code may appear in a close
+// frame on the wire per RFC 6455, section 7.4.1: the registered codes 1000
+// to 1003 and 1007 to 1014, and the application range 3000 to 4999. Codes
+// 1004, 1005, 1006, and 1015 are reserved and must never be sent, and other
+// codes below 3000 are unassigned.
+func IsValidCloseCode(code int) bool {
 	switch {
 	case code >= 1000 && code <= 1003:
 		return true
@@ -203,7 +241,7 @@ type Conn struct {
 	subprotocol string
 	state       int32 // atomic: stateOpen, stateClosing, stateClosed
 
-	readMu       sync.Mutex
+	readMu       sync.Mutex // held by the goroutine reading frames
 	readLimit    int64
 	readMsgSize  int64 // accumulated message size across fragments
 	readErr      error
@@ -212,22 +250,57 @@ type Conn struct {
 	readMsgType  int
 	readFinal    bool
 	readCompress bool
-
-	writeMu         sync.Mutex
+	readIdle     bool         // last readFrame failed before consuming any byte
+	readDeadline atomic.Int64 // UnixNano of the deadline set via SetReadDeadline; 0 means none
+
+	// peerClosed is closed once a close frame has been read, waking a
+	// CloseGracefully call that waits for a concurrent reader.
+	peerClosed    chan struct{}
+	peerCloseOnce sync.Once
+
+	// Limits on fragmented messages; see SetMessageDeadline and
+	// SetMaxFragments. Guarded by the reading goroutine.
+	msgDeadline      time.Duration
+	maxFragments     int
+	readMsgEnd       time.Time // message deadline of the message being read; zero when not armed
+	readFragments    int       // data frames of the message being read
+	readCallDeadline int64     // UnixNano of the deadline set by ReadMessageTimeout; 0 means none
+
+	msgMu           sync.Mutex // serializes data messages across all of their frames
+	writeMu         sync.Mutex // serializes frames on the wire
 	writeErr        error
-	writeBuf        []byte
-	writePos        int
-	writeFrameType  int
+	writeBuf        []byte // allocated on first write; unused with a pool
+	writeBufferSize int
+	writeFrameType  int // guarded by msgMu
 	writeCompress   bool
+	writeFragSize   int
 	writeBufferPool BufferPool
 	pingHandler     func(appData string) error
+	autoPongOff     bool // see DisableAutoPong
 	pongHandler     func(appData string) error
 	closeHandler    func(code int, text string) error
 
 	compressionEnabled bool
 	compressionLevel   int
+	compressThreshold  int
+	skipCompress       func(messageType int, data []byte) bool
 	msgTypePolicy      MessageTypePolicy
 	maxFrameSize       int64
+
+	// Close frame sent when a message exceeds the read limit; see
+	// SetReadLimitError.
+	readLimitCode   int
+	readLimitText   string
+	readLimitCustom bool
+
+	writeQueue       atomic.Pointer[writeQueue]
+	writeQueuePolicy WriteQueuePolicy
+
+	ctx     context.Context // set by Upgrade and Dial; nil means context.Background
+	ctxDone atomic.Bool     // the bound context closed the connection
+	stopCtx func() bool     // detaches the bound context; nil when unbound
+
+	stats connStats
 }
 
 type connConfig struct {
@@ -271,16 +344,6 @@ func newConnFromRWC(cfg connConfig) *Conn {
 		writeBufferSize = defaultWriteBufferSize
 	}
 
-	var writeBuf []byte
-	if cfg.writeBufferPool != nil {
-		if buf, ok := cfg.writeBufferPool.Get().([]byte); ok && len(buf) >= writeBufferSize+maxFrameHeaderSize {
-			writeBuf = buf[:writeBufferSize+maxFrameHeaderSize]
-		}
-	}
-	if writeBuf == nil {
-		writeBuf = make([]byte, writeBufferSize+maxFrameHeaderSize)
-	}
-
 	var br io.Reader = cfg.rwc
 	if cfg.netConn != nil {
 		br = cfg.netConn
@@ -297,22 +360,16 @@ func newConnFromRWC(cfg connConfig) *Conn {
 		netConn:          cfg.netConn,
 		br:               br,
 		isServer:         cfg.isServer,
-		readBuf:          make([]byte, readBufferSize),
-		writeBuf:         writeBuf,
-		writePos:         maxFrameHeaderSize,
+		readBuf:          make([]byte, maxFrameHeaderSize),
+		writeBufferSize:  writeBufferSize,
 		writeBufferPool:  cfg.writeBufferPool,
 		compressionLevel: 1,
+		peerClosed:       make(chan struct{}),
 	}
 
-	c.pingHandler = func(appData string) error {
-		return c.WriteControl(PongMessage, []byte(appData), time.Now().Add(5*time.Second))
-	}
+	c.pingHandler = c.defaultPingHandler
 	c.pongHandler = func(_ string) error { return nil }
-	c.closeHandler = func(code int, text string) error {
-		msg := FormatCloseMessage(code, text)
-		_ = c.WriteControl(CloseMessage, msg, time.Now().Add(5*time.Second))
-		return nil
-	}
+	c.closeHandler = c.defaultCloseHandler
 
 	return c
 }
@@ -329,28 +386,236 @@ func (c *Conn) Close() error {
 
 // CloseWithMessage sends a close frame with the given code and text,
 // then closes the underlying connection. It is safe to call concurrently.
+// A code rejected by IsValidCloseCode (other than CloseNoStatusReceived,
+// which sends an empty close frame) or a reason that is not valid UTF-8 is
+// not sent; the connection is closed without a close frame and
+// ErrInvalidCloseCode or ErrInvalidCloseReason is returned.
 func (c *Conn) CloseWithMessage(code int, text string) error {
 	if !atomic.CompareAndSwapInt32(&c.state, stateOpen, stateClosing) {
 		// Already closing or closed.
 		return nil
 	}
 
-	// Best-effort send close frame.
+	// Best-effort send close frame. An invalid code or reason is not sent,
+	// but the connection is still closed and the error reported.
 	msg := FormatCloseMessage(code, text)
-	_ = c.WriteControl(CloseMessage, msg, time.Now().Add(5*time.Second))
+	writeErr := c.WriteControl(CloseMessage, msg, time.Now().Add(5*time.Second))
 
-	// Return write buffer to pool under lock.
-	c.writeMu.Lock()
-	if c.writeBufferPool != nil && c.writeBuf != nil {
-		c.writeBufferPool.Put(c.writeBuf)
-		c.writeBuf = nil
+	err := c.closeUnderlying()
+	if isClosePayloadError(writeErr) {
+		return writeErr
 	}
+	return err
+}
+
+// isClosePayloadError reports whether err is a close frame validation
+// error returned by WriteControl.
+func isClosePayloadError(err error) bool {
+	return errors.Is(err, ErrInvalidCloseCode) || errors.Is(err, ErrInvalidCloseReason)
+}
+
+// CloseGracefully performs the full close handshake described in RFC 6455,
+// section 7.1.2: it sends a close frame with the given code and text, reads
+// and discards incoming messages until the peer's close frame arrives or the
+// timeout elapses, then closes the underlying connection.
+//
+// It returns nil when the peer completed the handshake and ErrCloseTimeout
+// when it did not respond in time. If the peer's close frame was already
+// received, the underlying connection is closed without waiting. A
+// non-positive timeout closes immediately after sending the close frame.
+//
+// Like Close, it is safe to call concurrently; only the first call performs
+// the handshake. CloseGracefully never reads in parallel with another
+// goroutine: when a read is in progress, it leaves the connection to that
+// reader and waits for it to receive the peer's close frame, so the
+// reader must keep reading until it gets the *CloseError. Otherwise it
+// reads and discards messages itself, and reads started meanwhile block
+// until it returns. Invalid codes and reasons are handled as for
+// CloseWithMessage.
+func (c *Conn) CloseGracefully(code int, text string, timeout time.Duration) error {
+	if !atomic.CompareAndSwapInt32(&c.state, stateOpen, stateClosing) {
+		// Already closing or closed.
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	msg := FormatCloseMessage(code, text)
+	writeErr := c.WriteControl(CloseMessage, msg, deadline)
+
+	var err error
+	if timeout > 0 && (writeErr == nil || errors.Is(writeErr, ErrCloseSent)) {
+		err = c.awaitPeerClose(deadline)
+	}
+
+	if closeErr := c.closeUnderlying(); err == nil {
+		err = closeErr
+	}
+	if isClosePayloadError(writeErr) {
+		return writeErr
+	}
+	return err
+}
+
+// awaitPeerClose waits until the peer's close frame is read or the deadline
+// passes. It reads the frames itself unless another goroutine is reading.
+func (c *Conn) awaitPeerClose(deadline time.Time) error {
+	if !c.readMu.TryLock() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		select {
+		case <-c.peerClosed:
+			return nil
+		case <-timer.C:
+			return ErrCloseTimeout
+		}
+	}
+	defer c.readMu.Unlock()
+
+	if err := c.SetReadDeadline(deadline); err != nil {
+		// Connections without deadline support are unblocked by closing
+		// the transport once the deadline passes.
+		timer := time.AfterFunc(time.Until(deadline), func() {
+			_ = c.rwc.Close()
+		})
+		defer timer.Stop()
+	}
+
+	buf := make([]byte, 512)
+	for {
+		_, r, err := c.nextReader()
+		if err == nil {
+			// Drain through read; Read would wait for readMu held here.
+			mr := r.(*messageReader)
+			for err == nil {
+				_, err = mr.read(buf)
+			}
+			if err == io.EOF {
+				err = nil
+			}
+		}
+
+		var closeErr *CloseError
+		switch {
+		case err == nil, errors.Is(err, ErrCloseSent):
+			// Ping replies fail once our close frame is out; keep draining.
+			continue
+		case errors.As(err, &closeErr):
+			return nil
+		case !time.Now().Before(deadline):
+			return ErrCloseTimeout
+		default:
+			return err
+		}
+	}
+}
+
+// closeUnderlying detaches the bound context, if any, and closes the
+// connection with closeTransport.
+func (c *Conn) closeUnderlying() error {
+	if c.stopCtx != nil {
+		c.stopCtx()
+	}
+	return c.closeTransport()
+}
+
+// closeTransport releases the write buffer, marks the connection closed,
+// and closes the transport.
+func (c *Conn) closeTransport() error {
+	c.writeMu.Lock()
+	c.writeBuf = nil
 	c.writeMu.Unlock()
 
 	atomic.StoreInt32(&c.state, stateClosed)
+	if q := c.writeQueue.Load(); q != nil {
+		q.stop(ErrWriteToClosedConnection)
+	}
 	return c.rwc.Close()
 }
 
+// Context returns the context the connection was established with: the
+// request context (or the context passed to UpgradeContext) on the server,
+// and the DialContext context on the client. Values such as request IDs and
+// trace spans are available from it.
+//
+// When BindContext is set on the Upgrader or Dialer, the returned context is
+// the bound context itself and the connection is closed when it is done.
+// Otherwise cancellation is detached, so the returned context is never done;
+// in particular it is unaffected by net/http cancelling the request context
+// when the handler returns.
+func (c *Conn) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// setContext associates ctx with the connection. When bind is true, ctx
+// being done closes the underlying connection, which unblocks pending reads
+// and writes; they then fail with an error wrapping the context's cause.
+func (c *Conn) setContext(ctx context.Context, bind bool) {
+	if !bind {
+		c.ctx = context.WithoutCancel(ctx)
+		return
+	}
+	c.ctx = ctx
+	c.stopCtx = context.AfterFunc(ctx, func() {
+		if atomic.CompareAndSwapInt32(&c.state, stateOpen, stateClosing) {
+			c.ctxDone.Store(true)
+			// Close the transport before closeTransport, which waits for
+			// writeMu and so for any write blocked on the peer.
+			_ = c.rwc.Close()
+			_ = c.closeTransport()
+		}
+	})
+}
+
+// contextErr replaces err with one wrapping the bound context's cause when
+// the connection was closed because that context is done.
+func (c *Conn) contextErr(err error) error {
+	if err == nil || !c.ctxDone.Load() {
+		return err
+	}
+	return fmt.Errorf("websocket: connection context done: %w", context.Cause(c.ctx))
+}
+
+// acquireWriteBuf returns a buffer for assembling one frame. Without a pool
+// the buffer is allocated on the first write and kept for the lifetime of the
+// connection, so connections that only read never allocate one. With a pool
+// the buffer is borrowed and must be handed back with releaseWriteBuf.
+// Pooled buffers are stored as *[]byte so that Put does not allocate; a
+// plain []byte from a pool's New function is accepted as well.
+// The caller must hold writeMu.
+func (c *Conn) acquireWriteBuf() *[]byte {
+	size := c.writeBufferSize + maxFrameHeaderSize
+	if c.writeBufferPool == nil {
+		if c.writeBuf == nil {
+			c.writeBuf = make([]byte, size)
+		}
+		return &c.writeBuf
+	}
+	switch buf := c.writeBufferPool.Get().(type) {
+	case *[]byte:
+		if buf != nil && len(*buf) >= size {
+			*buf = (*buf)[:size]
+			return buf
+		}
+	case []byte:
+		if len(buf) >= size {
+			buf = buf[:size]
+			return &buf
+		}
+	}
+	buf := make([]byte, size)
+	return &buf
+}
+
+// releaseWriteBuf returns a buffer obtained from acquireWriteBuf to the pool.
+func (c *Conn) releaseWriteBuf(buf *[]byte) {
+	if c.writeBufferPool != nil {
+		c.writeBufferPool.Put(buf)
+	}
+}
+
 // IsClosed reports whether the connection has been closed.
 func (c *Conn) IsClosed() bool {
 	return atomic.LoadInt32(&c.state) == stateClosed
@@ -381,6 +646,11 @@ func (c *Conn) UnderlyingConn() net.Conn {
 // Returns ErrDeadlineNotSupported if the underlying connection does not support deadlines (e.g., HTTP/2).
 func (c *Conn) SetReadDeadline(t time.Time) error {
 	if c.netConn != nil {
+		var nanos int64
+		if !t.IsZero() {
+			nanos = t.UnixNano()
+		}
+		c.readDeadline.Store(nanos)
 		return c.netConn.SetReadDeadline(t)
 	}
 	return ErrDeadlineNotSupported
@@ -396,10 +666,138 @@ func (c *Conn) SetWriteDeadline(t time.Time) error {
 }
 
 // SetReadLimit sets the maximum size in bytes for a message read from the peer.
+// A message that exceeds the limit fails the read with ErrReadLimit and, by
+// default, closes the connection with CloseMessageTooBig (RFC 6455, section
+// 7.4.1); see SetReadLimitError.
 func (c *Conn) SetReadLimit(limit int64) {
 	c.readLimit = limit
 }
 
+// SetReadLimitError sets the close code and reason sent to the peer when a
+// message exceeds the read limit. The default is CloseMessageTooBig with the
+// reason "message too big". A code of 0 sends no close frame: the read still
+// returns ErrReadLimit, and closing the connection is left to the caller.
+func (c *Conn) SetReadLimitError(code int, text string) {
+	c.readLimitCode = code
+	c.readLimitText = text
+	c.readLimitCustom = true
+}
+
+// readLimitExceeded fails reading with ErrReadLimit after closing the
+// connection with the configured close frame.
+func (c *Conn) readLimitExceeded() error {
+	code, text := CloseMessageTooBig, "message too big"
+	if c.readLimitCustom {
+		code, text = c.readLimitCode, c.readLimitText
+	}
+	if code != 0 {
+		_ = c.CloseWithMessage(code, text)
+	}
+	c.readErr = ErrReadLimit
+	return ErrReadLimit
+}
+
+// SetMessageDeadline bounds the time from the first frame of a fragmented
+// message to its final frame. SetReadDeadline applies to each socket read,
+// so a peer trickling continuation frames could otherwise keep a message
+// open indefinitely. While the rest of a message is read, the socket read
+// deadline is narrowed to the message deadline and then restored. A message
+// that exceeds it fails the read with ErrMessageDeadline and closes the
+// connection with ClosePolicyViolation. Zero, the default, disables the
+// limit.
+//
+// On connections without deadline support (e.g., HTTP/2), the deadline is
+// only checked as each continuation frame arrives.
+func (c *Conn) SetMessageDeadline(d time.Duration) {
+	c.msgDeadline = d
+}
+
+// SetMaxFragments limits the number of data frames (the first frame and its
+// continuation frames) in a message read from the peer. A message with more
+// fails the read with ErrTooManyFragments and closes the connection with
+// ClosePolicyViolation. Zero, the default, disables the limit.
+func (c *Conn) SetMaxFragments(n int) {
+	c.maxFragments = n
+}
+
+// startMessage records the first frame of a data message for the message
+// deadline and fragment limit.
+func (c *Conn) startMessage(final bool) {
+	c.endMessage()
+	c.readFragments = 1
+	if c.msgDeadline > 0 && !final {
+		c.readMsgEnd = time.Now().Add(c.msgDeadline)
+	}
+}
+
+// effectiveReadDeadline returns the earlier of the deadlines set by
+// SetReadDeadline and ReadMessageTimeout, or the zero time when neither is
+// set.
+func (c *Conn) effectiveReadDeadline() time.Time {
+	nanos := c.readDeadline.Load()
+	if call := c.readCallDeadline; call != 0 && (nanos == 0 || call < nanos) {
+		nanos = call
+	}
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// armMessageDeadline narrows the socket read deadline to the message
+// deadline before a continuation frame is read. It runs before every frame
+// because handlers such as the keepalive pong handler move the deadline.
+func (c *Conn) armMessageDeadline() {
+	if c.readMsgEnd.IsZero() || c.netConn == nil {
+		return
+	}
+	deadline := c.readMsgEnd
+	if d := c.effectiveReadDeadline(); !d.IsZero() && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.netConn.SetReadDeadline(deadline)
+}
+
+// endMessage disarms the message deadline and restores the socket read
+// deadline.
+func (c *Conn) endMessage() {
+	if c.readMsgEnd.IsZero() {
+		return
+	}
+	c.readMsgEnd = time.Time{}
+	if c.netConn != nil {
+		_ = c.netConn.SetReadDeadline(c.effectiveReadDeadline())
+	}
+}
+
+// checkFragment applies the message deadline and fragment limit after a
+// continuation frame arrives.
+func (c *Conn) checkFragment() error {
+	c.readFragments++
+	if c.maxFragments > 0 && c.readFragments > c.maxFragments {
+		return c.messageLimitExceeded(ErrTooManyFragments, "too many message fragments")
+	}
+	if !c.readMsgEnd.IsZero() && !time.Now().Before(c.readMsgEnd) {
+		return c.messageLimitExceeded(ErrMessageDeadline, "message deadline exceeded")
+	}
+	return nil
+}
+
+// messageDeadlineExpired reports whether err, returned while reading a
+// continuation frame, is a timeout caused by the message deadline.
+func (c *Conn) messageDeadlineExpired(err error) bool {
+	return !c.readMsgEnd.IsZero() && isTimeout(err) && !time.Now().Before(c.readMsgEnd)
+}
+
+// messageLimitExceeded fails reading with err after closing the connection
+// with ClosePolicyViolation and the given reason.
+func (c *Conn) messageLimitExceeded(err error, text string) error {
+	c.endMessage()
+	_ = c.CloseWithMessage(ClosePolicyViolation, text)
+	c.readErr = err
+	return err
+}
+
 // SetMaxFrameSize sets the maximum payload size in bytes for a single WebSocket
 // frame, enforced on both reads and writes. Zero disables the limit.
 // On read, a frame exceeding the limit closes the connection with CloseProtocolError
@@ -480,16 +878,61 @@ func (c *Conn) SetMessageTypePolicy(policy MessageTypePolicy) {
 	c.msgTypePolicy = policy
 }
 
+// ErrSkipPong is returned by a ping handler, set with SetPingHandler or
+// Upgrader.PingHandler, to leave a ping unanswered. Reading continues as if
+// the handler had returned nil, and no pong is sent on its behalf.
+var ErrSkipPong = errors.New("websocket: skip pong")
+
 // SetPingHandler sets the handler for ping messages received from the peer.
+//
+// The handler runs on the goroutine reading the connection, from within
+// NextReader, ReadMessage, or a Read of the current message, each time a
+// ping frame arrives, including between the fragments of a data message.
+// It must not call the read methods. It may reply with WriteControl or
+// WriteControlContext: control frames are written whole under the
+// connection's frame lock, so they are safe alongside a concurrent writer
+// and are interleaved between the fragments of the message it is sending
+// (RFC 6455, section 5.4). Keep the handler short, since reading is
+// blocked while it runs. An error other than ErrSkipPong fails the read.
+//
+// The default handler, also restored by passing nil, answers with a pong
+// carrying the ping payload (RFC 6455, section 5.5.3) unless
+// DisableAutoPong was called. A custom handler replaces it and sends
+// whatever pong it wants, or none.
 func (c *Conn) SetPingHandler(h func(appData string) error) {
 	if h == nil {
-		h = func(appData string) error {
-			return c.WriteControl(PongMessage, []byte(appData), time.Now().Add(5*time.Second))
-		}
+		h = c.defaultPingHandler
 	}
 	c.pingHandler = h
 }
 
+// DisableAutoPong stops the default ping handler from answering pings, for
+// protocols that reply to pings themselves, for example with application
+// data. Pings are still passed to the ping handler, and a handler set with
+// SetPingHandler is unaffected. Like SetPingHandler, it must be called
+// before reading, or from the reading goroutine.
+func (c *Conn) DisableAutoPong() {
+	c.autoPongOff = true
+}
+
+// defaultPingHandler answers a ping with a pong echoing its payload, unless
+// DisableAutoPong was called.
+func (c *Conn) defaultPingHandler(appData string) error {
+	if c.autoPongOff {
+		return nil
+	}
+	return c.WriteControl(PongMessage, []byte(appData), time.Now().Add(5*time.Second))
+}
+
+// handlePing passes a received ping to the ping handler. ErrSkipPong is not
+// a read error.
+func (c *Conn) handlePing(payload []byte) error {
+	if err := c.pingHandler(string(payload)); err != nil && !errors.Is(err, ErrSkipPong) {
+		return err
+	}
+	return nil
+}
+
 // SetPongHandler sets the handler for pong messages received from the peer.
 func (c *Conn) SetPongHandler(h func(appData string) error) {
 	if h == nil {
@@ -498,18 +941,65 @@ func (c *Conn) SetPongHandler(h func(appData string) error) {
 	c.pongHandler = h
 }
 
-// SetCloseHandler sets the handler for close messages received from the peer.
+// SetCloseHandler sets the handler for close messages received from the
+// peer. The handler is called only for close frames with a valid status
+// code; a close frame with a code that must not appear on the wire fails
+// the connection with CloseProtocolError before any handler runs.
+//
+// The default handler, also restored by passing nil, echoes the received
+// status code and reason back to the peer to complete the closing
+// handshake (RFC 6455, section 5.5.1). A close frame without a status code
+// is answered with an empty close frame.
 func (c *Conn) SetCloseHandler(h func(code int, text string) error) {
 	if h == nil {
-		h = func(code int, text string) error {
-			msg := FormatCloseMessage(code, text)
-			_ = c.WriteControl(CloseMessage, msg, time.Now().Add(5*time.Second))
-			return nil
-		}
+		h = c.defaultCloseHandler
 	}
 	c.closeHandler = h
 }
 
+// defaultCloseHandler echoes the peer's close code and reason.
+func (c *Conn) defaultCloseHandler(code int, text string) error {
+	msg := FormatCloseMessage(code, text)
+	_ = c.WriteControl(CloseMessage, msg, time.Now().Add(5*time.Second))
+	return nil
+}
+
+// handleClose processes a close frame received from the peer and returns
+// the error that ends reading: a *CloseError after the close handler has
+// run, or the handler's own error. A status code that must not appear on
+// the wire (RFC 6455, section 7.4.1) fails the connection with
+// CloseProtocolError (section 7.1.7) and returns ErrInvalidCloseCode. A
+// reason that is not valid UTF-8 (section 5.5.1) fails it with
+// CloseInvalidFramePayloadData and returns ErrInvalidCloseReason.
+func (c *Conn) handleClose(payload []byte) error {
+	defer c.peerCloseOnce.Do(func() { close(c.peerClosed) })
+
+	code := CloseNoStatusReceived
+	text := ""
+	if len(payload) >= 2 {
+		code = int(payload[0])<<8 | int(payload[1])
+		text = string(payload[2:])
+		if !IsValidCloseCode(code) {
+			c.readErr = ErrInvalidCloseCode
+			_ = c.CloseWithMessage(CloseProtocolError, "invalid close code")
+			return ErrInvalidCloseCode
+		}
+		if !utf8.ValidString(text) {
+			c.readErr = ErrInvalidCloseReason
+			_ = c.CloseWithMessage(CloseInvalidFramePayloadData, "invalid close reason")
+			return ErrInvalidCloseReason
+		}
+	}
+	if err := c.closeHandler(code, text); err != nil {
+		return err
+	}
+	c.readErr = &CloseError{
+		Code: code,
+		Text: text,
+	}
+	return c.readErr
+}
+
 // EnableWriteCompression enables or disables write compression for the connection.
 // When enabled and compression is negotiated (RFC 7692), outgoing messages will
 // be compressed using the permessage-deflate extension.
@@ -517,6 +1007,37 @@ func (c *Conn) EnableWriteCompression(enable bool) {
 	c.writeCompress = enable
 }
 
+// SetCompressionThreshold sets the minimum payload size, in bytes, of
+// messages that are compressed. Smaller messages are sent uncompressed
+// (RSV1 clear, RFC 7692, section 6) even when write compression is enabled,
+// since deflating a few bytes costs more than it saves. Zero, the default,
+// compresses every message. The threshold applies per message and does not
+// affect extension negotiation.
+func (c *Conn) SetCompressionThreshold(n int) {
+	c.compressThreshold = max(n, 0)
+}
+
+// SetSkipCompressionFunc sets a predicate that is called for each message
+// that would otherwise be compressed; when it returns true the message is
+// sent uncompressed. Use it to skip payloads that are already compressed,
+// such as images or gzip data. A nil f, the default, compresses every
+// message above the compression threshold.
+func (c *Conn) SetSkipCompressionFunc(f func(messageType int, data []byte) bool) {
+	c.skipCompress = f
+}
+
+// shouldCompress reports whether a message with the given payload is
+// written with permessage-deflate (RFC 7692).
+func (c *Conn) shouldCompress(messageType int, data []byte) bool {
+	if !c.writeCompress || !c.compressionEnabled {
+		return false
+	}
+	if len(data) < c.compressThreshold {
+		return false
+	}
+	return c.skipCompress == nil || !c.skipCompress(messageType, data)
+}
+
 // SetCompressionLevel sets the compression level for the connection.
 // Valid levels are -2 to 9 (flate package constants).
 // Per RFC 7692, compression uses the DEFLATE algorithm.
@@ -528,14 +1049,45 @@ func (c *Conn) SetCompressionLevel(level int) error {
 	return nil
 }
 
-// WriteControl writes a control message with the given deadline.
+// WriteControl writes a control message with the given deadline. The
+// payload of a close message is validated before it is written: a status
+// code rejected by IsValidCloseCode returns ErrInvalidCloseCode and a
+// reason that is not valid UTF-8 returns ErrInvalidCloseReason (RFC 6455,
+// section 5.5.1). An empty payload, which stands for CloseNoStatusReceived,
+// is always allowed.
 func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	return c.writeControl(context.Background(), messageType, data, deadline)
+}
+
+// WriteControlContext writes a control message, using the context deadline
+// (if any) as the write deadline. When the context is cancelled while the
+// frame is being written, the write is interrupted and ctx.Err() is returned.
+// An interrupted write may leave a partial frame on the wire, so later writes
+// fail with the same error. It returns ctx.Err() without writing if the
+// context is already done.
+func (c *Conn) WriteControlContext(ctx context.Context, messageType int, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	return c.writeControl(ctx, messageType, data, deadline)
+}
+
+// writeControl writes a control frame. When ctx can be cancelled and the
+// connection exposes a net.Conn, cancellation expires the write deadline to
+// unblock the write.
+func (c *Conn) writeControl(ctx context.Context, messageType int, data []byte, deadline time.Time) error {
 	if messageType != CloseMessage && messageType != PingMessage && messageType != PongMessage {
 		return ErrInvalidControlFrame
 	}
 	if len(data) > maxControlFramePayloadSize {
 		return ErrControlFramePayloadTooBig
 	}
+	if messageType == CloseMessage {
+		if err := validateClosePayload(data); err != nil {
+			return err
+		}
+	}
 	// Close frames are exempt: the close handshake must complete regardless of
 	// the data frame size limit. Ping/pong payloads are user-controlled, so
 	// the limit applies to them.
@@ -569,35 +1121,128 @@ func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) er
 		copy(frame[2:], data)
 	}
 
-	_, err := c.rwc.Write(frame)
+	var stop func() bool
+	interrupted := make(chan struct{})
+	if c.netConn != nil && ctx.Done() != nil {
+		stop = context.AfterFunc(ctx, func() {
+			_ = c.netConn.SetWriteDeadline(time.Unix(1, 0))
+			close(interrupted)
+		})
+	}
+
+	n, err := c.rwc.Write(frame)
+	c.stats.recordWrite(messageType, n, err)
+	err = c.contextErr(err)
+
+	if stop != nil && !stop() {
+		// Wait for the interrupt so it cannot override the cleared deadline.
+		<-interrupted
+		if err != nil {
+			err = ctx.Err()
+			c.writeErr = err
+		}
+	}
 
 	// Clear the deadline so subsequent data writes are not affected.
 	if c.netConn != nil {
 		_ = c.netConn.SetWriteDeadline(time.Time{})
 	}
 
-	if messageType == CloseMessage {
+	if messageType == CloseMessage && c.writeErr == nil {
 		c.writeErr = ErrCloseSent
 	}
 	return err
 }
 
+// validateClosePayload checks an outgoing close frame body per RFC 6455,
+// section 5.5.1: empty, or a sendable status code followed by UTF-8 text.
+func validateClosePayload(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) == 1 {
+		return ErrInvalidClosePayload
+	}
+	if !IsValidCloseCode(int(data[0])<<8 | int(data[1])) {
+		return ErrInvalidCloseCode
+	}
+	if !utf8.Valid(data[2:]) {
+		return ErrInvalidCloseReason
+	}
+	return nil
+}
+
 // WriteMessage writes a message with the given message type and payload.
+// When a write fragment size is set, payloads larger than it are split into
+// continuation frames (RFC 6455, section 5.4).
 func (c *Conn) WriteMessage(messageType int, data []byte) error {
 	if messageType != TextMessage && messageType != BinaryMessage {
 		return ErrInvalidMessageType
 	}
 
-	c.writeMu.Lock()
-	defer c.writeMu.Unlock()
+	c.msgMu.Lock()
+	defer c.msgMu.Unlock()
 
-	if c.writeErr != nil {
-		return c.writeErr
+	if c.writeFragSize <= 0 || len(data) <= c.writeFragSize {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+
+		_, err := c.writeFrameWithCompress(messageType, data, true, c.shouldCompress(messageType, data))
+		return err
 	}
 
-	compress := c.writeCompress && c.compressionEnabled
-	_, err := c.writeFrameWithCompress(messageType, data, true, compress)
-	return err
+	if c.shouldCompress(messageType, data) {
+		compressed, err := compressData(data, c.compressionLevel)
+		if err != nil {
+			return err
+		}
+		return c.writeFragments(messageType, compressed, true, true)
+	}
+	return c.writeFragments(messageType, data, true, false)
+}
+
+// SetWriteFragmentSize caps the payload of data frames written by
+// WriteMessage and NextWriter at n bytes; larger payloads are split into
+// continuation frames per RFC 6455, section 5.4. Compressed messages are
+// compressed as a whole and the compressed payload is split (RFC 7692,
+// section 6.1). Zero, the default, disables fragmentation. Prepared messages
+// are always written as a single frame.
+//
+// The write lock is released between fragments, so control frames written
+// concurrently with WriteControl, such as keepalive pings, can interleave
+// with a large message instead of waiting for all of it.
+func (c *Conn) SetWriteFragmentSize(n int) {
+	c.msgMu.Lock()
+	c.writeFragSize = max(n, 0)
+	c.msgMu.Unlock()
+}
+
+// writeFragments writes data as frames of at most writeFragSize payload
+// bytes. The first frame carries frameType and, for compressed messages,
+// RSV1; the rest are continuation frames. FIN is set on the last frame only
+// when final is true. An empty data writes a single empty frame. The write
+// lock is taken per frame; the caller must hold msgMu.
+func (c *Conn) writeFragments(frameType int, data []byte, final, rsv1 bool) error {
+	for {
+		chunk := data
+		if c.writeFragSize > 0 && len(chunk) > c.writeFragSize {
+			chunk = chunk[:c.writeFragSize]
+		}
+		data = data[len(chunk):]
+
+		c.writeMu.Lock()
+		err := c.writeErr
+		if err == nil {
+			err = c.writeFrame(frameType, chunk, final && len(data) == 0, rsv1)
+		}
+		c.writeMu.Unlock()
+
+		if err != nil || len(data) == 0 {
+			return err
+		}
+		frameType = continuationFrame
+		rsv1 = false
+	}
 }
 
 // NextWriter returns a writer for the next message to send.
@@ -607,11 +1252,14 @@ func (c *Conn) NextWriter(messageType int) (io.WriteCloser, error) {
 		return nil, ErrInvalidMessageType
 	}
 
-	c.writeMu.Lock()
+	c.msgMu.Lock()
 
-	if c.writeErr != nil {
-		c.writeMu.Unlock()
-		return nil, c.writeErr
+	c.writeMu.Lock()
+	err := c.writeErr
+	c.writeMu.Unlock()
+	if err != nil {
+		c.msgMu.Unlock()
+		return nil, err
 	}
 
 	c.writeFrameType = messageType
@@ -621,6 +1269,43 @@ func (c *Conn) NextWriter(messageType int) (io.WriteCloser, error) {
 	}, nil
 }
 
+// NextWriterContext returns a writer for the next message like NextWriter,
+// bounded by ctx. The context deadline (if any) becomes the write deadline
+// until the writer is closed, and cancelling the context interrupts a
+// blocked write. Once the context is done, Write and Close release the
+// writer and return ctx.Err(). If part of the message was already sent the
+// connection cannot continue the message, so later writes fail with the
+// same error; a message that was still buffered (for example for
+// compression) is discarded and the connection stays usable. It returns
+// ctx.Err() without starting a message if the context is already done.
+//
+// The single-writer rule still applies: the writer must be closed before
+// the next message is started.
+func (c *Conn) NextWriterContext(ctx context.Context, messageType int) (io.WriteCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	wc, err := c.NextWriter(messageType)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &contextWriter{w: wc.(*messageWriter), ctx: ctx}
+	if c.netConn != nil {
+		deadline, _ := ctx.Deadline()
+		_ = c.netConn.SetWriteDeadline(deadline)
+		if ctx.Done() != nil {
+			w.interrupted = make(chan struct{})
+			w.stop = context.AfterFunc(ctx, func() {
+				_ = c.netConn.SetWriteDeadline(time.Unix(1, 0))
+				close(w.interrupted)
+			})
+		}
+	}
+	return w, nil
+}
+
 // ReadMessage reads the next message from the connection.
 // For text messages, validates UTF-8 encoding per RFC 6455, section 5.6.
 func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
@@ -639,6 +1324,73 @@ func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
 	return messageType, p, err
 }
 
+// ReadMessageBuffer reads the next message like ReadMessage, appending its
+// payload to buf[:0] and returning the result. The buffer is grown only
+// when the message does not fit in cap(buf), so passing the data returned
+// by the previous call lets a read loop reuse one allocation across
+// messages. Fragmented and compressed messages are assembled into buf the
+// same way. The returned data aliases buf and is only valid until buf is
+// reused.
+func (c *Conn) ReadMessageBuffer(buf []byte) (messageType int, data []byte, err error) {
+	var r io.Reader
+	messageType, r, err = c.NextReader()
+	if err != nil {
+		return 0, buf[:0], err
+	}
+	data, err = appendReader(buf[:0], r)
+	if err != nil {
+		return messageType, data, err
+	}
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return 0, data[:0], ErrInvalidUTF8
+	}
+	return messageType, data, nil
+}
+
+// appendReader reads r until io.EOF, appending to buf. Unlike io.ReadAll it
+// fills the spare capacity of buf before growing it.
+func appendReader(buf []byte, r io.Reader) ([]byte, error) {
+	for {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			if err == io.EOF {
+				return buf, nil
+			}
+			return buf, err
+		}
+	}
+}
+
+// ReadText reads the next message and returns it as a string. Control
+// frames are handled as for ReadMessage. If the message is not a text
+// message, it is discarded and a *MessageTypeError is returned.
+func (c *Conn) ReadText() (string, error) {
+	p, err := c.readMessageOfType(TextMessage)
+	return string(p), err
+}
+
+// ReadBinary reads the next message and returns its payload. Control
+// frames are handled as for ReadMessage. If the message is not a binary
+// message, it is discarded and a *MessageTypeError is returned.
+func (c *Conn) ReadBinary() ([]byte, error) {
+	return c.readMessageOfType(BinaryMessage)
+}
+
+func (c *Conn) readMessageOfType(expected int) ([]byte, error) {
+	messageType, p, err := c.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	if messageType != expected {
+		return nil, &MessageTypeError{Expected: expected, Got: messageType}
+	}
+	return p, nil
+}
+
 // ReadMessageContext reads a message with context cancellation support.
 // When the context is cancelled, the read deadline is set to the current time
 // to unblock any pending read operation.
@@ -669,28 +1421,91 @@ func (c *Conn) ReadMessageContext(ctx context.Context) (messageType int, p []byt
 	return messageType, p, err
 }
 
+// ReadMessageTimeout reads the next message like ReadMessage, failing if it
+// does not arrive within d. The read deadline is set for the duration of the
+// call and then restored to the value last passed to SetReadDeadline, so a
+// connection-wide deadline is neither lost nor extended; if that deadline is
+// earlier than d, it applies instead. Ping, pong, and close frames that arrive
+// while waiting are handled as usual and count against d.
+//
+// When d elapses, the returned error matches ErrReadTimeout with errors.Is
+// and reports Timeout() through net.Error, which distinguishes it from a
+// *CloseError. If no part of the next message had arrived, the connection
+// remains usable and the read can be retried; a timeout in the middle of a
+// message leaves the connection unreadable.
+//
+// Returns ErrDeadlineNotSupported if the underlying connection does not
+// support deadlines (e.g., HTTP/2).
+func (c *Conn) ReadMessageTimeout(d time.Duration) (messageType int, p []byte, err error) {
+	if c.netConn == nil {
+		return 0, nil, ErrDeadlineNotSupported
+	}
+
+	deadline := time.Now().Add(d)
+	ownDeadline := true
+	if prev := c.readDeadline.Load(); prev != 0 && prev < deadline.UnixNano() {
+		deadline = time.Unix(0, prev)
+		ownDeadline = false
+	}
+	if err := c.netConn.SetReadDeadline(deadline); err != nil {
+		return 0, nil, err
+	}
+
+	c.readCallDeadline = deadline.UnixNano()
+	messageType, p, err = c.ReadMessage()
+	c.readCallDeadline = 0
+
+	var restore time.Time
+	if prev := c.readDeadline.Load(); prev != 0 {
+		restore = time.Unix(0, prev)
+	}
+	_ = c.netConn.SetReadDeadline(restore)
+
+	if ownDeadline && isTimeout(err) {
+		err = fmt.Errorf("%w: %w", ErrReadTimeout, err)
+	}
+	return messageType, p, err
+}
+
+// isTimeout reports whether err is a network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // NextReader returns the next message reader from the connection.
 func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 	c.readMu.Lock()
 	defer c.readMu.Unlock()
+	return c.nextReader()
+}
 
+// nextReader implements NextReader; the caller holds readMu.
+func (c *Conn) nextReader() (messageType int, r io.Reader, err error) {
 	if c.readErr != nil {
 		return 0, nil, c.readErr
 	}
 
 	for {
 		frameType, payload, final, compressed, err := c.readFrame()
+		if errors.Is(err, ErrReadLimit) {
+			return 0, nil, c.readLimitExceeded()
+		}
 		if err != nil {
 			if errors.Is(err, ErrFrameSizeExceeded) {
 				_ = c.CloseWithMessage(CloseProtocolError, "frame payload exceeds size limit")
 			}
-			c.readErr = err
+			// A deadline that expires between frames leaves the stream
+			// intact, so the connection stays readable.
+			if !(c.readIdle && isTimeout(err)) {
+				c.readErr = err
+			}
 			return 0, nil, err
 		}
 
 		switch frameType {
 		case PingMessage:
-			if err := c.pingHandler(string(payload)); err != nil {
+			if err := c.handlePing(payload); err != nil {
 				return 0, nil, err
 			}
 			continue
@@ -700,24 +1515,7 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 			}
 			continue
 		case CloseMessage:
-			code := CloseNoStatusReceived
-			text := ""
-			if len(payload) >= 2 {
-				code = int(payload[0])<<8 | int(payload[1])
-				text = string(payload[2:])
-				if !isValidCloseCode(code) {
-					c.readErr = ErrInvalidCloseCode
-					return 0, nil, ErrInvalidCloseCode
-				}
-			}
-			if err := c.closeHandler(code, text); err != nil {
-				return 0, nil, err
-			}
-			c.readErr = &CloseError{
-				Code: code,
-				Text: text,
-			}
-			return 0, nil, c.readErr
+			return 0, nil, c.handleClose(payload)
 		case TextMessage, BinaryMessage:
 			if (c.msgTypePolicy == MessageTypePolicyBinary && frameType == TextMessage) ||
 				(c.msgTypePolicy == MessageTypePolicyText && frameType == BinaryMessage) {
@@ -730,6 +1528,7 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 			c.readFinal = final
 			c.readCompress = compressed
 			c.readMsgSize = int64(len(payload))
+			c.startMessage(final)
 
 			// Per RFC 7692, compression applies to the entire message.
 			// For fragmented compressed messages, we must read all frames,
@@ -739,8 +1538,15 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 				// Read all continuation frames and accumulate compressed data.
 				compressedData := payload
 				for !final {
+					c.armMessageDeadline()
 					ft, p, f, _, readErr := c.readFrame()
+					if errors.Is(readErr, ErrReadLimit) {
+						return 0, nil, c.readLimitExceeded()
+					}
 					if readErr != nil {
+						if c.messageDeadlineExpired(readErr) {
+							return 0, nil, c.messageLimitExceeded(ErrMessageDeadline, "message deadline exceeded")
+						}
 						if errors.Is(readErr, ErrFrameSizeExceeded) {
 							_ = c.CloseWithMessage(CloseProtocolError, "frame payload exceeds size limit")
 						}
@@ -750,7 +1556,7 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 					// Handle control frames inline per RFC 6455, section 5.4.
 					switch ft {
 					case PingMessage:
-						if err := c.pingHandler(string(p)); err != nil {
+						if err := c.handlePing(p); err != nil {
 							return 0, nil, err
 						}
 						continue
@@ -760,44 +1566,30 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 						}
 						continue
 					case CloseMessage:
-						code := CloseNoStatusReceived
-						text := ""
-						if len(p) >= 2 {
-							code = int(p[0])<<8 | int(p[1])
-							text = string(p[2:])
-							if !isValidCloseCode(code) {
-								c.readErr = ErrInvalidCloseCode
-								return 0, nil, ErrInvalidCloseCode
-							}
-						}
-						if err := c.closeHandler(code, text); err != nil {
-							return 0, nil, err
-						}
-						c.readErr = &CloseError{
-							Code: code,
-							Text: text,
-						}
-						return 0, nil, c.readErr
+						return 0, nil, c.handleClose(p)
 					case continuationFrame:
 						// Expected continuation frame.
 					default:
 						return 0, nil, ErrExpectedContinuation
 					}
+					if err := c.checkFragment(); err != nil {
+						return 0, nil, err
+					}
 					c.readMsgSize += int64(len(p))
 					if c.readLimit > 0 && c.readMsgSize > c.readLimit {
-						c.readErr = ErrReadLimit
-						return 0, nil, ErrReadLimit
+						return 0, nil, c.readLimitExceeded()
 					}
 					compressedData = append(compressedData, p...)
 					final = f
 				}
+				c.endMessage()
 				// Decompress the complete message, enforcing read limit
 				// to prevent decompression bombs.
 				var decErr error
 				payload, decErr = decompressDataLimited(compressedData, c.readLimit)
 				if decErr != nil {
 					if decErr == ErrReadLimit {
-						c.readErr = ErrReadLimit
+						return 0, nil, c.readLimitExceeded()
 					}
 					return 0, nil, decErr
 				}
@@ -814,7 +1606,7 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 				payload, decErr = decompressDataLimited(payload, c.readLimit)
 				if decErr != nil {
 					if decErr == ErrReadLimit {
-						c.readErr = ErrReadLimit
+						return 0, nil, c.readLimitExceeded()
 					}
 					return 0, nil, decErr
 				}
@@ -846,6 +1638,10 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 // Returns the frame opcode, payload, final flag, and compression flag.
 // The compressed flag is set when RSV1 is set (RFC 7692 permessage-deflate).
 func (c *Conn) readFrame() (frameType int, payload []byte, final bool, compressed bool, err error) {
+	defer func() {
+		err = c.contextErr(err)
+	}()
+
 	// Use readBuf for header reading to reduce allocations.
 	// readBuf layout: [0:2] header, [2:10] extended length, [10:14] mask
 	if len(c.readBuf) < maxFrameHeaderSize {
@@ -853,7 +1649,9 @@ func (c *Conn) readFrame() (frameType int, payload []byte, final bool, compresse
 	}
 
 	// Read the first two bytes of the frame header (RFC 6455, section 5.2).
-	if _, err := io.ReadFull(c.br, c.readBuf[:2]); err != nil {
+	n, err := io.ReadFull(c.br, c.readBuf[:2])
+	c.readIdle = n == 0
+	if err != nil {
 		return 0, nil, false, false, err
 	}
 
@@ -889,6 +1687,7 @@ func (c *Conn) readFrame() (frameType int, payload []byte, final bool, compresse
 	}
 
 	payloadLen := int64(c.readBuf[1] & payloadLenMask)
+	headerLen := 2
 
 	switch payloadLen {
 	case payloadLen16:
@@ -896,10 +1695,12 @@ func (c *Conn) readFrame() (frameType int, payload []byte, final bool, compresse
 			return 0, nil, false, false, err
 		}
 		payloadLen = int64(c.readBuf[2])<<8 | int64(c.readBuf[3])
+		headerLen += 2
 	case payloadLen64:
 		if _, err := io.ReadFull(c.br, c.readBuf[2:10]); err != nil {
 			return 0, nil, false, false, err
 		}
+		headerLen += 8
 		// RFC 6455, section 5.2: the most significant bit MUST be 0.
 		if c.readBuf[2]&0x80 != 0 {
 			return 0, nil, false, false, ErrPayloadLengthOverflow
@@ -939,12 +1740,14 @@ func (c *Conn) readFrame() (frameType int, payload []byte, final bool, compresse
 			return 0, nil, false, false, err
 		}
 		mask = c.readBuf[10:14]
+		headerLen += 4
 	}
 
 	payload = make([]byte, payloadLen)
 	if _, err := io.ReadFull(c.br, payload); err != nil {
 		return 0, nil, false, false, err
 	}
+	c.stats.recordRead(frameType, headerLen+len(payload))
 
 	if masked {
 		maskBytes(mask, 0, payload)
@@ -1021,8 +1824,7 @@ func (w *messageWriter) Write(p []byte) (int, error) {
 		w.firstWrite = true
 	}
 
-	_, err := w.c.writeFrameWithCompress(frameType, p, false, false)
-	if err != nil {
+	if err := w.c.writeFragments(frameType, p, false, false); err != nil {
 		return 0, err
 	}
 	return len(p), nil
@@ -1033,27 +1835,129 @@ func (w *messageWriter) Close() error {
 		return nil
 	}
 	w.closed = true
+	defer w.c.msgMu.Unlock()
+	defer func() { w.c.writeFrameType = 0 }()
 
 	if w.compress {
-		// Compress the entire buffered message and send as a single frame.
-		_, err := w.c.writeFrameWithCompress(w.c.writeFrameType, w.buf, true, true)
+		data := w.buf
 		w.buf = nil
-		w.c.writeFrameType = 0
-		w.c.writeMu.Unlock()
-		return err
+		if !w.c.shouldCompress(w.c.writeFrameType, data) {
+			return w.c.writeFragments(w.c.writeFrameType, data, true, false)
+		}
+		// Compress the entire buffered message, then fragment the result.
+		data, err := compressData(data, w.c.compressionLevel)
+		if err != nil {
+			return err
+		}
+		return w.c.writeFragments(w.c.writeFrameType, data, true, true)
 	}
 
 	frameType := w.c.writeFrameType
 	if w.firstWrite {
 		frameType = continuationFrame
 	}
+	return w.c.writeFragments(frameType, nil, true, false)
+}
+
+// contextWriter is the message writer returned by NextWriterContext.
+type contextWriter struct {
+	w           *messageWriter
+	ctx         context.Context
+	stop        func() bool
+	interrupted chan struct{}
+	err         error // set once the writer was aborted by the context
+}
+
+func (w *contextWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if err := w.ctx.Err(); err != nil {
+		w.abort(err)
+		return 0, err
+	}
+
+	n, err := w.w.Write(p)
+	if err != nil {
+		if ctxErr := w.ctxErr(err); ctxErr != nil {
+			w.abort(ctxErr)
+			return 0, ctxErr
+		}
+	}
+	return n, err
+}
+
+func (w *contextWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.w.closed {
+		return nil
+	}
+	if err := w.ctx.Err(); err != nil {
+		w.abort(err)
+		return err
+	}
 
-	_, err := w.c.writeFrameWithCompress(frameType, nil, true, false)
-	w.c.writeFrameType = 0
-	w.c.writeMu.Unlock()
+	err := w.w.Close()
+	if err != nil {
+		if ctxErr := w.ctxErr(err); ctxErr != nil {
+			err = ctxErr
+			w.w.c.writeMu.Lock()
+			w.w.c.writeErr = err
+			w.w.c.writeMu.Unlock()
+		}
+	}
+	w.release()
 	return err
 }
 
+// ctxErr returns the context error behind a failed write, or nil if the
+// context is not done. The write deadline copied from ctx can expire just
+// before ctx itself reports DeadlineExceeded, so a timeout past the deadline
+// is attributed to the context as well.
+func (w *contextWriter) ctxErr(err error) error {
+	if ctxErr := w.ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if deadline, ok := w.ctx.Deadline(); ok && errors.Is(err, os.ErrDeadlineExceeded) && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// abort releases the message without finishing it. When frames of the
+// message were already sent, or a write was interrupted, the connection is
+// failed with err.
+func (w *contextWriter) abort(err error) {
+	w.err = err
+	c := w.w.c
+
+	c.writeMu.Lock()
+	if w.w.firstWrite || c.writeErr != nil {
+		c.writeErr = err
+	}
+	c.writeMu.Unlock()
+
+	w.w.closed = true
+	w.w.buf = nil
+	c.writeFrameType = 0
+	c.msgMu.Unlock()
+	w.release()
+}
+
+// release stops the cancellation hook and clears the write deadline.
+func (w *contextWriter) release() {
+	if w.stop != nil && !w.stop() {
+		// Wait for the interrupt so it cannot override the cleared deadline.
+		<-w.interrupted
+	}
+	w.stop = nil
+	if c := w.w.c; c.netConn != nil {
+		_ = c.netConn.SetWriteDeadline(time.Time{})
+	}
+}
+
 // writeFrameWithCompress writes a WebSocket frame per RFC 6455, section 5.2.
 // If compress is true, the payload is compressed using DEFLATE (RFC 7692)
 // and RSV1 bit is set to indicate permessage-deflate compression.
@@ -1073,13 +1977,26 @@ func (c *Conn) writeFrameWithCompress(frameType int, data []byte, final bool, co
 		}
 	}
 
+	if err := c.writeFrame(frameType, data, final, compress); err != nil {
+		return 0, err
+	}
+	return originalLen, nil
+}
+
+// writeFrame writes a single frame with the given payload, setting RSV1 when
+// rsv1 is true. On a transport error it records writeErr. The caller must
+// hold writeMu.
+func (c *Conn) writeFrame(frameType int, data []byte, final, rsv1 bool) error {
 	// Check per-frame size limit against the wire payload (post-compression).
 	if c.maxFrameSize > 0 && int64(len(data)) > c.maxFrameSize {
-		return 0, ErrFrameSizeExceeded
+		return ErrFrameSizeExceeded
 	}
 
-	// Use writeBuf for header to reduce allocations.
-	// writeBuf has maxFrameHeaderSize bytes at the beginning for the header.
+	// Use the write buffer for the header to reduce allocations.
+	// It has maxFrameHeaderSize bytes at the beginning for the header.
+	bufp := c.acquireWriteBuf()
+	defer c.releaseWriteBuf(bufp)
+	buf := *bufp
 	headerLen := 2
 
 	// First byte: FIN, RSV1, opcode.
@@ -1087,39 +2004,39 @@ func (c *Conn) writeFrameWithCompress(frameType int, data []byte, final bool, co
 	if final {
 		b0 |= finalBit // Set FIN bit for final fragment
 	}
-	if compress {
+	if rsv1 {
 		b0 |= rsv1Bit // Set RSV1 for compressed frame (RFC 7692)
 	}
-	c.writeBuf[0] = b0
+	buf[0] = b0
 
 	payloadLen := len(data)
 	switch {
 	case payloadLen <= 125:
-		c.writeBuf[1] = byte(payloadLen)
+		buf[1] = byte(payloadLen)
 	case payloadLen <= 65535:
-		c.writeBuf[1] = payloadLen16
-		c.writeBuf[2] = byte(payloadLen >> 8)
-		c.writeBuf[3] = byte(payloadLen)
+		buf[1] = payloadLen16
+		buf[2] = byte(payloadLen >> 8)
+		buf[3] = byte(payloadLen)
 		headerLen = 4
 	default:
-		c.writeBuf[1] = payloadLen64
-		c.writeBuf[2] = byte(payloadLen >> 56)
-		c.writeBuf[3] = byte(payloadLen >> 48)
-		c.writeBuf[4] = byte(payloadLen >> 40)
-		c.writeBuf[5] = byte(payloadLen >> 32)
-		c.writeBuf[6] = byte(payloadLen >> 24)
-		c.writeBuf[7] = byte(payloadLen >> 16)
-		c.writeBuf[8] = byte(payloadLen >> 8)
-		c.writeBuf[9] = byte(payloadLen)
+		buf[1] = payloadLen64
+		buf[2] = byte(payloadLen >> 56)
+		buf[3] = byte(payloadLen >> 48)
+		buf[4] = byte(payloadLen >> 40)
+		buf[5] = byte(payloadLen >> 32)
+		buf[6] = byte(payloadLen >> 24)
+		buf[7] = byte(payloadLen >> 16)
+		buf[8] = byte(payloadLen >> 8)
+		buf[9] = byte(payloadLen)
 		headerLen = 10
 	}
 
 	if !c.isServer {
-		c.writeBuf[1] |= maskBit
-		if _, err := io.ReadFull(randReader, c.writeBuf[headerLen:headerLen+4]); err != nil {
-			return 0, err
+		buf[1] |= maskBit
+		if _, err := io.ReadFull(randReader, buf[headerLen:headerLen+4]); err != nil {
+			return err
 		}
-		mask := c.writeBuf[headerLen : headerLen+4]
+		mask := buf[headerLen : headerLen+4]
 		headerLen += 4
 
 		maskedData := make([]byte, len(data))
@@ -1128,26 +2045,33 @@ func (c *Conn) writeFrameWithCompress(frameType int, data []byte, final bool, co
 		data = maskedData
 	}
 
-	// If payload fits in writeBuf after header, use single write.
-	if headerLen+payloadLen <= len(c.writeBuf) {
-		copy(c.writeBuf[headerLen:], data)
-		_, err := c.rwc.Write(c.writeBuf[:headerLen+payloadLen])
+	// If payload fits in the buffer after header, use single write.
+	if headerLen+payloadLen <= len(buf) {
+		copy(buf[headerLen:], data)
+		n, err := c.rwc.Write(buf[:headerLen+payloadLen])
+		c.stats.recordWrite(frameType, n, err)
 		if err != nil {
+			err = c.contextErr(err)
 			c.writeErr = err
 		}
-		return originalLen, err
+		return err
 	}
 
 	// For large payloads, write header and data separately.
-	if _, err := c.rwc.Write(c.writeBuf[:headerLen]); err != nil {
+	n, err := c.rwc.Write(buf[:headerLen])
+	if err != nil {
+		c.stats.recordWrite(frameType, n, err)
+		err = c.contextErr(err)
 		c.writeErr = err
-		return 0, err
+		return err
 	}
-	_, err := c.rwc.Write(data)
+	m, err := c.rwc.Write(data)
+	c.stats.recordWrite(frameType, n+m, err)
 	if err != nil {
+		err = c.contextErr(err)
 		c.writeErr = err
 	}
-	return originalLen, err
+	return err
 }
 
 type messageReader struct {
@@ -1159,14 +2083,28 @@ type messageReader struct {
 }
 
 func (r *messageReader) Read(p []byte) (int, error) {
+	r.c.readMu.Lock()
+	defer r.c.readMu.Unlock()
+	return r.read(p)
+}
+
+// read implements Read; the caller holds readMu.
+func (r *messageReader) read(p []byte) (int, error) {
 	for r.pos >= len(r.buf) {
 		if r.final {
 			return 0, io.EOF
 		}
 		// Read next frame for uncompressed fragmented messages.
 		// Compressed fragmented messages are fully read in NextReader.
+		r.c.armMessageDeadline()
 		frameType, payload, final, _, err := r.c.readFrame()
+		if errors.Is(err, ErrReadLimit) {
+			return 0, r.c.readLimitExceeded()
+		}
 		if err != nil {
+			if r.c.messageDeadlineExpired(err) {
+				return 0, r.c.messageLimitExceeded(ErrMessageDeadline, "message deadline exceeded")
+			}
 			if errors.Is(err, ErrFrameSizeExceeded) {
 				_ = r.c.CloseWithMessage(CloseProtocolError, "frame payload exceeds size limit")
 			}
@@ -1176,7 +2114,7 @@ func (r *messageReader) Read(p []byte) (int, error) {
 		// Handle control frames inline per RFC 6455, section 5.4.
 		switch frameType {
 		case PingMessage:
-			if err := r.c.pingHandler(string(payload)); err != nil {
+			if err := r.c.handlePing(payload); err != nil {
 				return 0, err
 			}
 			continue
@@ -1186,38 +2124,25 @@ func (r *messageReader) Read(p []byte) (int, error) {
 			}
 			continue
 		case CloseMessage:
-			code := CloseNoStatusReceived
-			text := ""
-			if len(payload) >= 2 {
-				code = int(payload[0])<<8 | int(payload[1])
-				text = string(payload[2:])
-				if !isValidCloseCode(code) {
-					r.c.readErr = ErrInvalidCloseCode
-					return 0, ErrInvalidCloseCode
-				}
-			}
-			if err := r.c.closeHandler(code, text); err != nil {
-				return 0, err
-			}
-			closeErr := &CloseError{
-				Code: code,
-				Text: text,
-			}
-			r.c.readErr = closeErr
-			return 0, closeErr
+			return 0, r.c.handleClose(payload)
 		case continuationFrame:
 			// Expected continuation frame.
 		default:
 			return 0, ErrExpectedContinuation
 		}
+		if err := r.c.checkFragment(); err != nil {
+			return 0, err
+		}
 		r.c.readMsgSize += int64(len(payload))
 		if r.c.readLimit > 0 && r.c.readMsgSize > r.c.readLimit {
-			r.c.readErr = ErrReadLimit
-			return 0, ErrReadLimit
+			return 0, r.c.readLimitExceeded()
 		}
 		r.buf = payload
 		r.pos = 0
 		r.final = final
+		if final {
+			r.c.endMessage()
+		}
 	}
 
 	n := copy(p, r.buf[r.pos:])
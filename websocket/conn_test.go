@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"runtime"
 	"strconv"
 	"sync"
 	"testing"
@@ -353,6 +355,42 @@ func TestWriteControlValidation(t *testing.T) {
 			payload: make([]byte, 126),
 			wantErr: ErrControlFramePayloadTooBig,
 		},
+		{
+			name:    "Close no status received",
+			msgType: CloseMessage,
+			payload: []byte{0x03, 0xED}, // 1005
+			wantErr: ErrInvalidCloseCode,
+		},
+		{
+			name:    "Close abnormal closure",
+			msgType: CloseMessage,
+			payload: FormatCloseMessage(CloseAbnormalClosure, ""),
+			wantErr: ErrInvalidCloseCode,
+		},
+		{
+			name:    "Close TLS handshake",
+			msgType: CloseMessage,
+			payload: FormatCloseMessage(CloseTLSHandshake, ""),
+			wantErr: ErrInvalidCloseCode,
+		},
+		{
+			name:    "Close code out of range",
+			msgType: CloseMessage,
+			payload: FormatCloseMessage(5000, ""),
+			wantErr: ErrInvalidCloseCode,
+		},
+		{
+			name:    "Close reason invalid UTF-8",
+			msgType: CloseMessage,
+			payload: FormatCloseMessage(CloseNormalClosure, "\xff"),
+			wantErr: ErrInvalidCloseReason,
+		},
+		{
+			name:    "Close one byte payload",
+			msgType: CloseMessage,
+			payload: []byte{0x03},
+			wantErr: ErrInvalidClosePayload,
+		},
 	}
 
 	for _, tt := range tests {
@@ -677,6 +715,186 @@ func TestReadMessage(t *testing.T) {
 	assert.Equal(t, []byte("hello"), data)
 }
 
+func TestReadMessageBuffer(t *testing.T) {
+	t.Run("Reuses buffer with enough capacity", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), []byte{0x01, 0x02}, true))
+		conn := newConn(mock, true, 0, 0)
+
+		buf := make([]byte, 0, 64)
+		msgType, data, err := conn.ReadMessageBuffer(buf)
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, []byte("hello"), data)
+		assert.Same(t, &buf[:1][0], &data[0])
+
+		msgType, data, err = conn.ReadMessageBuffer(data)
+		require.NoError(t, err)
+		assert.Equal(t, BinaryMessage, msgType)
+		assert.Equal(t, []byte{0x01, 0x02}, data)
+		assert.Same(t, &buf[:1][0], &data[0])
+	})
+
+	t.Run("Grows buffer when too small", func(t *testing.T) {
+		payload := bytes.Repeat([]byte("x"), 1000)
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), payload, true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, data, err := conn.ReadMessageBuffer(make([]byte, 0, 8))
+		require.NoError(t, err)
+		assert.Equal(t, payload, data)
+	})
+
+	t.Run("Nil buffer", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, data, err := conn.ReadMessageBuffer(nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello"), data)
+	})
+
+	t.Run("Empty message", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), nil, true))
+		conn := newConn(mock, true, 0, 0)
+
+		buf := []byte("stale")
+		_, data, err := conn.ReadMessageBuffer(buf)
+		require.NoError(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("Fragmented message", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello "), false))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), []byte("world"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		buf := make([]byte, 0, 64)
+		msgType, data, err := conn.ReadMessageBuffer(buf)
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, []byte("hello world"), data)
+		assert.Same(t, &buf[:1][0], &data[0])
+	})
+
+	t.Run("Compressed message", func(t *testing.T) {
+		original := []byte("hello compressed world, hello compressed world")
+		compressed, err := compressData(original, -1)
+		require.NoError(t, err)
+
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrameRaw(byte(TextMessage)|rsv1Bit|finalBit, compressed))
+		conn := newConn(mock, true, 0, 0)
+		conn.compressionEnabled = true
+
+		buf := make([]byte, 0, 128)
+		msgType, data, err := conn.ReadMessageBuffer(buf)
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, original, data)
+		assert.Same(t, &buf[:1][0], &data[0])
+	})
+
+	t.Run("Invalid UTF-8", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte{0xff, 0xfe}, true))
+		conn := newConn(mock, true, 0, 0)
+
+		msgType, data, err := conn.ReadMessageBuffer(nil)
+		require.ErrorIs(t, err, ErrInvalidUTF8)
+		assert.Equal(t, 0, msgType)
+		assert.Empty(t, data)
+	})
+}
+
+func TestReadTextAndBinary(t *testing.T) {
+	t.Run("ReadText returns text message", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		text, err := conn.ReadText()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", text)
+	})
+
+	t.Run("ReadText rejects binary message", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), []byte{0x01}, true))
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("next"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		text, err := conn.ReadText()
+		assert.Empty(t, text)
+		require.ErrorIs(t, err, ErrUnexpectedMessageType)
+		var typeErr *MessageTypeError
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, TextMessage, typeErr.Expected)
+		assert.Equal(t, BinaryMessage, typeErr.Got)
+		assert.Equal(t, "websocket: unexpected message type: expected text, got binary", err.Error())
+
+		text, err = conn.ReadText()
+		require.NoError(t, err)
+		assert.Equal(t, "next", text)
+	})
+
+	t.Run("ReadBinary returns binary message", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), []byte{0x01, 0x02}, true))
+		conn := newConn(mock, true, 0, 0)
+
+		data, err := conn.ReadBinary()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x01, 0x02}, data)
+	})
+
+	t.Run("ReadBinary rejects text message", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		data, err := conn.ReadBinary()
+		assert.Nil(t, data)
+		var typeErr *MessageTypeError
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, BinaryMessage, typeErr.Expected)
+		assert.Equal(t, TextMessage, typeErr.Got)
+	})
+
+	t.Run("Control frames are handled transparently", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(PingMessage), []byte("p"), true))
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		text, err := conn.ReadText()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", text)
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, PongMessage, frames[0].opcode)
+		assert.Equal(t, []byte("p"), frames[0].payload)
+	})
+
+	t.Run("Close is returned as CloseError", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, "bye"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, err := conn.ReadBinary()
+		var closeErr *CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, CloseNormalClosure, closeErr.Code)
+		assert.NotErrorIs(t, err, ErrUnexpectedMessageType)
+	})
+}
+
 func TestConnClose(t *testing.T) {
 	mock := newMockConn()
 	conn := newConn(mock, true, 0, 0)
@@ -702,6 +920,37 @@ func TestCloseWithMessage(t *testing.T) {
 		assert.Equal(t, byte(CloseMessage)|finalBit, data[0])
 	})
 
+	t.Run("Invalid code closes without close frame", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		err := conn.CloseWithMessage(CloseAbnormalClosure, "")
+		assert.ErrorIs(t, err, ErrInvalidCloseCode)
+		assert.True(t, mock.closed)
+		assert.True(t, conn.IsClosed())
+		assert.Empty(t, mock.writeBuf.Bytes())
+	})
+
+	t.Run("Invalid reason closes without close frame", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		err := conn.CloseWithMessage(CloseGoingAway, "\xfe")
+		assert.ErrorIs(t, err, ErrInvalidCloseReason)
+		assert.True(t, mock.closed)
+		assert.Empty(t, mock.writeBuf.Bytes())
+	})
+
+	t.Run("No status received sends empty close frame", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		require.NoError(t, conn.CloseWithMessage(CloseNoStatusReceived, ""))
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Empty(t, frames[0].payload)
+	})
+
 	t.Run("Double close is safe", func(t *testing.T) {
 		mock := newMockConn()
 		conn := newConn(mock, true, 0, 0)
@@ -730,78 +979,495 @@ func TestCloseWithMessage(t *testing.T) {
 		assert.True(t, mock.closed)
 	})
 
-	t.Run("Buffer pool return under lock", func(t *testing.T) {
-		pool := &testBufferPool{}
-		mock := newMockConn()
-		conn := newConnWithPool(mock, true, 0, 0, pool)
+	t.Run("Buffer pool untouched on close", func(t *testing.T) {
+		pool := &testBufferPool{}
+		mock := newMockConn()
+		conn := newConnWithPool(mock, true, 0, 0, pool)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("hello")))
+		require.Len(t, pool.buffers, 1)
+
+		err := conn.Close()
+		require.NoError(t, err)
+		assert.True(t, mock.closed)
+		assert.Len(t, pool.buffers, 1)
+		assert.Nil(t, conn.writeBuf)
+	})
+}
+
+func TestCloseGracefully(t *testing.T) {
+	t.Run("Peer echoes close", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		conn := newConn(server, true, 0, 0)
+		peer := newConn(client, false, 0, 0)
+
+		peerErr := make(chan error, 1)
+		go func() {
+			for {
+				if _, _, err := peer.ReadMessage(); err != nil {
+					peerErr <- err
+					return
+				}
+			}
+		}()
+
+		err := conn.CloseGracefully(CloseGoingAway, "bye", time.Second)
+		require.NoError(t, err)
+		assert.True(t, conn.IsClosed())
+
+		var closeErr *CloseError
+		require.ErrorAs(t, <-peerErr, &closeErr)
+		assert.Equal(t, CloseGoingAway, closeErr.Code)
+		assert.Equal(t, "bye", closeErr.Text)
+	})
+
+	t.Run("Drains pending messages before peer close", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		conn := newConn(server, true, 0, 0)
+
+		go func() {
+			// Consume the close frame, then send a message and a ping
+			// that were in flight before the peer's close.
+			frame := make([]byte, 4)
+			_, _ = io.ReadFull(client, frame)
+			_, _ = client.Write(buildMaskedFrame(byte(TextMessage), []byte("in flight"), true))
+			_, _ = client.Write(buildMaskedFrame(byte(PingMessage), nil, true))
+			_, _ = client.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, ""), true))
+		}()
+
+		err := conn.CloseGracefully(CloseNormalClosure, "", time.Second)
+		require.NoError(t, err)
+	})
+
+	t.Run("Concurrent reader receives peer close", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		conn := newConn(server, true, 0, 0)
+		peer := newConn(client, false, 0, 0)
+
+		// The peer sends a message, then echoes the close frame.
+		go func() {
+			_ = peer.WriteMessage(TextMessage, []byte("in flight"))
+			for {
+				if _, _, err := peer.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		readerErr := make(chan error, 1)
+		received := make(chan []byte, 1)
+		go func() {
+			for {
+				_, data, err := conn.ReadMessage()
+				if err != nil {
+					readerErr <- err
+					return
+				}
+				received <- data
+			}
+		}()
+
+		assert.Equal(t, []byte("in flight"), <-received)
+
+		// Wait until the reader is blocked on the next message.
+		require.Eventually(t, func() bool {
+			if conn.readMu.TryLock() {
+				conn.readMu.Unlock()
+				return false
+			}
+			return true
+		}, time.Second, time.Millisecond)
+
+		err := conn.CloseGracefully(CloseNormalClosure, "", time.Second)
+		require.NoError(t, err)
+
+		var closeErr *CloseError
+		require.ErrorAs(t, <-readerErr, &closeErr)
+		assert.Equal(t, CloseNormalClosure, closeErr.Code)
+	})
+
+	t.Run("Reads wait for the drain", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		conn := newConn(server, true, 0, 0)
+
+		release := make(chan struct{})
+		go func() {
+			frame := make([]byte, 4)
+			_, _ = io.ReadFull(client, frame)
+			<-release
+			_, _ = client.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, ""), true))
+		}()
+
+		done := make(chan error, 1)
+		go func() { done <- conn.CloseGracefully(CloseNormalClosure, "", time.Second) }()
+
+		// Wait until CloseGracefully owns the read side.
+		require.Eventually(t, func() bool {
+			if conn.readMu.TryLock() {
+				conn.readMu.Unlock()
+				return false
+			}
+			return true
+		}, time.Second, time.Millisecond)
+
+		readerErr := make(chan error, 1)
+		go func() {
+			_, _, err := conn.ReadMessage()
+			readerErr <- err
+		}()
+
+		close(release)
+		require.NoError(t, <-done)
+
+		var closeErr *CloseError
+		require.ErrorAs(t, <-readerErr, &closeErr)
+		assert.Equal(t, CloseNormalClosure, closeErr.Code)
+	})
+
+	t.Run("Peer never responds", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		conn := newConn(server, true, 0, 0)
+
+		// Consume the close frame but never answer it.
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+
+		start := time.Now()
+		err := conn.CloseGracefully(CloseNormalClosure, "", 50*time.Millisecond)
+		assert.ErrorIs(t, err, ErrCloseTimeout)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		assert.True(t, conn.IsClosed())
+	})
+
+	t.Run("Peer close already received", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, ""), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.ReadMessage()
+		require.Error(t, err)
+
+		err = conn.CloseGracefully(CloseNormalClosure, "", time.Second)
+		require.NoError(t, err)
+		assert.True(t, mock.closed)
+	})
+
+	t.Run("Non-positive timeout closes immediately", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		err := conn.CloseGracefully(CloseNormalClosure, "", 0)
+		require.NoError(t, err)
+		assert.True(t, mock.closed)
+		assert.Equal(t, byte(CloseMessage)|finalBit, mock.writeBuf.Bytes()[0])
+	})
+
+	t.Run("Concurrent with Close", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, ""), true))
+		conn := newConn(mock, true, 0, 0)
+
+		var wg sync.WaitGroup
+		wg.Go(func() { _ = conn.CloseGracefully(CloseNormalClosure, "", time.Second) })
+		wg.Go(func() { _ = conn.Close() })
+		wg.Wait()
+
+		assert.True(t, conn.IsClosed())
+		assert.True(t, mock.closed)
+	})
+}
+
+func TestReadLimitFragmentation(t *testing.T) {
+	t.Run("Fragmented uncompressed message exceeding limit", func(t *testing.T) {
+		mock := newMockConn()
+		// Each fragment is 60 bytes, limit is 100.
+		// Two fragments = 120 bytes, which exceeds the limit.
+		frag1 := make([]byte, 60)
+		frag2 := make([]byte, 60)
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), frag1, false))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), frag2, true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetReadLimit(100)
+
+		_, reader, err := conn.NextReader()
+		require.NoError(t, err)
+
+		// Read through the reader - should hit limit on continuation.
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrReadLimit)
+	})
+
+	t.Run("Fragmented uncompressed message under limit succeeds", func(t *testing.T) {
+		mock := newMockConn()
+		frag1 := []byte("hello")
+		frag2 := []byte("world")
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), frag1, false))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), frag2, true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetReadLimit(100)
+
+		_, reader, err := conn.NextReader()
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("helloworld"), data)
+	})
+
+	t.Run("Fragmented compressed message exceeding limit", func(t *testing.T) {
+		original := []byte("hello world, this is a fragmented test message")
+		compressed, err := compressData(original, -1)
+		require.NoError(t, err)
+
+		// Split into small fragments, set a very small limit.
+		half := len(compressed) / 2
+		part1 := compressed[:half]
+		part2 := compressed[half:]
+
+		var buf bytes.Buffer
+		buf.Write(buildMaskedFrameRaw(byte(TextMessage)|rsv1Bit, part1))
+		buf.Write(buildMaskedFrameRaw(byte(continuationFrame)|finalBit, part2))
+
+		mockConn := &mockConn{readBuf: &buf, writeBuf: &bytes.Buffer{}}
+		conn := newConn(mockConn, true, 1024, 1024)
+		conn.compressionEnabled = true
+		conn.SetReadLimit(int64(half)) // Limit smaller than total compressed size.
+
+		_, _, err = conn.NextReader()
+		assert.ErrorIs(t, err, ErrReadLimit)
+	})
+}
+
+func TestMessageDeadline(t *testing.T) {
+	// trickle writes the first frame of a text message and then a
+	// continuation frame every interval until the pipe fails. The frames
+	// written by the server are collected into written.
+	trickle := func(client net.Conn, interval time.Duration, written *bytes.Buffer, done chan struct{}) {
+		go func() {
+			defer close(done)
+			_, _ = io.Copy(written, client)
+		}()
+		go func() {
+			if _, err := client.Write(buildMaskedFrame(byte(TextMessage), []byte("a"), false)); err != nil {
+				return
+			}
+			for interval > 0 {
+				time.Sleep(interval)
+				if _, err := client.Write(buildMaskedFrame(byte(continuationFrame), []byte("b"), false)); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	t.Run("Trickled continuation frames", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		conn := newConn(server, true, 0, 0)
+		conn.SetMessageDeadline(100 * time.Millisecond)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		var written bytes.Buffer
+		done := make(chan struct{})
+		trickle(client, 20*time.Millisecond, &written, done)
+
+		start := time.Now()
+		_, _, err := conn.ReadMessage()
+		assert.ErrorIs(t, err, ErrMessageDeadline)
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		_, _, err = conn.ReadMessage()
+		assert.ErrorIs(t, err, ErrMessageDeadline)
+
+		_ = conn.Close()
+		<-done
+		require.GreaterOrEqual(t, written.Len(), 4)
+		assert.Equal(t, byte(CloseMessage)|finalBit, written.Bytes()[0])
+		assert.Equal(t, ClosePolicyViolation, int(binary.BigEndian.Uint16(written.Bytes()[2:4])))
+	})
+
+	t.Run("Stalled message", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		conn := newConn(server, true, 0, 0)
+		conn.SetMessageDeadline(50 * time.Millisecond)
+
+		var written bytes.Buffer
+		done := make(chan struct{})
+		trickle(client, 0, &written, done)
+
+		start := time.Now()
+		_, _, err := conn.ReadMessage()
+		assert.ErrorIs(t, err, ErrMessageDeadline)
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		_ = conn.Close()
+		<-done
+		require.GreaterOrEqual(t, written.Len(), 4)
+		assert.Equal(t, ClosePolicyViolation, int(binary.BigEndian.Uint16(written.Bytes()[2:4])))
+	})
+
+	t.Run("Earlier read deadline still applies", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		conn := newConn(server, true, 0, 0)
+		conn.SetMessageDeadline(5 * time.Second)
+
+		var written bytes.Buffer
+		done := make(chan struct{})
+		trickle(client, 0, &written, done)
+
+		_, _, err := conn.ReadMessageTimeout(50 * time.Millisecond)
+		assert.ErrorIs(t, err, ErrReadTimeout)
+		assert.NotErrorIs(t, err, ErrMessageDeadline)
+
+		_ = conn.Close()
+		<-done
+	})
+
+	t.Run("Deadline restored after message", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+
+		conn := newConn(server, true, 0, 0)
+		conn.SetMessageDeadline(50 * time.Millisecond)
+
+		go func() {
+			_, _ = client.Write(buildMaskedFrame(byte(TextMessage), []byte("hello "), false))
+			_, _ = client.Write(buildMaskedFrame(byte(continuationFrame), []byte("world"), true))
+			time.Sleep(150 * time.Millisecond)
+			_, _ = client.Write(buildMaskedFrame(byte(TextMessage), []byte("later"), true))
+		}()
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+
+		_, data, err = conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "later", string(data))
+		_ = conn.Close()
+	})
+
+	t.Run("Unfragmented message not bounded", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetMessageDeadline(time.Nanosecond)
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("Without deadline support", func(t *testing.T) {
+		var buf bytes.Buffer
+		buf.Write(buildMaskedFrame(byte(TextMessage), []byte("a"), false))
+		buf.Write(buildMaskedFrame(byte(continuationFrame), []byte("b"), true))
+		rwc := &mockConn{readBuf: &buf, writeBuf: &bytes.Buffer{}}
+
+		conn := newConnFromRWC(connConfig{rwc: rwc, isServer: true})
+		conn.SetMessageDeadline(time.Nanosecond)
 
-		err := conn.Close()
+		_, reader, err := conn.NextReader()
 		require.NoError(t, err)
-		assert.True(t, mock.closed)
-		assert.Len(t, pool.buffers, 1)
+		time.Sleep(time.Millisecond)
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrMessageDeadline)
 	})
 }
 
-func TestReadLimitFragmentation(t *testing.T) {
-	t.Run("Fragmented uncompressed message exceeding limit", func(t *testing.T) {
+func TestMaxFragments(t *testing.T) {
+	fragments := func(n int) *mockConn {
 		mock := newMockConn()
-		// Each fragment is 60 bytes, limit is 100.
-		// Two fragments = 120 bytes, which exceeds the limit.
-		frag1 := make([]byte, 60)
-		frag2 := make([]byte, 60)
-		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), frag1, false))
-		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), frag2, true))
+		for i := range n {
+			opcode := byte(continuationFrame)
+			if i == 0 {
+				opcode = byte(BinaryMessage)
+			}
+			mock.readBuf.Write(buildMaskedFrame(opcode, []byte{byte(i)}, i == n-1))
+		}
+		return mock
+	}
 
-		conn := newConn(mock, true, 0, 0)
-		conn.SetReadLimit(100)
+	t.Run("Within limit", func(t *testing.T) {
+		conn := newConn(fragments(3), true, 0, 0)
+		conn.SetMaxFragments(3)
 
-		_, reader, err := conn.NextReader()
+		_, data, err := conn.ReadMessage()
 		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 2}, data)
+	})
 
-		// Read through the reader - should hit limit on continuation.
-		_, err = io.ReadAll(reader)
-		assert.ErrorIs(t, err, ErrReadLimit)
+	t.Run("Exceeding limit", func(t *testing.T) {
+		mock := fragments(4)
+		conn := newConn(mock, true, 0, 0)
+		conn.SetMaxFragments(3)
+
+		_, _, err := conn.ReadMessage()
+		assert.ErrorIs(t, err, ErrTooManyFragments)
+
+		written := mock.writeBuf.Bytes()
+		require.GreaterOrEqual(t, len(written), 4)
+		assert.Equal(t, byte(CloseMessage)|finalBit, written[0])
+		assert.Equal(t, ClosePolicyViolation, int(binary.BigEndian.Uint16(written[2:4])))
+
+		_, _, err = conn.ReadMessage()
+		assert.ErrorIs(t, err, ErrTooManyFragments)
 	})
 
-	t.Run("Fragmented uncompressed message under limit succeeds", func(t *testing.T) {
+	t.Run("Control frames not counted", func(t *testing.T) {
 		mock := newMockConn()
-		frag1 := []byte("hello")
-		frag2 := []byte("world")
-		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), frag1, false))
-		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), frag2, true))
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("a"), false))
+		mock.readBuf.Write(buildMaskedFrame(byte(PingMessage), nil, true))
+		mock.readBuf.Write(buildMaskedFrame(byte(PongMessage), nil, true))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), []byte("b"), true))
 
 		conn := newConn(mock, true, 0, 0)
-		conn.SetReadLimit(100)
-
-		_, reader, err := conn.NextReader()
-		require.NoError(t, err)
+		conn.SetMaxFragments(2)
 
-		data, err := io.ReadAll(reader)
+		_, data, err := conn.ReadMessage()
 		require.NoError(t, err)
-		assert.Equal(t, []byte("helloworld"), data)
+		assert.Equal(t, "ab", string(data))
 	})
 
-	t.Run("Fragmented compressed message exceeding limit", func(t *testing.T) {
-		original := []byte("hello world, this is a fragmented test message")
-		compressed, err := compressData(original, -1)
+	t.Run("Compressed message", func(t *testing.T) {
+		compressed, err := compressData([]byte("hello compressed fragments"), -1)
 		require.NoError(t, err)
-
-		// Split into small fragments, set a very small limit.
-		half := len(compressed) / 2
-		part1 := compressed[:half]
-		part2 := compressed[half:]
+		third := len(compressed) / 3
 
 		var buf bytes.Buffer
-		buf.Write(buildMaskedFrameRaw(byte(TextMessage)|rsv1Bit, part1))
-		buf.Write(buildMaskedFrameRaw(byte(continuationFrame)|finalBit, part2))
+		buf.Write(buildMaskedFrameRaw(byte(TextMessage)|rsv1Bit, compressed[:third]))
+		buf.Write(buildMaskedFrameRaw(byte(continuationFrame), compressed[third:2*third]))
+		buf.Write(buildMaskedFrameRaw(byte(continuationFrame)|finalBit, compressed[2*third:]))
 
-		mockConn := &mockConn{readBuf: &buf, writeBuf: &bytes.Buffer{}}
-		conn := newConn(mockConn, true, 1024, 1024)
+		conn := newConn(&mockConn{readBuf: &buf, writeBuf: &bytes.Buffer{}}, true, 0, 0)
 		conn.compressionEnabled = true
-		conn.SetReadLimit(int64(half)) // Limit smaller than total compressed size.
+		conn.SetMaxFragments(2)
 
 		_, _, err = conn.NextReader()
-		assert.ErrorIs(t, err, ErrReadLimit)
+		assert.ErrorIs(t, err, ErrTooManyFragments)
+	})
+
+	t.Run("Unlimited by default", func(t *testing.T) {
+		conn := newConn(fragments(100), true, 0, 0)
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Len(t, data, 100)
 	})
 }
 
@@ -858,6 +1524,52 @@ func TestInvalidCloseCodeValidation(t *testing.T) {
 	}
 }
 
+func TestIsValidCloseCode(t *testing.T) {
+	for _, code := range []int{1000, 1001, 1002, 1003, 1007, 1008, 1009, 1010, 1011, 1012, 1013, 1014, 3000, 4000, 4999} {
+		t.Run(fmt.Sprintf("Valid %d", code), func(t *testing.T) {
+			assert.True(t, IsValidCloseCode(code))
+		})
+	}
+	for _, code := range []int{-1, 0, 999, 1004, 1005, 1006, 1015, 1016, 2999, 5000, 65535} {
+		t.Run(fmt.Sprintf("Invalid %d", code), func(t *testing.T) {
+			assert.False(t, IsValidCloseCode(code))
+		})
+	}
+}
+
+func TestInvalidCloseReason(t *testing.T) {
+	t.Run("Fails connection with invalid payload data", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseGoingAway, "bad \xff reason"), true))
+		conn := newConn(mock, true, 0, 0)
+		handlerCalled := false
+		conn.SetCloseHandler(func(int, string) error {
+			handlerCalled = true
+			return nil
+		})
+
+		_, _, err := conn.NextReader()
+		assert.ErrorIs(t, err, ErrInvalidCloseReason)
+		assert.False(t, handlerCalled)
+		assert.True(t, conn.IsClosed())
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, FormatCloseMessage(CloseInvalidFramePayloadData, "invalid close reason"), frames[0].payload)
+	})
+
+	t.Run("Valid UTF-8 reason is accepted", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseGoingAway, "до свидания"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.NextReader()
+		var closeErr *CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, "до свидания", closeErr.Text)
+	})
+}
+
 func TestRandReaderError(t *testing.T) {
 	t.Run("WriteControl propagates randReader error", func(t *testing.T) {
 		mock := newMockConn()
@@ -1069,6 +1781,99 @@ func TestControlFramesDuringFragmentation(t *testing.T) {
 	})
 }
 
+func TestConnDisableAutoPong(t *testing.T) {
+	pingThenText := func() *mockConn {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(PingMessage), []byte("hb"), true))
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("data"), true))
+		return mock
+	}
+
+	t.Run("Default replies with pong", func(t *testing.T) {
+		mock := pingThenText()
+		conn := newConn(mock, true, 0, 0)
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+		assert.Equal(t, []byte{finalBit | PongMessage, 2, 'h', 'b'}, mock.writeBuf.Bytes())
+	})
+
+	t.Run("Disabled writes no pong", func(t *testing.T) {
+		mock := pingThenText()
+		conn := newConn(mock, true, 0, 0)
+		conn.DisableAutoPong()
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+		assert.Empty(t, mock.writeBuf.Bytes())
+		assert.Equal(t, uint64(1), conn.Stats().PingsReceived)
+		assert.Equal(t, uint64(0), conn.Stats().PongsSent)
+	})
+
+	t.Run("Handler still invoked", func(t *testing.T) {
+		mock := pingThenText()
+		conn := newConn(mock, true, 0, 0)
+		conn.DisableAutoPong()
+
+		var pings []string
+		conn.SetPingHandler(func(appData string) error {
+			pings = append(pings, appData)
+			return nil
+		})
+
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hb"}, pings)
+		assert.Empty(t, mock.writeBuf.Bytes())
+	})
+
+	t.Run("Nil handler keeps pong disabled", func(t *testing.T) {
+		mock := pingThenText()
+		conn := newConn(mock, true, 0, 0)
+		conn.DisableAutoPong()
+		conn.SetPingHandler(func(string) error { return errors.New("replaced") })
+		conn.SetPingHandler(nil)
+
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Empty(t, mock.writeBuf.Bytes())
+	})
+
+	t.Run("ErrSkipPong is not a read error", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("he"), false))
+		mock.readBuf.Write(buildMaskedFrame(byte(PingMessage), []byte("hb"), true))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), []byte("llo"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		calls := 0
+		conn.SetPingHandler(func(string) error {
+			calls++
+			return fmt.Errorf("heartbeat handled: %w", ErrSkipPong)
+		})
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, mock.writeBuf.Bytes())
+	})
+
+	t.Run("Application reply from handler", func(t *testing.T) {
+		mock := pingThenText()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetPingHandler(func(appData string) error {
+			return conn.WriteControl(PongMessage, []byte("ack:"+appData), time.Time{})
+		})
+
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{finalBit | PongMessage, 6}, "ack:hb"...), mock.writeBuf.Bytes())
+	})
+}
+
 func TestIsClosed(t *testing.T) {
 	t.Run("Initially open", func(t *testing.T) {
 		mock := newMockConn()
@@ -1614,6 +2419,43 @@ func BenchmarkReadMessage(b *testing.B) {
 	}
 }
 
+func BenchmarkReadMessageBuffer(b *testing.B) {
+	sizes := []struct {
+		name string
+		size int
+	}{
+		{"Small_64B", 64},
+		{"Medium_1KB", 1024},
+		{"Large_64KB", 64 * 1024},
+	}
+
+	for _, size := range sizes {
+		payload := make([]byte, size.size)
+		for i := range payload {
+			payload[i] = byte(i % 256)
+		}
+
+		frame, _ := buildFrame(BinaryMessage, payload, true, false)
+
+		b.Run(size.name, func(b *testing.B) {
+			b.SetBytes(int64(size.size))
+			b.ReportAllocs()
+
+			buf := make([]byte, 0, size.size)
+			for b.Loop() {
+				mock := &benchMockConn{
+					readBuf: bytes.NewBuffer(frame),
+					buf:     make([]byte, 0, 1024),
+				}
+				conn := newConn(mock, true, 0, 0)
+				conn.SetReadLimit(int64(size.size + 1024))
+
+				_, buf, _ = conn.ReadMessageBuffer(buf)
+			}
+		})
+	}
+}
+
 func BenchmarkWriteControl(b *testing.B) {
 	mock := &benchMockConn{buf: make([]byte, 0, 256)}
 	conn := newConn(mock, true, 0, 0)
@@ -2468,77 +3310,196 @@ func TestStartKeepalive(t *testing.T) {
 		client.Close()
 		conn.Close()
 	})
-}
+}
+
+func TestReadMessageContext(t *testing.T) {
+	t.Run("Context cancellation", func(t *testing.T) {
+		server, client := net.Pipe()
+
+		conn := newConn(server, true, 1024, 1024)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, _, err := conn.ReadMessageContext(ctx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		client.Close()
+		conn.Close()
+	})
+
+	t.Run("Successful read before cancellation", func(t *testing.T) {
+		server, client := net.Pipe()
+
+		sConn := newConn(server, true, 1024, 1024)
+
+		// Write a message from the client side.
+		go func() {
+			frame := buildMaskedFrame(byte(TextMessage), []byte("hello"), true)
+			_, _ = client.Write(frame)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		msgType, p, err := sConn.ReadMessageContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, []byte("hello"), p)
+
+		// Close client first to unblock close frame write.
+		client.Close()
+		sConn.Close()
+	})
+
+	t.Run("Deadline reset after context cancellation", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+
+		// Wrap server with deadline tracking.
+		tracked := &deadlineTrackingConn{Conn: server}
+		conn := newConnFromRWC(connConfig{
+			rwc:             tracked,
+			netConn:         tracked,
+			isServer:        true,
+			readBufferSize:  1024,
+			writeBufferSize: 1024,
+			writeBufferPool: nil,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, _, err := conn.ReadMessageContext(ctx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// Verify the deadline was reset to zero (not left in the past).
+		tracked.mu.Lock()
+		lastDeadline := tracked.lastReadDeadline
+		tracked.mu.Unlock()
+		assert.True(t, lastDeadline.IsZero(), "read deadline should be reset to zero after context cancellation")
+	})
+}
+
+func TestReadMessageTimeout(t *testing.T) {
+	t.Run("Message arrives in time", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		defer client.Close()
+
+		conn := newConn(server, true, 1024, 1024)
+		go func() {
+			_, _ = client.Write(buildMaskedFrame(byte(TextMessage), []byte("hello"), true))
+		}()
+
+		msgType, p, err := conn.ReadMessageTimeout(time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, []byte("hello"), p)
+	})
 
-func TestReadMessageContext(t *testing.T) {
-	t.Run("Context cancellation", func(t *testing.T) {
+	t.Run("Timeout fires and connection stays readable", func(t *testing.T) {
 		server, client := net.Pipe()
+		defer server.Close()
+		defer client.Close()
 
 		conn := newConn(server, true, 1024, 1024)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
-		defer cancel()
-
-		_, _, err := conn.ReadMessageContext(ctx)
-		require.Error(t, err)
-		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		_, _, err := conn.ReadMessageTimeout(20 * time.Millisecond)
+		require.ErrorIs(t, err, ErrReadTimeout)
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+		var closeErr *CloseError
+		assert.False(t, errors.As(err, &closeErr))
 
-		client.Close()
-		conn.Close()
+		go func() {
+			_, _ = client.Write(buildMaskedFrame(byte(BinaryMessage), []byte{1, 2}, true))
+		}()
+		msgType, p, err := conn.ReadMessageTimeout(time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, BinaryMessage, msgType)
+		assert.Equal(t, []byte{1, 2}, p)
 	})
 
-	t.Run("Successful read before cancellation", func(t *testing.T) {
+	t.Run("Ping during wait is answered", func(t *testing.T) {
 		server, client := net.Pipe()
+		defer server.Close()
+		defer client.Close()
 
-		sConn := newConn(server, true, 1024, 1024)
-
-		// Write a message from the client side.
+		conn := newConn(server, true, 1024, 1024)
+		pong := make(chan []byte, 1)
 		go func() {
-			frame := buildMaskedFrame(byte(TextMessage), []byte("hello"), true)
-			_, _ = client.Write(frame)
+			_, _ = client.Write(buildMaskedFrame(byte(PingMessage), []byte("p"), true))
+			buf := make([]byte, 3)
+			_, _ = io.ReadFull(client, buf)
+			pong <- buf
+			_, _ = client.Write(buildMaskedFrame(byte(TextMessage), []byte("reply"), true))
 		}()
 
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-
-		msgType, p, err := sConn.ReadMessageContext(ctx)
+		_, p, err := conn.ReadMessageTimeout(time.Second)
 		require.NoError(t, err)
-		assert.Equal(t, TextMessage, msgType)
-		assert.Equal(t, []byte("hello"), p)
+		assert.Equal(t, []byte("reply"), p)
+		assert.Equal(t, []byte{PongMessage | finalBit, 1, 'p'}, <-pong)
+	})
 
-		// Close client first to unblock close frame write.
-		client.Close()
-		sConn.Close()
+	t.Run("Close is not a timeout", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseGoingAway, "bye"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.ReadMessageTimeout(time.Second)
+		var closeErr *CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, CloseGoingAway, closeErr.Code)
+		assert.NotErrorIs(t, err, ErrReadTimeout)
 	})
 
-	t.Run("Deadline reset after context cancellation", func(t *testing.T) {
+	t.Run("Connection deadline is restored", func(t *testing.T) {
 		server, client := net.Pipe()
 		defer client.Close()
 		defer server.Close()
 
-		// Wrap server with deadline tracking.
 		tracked := &deadlineTrackingConn{Conn: server}
 		conn := newConnFromRWC(connConfig{
-			rwc:             tracked,
-			netConn:         tracked,
-			isServer:        true,
-			readBufferSize:  1024,
-			writeBufferSize: 1024,
-			writeBufferPool: nil,
+			rwc:      tracked,
+			netConn:  tracked,
+			isServer: true,
 		})
 
-		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
-		defer cancel()
+		connDeadline := time.Now().Add(time.Hour)
+		require.NoError(t, conn.SetReadDeadline(connDeadline))
 
-		_, _, err := conn.ReadMessageContext(ctx)
-		require.Error(t, err)
-		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		_, _, err := conn.ReadMessageTimeout(10 * time.Millisecond)
+		require.ErrorIs(t, err, ErrReadTimeout)
 
-		// Verify the deadline was reset to zero (not left in the past).
 		tracked.mu.Lock()
 		lastDeadline := tracked.lastReadDeadline
 		tracked.mu.Unlock()
-		assert.True(t, lastDeadline.IsZero(), "read deadline should be reset to zero after context cancellation")
+		assert.True(t, lastDeadline.Equal(time.Unix(0, connDeadline.UnixNano())))
+	})
+
+	t.Run("Earlier connection deadline wins", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+
+		conn := newConn(server, true, 1024, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+
+		_, _, err := conn.ReadMessageTimeout(time.Hour)
+		require.Error(t, err)
+		assert.True(t, isTimeout(err))
+		assert.NotErrorIs(t, err, ErrReadTimeout)
+	})
+
+	t.Run("Deadlines not supported", func(t *testing.T) {
+		conn := newConnFromRWC(connConfig{rwc: &mockRWC{}, isServer: true})
+		_, _, err := conn.ReadMessageTimeout(time.Second)
+		assert.ErrorIs(t, err, ErrDeadlineNotSupported)
 	})
 }
 
@@ -2952,6 +3913,220 @@ func (d *writeDeadlineTrackingConn) getLastWriteDeadline() time.Time {
 	return d.lastWriteDeadline
 }
 
+func TestWriteControlContext(t *testing.T) {
+	t.Run("Writes control frame", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		require.NoError(t, conn.WriteControlContext(context.Background(), PingMessage, []byte("p")))
+		assert.Equal(t, []byte{byte(PingMessage) | finalBit, 1, 'p'}, mock.writeBuf.Bytes())
+	})
+
+	t.Run("Cancelled context does not write", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, conn.WriteControlContext(ctx, PingMessage, nil), context.Canceled)
+		assert.Zero(t, mock.writeBuf.Len())
+		assert.NoError(t, conn.WriteMessage(TextMessage, []byte("still usable")))
+	})
+
+	t.Run("Invalid control frame", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		assert.ErrorIs(t, conn.WriteControlContext(context.Background(), TextMessage, nil), ErrInvalidControlFrame)
+	})
+
+	t.Run("Cancel interrupts blocked write", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		defer clientSide.Close()
+		defer serverSide.Close()
+		conn := newConn(serverSide, true, 0, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		// Nobody reads the pipe, so the write blocks until cancel.
+		err := conn.WriteControlContext(ctx, PingMessage, []byte("p"))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, conn.WriteMessage(TextMessage, []byte("x")), context.Canceled)
+	})
+
+	t.Run("Context deadline bounds write", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		defer clientSide.Close()
+		defer serverSide.Close()
+		conn := newConn(serverSide, true, 0, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		assert.Error(t, conn.WriteControlContext(ctx, PingMessage, nil))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Deadline cleared after write", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+
+		tracked := &writeDeadlineTrackingConn{Conn: server}
+		conn := newConn(tracked, true, 0, 0)
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		require.NoError(t, conn.WriteControlContext(ctx, PingMessage, nil))
+		assert.True(t, tracked.getLastWriteDeadline().IsZero())
+	})
+}
+
+func TestNextWriterContext(t *testing.T) {
+	t.Run("Writes message", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		w, err := conn.NextWriterContext(context.Background(), TextMessage)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("hel"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("lo"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		reader := newConn(&mockConn{readBuf: bytes.NewBuffer(mock.writeBuf.Bytes()), writeBuf: &bytes.Buffer{}}, false, 0, 0)
+		msgType, data, err := reader.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, msgType)
+		assert.Equal(t, []byte("hello"), data)
+	})
+
+	t.Run("Cancelled context does not start message", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		w, err := conn.NextWriterContext(ctx, TextMessage)
+		assert.Nil(t, w)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, conn.WriteMessage(TextMessage, []byte("still usable")))
+	})
+
+	t.Run("Invalid message type", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		_, err := conn.NextWriterContext(context.Background(), PingMessage)
+		assert.ErrorIs(t, err, ErrInvalidMessageType)
+	})
+
+	t.Run("Cancel mid-stream fails later writes", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := conn.NextWriterContext(ctx, BinaryMessage)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("part"))
+		require.NoError(t, err)
+
+		cancel()
+
+		_, err = w.Write([]byte("more"))
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = w.Write([]byte("again"))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, w.Close(), context.Canceled)
+
+		// The unfinished message cannot be continued.
+		assert.ErrorIs(t, conn.WriteMessage(TextMessage, []byte("x")), context.Canceled)
+	})
+
+	t.Run("Cancel before first frame keeps connection usable", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.compressionEnabled = true
+		conn.EnableWriteCompression(true)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := conn.NextWriterContext(ctx, TextMessage)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("buffered"))
+		require.NoError(t, err)
+
+		cancel()
+
+		assert.ErrorIs(t, w.Close(), context.Canceled)
+		assert.Zero(t, mock.writeBuf.Len())
+		assert.NoError(t, conn.WriteMessage(TextMessage, []byte("next")))
+	})
+
+	t.Run("Cancel interrupts blocked write", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		defer clientSide.Close()
+		defer serverSide.Close()
+		conn := newConn(serverSide, true, 0, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := conn.NextWriterContext(ctx, BinaryMessage)
+		require.NoError(t, err)
+
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		// Nobody reads the pipe, so the write blocks until cancel.
+		_, err = w.Write(make([]byte, 64))
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = w.Write([]byte("x"))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, w.Close(), context.Canceled)
+		assert.ErrorIs(t, conn.WriteMessage(TextMessage, []byte("x")), context.Canceled)
+	})
+
+	t.Run("Context deadline bounds stream", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		defer clientSide.Close()
+		defer serverSide.Close()
+		conn := newConn(serverSide, true, 0, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		w, err := conn.NextWriterContext(ctx, BinaryMessage)
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = w.Write(make([]byte, 64))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Deadline cleared after close", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+
+		tracked := &writeDeadlineTrackingConn{Conn: server}
+		conn := newConn(tracked, true, 0, 0)
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		w, err := conn.NextWriterContext(ctx, TextMessage)
+		require.NoError(t, err)
+		assert.False(t, tracked.getLastWriteDeadline().IsZero())
+
+		_, err = w.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		assert.True(t, tracked.getLastWriteDeadline().IsZero())
+	})
+}
+
 func TestWriteControlClearsDeadline(t *testing.T) {
 	t.Run("Deadline cleared after ping", func(t *testing.T) {
 		server, client := net.Pipe()
@@ -2980,36 +4155,155 @@ func TestWriteControlClearsDeadline(t *testing.T) {
 		conn := newConn(server, true, 0, 0)
 		defer conn.Close()
 
-		err := conn.WriteControl(PingMessage, nil, time.Now().Add(5*time.Second))
-		require.NoError(t, err)
+		err := conn.WriteControl(PingMessage, nil, time.Now().Add(5*time.Second))
+		require.NoError(t, err)
+
+		err = conn.WriteMessage(TextMessage, []byte("hello"))
+		require.NoError(t, err)
+	})
+}
+
+func TestMessageReaderReadLimitSetsReadErr(t *testing.T) {
+	t.Run("readErr set on limit exceeded", func(t *testing.T) {
+		mock := newMockConn()
+		frag1 := make([]byte, 60)
+		frag2 := make([]byte, 60)
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), frag1, false))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), frag2, true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetReadLimit(100)
+
+		_, reader, err := conn.NextReader()
+		require.NoError(t, err)
+
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrReadLimit)
+
+		// readErr should be set, so the next NextReader call returns
+		// ErrReadLimit immediately instead of a confusing error.
+		_, _, err = conn.NextReader()
+		assert.ErrorIs(t, err, ErrReadLimit)
+	})
+}
+
+func TestReadLimitClose(t *testing.T) {
+	t.Run("Single frame over limit sends message too big", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), make([]byte, 200), true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetReadLimit(100)
+
+		_, _, err := conn.NextReader()
+		assert.ErrorIs(t, err, ErrReadLimit)
+		assert.True(t, conn.IsClosed())
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, CloseMessage, frames[0].opcode)
+		assert.Equal(t, FormatCloseMessage(CloseMessageTooBig, "message too big"), frames[0].payload)
+	})
+
+	t.Run("Fragmented message over limit sends message too big", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), make([]byte, 60), false))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), make([]byte, 60), true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetReadLimit(100)
+
+		_, reader, err := conn.NextReader()
+		require.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrReadLimit)
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, FormatCloseMessage(CloseMessageTooBig, "message too big"), frames[0].payload)
+	})
+
+	t.Run("Decompressed message over limit sends message too big", func(t *testing.T) {
+		compressed, err := compressData(bytes.Repeat([]byte("a"), 1000), 6)
+		require.NoError(t, err)
+
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrameRaw(byte(TextMessage)|finalBit|rsv1Bit, compressed))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.compressionEnabled = true
+		conn.SetReadLimit(100)
+
+		_, _, err = conn.NextReader()
+		assert.ErrorIs(t, err, ErrReadLimit)
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, FormatCloseMessage(CloseMessageTooBig, "message too big"), frames[0].payload)
+	})
+
+	t.Run("Custom close code and reason", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), make([]byte, 200), true))
+
+		conn := newConn(mock, true, 0, 0)
+		conn.SetReadLimit(100)
+		conn.SetReadLimitError(ClosePolicyViolation, "upload too large")
+
+		_, _, err := conn.NextReader()
+		assert.ErrorIs(t, err, ErrReadLimit)
 
-		err = conn.WriteMessage(TextMessage, []byte("hello"))
-		require.NoError(t, err)
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, FormatCloseMessage(ClosePolicyViolation, "upload too large"), frames[0].payload)
 	})
-}
 
-func TestMessageReaderReadLimitSetsReadErr(t *testing.T) {
-	t.Run("readErr set on limit exceeded", func(t *testing.T) {
+	t.Run("Zero code disables close frame", func(t *testing.T) {
 		mock := newMockConn()
-		frag1 := make([]byte, 60)
-		frag2 := make([]byte, 60)
-		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), frag1, false))
-		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), frag2, true))
+		mock.readBuf.Write(buildMaskedFrame(byte(BinaryMessage), make([]byte, 200), true))
 
 		conn := newConn(mock, true, 0, 0)
 		conn.SetReadLimit(100)
+		conn.SetReadLimitError(0, "")
 
-		_, reader, err := conn.NextReader()
-		require.NoError(t, err)
-
-		_, err = io.ReadAll(reader)
+		_, _, err := conn.NextReader()
 		assert.ErrorIs(t, err, ErrReadLimit)
+		assert.False(t, conn.IsClosed())
+		assert.Empty(t, mock.writeBuf.Bytes())
 
-		// readErr should be set, so the next NextReader call returns
-		// ErrReadLimit immediately instead of a confusing error.
 		_, _, err = conn.NextReader()
 		assert.ErrorIs(t, err, ErrReadLimit)
 	})
+
+	t.Run("Client connection receives close over the wire", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		defer client.Close()
+
+		serverConn := newConn(server, true, 0, 0)
+		serverConn.SetReadLimit(10)
+		clientConn := newConn(client, false, 0, 0)
+
+		go func() {
+			_ = clientConn.WriteMessage(TextMessage, []byte("this message is too long"))
+		}()
+
+		errCh := make(chan error, 1)
+		go func() {
+			_, _, err := clientConn.ReadMessage()
+			errCh <- err
+		}()
+
+		_, _, err := serverConn.ReadMessage()
+		assert.ErrorIs(t, err, ErrReadLimit)
+
+		select {
+		case err := <-errCh:
+			assert.True(t, IsCloseError(err, CloseMessageTooBig), "got %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("client did not receive close frame")
+		}
+	})
 }
 
 func TestMessageReaderReadFrameErrorSetsReadErr(t *testing.T) {
@@ -3081,66 +4375,128 @@ func (p *largeBufferPool) Put(buf any) {
 }
 
 func TestNewConnFromRWCWriteBufferPool(t *testing.T) {
-	t.Run("Pool returns valid buffer that is reused", func(t *testing.T) {
-		needed := defaultWriteBufferSize + maxFrameHeaderSize
-		poolBuf := make([]byte, needed+100) // larger than needed
-		pool := &largeBufferPool{buf: poolBuf}
-
+	newPooledConn := func(pool BufferPool) (*Conn, *mockRWC) {
 		rwc := &mockRWC{}
 		conn := newConnFromRWC(connConfig{
 			rwc:             rwc,
-			netConn:         nil,
 			isServer:        true,
-			readBufferSize:  0,
-			writeBufferSize: 0,
 			writeBufferPool: pool,
 		})
+		return conn, rwc
+	}
+
+	t.Run("No buffer is taken until the first write", func(t *testing.T) {
+		pool := &largeBufferPool{buf: make([]byte, 16)}
+		conn, _ := newPooledConn(pool)
+
+		assert.Nil(t, conn.writeBuf)
+		assert.NotNil(t, pool.buf)
+	})
+
+	t.Run("Pool buffer is borrowed per write and returned", func(t *testing.T) {
+		needed := defaultWriteBufferSize + maxFrameHeaderSize
+		poolBuf := make([]byte, needed+100) // larger than needed
+		pool := &largeBufferPool{buf: poolBuf}
+		conn, rwc := newPooledConn(pool)
+
+		require.NoError(t, conn.WriteMessage(BinaryMessage, []byte("data")))
+
+		require.Len(t, pool.putBufs, 1)
+		returned, ok := pool.putBufs[0].(*[]byte)
+		require.True(t, ok)
+		assert.Equal(t, needed, len(*returned))
+		assert.Equal(t, &poolBuf[0], &(*returned)[0])
+		assert.Nil(t, conn.writeBuf)
+		assert.Equal(t, []byte{byte(BinaryMessage) | finalBit, 4, 'd', 'a', 't', 'a'}, rwc.writeBuf.Bytes())
+	})
 
-		// The writeBuf should be a slice of the pooled buffer.
-		assert.Equal(t, needed, len(conn.writeBuf))
-		// Verify it is the same underlying array as the pool buffer.
-		assert.Equal(t, &poolBuf[0], &conn.writeBuf[0])
+	t.Run("Pointer from the pool is put back as is", func(t *testing.T) {
+		needed := defaultWriteBufferSize + maxFrameHeaderSize
+		poolBuf := make([]byte, needed)
+		pool := &testBufferPool{buffers: []any{&poolBuf}}
+		conn, _ := newPooledConn(pool)
+
+		require.NoError(t, conn.WriteMessage(BinaryMessage, []byte("data")))
+
+		require.Len(t, pool.buffers, 1)
+		returned, ok := pool.buffers[0].(*[]byte)
+		require.True(t, ok)
+		assert.Same(t, &poolBuf, returned)
 	})
 
 	t.Run("Pool returns buffer too small, falls back to alloc", func(t *testing.T) {
 		needed := defaultWriteBufferSize + maxFrameHeaderSize
 		smallBuf := make([]byte, needed-1) // too small
 		pool := &largeBufferPool{buf: smallBuf}
+		conn, _ := newPooledConn(pool)
 
-		rwc := &mockRWC{}
-		conn := newConnFromRWC(connConfig{
-			rwc:             rwc,
-			netConn:         nil,
-			isServer:        true,
-			readBufferSize:  0,
-			writeBufferSize: 0,
-			writeBufferPool: pool,
-		})
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("x")))
 
-		// Should have allocated a new buffer of the correct size.
-		assert.Equal(t, needed, len(conn.writeBuf))
-		// The returned buffer from pool was too small so it should not have been used.
-		assert.NotEqual(t, cap(smallBuf), cap(conn.writeBuf))
+		require.Len(t, pool.putBufs, 1)
+		returned, ok := pool.putBufs[0].(*[]byte)
+		require.True(t, ok)
+		assert.Equal(t, needed, len(*returned))
+		assert.NotEqual(t, cap(smallBuf), cap(*returned))
 	})
 
 	t.Run("Pool returns non-byte-slice, falls back to alloc", func(t *testing.T) {
 		pool := &largeBufferPool{buf: nil} // Get() returns nil
+		conn, _ := newPooledConn(pool)
 
-		rwc := &mockRWC{}
-		conn := newConnFromRWC(connConfig{
-			rwc:             rwc,
-			netConn:         nil,
-			isServer:        true,
-			readBufferSize:  0,
-			writeBufferSize: 0,
-			writeBufferPool: pool,
-		})
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("x")))
 
 		needed := defaultWriteBufferSize + maxFrameHeaderSize
-		assert.Equal(t, needed, len(conn.writeBuf))
+		require.Len(t, pool.putBufs, 1)
+		returned, ok := pool.putBufs[0].(*[]byte)
+		require.True(t, ok)
+		assert.Len(t, *returned, needed)
+	})
+
+	t.Run("Without a pool the buffer is allocated lazily and kept", func(t *testing.T) {
+		conn, _ := newPooledConn(nil)
+		assert.Nil(t, conn.writeBuf)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("x")))
+		require.NotNil(t, conn.writeBuf)
+		first := &conn.writeBuf[0]
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("y")))
+		assert.Equal(t, first, &conn.writeBuf[0])
 	})
 }
 
+// BenchmarkIdleConnMemory reports the steady-state heap held by 10k idle
+// connections that have exchanged one message each.
+func BenchmarkIdleConnMemory(b *testing.B) {
+	const conns = 10000
+
+	run := func(b *testing.B, pool BufferPool) {
+		for b.Loop() {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+
+			held := make([]*Conn, conns)
+			for i := range held {
+				held[i] = newConnFromRWC(connConfig{
+					rwc:             &benchMockConn{},
+					isServer:        true,
+					writeBufferPool: pool,
+				})
+				_ = held[i].WriteMessage(TextMessage, []byte("hello"))
+			}
+
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/conns, "B/conn")
+			runtime.KeepAlive(held)
+		}
+	}
+
+	b.Run("NoPool", func(b *testing.B) { run(b, nil) })
+	b.Run("SyncPool", func(b *testing.B) { run(b, &sync.Pool{}) })
+}
+
 func TestReadFrameTruncatedExtendedLength(t *testing.T) {
 	t.Run("16-bit extended length truncated", func(t *testing.T) {
 		mock := newMockConn()
@@ -3209,6 +4565,328 @@ func TestReadMessageReadAllError(t *testing.T) {
 	})
 }
 
+func TestCompressionThreshold(t *testing.T) {
+	newCompressingConn := func() (*Conn, *mockConn) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.compressionEnabled = true
+		conn.EnableWriteCompression(true)
+		return conn, mock
+	}
+	large := bytes.Repeat([]byte("compress me "), 20)
+
+	t.Run("Message below threshold is sent uncompressed", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		conn.SetCompressionThreshold(64)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("tiny")))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.False(t, frames[0].compressed)
+		assert.Equal(t, []byte("tiny"), frames[0].payload)
+	})
+
+	t.Run("Message at or above threshold is compressed", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		conn.SetCompressionThreshold(len(large))
+
+		require.NoError(t, conn.WriteMessage(TextMessage, large))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.True(t, frames[0].compressed)
+		assert.Less(t, len(frames[0].payload), len(large))
+	})
+
+	t.Run("Zero threshold compresses every message", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		conn.SetCompressionThreshold(-1)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("tiny")))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.True(t, frames[0].compressed)
+	})
+
+	t.Run("NextWriter applies threshold to whole message", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		conn.SetCompressionThreshold(8)
+
+		w, err := conn.NextWriter(TextMessage)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte("abc"))
+		_, _ = w.Write([]byte("def"))
+		require.NoError(t, w.Close())
+
+		w, err = conn.NextWriter(TextMessage)
+		require.NoError(t, err)
+		_, _ = w.Write(large[:10])
+		_, _ = w.Write(large[10:])
+		require.NoError(t, w.Close())
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 2)
+		assert.False(t, frames[0].compressed)
+		assert.Equal(t, []byte("abcdef"), frames[0].payload)
+		assert.True(t, frames[1].compressed)
+		assert.Less(t, len(frames[1].payload), len(large))
+	})
+
+	t.Run("Fragmented message below threshold is sent uncompressed", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		conn.SetCompressionThreshold(1024)
+		conn.SetWriteFragmentSize(16)
+
+		require.NoError(t, conn.WriteMessage(BinaryMessage, large))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Greater(t, len(frames), 1)
+		for _, f := range frames {
+			assert.False(t, f.compressed)
+		}
+	})
+
+	t.Run("Prepared message respects threshold", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		conn.SetCompressionThreshold(64)
+
+		pm, err := NewPreparedMessage(TextMessage, []byte("tiny"))
+		require.NoError(t, err)
+		require.NoError(t, conn.WritePreparedMessage(pm))
+
+		pm, err = NewPreparedMessage(TextMessage, large)
+		require.NoError(t, err)
+		require.NoError(t, conn.WritePreparedMessage(pm))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 2)
+		assert.False(t, frames[0].compressed)
+		assert.True(t, frames[1].compressed)
+	})
+
+	t.Run("Skip function disables compression per message", func(t *testing.T) {
+		conn, mock := newCompressingConn()
+		var calls []int
+		conn.SetSkipCompressionFunc(func(messageType int, _ []byte) bool {
+			calls = append(calls, messageType)
+			return messageType == BinaryMessage
+		})
+
+		require.NoError(t, conn.WriteMessage(BinaryMessage, large))
+		require.NoError(t, conn.WriteMessage(TextMessage, large))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 2)
+		assert.False(t, frames[0].compressed)
+		assert.Equal(t, large, frames[0].payload)
+		assert.True(t, frames[1].compressed)
+		assert.Equal(t, []int{BinaryMessage, TextMessage}, calls)
+	})
+
+	t.Run("Skip function not called when compression is disabled", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetSkipCompressionFunc(func(int, []byte) bool {
+			t.Fatal("skip function called without compression")
+			return false
+		})
+
+		require.NoError(t, conn.WriteMessage(TextMessage, large))
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.False(t, frames[0].compressed)
+	})
+
+	t.Run("Skip function not called below threshold", func(t *testing.T) {
+		conn, _ := newCompressingConn()
+		conn.SetCompressionThreshold(64)
+		conn.SetSkipCompressionFunc(func(int, []byte) bool {
+			t.Fatal("skip function called below threshold")
+			return false
+		})
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("tiny")))
+	})
+}
+
+// wireFrame is a frame decoded from bytes a Conn wrote.
+type wireFrame struct {
+	opcode     int
+	payload    []byte
+	final      bool
+	compressed bool
+}
+
+// readWireFrames decodes all frames in wire, as written by a server conn.
+func readWireFrames(t *testing.T, wire []byte) []wireFrame {
+	t.Helper()
+	mock := newMockConn()
+	mock.readBuf.Write(wire)
+	reader := newConn(mock, false, 0, 0)
+	reader.compressionEnabled = true
+
+	var frames []wireFrame
+	for {
+		opcode, payload, final, compressed, err := reader.readFrame()
+		if errors.Is(err, io.EOF) {
+			return frames
+		}
+		require.NoError(t, err)
+		frames = append(frames, wireFrame{opcode, append([]byte(nil), payload...), final, compressed})
+	}
+}
+
+func TestSetWriteFragmentSize(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 1024) // 10 KB
+
+	t.Run("WriteMessage splits into continuation frames", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetWriteFragmentSize(4096)
+
+		require.NoError(t, conn.WriteMessage(BinaryMessage, payload))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 3)
+		assert.Equal(t, BinaryMessage, frames[0].opcode)
+		assert.False(t, frames[0].final)
+		assert.Len(t, frames[0].payload, 4096)
+		assert.Equal(t, continuationFrame, frames[1].opcode)
+		assert.False(t, frames[1].final)
+		assert.Len(t, frames[1].payload, 4096)
+		assert.Equal(t, continuationFrame, frames[2].opcode)
+		assert.True(t, frames[2].final)
+		assert.Len(t, frames[2].payload, 10240-8192)
+
+		var joined []byte
+		for _, f := range frames {
+			joined = append(joined, f.payload...)
+		}
+		assert.Equal(t, payload, joined)
+		assert.Equal(t, uint64(1), conn.Stats().MessagesWritten)
+	})
+
+	t.Run("Zero disables fragmentation", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetWriteFragmentSize(4096)
+		conn.SetWriteFragmentSize(0)
+
+		require.NoError(t, conn.WriteMessage(BinaryMessage, payload))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.True(t, frames[0].final)
+		assert.Equal(t, payload, frames[0].payload)
+	})
+
+	t.Run("Small message is a single frame", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetWriteFragmentSize(4096)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("hi")))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, TextMessage, frames[0].opcode)
+		assert.True(t, frames[0].final)
+	})
+
+	t.Run("NextWriter splits large writes", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetWriteFragmentSize(4)
+
+		w, err := conn.NextWriter(TextMessage)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abcdefghij"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 4)
+		assert.Equal(t, TextMessage, frames[0].opcode)
+		assert.Equal(t, "abcd", string(frames[0].payload))
+		assert.Equal(t, "efgh", string(frames[1].payload))
+		assert.Equal(t, "ij", string(frames[2].payload))
+		assert.Equal(t, continuationFrame, frames[3].opcode)
+		assert.True(t, frames[3].final)
+		assert.Empty(t, frames[3].payload)
+		for _, f := range frames[:3] {
+			assert.False(t, f.final)
+		}
+	})
+
+	t.Run("Compressed payload is fragmented after compression", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.compressionEnabled = true
+		conn.EnableWriteCompression(true)
+		conn.SetWriteFragmentSize(8)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, payload))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Greater(t, len(frames), 1)
+		assert.True(t, frames[0].compressed)
+		for _, f := range frames[1:] {
+			assert.False(t, f.compressed, "RSV1 is only set on the first frame")
+			assert.Equal(t, continuationFrame, f.opcode)
+		}
+
+		reader := newMockConn()
+		reader.readBuf.Write(mock.writeBuf.Bytes())
+		client := newConn(reader, false, 0, 0)
+		client.compressionEnabled = true
+		_, p, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, payload, p)
+	})
+
+	t.Run("Control frames interleave with an open message", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetWriteFragmentSize(4)
+
+		w, err := conn.NextWriter(TextMessage)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abcd"))
+		require.NoError(t, err)
+
+		done := make(chan error, 1)
+		go func() { done <- conn.WriteControl(PingMessage, []byte("p"), time.Now().Add(time.Second)) }()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("WriteControl blocked by open message writer")
+		}
+
+		require.NoError(t, w.Close())
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 3)
+		assert.Equal(t, TextMessage, frames[0].opcode)
+		assert.Equal(t, PingMessage, frames[1].opcode)
+		assert.Equal(t, continuationFrame, frames[2].opcode)
+		assert.True(t, frames[2].final)
+	})
+
+	t.Run("Fragments satisfy the max frame size", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.SetMaxFrameSize(4096)
+
+		assert.ErrorIs(t, conn.WriteMessage(BinaryMessage, payload), ErrFrameSizeExceeded)
+
+		conn.SetWriteFragmentSize(4096)
+		assert.NoError(t, conn.WriteMessage(BinaryMessage, payload))
+	})
+}
+
 func TestMessageWriterWriteError(t *testing.T) {
 	t.Run("Write propagates writeFrameWithCompress error", func(t *testing.T) {
 		writeErr := errors.New("write failed")
@@ -3615,3 +5293,90 @@ func TestDefaultPongHandler(t *testing.T) {
 		assert.Equal(t, []byte("msg"), data)
 	})
 }
+
+func TestCloseHandlerEcho(t *testing.T) {
+	t.Run("Echoes valid close code and reason", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, "bye"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.NextReader()
+		assert.True(t, IsCloseError(err, CloseNormalClosure))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, CloseMessage, frames[0].opcode)
+		assert.Equal(t, FormatCloseMessage(CloseNormalClosure, "bye"), frames[0].payload)
+	})
+
+	t.Run("Echoes application close code", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(4001, ""), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.NextReader()
+		assert.True(t, IsCloseError(err, 4001))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, FormatCloseMessage(4001, ""), frames[0].payload)
+	})
+
+	t.Run("Answers empty close with empty close", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), nil, true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.NextReader()
+		assert.True(t, IsCloseError(err, CloseNoStatusReceived))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Empty(t, frames[0].payload)
+	})
+
+	t.Run("Nil restores echoing handler", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseGoingAway, ""), true))
+		conn := newConn(mock, true, 0, 0)
+		conn.SetCloseHandler(func(int, string) error { return nil })
+		conn.SetCloseHandler(nil)
+
+		_, _, err := conn.NextReader()
+		assert.True(t, IsCloseError(err, CloseGoingAway))
+
+		frames := readWireFrames(t, mock.writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.Equal(t, FormatCloseMessage(CloseGoingAway, ""), frames[0].payload)
+	})
+
+	for _, tc := range []struct {
+		name    string
+		payload []byte
+	}{
+		{"Reserved code", []byte{0x03, 0xED}},     // 1005
+		{"Unassigned code", []byte{0x07, 0xD0}},   // 2000
+		{"Out of range code", []byte{0x13, 0x88}}, // 5000
+	} {
+		t.Run(tc.name+" fails with protocol error", func(t *testing.T) {
+			mock := newMockConn()
+			mock.readBuf.Write(buildMaskedFrame(byte(CloseMessage), tc.payload, true))
+			conn := newConn(mock, true, 0, 0)
+			handlerCalled := false
+			conn.SetCloseHandler(func(int, string) error {
+				handlerCalled = true
+				return nil
+			})
+
+			_, _, err := conn.NextReader()
+			assert.ErrorIs(t, err, ErrInvalidCloseCode)
+			assert.False(t, handlerCalled)
+			assert.True(t, conn.IsClosed())
+
+			frames := readWireFrames(t, mock.writeBuf.Bytes())
+			require.Len(t, frames, 1)
+			assert.Equal(t, CloseMessage, frames[0].opcode)
+			assert.Equal(t, FormatCloseMessage(CloseProtocolError, "invalid close code"), frames[0].payload)
+		})
+	}
+}
@@ -50,15 +50,77 @@
 //	    log.Fatal(err)
 //	}
 //
+// HTTP/2:
+//
+// Setting Dialer.EnableHTTP2 offers h2 through TLS ALPN on wss:// URLs and
+// opens the connection with an RFC 8441 extended CONNECT request when the
+// server advertises SETTINGS_ENABLE_CONNECT_PROTOCOL, falling back to the
+// HTTP/1.1 upgrade otherwise. The Upgrader accepts both forms.
+//
+// Handshake Tracing:
+//
+// Dialer.Trace reports the DNS lookup, TCP connect, TLS handshake, and the
+// accepted upgrade of each dial through a HandshakeTrace. An
+// httptrace.ClientTrace in the DialContext context is honored as well. On
+// the server, Upgrader.OnUpgrade receives the duration and error of every
+// upgrade attempt.
+//
 // Concurrency:
 //
 // Connections support one concurrent reader and one concurrent writer.
 // Applications are responsible for ensuring that no more than one goroutine
-// calls the write methods (NextWriter, WriteMessage, WriteJSON, WritePreparedMessage,
-// WriteControl) concurrently, and that no more than one goroutine calls the
-// read methods (NextReader, ReadMessage, ReadJSON) concurrently.
+// calls the write methods (NextWriter, NextWriterContext, WriteMessage,
+// WriteJSON, WriteJSONWith, WritePreparedMessage, WriteControl,
+// WriteControlContext) concurrently, and
+// that no more than one goroutine calls the read methods (NextReader,
+// ReadMessage, ReadMessageBuffer, ReadText, ReadBinary, ReadJSON,
+// ReadJSONStrict) concurrently.
+//
+// The Close, CloseWithMessage, and CloseGracefully methods can be called
+// concurrently with other methods.
+//
+// Stats returns traffic counters and is also safe to call from any goroutine.
+//
+// EnableWriteQueue starts a writer goroutine that becomes the sole writer of
+// messages queued with WriteMessageAsync, which may then be called from any
+// number of goroutines. It returns ErrWriteQueueFull when the bounded queue is
+// full, giving producers a backpressure signal instead of blocking on a slow
+// peer.
+//
+// Typed Reads:
+//
+// ReadText and ReadBinary read the next message and return a
+// *MessageTypeError, matching ErrUnexpectedMessageType, when it has the
+// other data type. Control frames are handled as for ReadMessage, and the
+// connection remains readable after a type mismatch.
+//
+// Reusing Read Buffers:
+//
+// ReadMessageBuffer reads the next message into a caller-provided buffer,
+// appending to buf[:0] and growing it only when needed, so a read loop can
+// reuse one allocation. The returned data aliases buf.
+//
+// Read Timeouts:
 //
-// The Close method can be called concurrently with other methods.
+// ReadMessageTimeout reads the next message within a per-call timeout and
+// restores the connection's read deadline afterwards. A timeout matches
+// ErrReadTimeout, distinguishing it from a *CloseError; when it fires between
+// messages the connection remains readable.
+//
+// WriteControlContext writes a control frame bounded by a context: the context
+// deadline becomes the write deadline, and cancellation interrupts a blocked
+// write. NextWriterContext does the same for a streamed message: once the
+// context is done, Write and Close return ctx.Err().
+//
+// Connection Context:
+//
+// Conn.Context returns the request context (server) or DialContext context
+// (client) the connection was established with. With BindContext set on the
+// Upgrader or Dialer, the connection is closed when that context is done and
+// blocked reads and writes return an error wrapping the context's cause;
+// otherwise only its values are kept. UpgradeContext associates a context
+// other than the request context, which net/http cancels when the handler
+// returns.
 //
 // Keepalive:
 //
@@ -88,6 +150,23 @@
 //   - PingHandler: full control; the returned bytes become the pong payload.
 //     RequireEmptyPingPayload is still enforced before the handler is called.
 //
+// On any connection, DisableAutoPong stops the default ping handler from
+// answering while pings still reach the handler, and a ping handler returns
+// ErrSkipPong to leave a ping unanswered without failing the read. Ping
+// handlers run on the reading goroutine and may reply with WriteControl,
+// which is safe alongside a concurrent writer: control frames are written
+// whole, between the fragments of a data message.
+//
+// Fragmented Message Limits:
+//
+// SetMessageDeadline bounds the time from the first frame of a fragmented
+// message to its final frame, so a peer cannot keep a message open by
+// trickling continuation frames within the per-read deadline.
+// SetMaxFragments bounds the number of data frames in a message. Exceeding
+// either fails the read with ErrMessageDeadline or ErrTooManyFragments and
+// closes the connection with ClosePolicyViolation. Both are disabled by
+// default.
+//
 // Frame Size Limit:
 //
 // SetMaxFrameSize sets the maximum allowed payload length for a single WebSocket
@@ -96,6 +175,13 @@
 // Dialer expose MaxFrameSize to apply the limit automatically to every accepted
 // or dialed connection.
 //
+// Write Fragmentation:
+//
+// SetWriteFragmentSize splits outgoing data messages larger than n bytes into
+// continuation frames of at most n bytes. Control frames may be written between
+// the fragments of a message, so pings and close frames are not delayed by a
+// large transfer. A size of 0 disables fragmentation.
+//
 // Origin Checking:
 //
 // Web browsers allow any site to open a WebSocket connection to any other site.
@@ -103,12 +189,39 @@
 // calls the CheckOrigin function to validate the request origin. If CheckOrigin
 // is nil, the Upgrader uses a safe default that rejects cross-origin requests.
 //
+// Rejected handshakes, such as a disallowed origin (ErrOriginNotAllowed),
+// get a plain-text error response. Set Upgrader.Error to write a custom
+// response and log the reason instead.
+// CheckSameOrigin exposes the default check, so a custom CheckOrigin can
+// fall back to it.
+//
+// Connection Limit:
+//
+// Upgrader.MaxConnections caps the connections the Upgrader has accepted
+// that are still open. Further handshakes are rejected with 503 Service
+// Unavailable and ErrTooManyConnections, with a Retry-After header when
+// RetryAfter is set. A slot is freed once, when the connection is closed
+// or its transport fails, and CurrentConnections reports the slots in use.
+//
 // Compression:
 //
 // Per-message compression is negotiated during the WebSocket handshake when
 // EnableCompression is set to true on the Upgrader or Dialer. When compression
 // is enabled, messages are compressed using the permessage-deflate extension
 // (RFC 7692) with stateless compression (no context takeover).
+// SetCompressionThreshold sends messages smaller than a given size
+// uncompressed, and SetSkipCompressionFunc skips compression for payloads
+// chosen by a predicate, such as data that is already compressed. Both only
+// decide whether each message sets RSV1; negotiation is unaffected.
+//
+// Prepared Messages:
+//
+// A PreparedMessage caches the frame bytes of a message for broadcasting. Each
+// representation (compressed or not, masked for clients or not) is built the
+// first time a connection needs it and reused afterwards, so a pool mixing
+// compressed and uncompressed connections compresses the payload once.
+// WithPreparedVariants builds chosen representations when the message is
+// created.
 //
 // Extensions:
 //
@@ -2,32 +2,129 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 )
 
+// errTrailingJSON is wrapped in a JSONDecodeError by ReadJSONStrict when a
+// message holds more than one JSON value.
+var errTrailingJSON = errors.New("unexpected trailing data after JSON value")
+
+// JSONDecodeError is returned by ReadJSON and ReadJSONStrict when a message
+// was read but could not be decoded into the target value. Errors from
+// reading the message itself, such as a *CloseError or ErrReadLimit, are
+// returned as is, so callers can tell a malformed payload from a closed or
+// failed connection:
+//
+//	var decErr *websocket.JSONDecodeError
+//	if errors.As(err, &decErr) {
+//	    // reply with an error and keep reading
+//	}
+type JSONDecodeError struct {
+	Err error
+}
+
+func (e *JSONDecodeError) Error() string {
+	return "websocket: invalid JSON message: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *JSONDecodeError) Unwrap() error {
+	return e.Err
+}
+
 // WriteJSON writes the JSON encoding of v as a message.
 func (c *Conn) WriteJSON(v any) error {
+	return c.WriteJSONWith(v, encodeJSON)
+}
+
+// WriteJSONWith writes v as a text message using the provided encode function.
+// The encoder writes directly into the message writer returned by NextWriter,
+// without an intermediate copy of the encoded value. When compression is
+// active the message is still buffered in full before it is written, since
+// RFC 7692 compresses the message as a whole.
+// A nil encode falls back to the encoding/json encoder used by WriteJSON.
+func (c *Conn) WriteJSONWith(v any, encode func(w io.Writer, v any) error) error {
+	if encode == nil {
+		encode = encodeJSON
+	}
 	w, err := c.NextWriter(TextMessage)
 	if err != nil {
 		return err
 	}
-	err = json.NewEncoder(w).Encode(v)
+	err = encode(w, v)
 	if closeErr := w.Close(); closeErr != nil && err == nil {
 		err = closeErr
 	}
 	return err
 }
 
+// encodeJSON is the default encoder used by WriteJSON.
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
 // ReadJSON reads the next JSON-encoded message from the connection and
-// stores it in the value pointed to by v.
+// stores it in the value pointed to by v. Unknown fields and data after the
+// first JSON value are ignored; use ReadJSONStrict to reject them.
+//
+// The message is decoded as it is read, so the read limit set with
+// SetReadLimit bounds how much of an oversized message is consumed before
+// ErrReadLimit is returned. A payload that is not valid JSON for v yields
+// a *JSONDecodeError.
 func (c *Conn) ReadJSON(v any) error {
+	return c.readJSON(v, false)
+}
+
+// ReadJSONStrict is like ReadJSON, but rejects messages with fields that do
+// not match v and messages with data after the first JSON value, in the
+// same way as mux.BindJSON. Both are reported as a *JSONDecodeError.
+func (c *Conn) ReadJSONStrict(v any) error {
+	return c.readJSON(v, true)
+}
+
+func (c *Conn) readJSON(v any, strict bool) error {
 	_, r, err := c.NextReader()
 	if err != nil {
 		return err
 	}
-	err = json.NewDecoder(r).Decode(v)
-	if err == io.EOF {
-		err = io.ErrUnexpectedEOF
+
+	tr := &readErrTracker{r: r}
+	dec := json.NewDecoder(tr)
+	if strict {
+		dec.DisallowUnknownFields()
 	}
-	return err
+
+	err = dec.Decode(v)
+	if err == nil && strict {
+		if trailErr := dec.Decode(&struct{}{}); !errors.Is(trailErr, io.EOF) {
+			err = errTrailingJSON
+		}
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case tr.err != nil:
+		return tr.err
+	case errors.Is(err, io.EOF):
+		return &JSONDecodeError{Err: io.ErrUnexpectedEOF}
+	default:
+		return &JSONDecodeError{Err: err}
+	}
+}
+
+// readErrTracker records the first error other than io.EOF returned by the
+// message reader, so that a failed read is not mistaken for malformed JSON.
+type readErrTracker struct {
+	r   io.Reader
+	err error
+}
+
+func (t *readErrTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF && t.err == nil {
+		t.err = err
+	}
+	return n, err
 }
@@ -2,8 +2,10 @@ package websocket
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -315,6 +317,80 @@ func (f *failWriterConn) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func TestWriteJSONWith(t *testing.T) {
+	payload := map[string]string{"html": "<b>&</b>"}
+
+	// readWire decodes the frames written by a server-side Conn using a
+	// client-side Conn and returns the reassembled message.
+	readWire := func(t *testing.T, wire *bytes.Buffer) (int, []byte) {
+		t.Helper()
+		mc := newMockConn()
+		mc.readBuf = wire
+		client := newConn(mc, false, 1024, 1024)
+		messageType, p, err := client.ReadMessage()
+		require.NoError(t, err)
+		return messageType, p
+	}
+
+	t.Run("Custom encoder disables HTML escaping", func(t *testing.T) {
+		mc := newMockConn()
+		conn := newConn(mc, true, 1024, 1024)
+
+		err := conn.WriteJSONWith(payload, func(w io.Writer, v any) error {
+			enc := json.NewEncoder(w)
+			enc.SetEscapeHTML(false)
+			return enc.Encode(v)
+		})
+		require.NoError(t, err)
+
+		messageType, p := readWire(t, mc.writeBuf)
+		assert.Equal(t, TextMessage, messageType)
+		assert.Equal(t, "{\"html\":\"<b>&</b>\"}\n", string(p))
+	})
+
+	t.Run("Default WriteJSON escapes HTML", func(t *testing.T) {
+		mc := newMockConn()
+		conn := newConn(mc, true, 1024, 1024)
+
+		require.NoError(t, conn.WriteJSON(payload))
+
+		_, p := readWire(t, mc.writeBuf)
+		assert.Equal(t, "{\"html\":\"\\u003cb\\u003e\\u0026\\u003c/b\\u003e\"}\n", string(p))
+	})
+
+	t.Run("Nil encoder falls back to encoding/json", func(t *testing.T) {
+		mc := newMockConn()
+		conn := newConn(mc, true, 1024, 1024)
+
+		require.NoError(t, conn.WriteJSONWith(testMessage{Name: "a", Value: 1}, nil))
+
+		_, p := readWire(t, mc.writeBuf)
+		assert.Equal(t, "{\"name\":\"a\",\"value\":1}\n", string(p))
+	})
+
+	t.Run("Encoder error is returned and message closed", func(t *testing.T) {
+		mc := newMockConn()
+		conn := newConn(mc, true, 1024, 1024)
+		encErr := errors.New("encode failed")
+
+		err := conn.WriteJSONWith(payload, func(_ io.Writer, _ any) error {
+			return encErr
+		})
+		require.ErrorIs(t, err, encErr)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("next")))
+	})
+
+	t.Run("NextWriter error", func(t *testing.T) {
+		mc := newMockConn()
+		conn := newConn(mc, true, 1024, 1024)
+		conn.writeErr = ErrCloseSent
+
+		err := conn.WriteJSONWith(payload, nil)
+		assert.ErrorIs(t, err, ErrCloseSent)
+	})
+}
+
 func TestReadJSONNextReaderError(t *testing.T) {
 	t.Run("NextReader returns error", func(t *testing.T) {
 		mc := newMockConn()
@@ -330,6 +406,143 @@ func TestReadJSONNextReaderError(t *testing.T) {
 	})
 }
 
+func TestReadJSONStrict(t *testing.T) {
+	newJSONConn := func(frames ...[]byte) *Conn {
+		mock := newMockConn()
+		for _, f := range frames {
+			mock.readBuf.Write(f)
+		}
+		return newConn(mock, true, 1024, 1024)
+	}
+
+	t.Run("Decodes valid message", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), []byte(`{"name":"a","value":1}`), true))
+
+		var msg testMessage
+		require.NoError(t, conn.ReadJSONStrict(&msg))
+		assert.Equal(t, testMessage{Name: "a", Value: 1}, msg)
+	})
+
+	t.Run("Rejects unknown field", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), []byte(`{"name":"a","extra":true}`), true))
+
+		var msg testMessage
+		err := conn.ReadJSONStrict(&msg)
+		var decErr *JSONDecodeError
+		require.ErrorAs(t, err, &decErr)
+		assert.Contains(t, err.Error(), "unknown field")
+	})
+
+	t.Run("ReadJSON ignores unknown field", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), []byte(`{"name":"a","extra":true}`), true))
+
+		var msg testMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, "a", msg.Name)
+	})
+
+	t.Run("Rejects trailing data", func(t *testing.T) {
+		for _, payload := range []string{`{"name":"a"}{"name":"b"}`, `{"name":"a"} x`} {
+			conn := newJSONConn(buildMaskedFrame(byte(TextMessage), []byte(payload), true))
+
+			var msg testMessage
+			err := conn.ReadJSONStrict(&msg)
+			var decErr *JSONDecodeError
+			require.ErrorAs(t, err, &decErr, payload)
+			assert.ErrorIs(t, err, errTrailingJSON, payload)
+		}
+	})
+
+	t.Run("Allows trailing whitespace", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), []byte("{\"name\":\"a\"}\n"), true))
+
+		var msg testMessage
+		require.NoError(t, conn.ReadJSONStrict(&msg))
+	})
+
+	t.Run("Connection usable after decode error", func(t *testing.T) {
+		conn := newJSONConn(
+			buildMaskedFrame(byte(TextMessage), []byte(`{"bogus":1}`), true),
+			buildMaskedFrame(byte(TextMessage), []byte(`{"name":"next"}`), true),
+		)
+
+		var msg testMessage
+		var decErr *JSONDecodeError
+		require.ErrorAs(t, conn.ReadJSONStrict(&msg), &decErr)
+		require.NoError(t, conn.ReadJSONStrict(&msg))
+		assert.Equal(t, "next", msg.Name)
+	})
+
+	t.Run("Empty message is unexpected EOF", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), nil, true))
+
+		var msg testMessage
+		err := conn.ReadJSONStrict(&msg)
+		var decErr *JSONDecodeError
+		require.ErrorAs(t, err, &decErr)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("Syntax error is a decode error", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), []byte("not json"), true))
+
+		var msg testMessage
+		err := conn.ReadJSON(&msg)
+		var decErr *JSONDecodeError
+		require.ErrorAs(t, err, &decErr)
+		var syntaxErr *json.SyntaxError
+		assert.ErrorAs(t, err, &syntaxErr)
+	})
+
+	t.Run("Oversized frame rejected before buffering", func(t *testing.T) {
+		payload := []byte(`{"name":"` + strings.Repeat("x", 256) + `"}`)
+		conn := newJSONConn(buildMaskedFrame(byte(TextMessage), payload, true))
+		conn.SetReadLimit(64)
+
+		var msg testMessage
+		err := conn.ReadJSONStrict(&msg)
+		assert.ErrorIs(t, err, ErrReadLimit)
+		var decErr *JSONDecodeError
+		assert.False(t, errors.As(err, &decErr))
+	})
+
+	t.Run("Oversized fragmented message stops at limit", func(t *testing.T) {
+		conn := newJSONConn(
+			buildMaskedFrame(byte(TextMessage), []byte(`{"name":"`+strings.Repeat("x", 40)), false),
+			buildMaskedFrame(byte(continuationFrame), []byte(strings.Repeat("x", 40)), false),
+			buildMaskedFrame(byte(continuationFrame), []byte(`"}`), true),
+		)
+		conn.SetReadLimit(64)
+
+		var msg testMessage
+		err := conn.ReadJSON(&msg)
+		assert.ErrorIs(t, err, ErrReadLimit)
+		var decErr *JSONDecodeError
+		assert.False(t, errors.As(err, &decErr))
+	})
+
+	t.Run("Close is not a decode error", func(t *testing.T) {
+		conn := newJSONConn(buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseNormalClosure, ""), true))
+
+		var msg testMessage
+		err := conn.ReadJSONStrict(&msg)
+		assert.True(t, IsCloseError(err, CloseNormalClosure))
+		var decErr *JSONDecodeError
+		assert.False(t, errors.As(err, &decErr))
+	})
+
+	t.Run("Close mid-message is not a decode error", func(t *testing.T) {
+		conn := newJSONConn(
+			buildMaskedFrame(byte(TextMessage), []byte(`{"name":`), false),
+			buildMaskedFrame(byte(CloseMessage), FormatCloseMessage(CloseGoingAway, ""), true),
+		)
+
+		var msg testMessage
+		err := conn.ReadJSON(&msg)
+		assert.True(t, IsCloseError(err, CloseGoingAway))
+	})
+}
+
 func BenchmarkJSON(b *testing.B) {
 	type benchStruct struct {
 		ID      int      `json:"id"`
@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// connCount returns the counter of open connections, allocating it on the
+// first call.
+func (u *Upgrader) connCount() *atomic.Int64 {
+	if n, ok := u.conns.Load().(*atomic.Int64); ok {
+		return n
+	}
+	u.conns.CompareAndSwap(nil, new(atomic.Int64))
+	return u.conns.Load().(*atomic.Int64)
+}
+
+// CurrentConnections returns the number of connections accepted by the
+// Upgrader that still hold a MaxConnections slot. Connections are counted
+// only while MaxConnections is greater than zero.
+func (u *Upgrader) CurrentConnections() int {
+	n, ok := u.conns.Load().(*atomic.Int64)
+	if !ok {
+		return 0
+	}
+	return int(n.Load())
+}
+
+// acquireSlot reserves a slot for a new connection. It returns a nil slot
+// when MaxConnections is not set and false when the cap is reached.
+func (u *Upgrader) acquireSlot() (*connSlot, bool) {
+	if u.MaxConnections <= 0 {
+		return nil, true
+	}
+	n := u.connCount()
+	for {
+		cur := n.Load()
+		if cur >= int64(u.MaxConnections) {
+			return nil, false
+		}
+		if n.CompareAndSwap(cur, cur+1) {
+			return &connSlot{count: n}, true
+		}
+	}
+}
+
+// rejectTooManyConnections answers a handshake refused by MaxConnections.
+func (u *Upgrader) rejectTooManyConnections(w http.ResponseWriter, r *http.Request) {
+	if u.RetryAfter > 0 {
+		secs := int64((u.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	}
+	u.returnError(w, r, http.StatusServiceUnavailable, ErrTooManyConnections)
+}
+
+// connSlot is a MaxConnections slot held by one connection. A nil slot is
+// valid and does nothing.
+type connSlot struct {
+	count    *atomic.Int64
+	released atomic.Bool
+}
+
+// release frees the slot; calls after the first do nothing.
+func (s *connSlot) release() {
+	if s != nil && s.released.CompareAndSwap(false, true) {
+		s.count.Add(-1)
+	}
+}
+
+// observe releases the slot when err shows that the transport is unusable.
+// Timeouts leave the connection readable, so they keep the slot.
+func (s *connSlot) observe(err error) {
+	if err != nil && !isTimeout(err) {
+		s.release()
+	}
+}
+
+// track routes the reads, writes, and close of conn through the slot, so
+// that closing the connection or a transport failure releases it.
+func (s *connSlot) track(conn *Conn) {
+	if s == nil {
+		return
+	}
+	conn.rwc = &slotTransport{ReadWriteCloser: conn.rwc, slot: s}
+	conn.br = &slotReader{r: conn.br, slot: s}
+}
+
+// slotTransport releases its slot when the transport is closed or a write
+// fails.
+type slotTransport struct {
+	io.ReadWriteCloser
+	slot *connSlot
+}
+
+func (t *slotTransport) Write(p []byte) (int, error) {
+	n, err := t.ReadWriteCloser.Write(p)
+	t.slot.observe(err)
+	return n, err
+}
+
+func (t *slotTransport) Close() error {
+	t.slot.release()
+	return t.ReadWriteCloser.Close()
+}
+
+// slotReader releases its slot when a read fails, including on io.EOF
+// when the peer drops the connection.
+type slotReader struct {
+	r    io.Reader
+	slot *connSlot
+}
+
+func (r *slotReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.slot.observe(err)
+	return n, err
+}
@@ -0,0 +1,273 @@
+package websocket
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgraderMaxConnections(t *testing.T) {
+	newServer := func(t *testing.T, u *Upgrader, handle func(*Conn)) string {
+		t.Helper()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := u.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			handle(conn)
+		}))
+		t.Cleanup(server.Close)
+		return "ws" + strings.TrimPrefix(server.URL, "http")
+	}
+
+	dial := func(t *testing.T, url string) (*Conn, *http.Response, error) {
+		t.Helper()
+		conn, resp, err := DefaultDialer.Dial(url, nil)
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		if conn != nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+		return conn, resp, err
+	}
+
+	echo := func(conn *Conn) {
+		defer conn.Close()
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, data); err != nil {
+				return
+			}
+		}
+	}
+
+	t.Run("Rejects with 503 when full", func(t *testing.T) {
+		var reasons []error
+		u := &Upgrader{
+			MaxConnections: 1,
+			RetryAfter:     1500 * time.Millisecond,
+			Error: func(w http.ResponseWriter, _ *http.Request, status int, reason error) {
+				reasons = append(reasons, reason)
+				http.Error(w, reason.Error(), status)
+			},
+		}
+		url := newServer(t, u, echo)
+
+		_, _, err := dial(t, url)
+		require.NoError(t, err)
+		assert.Equal(t, 1, u.CurrentConnections())
+
+		conn, resp, err := dial(t, url)
+		require.ErrorIs(t, err, ErrBadHandshake)
+		assert.Nil(t, conn)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+		require.Len(t, reasons, 1)
+		assert.ErrorIs(t, reasons[0], ErrTooManyConnections)
+		assert.Equal(t, 1, u.CurrentConnections())
+	})
+
+	t.Run("No Retry-After by default", func(t *testing.T) {
+		u := &Upgrader{MaxConnections: 1}
+		url := newServer(t, u, echo)
+
+		_, _, err := dial(t, url)
+		require.NoError(t, err)
+		_, resp, err := dial(t, url)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("Close frees the slot", func(t *testing.T) {
+		u := &Upgrader{MaxConnections: 1}
+		url := newServer(t, u, echo)
+
+		conn, _, err := dial(t, url)
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		require.Eventually(t, func() bool { return u.CurrentConnections() == 0 }, 5*time.Second, 5*time.Millisecond)
+
+		_, _, err = dial(t, url)
+		require.NoError(t, err)
+	})
+
+	t.Run("Peer drop frees the slot without Close", func(t *testing.T) {
+		u := &Upgrader{MaxConnections: 1}
+		leaked := make(chan *Conn, 1)
+		url := newServer(t, u, func(conn *Conn) {
+			leaked <- conn
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		})
+
+		conn, _, err := dial(t, url)
+		require.NoError(t, err)
+		server := <-leaked
+		require.NoError(t, conn.UnderlyingConn().Close())
+
+		require.Eventually(t, func() bool { return u.CurrentConnections() == 0 }, 5*time.Second, 5*time.Millisecond)
+		require.NoError(t, server.Close())
+		assert.Equal(t, 0, u.CurrentConnections())
+	})
+
+	t.Run("Read timeout keeps the slot", func(t *testing.T) {
+		u := &Upgrader{MaxConnections: 1}
+		done := make(chan error)
+		finish := make(chan struct{})
+		url := newServer(t, u, func(conn *Conn) {
+			defer conn.Close()
+			_ = conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+			_, _, err := conn.ReadMessage()
+			done <- err
+			<-finish
+		})
+
+		_, _, err := dial(t, url)
+		require.NoError(t, err)
+		err = <-done
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+		assert.Equal(t, 1, u.CurrentConnections())
+		close(finish)
+	})
+
+	t.Run("Copy after first use shares the count", func(t *testing.T) {
+		u := &Upgrader{MaxConnections: 1}
+		url := newServer(t, u, echo)
+
+		_, _, err := dial(t, url)
+		require.NoError(t, err)
+
+		copied := *u
+		_, resp, err := dial(t, newServer(t, &copied, echo))
+		require.ErrorIs(t, err, ErrBadHandshake)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, 1, copied.CurrentConnections())
+	})
+
+	t.Run("Unlimited does not count", func(t *testing.T) {
+		u := &Upgrader{}
+		url := newServer(t, u, echo)
+
+		_, _, err := dial(t, url)
+		require.NoError(t, err)
+		assert.Equal(t, 0, u.CurrentConnections())
+	})
+
+	t.Run("HTTP/2", func(t *testing.T) {
+		u := &Upgrader{MaxConnections: 1, CheckOrigin: func(*http.Request) bool { return true }}
+		upgrade := func() (*Conn, *mockHTTP2Writer, error) {
+			server, client := net.Pipe()
+			t.Cleanup(func() { client.Close() })
+			go func() { _, _ = io.Copy(io.Discard, client) }()
+			w := &mockHTTP2Writer{headers: make(http.Header), writer: server}
+			r := httptest.NewRequest(http.MethodConnect, "/ws", nil)
+			r.ProtoMajor = 2
+			r.Proto = "websocket"
+			r.Body = io.NopCloser(server)
+			conn, err := u.Upgrade(w, r, nil)
+			return conn, w, err
+		}
+
+		conn, _, err := upgrade()
+		require.NoError(t, err)
+		assert.Equal(t, 1, u.CurrentConnections())
+
+		_, w, err := upgrade()
+		require.ErrorIs(t, err, ErrTooManyConnections)
+		assert.Equal(t, http.StatusServiceUnavailable, w.code)
+
+		_ = conn.Close()
+		_ = conn.Close()
+		assert.Equal(t, 0, u.CurrentConnections())
+	})
+
+	t.Run("Concurrent churn does not drift", func(t *testing.T) {
+		const limit = 8
+		var peak atomic.Int64
+		u := &Upgrader{MaxConnections: limit}
+		url := newServer(t, u, func(conn *Conn) {
+			for {
+				cur := int64(u.CurrentConnections())
+				old := peak.Load()
+				if cur <= old || peak.CompareAndSwap(old, cur) {
+					break
+				}
+			}
+			echo(conn)
+		})
+
+		var accepted, rejected atomic.Int64
+		var wg sync.WaitGroup
+		for range 32 {
+			wg.Go(func() {
+				for range 10 {
+					conn, resp, err := DefaultDialer.Dial(url, nil)
+					if err != nil {
+						if resp != nil && resp.StatusCode == http.StatusServiceUnavailable {
+							rejected.Add(1)
+						}
+						continue
+					}
+					accepted.Add(1)
+					if conn.WriteMessage(TextMessage, []byte("hi")) == nil {
+						_, _, _ = conn.ReadMessage()
+					}
+					// Alternate a clean close with an abrupt drop.
+					if accepted.Load()%2 == 0 {
+						_ = conn.Close()
+					} else {
+						_ = conn.UnderlyingConn().Close()
+					}
+				}
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(32*10), accepted.Load()+rejected.Load())
+		assert.Positive(t, accepted.Load())
+		assert.LessOrEqual(t, peak.Load(), int64(limit))
+		require.Eventually(t, func() bool { return u.CurrentConnections() == 0 }, 5*time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestConnSlot(t *testing.T) {
+	t.Run("Releases once", func(t *testing.T) {
+		var n atomic.Int64
+		n.Store(1)
+		s := &connSlot{count: &n}
+		s.release()
+		s.release()
+		s.observe(io.EOF)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("Nil slot is a no-op", func(t *testing.T) {
+		var s *connSlot
+		assert.NotPanics(t, func() {
+			s.release()
+			s.track(newConn(newMockConn(), true, 0, 0))
+		})
+	})
+}
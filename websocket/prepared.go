@@ -7,6 +7,13 @@ import (
 
 // PreparedMessage caches on-the-wire representations of a message payload.
 // Use PreparedMessage to efficiently send a message payload to multiple connections.
+//
+// A message has up to four representations, one for each combination of
+// compression (connections that negotiated permessage-deflate and would
+// compress the payload) and masking (client connections). Each one is built
+// the first time a connection needs it and reused by every later write, so
+// a broadcast to a pool mixing compressed and uncompressed connections
+// compresses the payload once. A PreparedMessage is safe for concurrent use.
 type PreparedMessage struct {
 	messageType int
 	data        []byte
@@ -15,30 +22,72 @@ type PreparedMessage struct {
 }
 
 type prepareKey struct {
-	isServer   bool
-	compress   bool
-	compressNo bool
+	isServer bool
+	compress bool
 }
 
 type preparedFrame struct {
 	data []byte
 }
 
-// NewPreparedMessage returns an initialized PreparedMessage.
-func NewPreparedMessage(messageType int, data []byte) (*PreparedMessage, error) {
+// PreparedVariant identifies one on-the-wire representation of a
+// PreparedMessage.
+type PreparedVariant struct {
+	// Compressed selects the permessage-deflate compressed frame (RFC 7692).
+	Compressed bool
+
+	// Client selects the masked frame written by client connections
+	// (RFC 6455, section 5.3). The zero value is the unmasked server frame.
+	Client bool
+}
+
+// PreparedOption configures a PreparedMessage created by NewPreparedMessage.
+type PreparedOption func(*preparedOptions)
+
+type preparedOptions struct {
+	variants []PreparedVariant
+}
+
+// WithPreparedVariants builds the given representations when the message
+// is created instead of on first use, moving the framing and compression
+// cost out of the broadcast loop. Other representations are still built
+// lazily when a connection needs them.
+func WithPreparedVariants(variants ...PreparedVariant) PreparedOption {
+	return func(o *preparedOptions) {
+		o.variants = append(o.variants, variants...)
+	}
+}
+
+// NewPreparedMessage returns an initialized PreparedMessage. An error is
+// returned for a message type other than TextMessage or BinaryMessage, or
+// when a variant requested with WithPreparedVariants cannot be built.
+func NewPreparedMessage(messageType int, data []byte, opts ...PreparedOption) (*PreparedMessage, error) {
 	if messageType != TextMessage && messageType != BinaryMessage {
 		return nil, ErrInvalidMessageType
 	}
 
+	var o preparedOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	pm := &PreparedMessage{
 		messageType: messageType,
 		data:        data,
 		frames:      make(map[prepareKey]*preparedFrame),
 	}
 
+	for _, v := range o.variants {
+		if _, err := pm.frame(prepareKey{isServer: !v.Client, compress: v.Compressed}); err != nil {
+			return nil, err
+		}
+	}
+
 	return pm, nil
 }
 
+// frame returns the frame bytes for key, building and caching them on the
+// first call.
 func (pm *PreparedMessage) frame(key prepareKey) ([]byte, error) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -48,7 +97,7 @@ func (pm *PreparedMessage) frame(key prepareKey) ([]byte, error) {
 	}
 
 	data := pm.data
-	if key.compress && !key.compressNo {
+	if key.compress {
 		compressed, err := compressData(data, defaultCompressionLevel)
 		if err != nil {
 			return nil, err
@@ -56,7 +105,7 @@ func (pm *PreparedMessage) frame(key prepareKey) ([]byte, error) {
 		data = compressed
 	}
 
-	frameData, err := buildFrame(pm.messageType, data, !key.isServer, key.compress && !key.compressNo)
+	frameData, err := buildFrame(pm.messageType, data, !key.isServer, key.compress)
 	if err != nil {
 		return nil, err
 	}
@@ -118,8 +167,17 @@ func buildFrame(messageType int, data []byte, masked bool, compressed bool) ([]b
 	return frame, nil
 }
 
-// WritePreparedMessage writes pm to the connection.
+// WritePreparedMessage writes pm to the connection, using the cached
+// representation that matches the connection's role and compression. The
+// compressed frame is only written when permessage-deflate was negotiated
+// with the peer and write compression applies to the payload (see
+// EnableWriteCompression, SetCompressionThreshold, and
+// SetSkipCompressionFunc); every other connection receives the plain frame,
+// so one PreparedMessage can be broadcast to peers with and without
+// compression.
 func (c *Conn) WritePreparedMessage(pm *PreparedMessage) error {
+	c.msgMu.Lock()
+	defer c.msgMu.Unlock()
 	c.writeMu.Lock()
 	defer c.writeMu.Unlock()
 
@@ -128,9 +186,8 @@ func (c *Conn) WritePreparedMessage(pm *PreparedMessage) error {
 	}
 
 	key := prepareKey{
-		isServer:   c.isServer,
-		compress:   c.compressionEnabled && c.writeCompress,
-		compressNo: !c.compressionEnabled,
+		isServer: c.isServer,
+		compress: c.shouldCompress(pm.messageType, pm.data),
 	}
 
 	frameData, err := pm.frame(key)
@@ -138,7 +195,8 @@ func (c *Conn) WritePreparedMessage(pm *PreparedMessage) error {
 		return err
 	}
 
-	_, err = c.rwc.Write(frameData)
+	n, err := c.rwc.Write(frameData)
+	c.stats.recordWrite(pm.messageType, n, err)
 	if err != nil {
 		c.writeErr = err
 	}
@@ -1,8 +1,14 @@
 package websocket
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -122,7 +128,7 @@ func TestPreparedMessageFrame(t *testing.T) {
 		pm, err := NewPreparedMessage(TextMessage, []byte("hello"))
 		require.NoError(t, err)
 
-		key := prepareKey{isServer: true, compress: false, compressNo: true}
+		key := prepareKey{isServer: true}
 
 		frame1, err := pm.frame(key)
 		require.NoError(t, err)
@@ -138,8 +144,8 @@ func TestPreparedMessageFrame(t *testing.T) {
 		pm, err := NewPreparedMessage(TextMessage, []byte("hello"))
 		require.NoError(t, err)
 
-		serverKey := prepareKey{isServer: true, compress: false, compressNo: true}
-		clientKey := prepareKey{isServer: false, compress: false, compressNo: true}
+		serverKey := prepareKey{isServer: true}
+		clientKey := prepareKey{isServer: false}
 
 		serverFrame, err := pm.frame(serverKey)
 		require.NoError(t, err)
@@ -230,7 +236,7 @@ func TestPreparedMessageWithCompression(t *testing.T) {
 		pm, err := NewPreparedMessage(TextMessage, []byte("compressible data"))
 		require.NoError(t, err)
 
-		key := prepareKey{isServer: true, compress: true, compressNo: false}
+		key := prepareKey{isServer: true, compress: true}
 		frame, err := pm.frame(key)
 		require.NoError(t, err)
 
@@ -241,8 +247,8 @@ func TestPreparedMessageWithCompression(t *testing.T) {
 		pm, err := NewPreparedMessage(TextMessage, []byte("data to compress"))
 		require.NoError(t, err)
 
-		compressedKey := prepareKey{isServer: true, compress: true, compressNo: false}
-		uncompressedKey := prepareKey{isServer: true, compress: false, compressNo: true}
+		compressedKey := prepareKey{isServer: true, compress: true}
+		uncompressedKey := prepareKey{isServer: true}
 
 		compFrame, err := pm.frame(compressedKey)
 		require.NoError(t, err)
@@ -257,7 +263,7 @@ func TestPreparedMessageWithCompression(t *testing.T) {
 		pm, err := NewPreparedMessage(TextMessage, []byte("compressed client"))
 		require.NoError(t, err)
 
-		key := prepareKey{isServer: false, compress: true, compressNo: false}
+		key := prepareKey{isServer: false, compress: true}
 		frame, err := pm.frame(key)
 		require.NoError(t, err)
 
@@ -348,13 +354,117 @@ func TestPreparedMessageFrameRandReaderError(t *testing.T) {
 		pm, err := NewPreparedMessage(TextMessage, []byte("hello"))
 		require.NoError(t, err)
 
-		key := prepareKey{isServer: false, compress: false, compressNo: true}
+		key := prepareKey{isServer: false}
 		frame, err := pm.frame(key)
 		assert.Nil(t, frame)
 		assert.ErrorIs(t, err, testErr)
 	})
 }
 
+func TestPreparedMessageVariants(t *testing.T) {
+	payload := bytes.Repeat([]byte("broadcast payload "), 20)
+
+	newPool := func(n int) ([]*mockConn, []*Conn) {
+		mocks := make([]*mockConn, n)
+		conns := make([]*Conn, n)
+		for i := range conns {
+			mocks[i] = newMockConn()
+			conns[i] = newConn(mocks[i], true, 0, 0)
+			if i%2 == 0 {
+				conns[i].compressionEnabled = true
+				conns[i].writeCompress = true
+			}
+		}
+		return mocks, conns
+	}
+
+	t.Run("Mixed pool builds each variant once", func(t *testing.T) {
+		pm, err := NewPreparedMessage(TextMessage, payload)
+		require.NoError(t, err)
+		assert.Empty(t, pm.frames)
+
+		mocks, conns := newPool(6)
+		for _, conn := range conns {
+			require.NoError(t, conn.WritePreparedMessage(pm))
+		}
+		require.Len(t, pm.frames, 2)
+
+		compressed := pm.frames[prepareKey{isServer: true, compress: true}].data
+		plain := pm.frames[prepareKey{isServer: true}].data
+		for i, mock := range mocks {
+			if i%2 == 0 {
+				assert.Equal(t, compressed, mock.writeBuf.Bytes())
+			} else {
+				assert.Equal(t, plain, mock.writeBuf.Bytes())
+			}
+		}
+
+		frames := readWireFrames(t, mocks[0].writeBuf.Bytes())
+		require.Len(t, frames, 1)
+		assert.True(t, frames[0].compressed)
+		decoded, err := decompressData(frames[0].payload)
+		require.NoError(t, err)
+		assert.Equal(t, payload, decoded)
+
+		// A second broadcast reuses the cached frames.
+		for _, conn := range conns {
+			require.NoError(t, conn.WritePreparedMessage(pm))
+		}
+		assert.Len(t, pm.frames, 2)
+		assert.Same(t, &compressed[0], &pm.frames[prepareKey{isServer: true, compress: true}].data[0])
+	})
+
+	t.Run("Eager variants", func(t *testing.T) {
+		pm, err := NewPreparedMessage(TextMessage, payload, WithPreparedVariants(
+			PreparedVariant{Compressed: true},
+			PreparedVariant{Client: true},
+		))
+		require.NoError(t, err)
+		require.Len(t, pm.frames, 2)
+		require.Contains(t, pm.frames, prepareKey{isServer: true, compress: true})
+		require.Contains(t, pm.frames, prepareKey{isServer: false})
+		eager := pm.frames[prepareKey{isServer: true, compress: true}].data
+
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		conn.compressionEnabled = true
+		conn.writeCompress = true
+		require.NoError(t, conn.WritePreparedMessage(pm))
+		assert.Equal(t, eager, mock.writeBuf.Bytes())
+		assert.Len(t, pm.frames, 2)
+	})
+
+	t.Run("Eager variant error", func(t *testing.T) {
+		origRandReader := randReader
+		testErr := errors.New("rand error")
+		randReader = &errReader{err: testErr}
+		defer func() { randReader = origRandReader }()
+
+		pm, err := NewPreparedMessage(TextMessage, payload, WithPreparedVariants(PreparedVariant{Client: true}))
+		assert.Nil(t, pm)
+		assert.ErrorIs(t, err, testErr)
+	})
+
+	t.Run("Concurrent broadcast", func(t *testing.T) {
+		pm, err := NewPreparedMessage(BinaryMessage, payload)
+		require.NoError(t, err)
+
+		mocks, conns := newPool(32)
+		var wg sync.WaitGroup
+		for _, conn := range conns {
+			wg.Go(func() {
+				assert.NoError(t, conn.WritePreparedMessage(pm))
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, pm.frames, 2)
+		for i, mock := range mocks {
+			assert.Equal(t, mocks[i%2].writeBuf.Bytes(), mock.writeBuf.Bytes(), fmt.Sprint(i))
+		}
+	})
+}
+
 func TestWritePreparedMessageFrameError(t *testing.T) {
 	t.Run("WritePreparedMessage fails when frame errors", func(t *testing.T) {
 		origRandReader := randReader
@@ -413,3 +523,135 @@ func BenchmarkPreparedMessage(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkPreparedMessageBroadcastMixed(b *testing.B) {
+	const poolSize = 1000
+	data := bytes.Repeat([]byte("prepared broadcast payload "), 40)
+
+	newPool := func() ([]*mockConn, []*Conn) {
+		mocks := make([]*mockConn, poolSize)
+		conns := make([]*Conn, poolSize)
+		for i := range conns {
+			mocks[i] = newMockConn()
+			conns[i] = newConn(mocks[i], true, 0, 0)
+			if i%2 == 0 {
+				conns[i].compressionEnabled = true
+				conns[i].writeCompress = true
+			}
+		}
+		return mocks, conns
+	}
+
+	b.Run("Prepared", func(b *testing.B) {
+		mocks, conns := newPool()
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for b.Loop() {
+			pm, _ := NewPreparedMessage(TextMessage, data)
+			for i, conn := range conns {
+				mocks[i].writeBuf.Reset()
+				_ = conn.WritePreparedMessage(pm)
+			}
+		}
+	})
+
+	b.Run("PreparedEager", func(b *testing.B) {
+		mocks, conns := newPool()
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for b.Loop() {
+			pm, _ := NewPreparedMessage(TextMessage, data, WithPreparedVariants(
+				PreparedVariant{}, PreparedVariant{Compressed: true}))
+			for i, conn := range conns {
+				mocks[i].writeBuf.Reset()
+				_ = conn.WritePreparedMessage(pm)
+			}
+		}
+	})
+
+	b.Run("WriteMessage", func(b *testing.B) {
+		mocks, conns := newPool()
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for b.Loop() {
+			for i, conn := range conns {
+				mocks[i].writeBuf.Reset()
+				_ = conn.WriteMessage(TextMessage, data)
+			}
+		}
+	})
+}
+
+func TestWritePreparedMessageNegotiatedCompression(t *testing.T) {
+	upgrader := Upgrader{EnableCompression: true}
+	accepted := make(chan *Conn, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		// Write compression only takes effect where it was negotiated.
+		conn.EnableWriteCompression(true)
+		accepted <- conn
+	}))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dial := func(compress bool) (*Conn, *Conn) {
+		d := &Dialer{EnableCompression: compress}
+		client, _, err := d.Dial(wsURL, nil)
+		require.NoError(t, err)
+		return client, <-accepted
+	}
+
+	compressedClient, compressedPeer := dial(true)
+	defer compressedClient.Close()
+	defer compressedPeer.Close()
+	plainClient, plainPeer := dial(false)
+	defer plainClient.Close()
+	defer plainPeer.Close()
+
+	require.True(t, compressedPeer.compressionEnabled)
+	require.False(t, plainPeer.compressionEnabled)
+
+	t.Run("Broadcast to mixed peers", func(t *testing.T) {
+		payload := bytes.Repeat([]byte("prepared broadcast "), 50)
+		pm, err := NewPreparedMessage(TextMessage, payload)
+		require.NoError(t, err)
+
+		for range 2 {
+			require.NoError(t, compressedPeer.WritePreparedMessage(pm))
+			require.NoError(t, plainPeer.WritePreparedMessage(pm))
+		}
+		assert.Len(t, pm.frames, 2)
+		assert.Contains(t, pm.frames, prepareKey{isServer: true, compress: true})
+		assert.Contains(t, pm.frames, prepareKey{isServer: true})
+
+		for _, client := range []*Conn{compressedClient, plainClient} {
+			for range 2 {
+				messageType, data, err := client.ReadMessage()
+				require.NoError(t, err)
+				assert.Equal(t, TextMessage, messageType)
+				assert.Equal(t, payload, data)
+			}
+		}
+
+		compressedStats := compressedPeer.Stats()
+		plainStats := plainPeer.Stats()
+		assert.Less(t, compressedStats.BytesWritten, plainStats.BytesWritten)
+	})
+
+	t.Run("Uncompressed frame for peer that did not negotiate", func(t *testing.T) {
+		pm, err := NewPreparedMessage(BinaryMessage, []byte("raw bytes"), WithPreparedVariants(PreparedVariant{Compressed: true}))
+		require.NoError(t, err)
+
+		require.NoError(t, plainPeer.WritePreparedMessage(pm))
+		messageType, data, err := plainClient.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, BinaryMessage, messageType)
+		assert.Equal(t, []byte("raw bytes"), data)
+	})
+}
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"bufio"
+	"context"
 	"crypto/sha1"
 	"encoding/base64"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,18 +36,47 @@ type Upgrader struct {
 	ReadBufferSize  int
 	WriteBufferSize int
 
-	// WriteBufferPool is a pool of buffers for write operations.
+	// WriteBufferPool is a pool of buffers for write operations. When set,
+	// a connection borrows a buffer only for the duration of each frame
+	// write and returns it afterwards, so idle connections hold no write
+	// buffer. Buffers are put into the pool as *[]byte values. A *sync.Pool
+	// satisfies this interface. The pool should only be shared by
+	// connections using the same WriteBufferSize.
 	WriteBufferPool BufferPool
 
 	// Subprotocols specifies the server's supported protocols in order of preference.
+	// Upgrade selects the first entry that the client also offered in its
+	// Sec-WebSocket-Protocol header, echoes it in the response, and exposes it
+	// via Conn.Subprotocol. When there is no overlap the upgrade still proceeds
+	// without a subprotocol, as permitted by RFC 6455, section 4.2.2; the
+	// handler can inspect Conn.Subprotocol and close the connection if a
+	// protocol is mandatory.
 	Subprotocols []string
 
 	// Error specifies the function for generating HTTP error responses.
+	// It is called whenever the handshake is rejected before the connection
+	// is taken over, over HTTP/1.1 and HTTP/2, with the suggested status
+	// code and the reason: ErrBadHandshake for a request that is not a
+	// WebSocket upgrade (400) or does not use GET (405),
+	// ErrUnsupportedVersion (400), ErrOriginNotAllowed (403), ErrMissingKey
+	// (400), ErrInvalidProtocol (400), ErrTooManyConnections (503), or an
+	// internal error (500). Nothing
+	// has been written to w yet, so the function can write any status,
+	// headers, and body, such as a JSON error or a branded page, and log the
+	// reason. Upgrade still returns an error afterwards.
+	//
+	// If nil, the reason is written as a plain-text body with http.Error.
 	Error func(w http.ResponseWriter, r *http.Request, status int, reason error)
 
 	// CheckOrigin returns true if the request Origin header is acceptable.
 	CheckOrigin func(r *http.Request) bool
 
+	// OnUpgrade, when set, is called after every Upgrade and UpgradeContext
+	// attempt with the time the server handshake took and its error, nil
+	// when the connection was established. It runs on the handler's
+	// goroutine before Upgrade returns, so it should not block.
+	OnUpgrade func(r *http.Request, duration time.Duration, err error)
+
 	// EnableCompression specifies if the server should attempt to negotiate
 	// per message compression (RFC 7692).
 	EnableCompression bool
@@ -74,10 +105,43 @@ type Upgrader struct {
 
 	// PingHandler is called for each received ping frame with the raw payload
 	// bytes. The returned bytes are used as the pong payload; returning nil
-	// sends an empty pong. If PingHandler is set, DisablePongReply and
+	// sends an empty pong, and returning ErrSkipPong sends none without
+	// failing the read. If PingHandler is set, DisablePongReply and
 	// EmptyPongPayload are ignored. RequireEmptyPingPayload is still enforced
 	// before PingHandler is called.
 	PingHandler func(payload []byte) ([]byte, error)
+
+	// BindContext ties each accepted connection to the request context (or
+	// the context passed to UpgradeContext): when it is done, the connection
+	// is closed and pending and later reads and writes fail with an error
+	// wrapping the context's cause. This lets a server shut down its
+	// WebSocket connections by cancelling http.Server.BaseContext.
+	//
+	// net/http cancels the request context when the handler returns, so
+	// handlers that hand the connection to another goroutine and return
+	// should use UpgradeContext with a longer-lived context.
+	BindContext bool
+
+	// MaxConnections caps the number of connections accepted by the
+	// Upgrader that are open at the same time. Once the cap is reached,
+	// Upgrade rejects further handshakes with 503 Service Unavailable and
+	// ErrTooManyConnections instead of switching protocols. A slot is freed
+	// exactly once, when the connection is closed or a read or write on it
+	// fails with an error other than a timeout, so connections dropped by
+	// the peer do not hold a slot until the handler notices. Zero means no
+	// limit.
+	//
+	// The count is kept by the Upgrader and shared with copies made after
+	// its first counted upgrade, so every upgrade the cap covers must go
+	// through the same Upgrader or such a copy. See CurrentConnections.
+	MaxConnections int
+
+	// RetryAfter, when greater than zero, is sent in the Retry-After header
+	// (rounded up to whole seconds) of the 503 response written when
+	// MaxConnections is reached. The header is set before Error is called.
+	RetryAfter time.Duration
+
+	conns atomic.Value // *atomic.Int64 of open connections; stored on first use
 }
 
 // applyConnPolicy applies all per-connection policies from the Upgrader to conn.
@@ -137,6 +201,15 @@ func (u *Upgrader) applyPingPolicy(conn *Conn) {
 	})
 }
 
+// Reasons passed to Upgrader.Error when a handshake is rejected.
+var (
+	ErrUnsupportedVersion = errors.New("websocket: unsupported version")
+	ErrOriginNotAllowed   = errors.New("websocket: origin not allowed")
+	ErrMissingKey         = errors.New("websocket: missing Sec-WebSocket-Key")
+	ErrInvalidProtocol    = errors.New("websocket: invalid :protocol for HTTP/2")
+	ErrTooManyConnections = errors.New("websocket: too many connections")
+)
+
 func (u *Upgrader) returnError(w http.ResponseWriter, r *http.Request, status int, reason error) {
 	if u.Error != nil {
 		u.Error(w, r, status, reason)
@@ -145,6 +218,8 @@ func (u *Upgrader) returnError(w http.ResponseWriter, r *http.Request, status in
 	http.Error(w, reason.Error(), status)
 }
 
+// selectSubprotocol returns the first server-preferred subprotocol offered by
+// the client, or an empty string when there is no overlap.
 func (u *Upgrader) selectSubprotocol(r *http.Request) string {
 	clientProtocols := Subprotocols(r)
 	for _, serverProtocol := range u.Subprotocols {
@@ -155,10 +230,73 @@ func (u *Upgrader) selectSubprotocol(r *http.Request) string {
 	return ""
 }
 
+// negotiateSubprotocol selects the subprotocol for the handshake response.
+// Without configured Subprotocols, a Sec-WebSocket-Protocol value in
+// responseHeader is used if the client offered it.
+func (u *Upgrader) negotiateSubprotocol(r *http.Request, responseHeader http.Header) string {
+	if len(u.Subprotocols) > 0 {
+		return u.selectSubprotocol(r)
+	}
+	for k, vs := range responseHeader {
+		if strings.EqualFold(k, "Sec-WebSocket-Protocol") && len(vs) > 0 && slices.Contains(Subprotocols(r), vs[0]) {
+			return vs[0]
+		}
+	}
+	return ""
+}
+
+// isHandshakeHeader reports whether name is a header the Upgrader writes
+// itself, which responseHeader cannot override.
+func isHandshakeHeader(name string) bool {
+	for _, h := range [...]string{"Upgrade", "Connection", "Sec-WebSocket-Accept", "Sec-WebSocket-Protocol", "Sec-WebSocket-Extensions"} {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
 // Upgrade upgrades the HTTP server connection to the WebSocket protocol.
 // This implements the server-side opening handshake per RFC 6455, section 4.2.2,
 // and RFC 8441 for HTTP/2 WebSocket bootstrapping.
+//
+// The responseHeader is included in the 101 Switching Protocols response
+// (the 200 response for HTTP/2), which is how handlers set cookies or other
+// custom headers on the handshake:
+//
+//	h := http.Header{}
+//	h.Set("Set-Cookie", "session=abc; HttpOnly")
+//	conn, err := upgrader.Upgrade(w, r, h)
+//
+// Headers that the handshake itself controls (Upgrade, Connection,
+// Sec-WebSocket-Accept, Sec-WebSocket-Protocol, Sec-WebSocket-Extensions)
+// are not copied. When Subprotocols is empty, a Sec-WebSocket-Protocol value
+// in responseHeader selects the subprotocol, provided the client offered it;
+// otherwise no subprotocol is sent.
+//
+// The request context is associated with the returned connection; see
+// Conn.Context and BindContext.
 func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	return u.UpgradeContext(r.Context(), w, r, responseHeader)
+}
+
+// UpgradeContext is like Upgrade but associates ctx with the connection
+// instead of the request context. See Conn.Context and BindContext.
+func (u *Upgrader) UpgradeContext(ctx context.Context, w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	start := time.Now()
+	conn, err := u.upgrade(w, r, responseHeader)
+	if u.OnUpgrade != nil {
+		u.OnUpgrade(r, time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.setContext(ctx, u.BindContext)
+	return conn, nil
+}
+
+// upgrade performs the opening handshake for Upgrade and UpgradeContext.
+func (u *Upgrader) upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
 	// Check for HTTP/2 WebSocket upgrade (RFC 8441).
 	if r.ProtoMajor == 2 && r.Method == http.MethodConnect {
 		return u.upgradeHTTP2(w, r, responseHeader)
@@ -176,7 +314,7 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 
 	// Check WebSocket version per RFC 6455, section 4.2.1, item 6.
 	if !strings.EqualFold(r.Header.Get("Sec-WebSocket-Version"), websocketVersion) {
-		u.returnError(w, r, http.StatusBadRequest, errors.New("websocket: unsupported version"))
+		u.returnError(w, r, http.StatusBadRequest, ErrUnsupportedVersion)
 		return nil, ErrBadHandshake
 	}
 
@@ -185,18 +323,18 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 		checkOrigin = checkSameOrigin
 	}
 	if !checkOrigin(r) {
-		u.returnError(w, r, http.StatusForbidden, errors.New("websocket: origin not allowed"))
+		u.returnError(w, r, http.StatusForbidden, ErrOriginNotAllowed)
 		return nil, ErrBadHandshake
 	}
 
 	// Extract challenge key per RFC 6455, section 4.2.1, item 5.
 	challengeKey := r.Header.Get("Sec-WebSocket-Key")
 	if challengeKey == "" {
-		u.returnError(w, r, http.StatusBadRequest, errors.New("websocket: missing Sec-WebSocket-Key"))
+		u.returnError(w, r, http.StatusBadRequest, ErrMissingKey)
 		return nil, ErrBadHandshake
 	}
 
-	subprotocol := u.selectSubprotocol(r)
+	subprotocol := u.negotiateSubprotocol(r, responseHeader)
 
 	// Negotiate permessage-deflate extension per RFC 7692.
 	var compress bool
@@ -217,8 +355,15 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 		return nil, ErrBadHandshake
 	}
 
+	slot, ok := u.acquireSlot()
+	if !ok {
+		u.rejectTooManyConnections(w, r)
+		return nil, ErrTooManyConnections
+	}
+
 	netConn, brw, err := h.Hijack()
 	if err != nil {
+		slot.release()
 		u.returnError(w, r, http.StatusInternalServerError, err)
 		return nil, err
 	}
@@ -250,6 +395,9 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 	}
 
 	for k, vs := range responseHeader {
+		if isHandshakeHeader(k) {
+			continue
+		}
 		for _, v := range vs {
 			buf.WriteString(k)
 			buf.WriteString(": ")
@@ -261,6 +409,7 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 	buf.WriteString("\r\n")
 
 	if err := buf.Flush(); err != nil {
+		slot.release()
 		netConn.Close()
 		return nil, err
 	}
@@ -278,6 +427,7 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 	conn.subprotocol = subprotocol
 	conn.compressionEnabled = compress
 	u.applyConnPolicy(conn)
+	slot.track(conn)
 
 	return conn, nil
 }
@@ -316,8 +466,10 @@ func (a *http2ConnAdapter) Close() error {
 // Uses an adapter wrapping r.Body and w instead of Hijack, since
 // HTTP/2 connections do not support hijacking.
 func (u *Upgrader) upgradeHTTP2(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
-	if r.Proto != "websocket" {
-		u.returnError(w, r, http.StatusBadRequest, errors.New("websocket: invalid :protocol for HTTP/2"))
+	// The :protocol pseudo-header (RFC 8441, section 4) is exposed by the
+	// net/http and x/net HTTP/2 servers as a ":protocol" header entry.
+	if r.Proto != "websocket" && r.Header.Get(":protocol") != "websocket" {
+		u.returnError(w, r, http.StatusBadRequest, ErrInvalidProtocol)
 		return nil, ErrBadHandshake
 	}
 
@@ -326,11 +478,11 @@ func (u *Upgrader) upgradeHTTP2(w http.ResponseWriter, r *http.Request, response
 		checkOrigin = checkSameOrigin
 	}
 	if !checkOrigin(r) {
-		u.returnError(w, r, http.StatusForbidden, errors.New("websocket: origin not allowed"))
+		u.returnError(w, r, http.StatusForbidden, ErrOriginNotAllowed)
 		return nil, ErrBadHandshake
 	}
 
-	subprotocol := u.selectSubprotocol(r)
+	subprotocol := u.negotiateSubprotocol(r, responseHeader)
 
 	// Negotiate permessage-deflate extension per RFC 7692.
 	var compress bool
@@ -346,6 +498,9 @@ func (u *Upgrader) upgradeHTTP2(w http.ResponseWriter, r *http.Request, response
 	}
 
 	for k, vs := range responseHeader {
+		if isHandshakeHeader(k) {
+			continue
+		}
 		for _, v := range vs {
 			w.Header().Add(k, v)
 		}
@@ -359,11 +514,18 @@ func (u *Upgrader) upgradeHTTP2(w http.ResponseWriter, r *http.Request, response
 		w.Header().Set("Sec-WebSocket-Extensions", fmt.Sprintf("permessage-deflate%s", compressionParams))
 	}
 
+	slot, ok := u.acquireSlot()
+	if !ok {
+		u.rejectTooManyConnections(w, r)
+		return nil, ErrTooManyConnections
+	}
+
 	// RFC 8441: Response is 200 OK, not 101 Switching Protocols.
 	w.WriteHeader(http.StatusOK)
 
 	rc := http.NewResponseController(w)
 	if err := rc.Flush(); err != nil {
+		slot.release()
 		return nil, err
 	}
 
@@ -383,6 +545,7 @@ func (u *Upgrader) upgradeHTTP2(w http.ResponseWriter, r *http.Request, response
 	conn.subprotocol = subprotocol
 	conn.compressionEnabled = compress
 	u.applyConnPolicy(conn)
+	slot.track(conn)
 
 	return conn, nil
 }
@@ -408,6 +571,14 @@ func computeAcceptKey(challengeKey string) string {
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
+// CheckSameOrigin reports whether the request has no Origin header or an
+// Origin whose host matches the request Host. It is the check Upgrade
+// applies when CheckOrigin is nil, and lets a custom CheckOrigin fall back
+// to it.
+func CheckSameOrigin(r *http.Request) bool {
+	return checkSameOrigin(r)
+}
+
 func checkSameOrigin(r *http.Request) bool {
 	origin := r.Header.Get("Origin")
 	if origin == "" {
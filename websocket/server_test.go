@@ -3,6 +3,7 @@ package websocket
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -189,6 +191,7 @@ func TestCheckSameOrigin(t *testing.T) {
 			}
 			result := checkSameOrigin(r)
 			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, CheckSameOrigin(r))
 		})
 	}
 }
@@ -292,6 +295,58 @@ func TestUpgraderSelectSubprotocol(t *testing.T) {
 	}
 }
 
+func TestUpgraderSubprotocolNegotiation(t *testing.T) {
+	upgrade := func(t *testing.T, u *Upgrader, offered string) (*Conn, string) {
+		t.Helper()
+
+		server, client := net.Pipe()
+
+		writeBuf := new(bytes.Buffer)
+		hijacker := &mockHijacker{
+			ResponseWriter: httptest.NewRecorder(),
+			conn:           server,
+			reader:         bufio.NewReader(strings.NewReader("")),
+			writer:         bufio.NewWriter(writeBuf),
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Connection", "upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		r.Header.Set("Sec-WebSocket-Protocol", offered)
+
+		conn, err := u.Upgrade(hijacker, r, nil)
+		require.NoError(t, err)
+		require.NotNil(t, conn)
+		t.Cleanup(func() {
+			client.Close()
+			conn.Close()
+		})
+
+		return conn, writeBuf.String()
+	}
+
+	t.Run("Selects first mutually supported protocol", func(t *testing.T) {
+		u := &Upgrader{Subprotocols: []string{"v2.chat", "v1.chat"}}
+
+		conn, response := upgrade(t, u, "v1.chat, v2.chat")
+
+		assert.Equal(t, "v2.chat", conn.Subprotocol())
+		assert.Contains(t, response, "Sec-WebSocket-Protocol: v2.chat\r\n")
+	})
+
+	t.Run("No overlap proceeds without subprotocol", func(t *testing.T) {
+		u := &Upgrader{Subprotocols: []string{"v2.chat"}}
+
+		conn, response := upgrade(t, u, "mqtt, stomp")
+
+		assert.Empty(t, conn.Subprotocol())
+		assert.Contains(t, response, "HTTP/1.1 101 Switching Protocols\r\n")
+		assert.NotContains(t, response, "Sec-WebSocket-Protocol")
+	})
+}
+
 type mockHijacker struct {
 	http.ResponseWriter
 	conn   net.Conn
@@ -878,6 +933,128 @@ func TestUpgraderReturnError(t *testing.T) {
 		u.returnError(w, r, http.StatusBadRequest, ErrBadHandshake)
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	upgradeRequest := func(method string) *http.Request {
+		r := httptest.NewRequest(method, "http://example.com/ws", nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		return r
+	}
+
+	jsonError := func(calls *[]error) func(http.ResponseWriter, *http.Request, int, error) {
+		return func(w http.ResponseWriter, _ *http.Request, status int, reason error) {
+			*calls = append(*calls, reason)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = fmt.Fprintf(w, `{"status":%d,"error":%q}`, status, reason.Error())
+		}
+	}
+
+	t.Run("Rejections invoke hook", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			req    func() *http.Request
+			status int
+			reason error
+		}{
+			{"Not an upgrade", func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+			}, http.StatusBadRequest, ErrBadHandshake},
+			{"Wrong method", func() *http.Request {
+				return upgradeRequest(http.MethodPost)
+			}, http.StatusMethodNotAllowed, ErrBadHandshake},
+			{"Unsupported version", func() *http.Request {
+				r := upgradeRequest(http.MethodGet)
+				r.Header.Set("Sec-WebSocket-Version", "8")
+				return r
+			}, http.StatusBadRequest, ErrUnsupportedVersion},
+			{"Bad origin", func() *http.Request {
+				r := upgradeRequest(http.MethodGet)
+				r.Header.Set("Origin", "http://evil.example")
+				return r
+			}, http.StatusForbidden, ErrOriginNotAllowed},
+			{"Missing key", func() *http.Request {
+				r := upgradeRequest(http.MethodGet)
+				r.Header.Del("Sec-WebSocket-Key")
+				return r
+			}, http.StatusBadRequest, ErrMissingKey},
+			{"Not hijackable", func() *http.Request {
+				return upgradeRequest(http.MethodGet)
+			}, http.StatusInternalServerError, nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var calls []error
+				u := &Upgrader{Error: jsonError(&calls)}
+				w := httptest.NewRecorder()
+
+				conn, err := u.Upgrade(w, tt.req(), nil)
+				assert.Nil(t, conn)
+				assert.ErrorIs(t, err, ErrBadHandshake)
+
+				require.Len(t, calls, 1)
+				if tt.reason != nil {
+					assert.ErrorIs(t, calls[0], tt.reason)
+				}
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.JSONEq(t, fmt.Sprintf(`{"status":%d,"error":%q}`, tt.status, calls[0].Error()), w.Body.String())
+			})
+		}
+	})
+
+	t.Run("Hook body reaches client", func(t *testing.T) {
+		var calls []error
+		u := &Upgrader{Error: jsonError(&calls)}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = u.Upgrade(w, r, nil)
+		}))
+		defer server.Close()
+
+		h := http.Header{}
+		h.Set("Origin", "http://evil.example")
+		_, resp, err := DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), h)
+		require.ErrorIs(t, err, ErrBadHandshake)
+		require.NotNil(t, resp)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.JSONEq(t, `{"status":403,"error":"websocket: origin not allowed"}`, string(body))
+		require.Len(t, calls, 1)
+		assert.ErrorIs(t, calls[0], ErrOriginNotAllowed)
+	})
+
+	t.Run("Default response unchanged", func(t *testing.T) {
+		u := &Upgrader{}
+		w := httptest.NewRecorder()
+		r := upgradeRequest(http.MethodGet)
+		r.Header.Set("Origin", "http://evil.example")
+
+		_, err := u.Upgrade(w, r, nil)
+		assert.ErrorIs(t, err, ErrBadHandshake)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "websocket: origin not allowed\n", w.Body.String())
+	})
+
+	t.Run("HTTP/2 rejection invokes hook", func(t *testing.T) {
+		var calls []error
+		u := &Upgrader{Error: jsonError(&calls)}
+		r := httptest.NewRequest(http.MethodConnect, "http://example.com/ws", nil)
+		r.ProtoMajor = 2
+		r.Proto = "HTTP/2.0"
+		w := httptest.NewRecorder()
+
+		_, err := u.Upgrade(w, r, nil)
+		assert.ErrorIs(t, err, ErrBadHandshake)
+		require.Len(t, calls, 1)
+		assert.ErrorIs(t, calls[0], ErrInvalidProtocol)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
 
 func TestUpgraderReadBufferSize(t *testing.T) {
@@ -1346,6 +1523,24 @@ func TestUpgraderPingHandler(t *testing.T) {
 		conn.Close()
 	})
 
+	t.Run("ErrSkipPong sends no pong", func(t *testing.T) {
+		conn, client := upgradeConn(t, &Upgrader{
+			PingHandler: func(_ []byte) ([]byte, error) {
+				return nil, ErrSkipPong
+			},
+		})
+		defer conn.Close()
+		defer client.Close()
+
+		require.NoError(t, conn.handlePing([]byte("ping")))
+
+		_ = client.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		_, err := client.Read(make([]byte, 8))
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+	})
+
 	t.Run("RequireEmptyPingPayload enforced before PingHandler", func(t *testing.T) {
 		called := false
 		conn, client := upgradeConn(t, &Upgrader{
@@ -1432,3 +1627,293 @@ func TestUpgraderMaxFrameSize(t *testing.T) {
 		conn.Close()
 	})
 }
+
+type ctxKey struct{}
+
+// upgradeConnContext is like upgradeConn but upgrades with UpgradeContext.
+func upgradeConnContext(t *testing.T, u *Upgrader, ctx context.Context) (*Conn, net.Conn) {
+	t.Helper()
+
+	server, client := net.Pipe()
+
+	hijacker := &mockHijacker{
+		ResponseWriter: httptest.NewRecorder(),
+		conn:           server,
+		reader:         bufio.NewReader(strings.NewReader("")),
+		writer:         bufio.NewWriter(io.Discard),
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Connection", "upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+	conn, err := u.UpgradeContext(ctx, hijacker, r, nil)
+	require.NoError(t, err)
+	require.NotNil(t, conn)
+
+	return conn, client
+}
+
+func TestUpgraderContext(t *testing.T) {
+	t.Run("Unbound context keeps values but not cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "req-1"))
+		conn, client := upgradeConnContext(t, &Upgrader{}, ctx)
+		defer conn.Close()
+		defer client.Close()
+
+		cancel()
+		assert.Equal(t, "req-1", conn.Context().Value(ctxKey{}))
+		assert.NoError(t, conn.Context().Err())
+		assert.False(t, conn.IsClosed())
+	})
+
+	t.Run("Bound context cancel unblocks read", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		conn, client := upgradeConnContext(t, &Upgrader{BindContext: true}, ctx)
+		defer client.Close()
+
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		_, _, err := conn.ReadMessage()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.True(t, conn.IsClosed())
+		assert.ErrorIs(t, conn.Context().Err(), context.Canceled)
+
+		err = conn.WriteMessage(TextMessage, []byte("x"))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Bound context cancel unblocks write", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		conn, client := upgradeConnContext(t, &Upgrader{BindContext: true}, ctx)
+		defer client.Close()
+
+		shutdown := errors.New("server shutting down")
+		time.AfterFunc(20*time.Millisecond, func() { cancel(shutdown) })
+
+		// Nobody reads the client end, so the write blocks until cancel.
+		err := conn.WriteMessage(BinaryMessage, []byte("payload"))
+		assert.ErrorIs(t, err, shutdown)
+	})
+
+	t.Run("Already done context closes immediately", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		conn, client := upgradeConnContext(t, &Upgrader{BindContext: true}, ctx)
+		defer client.Close()
+
+		assert.Eventually(t, conn.IsClosed, time.Second, time.Millisecond)
+		_, _, err := conn.NextReader()
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Close detaches context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		conn, client := upgradeConnContext(t, &Upgrader{BindContext: true}, ctx)
+		defer client.Close()
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+
+		require.NoError(t, conn.Close())
+		cancel()
+
+		_, _, err := conn.ReadMessage()
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Server BaseContext cancel closes connections", func(t *testing.T) {
+		baseCtx, cancelBase := context.WithCancel(context.Background())
+		defer cancelBase()
+
+		upgrader := &Upgrader{BindContext: true}
+		readErr := make(chan error, 1)
+
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			_, _, err = conn.ReadMessage()
+			readErr <- err
+		}))
+		srv.Config.BaseContext = func(net.Listener) context.Context { return baseCtx }
+		srv.Start()
+		defer srv.Close()
+
+		d := &Dialer{}
+		client, _, err := d.Dial(fmt.Sprintf("ws%s", strings.TrimPrefix(srv.URL, "http")), nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		cancelBase()
+
+		select {
+		case err := <-readErr:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(2 * time.Second):
+			t.Fatal("server read not unblocked by BaseContext cancel")
+		}
+
+		_, _, err = client.ReadMessage()
+		assert.Error(t, err)
+	})
+}
+
+func TestUpgraderResponseHeader(t *testing.T) {
+	newRequest := func(protocols string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Connection", "upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		if protocols != "" {
+			r.Header.Set("Sec-WebSocket-Protocol", protocols)
+		}
+		return r
+	}
+
+	upgrade := func(t *testing.T, u *Upgrader, r *http.Request, h http.Header) (*Conn, *http.Response) {
+		t.Helper()
+		server, client := net.Pipe()
+		t.Cleanup(func() { client.Close() })
+
+		var out bytes.Buffer
+		hijacker := &mockHijacker{
+			ResponseWriter: httptest.NewRecorder(),
+			conn:           server,
+			reader:         bufio.NewReader(strings.NewReader("")),
+			writer:         bufio.NewWriter(&out),
+		}
+
+		conn, err := u.Upgrade(hijacker, r, h)
+		require.NoError(t, err)
+		t.Cleanup(func() { go func() { _, _ = io.Copy(io.Discard, client) }(); conn.Close() })
+
+		resp, err := http.ReadResponse(bufio.NewReader(&out), r)
+		require.NoError(t, err)
+		return conn, resp
+	}
+
+	t.Run("Custom headers included", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("X-Session-Id", "sess-42")
+		h.Add("Set-Cookie", "a=1")
+		h.Add("Set-Cookie", "b=2")
+
+		_, resp := upgrade(t, &Upgrader{}, newRequest(""), h)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, "sess-42", resp.Header.Get("X-Session-Id"))
+		assert.Len(t, resp.Cookies(), 2)
+	})
+
+	t.Run("Handshake headers not overridden", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Upgrade", "h2c")
+		h.Set("Sec-WebSocket-Accept", "forged")
+		h.Set("Sec-WebSocket-Extensions", "x-custom")
+
+		_, resp := upgrade(t, &Upgrader{}, newRequest(""), h)
+		assert.Equal(t, []string{"websocket"}, resp.Header.Values("Upgrade"))
+		assert.Equal(t, []string{computeAcceptKey("dGhlIHNhbXBsZSBub25jZQ==")}, resp.Header.Values("Sec-WebSocket-Accept"))
+		assert.Empty(t, resp.Header.Values("Sec-WebSocket-Extensions"))
+	})
+
+	t.Run("Subprotocol from response header", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Sec-WebSocket-Protocol", "chat.v2")
+
+		conn, resp := upgrade(t, &Upgrader{}, newRequest("chat.v1, chat.v2"), h)
+		assert.Equal(t, "chat.v2", conn.Subprotocol())
+		assert.Equal(t, []string{"chat.v2"}, resp.Header.Values("Sec-WebSocket-Protocol"))
+	})
+
+	t.Run("Subprotocol not offered by client is dropped", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Sec-WebSocket-Protocol", "other")
+
+		conn, resp := upgrade(t, &Upgrader{}, newRequest("chat.v1"), h)
+		assert.Empty(t, conn.Subprotocol())
+		assert.Empty(t, resp.Header.Values("Sec-WebSocket-Protocol"))
+	})
+
+	t.Run("Configured Subprotocols take precedence", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Sec-WebSocket-Protocol", "chat.v2")
+
+		conn, resp := upgrade(t, &Upgrader{Subprotocols: []string{"chat.v1"}}, newRequest("chat.v1, chat.v2"), h)
+		assert.Equal(t, "chat.v1", conn.Subprotocol())
+		assert.Equal(t, []string{"chat.v1"}, resp.Header.Values("Sec-WebSocket-Protocol"))
+	})
+}
+
+func TestUpgraderOnUpgrade(t *testing.T) {
+	type attempt struct {
+		path     string
+		duration time.Duration
+		err      error
+	}
+
+	var mu sync.Mutex
+	var attempts []attempt
+	upgrader := &Upgrader{
+		OnUpgrade: func(r *http.Request, duration time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts = append(attempts, attempt{path: r.URL.Path, duration: duration, err: err})
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	last := func() attempt {
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEmpty(t, attempts)
+		return attempts[len(attempts)-1]
+	}
+
+	t.Run("Successful upgrade", func(t *testing.T) {
+		conn, _, err := DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ok", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		a := last()
+		assert.Equal(t, "/ok", a.path)
+		assert.NoError(t, a.err)
+		assert.Positive(t, a.duration)
+	})
+
+	t.Run("Failed upgrade", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/plain")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		a := last()
+		assert.Equal(t, "/plain", a.path)
+		assert.Error(t, a.err)
+	})
+
+	t.Run("Rejected origin", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Origin", "http://evil.example")
+		_, _, err := DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/origin", h)
+		require.ErrorIs(t, err, ErrBadHandshake)
+
+		a := last()
+		assert.Equal(t, "/origin", a.path)
+		assert.Error(t, a.err)
+	})
+}
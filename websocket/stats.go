@@ -0,0 +1,79 @@
+package websocket
+
+import "sync/atomic"
+
+// ConnStats is a snapshot of the traffic counters of a connection.
+//
+// Byte counts are measured on the wire: they include frame headers, masking
+// keys, and control frames, and reflect compressed payload sizes when
+// permessage-deflate (RFC 7692) is in use. Message counts cover data
+// messages only; a fragmented message is counted once. Message, ping, and
+// pong counts include only frames written successfully, while a failed
+// write still adds the bytes it put on the wire.
+type ConnStats struct {
+	MessagesRead    uint64
+	MessagesWritten uint64
+	BytesRead       uint64
+	BytesWritten    uint64
+	PingsReceived   uint64
+	PingsSent       uint64
+	PongsReceived   uint64
+	PongsSent       uint64
+}
+
+// connStats holds the live counters behind ConnStats.
+type connStats struct {
+	messagesRead    atomic.Uint64
+	messagesWritten atomic.Uint64
+	bytesRead       atomic.Uint64
+	bytesWritten    atomic.Uint64
+	pingsReceived   atomic.Uint64
+	pingsSent       atomic.Uint64
+	pongsReceived   atomic.Uint64
+	pongsSent       atomic.Uint64
+}
+
+// recordRead accounts for a frame of n wire bytes read from the peer.
+func (s *connStats) recordRead(frameType int, n int) {
+	s.bytesRead.Add(uint64(n))
+	switch frameType {
+	case TextMessage, BinaryMessage:
+		s.messagesRead.Add(1)
+	case PingMessage:
+		s.pingsReceived.Add(1)
+	case PongMessage:
+		s.pongsReceived.Add(1)
+	}
+}
+
+// recordWrite accounts for a frame of n wire bytes written to the peer.
+// The frame is counted only when the write returned no error.
+func (s *connStats) recordWrite(frameType int, n int, err error) {
+	s.bytesWritten.Add(uint64(n))
+	if err != nil {
+		return
+	}
+	switch frameType {
+	case TextMessage, BinaryMessage:
+		s.messagesWritten.Add(1)
+	case PingMessage:
+		s.pingsSent.Add(1)
+	case PongMessage:
+		s.pongsSent.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the connection's traffic counters. It is safe
+// to call concurrently with reads and writes.
+func (c *Conn) Stats() ConnStats {
+	return ConnStats{
+		MessagesRead:    c.stats.messagesRead.Load(),
+		MessagesWritten: c.stats.messagesWritten.Load(),
+		BytesRead:       c.stats.bytesRead.Load(),
+		BytesWritten:    c.stats.bytesWritten.Load(),
+		PingsReceived:   c.stats.pingsReceived.Load(),
+		PingsSent:       c.stats.pingsSent.Load(),
+		PongsReceived:   c.stats.pongsReceived.Load(),
+		PongsSent:       c.stats.pongsSent.Load(),
+	}
+}
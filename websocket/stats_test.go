@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnStats(t *testing.T) {
+	t.Run("Zero value for new connection", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		assert.Equal(t, ConnStats{}, conn.Stats())
+	})
+
+	t.Run("Two messages written and one read", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("hi"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("hello")))
+		require.NoError(t, conn.WriteMessage(BinaryMessage, []byte{1, 2, 3}))
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		assert.Equal(t, ConnStats{
+			MessagesRead:    1,
+			MessagesWritten: 2,
+			BytesRead:       2 + 4 + 2, // header, mask, payload
+			BytesWritten:    (2 + 5) + (2 + 3),
+		}, conn.Stats())
+		assert.Equal(t, uint64(mock.writeBuf.Len()), conn.Stats().BytesWritten)
+	})
+
+	t.Run("Pings and pongs", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(PingMessage), []byte("p"), true))
+		mock.readBuf.Write(buildMaskedFrame(byte(PongMessage), nil, true))
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("x"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteControl(PingMessage, nil, time.Time{}))
+
+		stats := conn.Stats()
+		assert.Equal(t, uint64(1), stats.PingsReceived)
+		assert.Equal(t, uint64(1), stats.PongsReceived)
+		assert.Equal(t, uint64(1), stats.PongsSent)
+		assert.Equal(t, uint64(1), stats.PingsSent)
+		assert.Equal(t, uint64(1), stats.MessagesRead)
+		assert.Equal(t, uint64(0), stats.MessagesWritten)
+		assert.Equal(t, uint64((2+4+1)+(2+4)+(2+4+1)), stats.BytesRead)
+		assert.Equal(t, uint64((2+1)+2), stats.BytesWritten)
+	})
+
+	t.Run("Fragmented messages count once", func(t *testing.T) {
+		mock := newMockConn()
+		mock.readBuf.Write(buildMaskedFrame(byte(TextMessage), []byte("ab"), false))
+		mock.readBuf.Write(buildMaskedFrame(byte(continuationFrame), []byte("cd"), true))
+		conn := newConn(mock, true, 0, 0)
+
+		_, p, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "abcd", string(p))
+
+		w, err := conn.NextWriter(TextMessage)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte("ab"))
+		_, _ = w.Write([]byte("cd"))
+		require.NoError(t, w.Close())
+
+		stats := conn.Stats()
+		assert.Equal(t, uint64(1), stats.MessagesRead)
+		assert.Equal(t, uint64(1), stats.MessagesWritten)
+		assert.Equal(t, uint64(2*(2+4+2)), stats.BytesRead)
+		assert.Equal(t, uint64(mock.writeBuf.Len()), stats.BytesWritten)
+	})
+
+	t.Run("Client frames include masking key", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, false, 0, 0)
+
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte("hello")))
+		assert.Equal(t, uint64(2+4+5), conn.Stats().BytesWritten)
+	})
+
+	t.Run("Prepared messages", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+
+		pm, err := NewPreparedMessage(TextMessage, []byte("broadcast"))
+		require.NoError(t, err)
+		require.NoError(t, conn.WritePreparedMessage(pm))
+
+		stats := conn.Stats()
+		assert.Equal(t, uint64(1), stats.MessagesWritten)
+		assert.Equal(t, uint64(2+9), stats.BytesWritten)
+	})
+
+	t.Run("Failed writes are not counted", func(t *testing.T) {
+		conn := newConnFromRWC(connConfig{
+			rwc:             &failingWriter{err: errors.New("write failed")},
+			isServer:        true,
+			readBufferSize:  1024,
+			writeBufferSize: 1024,
+		})
+
+		require.Error(t, conn.WriteControl(PingMessage, nil, time.Time{}))
+		require.Error(t, conn.WriteMessage(TextMessage, []byte("hello")))
+
+		pm, err := NewPreparedMessage(TextMessage, []byte("broadcast"))
+		require.NoError(t, err)
+		require.Error(t, conn.WritePreparedMessage(pm))
+
+		assert.Equal(t, ConnStats{}, conn.Stats())
+	})
+
+	t.Run("Concurrent snapshot with race detector", func(t *testing.T) {
+		conn := newConn(&benchMockConn{}, true, 0, 0)
+
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			for range 100 {
+				_ = conn.WriteMessage(TextMessage, []byte("x"))
+			}
+		})
+		wg.Go(func() {
+			for range 100 {
+				_ = conn.Stats()
+			}
+		})
+		wg.Wait()
+
+		assert.Equal(t, uint64(100), conn.Stats().MessagesWritten)
+	})
+}
+
+func BenchmarkConnStats(b *testing.B) {
+	conn := newConn(&benchMockConn{}, true, 0, 0)
+
+	for b.Loop() {
+		_ = conn.Stats()
+	}
+}
@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+)
+
+// HandshakeTrace is a set of hooks run at each phase of the client opening
+// handshake, to measure where establishing a connection spends its time.
+// Any hook may be nil. Hooks run synchronously on the dialing goroutine.
+//
+// The DNS and connect hooks are reported by the net.Dialer, so they fire
+// when the connection is dialed by the default dialer, or by a
+// NetDialContext or transport DialContext that dials with the context it
+// is given. DNS hooks do not fire for IP address literals. With a proxy,
+// they report the connection to the proxy. When Dialer.HTTPClient uses an
+// HTTP/2 transport, that transport establishes the connection and reports
+// only the phases it traces itself.
+//
+// An httptrace.ClientTrace in the context passed to DialContext sees the
+// same phases and, for the HTTP portion, GotConn, WroteHeaders,
+// WroteRequest, and GotFirstResponseByte; HandshakeTrace hooks run first.
+type HandshakeTrace struct {
+	// DNSStart is called when a host name lookup begins.
+	DNSStart func(host string)
+
+	// DNSDone is called when the lookup ends, with the addresses found or
+	// the lookup error.
+	DNSDone func(addrs []net.IPAddr, err error)
+
+	// ConnectStart is called when a TCP connection attempt to addr begins.
+	// It may be called several times when the host has several addresses.
+	ConnectStart func(network, addr string)
+
+	// ConnectDone is called when a connection attempt started by
+	// ConnectStart ends; err is nil when it succeeded.
+	ConnectDone func(network, addr string, err error)
+
+	// TLSHandshakeStart is called when the TLS handshake with the target
+	// begins.
+	TLSHandshakeStart func()
+
+	// TLSHandshakeDone is called when the TLS handshake ends, with the
+	// connection state or the handshake error.
+	TLSHandshakeDone func(state tls.ConnectionState, err error)
+
+	// GotSwitchingProtocols is called when the server has accepted the
+	// upgrade: a valid 101 Switching Protocols response over HTTP/1.1, or
+	// 200 for WebSocket over HTTP/2 (RFC 8441).
+	GotSwitchingProtocols func(resp *http.Response)
+}
+
+// withClientTrace returns ctx carrying t's connection hooks as an
+// httptrace.ClientTrace, composed with any trace already in ctx. A nil t
+// returns ctx unchanged.
+func (t *HandshakeTrace) withClientTrace(ctx context.Context) context.Context {
+	if t == nil {
+		return ctx
+	}
+
+	trace := &httptrace.ClientTrace{
+		ConnectStart:      t.ConnectStart,
+		ConnectDone:       t.ConnectDone,
+		TLSHandshakeStart: t.TLSHandshakeStart,
+		TLSHandshakeDone:  t.TLSHandshakeDone,
+	}
+	if t.DNSStart != nil {
+		trace.DNSStart = func(info httptrace.DNSStartInfo) {
+			t.DNSStart(info.Host)
+		}
+	}
+	if t.DNSDone != nil {
+		trace.DNSDone = func(info httptrace.DNSDoneInfo) {
+			t.DNSDone(info.Addrs, info.Err)
+		}
+	}
+
+	return httptrace.WithClientTrace(ctx, trace)
+}
+
+// gotSwitchingProtocols reports an accepted upgrade to t.
+func (t *HandshakeTrace) gotSwitchingProtocols(resp *http.Response) {
+	if t != nil && t.GotSwitchingProtocols != nil {
+		t.GotSwitchingProtocols(resp)
+	}
+}
+
+// tlsHandshake runs the client TLS handshake on tlsConn, reporting it to
+// the httptrace.ClientTrace in ctx.
+func tlsHandshake(ctx context.Context, tlsConn *tls.Conn) error {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	err := tlsConn.HandshakeContext(ctx)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+	}
+	return err
+}
@@ -0,0 +1,229 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// traceRecorder collects handshake phase names in the order they occur.
+type traceRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *traceRecorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *traceRecorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *traceRecorder) trace() *HandshakeTrace {
+	return &HandshakeTrace{
+		DNSStart: func(host string) { r.add("DNSStart " + host) },
+		DNSDone: func(addrs []net.IPAddr, err error) {
+			if err == nil && len(addrs) > 0 {
+				r.add("DNSDone")
+			}
+		},
+		ConnectStart: func(_, _ string) { r.add("ConnectStart") },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				r.add("ConnectDone")
+			}
+		},
+		TLSHandshakeStart: func() { r.add("TLSHandshakeStart") },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				r.add("TLSHandshakeDone error")
+				return
+			}
+			if state.HandshakeComplete {
+				r.add("TLSHandshakeDone")
+			}
+		},
+		GotSwitchingProtocols: func(resp *http.Response) {
+			r.add(fmt.Sprintf("GotSwitchingProtocols %d", resp.StatusCode))
+		},
+	}
+}
+
+func TestHandshakeTrace(t *testing.T) {
+	upgrader := &Upgrader{
+		CheckOrigin: func(_ *http.Request) bool { return true },
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	localhostURL := func(serverURL, scheme string) string {
+		u := strings.Replace(serverURL, "127.0.0.1", "localhost", 1)
+		return scheme + u[strings.Index(u, "://"):]
+	}
+
+	t.Run("Reports each phase in order", func(t *testing.T) {
+		rec := &traceRecorder{}
+		d := &Dialer{Trace: rec.trace()}
+
+		conn, _, err := d.Dial(localhostURL(server.URL, "ws"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, []string{
+			"DNSStart localhost",
+			"DNSDone",
+			"ConnectStart",
+			"ConnectDone",
+			"GotSwitchingProtocols 101",
+		}, rec.recorded())
+	})
+
+	t.Run("Reports TLS handshake", func(t *testing.T) {
+		rec := &traceRecorder{}
+		d := &Dialer{
+			TLSClientConfig: tlsServer.Client().Transport.(*http.Transport).TLSClientConfig,
+			Trace:           rec.trace(),
+		}
+
+		conn, _, err := d.Dial("wss"+strings.TrimPrefix(tlsServer.URL, "https"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, []string{
+			"ConnectStart",
+			"ConnectDone",
+			"TLSHandshakeStart",
+			"TLSHandshakeDone",
+			"GotSwitchingProtocols 101",
+		}, rec.recorded())
+	})
+
+	t.Run("Reports TLS handshake failure", func(t *testing.T) {
+		rec := &traceRecorder{}
+		d := &Dialer{Trace: rec.trace()}
+
+		_, _, err := d.Dial("wss"+strings.TrimPrefix(tlsServer.URL, "https"), nil)
+		require.Error(t, err)
+		assert.Equal(t, []string{
+			"ConnectStart",
+			"ConnectDone",
+			"TLSHandshakeStart",
+			"TLSHandshakeDone error",
+		}, rec.recorded())
+	})
+
+	t.Run("No upgrade on rejected handshake", func(t *testing.T) {
+		plain := httptest.NewServer(http.NotFoundHandler())
+		defer plain.Close()
+
+		rec := &traceRecorder{}
+		d := &Dialer{Trace: rec.trace()}
+
+		_, resp, err := d.Dial("ws"+strings.TrimPrefix(plain.URL, "http"), nil)
+		assert.ErrorIs(t, err, ErrBadHandshake)
+		require.NotNil(t, resp)
+		assert.NotContains(t, rec.recorded(), "GotSwitchingProtocols 404")
+	})
+
+	t.Run("Honors ClientTrace in context", func(t *testing.T) {
+		rec := &traceRecorder{}
+		ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+			ConnectDone: func(_, _ string, err error) {
+				if err == nil {
+					rec.add("client ConnectDone")
+				}
+			},
+			GotConn:      func(httptrace.GotConnInfo) { rec.add("client GotConn") },
+			WroteHeaders: func() { rec.add("client WroteHeaders") },
+			WroteRequest: func(info httptrace.WroteRequestInfo) {
+				if info.Err == nil {
+					rec.add("client WroteRequest")
+				}
+			},
+			GotFirstResponseByte: func() { rec.add("client GotFirstResponseByte") },
+		})
+
+		d := &Dialer{Trace: &HandshakeTrace{
+			ConnectDone: func(_, _ string, err error) {
+				if err == nil {
+					rec.add("ConnectDone")
+				}
+			},
+		}}
+
+		conn, _, err := d.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, []string{
+			"ConnectDone",
+			"client ConnectDone",
+			"client GotConn",
+			"client WroteHeaders",
+			"client WroteRequest",
+			"client GotFirstResponseByte",
+		}, rec.recorded())
+		assert.Same(t, httptrace.ContextClientTrace(ctx), httptrace.ContextClientTrace(conn.Context()),
+			"connection keeps the caller's context")
+	})
+
+	t.Run("Nil trace", func(t *testing.T) {
+		d := &Dialer{}
+		conn, _, err := d.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Nil(t, httptrace.ContextClientTrace(conn.Context()))
+	})
+}
+
+func BenchmarkHandshakeTrace(b *testing.B) {
+	upgrader := &Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	d := &Dialer{Trace: &HandshakeTrace{
+		ConnectDone:           func(_, _ string, _ error) {},
+		GotSwitchingProtocols: func(*http.Response) {},
+	}}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		conn, _, err := d.Dial(wsURL, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = conn.Close()
+	}
+}
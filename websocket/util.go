@@ -19,6 +19,11 @@ type BufferPool interface {
 // FormatCloseMessage formats closeCode and text as a WebSocket close message
 // per RFC 6455, section 5.5.1. The close frame body consists of a 2-byte
 // status code followed by optional UTF-8 encoded reason text.
+//
+// FormatCloseMessage does not validate its arguments. Codes rejected by
+// IsValidCloseCode, such as CloseAbnormalClosure and CloseTLSHandshake, and
+// reasons that are not valid UTF-8 are serialized as given, and WriteControl
+// refuses to send the result.
 func FormatCloseMessage(closeCode int, text string) []byte {
 	if closeCode == CloseNoStatusReceived {
 		return []byte{}
@@ -0,0 +1,219 @@
+// Package websockettest provides utilities for testing WebSocket code
+// without a network listener.
+//
+// NewPipe returns a connected server and client Conn over an in-memory
+// transport. The connections are established with a real opening handshake
+// (RFC 6455, section 4), so reads, writes, control frames, and the closing
+// handshake behave exactly as they do over TCP:
+//
+//	server, client := websockettest.NewPipe()
+//	defer server.Close()
+//	defer client.Close()
+//
+//	go websockettest.Echo(server)
+//
+//	_ = client.WriteMessage(websocket.TextMessage, []byte("hello"))
+//	_, p, _ := client.ReadMessage() // "hello"
+package websockettest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/vitalvas/kasper/websocket"
+)
+
+// NewPipe returns two connected WebSocket connections: server is the server
+// side of the connection and client the client side. Messages written to one
+// are read from the other.
+//
+// The transport is built on net.Pipe with an in-memory buffer in each
+// direction, so like a TCP socket a write completes without waiting for the
+// peer to read it, and Close does not block on an idle peer. Deadlines are
+// honoured. Both connections must be closed by the caller. NewPipe panics
+// if the handshake fails.
+func NewPipe() (server, client *websocket.Conn) {
+	server, client, err := newPipe()
+	if err != nil {
+		panic(fmt.Sprintf("websockettest: %v", err))
+	}
+	return server, client
+}
+
+// Echo reads messages from conn and writes each one back with the same
+// message type until a read or write fails. It returns the error that ended
+// the loop, which is a *websocket.CloseError when the peer closed the
+// connection. Echo is intended to be run in its own goroutine as a
+// stand-in for a peer.
+func Echo(conn *websocket.Conn) error {
+	for {
+		messageType, p, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := conn.WriteMessage(messageType, p); err != nil {
+			return err
+		}
+	}
+}
+
+// newPipe performs the opening handshake over a buffered pipe. The server end is
+// served by an http.Server listening on a single-connection listener.
+func newPipe() (*websocket.Conn, *websocket.Conn, error) {
+	serverEnd, clientEnd := bufferedPipe()
+	ln := newPipeListener(serverEnd)
+
+	type upgradeResult struct {
+		conn *websocket.Conn
+		err  error
+	}
+	upgraded := make(chan upgradeResult, 1)
+
+	upgrader := &websocket.Upgrader{}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			upgraded <- upgradeResult{conn, err}
+		}),
+	}
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		_ = srv.Serve(ln)
+	}()
+
+	d := &websocket.Dialer{
+		NetDialContext: func(context.Context, string, string) (net.Conn, error) {
+			return clientEnd, nil
+		},
+		Proxy: func(*http.Request) (*url.URL, error) { return nil, nil },
+	}
+
+	client, _, err := d.Dial("ws://pipe/", nil)
+
+	// The handshake either hijacked the server end or failed; either way
+	// the listener has nothing more to serve.
+	_ = ln.Close()
+	<-served
+
+	if err != nil {
+		_ = clientEnd.Close()
+		_ = serverEnd.Close()
+		return nil, nil, err
+	}
+
+	res := <-upgraded
+	if res.err != nil {
+		_ = client.Close()
+		return nil, nil, res.err
+	}
+	return res.conn, client, nil
+}
+
+// pipeListener is a net.Listener that accepts a single pre-established
+// connection.
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+	addr   net.Addr
+}
+
+func newPipeListener(conn net.Conn) *pipeListener {
+	l := &pipeListener{
+		conns:  make(chan net.Conn, 1),
+		closed: make(chan struct{}),
+		addr:   conn.LocalAddr(),
+	}
+	l.conns <- conn
+	return l
+}
+
+// Accept returns the pipe connection once, then blocks until the listener
+// is closed.
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close unblocks Accept.
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+// Addr returns the address of the pipe.
+func (l *pipeListener) Addr() net.Addr {
+	return l.addr
+}
+
+// bufferedPipe is like net.Pipe, except that data written to either end is
+// queued in memory until the other end reads it.
+func bufferedPipe() (net.Conn, net.Conn) {
+	a, aRelay := net.Pipe()
+	bRelay, b := net.Pipe()
+	go relay(aRelay, bRelay)
+	go relay(bRelay, aRelay)
+	return a, b
+}
+
+// relay copies everything read from src to dst through an unbounded queue,
+// so reads from src never wait on writes to dst. When src reaches EOF the
+// queue is drained and dst is closed; when dst fails, src is closed.
+func relay(src, dst net.Conn) {
+	var (
+		mu    sync.Mutex
+		cond  = sync.NewCond(&mu)
+		queue [][]byte
+		done  bool
+	)
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := src.Read(buf)
+			mu.Lock()
+			if n > 0 {
+				queue = append(queue, bytes.Clone(buf[:n]))
+			}
+			if err != nil {
+				done = true
+			}
+			cond.Signal()
+			mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		mu.Lock()
+		for len(queue) == 0 && !done {
+			cond.Wait()
+		}
+		if len(queue) == 0 {
+			mu.Unlock()
+			break
+		}
+		chunk := queue[0]
+		queue = queue[1:]
+		mu.Unlock()
+
+		if _, err := dst.Write(chunk); err != nil {
+			break
+		}
+	}
+
+	_ = dst.Close()
+	_ = src.Close()
+}
@@ -0,0 +1,120 @@
+package websockettest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vitalvas/kasper/websocket"
+)
+
+func TestNewPipe(t *testing.T) {
+	t.Run("Message from client to server", func(t *testing.T) {
+		server, client := NewPipe()
+		defer server.Close()
+		defer client.Close()
+
+		require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte("hello")))
+
+		mt, p, err := server.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.TextMessage, mt)
+		assert.Equal(t, "hello", string(p))
+	})
+
+	t.Run("Close from server to client", func(t *testing.T) {
+		server, client := NewPipe()
+		defer server.Close()
+		defer client.Close()
+
+		require.NoError(t, server.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "bye"), time.Now().Add(time.Second)))
+
+		_, _, err := client.ReadMessage()
+		require.Error(t, err)
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, "bye", closeErr.Text)
+	})
+
+	t.Run("Independent pipes", func(t *testing.T) {
+		server1, client1 := NewPipe()
+		defer server1.Close()
+		defer client1.Close()
+		server2, client2 := NewPipe()
+		defer server2.Close()
+		defer client2.Close()
+
+		require.NoError(t, server2.WriteMessage(websocket.BinaryMessage, []byte{2}))
+		require.NoError(t, server1.WriteMessage(websocket.BinaryMessage, []byte{1}))
+
+		_, p, err := client1.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1}, p)
+		_, p, err = client2.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{2}, p)
+	})
+}
+
+func TestBufferedPipe(t *testing.T) {
+	t.Run("Close does not wait for peer", func(t *testing.T) {
+		server, client := NewPipe()
+		defer client.Close()
+
+		start := time.Now()
+		require.NoError(t, server.Close())
+		assert.Less(t, time.Since(start), time.Second)
+
+		_, _, err := client.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	})
+
+	t.Run("Read deadline", func(t *testing.T) {
+		server, client := NewPipe()
+		defer server.Close()
+		defer client.Close()
+
+		require.NoError(t, client.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+		_, _, err := client.ReadMessage()
+		require.Error(t, err)
+	})
+}
+
+func TestEcho(t *testing.T) {
+	t.Run("Echoes messages until close", func(t *testing.T) {
+		server, client := NewPipe()
+		defer server.Close()
+		defer client.Close()
+
+		done := make(chan error, 1)
+		go func() { done <- Echo(server) }()
+
+		for _, tc := range []struct {
+			messageType int
+			data        string
+		}{
+			{websocket.TextMessage, "ping"},
+			{websocket.BinaryMessage, "\x00\x01\x02"},
+		} {
+			require.NoError(t, client.WriteMessage(tc.messageType, []byte(tc.data)))
+			mt, p, err := client.ReadMessage()
+			require.NoError(t, err)
+			assert.Equal(t, tc.messageType, mt)
+			assert.Equal(t, tc.data, string(p))
+		}
+
+		require.NoError(t, client.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second)))
+
+		select {
+		case err := <-done:
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+		case <-time.After(2 * time.Second):
+			t.Fatal("Echo did not return after close")
+		}
+	})
+}
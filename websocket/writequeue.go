@@ -0,0 +1,155 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+// Errors returned by the write queue.
+var (
+	ErrWriteQueueFull     = errors.New("websocket: write queue full")
+	ErrWriteQueueDisabled = errors.New("websocket: write queue not enabled")
+)
+
+// WriteQueuePolicy controls what WriteMessageAsync does when the write queue
+// is full.
+type WriteQueuePolicy int
+
+const (
+	// WriteQueuePolicyReject rejects the new message with ErrWriteQueueFull
+	// (default).
+	WriteQueuePolicyReject WriteQueuePolicy = iota
+	// WriteQueuePolicyDropOldest discards the oldest queued message to make
+	// room for the new one. Suited to streams where only recent state
+	// matters, such as price tickers or presence updates.
+	WriteQueuePolicyDropOldest
+)
+
+// queuedMessage is a data message waiting in the write queue.
+type queuedMessage struct {
+	messageType int
+	data        []byte
+}
+
+// writeQueue is the bounded queue drained by the writer goroutine started
+// by EnableWriteQueue.
+type writeQueue struct {
+	ch   chan queuedMessage
+	done chan struct{}
+	once sync.Once
+	err  error // set before done is closed
+}
+
+// stop terminates the queue with err. Only the first call takes effect.
+func (q *writeQueue) stop(err error) {
+	q.once.Do(func() {
+		q.err = err
+		close(q.done)
+	})
+}
+
+// EnableWriteQueue starts a writer goroutine that sends data messages queued
+// with WriteMessageAsync, holding at most size messages (a non-positive size
+// is treated as 1). Calls after the first have no effect.
+//
+// The writer goroutine becomes the sole writer of queued messages and
+// satisfies the single-writer rule on its own, so any number of goroutines
+// may call WriteMessageAsync concurrently. WriteMessage, NextWriter, and
+// WriteControl remain usable and are serialized with the queue, but their
+// messages are not ordered relative to queued ones; control frames such as
+// pings and close bypass the queue.
+//
+// Messages still queued when the connection closes are discarded. If a
+// queued write fails, the queue stops and later WriteMessageAsync calls
+// return that error.
+func (c *Conn) EnableWriteQueue(size int) {
+	if size < 1 {
+		size = 1
+	}
+	q := &writeQueue{
+		ch:   make(chan queuedMessage, size),
+		done: make(chan struct{}),
+	}
+	if !c.writeQueue.CompareAndSwap(nil, q) {
+		return
+	}
+	if c.IsClosed() {
+		q.stop(ErrWriteToClosedConnection)
+		return
+	}
+	go c.runWriteQueue(q)
+}
+
+// SetWriteQueuePolicy sets the behaviour of WriteMessageAsync when the queue
+// is full. It should be called before messages are queued.
+func (c *Conn) SetWriteQueuePolicy(policy WriteQueuePolicy) {
+	c.writeQueuePolicy = policy
+}
+
+// WriteMessageAsync queues a data message for the writer goroutine started by
+// EnableWriteQueue and returns without waiting for it to be sent. The data is
+// copied, so the caller may reuse it immediately.
+//
+// When the queue is full it returns ErrWriteQueueFull, or evicts the oldest
+// queued message under WriteQueuePolicyDropOldest. It returns
+// ErrWriteQueueDisabled if the queue was never enabled, and the error that
+// stopped the queue once the connection is closed or a write has failed.
+func (c *Conn) WriteMessageAsync(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return ErrInvalidMessageType
+	}
+
+	q := c.writeQueue.Load()
+	if q == nil {
+		return ErrWriteQueueDisabled
+	}
+
+	msg := queuedMessage{messageType: messageType, data: bytes.Clone(data)}
+	for {
+		select {
+		case <-q.done:
+			return q.err
+		default:
+		}
+
+		select {
+		case q.ch <- msg:
+			return nil
+		default:
+		}
+
+		if c.writeQueuePolicy != WriteQueuePolicyDropOldest {
+			return ErrWriteQueueFull
+		}
+		select {
+		case <-q.ch:
+		default:
+		}
+	}
+}
+
+// WriteQueueLen returns the number of messages waiting in the write queue,
+// or 0 if the queue is not enabled. A length that stays close to the queue
+// size indicates a consumer that cannot keep up.
+func (c *Conn) WriteQueueLen() int {
+	if q := c.writeQueue.Load(); q != nil {
+		return len(q.ch)
+	}
+	return 0
+}
+
+// runWriteQueue sends queued messages in order until the queue is stopped.
+func (c *Conn) runWriteQueue(q *writeQueue) {
+	for {
+		select {
+		case <-q.done:
+			return
+		case msg := <-q.ch:
+			if err := c.WriteMessage(msg.messageType, msg.data); err != nil {
+				q.stop(err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteQueue(t *testing.T) {
+	t.Run("Disabled by default", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		assert.ErrorIs(t, conn.WriteMessageAsync(TextMessage, []byte("x")), ErrWriteQueueDisabled)
+		assert.Equal(t, 0, conn.WriteQueueLen())
+	})
+
+	t.Run("Invalid message type", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		conn.EnableWriteQueue(1)
+		defer conn.Close()
+		assert.ErrorIs(t, conn.WriteMessageAsync(PingMessage, nil), ErrInvalidMessageType)
+	})
+
+	t.Run("Messages flush in order", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		server := newConn(serverSide, true, 0, 0)
+		client := newConn(clientSide, false, 0, 0)
+		defer server.Close()
+		defer clientSide.Close()
+
+		server.EnableWriteQueue(4)
+
+		const total = 20
+		go func() {
+			for i := range total {
+				msg := []byte(fmt.Sprintf("msg-%d", i))
+				for server.WriteMessageAsync(TextMessage, msg) != nil {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}()
+
+		for i := range total {
+			mt, p, err := client.ReadMessage()
+			require.NoError(t, err)
+			assert.Equal(t, TextMessage, mt)
+			assert.Equal(t, fmt.Sprintf("msg-%d", i), string(p))
+		}
+	})
+
+	t.Run("Full queue returns error", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		server := newConn(serverSide, true, 0, 0)
+		defer server.Close()
+		defer clientSide.Close()
+
+		server.EnableWriteQueue(2)
+
+		// Nobody reads the pipe, so the writer blocks on the first message.
+		require.NoError(t, server.WriteMessageAsync(BinaryMessage, []byte{0}))
+		require.Eventually(t, func() bool { return server.WriteQueueLen() == 0 }, time.Second, time.Millisecond)
+
+		require.NoError(t, server.WriteMessageAsync(BinaryMessage, []byte{1}))
+		require.NoError(t, server.WriteMessageAsync(BinaryMessage, []byte{2}))
+		assert.Equal(t, 2, server.WriteQueueLen())
+		assert.ErrorIs(t, server.WriteMessageAsync(BinaryMessage, []byte{3}), ErrWriteQueueFull)
+	})
+
+	t.Run("Drop oldest policy", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		server := newConn(serverSide, true, 0, 0)
+		client := newConn(clientSide, false, 0, 0)
+		defer server.Close()
+		defer clientSide.Close()
+
+		server.SetWriteQueuePolicy(WriteQueuePolicyDropOldest)
+		server.EnableWriteQueue(2)
+
+		require.NoError(t, server.WriteMessageAsync(BinaryMessage, []byte{0}))
+		require.Eventually(t, func() bool { return server.WriteQueueLen() == 0 }, time.Second, time.Millisecond)
+
+		for i := byte(1); i <= 4; i++ {
+			require.NoError(t, server.WriteMessageAsync(BinaryMessage, []byte{i}))
+		}
+		assert.Equal(t, 2, server.WriteQueueLen())
+
+		var got []byte
+		for range 3 {
+			_, p, err := client.ReadMessage()
+			require.NoError(t, err)
+			got = append(got, p...)
+		}
+		assert.Equal(t, []byte{0, 3, 4}, got)
+	})
+
+	t.Run("Data is copied", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		server := newConn(serverSide, true, 0, 0)
+		client := newConn(clientSide, false, 0, 0)
+		defer server.Close()
+		defer clientSide.Close()
+
+		server.EnableWriteQueue(1)
+		buf := []byte("before")
+		require.NoError(t, server.WriteMessageAsync(TextMessage, buf))
+		copy(buf, "after!")
+
+		_, p, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "before", string(p))
+	})
+
+	t.Run("Closed connection stops queue", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		conn.EnableWriteQueue(1)
+		require.NoError(t, conn.Close())
+		assert.ErrorIs(t, conn.WriteMessageAsync(TextMessage, []byte("x")), ErrWriteToClosedConnection)
+	})
+
+	t.Run("Enable after close", func(t *testing.T) {
+		conn := newConn(newMockConn(), true, 0, 0)
+		require.NoError(t, conn.Close())
+		conn.EnableWriteQueue(1)
+		assert.ErrorIs(t, conn.WriteMessageAsync(TextMessage, []byte("x")), ErrWriteToClosedConnection)
+	})
+
+	t.Run("Write failure stops queue", func(t *testing.T) {
+		mock := newMockConn()
+		conn := newConn(mock, true, 0, 0)
+		require.NoError(t, conn.WriteControl(CloseMessage, FormatCloseMessage(CloseNormalClosure, ""), time.Time{}))
+
+		conn.EnableWriteQueue(1)
+		require.NoError(t, conn.WriteMessageAsync(TextMessage, []byte("x")))
+		require.Eventually(t, func() bool {
+			return errors.Is(conn.WriteMessageAsync(TextMessage, []byte("y")), ErrCloseSent)
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("Concurrent producers", func(t *testing.T) {
+		serverSide, clientSide := net.Pipe()
+		server := newConn(serverSide, true, 0, 0)
+		client := newConn(clientSide, false, 0, 0)
+		defer server.Close()
+		defer clientSide.Close()
+
+		server.EnableWriteQueue(8)
+
+		const producers, perProducer = 4, 25
+		var wg sync.WaitGroup
+		for range producers {
+			wg.Go(func() {
+				for range perProducer {
+					for server.WriteMessageAsync(BinaryMessage, []byte{1}) != nil {
+						time.Sleep(time.Millisecond)
+					}
+				}
+			})
+		}
+
+		for range producers * perProducer {
+			_, _, err := client.ReadMessage()
+			require.NoError(t, err)
+		}
+		wg.Wait()
+	})
+}